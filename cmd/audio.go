@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	concatOutput    string
+	concatSeparator audio.Separator
+	concatForce     bool
+)
+
+// NewAudioCmd creates the audio command and its subcommands
+func NewAudioCmd() *cobra.Command {
+	audioCmd := &cobra.Command{
+		Use:   "audio",
+		Short: "Work with generated audio files",
+		Long:  `Work with audio files produced by synthesize, such as joining several files into one.`,
+	}
+
+	audioCmd.AddCommand(newAudioConcatCmd())
+	return audioCmd
+}
+
+func newAudioConcatCmd() *cobra.Command {
+	concatCmd := &cobra.Command{
+		Use:   "concat FILE FILE...",
+		Short: "Join audio files into one",
+		Long: `Join two or more audio files of the same format into a single file.
+
+WAV files must share their encoding, sample rate and channel count, and may be
+separated by silence with --gap or a short tone with --tone, such as between the
+items of a news digest; both are generated locally. MP3 files are joined frame
+by frame, with --gap inserting silent frames; Ogg files are chained as
+consecutive streams. Tones are only supported for WAV, and gaps for WAV and MP3.
+
+Examples:
+  assistant-cli audio concat intro.mp3 chapter1.mp3 -o combined.mp3
+  assistant-cli audio concat part1.wav part2.wav part3.wav --gap 750ms -o book.wav
+  assistant-cli audio concat item1.wav item2.wav item3.wav --gap 400ms --tone 150ms -o digest.wav`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MinimumNArgs(2)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runAudioConcat,
+	}
+
+	concatCmd.Flags().StringVarP(&concatOutput, "output", "o", "", "Output file path (required)")
+	addSeparatorFlags(concatCmd, &concatSeparator, "files")
+	concatCmd.Flags().BoolVarP(&concatForce, "force", "f", false, "Overwrite the output file if it exists")
+
+	return concatCmd
+}
+
+// addSeparatorFlags adds --gap, --tone, and --tone-frequency, setting sep,
+// to a command joining audio; between names what is joined
+func addSeparatorFlags(cmd *cobra.Command, sep *audio.Separator, between string) {
+	cmd.Flags().DurationVar(&sep.Gap, "gap", 0, "Silence between "+between+", e.g. 500ms (WAV and MP3 only)")
+	cmd.Flags().DurationVar(&sep.Tone, "tone", 0, "Tone between "+between+", e.g. 150ms, in the middle of any gap (WAV only)")
+	cmd.Flags().Float64Var(&sep.Frequency, "tone-frequency", audio.DefaultToneFrequency, "Pitch of the --tone in Hz")
+}
+
+// concatResult is the machine-readable result of audio concat
+type concatResult struct {
+	Inputs    []string         `json:"inputs"`
+	Container string           `json:"container"`
+	Gap       string           `json:"gap,omitempty"`
+	Tone      string           `json:"tone,omitempty"`
+	File      *output.FileInfo `json:"file"`
+}
+
+func runAudioConcat(cmd *cobra.Command, args []string) error {
+	if concatOutput == "" {
+		return usageError(fmt.Errorf("an output file is required (use -o/--output)"))
+	}
+	if err := outputPathPolicy(GetConfig().Get().Output.PathPolicy).Check(concatOutput); err != nil {
+		return validationError(err)
+	}
+	if _, err := os.Stat(concatOutput); err == nil && !concatForce {
+		return ioError(fmt.Errorf("output file already exists at %s (use --force to overwrite)", concatOutput))
+	}
+
+	bar := newProgressBar(cmd.ErrOrStderr(), int64(len(args)), "files")
+	files := make([][]byte, len(args))
+	for i, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			bar.Done()
+			return ioError(fmt.Errorf("failed to read audio file: %w", err))
+		}
+		files[i] = data
+		bar.Add(1, int64(len(data)))
+	}
+	bar.Done()
+
+	joined, err := audio.ConcatWith(files, concatSeparator)
+	if err != nil {
+		return validationError(fmt.Errorf("failed to concatenate audio: %w", err))
+	}
+	container, _ := audio.DetectContainer(joined)
+
+	if err := output.WriteFileAtomic(concatOutput, joined, 0644); err != nil {
+		return ioError(fmt.Errorf("failed to write audio file: %w", err))
+	}
+
+	result := &concatResult{Inputs: args, Container: string(container)}
+	if concatSeparator.Gap > 0 {
+		result.Gap = concatSeparator.Gap.String()
+	}
+	if concatSeparator.Tone > 0 {
+		result.Tone = concatSeparator.Tone.String()
+	}
+	if result.File, err = output.StatFile(concatOutput); err != nil {
+		return ioError(err)
+	}
+
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		fmt.Fprintf(w, "%s Joined %d files into %s (%d bytes)\n", styleFor(w).Success(), len(args), result.File.Path,
+			result.File.Size)
+	})
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runAudioConcatCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		concatOutput = ""
+		concatSeparator = audio.Separator{}
+		concatForce = false
+		outputFormat = outputFormatText
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"audio", "concat"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func writeTestWAV(t *testing.T, dir, name string, samples ...int16) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	data := audio.EncodeWAV(&audio.PCM{SampleRate: 1000, Channels: 1, Samples: samples})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestAudioConcatCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	first := writeTestWAV(t, dir, "a.wav", 1, 2)
+	second := writeTestWAV(t, dir, "b.wav", 3)
+	out := filepath.Join(dir, "joined.wav")
+
+	stdout, err := runAudioConcatCommand(t, "--output-format", "json", first, second, "-o", out, "--gap", "2ms")
+	require.NoError(t, err)
+
+	var result struct {
+		Data concatResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "wav", result.Data.Container)
+	assert.Equal(t, "2ms", result.Data.Gap)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	pcm, err := audio.DecodeWAV(data)
+	require.NoError(t, err)
+	assert.Equal(t, []int16{1, 2, 0, 0, 3}, pcm.Samples)
+
+	// An existing output file needs --force
+	_, err = runAudioConcatCommand(t, first, second, "-o", out)
+	assert.Equal(t, ExitIO, ExitCode(err))
+	_, err = runAudioConcatCommand(t, first, second, "-o", out, "--force")
+	assert.NoError(t, err)
+}
+
+func TestAudioConcatCommandTone(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	first := writeTestWAV(t, dir, "a.wav", 1)
+	second := writeTestWAV(t, dir, "b.wav", 2)
+	out := filepath.Join(dir, "digest.wav")
+
+	stdout, err := runAudioConcatCommand(t, "--output-format", "json", first, second, "-o", out,
+		"--gap", "4ms", "--tone", "20ms", "--tone-frequency", "100")
+	require.NoError(t, err)
+	var result struct {
+		Data concatResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "20ms", result.Data.Tone)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	pcm, err := audio.DecodeWAV(data)
+	require.NoError(t, err)
+	assert.Equal(t, []int16{1, 0, 0}, pcm.Samples[:3])
+	assert.Equal(t, audio.Tone(1000, 1, 100, 20*time.Millisecond).Samples, pcm.Samples[3:23])
+	assert.Equal(t, []int16{0, 0, 2}, pcm.Samples[23:])
+
+	// The tone must be below half the sample rate
+	_, err = runAudioConcatCommand(t, first, second, "-o", out, "--force", "--tone", "20ms")
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+func TestAudioConcatCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	wav := writeTestWAV(t, dir, "a.wav", 1)
+	text := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(text, []byte("not audio"), 0600))
+	out := filepath.Join(dir, "out.wav")
+
+	_, err := runAudioConcatCommand(t, wav, "-o", out)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	_, err = runAudioConcatCommand(t, wav, wav)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	_, err = runAudioConcatCommand(t, wav, text, "-o", out)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+
+	_, err = runAudioConcatCommand(t, wav, filepath.Join(dir, "missing.wav"), "-o", out)
+	assert.Equal(t, ExitIO, ExitCode(err))
+	assert.NoFileExists(t, out)
+}
+
+func TestAudioConcatCommandPathPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	first := writeTestWAV(t, dir, "a.wav", 1)
+	second := writeTestWAV(t, dir, "b.wav", 2)
+	out := filepath.Join(dir, "joined.js")
+
+	_, err := runAudioConcatCommand(t, first, second, "-o", out)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.NoFileExists(t, out)
+
+	config := writeTestConfig(t, "output:\n  path_policy:\n    allowed_extensions: [\".js\", \".wav\"]\n")
+	_, err = runAudioConcatCommand(t, "--config", config, first, second, "-o", out)
+	require.NoError(t, err)
+	assert.FileExists(t, out)
+}
@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/document"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	audiobookOutputDir string
+	audiobookVoice     string
+	audiobookFormat    string
+	audiobookForce     bool
+	audiobookPlayAll   bool
+	audiobookMerge     bool
+	audiobookSeparator audio.Separator
+)
+
+// NewAudiobookCmd creates the audiobook command
+func NewAudiobookCmd() *cobra.Command {
+	audiobookCmd := &cobra.Command{
+		Use:   "audiobook FILE",
+		Short: "Convert an EPUB, PDF, or Markdown file into per-chapter audio files",
+		Long: `Convert an EPUB, PDF, or Markdown file into an audiobook: one audio file per
+EPUB chapter, PDF page, or Markdown chapter heading, plus an M3U playlist
+listing them in reading order. With --merge, the chapters are also joined
+into one file with a CUE sheet marking where each chapter starts, separated
+by --gap of silence, or also a --tone for WAV output.
+
+Long chapters are synthesized in pieces and joined, so chapters of any length
+are supported; --concurrency synthesizes several pieces at the same time. With --play-all, each chapter is played as soon as it is ready
+while later ones are synthesized; in a terminal, space pauses, n skips to the
+next chapter and q stops playback. Files are written to --output-dir, by default a directory named
+after the book under output.default_path. Voice settings come from the
+configuration or the tts.presets entry given with --preset unless overridden
+with --voice; with --auto-language, paragraphs in another language are read by
+a voice for that language.
+
+Examples:
+  assistant-cli audiobook novel.epub
+  assistant-cli audiobook paper.pdf --voice en-US-Wavenet-F -o ./paper-audio
+  assistant-cli audiobook novel.epub --format OGG_OPUS --force
+  assistant-cli audiobook novel.epub --play-all
+  assistant-cli audiobook guide.md --merge
+  assistant-cli audiobook bilingual.epub --auto-language`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runAudiobook,
+	}
+
+	audiobookCmd.Flags().StringVarP(&audiobookOutputDir, "output-dir", "o", "",
+		"Directory for the chapter files and playlist (default: <output.default_path>/<book title>)")
+	audiobookCmd.Flags().StringVar(&audiobookVoice, "voice", "", "Voice name (default: tts.voice)")
+	audiobookCmd.Flags().StringVarP(&audiobookFormat, "format", "f", "MP3", "Audio format (MP3, OGG_OPUS, LINEAR16)")
+	audiobookCmd.Flags().BoolVar(&audiobookForce, "force", false, "Overwrite existing chapter files")
+	audiobookCmd.Flags().BoolVar(&audiobookPlayAll, "play-all", false,
+		"Play each chapter as soon as it is synthesized")
+	audiobookCmd.Flags().BoolVar(&audiobookMerge, "merge", false,
+		"Also join the chapters into one file with a CUE sheet of chapter offsets")
+	addSeparatorFlags(audiobookCmd, &audiobookSeparator, "merged chapters")
+	addConcurrencyFlag(audiobookCmd)
+	addAutoLanguageFlag(audiobookCmd)
+	addPresetFlag(audiobookCmd)
+	addNotifyFlag(audiobookCmd)
+
+	return audiobookCmd
+}
+
+// audiobookChapter describes one generated chapter file
+type audiobookChapter struct {
+	Title      string  `json:"title"`
+	File       string  `json:"file"`
+	Characters int     `json:"characters"`
+	Duration   float64 `json:"duration_seconds,omitempty"`
+	// Start is the offset of the chapter in the merged file
+	Start *float64 `json:"start_seconds,omitempty"`
+	// Kept is set when the existing file was kept instead of synthesized
+	Kept bool `json:"kept,omitempty"`
+	// ChunkRetries counts the retries of each piece of the chapter, when any
+	ChunkRetries []int `json:"chunk_retries,omitempty"`
+}
+
+// audiobookResult is the machine-readable result of audiobook
+type audiobookResult struct {
+	Source    string             `json:"source"`
+	Title     string             `json:"title"`
+	Provider  string             `json:"provider"`
+	Directory string             `json:"directory"`
+	Playlist  string             `json:"playlist"`
+	Merged    string             `json:"merged,omitempty"`
+	CueSheet  string             `json:"cue_sheet,omitempty"`
+	Chapters  []audiobookChapter `json:"chapters"`
+	Played    bool               `json:"played,omitempty"`
+}
+
+func runAudiobook(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+	cfg := GetConfig().Get()
+	renderer := newRenderer(cmd)
+
+	notice, err := newCompletionNotice(cfg, "audiobook")
+	if err != nil {
+		return err
+	}
+	notice.enableDesktop(cfg)
+	defer func() { notice.send(renderer, err) }()
+
+	if cfg, err = applyPreset(cmd, cfg, &audiobookFormat); err != nil {
+		return err
+	}
+	if err := checkLongTextFormat(audiobookFormat); err != nil {
+		return err
+	}
+	concurrency, err := longTextConcurrency(cfg)
+	if err != nil {
+		return err
+	}
+	if err := checkAutoLanguage(); err != nil {
+		return err
+	}
+	if !audiobookMerge && audiobookSeparator.Duration() > 0 {
+		return usageError(fmt.Errorf("--gap and --tone need --merge"))
+	}
+
+	doc, err := document.Open(args[0])
+	if err != nil {
+		if errors.Is(err, document.ErrUnsupportedDocument) {
+			return validationError(err)
+		}
+		return ioError(err)
+	}
+
+	provider, req, err := createLongTextProvider(ctx, renderer, cfg, audiobookVoice, audiobookFormat)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = provider.Close() }()
+	filters, err := inputFilters(cfg.Input, req.LanguageCode)
+	if err != nil {
+		return err
+	}
+	for i := range doc.Segments {
+		doc.Segments[i].Text = utils.ApplyFilters(doc.Segments[i].Text, filters...)
+		if err := checkLongTextSSML(doc.Segments[i].Text, cfg.Input); err != nil {
+			return fmt.Errorf("chapter %d (%s): %w", i+1, doc.Segments[i].Title, err)
+		}
+	}
+
+	ext := longTextExtension(req)
+	title := output.GetSafeFilename(doc.Title, "")
+	dir := audiobookOutputDir
+	if dir == "" {
+		dir = filepath.Join(cfg.Output.DefaultPath, title)
+	}
+	notice.payload.File = dir
+	overwrite, err := newOverwriteHandler(cfg.Output, audiobookForce)
+	if err != nil {
+		return err
+	}
+	chapters := make([]audiobookChapter, len(doc.Segments))
+	for i, segment := range doc.Segments {
+		chapters[i] = audiobookChapter{
+			Title:      segment.Title,
+			File:       fmt.Sprintf("%03d_%s", i+1, output.GetSafeFilename(segment.Title, ext)),
+			Characters: len([]rune(segment.Text)),
+		}
+		// A chapter the user chose to keep is not synthesized again
+		if _, err := overwrite.PrepareOverwrite(filepath.Join(dir, chapters[i].File)); err != nil {
+			switch {
+			case errors.Is(err, output.ErrOverwriteDeclined):
+				chapters[i].Kept = true
+			case errors.Is(err, output.ErrPathNotAllowed):
+				return validationError(err)
+			default:
+				return ioError(fmt.Errorf("%w (use --force to overwrite)", err))
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ioError(fmt.Errorf("failed to create output directory: %w", err))
+	}
+
+	queue := startBatchPlayback(renderer, audiobookPlayAll)
+	if queue != nil {
+		defer queue.Stop()
+	}
+
+	var chunks int64
+	for i, segment := range doc.Segments {
+		if !chapters[i].Kept {
+			pieces, err := longTextPieces(segment.Text, req, autoLanguageFlag)
+			if err != nil {
+				return fmt.Errorf("chapter %d (%s): %w", i+1, segment.Title, err)
+			}
+			chunks += int64(len(pieces))
+		}
+	}
+	bar := newProgressBar(cmd.ErrOrStderr(), chunks, "chunks")
+	defer bar.Done()
+
+	synthesizer := newSynthesizer(provider, audio.Options{}, false)
+	for i, segment := range doc.Segments {
+		bar.Step("[%d/%d] %s", i+1, len(doc.Segments), segment.Title)
+		if chapters[i].Kept {
+			keepChapter(&chapters[i], dir, queue)
+			continue
+		}
+
+		data, retries, err := synthesizeLongText(ctx, synthesizer, segment.Title, segment.Text, req,
+			autoLanguageFlag, cfg.Output.WriteMetadata, concurrency, bar)
+		if err != nil {
+			return fmt.Errorf("chapter %d (%s): %w", i+1, segment.Title, err)
+		}
+		chapters[i].ChunkRetries = reportedRetries(retries)
+
+		if err := output.WriteFileAtomic(filepath.Join(dir, chapters[i].File), data, 0644); err != nil {
+			return ioError(fmt.Errorf("failed to write audio file: %w", err))
+		}
+		if _, err := overwrite.PruneBackups(filepath.Join(dir, chapters[i].File)); err != nil {
+			renderer.Warnf("Warning: %v\n", err)
+		}
+		if duration, err := audio.Duration(data); err == nil {
+			chapters[i].Duration = duration.Seconds()
+		}
+		notice.addFile(filepath.Join(dir, chapters[i].File), chapters[i].Characters, chapters[i].Duration)
+		if queue != nil {
+			queue.Add(filepath.Join(dir, chapters[i].File))
+		}
+	}
+
+	playlist := filepath.Join(dir, title+".m3u")
+	if err := output.WriteFileAtomic(playlist, []byte(buildPlaylist(doc.Title, chapters)), 0644); err != nil {
+		return ioError(fmt.Errorf("failed to write playlist: %w", err))
+	}
+	var merged, cueSheet string
+	if audiobookMerge {
+		merged, cueSheet = filepath.Join(dir, title+"."+ext), filepath.Join(dir, title+".cue")
+		if err := mergeChapters(dir, merged, cueSheet, doc.Title, chapters, audiobookSeparator); err != nil {
+			return err
+		}
+	}
+
+	result := &audiobookResult{
+		Source:    args[0],
+		Title:     doc.Title,
+		Provider:  provider.Name(),
+		Directory: dir,
+		Playlist:  playlist,
+		Merged:    merged,
+		CueSheet:  cueSheet,
+		Chapters:  chapters,
+		Played:    finishBatchPlayback(renderer, queue),
+	}
+	return renderer.Result(result, func(w io.Writer) {
+		kept := 0
+		for _, chapter := range chapters {
+			if chapter.Kept {
+				kept++
+			}
+		}
+		fmt.Fprintf(w, "%s Wrote %d chapters of %q to %s\n", styleFor(w).Success(), len(chapters)-kept, doc.Title, dir)
+		if kept > 0 {
+			fmt.Fprintf(w, "  Kept %d existing chapter files\n", kept)
+		}
+		fmt.Fprintf(w, "  Playlist: %s\n", playlist)
+		if merged != "" {
+			fmt.Fprintf(w, "  Merged: %s\n  CUE sheet: %s\n", merged, cueSheet)
+		}
+	})
+}
+
+// keepChapter fills in the duration of a chapter kept from an earlier run
+// and queues it for playback
+func keepChapter(chapter *audiobookChapter, dir string, queue *playQueue) {
+	path := filepath.Join(dir, chapter.File)
+	if data, err := os.ReadFile(path); err == nil {
+		if duration, err := audio.Duration(data); err == nil {
+			chapter.Duration = duration.Seconds()
+		}
+	}
+	if queue != nil {
+		queue.Add(path)
+	}
+}
+
+// buildPlaylist returns an extended M3U playlist of the chapter files, which
+// are referenced relative to the playlist
+func buildPlaylist(title string, chapters []audiobookChapter) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#PLAYLIST:%s\n", title)
+	for _, chapter := range chapters {
+		seconds := -1
+		if chapter.Duration > 0 {
+			seconds = int(math.Round(chapter.Duration))
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n%s\n", seconds, chapter.Title, chapter.File)
+	}
+	return b.String()
+}
+
+// mergeChapters joins the chapter files in dir into the file merged, with
+// sep between them, and writes a CUE sheet of where each chapter starts to
+// cueSheet, setting the Start of each chapter
+func mergeChapters(dir, merged, cueSheet, title string, chapters []audiobookChapter, sep audio.Separator) error {
+	files := make([][]byte, len(chapters))
+	var offset time.Duration
+	for i := range chapters {
+		data, err := os.ReadFile(filepath.Join(dir, chapters[i].File))
+		if err != nil {
+			return ioError(fmt.Errorf("failed to read chapter file: %w", err))
+		}
+		duration, err := audio.Duration(data)
+		if err != nil {
+			return validationError(fmt.Errorf("cannot merge chapter %d (%s): %w", i+1, chapters[i].Title, err))
+		}
+		start := offset.Seconds()
+		chapters[i].Start = &start
+		files[i] = data
+		offset += duration + sep.Duration()
+	}
+
+	data, err := audio.ConcatWith(files, sep)
+	if err != nil {
+		return validationError(fmt.Errorf("cannot merge chapters: %w", err))
+	}
+	if err := output.WriteFileAtomic(merged, data, 0644); err != nil {
+		return ioError(fmt.Errorf("failed to write merged file: %w", err))
+	}
+	if err := output.WriteFileAtomic(cueSheet, []byte(buildCueSheet(title, filepath.Base(merged), chapters)), 0644); err != nil {
+		return ioError(fmt.Errorf("failed to write CUE sheet: %w", err))
+	}
+	return nil
+}
+
+// buildCueSheet returns a CUE sheet with a track for each chapter of the
+// merged file, which is referenced relative to the sheet
+func buildCueSheet(title, file string, chapters []audiobookChapter) string {
+	fileType := strings.ToUpper(strings.TrimPrefix(filepath.Ext(file), "."))
+	if fileType == "WAV" {
+		fileType = "WAVE"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "TITLE %s\n", cueQuote(title))
+	fmt.Fprintf(&b, "FILE %s %s\n", cueQuote(file), fileType)
+	for i, chapter := range chapters {
+		var start float64
+		if chapter.Start != nil {
+			start = *chapter.Start
+		}
+		// Offsets are in minutes, seconds, and frames of 1/75 second
+		frames := int(math.Round(start * 75))
+		fmt.Fprintf(&b, "  TRACK %02d AUDIO\n", i+1)
+		fmt.Fprintf(&b, "    TITLE %s\n", cueQuote(chapter.Title))
+		fmt.Fprintf(&b, "    INDEX 01 %02d:%02d:%02d\n", frames/(75*60), frames/75%60, frames%75)
+	}
+	return b.String()
+}
+
+// cueQuote quotes a CUE sheet string, which cannot contain double quotes
+func cueQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
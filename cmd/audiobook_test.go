@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/notify"
+	"github.com/mikefarmer/assistant-cli/internal/player"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runAudiobookCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		audiobookOutputDir = ""
+		audiobookVoice = ""
+		audiobookFormat = "MP3"
+		audiobookForce = false
+		audiobookPlayAll = false
+		audiobookMerge = false
+		audiobookSeparator = audio.Separator{}
+		notifyURL = ""
+		concurrencyFlag = 0
+		autoLanguageFlag = ""
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"audiobook"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+// writeTestEPUB writes a two chapter EPUB
+func writeTestEPUB(t *testing.T) string {
+	t.Helper()
+
+	files := map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container><rootfiles><rootfile full-path="content.opf"/></rootfiles></container>`,
+		"content.opf": `<?xml version="1.0"?>
+<package xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <metadata><dc:title>Short Stories</dc:title></metadata>
+  <manifest>
+    <item id="a" href="a.xhtml" media-type="application/xhtml+xml"/>
+    <item id="b" href="b.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="a"/><itemref idref="b"/></spine>
+</package>`,
+		"a.xhtml": `<html><body><h1>The Start</h1><p>Once upon a time.</p></body></html>`,
+		"b.xhtml": `<html><body><h1>The End</h1><p>They lived happily.</p></body></html>`,
+	}
+
+	path := filepath.Join(t.TempDir(), "stories.epub")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return path
+}
+
+func TestAudiobookCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	dir := filepath.Join(t.TempDir(), "book")
+
+	stdout, err := runAudiobookCommand(t, writeTestEPUB(t), "--config", config, "--output-format", "json",
+		"--format", "LINEAR16", "-o", dir)
+	require.NoError(t, err)
+
+	var result struct {
+		Data audiobookResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "Short Stories", result.Data.Title)
+	assert.Equal(t, "espeak", result.Data.Provider)
+	require.Len(t, result.Data.Chapters, 2)
+	assert.Equal(t, "001_The_Start.wav", result.Data.Chapters[0].File)
+	assert.Equal(t, "002_The_End.wav", result.Data.Chapters[1].File)
+
+	for _, chapter := range result.Data.Chapters {
+		data, err := os.ReadFile(filepath.Join(dir, chapter.File))
+		require.NoError(t, err)
+		_, err = audio.DecodeWAV(data)
+		assert.NoError(t, err)
+	}
+
+	playlist, err := os.ReadFile(result.Data.Playlist)
+	require.NoError(t, err)
+	assert.Contains(t, string(playlist), "#EXTM3U\n")
+	assert.Contains(t, string(playlist), "001_The_Start.wav\n")
+
+	// Existing chapters are backed up by default
+	_, err = runAudiobookCommand(t, writeTestEPUB(t), "--config", config, "--format", "LINEAR16", "-o", dir,
+		"--concurrency", "2")
+	require.NoError(t, err)
+	backups, err := filepath.Glob(filepath.Join(dir, "001_The_Start.wav.backup_*"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	// Without a terminal, prompt falls back to keeping them
+	for _, mode := range []string{"never", "prompt"} {
+		keep := writeTestConfig(t, "tts:\n  provider: \"espeak\"\noutput:\n  overwrite_mode: \""+mode+"\"\n")
+		_, err = runAudiobookCommand(t, writeTestEPUB(t), "--config", keep, "--format", "LINEAR16", "-o", dir)
+		require.Error(t, err, mode)
+		assert.Equal(t, ExitIO, ExitCode(err), mode)
+
+		_, err = runAudiobookCommand(t, writeTestEPUB(t), "--config", keep, "--format", "LINEAR16", "-o", dir,
+			"--force")
+		assert.NoError(t, err, mode)
+	}
+}
+
+// fakePlayerOnPath installs a stand-in aplay that logs the files it plays
+// and returns a function reading the log
+func fakePlayerOnPath(t *testing.T) func() []string {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("player detection differs on this platform")
+	}
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "played.log")
+	script := "#!/bin/sh\nbasename \"$1\" >> '" + log + "'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "aplay"), []byte(script), 0700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	// Test machines are often headless
+	t.Setenv(player.ForcePlaybackEnv, "1")
+
+	return func() []string {
+		data, err := os.ReadFile(log)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		return strings.Fields(string(data))
+	}
+}
+
+func TestAudiobookCommandPlayAll(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runAudiobookCommand(t, writeTestEPUB(t), "--config", config, "--output-format", "json",
+		"--format", "LINEAR16", "-o", t.TempDir(), "--play-all")
+	require.NoError(t, err)
+
+	var result struct {
+		Data audiobookResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Data.Played)
+	assert.Equal(t, []string{"001_The_Start.wav", "002_The_End.wav"}, played())
+}
+
+func TestAudiobookCommandNoPlay(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runAudiobookCommand(t, writeTestEPUB(t), "--config", config, "--output-format", "json",
+		"--format", "LINEAR16", "-o", t.TempDir(), "--play-all", "--no-play")
+	require.NoError(t, err)
+
+	var result struct {
+		Data audiobookResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.False(t, result.Data.Played)
+	assert.Len(t, result.Data.Chapters, 2)
+	assert.Empty(t, played())
+}
+
+func TestAudiobookCommandNotify(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	dir := filepath.Join(t.TempDir(), "book")
+
+	payloads := make(chan notify.Payload, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.Payload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads <- payload
+	}))
+	defer server.Close()
+
+	_, err := runAudiobookCommand(t, writeTestEPUB(t), "--config", config, "--format", "LINEAR16", "-o", dir,
+		"--notify-url", server.URL)
+	require.NoError(t, err)
+	payload := <-payloads
+	assert.Equal(t, "audiobook", payload.Command)
+	assert.Equal(t, notify.StatusSuccess, payload.Status)
+	assert.Equal(t, dir, payload.File)
+	assert.Len(t, payload.Files, 2)
+	assert.Positive(t, payload.Characters)
+
+	_, err = runAudiobookCommand(t, filepath.Join(t.TempDir(), "missing.epub"), "--config", config,
+		"--notify-url", server.URL)
+	require.Error(t, err)
+	payload = <-payloads
+	assert.Equal(t, notify.StatusFailure, payload.Status)
+	assert.Contains(t, payload.Error, "missing.epub")
+}
+
+func TestAudiobookCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	text := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(text, []byte("hello"), 0600))
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "no file", args: nil, code: ExitUsage},
+		{name: "raw PCM", args: []string{writeTestEPUB(t), "--format", "PCM"}, code: ExitValidation},
+		{name: "unsupported document", args: []string{text}, code: ExitValidation},
+		{name: "missing file", args: []string{filepath.Join(t.TempDir(), "missing.epub")}, code: ExitIO},
+		{name: "invalid notify URL", args: []string{writeTestEPUB(t), "--notify-url", "ftp://hooks"}, code: ExitUsage},
+		{name: "tone without merge", args: []string{writeTestEPUB(t), "--tone", "100ms"}, code: ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runAudiobookCommand(t, tt.args...)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, ExitCode(err))
+		})
+	}
+}
+
+func TestAudiobookCommandInvalidSSML(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n"+
+		"input:\n  paragraph_pause: 500ms\n  max_ssml_depth: 1\n")
+	book := filepath.Join(t.TempDir(), "book.md")
+	chapter := strings.Repeat("A paragraph of the only chapter.\n\n", 40)
+	require.NoError(t, os.WriteFile(book, []byte("# Book\n\n"+chapter), 0600))
+	dir := filepath.Join(t.TempDir(), "book")
+
+	// The pauses make the chapter SSML, which is checked against the
+	// limits before anything is synthesized
+	_, err := runAudiobookCommand(t, book, "--config", config, "--format", "LINEAR16", "-o", dir)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "maximum depth of 1")
+	assert.NoDirExists(t, dir)
+
+	// input.max_ssml_size, meant for single requests, does not limit the
+	// size of a chapter
+	config = writeTestConfig(t, "tts:\n  provider: \"espeak\"\n"+
+		"input:\n  paragraph_pause: 500ms\n  max_ssml_size: 1024\n")
+	_, err = runAudiobookCommand(t, book, "--config", config, "--format", "LINEAR16", "-o", dir)
+	require.NoError(t, err)
+}
+
+func TestBuildPlaylist(t *testing.T) {
+	playlist := buildPlaylist("Book", []audiobookChapter{
+		{Title: "One", File: "001_One.mp3", Duration: 61.6},
+		{Title: "Two", File: "002_Two.mp3"},
+	})
+
+	assert.Equal(t, "#EXTM3U\n#PLAYLIST:Book\n"+
+		"#EXTINF:62,One\n001_One.mp3\n"+
+		"#EXTINF:-1,Two\n002_Two.mp3\n", playlist)
+}
+
+func TestAudiobookCommandMerge(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	dir := filepath.Join(t.TempDir(), "guide")
+	source := filepath.Join(t.TempDir(), "guide.md")
+	require.NoError(t, os.WriteFile(source, []byte("# The Guide\n\n## Setup\n\nInstall it.\n\n## Usage\n\nRun it.\n"), 0600))
+
+	stdout, err := runAudiobookCommand(t, source, "--config", config, "--output-format", "json",
+		"--format", "LINEAR16", "-o", dir, "--merge", "--gap", "300ms", "--tone", "100ms")
+	require.NoError(t, err)
+
+	var result struct {
+		Data audiobookResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "The Guide", result.Data.Title)
+	require.Len(t, result.Data.Chapters, 2)
+	assert.Equal(t, "001_Setup.wav", result.Data.Chapters[0].File)
+	assert.Equal(t, filepath.Join(dir, "The_Guide.wav"), result.Data.Merged)
+
+	merged, err := os.ReadFile(result.Data.Merged)
+	require.NoError(t, err)
+	pcm, err := audio.DecodeWAV(merged)
+	require.NoError(t, err)
+	first, err := os.ReadFile(filepath.Join(dir, "001_Setup.wav"))
+	require.NoError(t, err)
+	firstPCM, err := audio.DecodeWAV(first)
+	require.NoError(t, err)
+	assert.Greater(t, len(pcm.Samples), len(firstPCM.Samples))
+
+	require.NotNil(t, result.Data.Chapters[1].Start)
+	assert.InDelta(t, result.Data.Chapters[0].Duration+0.4, *result.Data.Chapters[1].Start, 0.001)
+	cue, err := os.ReadFile(result.Data.CueSheet)
+	require.NoError(t, err)
+	assert.Contains(t, string(cue), "FILE \"The_Guide.wav\" WAVE\n  TRACK 01 AUDIO\n    TITLE \"Setup\"\n    INDEX 01 00:00:00\n")
+	assert.Contains(t, string(cue), "  TRACK 02 AUDIO\n    TITLE \"Usage\"\n")
+}
+
+func TestBuildCueSheet(t *testing.T) {
+	start := func(seconds float64) *float64 { return &seconds }
+	cue := buildCueSheet(`The "Book"`, "The_Book.mp3", []audiobookChapter{
+		{Title: "One", Start: start(0)},
+		{Title: "Two", Start: start(61.5)},
+		{Title: "Three", Start: start(3725.04)},
+	})
+
+	assert.Equal(t, "TITLE \"The 'Book'\"\nFILE \"The_Book.mp3\" MP3\n"+
+		"  TRACK 01 AUDIO\n    TITLE \"One\"\n    INDEX 01 00:00:00\n"+
+		"  TRACK 02 AUDIO\n    TITLE \"Two\"\n    INDEX 01 01:01:38\n"+
+		"  TRACK 03 AUDIO\n    TITLE \"Three\"\n    INDEX 01 62:05:03\n", cue)
+}
@@ -0,0 +1,543 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/document"
+	"github.com/mikefarmer/assistant-cli/internal/fileset"
+	"github.com/mikefarmer/assistant-cli/internal/longtext"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchOutputDir string
+	batchVoice     string
+	batchFormat    string
+	batchForce     bool
+	batchInclude   []string
+	batchExclude   []string
+	batchMerge     string
+	batchSeparator audio.Separator
+	batchPlayAll   bool
+)
+
+// NewBatchCmd creates the batch command
+func NewBatchCmd() *cobra.Command {
+	batchCmd := &cobra.Command{
+		Use:   "batch FILE|DIR|GLOB...",
+		Short: "Synthesize many text files into a matching tree of audio files",
+		Long: `Synthesize text files into audio files, one for each input. Arguments are
+files, directories, which are searched recursively, or glob patterns. Files
+found in directories are filtered with --include and --exclude: a pattern
+without a slash, such as '*.md', matches the file name, and one with a slash,
+such as 'drafts/**', the path below the directory, '**' matching any number
+of directories. Files named directly or matched by a glob argument are
+always taken. Hidden directories such as .git, and the output directory
+when it is inside an input directory, are skipped.
+
+Outputs are written to --output-dir, by default output.default_path, keeping
+the directories of the inputs, so notes/week1/monday.md becomes
+<output-dir>/week1/monday.mp3 for the argument notes. As with make, a file
+whose output is newer than it is skipped, so a second run only synthesizes
+the files that changed; --force synthesizes every file again.
+
+Markdown, EPUB, and PDF files are read as audiobook reads them; other files
+are read as plain text or SSML. Long files are synthesized in pieces and
+joined; --concurrency synthesizes several pieces at the same time. With
+--merge, the outputs, including those that were up to date, are also joined
+in order into one file, separated by --gap of silence, or also a --tone for
+WAV output. --play-all plays each output in order as soon as it is written.
+
+Examples:
+  assistant-cli batch notes/ --include '*.md' --exclude 'drafts/**' -o ./audio
+  assistant-cli batch 'chapters/*.txt' --format OGG_OPUS
+  assistant-cli batch notes/ -o ./audio --force
+  assistant-cli batch 'digest/*.txt' --format LINEAR16 --merge digest.wav --gap 1s`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runBatch,
+	}
+
+	batchCmd.Flags().StringVarP(&batchOutputDir, "output-dir", "o", "",
+		"Directory for the audio files (default: output.default_path)")
+	batchCmd.Flags().StringSliceVar(&batchInclude, "include", nil,
+		"Only take files in directories matching these patterns, e.g. '*.md'")
+	batchCmd.Flags().StringSliceVar(&batchExclude, "exclude", nil,
+		"Skip files in directories matching these patterns, e.g. 'drafts/**'")
+	batchCmd.Flags().StringVar(&batchVoice, "voice", "", "Voice name (default: tts.voice)")
+	batchCmd.Flags().StringVarP(&batchFormat, "format", "f", "MP3", "Audio format (MP3, OGG_OPUS, LINEAR16)")
+	batchCmd.Flags().BoolVar(&batchForce, "force", false, "Synthesize files whose output is up to date")
+	batchCmd.Flags().StringVar(&batchMerge, "merge", "", "Also join the outputs in order into this file")
+	addSeparatorFlags(batchCmd, &batchSeparator, "merged files")
+	batchCmd.Flags().BoolVar(&batchPlayAll, "play-all", false,
+		"Play each output as soon as it is written, including those that were up to date")
+	addConcurrencyFlag(batchCmd)
+	addAutoLanguageFlag(batchCmd)
+	addPresetFlag(batchCmd)
+	addInputEncodingFlag(batchCmd)
+	addNotifyFlag(batchCmd)
+
+	return batchCmd
+}
+
+// batchFile describes the output of one input file
+type batchFile struct {
+	Source     string  `json:"source"`
+	File       string  `json:"file"`
+	Characters int     `json:"characters,omitempty"`
+	Duration   float64 `json:"duration_seconds,omitempty"`
+	// UpToDate is set when the output was newer than the source and was
+	// not synthesized again
+	UpToDate bool `json:"up_to_date,omitempty"`
+	// Kept is set when the existing output was kept instead of synthesized
+	Kept bool `json:"kept,omitempty"`
+	// ChunkRetries counts the retries of each piece of the file, when any
+	ChunkRetries []int `json:"chunk_retries,omitempty"`
+}
+
+// batchResult is the machine-readable result of batch
+type batchResult struct {
+	Provider  string      `json:"provider"`
+	Directory string      `json:"directory"`
+	Files     []batchFile `json:"files"`
+	Merged    string      `json:"merged,omitempty"`
+	Played    bool        `json:"played,omitempty"`
+}
+
+func runBatch(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+	cfg := GetConfig().Get()
+	renderer := newRenderer(cmd)
+
+	notice, err := newCompletionNotice(cfg, "batch")
+	if err != nil {
+		return err
+	}
+	notice.enableDesktop(cfg)
+	defer func() { notice.send(renderer, err) }()
+
+	if cfg, err = applyPreset(cmd, cfg, &batchFormat); err != nil {
+		return err
+	}
+	if err := checkLongTextFormat(batchFormat); err != nil {
+		return err
+	}
+	concurrency, err := longTextConcurrency(cfg)
+	if err != nil {
+		return err
+	}
+	if err := checkAutoLanguage(); err != nil {
+		return err
+	}
+	if batchMerge == "" && batchSeparator.Duration() > 0 {
+		return usageError(fmt.Errorf("--gap and --tone need --merge"))
+	}
+
+	dir := batchOutputDir
+	if dir == "" {
+		dir = cfg.Output.DefaultPath
+	}
+	sources, err := fileset.Collect(args, fileset.Filter{Include: batchInclude, Exclude: batchExclude})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ioError(err)
+		}
+		return usageError(err)
+	}
+	sources, err = withoutOutputs(sources, dir, batchMerge)
+	if err != nil {
+		return ioError(err)
+	}
+	if len(sources) == 0 {
+		return usageError(fmt.Errorf("no input files found in %s", strings.Join(args, ", ")))
+	}
+	notice.payload.File = dir
+
+	provider, req, err := createLongTextProvider(ctx, renderer, cfg, batchVoice, batchFormat)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = provider.Close() }()
+	filters, err := inputFilters(cfg.Input, req.LanguageCode)
+	if err != nil {
+		return err
+	}
+
+	ext := longTextExtension(req)
+	overwrite, err := newOverwriteHandler(cfg.Output, batchForce)
+	if err != nil {
+		return err
+	}
+
+	files := make([]batchFile, len(sources))
+	pending := 0
+	for i, source := range sources {
+		files[i] = batchFile{Source: source.Path, File: fileset.OutputPath(dir, source, ext)}
+		if !batchForce {
+			upToDate, err := fileset.UpToDate(source.Path, files[i].File)
+			if err != nil {
+				return ioError(err)
+			}
+			if upToDate {
+				files[i].UpToDate = true
+				continue
+			}
+		}
+		// An output the user chose to keep is not synthesized again
+		if _, err := overwrite.PrepareOverwrite(files[i].File); err != nil {
+			switch {
+			case errors.Is(err, output.ErrOverwriteDeclined):
+				files[i].Kept = true
+				continue
+			case errors.Is(err, output.ErrPathNotAllowed):
+				return validationError(err)
+			default:
+				return ioError(fmt.Errorf("%w (use --force to overwrite)", err))
+			}
+		}
+		pending++
+	}
+	if batchMerge != "" {
+		if _, err := overwrite.PrepareOverwrite(batchMerge); err != nil {
+			if errors.Is(err, output.ErrOverwriteDeclined) {
+				return ioError(fmt.Errorf("%w (use --force to overwrite)", err))
+			}
+			return prepareError(err)
+		}
+	}
+
+	bar := newProgressBar(cmd.ErrOrStderr(), int64(pending), "files")
+	defer bar.Done()
+
+	queue := startBatchPlayback(renderer, batchPlayAll)
+	if queue != nil {
+		defer queue.Stop()
+	}
+
+	synthesizer := newSynthesizer(provider, audio.Options{}, false)
+	step := 0
+	for i, source := range sources {
+		if files[i].UpToDate || files[i].Kept {
+			if queue != nil {
+				queue.Add(files[i].File)
+			}
+			continue
+		}
+		step++
+		bar.Step("[%d/%d] %s", step, pending, source.Rel)
+
+		pieces, characters, err := readBatchPieces(source.Path, cfg.Input, filters, req)
+		if err != nil {
+			return fmt.Errorf("%s: %w", source.Path, err)
+		}
+		files[i].Characters = characters
+
+		var tags *output.Tags
+		if cfg.Output.WriteMetadata {
+			tags = batchTags(strings.TrimSuffix(filepath.Base(source.Rel), filepath.Ext(source.Rel)), pieces, req)
+		}
+		data, retries, err := synthesizePieces(ctx, synthesizer, pieces, req.AudioFormat, tags, concurrency, nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", source.Path, err)
+		}
+		files[i].ChunkRetries = reportedRetries(retries)
+
+		if err := os.MkdirAll(filepath.Dir(files[i].File), 0755); err != nil {
+			return ioError(fmt.Errorf("failed to create output directory: %w", err))
+		}
+		// An interrupted write must not leave a partial file that the next
+		// run would take as up to date
+		if err := output.WriteFileAtomic(files[i].File, data, 0644); err != nil {
+			return ioError(fmt.Errorf("failed to write audio file: %w", err))
+		}
+		if _, err := overwrite.PruneBackups(files[i].File); err != nil {
+			renderer.Warnf("Warning: %v\n", err)
+		}
+		if duration, err := audio.Duration(data); err == nil {
+			files[i].Duration = duration.Seconds()
+		}
+		notice.addFile(files[i].File, files[i].Characters, files[i].Duration)
+		bar.Add(1, int64(len(data)))
+		if queue != nil {
+			queue.Add(files[i].File)
+		}
+	}
+
+	if batchMerge != "" {
+		if err := mergeBatchFiles(batchMerge, files, batchSeparator); err != nil {
+			return err
+		}
+		if _, err := overwrite.PruneBackups(batchMerge); err != nil {
+			renderer.Warnf("Warning: %v\n", err)
+		}
+	}
+
+	result := &batchResult{Provider: provider.Name(), Directory: dir, Files: files, Merged: batchMerge,
+		Played: finishBatchPlayback(renderer, queue)}
+	return renderer.Result(result, func(w io.Writer) {
+		var upToDate, kept int
+		for _, file := range files {
+			switch {
+			case file.UpToDate:
+				upToDate++
+			case file.Kept:
+				kept++
+			}
+		}
+		fmt.Fprintf(w, "%s Wrote %d of %d files to %s\n", styleFor(w).Success(), pending, len(files), dir)
+		if upToDate > 0 {
+			fmt.Fprintf(w, "  Skipped %d files that were up to date\n", upToDate)
+		}
+		if kept > 0 {
+			fmt.Fprintf(w, "  Kept %d existing files\n", kept)
+		}
+		if batchMerge != "" {
+			fmt.Fprintf(w, "  Merged: %s\n", batchMerge)
+		}
+	})
+}
+
+// withoutOutputs leaves out the sources that are, or are below, one of
+// outputs, which are the outputs of an earlier run when the output directory
+// or merged file is inside an input directory. Empty outputs are ignored.
+func withoutOutputs(sources []fileset.File, outputs ...string) ([]fileset.File, error) {
+	var roots []string
+	for _, name := range outputs {
+		if name == "" {
+			continue
+		}
+		root, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+
+	kept := sources[:0]
+	for _, source := range sources {
+		path, err := filepath.Abs(source.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(roots, func(root string) bool { return isWithin(root, path) }) {
+			kept = append(kept, source)
+		}
+	}
+	return kept, nil
+}
+
+// isWithin reports whether path is root or below it
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// mergeBatchFiles joins the outputs of files in order into the file merged,
+// with sep between them
+func mergeBatchFiles(merged string, files []batchFile, sep audio.Separator) error {
+	parts := make([][]byte, len(files))
+	for i, file := range files {
+		data, err := os.ReadFile(file.File)
+		if err != nil {
+			return ioError(fmt.Errorf("failed to read audio file: %w", err))
+		}
+		parts[i] = data
+	}
+
+	data, err := audio.ConcatWith(parts, sep)
+	if err != nil {
+		return validationError(fmt.Errorf("cannot merge the outputs: %w", err))
+	}
+	if dir := filepath.Dir(merged); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return ioError(fmt.Errorf("failed to create output directory: %w", err))
+		}
+	}
+	if err := output.WriteFileAtomic(merged, data, 0644); err != nil {
+		return ioError(fmt.Errorf("failed to write merged file: %w", err))
+	}
+	return nil
+}
+
+// errReadWhole reports that an input of batch cannot be streamed and is
+// read whole instead
+var errReadWhole = errors.New("input is read whole")
+
+// readBatchPieces returns the pieces to synthesize for an input of batch,
+// after the filters, and the number of characters they were made from.
+// Plain text is streamed; documents, SSML, and text with voice markup are
+// read whole.
+func readBatchPieces(path string, cfg config.InputConfig, filters []utils.TextFilter,
+	req *tts.SynthesizeRequest) ([]longtext.Piece, int, error) {
+	// Detecting one language for the whole text needs all of it
+	if !isBatchDocument(path) && autoLanguageFlag != autoLanguageDocument {
+		pieces, characters, err := streamBatchFile(path, cfg, filters, req)
+		if !errors.Is(err, errReadWhole) {
+			return pieces, characters, err
+		}
+	}
+
+	text, err := readBatchFile(path, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+	text = utils.ApplyFilters(text, filters...)
+	if err := checkLongTextSSML(text, cfg); err != nil {
+		return nil, 0, err
+	}
+	pieces, err := longTextPieces(text, req, autoLanguageFlag)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pieces, utf8.RuneCountInString(text), nil
+}
+
+// streamBatchFile reads a plain text file with a ChunkReader, filtering each
+// request-sized chunk as it is read and splitting it into pieces, so the
+// text is held once and input.max_length applies to the whole stream. SSML
+// documents, which are split between their elements, and text with voice
+// markup, whose "@voice:" lines switch the voice across chunks, fail with
+// errReadWhole.
+func streamBatchFile(path string, cfg config.InputConfig, filters []utils.TextFilter,
+	req *tts.SynthesizeRequest) ([]longtext.Piece, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, ioError(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	processor, err := newFileInputProcessor(file, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+	reader := processor.NewChunkReader(longtext.ChunkSize)
+	var pieces []longtext.Piece
+	characters := 0
+	for first := true; ; first = false {
+		chunk, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return pieces, characters, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if first && longtext.IsSSML(chunk) {
+			return nil, 0, errReadWhole
+		}
+		chunk = utils.ApplyFilters(chunk, filters...)
+		if utils.HasVoiceMarkup(chunk) {
+			return nil, 0, errReadWhole
+		}
+		if err := checkLongTextSSML(chunk, cfg); err != nil {
+			return nil, 0, err
+		}
+		chunkPieces, err := longTextPieces(chunk, req, autoLanguageFlag)
+		if err != nil {
+			return nil, 0, err
+		}
+		pieces = append(pieces, chunkPieces...)
+		characters += utf8.RuneCountInString(chunk)
+	}
+}
+
+// isBatchDocument reports whether path is a document read as audiobook
+// reads it rather than as text
+func isBatchDocument(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epub", ".pdf", ".md", ".markdown":
+		return true
+	default:
+		return false
+	}
+}
+
+// readBatchFile returns the text of an input of batch: the chapters of a
+// document audiobook reads, or else the file as text in the encoding of
+// --input-encoding or input.encoding, checked like STDIN input
+func readBatchFile(path string, cfg config.InputConfig) (string, error) {
+	if isBatchDocument(path) {
+		doc, err := document.Open(path)
+		if err != nil {
+			if errors.Is(err, document.ErrUnsupportedDocument) {
+				return "", validationError(err)
+			}
+			return "", ioError(err)
+		}
+		parts := make([]string, 0, 2*len(doc.Segments))
+		for _, segment := range doc.Segments {
+			if segment.Title != "" {
+				parts = append(parts, segment.Title)
+			}
+			parts = append(parts, segment.Text)
+		}
+		return strings.Join(parts, "\n\n"), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", ioError(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	processor, err := newFileInputProcessor(file, cfg)
+	if err != nil {
+		return "", err
+	}
+	if !cfg.EnableSSMLSecurity {
+		return processor.ReadText()
+	}
+	// An SSML document is validated as it is read, before it is held whole
+	text, err := processor.ReadValidatedText(newLongTextSSMLValidator(cfg))
+	var validationErr *utils.ValidationError
+	if errors.As(err, &validationErr) {
+		return "", fmt.Errorf("input validation failed: %w", err)
+	}
+	return text, err
+}
+
+// newFileInputProcessor returns an input processor for file in the encoding
+// of --input-encoding or input.encoding
+func newFileInputProcessor(file io.Reader, cfg config.InputConfig) (*utils.InputProcessor, error) {
+	processor := utils.NewInputProcessorWithConfig(file, cfg.MaxLength)
+	encoding := cfg.Encoding
+	if inputEncodingFlag != "" {
+		encoding = inputEncodingFlag
+	}
+	if err := processor.SetEncoding(encoding); err != nil {
+		return nil, usageError(fmt.Errorf("invalid --input-encoding: %w", err))
+	}
+	return processor, nil
+}
+
+// batchTags returns the metadata tags of an output of batch. The source
+// hash is of the pieces, as streamed text is never held whole.
+func batchTags(title string, pieces []longtext.Piece, req *tts.SynthesizeRequest) *output.Tags {
+	digest := sha256.New()
+	for _, piece := range pieces {
+		_, _ = io.WriteString(digest, piece.Text)
+	}
+	tags := output.NewTags(title, req.Voice, req.LanguageCode, time.Now())
+	tags.SourceHash = hex.EncodeToString(digest.Sum(nil))
+	return &tags
+}
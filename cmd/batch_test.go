@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/notify"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runBatchCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		batchOutputDir = ""
+		batchVoice = ""
+		batchFormat = "MP3"
+		batchForce = false
+		batchInclude = nil
+		batchExclude = nil
+		batchMerge = ""
+		batchSeparator = audio.Separator{}
+		batchPlayAll = false
+		notifyURL = ""
+		concurrencyFlag = 0
+		autoLanguageFlag = ""
+		inputEncodingFlag = ""
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"batch"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+// writeBatchTree writes a tree of notes with a draft and a hidden directory
+func writeBatchTree(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"intro.md":          "# Intro\n\nWelcome to the notes.\n",
+		"week1/monday.txt":  "Monday was a good day.",
+		"week1/notes.log":   "Not a note.",
+		"drafts/unsure.md":  "Not ready to be read.",
+		".git/HEAD":         "ref: refs/heads/main",
+		"week2/tuesday.txt": "Tuesday was a better day.",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+// batchOutputs runs batch with args and returns its files
+func batchOutputs(t *testing.T, args ...string) []batchFile {
+	t.Helper()
+
+	stdout, err := runBatchCommand(t, append(args, "--output-format", "json")...)
+	require.NoError(t, err)
+	var result struct {
+		Data batchResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "espeak", result.Data.Provider)
+	return result.Data.Files
+}
+
+func TestBatchCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	src := writeBatchTree(t)
+	out := filepath.Join(t.TempDir(), "audio")
+	args := []string{src, "--config", config, "--format", "LINEAR16", "-o", out,
+		"--include", "*.md,*.txt", "--exclude", "drafts/**"}
+
+	files := batchOutputs(t, args...)
+	require.Len(t, files, 3)
+	for i, rel := range []string{"intro.wav", "week1/monday.wav", "week2/tuesday.wav"} {
+		assert.Equal(t, filepath.Join(out, filepath.FromSlash(rel)), files[i].File)
+		assert.False(t, files[i].UpToDate, rel)
+		data, err := os.ReadFile(files[i].File)
+		require.NoError(t, err, rel)
+		_, err = audio.DecodeWAV(data)
+		assert.NoError(t, err, rel)
+	}
+	assert.NoDirExists(t, filepath.Join(out, "drafts"))
+	assert.NoFileExists(t, filepath.Join(out, "week1", "notes.wav"))
+
+	// A second run skips the outputs that are newer than their sources
+	files = batchOutputs(t, args...)
+	require.Len(t, files, 3)
+	for _, file := range files {
+		assert.True(t, file.UpToDate, file.File)
+	}
+
+	// Only the changed source is synthesized again
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "week1", "monday.txt"), later, later))
+	files = batchOutputs(t, args...)
+	require.Len(t, files, 3)
+	assert.True(t, files[0].UpToDate)
+	assert.False(t, files[1].UpToDate)
+	assert.True(t, files[2].UpToDate)
+
+	// --force synthesizes every file again
+	files = batchOutputs(t, append(args, "--force")...)
+	for _, file := range files {
+		assert.False(t, file.UpToDate, file.File)
+	}
+}
+
+func TestBatchCommandGlob(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	src := writeBatchTree(t)
+	out := filepath.Join(t.TempDir(), "audio")
+
+	// Files matched by a glob argument are written by name
+	files := batchOutputs(t, filepath.Join(src, "week*", "*.txt"), "--config", config, "--format", "LINEAR16",
+		"-o", out)
+	require.Len(t, files, 2)
+	assert.Equal(t, filepath.Join(out, "monday.wav"), files[0].File)
+	assert.Equal(t, filepath.Join(out, "tuesday.wav"), files[1].File)
+}
+
+func TestBatchCommandOutputInsideInput(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	src := writeBatchTree(t)
+	out := filepath.Join(src, "audio")
+	args := []string{src, "--config", config, "--format", "LINEAR16", "-o", out, "--exclude", "drafts/**,*.log"}
+
+	require.Len(t, batchOutputs(t, args...), 3)
+	// The outputs of the first run are not read as inputs by the next
+	require.Len(t, batchOutputs(t, args...), 3)
+}
+
+func TestBatchCommandMerge(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	src := writeBatchTree(t)
+	out := filepath.Join(t.TempDir(), "audio")
+	merged := filepath.Join(src, "digest.wav")
+	args := []string{src, "--config", config, "--format", "LINEAR16", "-o", out, "--include", "*.txt",
+		"--merge", merged, "--gap", "500ms"}
+
+	files := batchOutputs(t, args...)
+	require.Len(t, files, 2)
+	var total time.Duration
+	for _, file := range files {
+		data, err := os.ReadFile(file.File)
+		require.NoError(t, err)
+		duration, err := audio.Duration(data)
+		require.NoError(t, err)
+		total += duration
+	}
+	data, err := os.ReadFile(merged)
+	require.NoError(t, err)
+	duration, err := audio.Duration(data)
+	require.NoError(t, err)
+	assert.InDelta(t, (total + 500*time.Millisecond).Seconds(), duration.Seconds(), 0.01)
+
+	// Up-to-date outputs are merged too, and the merged file in the input
+	// directory is not read as an input
+	files = batchOutputs(t, append(args, "--include", "*.wav")...)
+	require.Len(t, files, 2)
+	assert.True(t, files[0].UpToDate)
+	assert.FileExists(t, merged)
+
+	_, err = runBatchCommand(t, src, "--config", config, "--gap", "1s")
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.Contains(t, err.Error(), "--gap and --tone need --merge")
+}
+
+func TestBatchCommandPlayAll(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	src := writeBatchTree(t)
+	out := filepath.Join(t.TempDir(), "audio")
+	args := []string{src, "--config", config, "--format", "LINEAR16", "-o", out, "--include", "*.txt",
+		"--output-format", "json"}
+
+	stdout, err := runBatchCommand(t, append(args, "--play-all")...)
+	require.NoError(t, err)
+	var result struct {
+		Data batchResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Data.Played)
+	assert.Equal(t, []string{"monday.wav", "tuesday.wav"}, played())
+
+	// Up-to-date outputs are played in their turn
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "week2", "tuesday.txt"), later, later))
+	_, err = runBatchCommand(t, append(args, "--play-all")...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"monday.wav", "tuesday.wav", "monday.wav", "tuesday.wav"}, played())
+
+	// Without --play-all nothing is played
+	_, err = runBatchCommand(t, args...)
+	require.NoError(t, err)
+	assert.Len(t, played(), 4)
+}
+
+func TestBatchCommandNotify(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	src := writeBatchTree(t)
+	out := filepath.Join(t.TempDir(), "audio")
+
+	payloads := make(chan notify.Payload, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.Payload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads <- payload
+	}))
+	defer server.Close()
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\noutput:\n  notify_webhook: \""+server.URL+"\"\n")
+	args := []string{src, "--config", config, "--format", "LINEAR16", "-o", out, "--include", "*.txt"}
+
+	_, err := runBatchCommand(t, args...)
+	require.NoError(t, err)
+	payload := <-payloads
+	assert.Equal(t, "batch", payload.Command)
+	assert.Equal(t, notify.StatusSuccess, payload.Status)
+	assert.Equal(t, out, payload.File)
+	assert.Equal(t, []string{filepath.Join(out, "week1", "monday.wav"), filepath.Join(out, "week2", "tuesday.wav")},
+		payload.Files)
+	assert.Positive(t, payload.Characters)
+	assert.Positive(t, payload.DurationSeconds)
+
+	// Up-to-date outputs are not written again
+	_, err = runBatchCommand(t, args...)
+	require.NoError(t, err)
+	payload = <-payloads
+	assert.Equal(t, notify.StatusSuccess, payload.Status)
+	assert.Empty(t, payload.Files)
+
+	_, err = runBatchCommand(t, filepath.Join(src, "missing"), "--config", config)
+	require.Error(t, err)
+	payload = <-payloads
+	assert.Equal(t, notify.StatusFailure, payload.Status)
+	assert.Contains(t, payload.Error, "missing")
+}
+
+func TestBatchCommandOverwritePrompt(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\noutput:\n  overwrite_mode: \"prompt\"\n")
+	src := writeBatchTree(t)
+	out := filepath.Join(t.TempDir(), "audio")
+	args := []string{src, "--config", config, "--format", "LINEAR16", "-o", out, "--include", "*.md,*.txt",
+		"--exclude", "drafts/**"}
+	touch := func() {
+		later := time.Now().Add(time.Hour)
+		for _, name := range []string{"intro.md", "week1/monday.txt", "week2/tuesday.txt"} {
+			require.NoError(t, os.Chtimes(filepath.Join(src, filepath.FromSlash(name)), later, later))
+		}
+	}
+
+	// New outputs are written without asking
+	asked := answerPrompts(t, "")
+	require.Len(t, batchOutputs(t, args...), 3)
+	assert.Empty(t, asked.String())
+
+	// "All" overwrites every stale output after one question
+	touch()
+	asked = answerPrompts(t, "a\n")
+	files := batchOutputs(t, args...)
+	assert.Equal(t, 1, strings.Count(asked.String(), "Overwrite "))
+	for _, file := range files {
+		assert.False(t, file.Kept, file.File)
+		assert.False(t, file.UpToDate, file.File)
+	}
+
+	// "Don't overwrite any" keeps the rest without asking again
+	touch()
+	asked = answerPrompts(t, "y\nd\n")
+	files = batchOutputs(t, args...)
+	assert.Equal(t, 2, strings.Count(asked.String(), "Overwrite "))
+	assert.False(t, files[0].Kept)
+	assert.True(t, files[1].Kept)
+	assert.True(t, files[2].Kept)
+}
+
+func TestBatchCommandErrors(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	src := writeBatchTree(t)
+
+	_, err := runBatchCommand(t, filepath.Join(src, "missing"), "--config", config)
+	require.Error(t, err)
+	assert.Equal(t, ExitIO, ExitCode(err))
+
+	_, err = runBatchCommand(t, src, "--config", config, "--include", "*.epub")
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.Contains(t, err.Error(), "no input files found")
+
+	_, err = runBatchCommand(t, src, "--config", config, "--include", "[")
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	_, err = runBatchCommand(t, "--config", config)
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	// SSML files are validated as they are read
+	ssml := filepath.Join(t.TempDir(), "unsafe.ssml")
+	require.NoError(t, os.WriteFile(ssml, []byte("<speak>Hi <audio src='x.mp3'/></speak>"), 0600))
+	_, err = runBatchCommand(t, ssml, "--config", config, "-o", t.TempDir())
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.Contains(t, err.Error(), "audio tags are not allowed")
+}
+
+func TestStreamBatchFile(t *testing.T) {
+	cfg := config.GetDefaults().Input
+	cfg.MaxLength = 20000
+	req := &tts.SynthesizeRequest{AudioFormat: "MP3"}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	// Plain text is read in chunks that are the pieces of the whole text
+	text := strings.Repeat("This sentence is part of a long note.\n", 300)
+	pieces, characters, err := streamBatchFile(write("long.txt", text), cfg, nil, req)
+	require.NoError(t, err)
+	whole, err := longTextPieces(text, req, "")
+	require.NoError(t, err)
+	require.Greater(t, len(whole), 1)
+	require.Len(t, pieces, len(whole))
+	for i := range pieces {
+		assert.Equal(t, whole[i].Text, pieces[i].Text)
+	}
+	assert.Greater(t, characters, 10000)
+
+	// input.max_length applies to the whole stream
+	cfg.MaxLength = 5000
+	_, _, err = streamBatchFile(write("long.txt", text), cfg, nil, req)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	cfg.MaxLength = 20000
+
+	// SSML and voice markup are read whole
+	_, _, err = streamBatchFile(write("doc.txt", "<speak><p>Hello.</p></speak>"), cfg, nil, req)
+	assert.ErrorIs(t, err, errReadWhole)
+	_, _, err = streamBatchFile(write("voices.txt", "Hello.\n@voice: en-GB-News-K\nGood evening."), cfg, nil, req)
+	assert.ErrorIs(t, err, errReadWhole)
+
+	pieces, _, err = readBatchPieces(filepath.Join(dir, "voices.txt"), cfg, nil, req)
+	require.NoError(t, err)
+	require.Len(t, pieces, 2)
+	assert.Equal(t, "en-GB-News-K", pieces[1].Request.Voice)
+}
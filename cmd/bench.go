@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/bench"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+// benchLiveText is synthesized by the live benchmark; it is short to keep
+// the quota used small
+const benchLiveText = "Hello, world!"
+
+var (
+	benchTime         time.Duration
+	benchRun          string
+	benchLive         bool
+	benchLiveRequests int
+	benchBaseline     string
+	benchSave         bool
+	benchThreshold    float64
+)
+
+// NewBenchCmd creates the bench command
+func NewBenchCmd() *cobra.Command {
+	benchCmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark chunking, validation, and file output against a baseline",
+		Long: `Run standardized benchmarks of the local work done for every synthesis (text
+and SSML chunking, SSML validation and sanitization, audio joining, and file
+writes) and print the time, throughput, and allocations of each.
+
+Results are compared with the baseline in ~/.assistant-cli/bench-baseline.json
+(or --baseline), showing the change of each benchmark. A benchmark more than
+--threshold percent slower than the baseline is a regression, and the command
+exits with 1. Save a baseline with --save, for example before upgrading, to
+see whether a release made things slower on this machine.
+
+With --live, the latency of synthesizing a short phrase with the configured
+provider is measured too. It uses a little API quota, and being dependent on
+the network, is never counted as a regression.
+
+Examples:
+  assistant-cli bench --save
+  assistant-cli bench
+  assistant-cli bench --run chunking --time 3s
+  assistant-cli bench --live --live-requests 5
+  assistant-cli --output-format json bench --threshold 25`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runBench,
+	}
+
+	benchCmd.Flags().DurationVar(&benchTime, "time", time.Second, "Minimum run time of each benchmark")
+	benchCmd.Flags().StringVar(&benchRun, "run", "", "Run only the benchmarks whose names match this regular expression")
+	benchCmd.Flags().BoolVar(&benchLive, "live", false, "Also measure the API latency of the configured provider (uses quota)")
+	benchCmd.Flags().IntVar(&benchLiveRequests, "live-requests", 3, "Number of requests made by --live")
+	benchCmd.Flags().StringVar(&benchBaseline, "baseline", "",
+		"Baseline file (default ~/.assistant-cli/bench-baseline.json)")
+	benchCmd.Flags().BoolVar(&benchSave, "save", false, "Save the results as the new baseline")
+	benchCmd.Flags().Float64Var(&benchThreshold, "threshold", 10,
+		"Percent slower than the baseline that counts as a regression")
+
+	return benchCmd
+}
+
+// benchResult is the machine-readable result of bench
+type benchResult struct {
+	*bench.Report
+	BaselineFile string `json:"baseline_file"`
+	// Baseline describes the baseline compared with, if there is one
+	Baseline    *benchBaselineInfo `json:"baseline,omitempty"`
+	Comparisons []bench.Comparison `json:"comparisons,omitempty"`
+	Regressions int                `json:"regressions"`
+	Saved       bool               `json:"saved"`
+}
+
+// benchBaselineInfo identifies the baseline of a comparison
+type benchBaselineInfo struct {
+	CLIVersion string    `json:"cli_version"`
+	CreatedAt  time.Time `json:"created_at"`
+	// SameMachine is false when the baseline comes from another platform
+	// or CPU count, so that the comparison means little
+	SameMachine bool `json:"same_machine"`
+}
+
+func runBench(cmd *cobra.Command, _ []string) error {
+	if benchTime <= 0 {
+		return usageError(fmt.Errorf("--time must be positive, got %s", benchTime))
+	}
+	if benchThreshold < 0 {
+		return usageError(fmt.Errorf("--threshold must not be negative, got %g", benchThreshold))
+	}
+	if benchLive && benchLiveRequests < 1 {
+		return usageError(fmt.Errorf("--live-requests must be at least 1, got %d", benchLiveRequests))
+	}
+	var filter *regexp.Regexp
+	if benchRun != "" {
+		var err error
+		if filter, err = regexp.Compile(benchRun); err != nil {
+			return usageError(fmt.Errorf("invalid --run pattern: %w", err))
+		}
+	}
+
+	path := benchBaseline
+	if path == "" {
+		var err error
+		if path, err = bench.DefaultBaselinePath(); err != nil {
+			return ioError(err)
+		}
+	}
+	baseline, err := bench.LoadReport(path)
+	if err != nil {
+		return ioError(err)
+	}
+
+	ctx := context.Background()
+	renderer := newRenderer(cmd)
+	dir, err := os.MkdirTemp("", "assistant-cli-bench-")
+	if err != nil {
+		return ioError(fmt.Errorf("failed to create temporary directory: %w", err))
+	}
+	defer os.RemoveAll(dir)
+
+	cases := bench.Standard(dir)
+	if benchLive {
+		live, closeProvider, err := liveBenchCase(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeProvider()
+		cases = append(cases, live)
+	}
+	if filter != nil {
+		cases = filterBenchCases(cases, filter)
+		if len(cases) == 0 {
+			return usageError(fmt.Errorf("no benchmark matches --run %q", benchRun))
+		}
+	}
+
+	results := make([]bench.Result, 0, len(cases))
+	for _, c := range cases {
+		renderer.Detailf("Running %s...\n", c.Name)
+		r, err := bench.Run(ctx, []bench.Case{c}, benchTime)
+		if err != nil {
+			return err
+		}
+		results = append(results, r...)
+	}
+
+	result := &benchResult{
+		Report:       bench.NewReport(version, time.Now().UTC(), results),
+		BaselineFile: path,
+	}
+	if baseline != nil {
+		result.Baseline = &benchBaselineInfo{
+			CLIVersion:  baseline.CLIVersion,
+			CreatedAt:   baseline.CreatedAt,
+			SameMachine: baseline.SameMachine(result.Report),
+		}
+		result.Comparisons = bench.Compare(baseline, result.Report, benchThreshold/100)
+		for _, c := range result.Comparisons {
+			if c.Regressed {
+				result.Regressions++
+			}
+		}
+	}
+
+	if benchSave {
+		if err := result.Report.Save(path); err != nil {
+			return ioError(err)
+		}
+		result.Saved = true
+	}
+
+	text := func(w io.Writer) { printBenchResult(w, result) }
+	if result.Regressions > 0 {
+		err := fmt.Errorf("%d benchmark(s) more than %g%% slower than the baseline", result.Regressions, benchThreshold)
+		if !renderer.IsJSON() {
+			text(cmd.OutOrStdout())
+		}
+		return withResult(err, result)
+	}
+	return renderer.Result(result, text)
+}
+
+// liveBenchCase returns the case measuring the synthesis latency of the
+// configured provider, and a function that closes the provider
+func liveBenchCase(ctx context.Context) (bench.Case, func(), error) {
+	cfg := GetConfig().Get()
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		return bench.Case{}, nil, validationError(err)
+	}
+	ttsConfig := createTTSConfig(cfg.TTS)
+	provider, err := createProvider(ctx, providerName, cfg.Auth, ttsConfig)
+	if err != nil {
+		return bench.Case{}, nil, err
+	}
+
+	// Measure in the configured format where the provider supports it
+	format := ttsConfig.AudioEncoding
+	if formats := tts.SupportedFormats(providerName); formats != nil && !slices.Contains(formats, format) {
+		format = formats[0]
+	}
+	synthesizer := tts.NewSynthesizer(provider)
+	req := &tts.SynthesizeRequest{
+		Voice:        ttsConfig.Voice,
+		LanguageCode: ttsConfig.LanguageCode,
+		SpeakingRate: ttsConfig.SpeakingRate,
+		Pitch:        ttsConfig.Pitch,
+		VolumeGain:   ttsConfig.VolumeGain,
+		AudioFormat:  format,
+	}
+	return bench.Case{
+		Name:       "live/" + providerName,
+		Iterations: benchLiveRequests,
+		Live:       true,
+		Op: func(ctx context.Context) error {
+			_, err := synthesizer.SynthesizeText(ctx, benchLiveText, req)
+			return err
+		},
+	}, func() { provider.Close() }, nil
+}
+
+// filterBenchCases returns the cases whose names match filter
+func filterBenchCases(cases []bench.Case, filter *regexp.Regexp) []bench.Case {
+	var matched []bench.Case
+	for _, c := range cases {
+		if filter.MatchString(c.Name) {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// printBenchResult writes the benchmark table and the baseline comparison
+func printBenchResult(w io.Writer, result *benchResult) {
+	report := result.Report
+	fmt.Fprintf(w, "Benchmarks (%s, %s %s/%s, %d CPUs)\n", report.CLIVersion, report.GoVersion,
+		report.OS, report.Arch, report.CPUs)
+	if result.Baseline != nil {
+		fmt.Fprintf(w, "Baseline: %s from %s\n", result.Baseline.CLIVersion,
+			result.Baseline.CreatedAt.Local().Format(time.DateTime))
+		if !result.Baseline.SameMachine {
+			fmt.Fprintln(w, styleFor(w).Warning(), "The baseline was saved on a different machine; changes may not be meaningful")
+		}
+	}
+	fmt.Fprintln(w)
+
+	changes := make(map[string]bench.Comparison, len(result.Comparisons))
+	for _, c := range result.Comparisons {
+		changes[c.Name] = c
+	}
+	fmt.Fprintf(w, "%-22s %10s %12s %10s %10s %10s  %s\n", "Benchmark", "Runs", "Time/op", "MB/s", "B/op", "Allocs/op", "Change")
+	for _, r := range report.Results {
+		throughput := "-"
+		if r.MBPerSec > 0 {
+			throughput = fmt.Sprintf("%.1f", r.MBPerSec)
+		}
+		fmt.Fprintf(w, "%-22s %10d %12s %10s %10d %10d  %s\n", r.Name, r.Iterations,
+			formatPerOp(r.PerOp()), throughput, r.BytesPerOp, r.AllocsPerOp, formatChange(w, changes[r.Name]))
+	}
+
+	if result.Baseline == nil && !result.Saved {
+		fmt.Fprintf(w, "\nNo baseline to compare with; save one with --save\n")
+	}
+	if result.Saved {
+		fmt.Fprintf(w, "\n%s Saved the results as the baseline %s\n", styleFor(w).Success(), result.BaselineFile)
+	}
+}
+
+// formatPerOp rounds the time of an operation to three significant digits
+func formatPerOp(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	case d >= time.Microsecond:
+		return d.Round(10 * time.Nanosecond).String()
+	default:
+		return d.String()
+	}
+}
+
+// formatChange describes the change from the baseline, marking regressions
+func formatChange(w io.Writer, c bench.Comparison) string {
+	if c.BaselineNsPerOp == 0 {
+		return "-"
+	}
+	change := fmt.Sprintf("%+.1f%%", c.Change*100)
+	if c.Regressed {
+		return change + " " + styleFor(w).Failure() + " regression"
+	}
+	return change
+}
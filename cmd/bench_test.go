@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/bench"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runBenchCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		benchTime = time.Second
+		benchRun = ""
+		benchLive = false
+		benchLiveRequests = 3
+		benchBaseline = ""
+		benchSave = false
+		benchThreshold = 10
+		outputFormat = outputFormatText
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"bench", "--time", "1ms"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestBenchCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	stdout, err := runBenchCommand(t, "--baseline", baseline)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "chunking/text")
+	assert.Contains(t, stdout, "file/write")
+	assert.Contains(t, stdout, "No baseline to compare with")
+
+	stdout, err = runBenchCommand(t, "--baseline", baseline, "--save", "--run", "^chunking/")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Saved the results as the baseline")
+	assert.NotContains(t, stdout, "file/write")
+	saved, err := bench.LoadReport(baseline)
+	require.NoError(t, err)
+	require.Len(t, saved.Results, 2)
+
+	stdout, err = runBenchCommand(t, "--baseline", baseline, "--run", "^chunking/text$",
+		"--threshold", "1000000", "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data benchResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	require.NotNil(t, result.Data.Baseline)
+	assert.True(t, result.Data.Baseline.SameMachine)
+	require.Len(t, result.Data.Comparisons, 1)
+	assert.Positive(t, result.Data.Comparisons[0].BaselineNsPerOp)
+	assert.Zero(t, result.Data.Regressions)
+	assert.False(t, result.Data.Saved)
+}
+
+func TestBenchCommand_Regression(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	fast := bench.NewReport("1.0.0", time.Now(), []bench.Result{{Name: "chunking/text", Iterations: 1, NsPerOp: 1}})
+	require.NoError(t, fast.Save(baseline))
+
+	stdout, err := runBenchCommand(t, "--baseline", baseline, "--run", "chunking/text")
+	require.Error(t, err)
+	assert.Equal(t, ExitGeneral, ExitCode(err))
+	assert.ErrorContains(t, err, "1 benchmark(s) more than 10% slower than the baseline")
+	assert.Contains(t, stdout, "regression")
+}
+
+func TestBenchCommand_Live(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runBenchCommand(t, "--config", config, "--live", "--live-requests", "2",
+		"--run", "^live/", "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data benchResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	require.Len(t, result.Data.Results, 1)
+	live := result.Data.Results[0]
+	assert.Equal(t, "live/espeak", live.Name)
+	assert.Equal(t, 2, live.Iterations)
+	assert.True(t, live.Live)
+}
+
+func TestBenchCommand_InvalidFlags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tests := [][]string{
+		{"--time", "0s"},
+		{"--threshold", "-5"},
+		{"--run", "("},
+		{"--run", "nothing-matches"},
+		{"--live", "--live-requests", "0"},
+	}
+	for _, args := range tests {
+		_, err := runBenchCommand(t, args...)
+		assert.Equal(t, ExitUsage, ExitCode(err), "%v", args)
+	}
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// maxCompareVariants is the most variants compare synthesizes in one run, so
+// a typo in a list does not spend many requests
+const maxCompareVariants = 24
+
+var (
+	compareVoices    []string
+	compareSpeeds    []float64
+	comparePitches   []float64
+	compareText      string
+	compareOutputDir string
+	compareFormat    string
+	compareForce     bool
+	comparePlay      bool
+)
+
+// NewCompareCmd creates the compare command
+func NewCompareCmd() *cobra.Command {
+	compareCmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Synthesize the same text with several voices or settings",
+		Long: `Synthesize the same text with each combination of the given voices, speaking
+rates, and pitches, writing one labeled file per combination, to hear which
+suits the text best. Settings not given keep the configured value.
+
+The text comes from --text, or else STDIN. Files are written to --output-dir,
+by default a "compare" directory under output.default_path, and are named after
+what they vary, e.g. 01_en-US-Wavenet-D_rate_1.1.mp3. With --play, each file
+is played in turn as soon as it is ready; in a terminal, space pauses, n skips
+to the next file and q stops playback.
+
+Examples:
+  assistant-cli compare --voices en-US-Wavenet-D,en-US-Neural2-F,en-US-Studio-O --text "Welcome back"
+  assistant-cli compare --speeds 0.9,1.0,1.15 --pitches -2,0 --play < intro.txt
+  assistant-cli compare --voices en-GB-News-K,en-GB-Neural2-B --format LINEAR16 -o ./samples`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runCompare,
+	}
+
+	compareCmd.Flags().StringSliceVar(&compareVoices, "voices", nil, "Voices to compare, comma-separated")
+	compareCmd.Flags().Float64SliceVar(&compareSpeeds, "speeds", nil,
+		"Speaking rates to compare, comma-separated (0.25 to 4.0)")
+	compareCmd.Flags().Float64SliceVar(&comparePitches, "pitches", nil,
+		"Pitches to compare, comma-separated (-20.0 to 20.0)")
+	compareCmd.Flags().StringVar(&compareText, "text", "", "Text to synthesize (default: read from STDIN)")
+	compareCmd.Flags().StringVarP(&compareOutputDir, "output-dir", "o", "",
+		"Directory for the files (default: <output.default_path>/compare)")
+	compareCmd.Flags().StringVarP(&compareFormat, "format", "f", "MP3", "Audio format (MP3, OGG_OPUS, LINEAR16)")
+	compareCmd.Flags().BoolVar(&compareForce, "force", false, "Overwrite existing files")
+	compareCmd.Flags().BoolVar(&comparePlay, "play", false, "Play each file in turn as soon as it is synthesized")
+	addPresetFlag(compareCmd)
+	addInputEncodingFlag(compareCmd)
+
+	return compareCmd
+}
+
+// compareVariant is one combination of settings synthesized by compare
+type compareVariant struct {
+	Label        string  `json:"label"`
+	Voice        string  `json:"voice,omitempty"`
+	SpeakingRate float64 `json:"speaking_rate"`
+	Pitch        float64 `json:"pitch"`
+	File         string  `json:"file"`
+	Duration     float64 `json:"duration_seconds,omitempty"`
+}
+
+// compareResult is the machine-readable result of compare
+type compareResult struct {
+	Provider   string           `json:"provider"`
+	Directory  string           `json:"directory"`
+	Characters int              `json:"characters"`
+	Variants   []compareVariant `json:"variants"`
+	Played     bool             `json:"played,omitempty"`
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	cfg := GetConfig().Get()
+	renderer := newRenderer(cmd)
+
+	cfg, err := applyPreset(cmd, cfg, &compareFormat)
+	if err != nil {
+		return err
+	}
+	if err := checkLongTextFormat(compareFormat); err != nil {
+		return err
+	}
+	if err := checkCompareSettings(); err != nil {
+		return err
+	}
+
+	provider, req, err := createLongTextProvider(ctx, renderer, cfg, "", compareFormat)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = provider.Close() }()
+	if provider.Name() == tts.ProviderGoogle {
+		for _, name := range compareVoices {
+			name = resolveVoice(name)
+			if err := validateVoiceOffline(name, tts.VoiceLanguage(name), cfg.TTS.VoiceCacheTTL); err != nil {
+				return err
+			}
+		}
+	}
+
+	text := compareText
+	if text == "" {
+		if text, err = processInput(cfg.Input); err != nil {
+			return err
+		}
+	}
+	filters, err := inputFilters(cfg.Input, req.LanguageCode)
+	if err != nil {
+		return err
+	}
+	text = utils.ApplyFilters(text, filters...)
+
+	ext := longTextExtension(req)
+	dir := compareOutputDir
+	if dir == "" {
+		dir = filepath.Join(cfg.Output.DefaultPath, "compare")
+	}
+	overwrite, err := newOverwriteHandler(cfg.Output, compareForce)
+	if err != nil {
+		return err
+	}
+
+	variants := compareVariants(req)
+	for i := range variants {
+		variants[i].File = fmt.Sprintf("%02d_%s", i+1, output.GetSafeFilename(variants[i].Label, ext))
+		if _, err := overwrite.PrepareOverwrite(filepath.Join(dir, variants[i].File)); err != nil {
+			if errors.Is(err, output.ErrPathNotAllowed) {
+				return validationError(err)
+			}
+			return ioError(fmt.Errorf("%w (use --force to overwrite)", err))
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ioError(fmt.Errorf("failed to create output directory: %w", err))
+	}
+
+	queue := startBatchPlayback(renderer, comparePlay)
+	if queue != nil {
+		defer queue.Stop()
+	}
+
+	var chunks int64
+	for _, variant := range variants {
+		pieces, err := longTextPieces(text, compareRequest(req, variant), "")
+		if err != nil {
+			return err
+		}
+		chunks += int64(len(pieces))
+	}
+	bar := newProgressBar(cmd.ErrOrStderr(), chunks, "chunks")
+	defer bar.Done()
+
+	synthesizer := newSynthesizer(provider, audio.Options{}, false)
+	for i, variant := range variants {
+		bar.Step("[%d/%d] %s", i+1, len(variants), variant.Label)
+		data, _, err := synthesizeLongText(ctx, synthesizer, variant.Label, text, compareRequest(req, variant),
+			"", cfg.Output.WriteMetadata, 1, bar)
+		if err != nil {
+			return fmt.Errorf("%s: %w", variant.Label, err)
+		}
+
+		path := filepath.Join(dir, variant.File)
+		if err := output.WriteFileAtomic(path, data, 0644); err != nil {
+			return ioError(fmt.Errorf("failed to write audio file: %w", err))
+		}
+		if _, err := overwrite.PruneBackups(path); err != nil {
+			renderer.Warnf("Warning: %v\n", err)
+		}
+		if duration, err := audio.Duration(data); err == nil {
+			variants[i].Duration = duration.Seconds()
+		}
+		if queue != nil {
+			queue.Add(path)
+		}
+	}
+
+	result := &compareResult{
+		Provider:   provider.Name(),
+		Directory:  dir,
+		Characters: len([]rune(text)),
+		Variants:   variants,
+		Played:     finishBatchPlayback(renderer, queue),
+	}
+	return renderer.Result(result, func(w io.Writer) {
+		fmt.Fprintf(w, "%s Wrote %d variants to %s\n", styleFor(w).Success(), len(variants), dir)
+		for _, variant := range variants {
+			if variant.Duration > 0 {
+				fmt.Fprintf(w, "  %s (%.1fs)\n", variant.File, variant.Duration)
+			} else {
+				fmt.Fprintf(w, "  %s\n", variant.File)
+			}
+		}
+	})
+}
+
+// checkCompareSettings rejects settings out of range and runs with fewer
+// than two or too many variants
+func checkCompareSettings() error {
+	for _, speed := range compareSpeeds {
+		if speed < 0.25 || speed > 4.0 {
+			return usageError(fmt.Errorf("--speeds: %g is not between 0.25 and 4.0", speed))
+		}
+	}
+	for _, pitch := range comparePitches {
+		if pitch < -20.0 || pitch > 20.0 {
+			return usageError(fmt.Errorf("--pitches: %g is not between -20.0 and 20.0", pitch))
+		}
+	}
+
+	count := max(len(compareVoices), 1) * max(len(compareSpeeds), 1) * max(len(comparePitches), 1)
+	switch {
+	case count < 2:
+		return usageError(fmt.Errorf("nothing to compare: give at least two values across --voices, --speeds, and --pitches"))
+	case count > maxCompareVariants:
+		return usageError(fmt.Errorf("%d variants requested, at most %d are synthesized in one run", count,
+			maxCompareVariants))
+	}
+	return nil
+}
+
+// compareVariants returns every combination of --voices, --speeds, and
+// --pitches, in that order of nesting, with settings not given taken from
+// req. Labels name only the settings given.
+func compareVariants(req *tts.SynthesizeRequest) []compareVariant {
+	voices := compareVoices
+	if len(voices) == 0 {
+		voices = []string{req.Voice}
+	}
+	speeds := compareSpeeds
+	if len(speeds) == 0 {
+		speeds = []float64{req.SpeakingRate}
+	}
+	pitches := comparePitches
+	if len(pitches) == 0 {
+		pitches = []float64{req.Pitch}
+	}
+
+	var variants []compareVariant
+	for _, voice := range voices {
+		for _, speed := range speeds {
+			for _, pitch := range pitches {
+				var label []string
+				if len(compareVoices) > 0 || voice != "" {
+					label = append(label, voice)
+				}
+				if len(compareSpeeds) > 0 {
+					label = append(label, "rate "+strconv.FormatFloat(speed, 'g', -1, 64))
+				}
+				if len(comparePitches) > 0 {
+					label = append(label, "pitch "+strconv.FormatFloat(pitch, 'g', -1, 64))
+				}
+				variants = append(variants, compareVariant{
+					Label:        strings.Join(label, " "),
+					Voice:        voice,
+					SpeakingRate: speed,
+					Pitch:        pitch,
+				})
+			}
+		}
+	}
+	return variants
+}
+
+// compareRequest returns a copy of req with the settings of variant
+func compareRequest(req *tts.SynthesizeRequest, variant compareVariant) *tts.SynthesizeRequest {
+	switched := *req
+	if variant.Voice != req.Voice {
+		switched = *voiceRequest(req, variant.Voice)
+	}
+	switched.SpeakingRate = variant.SpeakingRate
+	switched.Pitch = variant.Pitch
+	return &switched
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCompareCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		compareVoices, compareSpeeds, comparePitches = nil, nil, nil
+		compareText = ""
+		compareOutputDir = ""
+		compareFormat = "MP3"
+		compareForce = false
+		comparePlay = false
+		presetFlag = ""
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"compare"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestCompareCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	dir := t.TempDir()
+
+	stdout, err := runCompareCommand(t, "--config", config, "--output-format", "json", "--format", "LINEAR16",
+		"-o", dir, "--voices", "en,de", "--speeds", "0.9,1.1", "--text", "Welcome back", "--play")
+	require.NoError(t, err)
+
+	var result struct {
+		Data compareResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "espeak", result.Data.Provider)
+	assert.True(t, result.Data.Played)
+	var files []string
+	for _, variant := range result.Data.Variants {
+		files = append(files, variant.File)
+		assert.FileExists(t, filepath.Join(dir, variant.File))
+	}
+	want := []string{"01_en_rate_0.9.wav", "02_en_rate_1.1.wav", "03_de_rate_0.9.wav", "04_de_rate_1.1.wav"}
+	assert.Equal(t, want, files)
+	assert.Equal(t, want, played())
+
+	// Existing files are backed up by default
+	_, err = runCompareCommand(t, "--config", config, "--format", "LINEAR16", "-o", dir,
+		"--voices", "en,de", "--speeds", "0.9,1.1", "--text", "Welcome back")
+	require.NoError(t, err)
+	backups, err := filepath.Glob(filepath.Join(dir, "01_en_rate_0.9.wav.backup_*"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
+
+func TestCompareCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	for _, args := range [][]string{
+		{"--voices", "en"},
+		{"--speeds", "1,9"},
+		{"--pitches", "-30,0"},
+		{"--voices", "a,b,c,d,e", "--speeds", "1,2,3", "--pitches", "1,2"},
+		{"--voices", "en,de", "extra"},
+	} {
+		_, err := runCompareCommand(t, append(args, "--config", config, "--text", "Hi")...)
+		assert.Equal(t, ExitUsage, ExitCode(err), args)
+	}
+
+	_, err := runCompareCommand(t, "--voices", "en,de", "--format", "MULAW", "--config", config, "--text", "Hi")
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+func TestCompareVariants(t *testing.T) {
+	t.Cleanup(func() { compareVoices, compareSpeeds, comparePitches = nil, nil, nil })
+
+	req := &tts.SynthesizeRequest{Voice: "en-US-Wavenet-D", LanguageCode: "en-US", SpeakingRate: 1.0, Pitch: -1}
+	comparePitches = []float64{-2, 2}
+	variants := compareVariants(req)
+	require.Len(t, variants, 2)
+	assert.Equal(t, "en-US-Wavenet-D pitch -2", variants[0].Label)
+	assert.Equal(t, 1.0, variants[1].SpeakingRate)
+
+	compareVoices = []string{"en-GB-News-K"}
+	variant := compareVariants(req)[1]
+	switched := compareRequest(req, variant)
+	assert.Equal(t, "en-GB-News-K", switched.Voice)
+	assert.Equal(t, "en-GB", switched.LanguageCode)
+	assert.Equal(t, 2.0, switched.Pitch)
+	assert.Equal(t, "en-US-Wavenet-D", req.Voice, "the request itself is unchanged")
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/mikefarmer/assistant-cli/internal/config"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 // configCmd represents the config command
@@ -17,8 +22,8 @@ var configCmd = &cobra.Command{
 	Long: `Manage configuration settings for assistant-cli.
 
 This command provides utilities to generate, validate, and view configuration files.
-It supports creating example configuration files, viewing current settings, and
-validating configuration for errors.`,
+It supports creating example configuration files, viewing current settings, reading
+and changing individual settings, and validating configuration for errors.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// If no subcommand is provided, show help
 		_ = cmd.Help()
@@ -31,12 +36,17 @@ var generateConfigCmd = &cobra.Command{
 	Long: `Generate an example configuration file with all available options and their default values.
 
 If no output path is specified, the default location (~/.assistant-cli.yaml) will be used.
-The generated file includes comprehensive comments explaining each configuration option.
+The generated YAML file includes comprehensive comments explaining each configuration option.
+
+JSON and TOML files hold the same defaults without comments. The format follows the
+extension of the output path, or --format, which also names the default file
+(~/.assistant-cli.json or ~/.assistant-cli.toml).
 
 Examples:
   assistant-cli config generate
   assistant-cli config generate ./my-config.yaml
-  assistant-cli config generate ~/.config/assistant-cli.yaml`,
+  assistant-cli config generate ~/.config/assistant-cli.toml
+  assistant-cli config generate --format json`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runGenerateConfig,
 }
@@ -50,10 +60,22 @@ If no config file is specified, the default configuration locations will be chec
 This command will report any validation errors, missing required values, or
 configuration inconsistencies.
 
+With --online, the command also checks that the configured authentication method
+can obtain a client, that the API is reachable, and that the configured voice exists,
+producing a single consolidated report (use --output-format json for support tickets).
+
+Warnings flag settings that work but are likely mistakes, such as an API key stored
+in the config file; they only fail validation with --strict.
+
+With --format json or yaml, the report is printed as a plain document listing each
+error's field, offending value, constraint, and suggested fix, for editor integrations.
+
 Examples:
   assistant-cli config validate
   assistant-cli config validate ~/.assistant-cli.yaml
-  assistant-cli config validate ./custom-config.yaml`,
+  assistant-cli config validate ./custom-config.yaml
+  assistant-cli config validate --online
+  assistant-cli config validate --format json`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runValidateConfig,
 }
@@ -67,9 +89,14 @@ This displays the complete configuration as it would be used by the application,
 including values from environment variables, configuration files, and defaults.
 Sensitive values like API keys and secrets will be masked for security.
 
+A project-local .assistant-cli.yaml in the current directory or one of its parents
+is merged over the user-level ~/.assistant-cli.yaml. With --show-sources, each value
+is annotated with the config file, environment variable, or default it came from.
+
 Examples:
   assistant-cli config show
   assistant-cli config show --format json
+  assistant-cli config show --format toml
   assistant-cli config show --include-defaults`,
 	RunE: runShowConfig,
 }
@@ -77,6 +104,8 @@ Examples:
 var (
 	generateForce  bool
 	generateFormat string
+	validateOnline bool
+	validateFormat string
 	showFormat     string
 	showDefaults   bool
 	showSources    bool
@@ -88,30 +117,62 @@ func init() {
 	configCmd.AddCommand(generateConfigCmd)
 	configCmd.AddCommand(validateConfigCmd)
 	configCmd.AddCommand(showConfigCmd)
+	configCmd.AddCommand(getConfigCmd)
+	configCmd.AddCommand(setConfigCmd)
+	configCmd.AddCommand(envConfigCmd)
+	configCmd.AddCommand(schemaConfigCmd)
 
 	// Generate command flags
 	generateConfigCmd.Flags().BoolVarP(&generateForce, "force", "f", false, "Overwrite existing config file")
-	generateConfigCmd.Flags().StringVar(&generateFormat, "format", "yaml", "Output format (yaml, json)")
+	generateConfigCmd.Flags().StringVar(&generateFormat, "format", "yaml",
+		"Output format (yaml, json, toml; default: from the file extension)")
+
+	// Validate command flags
+	validateConfigCmd.Flags().BoolVar(&validateOnline, "online", false,
+		"Also verify credentials can reach the API and the configured voice exists")
+	validateConfigCmd.Flags().StringVar(&validateFormat, "format", "text", "Report format (text, json, yaml)")
 
 	// Show command flags
-	showConfigCmd.Flags().StringVar(&showFormat, "format", "yaml", "Output format (yaml, json, table)")
+	showConfigCmd.Flags().StringVar(&showFormat, "format", "yaml", "Output format (yaml, json, toml, table)")
 	showConfigCmd.Flags().BoolVar(&showDefaults, "include-defaults", false, "Include default values")
 	showConfigCmd.Flags().BoolVar(&showSources, "show-sources", false, "Show configuration sources")
 	showConfigCmd.Flags().BoolVar(&maskSensitive, "mask-sensitive", true, "Mask sensitive values")
+
+	// Get command flags
+	getConfigCmd.Flags().BoolVar(&getMaskSensitive, "mask-sensitive", true, "Mask sensitive values")
+
+	// Env command flags
+	envConfigCmd.Flags().BoolVar(&envMaskSensitive, "mask-sensitive", true, "Mask sensitive values")
+	envConfigCmd.Flags().BoolVar(&envSetOnly, "set-only", false, "Only list variables that are set")
 }
 
 func runGenerateConfig(cmd *cobra.Command, args []string) error {
 	var outputPath string
+	format := generateFormat
 
 	if len(args) > 0 {
 		outputPath = args[0]
+		// The file extension picks the format unless --format is given
+		if !cmd.Flags().Changed("format") {
+			format = config.FileFormat(outputPath)
+		}
 	} else {
 		// Use default config path
 		home, err := os.UserHomeDir()
 		if err != nil {
 			return fmt.Errorf("failed to get home directory: %w", err)
 		}
-		outputPath = filepath.Join(home, ".assistant-cli.yaml")
+		outputPath = filepath.Join(home, ".assistant-cli."+format)
+	}
+
+	switch format {
+	case config.FormatYAML, config.FormatJSON, config.FormatTOML:
+	default:
+		return usageError(fmt.Errorf("unsupported format: %s (supported: yaml, json, toml)", format))
+	}
+	// Config files are read in the format of their extension
+	if ext := filepath.Ext(outputPath); ext != "" && config.FileFormat(outputPath) != format {
+		return usageError(fmt.Errorf("--format %s does not match the %s extension of %s", format, ext, outputPath))
 	}
 
 	// Expand tilde if present
@@ -126,7 +187,7 @@ func runGenerateConfig(cmd *cobra.Command, args []string) error {
 	// Check if file exists and handle overwrite
 	if _, err := os.Stat(outputPath); err == nil {
 		if !generateForce {
-			return fmt.Errorf("config file already exists at %s (use --force to overwrite)", outputPath)
+			return ioError(fmt.Errorf("config file already exists at %s (use --force to overwrite)", outputPath))
 		}
 	}
 
@@ -136,27 +197,24 @@ func runGenerateConfig(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
-	// Generate content based on format
-	var content string
-	switch generateFormat {
-	case "yaml":
-		content = config.GenerateExampleConfig()
-	case "json":
-		// For JSON, we would need to marshal the defaults struct
-		// For now, fall back to YAML format
-		content = config.GenerateExampleConfig()
-		fmt.Fprintf(os.Stderr, "Warning: JSON format not yet implemented, generating YAML instead\n")
-	default:
-		return fmt.Errorf("unsupported format: %s (supported: yaml, json)", generateFormat)
+	// Generate content based on format. JSON and TOML have no comments, so
+	// they hold the defaults alone.
+	content := []byte(config.GenerateExampleConfig())
+	if format != config.FormatYAML {
+		var err error
+		if content, err = config.Marshal(config.GetDefaults(), format); err != nil {
+			return fmt.Errorf("failed to encode configuration: %w", err)
+		}
 	}
 
 	// Write the file
-	if err := os.WriteFile(outputPath, []byte(content), 0600); err != nil {
+	if err := os.WriteFile(outputPath, content, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
-	fmt.Printf("✓ Generated configuration file: %s\n", outputPath)
-	fmt.Printf("Edit the file to customize your settings, then run 'assistant-cli config validate' to check for errors.\n")
+	statusf(os.Stdout, "%s Generated configuration file: %s\n", styleFor(os.Stdout).Success(), outputPath)
+	statusf(os.Stdout, "Edit the file to customize your settings, "+
+		"then run 'assistant-cli config validate' to check for errors.\n")
 
 	return nil
 }
@@ -168,39 +226,139 @@ func runValidateConfig(cmd *cobra.Command, args []string) error {
 		configFile = args[0]
 	}
 
+	switch validateFormat {
+	case "text", "json", "yaml":
+	default:
+		return usageError(fmt.Errorf("unsupported format: %s (supported: text, json, yaml)", validateFormat))
+	}
+
+	renderer := newRenderer(cmd)
+	out := cmd.OutOrStdout()
+	printText := !renderer.IsJSON() && validateFormat == "text"
+
 	// Create config manager and load configuration
 	manager := config.NewManager()
 	if configFile != "" {
 		manager.SetConfigFile(configFile)
 	}
-	if err := manager.Load(); err != nil {
-		fmt.Printf("❌ Configuration validation failed: %v\n", err)
-		return err
+
+	report := &configReport{ConfigFile: configFile}
+
+	// Load validates the configuration after reading it, so validation
+	// failures arrive wrapped in the load error
+	var validationErrors config.ValidationErrors
+	loadErr := manager.Load()
+	loaded := loadErr == nil || errors.As(loadErr, &validationErrors)
+	switch {
+	case len(validationErrors) > 0:
+		report.ConfigFile = manager.GetConfigFilePath()
+		report.Errors = validationErrors
+		report.fail("config", validationError(validationErrors))
+		report.Checks[0].Detail = fmt.Sprintf("%d validation error(s); see errors", len(validationErrors))
+	case loadErr != nil:
+		report.fail("config", validationError(loadErr))
+	default:
+		report.ConfigFile = manager.GetConfigFilePath()
+		report.pass("config", "static validation passed")
+	}
+
+	if loaded {
+		report.Warnings = manager.ValidationWarnings(isInteractive())
+		if len(report.Warnings) > 0 && strictMode {
+			report.fail("warnings", validationError(strictWarningsError(report.Warnings)))
+		}
+	}
+
+	if printText {
+		printStaticValidation(out, report)
+		if len(report.Warnings) > 0 {
+			fmt.Fprintf(out, "\n%s Warnings:\n", styleFor(out).Warning())
+			printWarnings(out, report.Warnings, "  - ")
+		}
+	}
+
+	// Online checks are still useful when static validation finds problems,
+	// but need a configuration that could be read
+	if validateOnline && loaded {
+		first := len(report.Checks)
+		ctx, cancel := context.WithTimeout(context.Background(), onlineCheckTimeout)
+		runOnlineChecks(ctx, manager.Get(), &report.checkList)
+		cancel()
+		if printText {
+			fmt.Fprintf(out, "\nOnline checks:\n")
+			printChecks(out, report.Checks[first:])
+		}
+	}
+
+	err := report.firstError()
+	report.Valid = err == nil
+
+	if !renderer.IsJSON() && validateFormat != "text" {
+		if encodeErr := writeConfigReport(out, report, validateFormat); encodeErr != nil {
+			return encodeErr
+		}
+	}
+
+	if err != nil {
+		return withResult(err, report)
+	}
+	return renderer.Result(report, nil)
+}
+
+// writeConfigReport prints the validation report as a plain JSON or YAML
+// document, without the --output-format envelope, for editor integrations
+func writeConfigReport(out io.Writer, report *configReport, format string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if format == "yaml" {
+		data, err = yaml.Marshal(report)
+	} else {
+		data, err = json.MarshalIndent(report, "", "  ")
+		data = append(data, '\n')
 	}
+	if err != nil {
+		return fmt.Errorf("failed to encode validation report: %w", err)
+	}
+
+	_, err = out.Write(data)
+	return err
+}
 
-	// Perform comprehensive validation
-	if err := manager.ValidateComprehensive(); err != nil {
-		fmt.Printf("❌ Configuration validation failed:\n")
+// printStaticValidation writes the human-readable result of static validation
+func printStaticValidation(out io.Writer, report *configReport) {
+	if check := report.Checks[0]; check.Status == checkFail && len(report.Errors) == 0 {
+		fmt.Fprintf(out, "%s Configuration validation failed: %s\n", styleFor(out).Failure(), check.Detail)
+		return
+	}
 
-		if validationErrors, ok := err.(config.ValidationErrors); ok {
-			for i, validationErr := range validationErrors {
-				fmt.Printf("  %d. %s\n", i+1, validationErr.Error())
+	if len(report.Errors) > 0 {
+		fmt.Fprintf(out, "%s Configuration validation failed:\n", styleFor(out).Failure())
+		for i, validationErr := range report.Errors {
+			fmt.Fprintf(out, "  %d. %s\n", i+1, validationErr.Error())
+			if validationErr.Suggestion != "" {
+				fmt.Fprintf(out, "     Suggestion: %s\n", validationErr.Suggestion)
 			}
-		} else {
-			fmt.Printf("  %v\n", err)
 		}
-		return err
+		return
 	}
 
-	configPath := manager.GetConfigFilePath()
-	if configPath == "" {
-		fmt.Printf("✓ Configuration validation passed (using defaults)\n")
-		fmt.Printf("Note: No configuration file found. Run 'assistant-cli config generate' to create one.\n")
+	if report.ConfigFile == "" {
+		fmt.Fprintf(out, "%s Configuration validation passed (using defaults)\n", styleFor(out).Success())
+		fmt.Fprintf(out, "Note: No configuration file found. Run 'assistant-cli config generate' to create one.\n")
 	} else {
-		fmt.Printf("✓ Configuration validation passed: %s\n", configPath)
+		fmt.Fprintf(out, "%s Configuration validation passed: %s\n", styleFor(out).Success(), report.ConfigFile)
 	}
+}
 
-	return nil
+// configShowResult is the machine-readable form of the effective configuration
+type configShowResult struct {
+	ConfigFile string         `json:"config_file,omitempty"`
+	Config     *config.Config `json:"config"`
+	// ConfigFiles and Sources are set with --show-sources
+	ConfigFiles []string                 `json:"config_files,omitempty"`
+	Sources     map[string]config.Source `json:"sources,omitempty"`
 }
 
 func runShowConfig(cmd *cobra.Command, args []string) error {
@@ -208,16 +366,23 @@ func runShowConfig(cmd *cobra.Command, args []string) error {
 	manager := GetConfig()
 
 	config := manager.Get()
+	renderer := newRenderer(cmd)
+
+	if renderer.IsJSON() {
+		return showConfigJSON(config, manager, renderer)
+	}
 
 	switch showFormat {
 	case "yaml":
 		return showConfigYAML(config, manager)
 	case "json":
-		return showConfigJSON(config, manager)
+		return showConfigJSON(config, manager, renderer)
+	case "toml":
+		return showConfigTOML(config)
 	case "table":
 		return showConfigTable(config, manager)
 	default:
-		return fmt.Errorf("unsupported format: %s (supported: yaml, json, table)", showFormat)
+		return usageError(fmt.Errorf("unsupported format: %s (supported: yaml, json, toml, table)", showFormat))
 	}
 }
 
@@ -229,56 +394,110 @@ func showConfigYAML(cfg *config.Config, manager *config.Manager) error {
 		maskSensitiveValues(&displayConfig)
 	}
 
-	// Print config file source info
+	// Print config file source info, and the source of each value as a
+	// trailing comment
+	source := func(string) string { return "" }
 	if showSources {
-		configPath := manager.GetConfigFilePath()
-		if configPath != "" {
-			fmt.Printf("# Configuration loaded from: %s\n", configPath)
-		} else {
+		files := manager.ConfigFiles()
+		switch len(files) {
+		case 0:
 			fmt.Printf("# Configuration: using defaults (no config file found)\n")
+		case 1:
+			fmt.Printf("# Configuration loaded from: %s\n", files[0])
+		default:
+			fmt.Printf("# Configuration merged from (later files override earlier ones):\n")
+			for _, file := range files {
+				fmt.Printf("#   %s\n", file)
+			}
 		}
 		fmt.Printf("# Environment variables with prefix: ASSISTANT_CLI_\n\n")
+		source = func(key string) string { return "  # " + getValueSource(manager, key) }
 	}
 
 	// For now, let's manually format key sections
 	fmt.Println("# Current Configuration")
 	fmt.Println("auth:")
-	fmt.Printf("  method: %q\n", displayConfig.Auth.Method)
-	fmt.Printf("  timeout: %q\n", displayConfig.Auth.Timeout.String())
-	fmt.Printf("  retry_attempts: %d\n", displayConfig.Auth.RetryAttempts)
+	fmt.Printf("  method: %q%s\n", displayConfig.Auth.Method, source("auth.method"))
+	fmt.Printf("  timeout: %q%s\n", displayConfig.Auth.Timeout.String(), source("auth.timeout"))
+	fmt.Printf("  retry_attempts: %d%s\n", displayConfig.Auth.RetryAttempts, source("auth.retry_attempts"))
 
 	fmt.Println("\ntts:")
-	fmt.Printf("  language: %q\n", displayConfig.TTS.Language)
+	fmt.Printf("  language: %q%s\n", displayConfig.TTS.Language, source("tts.language"))
 	if displayConfig.TTS.Voice != "" {
-		fmt.Printf("  voice: %q\n", displayConfig.TTS.Voice)
+		fmt.Printf("  voice: %q%s\n", displayConfig.TTS.Voice, source("tts.voice"))
 	}
-	fmt.Printf("  speaking_rate: %.2f\n", displayConfig.TTS.SpeakingRate)
-	fmt.Printf("  pitch: %.2f\n", displayConfig.TTS.Pitch)
-	fmt.Printf("  volume_gain: %.2f\n", displayConfig.TTS.VolumeGain)
-	fmt.Printf("  audio_encoding: %q\n", displayConfig.TTS.AudioEncoding)
+	fmt.Printf("  speaking_rate: %.2f%s\n", displayConfig.TTS.SpeakingRate, source("tts.speaking_rate"))
+	fmt.Printf("  pitch: %.2f%s\n", displayConfig.TTS.Pitch, source("tts.pitch"))
+	fmt.Printf("  volume_gain: %.2f%s\n", displayConfig.TTS.VolumeGain, source("tts.volume_gain"))
+	fmt.Printf("  audio_encoding: %q%s\n", displayConfig.TTS.AudioEncoding, source("tts.audio_encoding"))
 
 	fmt.Println("\noutput:")
-	fmt.Printf("  default_path: %q\n", displayConfig.Output.DefaultPath)
-	fmt.Printf("  format: %q\n", displayConfig.Output.Format)
-	fmt.Printf("  overwrite_mode: %q\n", displayConfig.Output.OverwriteMode)
-	fmt.Printf("  auto_filename: %t\n", displayConfig.Output.AutoFilename)
+	fmt.Printf("  default_path: %q%s\n", displayConfig.Output.DefaultPath, source("output.default_path"))
+	fmt.Printf("  format: %q%s\n", displayConfig.Output.Format, source("output.format"))
+	fmt.Printf("  overwrite_mode: %q%s\n", displayConfig.Output.OverwriteMode, source("output.overwrite_mode"))
+	fmt.Printf("  auto_filename: %t%s\n", displayConfig.Output.AutoFilename, source("output.auto_filename"))
 
 	fmt.Println("\nplayback:")
-	fmt.Printf("  auto_play: %t\n", displayConfig.Playback.AutoPlay)
-	fmt.Printf("  volume: %.2f\n", displayConfig.Playback.Volume)
+	fmt.Printf("  auto_play: %t%s\n", displayConfig.Playback.AutoPlay, source("playback.auto_play"))
+	fmt.Printf("  player: %q%s\n", displayConfig.Playback.Player, source("playback.player"))
+	fmt.Printf("  players: %q%s\n", displayConfig.Playback.Players, source("playback.players"))
+	fmt.Printf("  volume: %.2f%s\n", displayConfig.Playback.Volume, source("playback.volume"))
+	fmt.Printf("  speed: %.2f%s\n", displayConfig.Playback.Speed, source("playback.speed"))
 
 	return nil
 }
 
-func showConfigJSON(cfg *config.Config, manager *config.Manager) error {
-	fmt.Println("JSON format display not yet implemented")
-	fmt.Println("Please use --format yaml or --format table")
+func showConfigJSON(cfg *config.Config, manager *config.Manager, renderer *Renderer) error {
+	displayConfig := *cfg
+
+	if maskSensitive {
+		maskSensitiveValues(&displayConfig)
+	}
+
+	result := &configShowResult{
+		ConfigFile: manager.GetConfigFilePath(),
+		Config:     &displayConfig,
+	}
+
+	if renderer.IsJSON() {
+		if showSources {
+			result.ConfigFiles = manager.ConfigFiles()
+			result.Sources = make(map[string]config.Source)
+			for _, key := range config.Keys() {
+				result.Sources[key] = manager.Source(key)
+			}
+		}
+		return renderer.Result(result, nil)
+	}
+
+	// Plain --format json prints the configuration as a config file, without
+	// the envelope
+	data, err := config.Marshal(result.Config, config.FormatJSON)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	fmt.Print(string(data))
+
 	return nil
 }
 
-func showConfigTable(cfg *config.Config, manager *config.Manager) error {
-	_ = manager // Manager parameter not used in table format
+func showConfigTOML(cfg *config.Config) error {
+	displayConfig := *cfg
 
+	if maskSensitive {
+		maskSensitiveValues(&displayConfig)
+	}
+
+	data, err := config.Marshal(&displayConfig, config.FormatTOML)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	fmt.Print(string(data))
+
+	return nil
+}
+
+func showConfigTable(cfg *config.Config, manager *config.Manager) error {
 	displayConfig := *cfg
 
 	if maskSensitive {
@@ -289,37 +508,46 @@ func showConfigTable(cfg *config.Config, manager *config.Manager) error {
 	fmt.Printf("%-30s %-20s %s\n", "-------", "-----", "------")
 
 	// Auth settings
-	fmt.Printf("%-30s %-20s %s\n", "auth.method", displayConfig.Auth.Method, getValueSource("auth.method"))
-	fmt.Printf("%-30s %-20s %s\n", "auth.timeout", displayConfig.Auth.Timeout.String(), getValueSource("auth.timeout"))
+	fmt.Printf("%-30s %-20s %s\n", "auth.method", displayConfig.Auth.Method, getValueSource(manager, "auth.method"))
+	fmt.Printf("%-30s %-20s %s\n", "auth.timeout", displayConfig.Auth.Timeout.String(),
+		getValueSource(manager, "auth.timeout"))
 	fmt.Printf("%-30s %-20d %s\n", "auth.retry_attempts", displayConfig.Auth.RetryAttempts,
-		getValueSource("auth.retry_attempts"))
+		getValueSource(manager, "auth.retry_attempts"))
 
 	// TTS settings
-	fmt.Printf("%-30s %-20s %s\n", "tts.language", displayConfig.TTS.Language, getValueSource("tts.language"))
+	fmt.Printf("%-30s %-20s %s\n", "tts.language", displayConfig.TTS.Language, getValueSource(manager, "tts.language"))
 	if displayConfig.TTS.Voice != "" {
-		fmt.Printf("%-30s %-20s %s\n", "tts.voice", displayConfig.TTS.Voice, getValueSource("tts.voice"))
+		fmt.Printf("%-30s %-20s %s\n", "tts.voice", displayConfig.TTS.Voice, getValueSource(manager, "tts.voice"))
 	}
 	fmt.Printf("%-30s %-20.2f %s\n", "tts.speaking_rate", displayConfig.TTS.SpeakingRate,
-		getValueSource("tts.speaking_rate"))
-	fmt.Printf("%-30s %-20.2f %s\n", "tts.pitch", displayConfig.TTS.Pitch, getValueSource("tts.pitch"))
+		getValueSource(manager, "tts.speaking_rate"))
+	fmt.Printf("%-30s %-20.2f %s\n", "tts.pitch", displayConfig.TTS.Pitch, getValueSource(manager, "tts.pitch"))
 	fmt.Printf("%-30s %-20.2f %s\n", "tts.volume_gain", displayConfig.TTS.VolumeGain,
-		getValueSource("tts.volume_gain"))
+		getValueSource(manager, "tts.volume_gain"))
 	fmt.Printf("%-30s %-20s %s\n", "tts.audio_encoding", displayConfig.TTS.AudioEncoding,
-		getValueSource("tts.audio_encoding"))
+		getValueSource(manager, "tts.audio_encoding"))
 
 	// Output settings
 	fmt.Printf("%-30s %-20s %s\n", "output.default_path", displayConfig.Output.DefaultPath,
-		getValueSource("output.default_path"))
-	fmt.Printf("%-30s %-20s %s\n", "output.format", displayConfig.Output.Format, getValueSource("output.format"))
+		getValueSource(manager, "output.default_path"))
+	fmt.Printf("%-30s %-20s %s\n", "output.format", displayConfig.Output.Format,
+		getValueSource(manager, "output.format"))
 	fmt.Printf("%-30s %-20s %s\n", "output.overwrite_mode", displayConfig.Output.OverwriteMode,
-		getValueSource("output.overwrite_mode"))
+		getValueSource(manager, "output.overwrite_mode"))
 	fmt.Printf("%-30s %-20t %s\n", "output.auto_filename", displayConfig.Output.AutoFilename,
-		getValueSource("output.auto_filename"))
+		getValueSource(manager, "output.auto_filename"))
 
 	// Playback settings
 	fmt.Printf("%-30s %-20t %s\n", "playback.auto_play", displayConfig.Playback.AutoPlay,
-		getValueSource("playback.auto_play"))
-	fmt.Printf("%-30s %-20.2f %s\n", "playback.volume", displayConfig.Playback.Volume, getValueSource("playback.volume"))
+		getValueSource(manager, "playback.auto_play"))
+	fmt.Printf("%-30s %-20s %s\n", "playback.player", displayConfig.Playback.Player,
+		getValueSource(manager, "playback.player"))
+	fmt.Printf("%-30s %-20s %s\n", "playback.players", strings.Join(displayConfig.Playback.Players, ","),
+		getValueSource(manager, "playback.players"))
+	fmt.Printf("%-30s %-20.2f %s\n", "playback.volume", displayConfig.Playback.Volume,
+		getValueSource(manager, "playback.volume"))
+	fmt.Printf("%-30s %-20.2f %s\n", "playback.speed", displayConfig.Playback.Speed,
+		getValueSource(manager, "playback.speed"))
 
 	return nil
 }
@@ -334,12 +562,6 @@ func maskSensitiveValues(cfg *config.Config) {
 	}
 }
 
-func getValueSource(key string) string {
-	// This is a simplified source detection - in a real implementation,
-	// we would track where each value came from during loading
-	if os.Getenv("ASSISTANT_CLI_"+strings.ToUpper(strings.ReplaceAll(key, ".", "_"))) != "" {
-		return "environment"
-	}
-	// For now, assume others are from config file or defaults
-	return "config/default"
+func getValueSource(manager *config.Manager, key string) string {
+	return manager.Source(key).String()
 }
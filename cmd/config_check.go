@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+)
+
+// onlineCheckTimeout bounds the total time spent on online configuration checks
+const onlineCheckTimeout = time.Minute
+
+// Check statuses reported by config validate
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// configCheck is the outcome of a single configuration check
+type configCheck struct {
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status" yaml:"status"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	err    error
+}
+
+// checkList collects check outcomes in the order they ran
+type checkList struct {
+	Checks []configCheck `json:"checks" yaml:"checks"`
+}
+
+func (r *checkList) pass(name, detail string) {
+	r.Checks = append(r.Checks, configCheck{Name: name, Status: checkPass, Detail: detail})
+}
+
+func (r *checkList) fail(name string, err error) {
+	r.Checks = append(r.Checks, configCheck{Name: name, Status: checkFail, Detail: err.Error(), err: err})
+}
+
+func (r *checkList) skip(name, detail string) {
+	r.Checks = append(r.Checks, configCheck{Name: name, Status: checkSkip, Detail: detail})
+}
+
+// configReport is the consolidated result of config validate
+type configReport struct {
+	ConfigFile string                  `json:"config_file,omitempty" yaml:"config_file,omitempty"`
+	Valid      bool                    `json:"valid" yaml:"valid"`
+	Errors     config.ValidationErrors `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Warnings   config.ValidationErrors `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	checkList  `yaml:",inline"`
+}
+
+// firstError returns the error of the first failed check
+func (r *checkList) firstError() error {
+	for _, check := range r.Checks {
+		if check.Status == checkFail {
+			return check.err
+		}
+	}
+	return nil
+}
+
+// runOnlineChecks verifies that the configured provider is usable, including
+// credentials for Google Cloud, and that the configured voice exists. Checks
+// after a failure are skipped. The deadline of ctx bounds the checks.
+func runOnlineChecks(ctx context.Context, cfg *config.Config, report *checkList) {
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		report.fail("provider", validationError(err))
+		report.skip("api", "unknown provider")
+		report.skip("voice", "unknown provider")
+		return
+	}
+
+	var provider tts.Provider
+	apiKey := false
+	if providerName == tts.ProviderGoogle {
+		ttsConfig := createTTSConfig(cfg.TTS)
+		authConfig := convertToAuthConfig(cfg.Auth)
+		authConfig.Network = convertToNetworkConfig(cfg.Network)
+		authConfig.ClientOptions = tts.ConnectionOptions(ttsConfig)
+		authManager := auth.NewAuthManager(authConfig)
+		if err := authManager.Validate(ctx); err != nil {
+			report.fail("auth", authError(err))
+			report.skip("api", "authentication failed")
+			report.skip("voice", "authentication failed")
+			return
+		}
+		report.pass("auth", fmt.Sprintf("authenticated with %s", authManager.GetActiveMethod()))
+		apiKey = authManager.GetActiveMethod() == auth.AuthMethodAPIKey
+
+		client, err := createTTSClient(ctx, authManager, ttsConfig)
+		if err != nil {
+			report.fail("api", err)
+			report.skip("voice", "API unreachable")
+			return
+		}
+		provider = client
+	} else {
+		report.skip("auth", fmt.Sprintf("not required by the %s provider", providerName))
+
+		provider, err = createProvider(ctx, providerName, cfg.Auth, createTTSConfig(cfg.TTS))
+		if err != nil {
+			report.fail("api", err)
+			report.skip("voice", "provider unavailable")
+			return
+		}
+	}
+	defer provider.Close()
+
+	voices, err := provider.ListVoices(ctx, "")
+	if err != nil {
+		// Keys are only checked by the API, so explain why it rejected one
+		if apiKey {
+			err = auth.ExplainAPIKeyError(err)
+		}
+		report.fail("api", err)
+		report.skip("voice", "API unreachable")
+		return
+	}
+	report.pass("api", fmt.Sprintf("%d %s voices available", len(voices), providerName))
+
+	checkConfiguredVoice(cfg.TTS, voices, report)
+}
+
+// checkConfiguredVoice verifies the configured voice exists and supports the
+// configured language
+func checkConfiguredVoice(ttsCfg config.TTSConfig, voices []*texttospeechpb.Voice, report *checkList) {
+	if ttsCfg.Voice == "" {
+		report.skip("voice", "no voice configured; the API picks one for "+ttsCfg.Language)
+		return
+	}
+
+	for _, v := range voices {
+		if v.GetName() != ttsCfg.Voice {
+			continue
+		}
+		for _, code := range v.GetLanguageCodes() {
+			if strings.EqualFold(code, ttsCfg.Language) {
+				report.pass("voice", fmt.Sprintf("%s supports %s", ttsCfg.Voice, ttsCfg.Language))
+				return
+			}
+		}
+		report.fail("voice", validationError(fmt.Errorf("voice %s does not support language %s (supports %s)",
+			ttsCfg.Voice, ttsCfg.Language, strings.Join(v.GetLanguageCodes(), ", "))))
+		return
+	}
+
+	report.fail("voice", validationError(fmt.Errorf("voice %s not found", ttsCfg.Voice)))
+}
+
+// printChecks writes a human-readable summary of report checks
+func printChecks(w io.Writer, checks []configCheck) {
+	style := styleFor(w)
+	for _, check := range checks {
+		symbol := style.Success()
+		switch check.Status {
+		case checkFail:
+			symbol = style.Failure()
+		case checkSkip:
+			symbol = "-"
+		}
+		fmt.Fprintf(w, "  %s %s: %s\n", symbol, check.Name, check.Detail)
+	}
+}
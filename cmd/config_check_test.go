@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConfiguredVoice(t *testing.T) {
+	voices := []*texttospeechpb.Voice{
+		{Name: "en-US-Wavenet-D", LanguageCodes: []string{"en-US"}},
+		{Name: "de-DE-Wavenet-A", LanguageCodes: []string{"de-DE"}},
+	}
+
+	tests := []struct {
+		name       string
+		voice      string
+		language   string
+		wantStatus string
+		wantDetail string
+	}{
+		{"no voice configured", "", "en-US", checkSkip, "no voice configured"},
+		{"voice found", "en-US-Wavenet-D", "en-US", checkPass, "supports en-US"},
+		{"language case insensitive", "en-US-Wavenet-D", "en-us", checkPass, "supports"},
+		{"wrong language", "de-DE-Wavenet-A", "en-US", checkFail, "does not support language en-US"},
+		{"unknown voice", "en-US-Nonexistent", "en-US", checkFail, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &checkList{}
+			checkConfiguredVoice(config.TTSConfig{Voice: tt.voice, Language: tt.language}, voices, report)
+
+			require.Len(t, report.Checks, 1)
+			assert.Equal(t, "voice", report.Checks[0].Name)
+			assert.Equal(t, tt.wantStatus, report.Checks[0].Status)
+			assert.Contains(t, report.Checks[0].Detail, tt.wantDetail)
+
+			if tt.wantStatus == checkFail {
+				assert.Equal(t, ExitValidation, ExitCode(report.firstError()))
+			} else {
+				assert.NoError(t, report.firstError())
+			}
+		})
+	}
+}
+
+func TestConfigReportErrorEnvelope(t *testing.T) {
+	report := &configReport{}
+	report.pass("config", "static validation passed")
+	report.fail("auth", authError(errors.New("no credentials")))
+	report.skip("api", "authentication failed")
+
+	buf := new(bytes.Buffer)
+	renderer := &Renderer{format: outputFormatJSON, command: "config validate", stdout: buf}
+	renderer.Error(withResult(report.firstError(), report))
+
+	var result struct {
+		Success bool         `json:"success"`
+		Data    configReport `json:"data"`
+		Error   ErrorInfo    `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.False(t, result.Success)
+	assert.Equal(t, ExitAuth, result.Error.ExitCode)
+	assert.Equal(t, "no credentials", result.Error.Message)
+	assert.False(t, result.Data.Valid)
+	require.Len(t, result.Data.Checks, 3)
+	assert.Equal(t, checkFail, result.Data.Checks[1].Status)
+	assert.Equal(t, checkSkip, result.Data.Checks[2].Status)
+}
+
+func TestWithResultNil(t *testing.T) {
+	assert.NoError(t, withResult(nil, "data"))
+}
+
+func TestPrintChecks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	printChecks(buf, []configCheck{
+		{Name: "auth", Status: checkPass, Detail: "authenticated with api_key"},
+		{Name: "api", Status: checkFail, Detail: "unavailable"},
+		{Name: "voice", Status: checkSkip, Detail: "API unreachable"},
+	})
+
+	output := buf.String()
+	assert.Contains(t, output, "✓ auth: authenticated with api_key")
+	assert.Contains(t, output, "❌ api: unavailable")
+	assert.Contains(t, output, "- voice: API unreachable")
+}
+
+func TestConfigValidateFormat(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { validateFormat = "text" })
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`tts:
+  language: "en-US"
+  audio_encoding: "mp3"
+  speaking_rate: 9.0
+`), 0600))
+
+	runValidate := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"config", "validate"}, args...))
+		// The help flag only exists once cobra has added it, so a failure is fine
+		_ = validateConfigCmd.Flags().Set("help", "false")
+		err := rootCmd.Execute()
+		return buf.String(), err
+	}
+
+	t.Run("json", func(t *testing.T) {
+		output, err := runValidate(configPath, "--format", "json")
+		require.Error(t, err)
+		assert.Equal(t, ExitValidation, ExitCode(err))
+
+		var report struct {
+			Valid  bool `json:"valid"`
+			Errors []struct {
+				Field      string      `json:"field"`
+				Value      interface{} `json:"value"`
+				Constraint string      `json:"constraint"`
+				Suggestion string      `json:"suggestion"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(output), &report))
+		assert.False(t, report.Valid)
+		require.Len(t, report.Errors, 2)
+		assert.Equal(t, "tts.speaking_rate", report.Errors[0].Field)
+		assert.Equal(t, 9.0, report.Errors[0].Value)
+		assert.Equal(t, "between 0.25 and 4.0", report.Errors[0].Constraint)
+		assert.Equal(t, "tts.audio_encoding", report.Errors[1].Field)
+		assert.Equal(t, `did you mean "MP3"?`, report.Errors[1].Suggestion)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		output, err := runValidate(configPath, "--format", "yaml")
+		require.Error(t, err)
+		assert.Contains(t, output, "valid: false")
+		assert.Contains(t, output, "field: tts.audio_encoding")
+		assert.Contains(t, output, "constraint: 'one of: MP3, LINEAR16, OGG_OPUS, MULAW, ALAW, PCM, AAC, FLAC, OPUS, WEBM'")
+	})
+
+	t.Run("text shows suggestions", func(t *testing.T) {
+		output, err := runValidate(configPath, "--format", "text")
+		require.Error(t, err)
+		assert.Contains(t, output, "Configuration validation failed")
+		assert.Contains(t, output, `Suggestion: did you mean "MP3"?`)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := runValidate(configPath, "--format", "xml")
+		require.Error(t, err)
+		assert.Equal(t, ExitUsage, ExitCode(err))
+	})
+}
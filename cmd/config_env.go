@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var envConfigCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List the environment variables that configure the CLI",
+	Long: `List every ASSISTANT_CLI_* environment variable, the setting it overrides,
+and its current value. Environment variables take precedence over config files.
+
+The list is derived from the configuration settings, so it always matches
+what the CLI reads. Each variable is the setting's key in upper case with
+dots replaced by underscores, e.g. ASSISTANT_CLI_TTS_VOICE for tts.voice;
+a few credentials also keep their shorter names such as ASSISTANT_CLI_API_KEY.
+Lists are given comma-separated and durations like 30s. Secrets are masked
+unless --mask-sensitive=false is given.
+
+Examples:
+  assistant-cli config env
+  assistant-cli config env --set-only
+  assistant-cli --output-format json config env`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.NoArgs(cmd, args); err != nil {
+			return usageError(err)
+		}
+		return nil
+	},
+	RunE: runEnvConfig,
+}
+
+var (
+	envMaskSensitive bool
+	envSetOnly       bool
+)
+
+// configEnvResult is the machine-readable result of config env
+type configEnvResult struct {
+	Variables []configEnvVariable `json:"variables"`
+	// Unsupported lists the settings that cannot be set from the environment
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// configEnvVariable is one environment variable and its current value
+type configEnvVariable struct {
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+	Set       bool   `json:"set"`
+	Value     string `json:"value,omitempty"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+func runEnvConfig(cmd *cobra.Command, _ []string) error {
+	result := &configEnvResult{Variables: []configEnvVariable{}}
+	for _, binding := range config.EnvBindings() {
+		if !binding.Supported {
+			result.Unsupported = append(result.Unsupported, binding.Key)
+			continue
+		}
+		for _, name := range binding.Variables {
+			variable := configEnvVariable{Name: name, Key: binding.Key, Sensitive: binding.Sensitive}
+			variable.Value, variable.Set = os.LookupEnv(name)
+			if variable.Set && variable.Sensitive && envMaskSensitive {
+				variable.Value = "***masked***"
+			}
+			if variable.Set || !envSetOnly {
+				result.Variables = append(result.Variables, variable)
+			}
+		}
+	}
+
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		printConfigEnv(w, result)
+	})
+}
+
+// printConfigEnv writes the environment variables as a table
+func printConfigEnv(w io.Writer, result *configEnvResult) {
+	if len(result.Variables) == 0 {
+		fmt.Fprintln(w, "No ASSISTANT_CLI_* environment variables are set.")
+	} else {
+		width := len("Variable")
+		for _, variable := range result.Variables {
+			width = max(width, len(variable.Name))
+		}
+
+		fmt.Fprintf(w, "%-*s %-4s %-30s %s\n", width, "Variable", "Set", "Setting", "Value")
+		fmt.Fprintf(w, "%-*s %-4s %-30s %s\n", width, "--------", "---", "-------", "-----")
+		for _, variable := range result.Variables {
+			set := "no"
+			if variable.Set {
+				set = "yes"
+			}
+			line := fmt.Sprintf("%-*s %-4s %-30s %s", width, variable.Name, set, variable.Key, variable.Value)
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+		}
+	}
+
+	if len(result.Unsupported) > 0 {
+		fmt.Fprintf(w, "\nNot settable from the environment (use config set): %s\n",
+			strings.Join(result.Unsupported, ", "))
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASSISTANT_CLI_TTS_VOICE", "en-GB-Neural2-A")
+	t.Setenv("ASSISTANT_CLI_API_KEY", "AIzaSyDummyKeyForTesting1234567890abc")
+
+	stdout, err := runConfigKeyCommand(t, "env")
+	require.NoError(t, err)
+	assert.Regexp(t, `ASSISTANT_CLI_TTS_VOICE +yes +tts\.voice +en-GB-Neural2-A\n`, stdout)
+	assert.Regexp(t, `ASSISTANT_CLI_API_KEY +yes +auth\.api_key +\*\*\*masked\*\*\*\n`, stdout)
+	assert.Regexp(t, `ASSISTANT_CLI_TTS_PITCH +no +tts\.pitch\n`, stdout)
+	assert.Contains(t, stdout, "Not settable from the environment (use config set): input.emoji_names, input.speakers, playback.format_players")
+
+	stdout, err = runConfigKeyCommand(t, "env", "--set-only", "--mask-sensitive=false")
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "ASSISTANT_CLI_TTS_PITCH")
+	assert.Contains(t, stdout, "AIzaSyDummyKeyForTesting1234567890abc")
+}
+
+func TestConfigEnv_JSON(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASSISTANT_CLI_PLAYBACK_PLAYER", "mpv")
+
+	stdout, err := runConfigKeyCommand(t, "env", "--output-format", "json")
+	require.NoError(t, err)
+
+	var envelope struct {
+		Data configEnvResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &envelope))
+
+	// Every setting is listed, either with its variables or as unsupported
+	keys := make(map[string]bool)
+	for _, variable := range envelope.Data.Variables {
+		keys[variable.Key] = true
+		if variable.Name == "ASSISTANT_CLI_PLAYBACK_PLAYER" {
+			assert.True(t, variable.Set)
+			assert.Equal(t, "mpv", variable.Value)
+		}
+	}
+	for _, key := range envelope.Data.Unsupported {
+		keys[key] = true
+	}
+	assert.Len(t, keys, len(config.Keys()))
+}
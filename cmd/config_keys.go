@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var getConfigCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print the value of a configuration setting",
+	Long: `Print the effective value of one configuration setting, such as tts.voice,
+after applying the config file, environment variables, and defaults.
+
+Lists print comma-separated and maps as comma-separated key=value pairs, the
+same way config set accepts them. Secrets are masked unless
+--mask-sensitive=false is given.
+
+Examples:
+  assistant-cli config get tts.voice
+  assistant-cli config get playback.players
+  assistant-cli --output-format json config get tts.speaking_rate`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return usageError(err)
+		}
+		return nil
+	},
+	RunE: runGetConfig,
+}
+
+var setConfigCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Change a configuration setting in the config file",
+	Long: `Change one configuration setting in the config file, validating the new value
+with the rest of the configuration before writing. Comments and the other
+settings in the file are kept.
+
+The config file given with --config is edited, otherwise the one that was
+loaded, otherwise ~/.assistant-cli.yaml is created.
+
+Lists are given comma-separated and maps as comma-separated key=value pairs;
+an empty value clears them.
+
+Examples:
+  assistant-cli config set tts.voice en-US-Neural2-F
+  assistant-cli config set tts.speaking_rate 1.25
+  assistant-cli config set playback.players mpv,builtin
+  assistant-cli config set playback.format_players ogg=mpv,wav=aplay`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return usageError(err)
+		}
+		return nil
+	},
+	RunE: runSetConfig,
+}
+
+var getMaskSensitive bool
+
+// configValueResult is the machine-readable result of config get and set
+type configValueResult struct {
+	File  string      `json:"file,omitempty"`
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+func runGetConfig(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	cfg := *GetConfig().Get()
+	if getMaskSensitive {
+		maskSensitiveValues(&cfg)
+	}
+
+	value, err := config.GetValue(&cfg, key)
+	if err != nil {
+		return usageError(err)
+	}
+
+	result := &configValueResult{Key: key, Value: value}
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		fmt.Fprintln(w, config.FormatValue(value))
+	})
+}
+
+func runSetConfig(cmd *cobra.Command, args []string) error {
+	key, raw := args[0], args[1]
+	manager := GetConfig()
+
+	path, err := configFileToEdit(manager)
+	if err != nil {
+		return ioError(err)
+	}
+
+	value, err := manager.Set(path, key, raw)
+	if err != nil {
+		var keyErr *config.KeyError
+		if errors.As(err, &keyErr) {
+			return usageError(err)
+		}
+		return err
+	}
+
+	result := &configValueResult{File: path, Key: key, Value: value}
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		statusf(w, "%s Set %s = %s in %s\n", styleFor(w).Success(), key, config.FormatValue(value), path)
+		if env := config.EnvVar(key); os.Getenv(env) != "" {
+			fmt.Fprintf(w, "Note: %s is set and overrides the config file\n", env)
+		}
+	})
+}
+
+// configFileToEdit returns the --config file, the loaded config file that
+// takes precedence (a project-local one over the user-level one), or the
+// default location
+func configFileToEdit(manager *config.Manager) (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	if path := manager.GetConfigFilePath(); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".assistant-cli.yaml"), nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runConfigKeyCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		getMaskSensitive = true
+		envMaskSensitive = true
+		envSetOnly = false
+		quietFlag, verboseFlag, verbosity = false, false, verbosityNormal
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"config"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestConfigSetAndGet(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTestConfig(t, "# Voice settings\ntts:\n  # Preferred voice\n  voice: \"en-US-Wavenet-D\"\n")
+
+	stdout, err := runConfigKeyCommand(t, "set", "tts.voice", "en-US-Neural2-F", "--config", path)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "✓ Set tts.voice = en-US-Neural2-F in "+path)
+
+	_, err = runConfigKeyCommand(t, "set", "playback.players", "mpv, builtin", "--config", path)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Preferred voice")
+	assert.Contains(t, string(data), "voice: en-US-Neural2-F")
+
+	stdout, err = runConfigKeyCommand(t, "get", "tts.voice", "--config", path)
+	require.NoError(t, err)
+	assert.Equal(t, "en-US-Neural2-F\n", stdout)
+
+	stdout, err = runConfigKeyCommand(t, "get", "playback.players", "--config", path, "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data struct {
+			Key   string   `json:"key"`
+			Value []string `json:"value"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, []string{"mpv", "builtin"}, result.Data.Value)
+}
+
+func TestConfigSet_CreatesDefaultFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	_, err := runConfigKeyCommand(t, "set", "tts.speaking_rate", "1.25")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(home, ".assistant-cli.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "tts:\n  speaking_rate: 1.25\n", string(data))
+}
+
+func TestConfigSet_Errors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTestConfig(t, "tts:\n  speaking_rate: 1.5\n")
+
+	_, err := runConfigKeyCommand(t, "set", "tts.voise", "x", "--config", path)
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.Contains(t, err.Error(), `did you mean "tts.voice"?`)
+
+	_, err = runConfigKeyCommand(t, "set", "tts.speaking_rate", "9", "--config", path)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+
+	_, err = runConfigKeyCommand(t, "set", "tts.speaking_rate", "fast", "--config", path)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.Contains(t, err.Error(), "must be a number")
+
+	// Rejected values leave the file untouched
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "tts:\n  speaking_rate: 1.5\n", string(data))
+
+	_, err = runConfigKeyCommand(t, "get", "tts", "--config", path)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
+
+func TestConfigGet_MasksSecrets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTestConfig(t, "auth:\n  method: \"apikey\"\n  api_key: \"AIzaSyDummyKeyForTesting1234567890abc\"\n")
+
+	stdout, err := runConfigKeyCommand(t, "get", "auth.api_key", "--config", path)
+	require.NoError(t, err)
+	assert.Equal(t, "***masked***\n", stdout)
+
+	stdout, err = runConfigKeyCommand(t, "get", "auth.api_key", "--config", path, "--mask-sensitive=false")
+	require.NoError(t, err)
+	assert.Equal(t, "AIzaSyDummyKeyForTesting1234567890abc\n", stdout)
+}
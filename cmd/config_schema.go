@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var schemaConfigCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the config file",
+	Long: `Print a JSON Schema describing the config file: every setting with its type,
+default, allowed values, and range. The schema is generated from the same
+definitions the CLI validates against, so it always matches.
+
+Editors use the schema to complete and check config files. For YAML files
+with the YAML language server (e.g. in VS Code), save the schema and add a
+comment to the top of the file:
+
+  # yaml-language-server: $schema=/path/to/assistant-cli.schema.json
+
+Examples:
+  assistant-cli config schema > ~/.assistant-cli.schema.json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.NoArgs(cmd, args); err != nil {
+			return usageError(err)
+		}
+		return nil
+	},
+	RunE: runSchemaConfig,
+}
+
+func runSchemaConfig(cmd *cobra.Command, _ []string) error {
+	schema := config.Schema()
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	return newRenderer(cmd).Result(schema, func(w io.Writer) {
+		fmt.Fprintln(w, string(data))
+	})
+}
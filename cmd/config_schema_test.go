@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	stdout, err := runConfigKeyCommand(t, "schema")
+	require.NoError(t, err)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &schema))
+	assert.Equal(t, config.SchemaURI, schema["$schema"])
+	assert.Contains(t, schema["properties"], "tts")
+}
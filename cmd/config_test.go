@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/mikefarmer/assistant-cli/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -147,6 +148,12 @@ invalid_yaml: [
 			wantErr:    false,
 			wantOutput: "Validate the configuration file",
 		},
+		{
+			name:       "validate help lists online flag",
+			args:       []string{"config", "validate", "--help"},
+			wantErr:    false,
+			wantOutput: "--online",
+		},
 		// Note: These tests are removed because config validate requires actual implementation
 		// and the current tests would fail without proper config package integration
 	}
@@ -244,62 +251,67 @@ func TestRunGenerateConfigFunction(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "already exists")
 	})
-}
 
-func TestMaskSensitiveValues(t *testing.T) {
-	// This would require importing the config package and creating a config struct
-	// For now, we'll test that the mask function exists and doesn't panic
-	t.Run("mask function exists", func(t *testing.T) {
-		// Test that the mask function can be called without panicking
-		// In a real test, we would create a config struct and test masking
-		assert.NotPanics(t, func() {
-			// maskSensitiveValues would be called here with a real config
-			// For now, just test that getValueSource works
-			source := getValueSource("test.key")
-			assert.Contains(t, []string{"environment", "config/default"}, source)
+	t.Run("generate picks the format from the extension", func(t *testing.T) {
+		for _, format := range []string{"json", "toml"} {
+			outputPath := filepath.Join(tempDir, "config."+format)
+			require.NoError(t, runGenerateConfig(generateConfigCmd, []string{outputPath}))
+
+			manager := config.NewManager()
+			manager.SetConfigFile(outputPath)
+			require.NoError(t, manager.Load(), format)
+			assert.Equal(t, config.GetDefaults().TTS, manager.Get().TTS, format)
+			assert.Equal(t, config.GetDefaults().Auth, manager.Get().Auth, format)
+		}
+	})
+
+	t.Run("generate rejects a format that does not match the extension", func(t *testing.T) {
+		flag := generateConfigCmd.Flags().Lookup("format")
+		require.NoError(t, flag.Value.Set("toml"))
+		flag.Changed = true
+		t.Cleanup(func() {
+			_ = flag.Value.Set("yaml")
+			flag.Changed = false
 		})
+
+		err := runGenerateConfig(generateConfigCmd, []string{filepath.Join(tempDir, "config.json")})
+		assert.Equal(t, ExitUsage, ExitCode(err))
+		assert.Contains(t, err.Error(), "does not match")
 	})
 }
 
+func TestMaskSensitiveValues(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.Auth.APIKey = "AIzaSyExampleKeyThatIsLongEnough123456"
+	cfg.Auth.OAuth2ClientSecret = "secret"
+
+	maskSensitiveValues(cfg)
+	assert.Equal(t, "***masked***", cfg.Auth.APIKey)
+	assert.Equal(t, "***masked***", cfg.Auth.OAuth2ClientSecret)
+}
+
 func TestGetValueSource(t *testing.T) {
+	t.Setenv("ASSISTANT_CLI_AUTH_METHOD", "apikey")
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("tts:\n  voice: \"en-US-Neural2-F\"\n"), 0600))
+
+	manager := config.NewManager()
+	manager.SetConfigFile(configFile)
+	require.NoError(t, manager.Load())
+
 	tests := []struct {
 		name     string
 		key      string
-		envVar   string
-		envValue string
 		expected string
 	}{
-		{
-			name:     "environment source",
-			key:      "auth.method",
-			envVar:   "ASSISTANT_CLI_AUTH_METHOD",
-			envValue: "apikey",
-			expected: "environment",
-		},
-		{
-			name:     "config/default source",
-			key:      "tts.language",
-			expected: "config/default",
-		},
+		{name: "environment source", key: "auth.method", expected: "env ASSISTANT_CLI_AUTH_METHOD"},
+		{name: "config file source", key: "tts.voice", expected: configFile},
+		{name: "default source", key: "tts.language", expected: "default"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Set environment variable if specified
-			if tt.envVar != "" && tt.envValue != "" {
-				originalValue := os.Getenv(tt.envVar)
-				os.Setenv(tt.envVar, tt.envValue)
-				defer func() {
-					if originalValue != "" {
-						os.Setenv(tt.envVar, originalValue)
-					} else {
-						os.Unsetenv(tt.envVar)
-					}
-				}()
-			}
-
-			source := getValueSource(tt.key)
-			assert.Equal(t, tt.expected, source)
+			assert.Equal(t, tt.expected, getValueSource(manager, tt.key))
 		})
 	}
 }
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package cmd
+
+import "os"
+
+// legacyConsole reports whether f is a legacy Windows console, never the
+// case on this platform
+func legacyConsole(_ *os.File) bool {
+	return false
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// legacyConsole reports whether f is a Windows console without virtual
+// terminal support, which shows ANSI colors and Unicode symbols as garbage.
+// Consoles that support it have it enabled.
+func legacyConsole(f *os.File) bool {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// Not a console, e.g. a terminal emulator's pipe
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return false
+	}
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) != nil
+}
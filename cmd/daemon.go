@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils/suggest"
+	"github.com/spf13/cobra"
+)
+
+var daemonSocket string
+
+// NewDaemonCmd creates the daemon command
+func NewDaemonCmd() *cobra.Command {
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Serve synthesis requests from a warm process over a local socket",
+		Long: `Run in the foreground, keeping the TTS client, credentials, and voice catalog
+loaded, and synthesize requests sent over a local socket by 'assistant-cli say'.
+Each request then skips process startup, configuration loading, and
+connecting to the API, which makes repeated synthesis from editors and scripts
+noticeably faster.
+
+The socket is $XDG_RUNTIME_DIR/assistant-cli.sock, or daemon.sock in a private
+assistant-cli-<uid> directory in the temporary directory, and only the current
+user can connect to it.
+Changes to the configuration file, or SIGHUP, reload the configuration for
+the requests and jobs that follow; changes to tts.provider and auth need a
+restart. Stop the daemon with Ctrl+C or SIGTERM.
+
+The daemon also runs the recurring jobs in scheduler.jobs, such as a morning
+briefing read from a URL or a command's output, on cron-like schedules. Each
+run is recorded in the job history, which 'assistant-cli daemon jobs' shows,
+and failed runs are posted to the job's notify_url or output.notify_webhook.
+
+Examples:
+  assistant-cli daemon &
+  assistant-cli say "Build finished"
+  assistant-cli daemon --socket /tmp/tts.sock --verbose`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runDaemon,
+	}
+
+	addSocketFlag(daemonCmd)
+	daemonCmd.AddCommand(NewDaemonJobsCmd())
+
+	return daemonCmd
+}
+
+// addSocketFlag adds --socket to the daemon and its clients
+func addSocketFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&daemonSocket, "socket", "",
+		"Socket of the daemon (default: "+daemon.DefaultSocketPath()+")")
+}
+
+// socketPath returns the --socket path, or the default socket
+func socketPath() string {
+	if daemonSocket == "" {
+		return daemon.DefaultSocketPath()
+	}
+	return expandHomeDirs([]string{daemonSocket})[0]
+}
+
+// dialDaemon connects to the daemon on the --socket path
+func dialDaemon(ctx context.Context) (*daemon.Client, error) {
+	client, err := daemon.Dial(ctx, socketPath())
+	if err != nil {
+		if errors.Is(err, daemon.ErrNotRunning) {
+			return nil, newExitError(ExitUnavailable, fmt.Errorf("%w; start one with 'assistant-cli daemon'", err))
+		}
+		return nil, newExitError(ExitUnavailable, err)
+	}
+	return client, nil
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := GetConfig().Get()
+	renderer := newRenderer(cmd)
+	handler, err := newDaemonHandler(ctx, renderer, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = handler.provider.Close() }()
+	// Loaded once, so the first request is as fast as later ones
+	handler.loadVoices(ctx, renderer)
+
+	// The scheduler runs without jobs too, so that jobs added to the
+	// configuration later are run
+	jobs, runner, err := newJobScheduler(cfg, handler, renderer)
+	if err != nil {
+		return err
+	}
+
+	path := socketPath()
+	listener, err := daemon.Listen(path)
+	if err != nil {
+		if errors.Is(err, daemon.ErrRunning) {
+			return usageError(err)
+		}
+		return ioError(err)
+	}
+
+	server := daemon.NewServer(handler.synthesize)
+	server.ExitCode = ExitCode
+	server.Logf = func(format string, args ...interface{}) {
+		slog.Warn(fmt.Sprintf(format, args...))
+	}
+
+	statusf(os.Stderr, "Listening on %s (provider %s, voice %s); press Ctrl+C to stop\n",
+		path, handler.provider.Name(), handler.base.Voice)
+	jobs.Logf = server.Logf
+	scheduled := make(chan struct{})
+	// Running jobs are stopped before the daemon exits
+	defer func() {
+		stop()
+		<-scheduled
+	}()
+	go func() {
+		defer close(scheduled)
+		jobs.Run(ctx)
+	}()
+	if len(cfg.Scheduler.Jobs) > 0 {
+		statusf(os.Stderr, "Scheduled %d jobs\n", len(cfg.Scheduler.Jobs))
+	}
+
+	watchConfig(ctx, renderer, func(change *config.Change) {
+		handler.reload(renderer, change)
+		if change.Changed("scheduler.jobs") {
+			if err := runner.setJobs(jobs, change.New.Scheduler.Jobs); err != nil {
+				renderer.Warnf("Warning: scheduler.jobs not changed: %v\n", err)
+			} else {
+				statusf(os.Stderr, "Scheduled %d jobs\n", len(change.New.Scheduler.Jobs))
+			}
+		}
+	})
+	if err := server.Serve(ctx, listener); err != nil {
+		return ioError(err)
+	}
+	statusf(os.Stderr, "Stopped\n")
+	return nil
+}
+
+// daemonHandler synthesizes the requests of daemon clients with one
+// provider created at startup. say uses it directly when no daemon runs.
+type daemonHandler struct {
+	provider tts.Provider
+	// providerName is the configured provider, which differs from the name
+	// of provider after a fallback
+	providerName string
+	// voices is the voice catalog loaded at startup, or nil when it was not
+	// loaded or the provider is not Google
+	voices []string
+
+	// mu guards the settings below, which reload replaces
+	mu          sync.RWMutex
+	cfg         *config.Config
+	synthesizer *tts.Synthesizer
+	// base holds the configured voice and audio settings requests start from
+	base tts.SynthesizeRequest
+}
+
+// newDaemonHandler creates the provider requests are synthesized with
+func newDaemonHandler(ctx context.Context, renderer *Renderer, cfg *config.Config) (*daemonHandler, error) {
+	format := "MP3"
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		return nil, validationError(err)
+	}
+	if formats := tts.SupportedFormats(providerName); formats != nil {
+		format = formats[0]
+	}
+
+	provider, base, err := createLongTextProvider(ctx, renderer, cfg, "", format)
+	if err != nil {
+		return nil, err
+	}
+	postProcess, err := createPostProcessOptions(cfg.Output.PostProcess)
+	if err != nil {
+		_ = provider.Close()
+		return nil, err
+	}
+
+	return &daemonHandler{
+		provider:     provider,
+		providerName: providerName,
+		cfg:          cfg,
+		synthesizer:  newSynthesizer(provider, postProcess, false),
+		base:         *base,
+	}, nil
+}
+
+// settings returns the configuration and the request settings of the
+// handler
+func (h *daemonHandler) settings() (*config.Config, tts.SynthesizeRequest) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.cfg, h.base
+}
+
+// reload makes the requests that follow use the changed configuration: its
+// voice and audio settings, aliases, input filters, and post-processing.
+// The provider stays the one created at startup, so changes to
+// tts.provider and auth need a restart. An invalid change is reported and
+// the current settings stay in effect.
+func (h *daemonHandler) reload(renderer *Renderer, change *config.Change) {
+	if change.Changed("tts.provider") || change.Changed("auth") {
+		renderer.Warnf("Warning: changes to tts.provider and auth take effect after a restart\n")
+	}
+
+	_, current := h.settings()
+	_, base, err := longTextSettings(change.New, h.providerName, "", current.AudioFormat)
+	if err == nil && h.provider.Name() != h.providerName {
+		adaptRequest(renderer, base, h.provider.Name())
+	}
+	postProcess, postErr := createPostProcessOptions(change.New.Output.PostProcess)
+	if err = errors.Join(err, postErr); err != nil {
+		renderer.Warnf("Warning: configuration changes not applied: %v\n", err)
+		return
+	}
+
+	synthesizer := newSynthesizer(h.provider, postProcess, false)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cfg, h.synthesizer, h.base = change.New, synthesizer, *base
+}
+
+// loadVoices loads the Google voice catalog, so that requests for unknown
+// voices fail before calling the API
+func (h *daemonHandler) loadVoices(ctx context.Context, renderer *Renderer) {
+	if h.provider.Name() != tts.ProviderGoogle {
+		return
+	}
+	cfg, _ := h.settings()
+	cache, err := newPersistentVoiceCache(h.provider, cfg.TTS)
+	if err == nil {
+		var listing *tts.VoiceListing
+		if listing, err = cache.GetVoices(ctx, "", false); err == nil {
+			h.voices = voiceNames(listing.Voices)
+		}
+	}
+	if err != nil {
+		renderer.Warnf("Warning: voice catalog not loaded, voices are checked by the API: %v\n", err)
+	}
+}
+
+// synthesize is the daemon.Handler: it applies the settings of the request
+// to the configured ones and writes the audio of the requested region
+func (h *daemonHandler) synthesize(ctx context.Context, request *daemon.Request) (*daemon.Response, error) {
+	region, offset, err := request.Region()
+	if err != nil {
+		return nil, usageError(err)
+	}
+	if strings.TrimSpace(region) == "" {
+		return nil, usageError(errors.New("no text to synthesize"))
+	}
+
+	h.mu.RLock()
+	cfg, synthesizer, req := h.cfg, h.synthesizer, h.base
+	h.mu.RUnlock()
+	if request.Voice != "" {
+		req.Voice = tts.ResolveVoiceAlias(cfg.TTS.VoiceAliases, request.Voice)
+		if language := tts.VoiceLanguage(req.Voice); language != "" {
+			req.LanguageCode = language
+		}
+	}
+	if request.Language != "" {
+		req.LanguageCode = request.Language
+		if request.Voice == "" && h.provider.Name() == tts.ProviderGoogle {
+			if preferred, ok := tts.PreferredVoice(cfg.TTS.LanguageVoices, request.Language); ok {
+				req.Voice, req.LanguageCode = preferred, tts.VoiceLanguage(preferred)
+			}
+		}
+	}
+	if request.SpeakingRate != 0 {
+		if request.SpeakingRate < 0.25 || request.SpeakingRate > 4.0 {
+			return nil, usageError(fmt.Errorf("speaking rate must be between 0.25 and 4.0, got %g",
+				request.SpeakingRate))
+		}
+		req.SpeakingRate = request.SpeakingRate
+	}
+	if request.Format != "" {
+		req.AudioFormat = strings.ToUpper(request.Format)
+		if err := checkTranscoder(req.AudioFormat); err != nil {
+			return nil, err
+		}
+	}
+	if err := h.checkVoice(req.Voice); err != nil {
+		return nil, err
+	}
+
+	text, err := filterText(region, cfg.Input, req.LanguageCode)
+	if err != nil {
+		return nil, err
+	}
+	req.Text = text
+	req.Timepoints = request.SpeechMarks
+
+	resp := &daemon.Response{Voice: req.Voice}
+	if request.Selection != nil || request.Cursor != nil {
+		resp.Range = &daemon.Range{
+			Start: daemon.PositionAt(request.Text, offset),
+			End:   daemon.PositionAt(request.Text, offset+len(region)),
+		}
+	}
+	if request.Output != "" {
+		if !filepath.IsAbs(request.Output) {
+			return nil, usageError(fmt.Errorf("output path %s is not absolute", request.Output))
+		}
+		req.OutputFile = request.Output
+	} else {
+		file, err := os.CreateTemp("", daemon.TempFilePrefix+"*."+longTextExtension(&req))
+		if err != nil {
+			return nil, ioError(fmt.Errorf("failed to create temporary file: %w", err))
+		}
+		_ = file.Close()
+		req.OutputFile, resp.Temporary = file.Name(), true
+	}
+
+	slog.Debug("synthesizing for client", "voice", req.Voice, "language", req.LanguageCode,
+		"characters", len(req.Text), "output", req.OutputFile)
+	result, err := synthesizer.Synthesize(ctx, &req)
+	if err != nil {
+		if resp.Temporary {
+			_ = os.Remove(req.OutputFile)
+		}
+		return nil, fmt.Errorf("synthesis failed: %w", explainAPIError(err))
+	}
+	resp.File = result.OutputFile
+	resp.DurationSeconds = result.Duration.Seconds()
+
+	if request.SpeechMarks {
+		if result.Duration <= 0 {
+			if resp.Temporary {
+				_ = os.Remove(resp.File)
+			}
+			return nil, validationError(fmt.Errorf(
+				"speech marks need the length of the audio, which cannot be read from %s output", result.Format))
+		}
+		resp.Marks = documentMarks(request.Text, offset, speechMarks(region, result))
+	}
+	return resp, nil
+}
+
+// documentMarks places the marks estimated for the region at offset in text
+// at their positions in text, for editors to highlight words as they are
+// spoken
+func documentMarks(text string, offset int, marks *output.SpeechMarks) []daemon.Mark {
+	result := make([]daemon.Mark, 0, len(marks.Marks))
+	for _, mark := range marks.Marks {
+		result = append(result, daemon.Mark{
+			Type:       mark.Type,
+			TimeMillis: mark.TimeMillis,
+			Value:      mark.Value,
+			Range: daemon.Range{
+				Start: daemon.PositionAt(text, offset+mark.Start),
+				End:   daemon.PositionAt(text, offset+mark.End),
+			},
+		})
+	}
+	return result
+}
+
+// checkVoice checks voice against the catalog loaded at startup
+func (h *daemonHandler) checkVoice(voice string) error {
+	if h.voices == nil || voice == "" || slices.Contains(h.voices, voice) {
+		return nil
+	}
+	message := fmt.Sprintf("voice %s not found", voice)
+	if match, ok := suggest.Closest(voice, h.voices); ok {
+		message += fmt.Sprintf("; did you mean %s?", match)
+	}
+	return validationError(errors.New(message))
+}
@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/mikefarmer/assistant-cli/internal/document"
+	"github.com/mikefarmer/assistant-cli/internal/notify"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/scheduler"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// jobFetchTimeout bounds the download of a job's URL
+	jobFetchTimeout = 30 * time.Second
+	// jobCommandTimeout bounds the command of a job
+	jobCommandTimeout = 5 * time.Minute
+	// maxJobSourceSize bounds the text read from a job's URL or command
+	maxJobSourceSize = 10 << 20
+)
+
+// NewDaemonJobsCmd creates the daemon jobs command
+func NewDaemonJobsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "jobs",
+		Short: "List the scheduled jobs of the daemon and their latest runs",
+		Long: `List the jobs in scheduler.jobs with their schedule, next run, and the
+outcome of their latest runs, as recorded by the daemon in the job history
+(scheduler.history_file, ~/.assistant-cli/scheduler.json by default).
+
+Examples:
+  assistant-cli daemon jobs
+  assistant-cli daemon jobs --output-format json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runDaemonJobs,
+	}
+}
+
+// jobStatus is a scheduled job and its history
+type jobStatus struct {
+	Name     string           `json:"name"`
+	Schedule string           `json:"schedule"`
+	NextRun  time.Time        `json:"next_run"`
+	Runs     []*scheduler.Run `json:"runs"`
+}
+
+func runDaemonJobs(cmd *cobra.Command, args []string) error {
+	cfg := GetConfig().Get()
+	history, err := loadJobHistory(cfg.Scheduler)
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]jobStatus, 0, len(cfg.Scheduler.Jobs))
+	for _, job := range cfg.Scheduler.Jobs {
+		schedule, err := scheduler.Parse(job.Schedule)
+		if err != nil {
+			return validationError(fmt.Errorf("job %s: %w", job.Name, err))
+		}
+		runs := history.Runs(job.Name)
+		if runs == nil {
+			runs = []*scheduler.Run{}
+		}
+		jobs = append(jobs, jobStatus{
+			Name:     job.Name,
+			Schedule: job.Schedule,
+			NextRun:  schedule.Next(time.Now()),
+			Runs:     runs,
+		})
+	}
+
+	return newRenderer(cmd).Result(jobs, func(w io.Writer) {
+		if len(jobs) == 0 {
+			_, _ = fmt.Fprintln(w, "No jobs are scheduled; add them to scheduler.jobs")
+			return
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "JOB\tSCHEDULE\tNEXT RUN\tLAST RUN\tSTATUS")
+		for _, job := range jobs {
+			last, status := "never", ""
+			if len(job.Runs) > 0 {
+				run := job.Runs[len(job.Runs)-1]
+				last, status = run.StartedAt.Local().Format("2006-01-02 15:04"), run.Status
+				if run.Error != "" {
+					status += ": " + run.Error
+				}
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", job.Name, job.Schedule,
+				job.NextRun.Format("2006-01-02 15:04"), last, status)
+		}
+		_ = tw.Flush()
+	})
+}
+
+// loadJobHistory reads the job history at scheduler.history_file, or the
+// default location
+func loadJobHistory(cfg config.SchedulerConfig) (*scheduler.History, error) {
+	path := cfg.HistoryFile
+	if path == "" {
+		var err error
+		if path, err = scheduler.DefaultHistoryPath(); err != nil {
+			return nil, ioError(err)
+		}
+	} else {
+		path = expandHomeDirs([]string{path})[0]
+	}
+	history, err := scheduler.LoadHistory(path, cfg.HistoryLimit)
+	if err != nil {
+		return nil, ioError(err)
+	}
+	return history, nil
+}
+
+// newJobScheduler creates the scheduler of the configured jobs and the
+// runner that runs them with handler
+func newJobScheduler(cfg *config.Config, handler *daemonHandler, renderer *Renderer) (*scheduler.Scheduler,
+	*jobRunner, error) {
+	history, err := loadJobHistory(cfg.Scheduler)
+	if err != nil {
+		return nil, nil, err
+	}
+	jobs, configured, err := parseJobs(cfg.Scheduler.Jobs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runner := &jobRunner{handler: handler, history: history, renderer: renderer, jobs: configured}
+	return scheduler.New(jobs, runner.run), runner, nil
+}
+
+// parseJobs parses the schedules of the configured jobs, returning them
+// with the jobs by name
+func parseJobs(configured []config.ScheduledJobConfig) ([]scheduler.Job, map[string]config.ScheduledJobConfig, error) {
+	jobs := make([]scheduler.Job, 0, len(configured))
+	byName := make(map[string]config.ScheduledJobConfig, len(configured))
+	for _, job := range configured {
+		schedule, err := scheduler.Parse(job.Schedule)
+		if err != nil {
+			return nil, nil, validationError(fmt.Errorf("job %s: %w", job.Name, err))
+		}
+		jobs = append(jobs, scheduler.Job{Name: job.Name, Schedule: schedule})
+		byName[job.Name] = job
+	}
+	return jobs, byName, nil
+}
+
+// jobRunner runs scheduled jobs with the daemon's provider, recording each
+// run in the history
+type jobRunner struct {
+	handler  *daemonHandler
+	history  *scheduler.History
+	renderer *Renderer
+
+	// mu guards jobs, which setJobs replaces
+	mu   sync.Mutex
+	jobs map[string]config.ScheduledJobConfig
+}
+
+// setJobs schedules the jobs of a changed configuration on s. Invalid
+// schedules keep the current jobs.
+func (r *jobRunner) setJobs(s *scheduler.Scheduler, configured []config.ScheduledJobConfig) error {
+	jobs, byName, err := parseJobs(configured)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.jobs = byName
+	r.mu.Unlock()
+	s.SetJobs(jobs)
+	return nil
+}
+
+// run is the scheduler.RunFunc of the daemon
+func (r *jobRunner) run(ctx context.Context, name string, due time.Time) {
+	r.mu.Lock()
+	job, ok := r.jobs[name]
+	r.mu.Unlock()
+	if !ok {
+		// The job was removed from the configuration
+		return
+	}
+	run := &scheduler.Run{Job: name, StartedAt: time.Now()}
+	statusf(os.Stderr, "Running job %s\n", name)
+
+	err := r.synthesize(ctx, job, run)
+	if err != nil && ctx.Err() != nil {
+		// The daemon is stopping; the run did not fail
+		return
+	}
+	run.FinishedAt = time.Now()
+	run.Status = scheduler.StatusSuccess
+	if err != nil {
+		run.Status, run.Error = scheduler.StatusFailure, err.Error()
+		r.renderer.Warnf("Warning: job %s failed: %v\n", name, err)
+		r.notifyFailure(job, run)
+	} else {
+		statusf(os.Stderr, "%s Job %s finished\n", styleFor(os.Stderr).Success(), name)
+	}
+	if err := r.history.Record(run); err != nil {
+		r.renderer.Warnf("Warning: job %s not recorded: %v\n", name, err)
+	}
+}
+
+// synthesize reads the text of job, synthesizes it, and plays or keeps
+// the audio
+func (r *jobRunner) synthesize(ctx context.Context, job config.ScheduledJobConfig, run *scheduler.Run) error {
+	text, err := r.jobText(ctx, job)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return errors.New("the job's source gave no text")
+	}
+	run.Characters = len([]rune(text))
+
+	request := &daemon.Request{Text: text, Voice: job.Voice, Format: job.Format}
+	if job.Output != "" {
+		cfg, base := r.handler.settings()
+		request.Output, err = renderOutputPath(cfg, base, job.Output, text, job.Voice, job.Format)
+		if err != nil {
+			return err
+		}
+	}
+	resp, err := r.handler.synthesize(ctx, request)
+	if err != nil {
+		return err
+	}
+	run.DurationSeconds = resp.DurationSeconds
+	if resp.Temporary {
+		defer func() { _ = os.Remove(resp.File) }()
+	} else {
+		run.File = resp.File
+	}
+
+	if !job.Play {
+		return nil
+	}
+	if reason := playbackSkipReason(); reason != "" {
+		if resp.Temporary {
+			return fmt.Errorf("playback is off (%s) and the job has no output", reason)
+		}
+		r.renderer.Warnf("Warning: job %s not played: playback is off (%s)\n", job.Name, reason)
+		return nil
+	}
+	audioPlayer, err := newConfiguredPlayer(r.renderer)
+	if err != nil {
+		return err
+	}
+	return playUntilDone(ctx, audioPlayer, resp.File)
+}
+
+// jobText reads the text of job from its URL, command, or text
+func (r *jobRunner) jobText(ctx context.Context, job config.ScheduledJobConfig) (string, error) {
+	switch {
+	case job.URL != "":
+		return r.fetchText(ctx, job.URL)
+	case job.Command != "":
+		ctx, cancel := context.WithTimeout(ctx, jobCommandTimeout)
+		defer cancel()
+		command := shellCommand(ctx, job.Command)
+		var stderr strings.Builder
+		command.Stderr = &stderr
+		out, err := command.Output()
+		if err != nil {
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return "", fmt.Errorf("command failed: %w: %s", err, message)
+			}
+			return "", fmt.Errorf("command failed: %w", err)
+		}
+		if len(out) > maxJobSourceSize {
+			out = out[:maxJobSourceSize]
+		}
+		return string(out), nil
+	default:
+		return job.Text, nil
+	}
+}
+
+// fetchText downloads url, reading HTML pages as their text
+func (r *jobRunner) fetchText(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, jobFetchTimeout)
+	defer cancel()
+	cfg, _ := r.handler.settings()
+	transport, err := httpTransport(cfg.Network)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	req.Header.Set("User-Agent", "assistant-cli")
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJobSourceSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		return document.HTMLText(string(body))
+	}
+	return string(body), nil
+}
+
+// renderOutputPath renders the output template pattern for text to an
+// absolute path, under output.default_path unless the template is absolute,
+// and creates its directory. voice and format override those of base.
+func renderOutputPath(cfg *config.Config, base tts.SynthesizeRequest, pattern, text, voice, format string) (string, error) {
+	root := expandHomeDirs([]string{cfg.Output.DefaultPath})[0]
+	pattern = expandHomeDirs([]string{pattern})[0]
+	if filepath.IsAbs(pattern) {
+		// Rendering sanitizes the path, so the directories before the first
+		// field are kept as they are
+		fixed := pattern
+		if i := strings.Index(pattern, "{{"); i >= 0 {
+			fixed = pattern[:i]
+		}
+		i := strings.LastIndexAny(fixed, `/\`)
+		root, pattern = pattern[:i+1], pattern[i+1:]
+	}
+
+	tmpl, err := output.ParseFilenameTemplate(pattern)
+	if err != nil {
+		return "", validationError(err)
+	}
+	language := base.LanguageCode
+	if voice == "" {
+		voice = base.Voice
+	} else if l := tts.VoiceLanguage(voice); l != "" {
+		language = l
+	}
+	format = strings.ToUpper(format)
+	if format == "" {
+		format = base.AudioFormat
+	}
+	data := output.NewFilenameData(text, voice, language, format, tts.FileExtension(format), time.Now())
+	name, err := tmpl.Render(data, cfg.Output.MaxFilenameLength)
+	if err != nil {
+		return "", validationError(err)
+	}
+
+	path, err := filepath.Abs(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return "", ioError(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", ioError(fmt.Errorf("failed to create output directory: %w", err))
+	}
+	return path, nil
+}
+
+// notifyFailure posts the failed run to the job's notify_url, or
+// output.notify_webhook
+func (r *jobRunner) notifyFailure(job config.ScheduledJobConfig, run *scheduler.Run) {
+	cfg, _ := r.handler.settings()
+	target := job.NotifyURL
+	if target == "" {
+		target = cfg.Output.NotifyWebhook
+	}
+	if target == "" {
+		return
+	}
+	transport, err := httpTransport(cfg.Network)
+	if err != nil {
+		r.renderer.Warnf("Warning: failed to send failure notification: %v\n", err)
+		return
+	}
+	webhook := notify.NewWebhook(target, &http.Client{Transport: transport})
+	payload := notify.Payload{
+		Command:    "daemon",
+		Job:        job.Name,
+		Status:     notify.StatusFailure,
+		Error:      run.Error,
+		Characters: run.Characters,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+	}
+	if err := webhook.Send(context.Background(), payload); err != nil {
+		r.renderer.Warnf("Warning: failed to send failure notification: %v\n", err)
+	}
+}
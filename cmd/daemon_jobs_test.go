@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/notify"
+	"github.com/mikefarmer/assistant-cli/internal/scheduler"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestJobRunner creates a runner of jobs with an espeak handler, writing
+// output and history to temporary directories
+func newTestJobRunner(t *testing.T, jobs ...config.ScheduledJobConfig) *jobRunner {
+	t.Helper()
+	cfg := espeakTestConfig(t)
+	cfg.Output.DefaultPath = t.TempDir()
+	cfg.Scheduler.HistoryFile = filepath.Join(t.TempDir(), "scheduler.json")
+	cfg.Scheduler.Jobs = jobs
+	renderer := newRenderer(&cobra.Command{})
+	handler, err := newDaemonHandler(context.Background(), renderer, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = handler.provider.Close() })
+
+	_, runner, err := newJobScheduler(cfg, handler, renderer)
+	require.NoError(t, err)
+	return runner
+}
+
+func TestJobRunnerWritesOutput(t *testing.T) {
+	runner := newTestJobRunner(t, config.ScheduledJobConfig{
+		Name:     "briefing",
+		Schedule: "0 7 * * *",
+		Command:  "echo Good morning",
+		Format:   "LINEAR16",
+		Output:   "briefings/{{.Date}}.{{.Ext}}",
+	})
+
+	runner.run(context.Background(), "briefing", time.Now())
+
+	run := runner.history.Last("briefing")
+	require.NotNil(t, run)
+	assert.Equal(t, scheduler.StatusSuccess, run.Status, run.Error)
+	assert.Equal(t, len("Good morning\n"), run.Characters)
+	want := filepath.Join(runner.handler.cfg.Output.DefaultPath, "briefings", time.Now().Format("2006-01-02")+".wav")
+	assert.Equal(t, want, run.File)
+	assert.FileExists(t, want)
+}
+
+func TestJobRunnerNotifiesFailure(t *testing.T) {
+	var got notify.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	runner := newTestJobRunner(t, config.ScheduledJobConfig{
+		Name:      "news",
+		Schedule:  "@daily",
+		Command:   "echo unreachable >&2; exit 3",
+		Play:      true,
+		NotifyURL: server.URL,
+	})
+
+	runner.run(context.Background(), "news", time.Now())
+
+	run := runner.history.Last("news")
+	require.NotNil(t, run)
+	assert.Equal(t, scheduler.StatusFailure, run.Status)
+	assert.Contains(t, run.Error, "unreachable")
+	assert.Equal(t, "news", got.Job)
+	assert.Equal(t, notify.StatusFailure, got.Status)
+}
+
+func TestJobRunnerFetchesHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html><body><h1>Weather</h1><p>Sunny and <b>warm</b>.</p></body></html>"))
+	}))
+	defer server.Close()
+	runner := newTestJobRunner(t)
+
+	text, err := runner.jobText(context.Background(), config.ScheduledJobConfig{URL: server.URL})
+	require.NoError(t, err)
+	assert.Contains(t, text, "Weather")
+	assert.Contains(t, text, "Sunny and warm.")
+	assert.NotContains(t, text, "<p>")
+}
+
+func TestDaemonJobsCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	historyFile := filepath.Join(t.TempDir(), "scheduler.json")
+	history, err := scheduler.LoadHistory(historyFile, 10)
+	require.NoError(t, err)
+	started := time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC)
+	require.NoError(t, history.Record(&scheduler.Run{Job: "briefing", Status: scheduler.StatusFailure,
+		Error: "command failed", StartedAt: started, FinishedAt: started.Add(time.Second)}))
+	config := writeTestConfig(t, "scheduler:\n  history_file: \""+filepath.ToSlash(historyFile)+"\"\n"+
+		"  jobs:\n    - name: \"briefing\"\n      schedule: \"0 7 * * *\"\n      text: \"Good morning\"\n      play: true\n")
+	t.Cleanup(func() {
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"daemon", "jobs", "--config", config, "--output-format", "json"})
+	require.NoError(t, rootCmd.Execute())
+
+	var result struct {
+		Data []jobStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.Len(t, result.Data, 1)
+	assert.Equal(t, "briefing", result.Data[0].Name)
+	assert.Equal(t, 7, result.Data[0].NextRun.Hour())
+	require.Len(t, result.Data[0].Runs, 1)
+	assert.Equal(t, "command failed", result.Data[0].Runs[0].Error)
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// espeakTestConfig returns the default configuration with the fake espeak
+// provider, for creating daemon handlers in tests
+func espeakTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+
+	// The synthesize flags, which createTTSConfig reads, get their defaults
+	NewRootCmd()
+	cfg := config.GetDefaults()
+	cfg.TTS.Provider = "espeak"
+	return cfg
+}
+
+// startTestDaemon serves an espeak daemon on a new socket until the test
+// ends and returns the socket path
+func startTestDaemon(t *testing.T) string {
+	t.Helper()
+	cfg := espeakTestConfig(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	handler, err := newDaemonHandler(ctx, newRenderer(&cobra.Command{}), cfg)
+	require.NoError(t, err)
+
+	// Socket paths are limited to about 100 bytes, which test temp
+	// directories can exceed
+	dir, err := os.MkdirTemp("", "acd")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "d.sock")
+	listener, err := daemon.Listen(path)
+	require.NoError(t, err)
+
+	server := daemon.NewServer(handler.synthesize)
+	server.ExitCode = ExitCode
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		_ = handler.provider.Close()
+		_ = os.RemoveAll(dir)
+	})
+	return path
+}
+
+func runSayCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		sayVoice = ""
+		sayLanguage = ""
+		saySpeed = 0
+		sayFormat = ""
+		sayOutput = ""
+		daemonSocket = ""
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"say"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestSayThroughDaemon(t *testing.T) {
+	socket := startTestDaemon(t)
+	played := fakePlayerOnPath(t)
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runSayCommand(t, "Build", "finished", "--socket", socket, "--config", config,
+		"--output-format", "json")
+	require.NoError(t, err)
+
+	var result struct {
+		Data sayResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Data.Played)
+	assert.Empty(t, result.Data.File)
+
+	// The temporary file is removed after playback
+	files := played()
+	require.Len(t, files, 1)
+	assert.True(t, strings.HasPrefix(files[0], "assistant-cli-say-"), files[0])
+	assert.NoFileExists(t, filepath.Join(os.TempDir(), files[0]))
+
+	// Save without playing
+	path := filepath.Join(t.TempDir(), "later.wav")
+	stdout, err = runSayCommand(t, "Later", "--socket", socket, "--config", config, "--output-format", "json",
+		"-o", path, "--no-play")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, path, result.Data.File)
+	assert.False(t, result.Data.Played)
+	assert.FileExists(t, path)
+	assert.Len(t, played(), 1)
+}
+
+func TestSayWithoutDaemon(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	// No daemon listens on the default socket
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runSayCommand(t, "Hello", "--config", config, "--output-format", "json")
+	require.NoError(t, err)
+
+	var result struct {
+		Data sayResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Data.Played)
+	files := played()
+	require.Len(t, files, 1)
+	assert.NoFileExists(t, filepath.Join(os.TempDir(), files[0]))
+}
+
+func TestSayErrors(t *testing.T) {
+	socket := startTestDaemon(t)
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	output := filepath.Join(t.TempDir(), "out.wav")
+
+	// Errors of the daemon keep their exit code
+	_, err := runSayCommand(t, "Hello", "--socket", socket, "--config", config, "-o", output, "--no-play",
+		"--speed", "9")
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.ErrorContains(t, err, "speaking rate must be between")
+
+	_, err = runSayCommand(t, "Hello", "--socket", filepath.Join(t.TempDir(), "none.sock"), "--config", config,
+		"-o", output, "--no-play")
+	assert.Equal(t, ExitUnavailable, ExitCode(err))
+	assert.ErrorContains(t, err, "start one with 'assistant-cli daemon'")
+
+	_, err = runSayCommand(t, "Hello", "--socket", socket, "--config", config, "--no-play")
+	assert.ErrorContains(t, err, "give --output")
+}
+
+func TestDaemonReloadsConfig(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	configFile := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n  voice: \"en-gb\"\n")
+	dir, err := os.MkdirTemp("", "acd")
+	require.NoError(t, err)
+	socket := filepath.Join(dir, "d.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"daemon", "--socket", socket, "--config", configFile})
+	done := make(chan error, 1)
+	go func() { done <- rootCmd.ExecuteContext(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		daemonSocket = ""
+		cfgFile = ""
+		_ = os.RemoveAll(dir)
+	})
+
+	// voice returns the voice the daemon synthesizes with, or "" while it
+	// is not listening
+	voice := func() string {
+		client, err := daemon.Dial(ctx, socket)
+		if err != nil {
+			return ""
+		}
+		defer func() { _ = client.Close() }()
+		resp, err := client.Synthesize(ctx, &daemon.Request{Text: "Hello"})
+		require.NoError(t, err)
+		_ = os.Remove(resp.File)
+		return resp.Voice
+	}
+	require.Eventually(t, func() bool { return voice() == "en-gb" }, 10*time.Second, 50*time.Millisecond)
+
+	// The file is written until the watcher, which starts with the server,
+	// sees it
+	changed := "tts:\n  provider: \"espeak\"\n  voice: \"en-us\"\n"
+	require.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(configFile, []byte(changed), 0600))
+		return voice() == "en-us"
+	}, 10*time.Second, 200*time.Millisecond)
+}
+
+func TestDaemonHandlerCheckVoice(t *testing.T) {
+	handler := &daemonHandler{voices: []string{"en-US-Neural2-F", "en-GB-Neural2-A"}}
+	assert.NoError(t, handler.checkVoice("en-US-Neural2-F"))
+	assert.NoError(t, handler.checkVoice(""))
+
+	err := handler.checkVoice("en-US-Nueral2-F")
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "did you mean en-US-Neural2-F?")
+
+	// Without a catalog the API checks voices
+	assert.NoError(t, (&daemonHandler{}).checkVoice("en-US-Anything"))
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runDocsCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		docsDir = ""
+		outputFormat = outputFormatText
+	})
+
+	stdout := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"docs"}, args...))
+	err := rootCmd.Execute()
+	return stdout.String(), err
+}
+
+func TestDocsCommand_Markdown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "reference")
+
+	stdout, err := runDocsCommand(t, "markdown", "--dir", dir)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "markdown pages to "+dir)
+
+	page, err := os.ReadFile(filepath.Join(dir, "assistant-cli_config_schema.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "## assistant-cli config schema")
+	assert.Contains(t, string(page), "* [assistant-cli config](assistant-cli_config.md)")
+	_, err = os.Stat(filepath.Join(dir, "assistant-cli_help.md"))
+	assert.True(t, os.IsNotExist(err), "help command should not get a page")
+}
+
+func TestDocsCommand_Man(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SOURCE_DATE_EPOCH", "1717200000")
+	dir := t.TempDir()
+
+	stdout, err := runDocsCommand(t, "man", "--dir", dir, "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data docsResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "man", result.Data.Format)
+	assert.Contains(t, result.Data.Files, filepath.Join(dir, "assistant-cli-synthesize.1"))
+	for _, file := range result.Data.Files {
+		assert.FileExists(t, file)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "assistant-cli.1"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `.TH "ASSISTANT-CLI" "1" "Jun 2024"`)
+}
+
+func TestDocsCommand_InvalidFormat(t *testing.T) {
+	_, err := runDocsCommand(t, "html")
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/mikefarmer/assistant-cli/internal/player"
+	"github.com/spf13/cobra"
+)
+
+var editorPlay bool
+
+// NewEditorCmd creates the editor command
+func NewEditorCmd() *cobra.Command {
+	editorCmd := &cobra.Command{
+		Use:   "editor",
+		Short: "Speak editor selections through the daemon over STDIN and STDOUT",
+		Long: `Bridge an editor plugin to 'assistant-cli daemon'. The plugin starts this
+command once and writes one JSON request per line to its STDIN; each response
+is written as one JSON line to STDOUT, in the daemon's protocol.
+
+A request carries the buffer text and the selection or cursor, in zero-based
+lines and characters, and speaks the selection, or else the paragraph at the
+cursor. The response gives the audio file, the range that was spoken and,
+with "speech_marks", when each word of it starts, so the plugin can highlight
+words as they are read. A new request cancels the one in flight, whose
+response has "canceled" set; {"cancel": true} only cancels.
+
+With --play the audio is played here, and each new request stops it;
+temporary files are then removed after playback instead of by the plugin.
+
+Request:
+  {"id": "7", "text": "First line.\nSecond line.", "selection": {"start": {"line": 1, "character": 0},
+   "end": {"line": 1, "character": 12}}, "speech_marks": true}
+Response:
+  {"id": "7", "file": "/tmp/assistant-cli-say-1.mp3", "temporary": true, "duration_seconds": 0.9,
+   "range": {...}, "marks": [{"type": "word", "time_ms": 0, "value": "Second", "range": {...}}, ...]}
+
+Examples:
+  assistant-cli editor --play
+  printf '{"text": "Hello", "cursor": {"line": 0, "character": 0}}\n' | assistant-cli editor`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runEditor,
+	}
+
+	editorCmd.Flags().BoolVar(&editorPlay, "play", false, "Play each response, stopping it when a new request arrives")
+	addSocketFlag(editorCmd)
+
+	return editorCmd
+}
+
+func runEditor(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	renderer := newRenderer(cmd)
+	client, err := dialDaemon(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	var speaker *editorSpeaker
+	if editorPlay && !skipPlayback() {
+		audioPlayer, err := newConfiguredPlayer(renderer)
+		if err != nil {
+			return err
+		}
+		speaker = &editorSpeaker{player: audioPlayer}
+		defer speaker.wait()
+	}
+
+	var mu sync.Mutex
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	write := func(resp *daemon.Response) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return encoder.Encode(resp)
+	}
+
+	// Responses are passed on as they arrive, while requests keep coming
+	received := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := client.Receive()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				received <- err
+				return
+			}
+			if speaker != nil && resp.File != "" && resp.Error == "" {
+				if err := speaker.play(resp); err != nil {
+					renderer.Warnf("Warning: %v\n", err)
+				}
+			}
+			if err := write(resp); err != nil {
+				received <- ioError(fmt.Errorf("failed to write response: %w", err))
+				return
+			}
+		}
+	}()
+
+	if err := forwardEditorRequests(cmd.InOrStdin(), client, speaker, write); err != nil {
+		return err
+	}
+	// The daemon answers what is in flight, then closes the connection
+	if err := client.CloseSend(); err != nil {
+		return ioError(err)
+	}
+	return <-received
+}
+
+// forwardEditorRequests sends each request line of in to the daemon until
+// in ends. Malformed lines are answered with an error response.
+func forwardEditorRequests(in io.Reader, client *daemon.Client, speaker *editorSpeaker,
+	write func(*daemon.Response) error) error {
+	reader := bufio.NewReader(in)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var req daemon.Request
+			if err := json.Unmarshal(line, &req); err != nil {
+				if err := write(&daemon.Response{Error: fmt.Sprintf("invalid request: %v", err),
+					ExitCode: ExitUsage}); err != nil {
+					return ioError(fmt.Errorf("failed to write response: %w", err))
+				}
+			} else {
+				if req.Output != "" {
+					output, err := filepath.Abs(expandHomeDirs([]string{req.Output})[0])
+					if err == nil {
+						req.Output = output
+					}
+				}
+				// Whatever is being read is stale once the editor asks again
+				if speaker != nil {
+					speaker.stop()
+				}
+				if err := client.Send(&req); err != nil {
+					return newExitError(ExitUnavailable, err)
+				}
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+		if readErr != nil {
+			return ioError(fmt.Errorf("failed to read request: %w", readErr))
+		}
+	}
+}
+
+// editorSpeaker plays the audio of one response at a time
+type editorSpeaker struct {
+	player *player.AudioPlayer
+
+	mu       sync.Mutex
+	playback *player.Playback
+	// temporary is the file of the playback to remove once it ends
+	temporary string
+}
+
+// play stops the current playback and plays the audio of resp
+func (s *editorSpeaker) play(resp *daemon.Response) error {
+	s.stop()
+
+	playback, err := s.player.Start(resp.File)
+	if err != nil {
+		if resp.Temporary {
+			_ = os.Remove(resp.File)
+		}
+		return fmt.Errorf("failed to play audio: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playback = playback
+	if resp.Temporary {
+		s.temporary = resp.File
+	}
+	return nil
+}
+
+// stop ends the current playback
+func (s *editorSpeaker) stop() {
+	s.finish(true)
+}
+
+// wait lets the current playback end
+func (s *editorSpeaker) wait() {
+	s.finish(false)
+}
+
+// finish ends or waits for the current playback and removes its file
+func (s *editorSpeaker) finish(stop bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.playback == nil {
+		return
+	}
+	if stop {
+		_ = s.playback.Stop()
+	}
+	<-s.playback.Done()
+	if s.temporary != "" {
+		_ = os.Remove(s.temporary)
+	}
+	s.playback, s.temporary = nil, ""
+}
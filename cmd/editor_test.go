@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runEditorCommand(t *testing.T, input string, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		editorPlay = false
+		daemonSocket = ""
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetIn(strings.NewReader(input))
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"editor"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestEditorCommand(t *testing.T) {
+	socket := startTestDaemon(t)
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	input := `not json
+{"id": "a", "text": "Intro.\n\nSay hello world.", "cursor": {"line": 2, "character": 4}, "speech_marks": true}
+`
+	stdout, err := runEditorCommand(t, input, "--socket", socket, "--config", config)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2)
+
+	var invalid daemon.Response
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &invalid))
+	assert.Contains(t, invalid.Error, "invalid request")
+	assert.Equal(t, ExitUsage, invalid.ExitCode)
+
+	var resp daemon.Response
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &resp))
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, "a", resp.ID)
+	assert.True(t, resp.Temporary)
+	assert.FileExists(t, resp.File)
+	t.Cleanup(func() { _ = os.Remove(resp.File) })
+	assert.Equal(t, &daemon.Range{Start: daemon.Position{Line: 2}, End: daemon.Position{Line: 2, Character: 16}},
+		resp.Range)
+
+	// Marks point into the buffer, not the spoken paragraph
+	require.Len(t, resp.Marks, 3)
+	assert.Equal(t, "hello", resp.Marks[1].Value)
+	assert.Equal(t, daemon.Range{Start: daemon.Position{Line: 2, Character: 4},
+		End: daemon.Position{Line: 2, Character: 9}}, resp.Marks[1].Range)
+}
+
+func TestEditorCommandNoDaemon(t *testing.T) {
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	_, err := runEditorCommand(t, "", "--socket", t.TempDir()+"/none.sock", "--config", config)
+	assert.Equal(t, ExitUnavailable, ExitCode(err))
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Exit codes returned by the CLI so that scripts can distinguish failure modes
+const (
+	ExitOK          = 0
+	ExitGeneral     = 1
+	ExitUsage       = 2
+	ExitAuth        = 3
+	ExitValidation  = 4
+	ExitQuota       = 5
+	ExitIO          = 6
+	ExitUnavailable = 7
+)
+
+// exitCodeNames maps exit codes to the error kind reported in JSON output
+var exitCodeNames = map[int]string{
+	ExitGeneral:     "general",
+	ExitUsage:       "usage",
+	ExitAuth:        "auth",
+	ExitValidation:  "validation",
+	ExitQuota:       "quota",
+	ExitIO:          "io",
+	ExitUnavailable: "unavailable",
+}
+
+// ExitError attaches an exit code to an error returned by a command
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// newExitError wraps err with the given exit code. A nil error stays nil.
+func newExitError(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// authError marks err as an authentication failure
+func authError(err error) error {
+	return newExitError(ExitAuth, err)
+}
+
+// validationError marks err as an input or configuration validation failure
+func validationError(err error) error {
+	return newExitError(ExitValidation, err)
+}
+
+// ioError marks err as a file system or stream failure
+func ioError(err error) error {
+	return newExitError(ExitIO, err)
+}
+
+// usageError marks err as an invalid invocation
+func usageError(err error) error {
+	return newExitError(ExitUsage, err)
+}
+
+// resultError carries partial result data that is rendered alongside the
+// error in JSON mode, such as a validation report listing every failed check
+type resultError struct {
+	err  error
+	data interface{}
+}
+
+func (e *resultError) Error() string {
+	return e.err.Error()
+}
+
+func (e *resultError) Unwrap() error {
+	return e.err
+}
+
+// withResult attaches result data to err. A nil error stays nil.
+func withResult(err error, data interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &resultError{err: err, data: data}
+}
+
+// explainAPIError replaces a raw API error caused by the API key, a quota,
+// or missing permissions with one explaining the cause and how to fix it,
+// naming the project of the configured credentials
+func explainAPIError(err error) error {
+	err = auth.ExplainAPIKeyError(err)
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.PermissionDenied:
+		project := auth.NewAuthManager(convertToAuthConfig(GetConfig().Get().Auth)).ProjectID()
+		return auth.ExplainAccessError(err, project)
+	default:
+		return err
+	}
+}
+
+// ExitCode classifies err into one of the CLI exit codes. API status codes take
+// precedence since they describe the root cause most precisely, followed by
+// codes attached explicitly in cmd and finally known error types from the
+// internal packages.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	// The API reports an invalid key as an invalid argument, but it is a
+	// credentials problem
+	var keyErr *auth.APIKeyError
+	if errors.As(err, &keyErr) {
+		return ExitAuth
+	}
+
+	if code, ok := grpcExitCode(err); ok {
+		return code
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	var inputErr *utils.InputError
+	if errors.As(err, &inputErr) {
+		if inputErr.Type == "read" {
+			return ExitIO
+		}
+		return ExitValidation
+	}
+
+	var ssmlErr *utils.ValidationError
+	var configErr *config.ValidationError
+	var configErrs config.ValidationErrors
+	if errors.As(err, &ssmlErr) || errors.As(err, &configErr) || errors.As(err, &configErrs) {
+		return ExitValidation
+	}
+
+	var fileErr *output.FileError
+	var pathErr *fs.PathError
+	if errors.As(err, &fileErr) || errors.As(err, &pathErr) {
+		return ExitIO
+	}
+
+	return ExitGeneral
+}
+
+// grpcExitCode maps a Google API status found in the error chain to an exit code
+func grpcExitCode(err error) (int, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0, false
+	}
+
+	switch st.Code() {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return ExitAuth, true
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return ExitValidation, true
+	case codes.ResourceExhausted:
+		return ExitQuota, true
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return ExitUnavailable, true
+	default:
+		return 0, false
+	}
+}
+
+// exitCodeName returns the error kind for an exit code
+func exitCodeName(code int) string {
+	if name, ok := exitCodeNames[code]; ok {
+		return name
+	}
+	return exitCodeNames[ExitGeneral]
+}
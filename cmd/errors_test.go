@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil error", nil, ExitOK},
+		{"generic error", errors.New("boom"), ExitGeneral},
+		{"explicit auth error", authError(errors.New("no credentials")), ExitAuth},
+		{"wrapped explicit error", fmt.Errorf("outer: %w", validationError(errors.New("bad"))), ExitValidation},
+		{"usage error", usageError(errors.New("bad flag")), ExitUsage},
+		{"io error", ioError(errors.New("disk full")), ExitIO},
+		{
+			"quota status",
+			fmt.Errorf("synthesis failed: %w", status.Error(codes.ResourceExhausted, "quota exceeded")),
+			ExitQuota,
+		},
+		{"permission status", status.Error(codes.PermissionDenied, "denied"), ExitAuth},
+		{"unauthenticated status", status.Error(codes.Unauthenticated, "bad key"), ExitAuth},
+		{"invalid argument status", status.Error(codes.InvalidArgument, "bad ssml"), ExitValidation},
+		{"unavailable status", status.Error(codes.Unavailable, "down"), ExitUnavailable},
+		{"unmapped status", status.Error(codes.Internal, "oops"), ExitGeneral},
+		{
+			"rejected API key",
+			auth.ExplainAPIKeyError(status.Error(codes.InvalidArgument, "API key not valid. Please pass a valid API key.")),
+			ExitAuth,
+		},
+		{
+			"status takes precedence over explicit code",
+			authError(fmt.Errorf("validation failed: %w", status.Error(codes.ResourceExhausted, "quota"))),
+			ExitQuota,
+		},
+		{
+			"ssml validation error",
+			fmt.Errorf("input validation failed: %w", &utils.ValidationError{Type: "security", Message: "bad"}),
+			ExitValidation,
+		},
+		{"input length error", &utils.InputError{Type: "length", Message: "too long"}, ExitValidation},
+		{"input read error", &utils.InputError{Type: "read", Message: "broken pipe"}, ExitIO},
+		{"config validation error", &config.ValidationError{Field: "tts.pitch", Message: "out of range"}, ExitValidation},
+		{
+			"config validation errors",
+			config.ValidationErrors{{Field: "tts.pitch", Message: "out of range"}},
+			ExitValidation,
+		},
+		{"file error", &output.FileError{Operation: "write", Path: "/x", Err: errors.New("denied")}, ExitIO},
+		{"path error", &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist}, ExitIO},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestExitError(t *testing.T) {
+	inner := errors.New("inner")
+	err := newExitError(ExitIO, inner)
+
+	assert.Equal(t, "inner", err.Error())
+	assert.ErrorIs(t, err, inner)
+	assert.NoError(t, newExitError(ExitIO, nil))
+}
+
+func TestExitCodeName(t *testing.T) {
+	assert.Equal(t, "auth", exitCodeName(ExitAuth))
+	assert.Equal(t, "quota", exitCodeName(ExitQuota))
+	assert.Equal(t, "general", exitCodeName(42))
+}
+
+func TestExplainAPIError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useGlobalConfig(t, writeTestConfig(t, configWithAPIKey))
+
+	quota := fmt.Errorf("synthesis failed: %w", status.Error(codes.ResourceExhausted, "quota exceeded"))
+	err := explainAPIError(quota)
+	var accessErr *auth.AccessError
+	assert.ErrorAs(t, err, &accessErr)
+	assert.Contains(t, err.Error(), "request a higher limit")
+	assert.Equal(t, ExitQuota, ExitCode(err))
+
+	denied := status.Error(codes.PermissionDenied, "caller lacks permission")
+	assert.Equal(t, ExitAuth, ExitCode(explainAPIError(denied)))
+
+	invalid := status.Error(codes.InvalidArgument, "bad voice")
+	assert.Equal(t, invalid, explainAPIError(invalid))
+}
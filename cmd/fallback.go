@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+)
+
+// shouldFallBack reports whether err means the primary provider could not be
+// reached or authenticated, as opposed to a problem with the request itself
+func shouldFallBack(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	switch ExitCode(err) {
+	case ExitAuth, ExitUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// fallBack creates the configured fallback provider after the primary
+// provider failed with cause, and warns about the switch. cause is returned
+// unchanged when no fallback is configured or the failure is not one a
+// fallback can work around.
+func fallBack(ctx context.Context, renderer *Renderer, ttsCfg config.TTSConfig, ttsConfig *tts.ClientConfig,
+	cause error) (tts.Provider, error) {
+	if ttsCfg.FallbackProvider == "" || !shouldFallBack(cause) {
+		return nil, cause
+	}
+
+	fallback, err := createProvider(ctx, ttsCfg.FallbackProvider, config.AuthConfig{}, ttsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%w (fallback provider %s also unavailable: %v)", cause, ttsCfg.FallbackProvider, err)
+	}
+
+	renderer.Warnf("Warning: %v\n", cause)
+	renderer.Warnf("Falling back to the local %s provider\n", fallback.Name())
+	return fallback, nil
+}
+
+// adaptRequest adjusts req for a fallback provider: voice names and custom
+// voices are provider-specific, so the language picks the voice, and
+// unsupported audio formats are replaced along with the output file extension
+func adaptRequest(renderer *Renderer, req *tts.SynthesizeRequest, providerName string) {
+	req.Voice = ""
+	req.CustomVoice = ""
+
+	formats := tts.SupportedFormats(providerName)
+	if formats == nil || slices.Contains(formats, strings.ToUpper(req.AudioFormat)) {
+		return
+	}
+
+	format := formats[0]
+	if ext := filepath.Ext(req.OutputFile); strings.EqualFold(ext, "."+tts.FileExtension(req.AudioFormat)) {
+		req.OutputFile = strings.TrimSuffix(req.OutputFile, ext) + "." + tts.FileExtension(format)
+	}
+	renderer.Warnf("Warning: the %s provider cannot produce %s; writing %s audio instead\n",
+		providerName, req.AudioFormat, format)
+	req.AudioFormat = format
+}
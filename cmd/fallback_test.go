@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestShouldFallBack(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"auth failure", authError(errors.New("no credentials")), true},
+		{"service unavailable", status.Error(codes.Unavailable, "connection refused"), true},
+		{"deadline exceeded", fmt.Errorf("synthesis failed: %w", context.DeadlineExceeded), true},
+		{"invalid request", status.Error(codes.InvalidArgument, "bad voice"), false},
+		{"quota exhausted", status.Error(codes.ResourceExhausted, "quota"), false},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shouldFallBack(tt.err))
+		})
+	}
+}
+
+func TestFallBackNotConfigured(t *testing.T) {
+	cause := authError(errors.New("no credentials"))
+
+	provider, err := fallBack(context.Background(), newRenderer(&cobra.Command{}), config.TTSConfig{}, &tts.ClientConfig{}, cause)
+	assert.Nil(t, provider)
+	assert.Same(t, cause, err)
+}
+
+func TestFallBackUnavailable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	cause := authError(errors.New("no credentials"))
+
+	provider, err := fallBack(context.Background(), newRenderer(&cobra.Command{}),
+		config.TTSConfig{FallbackProvider: tts.ProviderEspeak}, &tts.ClientConfig{}, cause)
+	assert.Nil(t, provider)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, ExitAuth, ExitCode(err))
+	assert.Contains(t, err.Error(), "fallback provider espeak also unavailable")
+}
+
+func TestAdaptRequest(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	renderer := newRenderer(cmd)
+
+	req := &tts.SynthesizeRequest{Voice: "en-US-Wavenet-D", AudioFormat: "MP3", OutputFile: "speech.mp3"}
+	adaptRequest(renderer, req, tts.ProviderEspeak)
+
+	assert.Empty(t, req.Voice)
+	assert.Equal(t, "LINEAR16", req.AudioFormat)
+	assert.Equal(t, "speech.wav", req.OutputFile)
+	assert.Contains(t, out.String(), "the espeak provider cannot produce MP3; writing LINEAR16 audio instead")
+
+	// Explicitly named files without the format's extension are kept
+	req = &tts.SynthesizeRequest{AudioFormat: "MP3", OutputFile: "speech.audio"}
+	adaptRequest(renderer, req, tts.ProviderEspeak)
+	assert.Equal(t, "speech.audio", req.OutputFile)
+}
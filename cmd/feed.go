@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/feed"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// feedFetchTimeout bounds the download of the feed itself
+const feedFetchTimeout = 30 * time.Second
+
+var (
+	feedOutputDir  string
+	feedVoice      string
+	feedFormat     string
+	feedLimit      int
+	feedStateFile  string
+	feedPodcastURL string
+	feedPlayAll    bool
+)
+
+// NewFeedCmd creates the feed command
+func NewFeedCmd() *cobra.Command {
+	feedCmd := &cobra.Command{
+		Use:   "feed URL",
+		Short: "Narrate new items of an RSS or Atom feed",
+		Long: `Fetch an RSS or Atom feed and narrate every item that has not been narrated
+before, writing one numbered audio file per item, oldest first.
+
+Processed items are remembered in a state file (~/.assistant-cli/feeds.json by
+default), so running the command again, e.g. from cron, only narrates new items.
+Items without text are remembered but produce no file.
+
+With --play-all, each item is played as soon as it is ready while later ones
+are synthesized; in a terminal, space pauses, n skips to the next item and q
+stops playback.
+
+With --auto-language, items or paragraphs in another language than the
+configured voice are read by a voice for that language.
+
+With --podcast-url, a podcast feed (podcast.xml) listing every narrated item is
+written to the output directory, with enclosure URLs under the given base URL
+where the directory is published.
+
+Examples:
+  assistant-cli feed https://example.com/rss.xml
+  assistant-cli feed https://example.com/atom.xml --limit 3 -o ~/podcasts/example
+  assistant-cli feed https://example.com/rss.xml --podcast-url https://cdn.example.com/example`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runFeed,
+	}
+
+	feedCmd.Flags().StringVarP(&feedOutputDir, "output-dir", "o", "",
+		"Directory for the audio files (default: <output.default_path>/<feed title>)")
+	feedCmd.Flags().StringVar(&feedVoice, "voice", "", "Voice name (default: tts.voice)")
+	feedCmd.Flags().StringVarP(&feedFormat, "format", "f", "MP3", "Audio format (MP3, OGG_OPUS, LINEAR16)")
+	feedCmd.Flags().IntVar(&feedLimit, "limit", 0, "Narrate at most this many new items, oldest first (0 for all)")
+	feedCmd.Flags().StringVar(&feedStateFile, "state", "",
+		"State file of processed items (default: ~/.assistant-cli/feeds.json)")
+	feedCmd.Flags().StringVar(&feedPodcastURL, "podcast-url", "",
+		"Write podcast.xml with enclosures under this base URL")
+	feedCmd.Flags().BoolVar(&feedPlayAll, "play-all", false, "Play each new item as soon as it is synthesized")
+	addConcurrencyFlag(feedCmd)
+	addAutoLanguageFlag(feedCmd)
+	addPresetFlag(feedCmd)
+	addNotifyFlag(feedCmd)
+
+	return feedCmd
+}
+
+// feedItemResult describes one narrated item
+type feedItemResult struct {
+	Title      string  `json:"title"`
+	Link       string  `json:"link,omitempty"`
+	File       string  `json:"file,omitempty"`
+	Characters int     `json:"characters"`
+	Duration   float64 `json:"duration_seconds,omitempty"`
+	// ChunkRetries counts the retries of each piece of the text, when any
+	ChunkRetries []int `json:"chunk_retries,omitempty"`
+}
+
+// feedResult is the machine-readable result of feed
+type feedResult struct {
+	Feed      string           `json:"feed"`
+	Title     string           `json:"title"`
+	Provider  string           `json:"provider,omitempty"`
+	Directory string           `json:"directory"`
+	State     string           `json:"state"`
+	Podcast   string           `json:"podcast,omitempty"`
+	Items     []feedItemResult `json:"items"`
+	// Remaining counts new items left for a later run by --limit
+	Remaining int  `json:"remaining"`
+	Played    bool `json:"played,omitempty"`
+}
+
+func runFeed(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+	cfg := GetConfig().Get()
+	renderer := newRenderer(cmd)
+	feedURL := args[0]
+
+	notice, err := newCompletionNotice(cfg, "feed")
+	if err != nil {
+		return err
+	}
+	notice.enableDesktop(cfg)
+	var result *feedResult
+	defer func() {
+		// Items narrated before a failure are reported too
+		if result != nil {
+			notice.payload.File = result.Directory
+			for _, item := range result.Items {
+				if item.File != "" {
+					notice.addFile(filepath.Join(result.Directory, item.File), item.Characters, item.Duration)
+				}
+			}
+		}
+		notice.send(renderer, err)
+	}()
+
+	if cfg, err = applyPreset(cmd, cfg, &feedFormat); err != nil {
+		return err
+	}
+	if err := checkLongTextFormat(feedFormat); err != nil {
+		return err
+	}
+	if feedLimit < 0 {
+		return usageError(fmt.Errorf("--limit must not be negative"))
+	}
+	concurrency, err := longTextConcurrency(cfg)
+	if err != nil {
+		return err
+	}
+	if err := checkAutoLanguage(); err != nil {
+		return err
+	}
+
+	statePath := feedStateFile
+	if statePath == "" {
+		var err error
+		if statePath, err = feed.DefaultStatePath(); err != nil {
+			return ioError(err)
+		}
+	}
+	state, err := feed.LoadState(statePath)
+	if err != nil {
+		return ioError(err)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, feedFetchTimeout)
+	defer cancel()
+	transport, err := httpTransport(cfg.Network)
+	if err != nil {
+		return validationError(err)
+	}
+	client := &http.Client{Transport: &progressTransport{base: transport, w: cmd.ErrOrStderr()}}
+	f, err := feed.Fetch(fetchCtx, client, feedURL)
+	if err != nil {
+		if errors.Is(err, feed.ErrInvalidFeed) {
+			return validationError(err)
+		}
+		return newExitError(ExitUnavailable, err)
+	}
+
+	history := state.Feed(feedURL)
+	if f.Title != "" {
+		history.Title = f.Title
+	}
+	title := history.Title
+	if title == "" {
+		title = "feed"
+	}
+
+	var pending []feed.Item
+	for _, item := range f.Items {
+		if !history.Seen(item.ID) {
+			pending = append(pending, item)
+		}
+	}
+	remaining := 0
+	if feedLimit > 0 && len(pending) > feedLimit {
+		remaining = len(pending) - feedLimit
+		pending = pending[:feedLimit]
+	}
+
+	dir := feedOutputDir
+	if dir == "" {
+		dir = filepath.Join(cfg.Output.DefaultPath, output.GetSafeFilename(title, ""))
+	}
+
+	result = &feedResult{
+		Feed:      feedURL,
+		Title:     title,
+		Directory: dir,
+		State:     statePath,
+		Items:     []feedItemResult{},
+		Remaining: remaining,
+	}
+
+	if len(pending) > 0 {
+		if err := narrateFeedItems(ctx, renderer, cfg, cmd.ErrOrStderr(), pending, state, history, dir,
+			concurrency, result); err != nil {
+			return err
+		}
+	}
+
+	if feedPodcastURL != "" {
+		podcast, err := feed.BuildPodcast(feed.Podcast{Title: title, Link: f.Link, BaseURL: feedPodcastURL},
+			history.Narrated())
+		if err != nil {
+			return validationError(err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return ioError(fmt.Errorf("failed to create output directory: %w", err))
+		}
+		result.Podcast = filepath.Join(dir, feed.PodcastFile)
+		if err := output.WriteFileAtomic(result.Podcast, podcast, 0644); err != nil {
+			return ioError(fmt.Errorf("failed to write podcast feed: %w", err))
+		}
+	}
+
+	return renderer.Result(result, func(w io.Writer) {
+		if len(result.Items) == 0 {
+			fmt.Fprintf(w, "No new items in %q\n", title)
+		} else {
+			fmt.Fprintf(w, "%s Narrated %d new items of %q to %s\n", styleFor(w).Success(), len(result.Items), title, dir)
+		}
+		if remaining > 0 {
+			fmt.Fprintf(w, "  %d more new items left for the next run\n", remaining)
+		}
+		if result.Podcast != "" {
+			fmt.Fprintf(w, "  Podcast: %s\n", result.Podcast)
+		}
+	})
+}
+
+// narrateFeedItems synthesizes each pending item into the next numbered file
+// of dir, concurrency pieces of an item at a time, saving the state after every item so that an interrupted run
+// resumes where it stopped
+func narrateFeedItems(ctx context.Context, renderer *Renderer, cfg *config.Config, progress io.Writer, pending []feed.Item,
+	state *feed.State, history *feed.FeedState, dir string, concurrency int, result *feedResult) error {
+	provider, req, err := createLongTextProvider(ctx, renderer, cfg, feedVoice, feedFormat)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = provider.Close() }()
+	result.Provider = provider.Name()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ioError(fmt.Errorf("failed to create output directory: %w", err))
+	}
+
+	queue := startBatchPlayback(renderer, feedPlayAll)
+	if queue != nil {
+		defer queue.Stop()
+	}
+
+	filters, err := inputFilters(cfg.Input, req.LanguageCode)
+	if err != nil {
+		return err
+	}
+	texts := make([]string, len(pending))
+	var chunks int64
+	for i, item := range pending {
+		if item.Text != "" {
+			texts[i] = utils.ApplyFilters(feedItemText(item), filters...)
+			pieces, err := longTextPieces(texts[i], req, autoLanguageFlag)
+			if err != nil {
+				return fmt.Errorf("item %q: %w", item.Title, err)
+			}
+			chunks += int64(len(pieces))
+		}
+	}
+	bar := newProgressBar(progress, chunks, "chunks")
+	defer bar.Done()
+
+	ext := longTextExtension(req)
+	synthesizer := newSynthesizer(provider, audio.Options{}, false)
+	number := len(history.Narrated())
+	for i, item := range pending {
+		bar.Step("[%d/%d] %s", i+1, len(pending), item.Title)
+
+		episode := &feed.Episode{
+			ID:        item.ID,
+			Title:     item.Title,
+			Link:      item.Link,
+			Published: item.Published,
+		}
+		itemResult := feedItemResult{Title: item.Title, Link: item.Link, Characters: len([]rune(item.Text))}
+
+		if item.Text != "" {
+			data, retries, err := synthesizeLongText(ctx, synthesizer, item.Title, texts[i], req,
+				autoLanguageFlag, cfg.Output.WriteMetadata, concurrency, bar)
+			if err != nil {
+				return fmt.Errorf("item %q: %w", item.Title, err)
+			}
+
+			number++
+			name := item.Title
+			if name == "" {
+				name = "item"
+			}
+			episode.File = fmt.Sprintf("%03d_%s", number, output.GetSafeFilename(name, ext))
+			if err := output.WriteFileAtomic(filepath.Join(dir, episode.File), data, 0644); err != nil {
+				return ioError(fmt.Errorf("failed to write audio file: %w", err))
+			}
+			episode.Size = int64(len(data))
+			if duration, err := audio.Duration(data); err == nil {
+				episode.DurationSeconds = duration.Seconds()
+			}
+			itemResult.File = episode.File
+			itemResult.Duration = episode.DurationSeconds
+			itemResult.ChunkRetries = reportedRetries(retries)
+			if queue != nil {
+				queue.Add(filepath.Join(dir, episode.File))
+			}
+		}
+
+		episode.Processed = time.Now()
+		history.Episodes = append(history.Episodes, episode)
+		if err := state.Save(); err != nil {
+			return ioError(err)
+		}
+		result.Items = append(result.Items, itemResult)
+	}
+
+	result.Played = finishBatchPlayback(renderer, queue)
+	return nil
+}
+
+// feedItemText returns the text narrated for an item: the title read as its
+// own sentence before the article
+func feedItemText(item feed.Item) string {
+	if item.Title == "" {
+		return item.Text
+	}
+	heading := item.Title
+	if !strings.ContainsAny(heading[len(heading)-1:], ".!?") {
+		heading += "."
+	}
+	return heading + "\n\n" + item.Text
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runFeedCommand(t *testing.T, args ...string) (*feedResult, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		feedOutputDir = ""
+		feedVoice = ""
+		feedFormat = "MP3"
+		feedLimit = 0
+		feedStateFile = ""
+		feedPodcastURL = ""
+		feedPlayAll = false
+		notifyURL = ""
+		concurrencyFlag = 0
+		autoLanguageFlag = ""
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"--output-format", "json", "feed"}, args...))
+	if err := rootCmd.Execute(); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data feedResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	return &result.Data, nil
+}
+
+// serveFeed serves an RSS feed whose items can be changed between requests
+func serveFeed(t *testing.T, items *[]string) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<rss><channel><title>Test News</title><link>https://news.example.com/</link>" +
+			strings.Join(*items, "") + "</channel></rss>"))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/rss"
+}
+
+func TestFeedCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	dir := filepath.Join(t.TempDir(), "news")
+	state := filepath.Join(t.TempDir(), "feeds.json")
+
+	items := []string{
+		"<item><guid>2</guid><title>Second</title><pubDate>Tue, 03 Mar 2026 08:00:00 +0000</pubDate>" +
+			"<description>Second story.</description></item>",
+		"<item><guid>1</guid><title>First</title><pubDate>Mon, 02 Mar 2026 08:00:00 +0000</pubDate>" +
+			"<description>First story.</description></item>",
+	}
+	url := serveFeed(t, &items)
+	args := []string{url, "--config", config, "--format", "LINEAR16", "-o", dir, "--state", state,
+		"--podcast-url", "https://cdn.example.com/news"}
+
+	result, err := runFeedCommand(t, append(args, "--limit", "1")...)
+	require.NoError(t, err)
+	assert.Equal(t, "Test News", result.Title)
+	assert.Equal(t, "espeak", result.Provider)
+	assert.Equal(t, 1, result.Remaining)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "001_First.wav", result.Items[0].File)
+	assert.FileExists(t, filepath.Join(dir, "001_First.wav"))
+
+	// The next run picks up where the previous one stopped, including new items
+	items = append([]string{"<item><guid>3</guid><title>Third</title>" +
+		"<pubDate>Wed, 04 Mar 2026 08:00:00 +0000</pubDate><description></description></item>"}, items...)
+	result, err = runFeedCommand(t, args...)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "002_Second.wav", result.Items[0].File)
+	// Items without text are recorded but not narrated
+	assert.Empty(t, result.Items[1].File)
+
+	podcast, err := os.ReadFile(result.Podcast)
+	require.NoError(t, err)
+	assert.Contains(t, string(podcast), "https://cdn.example.com/news/001_First.wav")
+	assert.Contains(t, string(podcast), "https://cdn.example.com/news/002_Second.wav")
+	assert.NotContains(t, string(podcast), "Third")
+
+	result, err = runFeedCommand(t, args...)
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+}
+
+func TestFeedCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	notFeed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>Hello</body></html>"))
+	}))
+	defer notFeed.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "no URL", args: nil, code: ExitUsage},
+		{name: "negative limit", args: []string{notFeed.URL, "--limit", "-1"}, code: ExitUsage},
+		{name: "raw PCM", args: []string{notFeed.URL, "--format", "PCM"}, code: ExitValidation},
+		{name: "not a URL", args: []string{"feed.xml"}, code: ExitValidation},
+		{name: "not a feed", args: []string{notFeed.URL}, code: ExitValidation},
+		{name: "unreachable", args: []string{unreachable.URL}, code: ExitUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runFeedCommand(t, tt.args...)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, ExitCode(err))
+		})
+	}
+}
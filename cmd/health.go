@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	healthTimeout time.Duration
+	healthJSON    bool
+)
+
+// NewHealthCmd creates the health command
+func NewHealthCmd() *cobra.Command {
+	healthCmd := &cobra.Command{
+		Use:   "health",
+		Short: "Check that authentication and the TTS API are working",
+		Long: `Check that the configured provider can be reached with the configured
+credentials and that the configured voice exists, for cron jobs and
+monitoring systems to verify the pipeline before a batch run.
+
+Unlike selftest, health synthesizes nothing, so it uses no API quota. It exits
+with 0 when every check passes, and otherwise with the exit code of the first
+failure (3 authentication, 4 validation, 5 quota, 7 service unavailable or
+--timeout exceeded).
+
+Examples:
+  assistant-cli health
+  assistant-cli health --timeout 10s --json
+  assistant-cli health -q && assistant-cli audiobook nightly.epub`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runHealth,
+	}
+
+	healthCmd.Flags().DurationVar(&healthTimeout, "timeout", 30*time.Second, "Deadline for all checks together")
+	healthCmd.Flags().BoolVar(&healthJSON, "json", false, "Print the report as JSON (same as --output-format json)")
+
+	return healthCmd
+}
+
+// healthReport is the machine-readable result of health
+type healthReport struct {
+	Provider string `json:"provider,omitempty"`
+	Healthy  bool   `json:"healthy"`
+	// Elapsed is how long the checks took, in milliseconds
+	Elapsed int64 `json:"elapsed_ms"`
+	checkList
+}
+
+func runHealth(cmd *cobra.Command, args []string) error {
+	if healthTimeout <= 0 {
+		return usageError(fmt.Errorf("--timeout must be positive, got %s", healthTimeout))
+	}
+	if healthJSON {
+		outputFormat = outputFormatJSON
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+	defer cancel()
+
+	renderer := newRenderer(cmd)
+	cfg := GetConfig().Get()
+	report := &healthReport{}
+	if provider, err := tts.NormalizeProvider(cfg.TTS.Provider); err == nil {
+		report.Provider = provider
+	}
+
+	start := time.Now()
+	runOnlineChecks(ctx, cfg, &report.checkList)
+	report.Elapsed = time.Since(start).Milliseconds()
+
+	err := report.firstError()
+	report.Healthy = err == nil
+
+	text := func(w io.Writer) { printHealthReport(w, report) }
+	if err != nil {
+		if !renderer.IsJSON() {
+			text(cmd.OutOrStdout())
+		}
+		return withResult(err, report)
+	}
+	return renderer.Result(report, text)
+}
+
+// printHealthReport writes the human-readable health summary
+func printHealthReport(w io.Writer, report *healthReport) {
+	if report.Provider != "" {
+		fmt.Fprintf(w, "Health of the %s provider:\n", report.Provider)
+	} else {
+		fmt.Fprintln(w, "Health:")
+	}
+	printChecks(w, report.Checks)
+
+	if report.Healthy {
+		fmt.Fprintf(w, "%s Healthy (%dms)\n", styleFor(w).Success(), report.Elapsed)
+	} else {
+		fmt.Fprintf(w, "%s Unhealthy (%dms)\n", styleFor(w).Failure(), report.Elapsed)
+	}
+}
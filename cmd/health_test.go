@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runHealthCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		healthTimeout = 30 * time.Second
+		healthJSON = false
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"health"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestHealthCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n  voice: \"\"\n")
+
+	stdout, err := runHealthCommand(t, "--config", config)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Health of the espeak provider:")
+	assert.Contains(t, stdout, "Healthy")
+
+	stdout, err = runHealthCommand(t, "--config", config, "--json", "--timeout", "10s")
+	require.NoError(t, err)
+	var result struct {
+		Success bool         `json:"success"`
+		Data    healthReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Success)
+	assert.True(t, result.Data.Healthy)
+	assert.Equal(t, "espeak", result.Data.Provider)
+	assert.NotEmpty(t, result.Data.Checks)
+}
+
+func TestHealthCommand_Unhealthy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	config := writeTestConfig(t, "tts:\n  provider: \"google\"\n")
+
+	stdout, err := runHealthCommand(t, "--config", config)
+	assert.Equal(t, ExitAuth, ExitCode(err))
+	assert.Contains(t, stdout, "Unhealthy")
+
+	_, err = runHealthCommand(t, "--config", config, "--timeout", "0s")
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,7 +27,7 @@ var loginCmd = &cobra.Command{
 
 The tool will guide you through the authentication process and store
 credentials securely for future use.`,
-	Run: runLogin,
+	RunE: runLogin,
 }
 
 var (
@@ -42,7 +43,7 @@ var (
 func init() {
 	// Add flags for different authentication methods
 	loginCmd.Flags().StringVarP(&loginMethod, "method", "m", "",
-		"Authentication method: apikey, serviceaccount, or oauth2")
+		"Authentication method: "+joinChoices(auth.AuthMethods()))
 	loginCmd.Flags().StringVar(&loginAPIKey, "api-key", "", "Google Cloud API key")
 	loginCmd.Flags().StringVar(&loginServiceFile, "service-account", "", "Path to service account JSON file")
 	loginCmd.Flags().StringVar(&loginClientID, "client-id", "", "OAuth2 client ID")
@@ -51,82 +52,101 @@ func init() {
 	loginCmd.Flags().BoolVar(&loginValidate, "validate", true, "Validate authentication by making a test API call")
 }
 
-func runLogin(cmd *cobra.Command, args []string) {
+// loginResult is the machine-readable outcome of the login command
+type loginResult struct {
+	Method            string `json:"method"`
+	AlreadyConfigured bool   `json:"already_configured"`
+	Validated         bool   `json:"validated"`
+	VoicesFound       int    `json:"voices_found,omitempty"`
+	ConfigSaved       bool   `json:"config_saved"`
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
+	renderer := newRenderer(cmd)
+
 	// Determine authentication method
 	method, err := determineAuthMethod()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error determining authentication method: %v\n", err)
-		cancel() // Ensure context is canceled before exit
-		os.Exit(1)
+		return usageError(fmt.Errorf("error determining authentication method: %w", err))
 	}
 
-	fmt.Printf("Using authentication method: %s\n", method)
+	renderer.Logf("Using authentication method: %s\n", method)
+	result := &loginResult{Method: method.String()}
 
 	// Create auth configuration
 	authConfig := createAuthConfig(method)
 
 	// Create auth manager
 	authManager := auth.NewAuthManager(authConfig)
+	slog.Debug("created auth manager", "method", method.String())
 
 	// Check if already authenticated (unless force is specified)
 	if !loginForce && authManager.IsConfigured() {
-		fmt.Println("Already authenticated. Use --force to re-authenticate.")
+		renderer.Logf("Already authenticated. Use --force to re-authenticate.\n")
+		result.AlreadyConfigured = true
 
 		if loginValidate {
-			fmt.Println("Validating existing authentication...")
-			if err := validateAuthentication(ctx, authManager, method); err != nil {
-				fmt.Fprintf(os.Stderr, "Validation failed: %v\n", err)
-				fmt.Println("Please run 'assistant-cli login --force' to re-authenticate.")
-				os.Exit(1)
+			renderer.Logf("Validating existing authentication...\n")
+			voiceCount, err := validateAuthentication(ctx, authManager, method)
+			if err != nil {
+				renderer.Warnf("Please run 'assistant-cli login --force' to re-authenticate.\n")
+				return authError(fmt.Errorf("validation failed: %w", err))
 			}
-			fmt.Println("Authentication is valid!")
+			renderer.Logf("Successfully authenticated! Found %d available voices.\n", voiceCount)
+			renderer.Logf("Authentication is valid!\n")
+			result.Validated = true
+			result.VoicesFound = voiceCount
 		}
-		return
+		return renderer.Result(result, nil)
 	}
 
 	// Perform authentication
-	fmt.Println("Starting authentication process...")
+	renderer.Logf("Starting authentication process...\n")
 	if err := performAuthentication(ctx, authManager, method); err != nil {
-		fmt.Fprintf(os.Stderr, "Authentication failed: %v\n", err)
-		os.Exit(1)
+		return authError(fmt.Errorf("authentication failed: %w", err))
 	}
 
 	// Validate authentication
 	if loginValidate {
-		fmt.Println("Validating authentication...")
-		if err := validateAuthentication(ctx, authManager, method); err != nil {
-			fmt.Fprintf(os.Stderr, "Validation failed: %v\n", err)
-			os.Exit(1)
+		renderer.Logf("Validating authentication...\n")
+		voiceCount, err := validateAuthentication(ctx, authManager, method)
+		if err != nil {
+			return authError(fmt.Errorf("validation failed: %w", err))
 		}
-		fmt.Println("Authentication validated successfully!")
+		renderer.Logf("Successfully authenticated! Found %d available voices.\n", voiceCount)
+		renderer.Logf("Authentication validated successfully!\n")
+		result.Validated = true
+		result.VoicesFound = voiceCount
 	}
 
 	// Save configuration
-	if err := saveAuthConfig(authConfig, method); err != nil {
+	if err := saveAuthConfig(authConfig, method, renderer); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Failed to save configuration: %v\n", err)
+	} else {
+		result.ConfigSaved = true
 	}
 
-	fmt.Println("Authentication completed successfully!")
-	fmt.Printf("You can now use 'assistant-cli synthesize' to convert text to speech.\n")
+	renderer.Logf("Authentication completed successfully!\n")
+	renderer.Logf("You can now use 'assistant-cli synthesize' to convert text to speech.\n")
+	return renderer.Result(result, nil)
+}
+
+// joinChoices lists choices as "a, b, or c"
+func joinChoices(choices []string) string {
+	if len(choices) < 2 {
+		return strings.Join(choices, "")
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + ", or " + choices[len(choices)-1]
 }
 
 // determineAuthMethod determines which authentication method to use
 func determineAuthMethod() (auth.AuthMethod, error) {
 	// If method is explicitly specified
 	if loginMethod != "" {
-		switch strings.ToLower(loginMethod) {
-		case "apikey", "api-key":
-			return auth.AuthMethodAPIKey, nil
-		case "serviceaccount", "service-account":
-			return auth.AuthMethodServiceAccount, nil
-		case "oauth2", "oauth":
-			return auth.AuthMethodOAuth2, nil
-		default:
-			return auth.AuthMethodAPIKey, fmt.Errorf("invalid authentication method: %s", loginMethod)
-		}
+		return auth.ParseAuthMethod(loginMethod)
 	}
 
 	// Auto-detect based on provided flags
@@ -184,6 +204,7 @@ func promptForAuthMethod() (auth.AuthMethod, error) {
 func createAuthConfig(method auth.AuthMethod) auth.AuthConfig {
 	config := auth.DefaultAuthConfig()
 	config.Method = method
+	config.Network = convertToNetworkConfig(GetConfig().Get().Network)
 
 	switch method {
 	case auth.AuthMethodAPIKey:
@@ -277,17 +298,23 @@ func performAuthentication(ctx context.Context, authManager *auth.AuthManager, m
 		return err
 
 	default:
-		return fmt.Errorf("unsupported authentication method: %s", method)
+		if _, err := auth.ParseAuthMethod(method.String()); err != nil {
+			return fmt.Errorf("unsupported authentication method: %s", method)
+		}
+		// Registered providers authenticate when a client is created
+		_, err := authManager.GetClient(ctx)
+		return err
 	}
 }
 
 // validateAuthentication validates the authentication by making a test API call
-func validateAuthentication(ctx context.Context, authManager *auth.AuthManager, _ auth.AuthMethod) error {
+// and returns the number of voices available to the authenticated account
+func validateAuthentication(ctx context.Context, authManager *auth.AuthManager, method auth.AuthMethod) (int, error) {
 
 	// Get a client - this will trigger authentication if needed
 	client, err := authManager.GetClient(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get authenticated client: %w", err)
+		return 0, fmt.Errorf("failed to get authenticated client: %w", err)
 	}
 	defer client.Close()
 
@@ -295,23 +322,29 @@ func validateAuthentication(ctx context.Context, authManager *auth.AuthManager,
 	req := &texttospeechpb.ListVoicesRequest{}
 	resp, err := client.ListVoices(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to list voices: %w", err)
+		if method == auth.AuthMethodAPIKey {
+			err = auth.ExplainAPIKeyError(err)
+		}
+		return 0, fmt.Errorf("failed to list voices: %w", err)
 	}
 
-	fmt.Printf("Successfully authenticated! Found %d available voices.\n", len(resp.Voices))
-	return nil
+	return len(resp.Voices), nil
 }
 
 // saveAuthConfig saves the authentication configuration to the config file
-func saveAuthConfig(authConfig auth.AuthConfig, method auth.AuthMethod) error {
-	// Set configuration values in viper
-	viper.Set("auth.method", method.String())
+func saveAuthConfig(authConfig auth.AuthConfig, method auth.AuthMethod, renderer *Renderer) error {
+	// Set configuration values in viper; registered providers are not
+	// saved, they are picked by auto-selection or --method
+	switch method {
+	case auth.AuthMethodAPIKey, auth.AuthMethodServiceAccount, auth.AuthMethodOAuth2:
+		viper.Set("auth.method", method.String())
+	}
 
 	switch method {
 	case auth.AuthMethodAPIKey:
 		// Don't save API key to config file for security
 		// User should use environment variable or command line flag
-		fmt.Println("Note: API key not saved to config file. Use ASSISTANT_CLI_API_KEY environment variable.")
+		renderer.Logf("Note: API key not saved to config file. Use ASSISTANT_CLI_API_KEY environment variable.\n")
 
 	case auth.AuthMethodServiceAccount:
 		viper.Set("auth.service_account_file", authConfig.ServiceAccountFile)
@@ -319,7 +352,7 @@ func saveAuthConfig(authConfig auth.AuthConfig, method auth.AuthMethod) error {
 	case auth.AuthMethodOAuth2:
 		// Don't save client credentials to config file for security
 		// OAuth2 tokens are saved separately by the OAuth2 provider
-		fmt.Println("Note: OAuth2 client credentials not saved to config file. Use environment variables.")
+		renderer.Logf("Note: OAuth2 client credentials not saved to config file. Use environment variables.\n")
 	}
 
 	// Get config file path
@@ -340,5 +373,6 @@ func saveAuthConfig(authConfig auth.AuthConfig, method auth.AuthMethod) error {
 	}
 
 	// Write config file
+	renderer.Detailf("Saving authentication settings to %s\n", configFile)
 	return viper.WriteConfig()
 }
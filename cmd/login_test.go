@@ -396,7 +396,7 @@ func TestValidateAuthentication(t *testing.T) {
 		authManager := auth.NewAuthManager(authConfig)
 
 		// This will fail because no valid auth is configured, but we can test error handling
-		err := validateAuthentication(context.TODO(), authManager, auth.AuthMethodAPIKey)
+		_, err := validateAuthentication(context.TODO(), authManager, auth.AuthMethodAPIKey)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to get authenticated client")
 	})
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/longtext"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// maxConcurrency is the most pieces --concurrency lets run at the same time,
+// as for tts.concurrency
+const maxConcurrency = 16
+
+// checkLongTextFormat rejects formats that cannot hold audio joined from
+// several responses
+func checkLongTextFormat(format string) error {
+	switch strings.ToUpper(format) {
+	case "MP3", "OGG_OPUS", "OGG", "LINEAR16", "WAV":
+		return nil
+	default:
+		return validationError(fmt.Errorf("long texts require MP3, OGG_OPUS or LINEAR16 output, not %s", format))
+	}
+}
+
+// createLongTextProvider creates the synthesis provider and the request
+// settings shared by every piece of a long text, falling back like
+// synthesize does. Empty voice keeps the configured voice.
+func createLongTextProvider(ctx context.Context, renderer *Renderer, cfg *config.Config,
+	voice, format string) (tts.Provider, *tts.SynthesizeRequest, error) {
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		return nil, nil, validationError(err)
+	}
+
+	ttsConfig, req, err := longTextSettings(cfg, providerName, voice, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	provider, err := createProvider(ctx, providerName, cfg.Auth, ttsConfig)
+	if err != nil {
+		if provider, err = fallBack(ctx, renderer, cfg.TTS, ttsConfig, err); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if provider.Name() != providerName {
+		adaptRequest(renderer, req, provider.Name())
+	}
+	return provider, req, nil
+}
+
+// longTextSettings returns the client configuration and the request
+// settings of cfg for the provider named providerName, checking Google
+// voices against the cached catalog
+func longTextSettings(cfg *config.Config, providerName, voice, format string) (*tts.ClientConfig,
+	*tts.SynthesizeRequest, error) {
+	ttsConfig := createTTSConfig(cfg.TTS)
+	if voice != "" {
+		ttsConfig.Voice = tts.ResolveVoiceAlias(cfg.TTS.VoiceAliases, voice)
+	}
+	ttsConfig.AudioEncoding = format
+	if providerName == tts.ProviderGoogle {
+		if err := validateVoiceOffline(ttsConfig.Voice, ttsConfig.LanguageCode, cfg.TTS.VoiceCacheTTL); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	req := &tts.SynthesizeRequest{
+		Voice:          ttsConfig.Voice,
+		LanguageCode:   ttsConfig.LanguageCode,
+		SpeakingRate:   ttsConfig.SpeakingRate,
+		Pitch:          ttsConfig.Pitch,
+		VolumeGain:     ttsConfig.VolumeGain,
+		AudioFormat:    format,
+		EffectsProfile: ttsConfig.EffectsProfile,
+	}
+	return ttsConfig, req, nil
+}
+
+// longTextExtension returns the file extension of the audio synthesized with
+// req, whose format the fallback provider may have changed from the one
+// requested
+func longTextExtension(req *tts.SynthesizeRequest) string {
+	return tts.FileExtension(req.AudioFormat)
+}
+
+// concurrencyFlag is the --concurrency flag of the commands that synthesize
+// long texts
+var concurrencyFlag int
+
+// addConcurrencyFlag adds --concurrency to a command that synthesizes long
+// texts
+func addConcurrencyFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&concurrencyFlag, "concurrency", 0,
+		"Pieces of a text synthesized at the same time (default: tts.concurrency)")
+}
+
+// longTextConcurrency returns the number of pieces synthesized at the same
+// time, from --concurrency or else tts.concurrency
+func longTextConcurrency(cfg *config.Config) (int, error) {
+	if concurrencyFlag == 0 {
+		return max(cfg.TTS.Concurrency, 1), nil
+	}
+	if concurrencyFlag < 1 || concurrencyFlag > maxConcurrency {
+		return 0, usageError(fmt.Errorf("--concurrency must be between 1 and %d, got %d",
+			maxConcurrency, concurrencyFlag))
+	}
+	return concurrencyFlag, nil
+}
+
+// Values of --auto-language
+const (
+	autoLanguageDocument  = "document"
+	autoLanguageParagraph = "paragraph"
+)
+
+// autoLanguageFlag is the --auto-language flag of the commands that
+// synthesize long texts
+var autoLanguageFlag string
+
+// addAutoLanguageFlag adds --auto-language to a command that synthesizes long
+// texts. Given without a value it detects the language of each paragraph.
+func addAutoLanguageFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&autoLanguageFlag, "auto-language", "",
+		"Detect the language of each paragraph, or of the whole text with =document, and switch to a voice for it")
+	cmd.Flags().Lookup("auto-language").NoOptDefVal = autoLanguageParagraph
+}
+
+// checkAutoLanguage rejects unknown --auto-language values
+func checkAutoLanguage() error {
+	switch autoLanguageFlag {
+	case "", autoLanguageDocument, autoLanguageParagraph:
+		return nil
+	default:
+		return usageError(fmt.Errorf("--auto-language must be %s or %s, got %q",
+			autoLanguageParagraph, autoLanguageDocument, autoLanguageFlag))
+	}
+}
+
+// languageRequest returns req switched to the voice for language, an ISO
+// 639-1 code, or req itself when it already speaks the language or none is
+// known. The voice is the tts.language_voices favorite for the language, or
+// else a standard one. Providers without named voices, such as espeak,
+// switch only the language code.
+func languageRequest(req *tts.SynthesizeRequest, language string) *tts.SynthesizeRequest {
+	if language == "" || tts.SameLanguage(req.LanguageCode, language) {
+		return req
+	}
+	languageCode, voice, ok := tts.LanguageVoice(language)
+	if !ok {
+		return req
+	}
+
+	switched := *req
+	switched.LanguageCode = languageCode
+	if req.Voice != "" {
+		switched.Voice = voice
+		if preferred, ok := tts.PreferredVoice(GetConfig().Get().TTS.LanguageVoices, languageCode); ok {
+			switched.Voice, switched.LanguageCode = preferred, tts.VoiceLanguage(preferred)
+		}
+	}
+	return &switched
+}
+
+// longTextPieces splits a long text into the pieces synthesized one request
+// at a time. Segments given a voice by voice markup are read by it; the rest
+// by req, switched to the voice for the language autoLanguage detects in the
+// whole segment or in each paragraph. SSML that cannot be split between
+// elements is rejected.
+func longTextPieces(text string, req *tts.SynthesizeRequest, autoLanguage string) ([]longtext.Piece, error) {
+	var pieces []longtext.Piece
+	add := func(text string, req *tts.SynthesizeRequest) error {
+		chunks, err := longtext.Split(text, longtext.ChunkSize)
+		if err != nil {
+			return validationError(err)
+		}
+		for _, chunk := range chunks {
+			pieces = append(pieces, longtext.Piece{Text: chunk, Request: req})
+		}
+		return nil
+	}
+
+	for _, voiceSegment := range utils.ParseVoiceMarkup(text) {
+		text := voiceSegment.Text
+		var err error
+		switch {
+		case voiceSegment.Voice != "":
+			err = add(text, voiceRequest(req, voiceSegment.Voice))
+		case autoLanguage == autoLanguageDocument:
+			err = add(text, languageRequest(req, utils.DetectLanguage(text)))
+		case autoLanguage == autoLanguageParagraph:
+			for _, segment := range utils.SegmentLanguages(text) {
+				if err = add(segment.Text, languageRequest(req, segment.Language)); err != nil {
+					break
+				}
+			}
+		default:
+			err = add(text, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pieces, nil
+}
+
+// longTextMaxSSMLSize is the smallest SSML document size limit of the
+// long-text commands, which validate documents as streams and split them,
+// so that books are not refused by the input.max_ssml_size meant for a
+// single request. It is the largest value input.max_ssml_size accepts.
+const longTextMaxSSMLSize = 16 << 20
+
+// newLongTextSSMLValidator returns an SSML validator with the limits of cfg,
+// accepting documents of up to at least longTextMaxSSMLSize
+func newLongTextSSMLValidator(cfg config.InputConfig) *utils.SSMLValidator {
+	validator := newSSMLValidator(cfg)
+	validator.SetMaxDocumentSize(max(cfg.MaxSSMLSize, longTextMaxSSMLSize))
+	return validator
+}
+
+// checkLongTextSSML validates text that is already held, such as the
+// chapters of a document or filtered text, when it is an SSML document,
+// before it is split. Files are validated while they are read instead, with
+// utils.InputProcessor.ReadValidatedText. Plain text passes unchecked.
+func checkLongTextSSML(text string, cfg config.InputConfig) error {
+	if !cfg.EnableSSMLSecurity || !longtext.IsSSML(text) {
+		return nil
+	}
+	if err := newLongTextSSMLValidator(cfg).ValidateSSMLReader(strings.NewReader(text)); err != nil {
+		return fmt.Errorf("input validation failed: %w", err)
+	}
+	return nil
+}
+
+// synthesizeLongText synthesizes text in request-sized pieces, concurrency
+// at a time, and joins the audio in order into one file, tagged with title
+// when writeMetadata is set. With autoLanguage, pieces in another language
+// than req are read by a voice for it. Each piece is added to bar. The
+// retries made for each piece are returned with the audio.
+func synthesizeLongText(ctx context.Context, synthesizer *tts.Synthesizer, title, text string,
+	req *tts.SynthesizeRequest, autoLanguage string, writeMetadata bool, concurrency int,
+	bar *progressBar) ([]byte, []int, error) {
+	pieces, err := longTextPieces(text, req, autoLanguage)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tags *output.Tags
+	if writeMetadata {
+		t := output.NewTags(text, req.Voice, req.LanguageCode, time.Now())
+		t.Title = title
+		tags = &t
+	}
+	return synthesizePieces(ctx, synthesizer, pieces, req.AudioFormat, tags, concurrency, bar)
+}
+
+// synthesizePieces synthesizes pieces, concurrency at a time, and joins the
+// audio in order into one file of format, tagged with tags when not nil.
+// Each piece is added to bar. The retries made for each piece are returned
+// with the audio.
+func synthesizePieces(ctx context.Context, synthesizer *tts.Synthesizer, pieces []longtext.Piece, format string,
+	tags *output.Tags, concurrency int, bar *progressBar) ([]byte, []int, error) {
+	responses, err := longtext.Synthesize(ctx, synthesizer, pieces, concurrency,
+		func(_ int, resp *tts.SynthesizeResponse) { bar.Add(1, int64(len(resp.AudioData))) })
+	if err != nil {
+		return nil, nil, err
+	}
+	retries := make([]int, len(responses))
+	for i, resp := range responses {
+		retries[i] = resp.Retries
+	}
+
+	joined, err := audio.Concat(longtext.Audio(responses), 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to join audio: %w", err)
+	}
+
+	if tags != nil {
+		if joined, err = output.WriteTags(joined, format, *tags); err != nil {
+			return nil, nil, fmt.Errorf("failed to write metadata: %w", err)
+		}
+	}
+	return joined, retries, nil
+}
+
+// reportedRetries returns the retries of each piece for a result, or nil
+// when no piece was retried
+func reportedRetries(retries []int) []int {
+	for _, n := range retries {
+		if n > 0 {
+			return retries
+		}
+	}
+	return nil
+}
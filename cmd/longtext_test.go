@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoTTSClient returns the text as audio, finishing earlier pieces last so
+// that concurrent requests complete out of order
+type echoTTSClient struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	fail     string
+}
+
+func (c *echoTTSClient) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audio *texttospeechpb.AudioConfig) ([]byte, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	if text == c.fail {
+		return nil, errors.New("unavailable")
+	}
+	select {
+	case <-time.After(time.Duration(10-int(text[len(text)-1]-'0')) * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []byte(text), nil
+}
+
+func (c *echoTTSClient) ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
+	return nil, nil
+}
+
+func (c *echoTTSClient) Close() error {
+	return nil
+}
+
+func TestReportedRetries(t *testing.T) {
+	assert.Nil(t, reportedRetries([]int{0, 0}))
+	assert.Equal(t, []int{0, 2}, reportedRetries([]int{0, 2}))
+}
+
+func TestLongTextConcurrency(t *testing.T) {
+	t.Cleanup(func() { concurrencyFlag = 0 })
+	cfg := config.GetDefaults()
+	cfg.TTS.Concurrency = 4
+
+	n, err := longTextConcurrency(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	concurrencyFlag = 2
+	n, err = longTextConcurrency(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	concurrencyFlag = maxConcurrency + 1
+	_, err = longTextConcurrency(cfg)
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
+
+func TestLongTextPieces(t *testing.T) {
+	const text = "The weather is nice and the birds are singing.\n\n" +
+		"Das Wetter ist schön und die Vögel singen auf dem Dach.\n\nThe end of the story."
+	req := &tts.SynthesizeRequest{Voice: "en-GB-Wavenet-B", LanguageCode: "en-GB"}
+
+	pieces, err := longTextPieces(text, req, "")
+	require.NoError(t, err)
+	require.Len(t, pieces, 1)
+	assert.Same(t, req, pieces[0].Request)
+
+	pieces, err = longTextPieces(text, req, autoLanguageDocument)
+	require.NoError(t, err)
+	require.Len(t, pieces, 1)
+	assert.Same(t, req, pieces[0].Request, "mostly English")
+
+	pieces, err = longTextPieces(text, req, autoLanguageParagraph)
+	require.NoError(t, err)
+	require.Len(t, pieces, 3)
+	assert.Same(t, req, pieces[0].Request)
+	assert.Equal(t, "de-DE-Standard-A", pieces[1].Request.Voice)
+	assert.Equal(t, "de-DE", pieces[1].Request.LanguageCode)
+	assert.Equal(t, "The end of the story.", pieces[2].Text)
+	assert.Same(t, req, pieces[2].Request)
+	assert.Equal(t, "en-GB-Wavenet-B", req.Voice, "the shared request is not modified")
+
+	// Without a named voice, as for espeak, only the language switches
+	switched := languageRequest(&tts.SynthesizeRequest{LanguageCode: "en-US"}, "fr")
+	assert.Empty(t, switched.Voice)
+	assert.Equal(t, "fr-FR", switched.LanguageCode)
+}
+
+func TestLongTextPieces_InvalidSSML(t *testing.T) {
+	req := &tts.SynthesizeRequest{Voice: "en-GB-Wavenet-B", LanguageCode: "en-GB"}
+	ssml := "<speak>" + strings.Repeat("<p>Words to read aloud.</p>", 200) + "<p>Unclosed</speak>"
+	_, err := longTextPieces(ssml, req, "")
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "invalid SSML")
+}
+
+func TestCheckLongTextSSML(t *testing.T) {
+	cfg := config.GetDefaults().Input
+	cfg.MaxSSMLDepth = 2
+
+	require.NoError(t, checkLongTextSSML("<speak><p>Hello</p></speak>", cfg))
+	require.NoError(t, checkLongTextSSML("Plain <text> is not checked", cfg))
+
+	err := checkLongTextSSML("<speak><p><s>Too deep</s></p></speak>", cfg)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "maximum depth of 2")
+
+	cfg.EnableSSMLSecurity = false
+	require.NoError(t, checkLongTextSSML("<speak><p><s>Too deep</s></p></speak>", cfg))
+}
+
+func TestNewLongTextSSMLValidator(t *testing.T) {
+	cfg := config.GetDefaults().Input
+	assert.Equal(t, longTextMaxSSMLSize, newLongTextSSMLValidator(cfg).MaxDocumentSize())
+	assert.Equal(t, cfg.MaxSSMLDepth, newLongTextSSMLValidator(cfg).MaxNestingDepth())
+}
+
+func TestLanguageRequest_LanguageVoices(t *testing.T) {
+	useGlobalConfig(t, writeTestConfig(t, `
+tts:
+  language_voices:
+    de-DE: Neural2-B
+`))
+
+	switched := languageRequest(&tts.SynthesizeRequest{Voice: "en-US-Neural2-F", LanguageCode: "en-US"}, "de")
+	assert.Equal(t, "de-DE-Neural2-B", switched.Voice)
+	assert.Equal(t, "de-DE", switched.LanguageCode)
+
+	switched = languageRequest(&tts.SynthesizeRequest{Voice: "en-US-Neural2-F", LanguageCode: "en-US"}, "fr")
+	assert.Equal(t, "fr-FR-Standard-A", switched.Voice, "languages without a favorite use a standard voice")
+}
+
+func TestCheckAutoLanguage(t *testing.T) {
+	t.Cleanup(func() { autoLanguageFlag = "" })
+
+	for _, value := range []string{"", autoLanguageDocument, autoLanguageParagraph} {
+		autoLanguageFlag = value
+		assert.NoError(t, checkAutoLanguage())
+	}
+
+	autoLanguageFlag = "sentence"
+	assert.Equal(t, ExitUsage, ExitCode(checkAutoLanguage()))
+}
+
+func TestLongTextExtension(t *testing.T) {
+	req := &tts.SynthesizeRequest{AudioFormat: "MP3"}
+	assert.Equal(t, "mp3", longTextExtension(req))
+
+	// espeak cannot produce MP3, so the fallback writes WAV files
+	adaptRequest(newRenderer(&cobra.Command{}), req, tts.ProviderEspeak)
+	assert.Equal(t, "wav", longTextExtension(req))
+}
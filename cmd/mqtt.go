@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/mikefarmer/assistant-cli/internal/mqtt"
+	"github.com/mikefarmer/assistant-cli/internal/player"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mqttBroker      string
+	mqttTopic       string
+	mqttResultTopic string
+	mqttOutput      string
+)
+
+const (
+	// mqttConnectTimeout bounds connecting and subscribing to the broker
+	mqttConnectTimeout = 30 * time.Second
+	// mqttMaxBackoff is the longest wait before reconnecting to the broker
+	mqttMaxBackoff = time.Minute
+)
+
+// NewMQTTCmd creates the mqtt command
+func NewMQTTCmd() *cobra.Command {
+	mqttCmd := &cobra.Command{
+		Use:   "mqtt",
+		Short: "Speak the messages of an MQTT topic, for smart-home announcements",
+		Long: `Subscribe to an MQTT topic and speak each message, so that home automation
+such as Home Assistant can make announcements through this machine. A message
+is the text to speak, or a JSON object such as
+  {"id": "door", "text": "The front door is open", "voice": "en-GB-Neural2-A"}
+with optional voice, language, speaking_rate, and format. Retained messages
+and empty messages are ignored.
+
+Each message is played here (mqtt.play), written to mqtt.output, or both. With
+mqtt.result_topic, the outcome of each message is published there as JSON:
+{"id": "door", "file": "...", "duration_seconds": 1.4, "played": true}, or
+{"id": "door", "error": "..."}.
+
+The broker is mqtt.broker (mqtt:// or mqtts:// for TLS); TLS, credentials, and
+client certificates are set under mqtt in the configuration, with the password
+best given as ASSISTANT_CLI_MQTT_PASSWORD. The command reconnects when the
+connection drops, and runs until Ctrl+C or SIGTERM. Changes to the
+configuration file apply to the messages that follow, except for those
+under mqtt, tts.provider, and auth, which need a restart.
+
+Examples:
+  assistant-cli mqtt --broker mqtt://homeassistant.local --topic home/announce
+  assistant-cli mqtt --result-topic home/announce/result -o "announcements/{{.Date}}_{{.Hash}}.{{.Ext}}"
+  mosquitto_pub -t assistant-cli/say -m "Dinner is ready"`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runMQTT,
+	}
+
+	mqttCmd.Flags().StringVar(&mqttBroker, "broker", "", "Broker URL, e.g. mqtts://broker:8883 (default: mqtt.broker)")
+	mqttCmd.Flags().StringVarP(&mqttTopic, "topic", "t", "", "Topic whose messages are spoken (default: mqtt.topic)")
+	mqttCmd.Flags().StringVar(&mqttResultTopic, "result-topic", "",
+		"Topic results are published to (default: mqtt.result_topic)")
+	mqttCmd.Flags().StringVarP(&mqttOutput, "output", "o", "",
+		"Filename template of the audio to keep (default: mqtt.output)")
+
+	return mqttCmd
+}
+
+// mqttRequest is a JSON message
+type mqttRequest struct {
+	ID           string  `json:"id"`
+	Text         string  `json:"text"`
+	Voice        string  `json:"voice"`
+	Language     string  `json:"language"`
+	SpeakingRate float64 `json:"speaking_rate"`
+	Format       string  `json:"format"`
+}
+
+// mqttResult is published to the result topic for each message
+type mqttResult struct {
+	ID       string  `json:"id,omitempty"`
+	Voice    string  `json:"voice,omitempty"`
+	File     string  `json:"file,omitempty"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+	Played   bool    `json:"played"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// mqttSummary is the machine-readable result of mqtt
+type mqttSummary struct {
+	Spoken int `json:"spoken"`
+	Failed int `json:"failed"`
+}
+
+func runMQTT(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	settings := GetConfig().Get().MQTT
+	if mqttBroker != "" {
+		settings.Broker = mqttBroker
+	}
+	if mqttTopic != "" {
+		settings.Topic = mqttTopic
+	}
+	if mqttResultTopic != "" {
+		settings.ResultTopic = mqttResultTopic
+	}
+	if mqttOutput != "" {
+		settings.Output = mqttOutput
+	}
+	if settings.Broker == "" {
+		return usageError(errors.New("no broker is set; give --broker or set mqtt.broker"))
+	}
+	if !settings.Play && settings.Output == "" {
+		return usageError(errors.New("messages would neither be played nor kept; set mqtt.play or --output"))
+	}
+
+	renderer := newRenderer(cmd)
+	bridge, err := newMQTTBridge(ctx, GetConfig().Get(), settings, renderer)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = bridge.handler.provider.Close() }()
+	options, err := mqttOptions(settings)
+	if err != nil {
+		return err
+	}
+	options.Logf = func(format string, args ...interface{}) {
+		renderer.Warnf("Warning: "+format+"\n", args...)
+	}
+	watchConfig(ctx, renderer, func(change *config.Change) {
+		bridge.handler.reload(renderer, change)
+		if change.Changed("mqtt") {
+			renderer.Warnf("Warning: changes to mqtt take effect after a restart\n")
+		}
+	})
+
+	backoff := time.Second
+	for ctx.Err() == nil {
+		connected, err := bridge.serve(ctx, options)
+		if ctx.Err() != nil {
+			break
+		}
+		if errors.Is(err, mqtt.ErrRefused) {
+			// Bad credentials do not fix themselves
+			return authError(err)
+		}
+		if connected {
+			backoff = time.Second
+		}
+		renderer.Warnf("Warning: %v; reconnecting in %s\n", err, backoff)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > mqttMaxBackoff {
+			backoff = mqttMaxBackoff
+		}
+	}
+
+	summary := bridge.summary
+	return renderer.Result(&summary, func(w io.Writer) {
+		statusf(w, "%s Spoke %d messages (%d failed)\n", styleFor(w).Success(), summary.Spoken, summary.Failed)
+	})
+}
+
+// mqttOptions returns the connection options of settings
+func mqttOptions(settings config.MQTTConfig) (mqtt.Options, error) {
+	options := mqtt.Options{
+		Broker:    settings.Broker,
+		ClientID:  settings.ClientID,
+		Username:  settings.Username,
+		Password:  settings.Password,
+		KeepAlive: settings.KeepAlive,
+	}
+	if settings.CAFile == "" && settings.CertFile == "" && !settings.InsecureSkipVerify {
+		return options, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: settings.InsecureSkipVerify}
+	if settings.CAFile != "" {
+		pool, err := auth.NetworkConfig{CABundle: expandHomeDirs([]string{settings.CAFile})[0]}.CertPool()
+		if err != nil {
+			return options, validationError(fmt.Errorf("mqtt.ca_file: %w", err))
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if settings.CertFile != "" {
+		paths := expandHomeDirs([]string{settings.CertFile, settings.KeyFile})
+		certificate, err := tls.LoadX509KeyPair(paths[0], paths[1])
+		if err != nil {
+			return options, validationError(fmt.Errorf("failed to load the MQTT client certificate: %w", err))
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	options.TLSConfig = tlsConfig
+	return options, nil
+}
+
+// mqttBridge speaks the messages of a topic with a provider created once
+type mqttBridge struct {
+	settings config.MQTTConfig
+	handler  *daemonHandler
+	renderer *Renderer
+	// player is nil when messages are not played
+	player  *player.AudioPlayer
+	summary mqttSummary
+}
+
+// newMQTTBridge creates the provider and player messages are spoken with
+func newMQTTBridge(ctx context.Context, cfg *config.Config, settings config.MQTTConfig,
+	renderer *Renderer) (*mqttBridge, error) {
+	bridge := &mqttBridge{settings: settings, renderer: renderer}
+	if settings.Play {
+		if reason := playbackSkipReason(); reason != "" {
+			if settings.Output == "" {
+				return nil, usageError(fmt.Errorf("playback is off (%s); give --output to keep the audio instead", reason))
+			}
+			renderer.Warnf("Warning: messages are not played: playback is off (%s)\n", reason)
+		} else {
+			audioPlayer, err := newConfiguredPlayer(renderer)
+			if err != nil {
+				return nil, err
+			}
+			bridge.player = audioPlayer
+		}
+	}
+
+	handler, err := newDaemonHandler(ctx, renderer, cfg)
+	if err != nil {
+		return nil, err
+	}
+	handler.loadVoices(ctx, renderer)
+	bridge.handler = handler
+	return bridge, nil
+}
+
+// serve connects to the broker and speaks the messages of the topic until
+// ctx is done or the connection ends, returning whether it connected and
+// why the connection ended
+func (b *mqttBridge) serve(ctx context.Context, options mqtt.Options) (bool, error) {
+	connectCtx, cancel := context.WithTimeout(ctx, mqttConnectTimeout)
+	defer cancel()
+	client, err := mqtt.Dial(connectCtx, options)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = client.Close() }()
+	qos := byte(b.settings.QoS)
+	if err := client.Subscribe(connectCtx, b.settings.Topic, qos); err != nil {
+		return false, err
+	}
+	statusf(os.Stderr, "Listening for messages on %s at %s; press Ctrl+C to stop\n", b.settings.Topic, options.Broker)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case message, ok := <-client.Messages():
+			if !ok {
+				return true, client.Err()
+			}
+			if message.Retained {
+				detailf(os.Stderr, "Ignoring retained message on %s\n", message.Topic)
+				continue
+			}
+			result := b.speak(ctx, message.Payload)
+			if result == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return true, nil
+			}
+			if result.Error != "" {
+				b.summary.Failed++
+				b.renderer.Warnf("Warning: message on %s not spoken: %s\n", message.Topic, result.Error)
+			} else {
+				b.summary.Spoken++
+			}
+			if b.settings.ResultTopic != "" {
+				data, _ := json.Marshal(result)
+				if err := client.Publish(ctx, b.settings.ResultTopic, data, qos, false); err != nil && ctx.Err() == nil {
+					b.renderer.Warnf("Warning: failed to publish the result: %v\n", err)
+				}
+			}
+		}
+	}
+}
+
+// speak synthesizes and plays or keeps the message in payload, returning
+// nil for empty messages
+func (b *mqttBridge) speak(ctx context.Context, payload []byte) *mqttResult {
+	var req mqttRequest
+	if trimmed := strings.TrimSpace(string(payload)); strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return &mqttResult{Error: fmt.Sprintf("invalid message: %v", err)}
+		}
+	} else {
+		req.Text = trimmed
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return nil
+	}
+
+	result := &mqttResult{ID: req.ID}
+	request := &daemon.Request{
+		Text:         req.Text,
+		Voice:        req.Voice,
+		Language:     req.Language,
+		SpeakingRate: req.SpeakingRate,
+		Format:       req.Format,
+	}
+	detailf(os.Stderr, "Message: %s\n", req.Text)
+	if b.settings.Output != "" {
+		cfg, base := b.handler.settings()
+		output, err := renderOutputPath(cfg, base, b.settings.Output, req.Text, req.Voice, req.Format)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		request.Output = output
+	}
+
+	resp, err := b.handler.synthesize(ctx, request)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if resp.Temporary {
+		defer func() { _ = os.Remove(resp.File) }()
+	} else {
+		result.File = resp.File
+	}
+	result.Voice, result.Duration = resp.Voice, resp.DurationSeconds
+
+	if b.player != nil {
+		if err := playUntilDone(ctx, b.player, resp.File); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Played = true
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMQTTCommand runs mqtt with args
+func runMQTTCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		mqttBroker = ""
+		mqttTopic = ""
+		mqttResultTopic = ""
+		mqttOutput = ""
+		noPlay = false
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"mqtt"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestMQTTBridgeSpeak(t *testing.T) {
+	cfg := espeakTestConfig(t)
+	cfg.Output.DefaultPath = t.TempDir()
+	settings := cfg.MQTT
+	settings.Play = false
+	settings.Output = "announcements/{{.Text}}.{{.Ext}}"
+	bridge, err := newMQTTBridge(context.Background(), cfg, settings, newRenderer(&cobra.Command{}))
+	require.NoError(t, err)
+	defer func() { _ = bridge.handler.provider.Close() }()
+
+	result := bridge.speak(context.Background(), []byte("Dinner is ready"))
+	require.NotNil(t, result)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, filepath.Join(cfg.Output.DefaultPath, "announcements", "Dinner_is_ready.wav"), result.File)
+	assert.FileExists(t, result.File)
+	assert.False(t, result.Played)
+
+	result = bridge.speak(context.Background(), []byte(`{"id": "door", "text": "The door is open"}`))
+	require.NotNil(t, result)
+	assert.Equal(t, "door", result.ID)
+	assert.Empty(t, result.Error)
+	assert.True(t, strings.HasSuffix(result.File, "The_door_is_open.wav"), result.File)
+
+	result = bridge.speak(context.Background(), []byte(`{"text": `))
+	require.NotNil(t, result)
+	assert.Contains(t, result.Error, "invalid message")
+
+	result = bridge.speak(context.Background(), []byte(`{"id": "door", "speaking_rate": 9, "text": "Hi"}`))
+	require.NotNil(t, result)
+	assert.Contains(t, result.Error, "speaking rate must be between")
+
+	assert.Nil(t, bridge.speak(context.Background(), []byte("  ")))
+}
+
+func TestMQTTOptions(t *testing.T) {
+	options, err := mqttOptions(config.MQTTConfig{Broker: "mqtts://broker:8883", ClientID: "speaker",
+		Username: "home", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "home", options.Username)
+	assert.Nil(t, options.TLSConfig, "the system roots are used")
+
+	options, err = mqttOptions(config.MQTTConfig{Broker: "mqtts://broker:8883", InsecureSkipVerify: true})
+	require.NoError(t, err)
+	require.NotNil(t, options.TLSConfig)
+	assert.True(t, options.TLSConfig.InsecureSkipVerify)
+
+	_, err = mqttOptions(config.MQTTConfig{Broker: "mqtts://broker:8883", CAFile: filepath.Join(t.TempDir(), "ca.pem")})
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+func TestMQTTCommandErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	_, err := runMQTTCommand(t, "--config", config)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.ErrorContains(t, err, "no broker is set")
+
+	_, err = runMQTTCommand(t, "--config", config, "--broker", "mqtt://127.0.0.1:1", "--no-play")
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.ErrorContains(t, err, "playback is off")
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+)
+
+// convertToNetworkConfig converts config.NetworkConfig to auth.NetworkConfig,
+// expanding a leading ~ in the CA bundle path
+func convertToNetworkConfig(cfg config.NetworkConfig) auth.NetworkConfig {
+	caBundle := cfg.CABundle
+	if strings.HasPrefix(caBundle, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			caBundle = filepath.Join(home, caBundle[2:])
+		}
+	}
+	return auth.NetworkConfig{
+		Proxy:    cfg.Proxy,
+		Endpoint: cfg.TTSEndpoint,
+		CABundle: caBundle,
+	}
+}
+
+// httpTransport returns the transport for downloads, going through the
+// configured proxy and trusting the configured CA bundle
+func httpTransport(cfg config.NetworkConfig) (http.RoundTripper, error) {
+	if cfg.Proxy == "" && cfg.CABundle == "" {
+		return http.DefaultTransport, nil
+	}
+	return convertToNetworkConfig(cfg).Transport()
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+// notifyURL is the --notify-url flag of the commands that synthesize
+var notifyURL string
+
+// addNotifyFlag adds --notify-url to a command that synthesizes
+func addNotifyFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&notifyURL, "notify-url", "",
+		"POST a JSON summary to this URL when the job finishes (default: output.notify_webhook)")
+}
+
+// desktopNotifier shows desktop notifications; tests replace it
+type desktopNotifier interface {
+	Notify(ctx context.Context, title, message string) error
+}
+
+// completionNotice collects the outcome of a job for the completion webhook
+// and desktop notification
+type completionNotice struct {
+	url     string
+	network config.NetworkConfig
+	desktop desktopNotifier
+	payload notify.Payload
+}
+
+// newCompletionNotice starts collecting the outcome of command for
+// --notify-url, or output.notify_webhook without it
+func newCompletionNotice(cfg *config.Config, command string) (*completionNotice, error) {
+	target := notifyURL
+	if target != "" {
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, usageError(fmt.Errorf("--notify-url must be an HTTP or HTTPS URL: %q", target))
+		}
+	} else {
+		target = cfg.Output.NotifyWebhook
+	}
+	return &completionNotice{
+		url:     target,
+		network: cfg.Network,
+		payload: notify.Payload{Command: command, StartedAt: time.Now()},
+	}, nil
+}
+
+// enableDesktop shows a desktop notification when the job finishes, if
+// app.desktop_notifications is set and the job was started from a terminal.
+// Status output going to a terminal too keeps cron jobs, whose stdin may be
+// /dev/null, from notifying.
+func (n *completionNotice) enableDesktop(cfg *config.Config) {
+	if cfg.App.DesktopNotifications && isInteractive() && isTerminal(os.Stderr) {
+		n.desktop = notify.NewDesktop()
+	}
+}
+
+// addFile records a file written by a batch job
+func (n *completionNotice) addFile(path string, characters int, duration float64) {
+	n.payload.Files = append(n.payload.Files, path)
+	n.payload.Characters += characters
+	n.payload.DurationSeconds += duration
+}
+
+// send reports the outcome of the job, which finished with err. A failed
+// notification is reported as a warning and never fails the job.
+func (n *completionNotice) send(renderer *Renderer, err error) {
+	n.payload.FinishedAt = time.Now()
+	n.payload.Status = notify.StatusSuccess
+	if err != nil {
+		n.payload.Status = notify.StatusFailure
+		n.payload.Error = err.Error()
+	}
+
+	if n.desktop != nil {
+		title, message := desktopMessage(n.payload)
+		if notifyErr := n.desktop.Notify(context.Background(), title, message); notifyErr != nil {
+			renderer.Warnf("Warning: failed to show desktop notification: %v\n", notifyErr)
+		}
+	}
+	if n.url != "" {
+		n.post(renderer)
+	}
+}
+
+// post sends the payload to the webhook
+func (n *completionNotice) post(renderer *Renderer) {
+	transport, err := httpTransport(n.network)
+	if err != nil {
+		renderer.Warnf("Warning: failed to send completion notification: %v\n", err)
+		return
+	}
+	webhook := notify.NewWebhook(n.url, &http.Client{Transport: transport})
+	if err := webhook.Send(context.Background(), n.payload); err != nil {
+		renderer.Warnf("Warning: failed to send completion notification: %v\n", err)
+		return
+	}
+	renderer.Detailf("Notified %s\n", n.url)
+}
+
+// desktopMessage returns the title and text of the desktop notification
+// for a finished job
+func desktopMessage(payload notify.Payload) (string, string) {
+	if payload.Status == notify.StatusFailure {
+		return "assistant-cli " + payload.Command + " failed", payload.Error
+	}
+
+	title := "assistant-cli " + payload.Command + " finished"
+	switch len(payload.Files) {
+	case 0:
+		return title, "No new audio was synthesized"
+	case 1:
+		return title, "Wrote 1 audio file to " + payload.File
+	default:
+		return title, fmt.Sprintf("Wrote %d audio files to %s", len(payload.Files), payload.File)
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCompletionNotice(t *testing.T) {
+	t.Cleanup(func() { notifyURL = "" })
+	cfg := config.GetDefaults()
+	cfg.Output.NotifyWebhook = "https://hooks.example.com/config"
+
+	notice, err := newCompletionNotice(cfg, "synthesize")
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/config", notice.url)
+
+	// The flag overrides the config
+	notifyURL = "http://localhost:8123/api/webhook/tts"
+	notice, err = newCompletionNotice(cfg, "synthesize")
+	require.NoError(t, err)
+	assert.Equal(t, notifyURL, notice.url)
+
+	notifyURL = "localhost:8123"
+	_, err = newCompletionNotice(cfg, "synthesize")
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
+
+func TestCompletionNoticeSend(t *testing.T) {
+	var got notify.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	cfg := config.GetDefaults()
+	cfg.Output.NotifyWebhook = server.URL
+	notice, err := newCompletionNotice(cfg, "feed")
+	require.NoError(t, err)
+	notice.addFile("/audio/001_a.mp3", 100, 1.5)
+	notice.addFile("/audio/002_b.mp3", 50, 2)
+
+	stderr := new(bytes.Buffer)
+	notice.send(&Renderer{stdout: stderr, stderr: stderr}, errors.New("item \"b\": quota exceeded"))
+	assert.Equal(t, "feed", got.Command)
+	assert.Equal(t, notify.StatusFailure, got.Status)
+	assert.Equal(t, "item \"b\": quota exceeded", got.Error)
+	assert.Equal(t, []string{"/audio/001_a.mp3", "/audio/002_b.mp3"}, got.Files)
+	assert.Equal(t, 150, got.Characters)
+	assert.InDelta(t, 3.5, got.DurationSeconds, 0.001)
+	assert.False(t, got.FinishedAt.Before(got.StartedAt))
+	assert.Empty(t, stderr.String())
+}
+
+func TestCompletionNoticeSend_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	cfg := config.GetDefaults()
+	cfg.Output.NotifyWebhook = server.URL
+	notice, err := newCompletionNotice(cfg, "synthesize")
+	require.NoError(t, err)
+
+	stderr := new(bytes.Buffer)
+	notice.send(&Renderer{stdout: stderr, stderr: stderr}, nil)
+	assert.Contains(t, stderr.String(), "failed to send completion notification")
+}
+
+// fakeDesktop records desktop notifications
+type fakeDesktop struct {
+	titles, messages []string
+	err              error
+}
+
+func (d *fakeDesktop) Notify(ctx context.Context, title, message string) error {
+	d.titles = append(d.titles, title)
+	d.messages = append(d.messages, message)
+	return d.err
+}
+
+func TestCompletionNoticeSend_Desktop(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.App.DesktopNotifications = true
+	notice, err := newCompletionNotice(cfg, "audiobook")
+	require.NoError(t, err)
+	// Tests have no terminal, so the setting alone does not enable it
+	notice.enableDesktop(cfg)
+	assert.Nil(t, notice.desktop)
+
+	desktop := &fakeDesktop{}
+	notice.desktop = desktop
+	notice.payload.File = "/audio/book"
+	notice.addFile("/audio/book/001_one.mp3", 10, 1)
+	notice.addFile("/audio/book/002_two.mp3", 10, 1)
+
+	stderr := new(bytes.Buffer)
+	renderer := &Renderer{stdout: stderr, stderr: stderr}
+	notice.send(renderer, nil)
+	assert.Equal(t, []string{"assistant-cli audiobook finished"}, desktop.titles)
+	assert.Equal(t, []string{"Wrote 2 audio files to /audio/book"}, desktop.messages)
+	assert.Empty(t, stderr.String())
+
+	desktop.err = errors.New("notify-send failed")
+	notice.send(renderer, errors.New("chapter 2 (Two): quota exceeded"))
+	assert.Equal(t, "assistant-cli audiobook failed", desktop.titles[1])
+	assert.Equal(t, "chapter 2 (Two): quota exceeded", desktop.messages[1])
+	assert.Contains(t, stderr.String(), "failed to show desktop notification")
+}
+
+func TestDesktopMessage(t *testing.T) {
+	title, message := desktopMessage(notify.Payload{Command: "feed", Status: notify.StatusSuccess, File: "/feeds/blog"})
+	assert.Equal(t, "assistant-cli feed finished", title)
+	assert.Equal(t, "No new audio was synthesized", message)
+
+	_, message = desktopMessage(notify.Payload{Command: "feed", Status: notify.StatusSuccess, File: "/feeds/blog",
+		Files: []string{"/feeds/blog/001_post.mp3"}})
+	assert.Equal(t, "Wrote 1 audio file to /feeds/blog", message)
+
+	// A batch run whose outputs were all up to date
+	title, message = desktopMessage(notify.Payload{Command: "batch", Status: notify.StatusSuccess, File: "/audio"})
+	assert.Equal(t, "assistant-cli batch finished", title)
+	assert.Equal(t, "No new audio was synthesized", message)
+}
@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/mikefarmer/assistant-cli/internal/notify"
+	"github.com/mikefarmer/assistant-cli/internal/player"
+	"github.com/spf13/cobra"
+)
+
+var (
+	notifyWatchCommand string
+	notifyPatterns     []string
+	notifyRateLimit    int
+	notifyDedupWindow  time.Duration
+	notifyVoice        string
+)
+
+// maxAlertLineLength bounds the lines of watched output, which some
+// commands print as long JSON documents
+const maxAlertLineLength = 1024 * 1024
+
+// NewNotifyCmd creates the notify command
+func NewNotifyCmd() *cobra.Command {
+	notifyCmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Speak the lines of a command's output that match alert patterns",
+		Long: `Run a long-lived command, such as one following events or logs, and speak
+each line of its output that matches one of the patterns in alerts.patterns
+or given with --pattern. Without --watch-command the lines are read from
+STDIN. The output is passed through, so it can still be read, and the command
+runs until it exits or Ctrl+C is pressed.
+
+A pattern with groups speaks the text of its groups, so "Warning (\w+) .*?
+(pod/\S+)" reads "BackOff pod/web-1" rather than the whole line; a pattern
+without groups speaks the line. At most alerts.rate_limit alerts are spoken a
+minute, and an alert repeated within alerts.dedup_window is spoken once.
+Changes to alerts and tts in the configuration file apply to the lines that
+follow.
+
+Alerts are synthesized by 'assistant-cli daemon' when it is running, and
+otherwise in this process.
+
+Examples:
+  assistant-cli notify --watch-command "kubectl get events -w" --pattern "Warning\s+(\w+)"
+  tail -f /var/log/syslog | assistant-cli notify --pattern "(?i)disk.*full"
+  assistant-cli notify -w "journalctl -f" -p "Failed to start (.*)\." --rate-limit 2`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runNotify,
+	}
+
+	notifyCmd.Flags().StringVarP(&notifyWatchCommand, "watch-command", "w", "",
+		"Shell command whose output to watch (default: read STDIN)")
+	notifyCmd.Flags().StringArrayVarP(&notifyPatterns, "pattern", "p", nil,
+		"Regular expression of the lines to speak, in addition to alerts.patterns (repeatable)")
+	notifyCmd.Flags().IntVar(&notifyRateLimit, "rate-limit", 0,
+		"Alerts spoken per minute at most, 0 for no limit (default: alerts.rate_limit)")
+	notifyCmd.Flags().DurationVar(&notifyDedupWindow, "dedup-window", 0,
+		"Speak a repeated alert once within this time, 0 for every time (default: alerts.dedup_window)")
+	notifyCmd.Flags().StringVarP(&notifyVoice, "voice", "v", "", "Voice to use (default: tts.voice)")
+	addSocketFlag(notifyCmd)
+
+	return notifyCmd
+}
+
+// notifyResult is the machine-readable result of notify
+type notifyResult struct {
+	Lines       int `json:"lines"`
+	Spoken      int `json:"spoken"`
+	Duplicates  int `json:"duplicates"`
+	RateLimited int `json:"rate_limited"`
+	Failed      int `json:"failed"`
+}
+
+func runNotify(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if reason := playbackSkipReason(); reason != "" {
+		return usageError(fmt.Errorf("alerts cannot be spoken: playback is off (%s)", reason))
+	}
+	watcher, patterns, err := newAlertWatcher(cmd, GetConfig().Get().Alerts)
+	if err != nil {
+		return err
+	}
+
+	renderer := newRenderer(cmd)
+	speaker, err := newAlertSpeaker(ctx, renderer)
+	if err != nil {
+		return err
+	}
+	defer speaker.close()
+
+	// A changed configuration applies to the lines that follow, with the
+	// dedup window and rate limit starting over when alerts changed
+	var watcherMu sync.Mutex
+	watchConfig(ctx, renderer, func(change *config.Change) {
+		if speaker.handler != nil {
+			speaker.handler.reload(renderer, change)
+		}
+		if !change.Changed("alerts") {
+			return
+		}
+		next, _, err := newAlertWatcher(cmd, change.New.Alerts)
+		if err != nil {
+			renderer.Warnf("Warning: alerts not changed: %v\n", err)
+			return
+		}
+		watcherMu.Lock()
+		watcher = next
+		watcherMu.Unlock()
+	})
+
+	// Watched output goes where text output goes, keeping stdout a single
+	// JSON document in JSON mode
+	passthrough := cmd.OutOrStdout()
+	if renderer.IsJSON() {
+		passthrough = cmd.ErrOrStderr()
+	}
+
+	input := cmd.InOrStdin()
+	var watched *exec.Cmd
+	if notifyWatchCommand != "" {
+		watched = shellCommand(ctx, notifyWatchCommand)
+		watched.Stderr = cmd.ErrOrStderr()
+		stdout, err := watched.StdoutPipe()
+		if err != nil {
+			return ioError(err)
+		}
+		if err := watched.Start(); err != nil {
+			return ioError(fmt.Errorf("failed to start %q: %w", notifyWatchCommand, err))
+		}
+		input = stdout
+		statusf(os.Stderr, "Watching %q for %d patterns; press Ctrl+C to stop\n", notifyWatchCommand, patterns)
+	} else {
+		statusf(os.Stderr, "Watching STDIN for %d patterns\n", patterns)
+	}
+
+	// Alerts are spoken one after another while lines keep being read, so
+	// a slow synthesis does not stall the watched command
+	queue := make(chan string, 16)
+	spoken := make(chan struct{})
+	result := &notifyResult{}
+	go func() {
+		defer close(spoken)
+		for message := range queue {
+			if ctx.Err() != nil {
+				continue
+			}
+			if err := speaker.speak(ctx, message); err != nil {
+				if ctx.Err() == nil {
+					renderer.Warnf("Warning: alert %q not spoken: %v\n", message, err)
+					result.Failed++
+				}
+				continue
+			}
+			result.Spoken++
+		}
+	}()
+
+	readErr := watchLines(ctx, input, passthrough, func(line string) {
+		result.Lines++
+		watcherMu.Lock()
+		message, verdict := watcher.Check(line)
+		watcherMu.Unlock()
+		switch verdict {
+		case notify.Alert:
+			detailf(os.Stderr, "Alert: %s\n", message)
+			select {
+			case queue <- message:
+			case <-ctx.Done():
+			}
+		case notify.Duplicate:
+			result.Duplicates++
+		case notify.RateLimited:
+			detailf(os.Stderr, "Rate limit reached, not speaking: %s\n", message)
+			result.RateLimited++
+		}
+	})
+	close(queue)
+	<-spoken
+
+	if watched != nil {
+		// The command may not have finished when reading was interrupted
+		if ctx.Err() != nil && watched.Process != nil {
+			_ = watched.Process.Kill()
+		}
+		if err := watched.Wait(); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("watched command failed: %w", err)
+		}
+	}
+	if readErr != nil && ctx.Err() == nil {
+		return ioError(fmt.Errorf("failed to read output: %w", readErr))
+	}
+
+	return renderer.Result(result, func(w io.Writer) {
+		statusf(w, "%s Spoke %d alerts from %d lines (%d duplicates, %d over the rate limit, %d failed)\n",
+			styleFor(w).Success(), result.Spoken, result.Lines, result.Duplicates, result.RateLimited, result.Failed)
+	})
+}
+
+// newAlertWatcher creates the watcher of the patterns in alerts and
+// --pattern, with the limits of alerts unless given by flags, returning it
+// with the number of patterns
+func newAlertWatcher(cmd *cobra.Command, alerts config.AlertsConfig) (*notify.Watcher, int, error) {
+	patterns := append(append([]string{}, alerts.Patterns...), notifyPatterns...)
+	if len(patterns) == 0 {
+		return nil, 0, usageError(errors.New("no patterns to watch for; give --pattern or set alerts.patterns"))
+	}
+	if cmd.Flags().Changed("rate-limit") {
+		alerts.RateLimit = notifyRateLimit
+	}
+	if cmd.Flags().Changed("dedup-window") {
+		alerts.DedupWindow = notifyDedupWindow
+	}
+	if alerts.RateLimit < 0 || alerts.DedupWindow < 0 {
+		return nil, 0, usageError(errors.New("--rate-limit and --dedup-window cannot be negative"))
+	}
+	watcher, err := notify.NewWatcher(patterns, alerts.RateLimit, alerts.DedupWindow)
+	if err != nil {
+		return nil, 0, usageError(err)
+	}
+	return watcher, len(patterns), nil
+}
+
+// watchLines passes each line of in through to out and to check until in
+// ends or ctx is done
+func watchLines(ctx context.Context, in io.Reader, out io.Writer, check func(line string)) error {
+	lines := make(chan string)
+	scanned := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxAlertLineLength)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanned <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				select {
+				case err := <-scanned:
+					return err
+				default:
+					return nil
+				}
+			}
+			_, _ = fmt.Fprintln(out, line)
+			check(line)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// shellCommand runs command with the shell of the platform
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// alertSpeaker synthesizes and plays alerts, with the daemon when one is
+// running and otherwise with a provider created once for the whole watch
+type alertSpeaker struct {
+	client  *daemon.Client
+	handler *daemonHandler
+	player  *player.AudioPlayer
+}
+
+// newAlertSpeaker connects to the daemon, or without --socket creates the
+// provider when no daemon is running
+func newAlertSpeaker(ctx context.Context, renderer *Renderer) (*alertSpeaker, error) {
+	audioPlayer, err := newConfiguredPlayer(renderer)
+	if err != nil {
+		return nil, err
+	}
+	speaker := &alertSpeaker{player: audioPlayer}
+
+	client, err := dialDaemon(ctx)
+	if err == nil {
+		speaker.client = client
+		return speaker, nil
+	}
+	if daemonSocket != "" || !errors.Is(err, daemon.ErrNotRunning) {
+		return nil, err
+	}
+
+	detailf(os.Stderr, "No daemon is running; synthesizing in this process\n")
+	cfg := GetConfig().Get()
+	if err := validateRequestVoice(&daemon.Request{Voice: notifyVoice}, cfg); err != nil {
+		return nil, err
+	}
+	speaker.handler, err = newDaemonHandler(ctx, renderer, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return speaker, nil
+}
+
+// speak synthesizes message and plays it to the end
+func (s *alertSpeaker) speak(ctx context.Context, message string) error {
+	request := &daemon.Request{Text: message, Voice: notifyVoice}
+	var resp *daemon.Response
+	var err error
+	if s.client != nil {
+		resp, err = s.client.Synthesize(ctx, request)
+	} else {
+		resp, err = s.handler.synthesize(ctx, request)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Temporary {
+		defer func() { _ = os.Remove(resp.File) }()
+	}
+
+	return playUntilDone(ctx, s.player, resp.File)
+}
+
+// close disconnects from the daemon or closes the provider
+func (s *alertSpeaker) close() {
+	if s.client != nil {
+		_ = s.client.Close()
+	}
+	if s.handler != nil {
+		_ = s.handler.provider.Close()
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runNotifyCommand runs notify with stdin as its input
+func runNotifyCommand(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		notifyWatchCommand = ""
+		notifyPatterns = nil
+		notifyRateLimit = 0
+		notifyDedupWindow = 0
+		notifyVoice = ""
+		daemonSocket = ""
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetIn(strings.NewReader(stdin))
+	rootCmd.SetArgs(append([]string{"notify"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestNotifyWatchCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\nalerts:\n  patterns: [\"Warning\\\\s+(\\\\w+)\"]\n")
+
+	stdout, err := runNotifyCommand(t, "", "--config", config,
+		"--watch-command", "printf 'Normal Pulled\\nWarning BackOff\\nWarning BackOff\\nError: disk full\\n'",
+		"--pattern", "^Error: (.*)")
+	require.NoError(t, err)
+	// The watched output is passed through
+	assert.Contains(t, stdout, "Normal Pulled\nWarning BackOff\n")
+	assert.Contains(t, stdout, "Spoke 2 alerts from 4 lines (1 duplicates")
+	assert.Len(t, played(), 2)
+}
+
+func TestNotifyStdin(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runNotifyCommand(t, "fail one\nfail two\nfail three\n", "--config", config,
+		"--output-format", "json", "--pattern", "fail", "--rate-limit", "2")
+	require.NoError(t, err)
+
+	var result struct {
+		Data notifyResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, notifyResult{Lines: 3, Spoken: 2, RateLimited: 1}, result.Data)
+	assert.Len(t, played(), 2)
+}
+
+func TestNotifyErrors(t *testing.T) {
+	fakePlayerOnPath(t)
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	_, err := runNotifyCommand(t, "", "--config", config)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.ErrorContains(t, err, "no patterns to watch for")
+
+	_, err = runNotifyCommand(t, "", "--config", config, "--pattern", "(unclosed")
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	_, err = runNotifyCommand(t, "", "--config", config, "--pattern", "x", "--no-play")
+	assert.ErrorContains(t, err, "playback is off")
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanKeep      int
+	cleanOlderThan string
+	cleanRecursive bool
+	cleanDryRun    bool
+)
+
+// NewOutputCmd creates the output command and its subcommands
+func NewOutputCmd() *cobra.Command {
+	outputCmd := &cobra.Command{
+		Use:   "output",
+		Short: "Manage generated output files",
+		Long:  `Manage the files written by synthesize and audiobook, such as the backups kept of overwritten files.`,
+	}
+
+	outputCmd.AddCommand(newCleanBackupsCmd())
+	return outputCmd
+}
+
+func newCleanBackupsCmd() *cobra.Command {
+	cleanCmd := &cobra.Command{
+		Use:   "clean-backups [DIR]",
+		Short: "Remove old backups of overwritten files",
+		Long: `Remove the backups made in output.overwrite_mode "backup" that the retention
+no longer keeps. DIR defaults to output.default_path.
+
+The retention comes from output.backup_retention unless --keep or --older-than
+is given. Only files named like backups (<file>.backup_<date>_<time>) are
+considered; other files and symlinks are never touched. Use --dry-run to list
+what would be removed first.
+
+Examples:
+  assistant-cli output clean-backups --keep 3
+  assistant-cli output clean-backups ./audio --older-than 30d --dry-run
+  assistant-cli output clean-backups ./books -r`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runCleanBackups,
+	}
+
+	cleanCmd.Flags().IntVar(&cleanKeep, "keep", 0, "Keep this many of the newest backups of each file")
+	cleanCmd.Flags().StringVar(&cleanOlderThan, "older-than", "", "Remove backups older than this age, e.g. 72h or 30d")
+	cleanCmd.Flags().BoolVarP(&cleanRecursive, "recursive", "r", false, "Also clean subdirectories")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List the backups that would be removed")
+
+	return cleanCmd
+}
+
+// cleanBackupsResult is the machine-readable result of output clean-backups
+type cleanBackupsResult struct {
+	Directory string          `json:"directory"`
+	Retention string          `json:"retention"`
+	DryRun    bool            `json:"dry_run,omitempty"`
+	Removed   []output.Backup `json:"removed"`
+	Bytes     int64           `json:"bytes"`
+}
+
+func runCleanBackups(cmd *cobra.Command, args []string) error {
+	cfg := GetConfig().Get()
+
+	retention, err := cleanRetention(cmd, cfg.Output.BackupRetention)
+	if err != nil {
+		return err
+	}
+
+	dir := cfg.Output.DefaultPath
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	dirs := []string{dir}
+	if cleanRecursive {
+		if dirs, err = subdirectories(dir); err != nil {
+			return ioError(err)
+		}
+	}
+
+	result := &cleanBackupsResult{Directory: dir, Retention: describeRetention(retention), DryRun: cleanDryRun,
+		Removed: []output.Backup{}}
+	for _, d := range dirs {
+		removed, err := output.CleanBackups(d, retention, cleanDryRun)
+		result.Removed = append(result.Removed, removed...)
+		if err != nil {
+			return ioError(withResult(err, result))
+		}
+	}
+	for _, backup := range result.Removed {
+		result.Bytes += backup.Size
+	}
+
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		verb := "Removed"
+		if cleanDryRun {
+			verb = "Would remove"
+		}
+		for _, backup := range result.Removed {
+			detailf(w, "  %s %s\n", verb, backup.Path)
+		}
+		fmt.Fprintf(w, "%s %s %d backups (%s) from %s, keeping %s\n", styleFor(w).Success(), verb,
+			len(result.Removed), formatByteCount(result.Bytes), dir, result.Retention)
+	})
+}
+
+// cleanRetention returns the retention set by --keep and --older-than, or
+// output.backup_retention without them
+func cleanRetention(cmd *cobra.Command, configured string) (output.BackupRetention, error) {
+	if !cmd.Flags().Changed("keep") && !cmd.Flags().Changed("older-than") {
+		retention, err := output.ParseBackupRetention(configured)
+		if err != nil {
+			return retention, validationError(err)
+		}
+		if retention.IsZero() {
+			return retention, usageError(fmt.Errorf("no backup retention is set: " +
+				"use --keep or --older-than, or set output.backup_retention"))
+		}
+		return retention, nil
+	}
+
+	var retention output.BackupRetention
+	if cmd.Flags().Changed("keep") {
+		if cleanKeep < 1 {
+			return retention, usageError(fmt.Errorf("--keep must keep at least 1 backup, got %d", cleanKeep))
+		}
+		retention.MaxCount = cleanKeep
+	}
+	if cleanOlderThan != "" {
+		age, err := output.ParseBackupRetention(cleanOlderThan)
+		if err != nil || age.MaxAge <= 0 {
+			return retention, usageError(fmt.Errorf("invalid --older-than %q: expected an age such as 72h or 30d",
+				cleanOlderThan))
+		}
+		retention.MaxAge = age.MaxAge
+	}
+	return retention, nil
+}
+
+// describeRetention describes what a retention keeps
+func describeRetention(retention output.BackupRetention) string {
+	switch {
+	case retention.MaxCount > 0 && retention.MaxAge > 0:
+		return fmt.Sprintf("the %d newest within %s", retention.MaxCount, formatAge(retention.MaxAge))
+	case retention.MaxAge > 0:
+		return "those within " + formatAge(retention.MaxAge)
+	default:
+		return fmt.Sprintf("the %d newest", retention.MaxCount)
+	}
+}
+
+// formatAge formats an age in days when it is a whole number of them
+func formatAge(age time.Duration) string {
+	const day = 24 * time.Hour
+	if age%day == 0 {
+		return fmt.Sprintf("%dd", age/day)
+	}
+	return age.String()
+}
+
+// subdirectories returns dir and every directory below it, without
+// following symlinks
+func subdirectories(dir string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs, err
+}
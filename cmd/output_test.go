@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCleanBackupsCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		cleanKeep = 0
+		cleanOlderThan = ""
+		cleanRecursive = false
+		cleanDryRun = false
+		outputFormat = outputFormatText
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"output", "clean-backups"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+// writeTestBackups creates count backups of name in dir, the first one the
+// oldest, and returns their paths
+func writeTestBackups(t *testing.T, dir, name string, count int) []string {
+	t.Helper()
+	paths := make([]string, count)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, name+".backup_20260101_120000_"+string(rune('1'+i)))
+		require.NoError(t, os.WriteFile(paths[i], []byte("old"), 0600))
+		modified := time.Now().Add(-time.Duration(count-i) * time.Hour)
+		require.NoError(t, os.Chtimes(paths[i], modified, modified))
+	}
+	return paths
+}
+
+func TestCleanBackupsCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	backups := writeTestBackups(t, dir, "a.mp3", 3)
+	sub := filepath.Join(dir, "book")
+	require.NoError(t, os.Mkdir(sub, 0700))
+	nested := writeTestBackups(t, sub, "001_Start.mp3", 2)
+	unrelated := filepath.Join(dir, "a.mp3")
+	require.NoError(t, os.WriteFile(unrelated, []byte("new"), 0600))
+
+	// A dry run lists without removing
+	stdout, err := runCleanBackupsCommand(t, dir, "--keep", "1", "--dry-run", "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data cleanBackupsResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Data.DryRun)
+	assert.Len(t, result.Data.Removed, 2)
+	assert.Equal(t, int64(6), result.Data.Bytes)
+	for _, path := range backups {
+		assert.FileExists(t, path)
+	}
+
+	stdout, err = runCleanBackupsCommand(t, dir, "--keep", "1", "-r")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Removed 3 backups")
+	assert.NoFileExists(t, backups[0])
+	assert.NoFileExists(t, backups[1])
+	assert.FileExists(t, backups[2])
+	assert.NoFileExists(t, nested[0])
+	assert.FileExists(t, nested[1])
+	assert.FileExists(t, unrelated)
+}
+
+func TestCleanBackupsCommand_ConfiguredRetention(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	backups := writeTestBackups(t, dir, "a.mp3", 2)
+
+	// Without flags or a configured retention nothing is removed
+	_, err := runCleanBackupsCommand(t, dir)
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	config := writeTestConfig(t, "output:\n  backup_retention: \"90m\"\n")
+	_, err = runCleanBackupsCommand(t, dir, "--config", config)
+	require.NoError(t, err)
+	assert.NoFileExists(t, backups[0])
+	assert.FileExists(t, backups[1])
+}
+
+func TestCleanBackupsCommand_InvalidFlags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, args := range [][]string{{"--keep", "0"}, {"--older-than", "soon"}, {"a", "b"}} {
+		_, err := runCleanBackupsCommand(t, args...)
+		require.Error(t, err, args)
+		assert.Equal(t, ExitUsage, ExitCode(err), args)
+	}
+}
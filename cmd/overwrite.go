@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// newOverwriteHandler returns a file handler applying output.overwrite_mode
+// to existing output files, or overwriting them when force is set. The
+// prompt mode asks on the terminal when stdin and stdout are both attached
+// to one and applies output.overwrite_fallback otherwise. Backups are pruned
+// to output.backup_retention, and paths are checked against
+// output.path_policy.
+func newOverwriteHandler(cfg config.OutputConfig, force bool) (*output.FileHandler, error) {
+	retention, err := output.ParseBackupRetention(cfg.BackupRetention)
+	if err != nil {
+		return nil, validationError(err)
+	}
+	if force {
+		handler := output.NewFileHandlerWithOptions(".", false, output.OverwriteAlways)
+		handler.SetBackupRetention(retention)
+		handler.SetPathPolicy(outputPathPolicy(cfg.PathPolicy))
+		return handler, nil
+	}
+
+	mode, err := output.ParseOverwriteMode(cfg.OverwriteMode)
+	if err != nil {
+		return nil, validationError(err)
+	}
+	fallback := output.OverwriteNever
+	if cfg.OverwriteFallback != "" {
+		if fallback, err = output.ParseOverwriteMode(cfg.OverwriteFallback); err != nil {
+			return nil, validationError(err)
+		}
+	}
+
+	handler := output.NewFileHandlerWithOptions(".", false, mode)
+	handler.SetBackupRetention(retention)
+	handler.SetPathPolicy(outputPathPolicy(cfg.PathPolicy))
+	handler.SetPrompter(overwritePrompter(), fallback)
+	return handler, nil
+}
+
+// overwritePrompter returns the prompter asking on the terminal, or nil when
+// stdin and stdout are not both attached to one; tests replace it
+var overwritePrompter = func() output.Prompter {
+	if isInteractive() && isTerminal(os.Stdout) {
+		return output.NewTerminalPrompter(os.Stdin, os.Stderr)
+	}
+	return nil
+}
+
+// prepareError classifies an error preparing an output file: a path the
+// policy refuses is a validation error, anything else an I/O error
+func prepareError(err error) error {
+	if errors.Is(err, output.ErrPathNotAllowed) {
+		return validationError(err)
+	}
+	return ioError(err)
+}
+
+// outputPathPolicy converts config.PathPolicyConfig to output.PathPolicy,
+// expanding a leading ~ in the directories
+func outputPathPolicy(cfg config.PathPolicyConfig) output.PathPolicy {
+	return output.PathPolicy{
+		AllowedDirs:       expandHomeDirs(cfg.AllowedDirs),
+		BlockedDirs:       expandHomeDirs(cfg.BlockedDirs),
+		AllowedExtensions: cfg.AllowedExtensions,
+		BlockedExtensions: cfg.BlockedExtensions,
+	}
+}
+
+// expandHomeDirs replaces a leading ~ in each of dirs with the home directory
+func expandHomeDirs(dirs []string) []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dirs
+	}
+	expanded := make([]string, len(dirs))
+	for i, dir := range dirs {
+		if dir == "~" || strings.HasPrefix(dir, "~/") {
+			dir = filepath.Join(home, dir[1:])
+		}
+		expanded[i] = dir
+	}
+	return expanded
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOverwriteHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.OutputConfig
+		force   bool
+		wantErr bool
+		backup  bool
+	}{
+		{"backup", config.OutputConfig{OverwriteMode: "backup"}, false, false, true},
+		{"never", config.OutputConfig{OverwriteMode: "never"}, false, true, false},
+		{"force", config.OutputConfig{OverwriteMode: "never"}, true, false, false},
+		// Tests have no terminal, so prompt applies the fallback
+		{"prompt without fallback", config.OutputConfig{OverwriteMode: "prompt"}, false, true, false},
+		{"prompt falling back to backup", config.OutputConfig{OverwriteMode: "prompt", OverwriteFallback: "backup"},
+			false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.mp3")
+			require.NoError(t, os.WriteFile(path, []byte("old"), 0600))
+
+			handler, err := newOverwriteHandler(tt.cfg, tt.force)
+			require.NoError(t, err)
+			info, err := handler.PrepareOverwrite(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.backup, info.BackupPath != "")
+		})
+	}
+}
+
+// answerPrompts makes overwrite prompts read answers and returns what they
+// ask
+func answerPrompts(t *testing.T, answers string) *bytes.Buffer {
+	t.Helper()
+	asked := new(bytes.Buffer)
+	previous := overwritePrompter
+	overwritePrompter = func() output.Prompter {
+		return output.NewTerminalPrompter(strings.NewReader(answers), asked)
+	}
+	t.Cleanup(func() { overwritePrompter = previous })
+	return asked
+}
+
+func TestNewOverwriteHandler_Prompt(t *testing.T) {
+	asked := answerPrompts(t, "y\nd\n")
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i, name := range []string{"a.mp3", "b.mp3", "c.mp3"} {
+		paths[i] = filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(paths[i], []byte("old"), 0600))
+	}
+
+	handler, err := newOverwriteHandler(config.OutputConfig{OverwriteMode: "prompt"}, false)
+	require.NoError(t, err)
+	_, err = handler.PrepareOverwrite(paths[0])
+	assert.NoError(t, err)
+	// "Don't overwrite any" keeps this file and the next without asking
+	_, err = handler.PrepareOverwrite(paths[1])
+	assert.ErrorIs(t, err, output.ErrOverwriteDeclined)
+	_, err = handler.PrepareOverwrite(paths[2])
+	assert.ErrorIs(t, err, output.ErrOverwriteDeclined)
+	assert.Equal(t, 2, strings.Count(asked.String(), "Overwrite "))
+}
+
+func TestNewOverwriteHandler_InvalidMode(t *testing.T) {
+	_, err := newOverwriteHandler(config.OutputConfig{OverwriteMode: "sometimes"}, false)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+func TestOutputPathPolicy(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	policy := outputPathPolicy(config.PathPolicyConfig{
+		AllowedDirs:       []string{"~/audio", "/srv/audio"},
+		BlockedExtensions: []string{".exe"},
+	})
+	assert.Equal(t, []string{filepath.Join(home, "audio"), "/srv/audio"}, policy.AllowedDirs)
+	assert.Equal(t, []string{".exe"}, policy.BlockedExtensions)
+	assert.NoError(t, policy.Check(filepath.Join(home, "audio", "out.mp3")))
+	assert.Error(t, policy.Check(filepath.Join(home, "out.mp3")))
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mikefarmer/assistant-cli/internal/player"
+)
+
+// playbackController is the part of player.Playback driven by keyboard
+// controls
+type playbackController interface {
+	Pause() error
+	Resume() error
+	Stop() error
+	Status() player.State
+	Done() <-chan struct{}
+}
+
+// playbackSkipper is implemented by controllers playing several files
+type playbackSkipper interface {
+	Skip() error
+}
+
+// controlPlayback applies key presses to playback until it ends: space (or
+// p) toggles pause, n skips to the next file of a queue, and q stops
+func controlPlayback(playback playbackController, keys <-chan byte, out io.Writer) {
+	for {
+		select {
+		case <-playback.Done():
+			return
+		case key, ok := <-keys:
+			if !ok {
+				// No more input; let playback run to the end
+				keys = nil
+				continue
+			}
+			switch key {
+			case ' ', 'p', 'P':
+				togglePause(playback, out)
+			case 'n', 'N':
+				if skipper, ok := playback.(playbackSkipper); ok {
+					if err := skipper.Skip(); err != nil {
+						fmt.Fprintf(out, "Warning: %v\n", err)
+						continue
+					}
+					fmt.Fprintln(out, "⏭ Skipped")
+				}
+			case 'q', 'Q':
+				if err := playback.Stop(); err != nil {
+					fmt.Fprintf(out, "Warning: %v\n", err)
+					continue
+				}
+				fmt.Fprintln(out, "■ Stopped")
+			}
+		}
+	}
+}
+
+func togglePause(playback playbackController, out io.Writer) {
+	var err error
+	if playback.Status() == player.StatePaused {
+		if err = playback.Resume(); err == nil {
+			fmt.Fprintln(out, "▶ Resumed")
+		}
+	} else {
+		if err = playback.Pause(); err == nil {
+			fmt.Fprintln(out, "⏸ Paused")
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Warning: %v\n", err)
+	}
+}
+
+// startKeyControls lets the user control playback from the terminal until
+// it ends, printing help first. It returns nil when stdin is not a terminal;
+// otherwise the returned function must be called after playback has ended
+// to restore the terminal.
+func startKeyControls(playback playbackController, help string) func() {
+	if !isInteractive() {
+		return nil
+	}
+	restore, err := enableKeyInput(os.Stdin)
+	if err != nil {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "  %s\n", help)
+	keys := watchKeys(os.Stdin, playback.Done())
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		controlPlayback(playback, keys, os.Stderr)
+		for range keys {
+			// Wait for the reader before restoring the terminal
+		}
+	}()
+
+	return func() {
+		<-finished
+		restore()
+	}
+}
+
+// playQueue plays files as a batch command produces them, with keyboard
+// controls in a terminal
+type playQueue struct {
+	queue        *player.Queue
+	stopControls func()
+	once         sync.Once
+}
+
+// startPlayQueue starts an empty queue on the configured player
+func startPlayQueue(renderer *Renderer) (*playQueue, error) {
+	audioPlayer, err := newConfiguredPlayer(renderer)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := player.NewQueue(audioPlayer)
+	return &playQueue{
+		queue:        queue,
+		stopControls: startKeyControls(queue, "space: pause/resume, n: next, q: stop"),
+	}, nil
+}
+
+// Add queues a finished file for playback
+func (p *playQueue) Add(filePath string) {
+	p.queue.Add(filePath)
+}
+
+// Finish waits until every queued file has been played, or playback was
+// stopped, and restores the terminal
+func (p *playQueue) Finish() error {
+	p.queue.Close()
+	err := p.queue.Wait()
+	p.release()
+	return err
+}
+
+// Stop ends playback at once, e.g. when the batch failed
+func (p *playQueue) Stop() {
+	_ = p.queue.Stop()
+	p.release()
+}
+
+func (p *playQueue) release() {
+	p.once.Do(func() {
+		if p.stopControls != nil {
+			p.stopControls()
+		}
+	})
+}
+
+// startBatchPlayback starts a play queue for a batch command when enabled.
+// Batch output is still written when playback is unavailable, so failures
+// are only warnings and nil is returned.
+func startBatchPlayback(renderer *Renderer, enabled bool) *playQueue {
+	if !enabled || skipPlayback() {
+		return nil
+	}
+	queue, err := startPlayQueue(renderer)
+	if err != nil {
+		renderer.Warnf("Warning: Failed to play audio: %v\n", err)
+		return nil
+	}
+	return queue
+}
+
+// finishBatchPlayback waits for a batch play queue to finish and reports
+// whether the files were played
+func finishBatchPlayback(renderer *Renderer, queue *playQueue) bool {
+	if queue == nil {
+		return false
+	}
+	if err := queue.Finish(); err != nil {
+		renderer.Warnf("Warning: Failed to play audio: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// watchKeys reads single key presses from the terminal until done is
+// closed. The returned channel is closed once reading has stopped, after
+// which the terminal may be restored.
+func watchKeys(terminal *os.File, done <-chan struct{}) <-chan byte {
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 1)
+		for {
+			// Reads time out regularly (see enableKeyInput) so done is
+			// noticed without a key press
+			n, err := terminal.Read(buf)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return
+			}
+			if n == 1 {
+				select {
+				case keys <- buf[0]:
+				case <-done:
+					return
+				}
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+	return keys
+}
+
+// playbackSkipReason returns why requested playback is skipped: --no-play,
+// or a headless environment. It returns "" when audio can be played.
+func playbackSkipReason() string {
+	if noPlay {
+		return "--no-play is set"
+	}
+	if headless, reason := player.Headless(); headless {
+		return reason + "; set " + player.ForcePlaybackEnv + "=1 to play anyway"
+	}
+	return ""
+}
+
+// skipPlayback reports skipped playback on stderr, returning true when
+// playback is skipped
+func skipPlayback() bool {
+	reason := playbackSkipReason()
+	if reason == "" {
+		return false
+	}
+	statusf(os.Stderr, "Skipping playback: %s\n", reason)
+	return true
+}
+
+// playUntilDone plays filePath with audioPlayer to the end, stopping it when
+// ctx is done, for long-running commands that speak one file after another
+func playUntilDone(ctx context.Context, audioPlayer *player.AudioPlayer, filePath string) error {
+	playback, err := audioPlayer.Start(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to play audio: %w", err)
+	}
+	select {
+	case <-playback.Done():
+	case <-ctx.Done():
+		_ = playback.Stop()
+		<-playback.Done()
+	}
+	return nil
+}
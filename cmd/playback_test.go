@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/player"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePlayback records control calls
+type fakePlayback struct {
+	state    player.State
+	done     chan struct{}
+	pauseErr error
+	calls    []string
+}
+
+func newFakePlayback() *fakePlayback {
+	return &fakePlayback{state: player.StatePlaying, done: make(chan struct{})}
+}
+
+func (f *fakePlayback) Pause() error {
+	f.calls = append(f.calls, "pause")
+	if f.pauseErr != nil {
+		return f.pauseErr
+	}
+	f.state = player.StatePaused
+	return nil
+}
+
+func (f *fakePlayback) Resume() error {
+	f.calls = append(f.calls, "resume")
+	f.state = player.StatePlaying
+	return nil
+}
+
+func (f *fakePlayback) Stop() error {
+	f.calls = append(f.calls, "stop")
+	f.state = player.StateStopped
+	close(f.done)
+	return nil
+}
+
+func (f *fakePlayback) Status() player.State  { return f.state }
+func (f *fakePlayback) Done() <-chan struct{} { return f.done }
+
+// fakeQueue is a fakePlayback that can skip
+type fakeQueue struct {
+	*fakePlayback
+}
+
+func (f *fakeQueue) Skip() error {
+	f.calls = append(f.calls, "skip")
+	return nil
+}
+
+func sendKeys(keys ...byte) <-chan byte {
+	ch := make(chan byte, len(keys))
+	for _, key := range keys {
+		ch <- key
+	}
+	return ch
+}
+
+func TestControlPlayback(t *testing.T) {
+	playback := newFakePlayback()
+	out := new(bytes.Buffer)
+
+	controlPlayback(playback, sendKeys(' ', 'x', 'p', 'q'), out)
+
+	assert.Equal(t, []string{"pause", "resume", "stop"}, playback.calls)
+	assert.Equal(t, "⏸ Paused\n▶ Resumed\n■ Stopped\n", out.String())
+}
+
+func TestControlPlayback_PauseUnsupported(t *testing.T) {
+	playback := newFakePlayback()
+	playback.pauseErr = player.ErrPauseUnsupported
+	out := new(bytes.Buffer)
+
+	controlPlayback(playback, sendKeys(' ', 'q'), out)
+
+	assert.Equal(t, []string{"pause", "stop"}, playback.calls)
+	assert.Contains(t, out.String(), "Warning: pausing playback is not supported")
+}
+
+func TestControlPlayback_InputClosed(t *testing.T) {
+	playback := newFakePlayback()
+	keys := make(chan byte)
+	close(keys)
+
+	finished := make(chan struct{})
+	go func() {
+		controlPlayback(playback, keys, new(bytes.Buffer))
+		close(finished)
+	}()
+
+	// Playback keeps running until it ends by itself
+	close(playback.done)
+	<-finished
+	assert.Empty(t, playback.calls)
+}
+
+func TestControlPlayback_Skip(t *testing.T) {
+	// Single playbacks ignore n
+	playback := newFakePlayback()
+	controlPlayback(playback, sendKeys('n', 'q'), new(bytes.Buffer))
+	assert.Equal(t, []string{"stop"}, playback.calls)
+
+	queue := &fakeQueue{newFakePlayback()}
+	out := new(bytes.Buffer)
+	controlPlayback(queue, sendKeys('n', 'N', 'q'), out)
+	assert.Equal(t, []string{"skip", "skip", "stop"}, queue.calls)
+	assert.Equal(t, "⏭ Skipped\n⏭ Skipped\n■ Stopped\n", out.String())
+}
+
+func TestPlaybackSkipReason(t *testing.T) {
+	t.Setenv(player.ForcePlaybackEnv, "1")
+	assert.Empty(t, playbackSkipReason())
+
+	noPlay = true
+	t.Cleanup(func() { noPlay = false })
+	assert.Equal(t, "--no-play is set", playbackSkipReason())
+
+	noPlay = false
+	t.Setenv(player.ForcePlaybackEnv, "")
+	t.Setenv("CI", "true")
+	assert.Contains(t, playbackSkipReason(), "running in CI (CI is set)")
+	assert.Contains(t, playbackSkipReason(), player.ForcePlaybackEnv)
+}
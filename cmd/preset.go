@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/pkg/utils/suggest"
+	"github.com/spf13/cobra"
+)
+
+// presetFlag is the --preset flag of the commands that synthesize speech
+var presetFlag string
+
+var (
+	presetSaveVoice    string
+	presetSaveLanguage string
+	presetSaveSpeed    float64
+	presetSavePitch    float64
+	presetSaveVolume   float64
+	presetSaveFormat   string
+	presetSaveEffects  []string
+)
+
+// NewPresetCmd creates the preset command and its subcommands
+func NewPresetCmd() *cobra.Command {
+	presetCmd := &cobra.Command{
+		Use:   "preset",
+		Short: "Manage named voice presets",
+		Long: `Manage the named sets of synthesis parameters in tts.presets, such as a
+"podcast" voice with its own rate, pitch, and effects profile. Select one with
+--preset on synthesize, audiobook, or feed; flags given alongside it still win.`,
+	}
+
+	presetCmd.AddCommand(newPresetListCmd())
+	presetCmd.AddCommand(newPresetSaveCmd())
+	return presetCmd
+}
+
+func newPresetListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the configured voice presets",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runPresetList,
+	}
+}
+
+func newPresetSaveCmd() *cobra.Command {
+	saveCmd := &cobra.Command{
+		Use:   "save NAME",
+		Short: "Save flag values as a voice preset",
+		Long: `Save the settings given as flags as the preset NAME in the config file,
+replacing a preset of that name. Only the flags given are saved; the others
+keep the configured value when the preset is used.
+
+The config file given with --config is edited, otherwise the one that was
+loaded, otherwise ~/.assistant-cli.yaml is created.
+
+Examples:
+  assistant-cli preset save podcast --voice en-US-Studio-O --speed 1.1 --pitch -1
+  assistant-cli preset save car --effects car-class-device --volume 4
+  assistant-cli synthesize --preset podcast "Welcome back to the show"`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runPresetSave,
+	}
+
+	saveCmd.Flags().StringVarP(&presetSaveVoice, "voice", "v", "", "Voice name (e.g., en-US-Studio-O)")
+	saveCmd.Flags().StringVarP(&presetSaveLanguage, "language", "l", "", "Language code (e.g., en-US)")
+	saveCmd.Flags().Float64VarP(&presetSaveSpeed, "speed", "s", 1.0, "Speaking rate (0.25 to 4.0)")
+	saveCmd.Flags().Float64VarP(&presetSavePitch, "pitch", "p", 0.0, "Voice pitch (-20.0 to 20.0)")
+	saveCmd.Flags().Float64VarP(&presetSaveVolume, "volume", "g", 0.0, "Volume gain in dB (-96.0 to 16.0)")
+	saveCmd.Flags().StringVarP(&presetSaveFormat, "format", "f", "", "Audio format (MP3, LINEAR16, OGG_OPUS, ...)")
+	saveCmd.Flags().StringSliceVar(&presetSaveEffects, "effects", nil,
+		"Audio effects profiles, comma-separated (e.g., large-home-entertainment-class-device)")
+
+	return saveCmd
+}
+
+// addPresetFlag adds --preset to a command that synthesizes speech
+func addPresetFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&presetFlag, "preset", "", "Use the settings of this tts.presets entry")
+}
+
+// applyPreset returns a copy of cfg with the settings of the --preset entry
+// of tts.presets in place of the configured ones, except those given with a
+// flag of cmd. A preset format replaces *format unless --format was given.
+// Without --preset cfg is returned as is.
+func applyPreset(cmd *cobra.Command, cfg *config.Config, format *string) (*config.Config, error) {
+	if presetFlag == "" {
+		return cfg, nil
+	}
+
+	name := strings.ToLower(presetFlag)
+	preset, ok := cfg.TTS.Presets[name]
+	if !ok {
+		message := fmt.Sprintf("unknown preset %q", presetFlag)
+		if match, ok := suggest.Closest(name, presetNames(cfg.TTS.Presets)); ok {
+			message += fmt.Sprintf("; did you mean %s?", match)
+		}
+		return nil, usageError(fmt.Errorf("%s\nRun 'assistant-cli preset list' to see the configured presets", message))
+	}
+
+	changed := cmd.Flags().Changed
+	result := *cfg
+	if preset.Voice != "" && !changed("voice") {
+		result.TTS.Voice = preset.Voice
+	}
+	if preset.Language != "" && !changed("language") {
+		result.TTS.Language = preset.Language
+	}
+	if preset.SpeakingRate != 0 && !changed("speed") {
+		result.TTS.SpeakingRate = preset.SpeakingRate
+	}
+	if preset.Pitch != 0 && !changed("pitch") {
+		result.TTS.Pitch = preset.Pitch
+	}
+	if preset.VolumeGain != 0 && !changed("volume") {
+		result.TTS.VolumeGain = preset.VolumeGain
+	}
+	if len(preset.Effects) > 0 {
+		result.TTS.EffectsProfile = preset.Effects
+	}
+	if preset.Format != "" && !changed("format") {
+		*format = preset.Format
+	}
+	return &result, nil
+}
+
+// presetNames returns the names of presets in order
+func presetNames(presets map[string]config.VoicePreset) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// presetSummary describes the settings of a preset, e.g.
+// "voice=en-US-Studio-O speaking_rate=1.1"
+func presetSummary(preset config.VoicePreset) string {
+	var settings []string
+	add := func(key, value string) {
+		settings = append(settings, key+"="+value)
+	}
+	if preset.Voice != "" {
+		add("voice", preset.Voice)
+	}
+	if preset.Language != "" {
+		add("language", preset.Language)
+	}
+	if preset.SpeakingRate != 0 {
+		add("speaking_rate", strconv.FormatFloat(preset.SpeakingRate, 'g', -1, 64))
+	}
+	if preset.Pitch != 0 {
+		add("pitch", strconv.FormatFloat(preset.Pitch, 'g', -1, 64))
+	}
+	if preset.VolumeGain != 0 {
+		add("volume_gain", strconv.FormatFloat(preset.VolumeGain, 'g', -1, 64))
+	}
+	if preset.Format != "" {
+		add("format", preset.Format)
+	}
+	if len(preset.Effects) > 0 {
+		add("effects_profile", strings.Join(preset.Effects, ","))
+	}
+	return strings.Join(settings, " ")
+}
+
+// presetResult is one preset in the machine-readable result of preset list
+// and save
+type presetResult struct {
+	Name string `json:"name"`
+	config.VoicePreset
+}
+
+// presetListResult is the machine-readable result of preset list
+type presetListResult struct {
+	Presets []presetResult `json:"presets"`
+}
+
+// presetSaveResult is the machine-readable result of preset save
+type presetSaveResult struct {
+	File   string       `json:"file"`
+	Preset presetResult `json:"preset"`
+}
+
+func runPresetList(cmd *cobra.Command, args []string) error {
+	presets := GetConfig().Get().TTS.Presets
+
+	result := &presetListResult{Presets: []presetResult{}}
+	for _, name := range presetNames(presets) {
+		result.Presets = append(result.Presets, presetResult{Name: name, VoicePreset: presets[name]})
+	}
+
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		if len(result.Presets) == 0 {
+			fmt.Fprintln(w, "No presets configured; save one with 'assistant-cli preset save NAME'")
+			return
+		}
+		width := 0
+		for _, preset := range result.Presets {
+			width = max(width, len(preset.Name))
+		}
+		for _, preset := range result.Presets {
+			fmt.Fprintf(w, "%-*s  %s\n", width, preset.Name, presetSummary(preset.VoicePreset))
+		}
+	})
+}
+
+func runPresetSave(cmd *cobra.Command, args []string) error {
+	name := strings.ToLower(args[0])
+	changed := cmd.Flags().Changed
+
+	var preset config.VoicePreset
+	if changed("voice") {
+		preset.Voice = presetSaveVoice
+	}
+	if changed("language") {
+		preset.Language = presetSaveLanguage
+	}
+	if changed("speed") {
+		preset.SpeakingRate = presetSaveSpeed
+	}
+	if changed("pitch") {
+		preset.Pitch = presetSavePitch
+	}
+	if changed("volume") {
+		preset.VolumeGain = presetSaveVolume
+	}
+	if changed("format") {
+		preset.Format = strings.ToUpper(presetSaveFormat)
+	}
+	if changed("effects") {
+		preset.Effects = presetSaveEffects
+	}
+	if presetSummary(preset) == "" {
+		return usageError(fmt.Errorf("give at least one setting to save, e.g. --voice or --speed"))
+	}
+
+	manager := GetConfig()
+	path, err := configFileToEdit(manager)
+	if err != nil {
+		return ioError(err)
+	}
+	if err := manager.SetPreset(path, name, preset); err != nil {
+		return err
+	}
+
+	result := &presetSaveResult{File: path, Preset: presetResult{Name: name, VoicePreset: preset}}
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		statusf(w, "%s Saved preset %s (%s) in %s\n", styleFor(w).Success(), name, presetSummary(preset), path)
+	})
+}
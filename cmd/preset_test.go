@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runPresetCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		presetSaveVoice, presetSaveLanguage, presetSaveFormat = "", "", ""
+		presetSaveSpeed, presetSavePitch, presetSaveVolume = 1.0, 0, 0
+		presetSaveEffects = nil
+		quietFlag, verboseFlag, verbosity = false, false, verbosityNormal
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"preset"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestPresetSaveAndList(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTestConfig(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+
+	stdout, err := runPresetCommand(t, "list", "--config", path)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No presets configured")
+
+	stdout, err = runPresetCommand(t, "save", "Podcast", "--voice", "en-US-Studio-O", "--speed", "1.1",
+		"--effects", "large-home-entertainment-class-device", "--config", path)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Saved preset podcast")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "voice: \"en-US-Wavenet-D\"")
+	assert.Contains(t, string(data), "podcast:")
+	assert.NotContains(t, string(data), "pitch")
+
+	stdout, err = runPresetCommand(t, "list", "--config", path)
+	require.NoError(t, err)
+	assert.Equal(t, "podcast  voice=en-US-Studio-O speaking_rate=1.1 "+
+		"effects_profile=large-home-entertainment-class-device\n", stdout)
+
+	stdout, err = runPresetCommand(t, "list", "--config", path, "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data presetListResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	require.Len(t, result.Data.Presets, 1)
+	assert.Equal(t, "podcast", result.Data.Presets[0].Name)
+	assert.Equal(t, 1.1, result.Data.Presets[0].SpeakingRate)
+}
+
+func TestPresetSave_Errors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTestConfig(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+
+	_, err := runPresetCommand(t, "save", "empty", "--config", path)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	_, err = runPresetCommand(t, "save", "fast", "--speed", "9", "--config", path)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+
+	_, err = runPresetCommand(t, "save", "bad.name", "--voice", "en-US-Studio-O", "--config", path)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+func TestApplyPreset(t *testing.T) {
+	t.Cleanup(func() { presetFlag = "" })
+
+	cfg := config.GetDefaults()
+	cfg.TTS.Presets = map[string]config.VoicePreset{
+		"podcast": {Voice: "en-US-Studio-O", SpeakingRate: 1.1, Pitch: -2, Format: "OGG_OPUS",
+			Effects: []string{"large-home-entertainment-class-device"}},
+	}
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().String("voice", "", "")
+		cmd.Flags().Float64("pitch", 0, "")
+		cmd.Flags().String("format", "MP3", "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	format := "MP3"
+	got, err := applyPreset(newCmd(), cfg, &format)
+	require.NoError(t, err)
+	assert.Same(t, cfg, got, "no --preset leaves the configuration alone")
+
+	presetFlag = "Podcast"
+	got, err = applyPreset(newCmd("--pitch", "3"), cfg, &format)
+	require.NoError(t, err)
+	assert.Equal(t, "en-US-Studio-O", got.TTS.Voice)
+	assert.Equal(t, 1.1, got.TTS.SpeakingRate)
+	assert.Equal(t, cfg.TTS.Pitch, got.TTS.Pitch, "flags win over the preset")
+	assert.Equal(t, []string{"large-home-entertainment-class-device"}, got.TTS.EffectsProfile)
+	assert.Equal(t, "OGG_OPUS", format)
+	assert.NotEqual(t, "en-US-Studio-O", cfg.TTS.Voice, "the configuration itself is unchanged")
+
+	format = "WAV"
+	_, err = applyPreset(newCmd("--format", "WAV"), cfg, &format)
+	require.NoError(t, err)
+	assert.Equal(t, "WAV", format)
+
+	presetFlag = "podcats"
+	_, err = applyPreset(newCmd(), cfg, &format)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.Contains(t, err.Error(), "did you mean podcast?")
+}
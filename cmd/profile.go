@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils/suggest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// profileFlag is the --profile flag of synthesize
+	profileFlag string
+	// outputContainer is the container the --profile writes MULAW and ALAW
+	// audio in
+	outputContainer string
+)
+
+// outputProfile bundles the output settings a destination needs
+type outputProfile struct {
+	// formats are the encodings the destination plays, the first used
+	// unless another is given with --format
+	formats    []string
+	sampleRate int
+	effects    []string
+	container  string
+}
+
+// outputProfiles are the bundles --profile selects
+var outputProfiles = map[string]outputProfile{
+	"telephony": {
+		formats:    []string{"MULAW", "ALAW"},
+		sampleRate: output.TelephonySampleRate,
+		effects:    []string{"telephony-class-application"},
+		container:  tts.ContainerWAV,
+	},
+	"asterisk": {
+		formats:    []string{"MULAW", "ALAW"},
+		sampleRate: output.TelephonySampleRate,
+		effects:    []string{"telephony-class-application"},
+		container:  tts.ContainerRaw,
+	},
+}
+
+// addProfileFlag adds --profile to a command that synthesizes speech
+func addProfileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&profileFlag, "profile", "",
+		"Output bundle for a destination: "+strings.Join(profileNames(), ", "))
+}
+
+// applyProfile returns a copy of cfg with the effects profile of the
+// --profile bundle, and sets the format, sample rate, and container of the
+// output to those of the bundle. Settings the bundle cannot be combined with
+// are rejected: another format, given with --format or by the preset,
+// another sample rate, an output file of another type, post-processing, and
+// providers that cannot produce the format. Without --profile cfg is
+// returned as is.
+func applyProfile(cmd *cobra.Command, cfg *config.Config, providerName string,
+	postProcess audio.Options) (*config.Config, error) {
+	if profileFlag == "" {
+		return cfg, nil
+	}
+
+	name := strings.ToLower(profileFlag)
+	profile, ok := outputProfiles[name]
+	if !ok {
+		message := fmt.Sprintf("unknown profile %q", profileFlag)
+		if match, ok := suggest.Closest(name, profileNames()); ok {
+			message += fmt.Sprintf("; did you mean %s?", match)
+		}
+		return nil, usageError(fmt.Errorf("%s (profiles: %s)", message, strings.Join(profileNames(), ", ")))
+	}
+
+	format := profile.formats[0]
+	if cmd.Flags().Changed("format") || presetFormat(cfg) != "" {
+		format = strings.ToUpper(audioFormat)
+		if !slices.Contains(profile.formats, format) {
+			return nil, usageError(fmt.Errorf("--profile %s writes %s audio, not %s",
+				name, strings.Join(profile.formats, " or "), audioFormat))
+		}
+	}
+	if cmd.Flags().Changed("sample-rate") && sampleRate != profile.sampleRate {
+		return nil, usageError(fmt.Errorf("--profile %s writes %d Hz audio, not %d Hz",
+			name, profile.sampleRate, sampleRate))
+	}
+	if postProcess.Enabled() {
+		return nil, usageError(fmt.Errorf("--profile %s audio cannot be post-processed; "+
+			"drop --normalize, --trim-silence, --fade-in, --fade-out, and --music, or turn off output.post_process", name))
+	}
+	if formats := tts.SupportedFormats(providerName); formats != nil && !slices.Contains(formats, format) {
+		return nil, usageError(fmt.Errorf("--profile %s needs the google provider; the %s provider cannot produce %s",
+			name, providerName, format))
+	}
+	extension := tts.ContainerExtension(format, profile.container)
+	if ext := filepath.Ext(outputFile); outputFile != defaultOutputFile && ext != "" &&
+		!strings.EqualFold(ext, "."+extension) {
+		return nil, usageError(fmt.Errorf("--profile %s writes .%s files, not %s", name, extension, ext))
+	}
+
+	audioFormat, sampleRate, outputContainer = format, profile.sampleRate, profile.container
+	result := *cfg
+	result.TTS.EffectsProfile = profile.effects
+	return &result, nil
+}
+
+// presetFormat returns the format of the --preset entry of tts.presets, if
+// it sets one
+func presetFormat(cfg *config.Config) string {
+	if presetFlag == "" {
+		return ""
+	}
+	return cfg.TTS.Presets[strings.ToLower(presetFlag)].Format
+}
+
+// profileNames returns the names of the output profiles in order
+func profileNames() []string {
+	names := make([]string, 0, len(outputProfiles))
+	for name := range outputProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
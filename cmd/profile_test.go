@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProfile(t *testing.T) {
+	t.Cleanup(func() {
+		profileFlag, presetFlag, outputContainer = "", "", ""
+		audioFormat, sampleRate, outputFile = "MP3", 0, defaultOutputFile
+	})
+
+	cfg := config.GetDefaults()
+	cfg.TTS.Presets = map[string]config.VoicePreset{"podcast": {Format: "OGG_OPUS"}}
+	apply := func(args ...string) (*config.Config, error) {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().StringVarP(&audioFormat, "format", "f", "MP3", "")
+		cmd.Flags().IntVar(&sampleRate, "sample-rate", 0, "")
+		cmd.Flags().StringVarP(&outputFile, "output", "o", defaultOutputFile, "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		withPreset, err := applyPreset(cmd, cfg, &audioFormat)
+		require.NoError(t, err)
+		return applyProfile(cmd, withPreset, tts.ProviderGoogle, audio.Options{})
+	}
+
+	got, err := apply()
+	require.NoError(t, err)
+	assert.Same(t, cfg, got, "no --profile leaves the configuration alone")
+
+	profileFlag = "Telephony"
+	got, err = apply("-o", "hold.wav")
+	require.NoError(t, err)
+	assert.Equal(t, "MULAW", audioFormat)
+	assert.Equal(t, 8000, sampleRate)
+	assert.Equal(t, tts.ContainerWAV, outputContainer)
+	assert.Equal(t, []string{"telephony-class-application"}, got.TTS.EffectsProfile)
+	assert.Equal(t, []string{"headphone-class-device"}, cfg.TTS.EffectsProfile, "the configuration itself is unchanged")
+
+	profileFlag = "asterisk"
+	_, err = apply("-f", "alaw", "--sample-rate", "8000", "-o", "hold.alaw")
+	require.NoError(t, err)
+	assert.Equal(t, "ALAW", audioFormat)
+	assert.Equal(t, tts.ContainerRaw, outputContainer)
+
+	tests := []struct {
+		name    string
+		profile string
+		preset  string
+		args    []string
+		want    string
+	}{
+		{"other format", "telephony", "", []string{"-f", "MP3"}, "writes MULAW or ALAW audio, not MP3"},
+		{"preset format", "telephony", "podcast", nil, "not OGG_OPUS"},
+		{"other sample rate", "telephony", "", []string{"--sample-rate", "16000"}, "writes 8000 Hz audio, not 16000 Hz"},
+		{"other file type", "asterisk", "", []string{"-o", "hold.wav"}, "writes .ulaw files, not .wav"},
+		{"unknown profile", "telefony", "", nil, "did you mean telephony?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profileFlag, presetFlag = tt.profile, tt.preset
+			t.Cleanup(func() { presetFlag = "" })
+
+			_, err := apply(tt.args...)
+			assert.Equal(t, ExitUsage, ExitCode(err))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	profileFlag = "telephony"
+	cmd := &cobra.Command{Use: "test"}
+	_, err = applyProfile(cmd, cfg, tts.ProviderGoogle, audio.Options{Normalize: true})
+	assert.ErrorContains(t, err, "cannot be post-processed")
+	_, err = applyProfile(cmd, cfg, tts.ProviderEspeak, audio.Options{})
+	assert.ErrorContains(t, err, "needs the google provider")
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// progressBarWidth is the number of cells of the bar itself
+	progressBarWidth = 24
+	// progressRedrawInterval limits how often a bar is redrawn for byte
+	// counts, which may change many times a second
+	progressRedrawInterval = 100 * time.Millisecond
+	// progressCaptionWidth is the longest step caption shown next to a bar
+	progressCaptionWidth = 40
+)
+
+// progressBar reports the progress of a long operation on a status writer.
+// On a terminal with app.show_progress set it redraws a bar with the count
+// done, the bytes written, and an estimate of the time left; elsewhere it
+// falls back to a plain line per step. A nil progressBar, as returned when
+// --quiet, reports nothing.
+type progressBar struct {
+	w     io.Writer
+	unit  string
+	total int64
+	done  int64
+	// written counts the bytes produced so far, shown when the unit is not
+	// bytes already
+	written int64
+	caption string
+	start   time.Time
+	drawn   time.Time
+	// live is set when the bar is redrawn in place on a terminal
+	live bool
+}
+
+// newProgressBar creates a progress bar for total units of work, such as
+// "chunks" or "bytes"
+func newProgressBar(w io.Writer, total int64, unit string) *progressBar {
+	if verbosity == verbosityQuiet {
+		return nil
+	}
+	return &progressBar{
+		w:     w,
+		unit:  unit,
+		total: total,
+		start: time.Now(),
+		// Legacy Windows consoles cannot redraw a line in place
+		live: GetConfig().Get().App.ShowProgress && isTerminal(w) && !styleFor(w).ascii,
+	}
+}
+
+// Step starts the next step of the operation, such as a chapter. Its
+// caption is shown next to a live bar, or printed as a line otherwise.
+func (p *progressBar) Step(format string, args ...interface{}) {
+	if p == nil {
+		return
+	}
+	p.caption = fmt.Sprintf(format, args...)
+	if !p.live {
+		fmt.Fprintln(p.w, p.caption)
+		return
+	}
+	p.draw()
+}
+
+// Add records n more units done and written more bytes produced
+func (p *progressBar) Add(n, written int64) {
+	if p == nil {
+		return
+	}
+	p.done += n
+	p.written += written
+	finished := p.total > 0 && p.done >= p.total
+	if p.live && (p.unit != "bytes" || finished || time.Since(p.drawn) >= progressRedrawInterval) {
+		p.draw()
+	}
+}
+
+// Done ends a live bar with a final redraw, moving to the next line
+func (p *progressBar) Done() {
+	if p == nil || !p.live {
+		return
+	}
+	p.draw()
+	fmt.Fprintln(p.w)
+	p.live = false
+}
+
+// draw redraws the bar in place
+func (p *progressBar) draw() {
+	p.drawn = time.Now()
+	fmt.Fprintf(p.w, "\r\033[K%s", p.line(p.drawn.Sub(p.start)))
+}
+
+// line renders the bar after elapsed time, e.g.
+// "[#########---------------] 3/8 chunks  1.2 MB  ETA 0:42  Chapter 2"
+func (p *progressBar) line(elapsed time.Duration) string {
+	var b strings.Builder
+
+	filled := 0
+	if p.total > 0 {
+		filled = int(p.done * progressBarWidth / p.total)
+		filled = min(filled, progressBarWidth)
+	}
+	b.WriteString("[" + strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled) + "]")
+
+	switch {
+	case p.unit == "bytes" && p.total > 0:
+		fmt.Fprintf(&b, " %s/%s", formatByteCount(p.done), formatByteCount(p.total))
+	case p.unit == "bytes":
+		fmt.Fprintf(&b, " %s", formatByteCount(p.done))
+	default:
+		fmt.Fprintf(&b, " %d/%d %s", p.done, p.total, p.unit)
+		if p.written > 0 {
+			fmt.Fprintf(&b, "  %s", formatByteCount(p.written))
+		}
+	}
+
+	if p.done > 0 && p.done < p.total {
+		left := time.Duration(float64(elapsed) / float64(p.done) * float64(p.total-p.done))
+		fmt.Fprintf(&b, "  ETA %s", formatETA(left))
+	}
+
+	if p.caption != "" {
+		caption := []rune(p.caption)
+		if len(caption) > progressCaptionWidth {
+			caption = append(caption[:progressCaptionWidth-1], '…')
+		}
+		b.WriteString("  " + string(caption))
+	}
+	return b.String()
+}
+
+// formatByteCount formats a byte count with a binary unit, e.g. 1.5 MB
+func formatByteCount(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatETA formats a time left as m:ss, or h:mm:ss from an hour on
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// isTerminal reports whether w writes to a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressTransport reports the bytes of response bodies to a progress bar
+// as they are downloaded
+type progressTransport struct {
+	base http.RoundTripper
+	w    io.Writer
+}
+
+// RoundTrip sends the request and wraps the response body
+func (t *progressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	resp.Body = &progressReader{ReadCloser: resp.Body, bar: newProgressBar(t.w, resp.ContentLength, "bytes")}
+	return resp, nil
+}
+
+// progressReader counts the bytes read from a download
+type progressReader struct {
+	io.ReadCloser
+	bar *progressBar
+}
+
+// Read reads from the body, adding the bytes read to the bar
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.bar.Add(int64(n), 0)
+	return n, err
+}
+
+// Close ends the bar and closes the body
+func (r *progressReader) Close() error {
+	r.bar.Done()
+	return r.ReadCloser.Close()
+}
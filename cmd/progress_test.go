@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressBar_Plain(t *testing.T) {
+	resetVerbosity(t)
+	buf := new(bytes.Buffer)
+
+	bar := newProgressBar(buf, 4, "chunks")
+	require.NotNil(t, bar)
+	assert.False(t, bar.live, "a buffer is not a terminal")
+
+	bar.Step("[%d/%d] %s", 1, 2, "Intro")
+	bar.Add(2, 1024)
+	bar.Step("[%d/%d] %s", 2, 2, "Outro")
+	bar.Add(2, 1024)
+	bar.Done()
+	assert.Equal(t, "[1/2] Intro\n[2/2] Outro\n", buf.String())
+}
+
+func TestProgressBar_Quiet(t *testing.T) {
+	resetVerbosity(t)
+	verbosity = verbosityQuiet
+
+	bar := newProgressBar(new(bytes.Buffer), 4, "chunks")
+	assert.Nil(t, bar)
+	// A nil bar reports nothing
+	bar.Step("step")
+	bar.Add(1, 1)
+	bar.Done()
+}
+
+func TestProgressBar_Live(t *testing.T) {
+	buf := new(bytes.Buffer)
+	bar := &progressBar{w: buf, unit: "chunks", total: 4, start: time.Now(), live: true}
+
+	bar.Step("Chapter 1")
+	bar.Add(1, 2048)
+	bar.Done()
+
+	output := buf.String()
+	assert.Contains(t, output, "\r\033[K[------------------------] 0/4 chunks  Chapter 1")
+	assert.Contains(t, output, "\r\033[K[######------------------] 1/4 chunks  2.0 KB  ETA ")
+	assert.True(t, strings.HasSuffix(output, "\n"), "Done ends the line")
+}
+
+func TestProgressBar_Line(t *testing.T) {
+	tests := []struct {
+		name string
+		bar  progressBar
+		want string
+	}{
+		{
+			"chunks with ETA",
+			progressBar{unit: "chunks", total: 4, done: 2, written: 1536, caption: "[2/3] Chapter"},
+			"[############------------] 2/4 chunks  1.5 KB  ETA 0:10  [2/3] Chapter",
+		},
+		{
+			"bytes of known size",
+			progressBar{unit: "bytes", total: 4 << 20, done: 1 << 20},
+			"[######------------------] 1.0 MB/4.0 MB  ETA 0:30",
+		},
+		{
+			"bytes of unknown size",
+			progressBar{unit: "bytes", total: -1, done: 512},
+			"[------------------------] 512 B",
+		},
+		{
+			"long caption",
+			progressBar{unit: "chunks", total: 1, done: 1, caption: strings.Repeat("x", 50)},
+			"[########################] 1/1 chunks  " + strings.Repeat("x", 39) + "…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.bar.line(10*time.Second))
+		})
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	assert.Equal(t, "0:05", formatETA(5*time.Second))
+	assert.Equal(t, "2:03", formatETA(123*time.Second))
+	assert.Equal(t, "1:01:01", formatETA(time.Hour+61*time.Second))
+}
+
+func TestProgressTransport(t *testing.T) {
+	resetVerbosity(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &progressTransport{base: http.DefaultTransport, w: new(bytes.Buffer)}}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Len(t, data, 1000)
+	reader, ok := resp.Body.(*progressReader)
+	require.True(t, ok)
+	assert.Equal(t, int64(1000), reader.bar.done)
+	assert.Equal(t, int64(1000), reader.bar.total)
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+// maxPronounceLength is the longest word or phrase pronounce accepts, in
+// characters; longer texts belong to synthesize
+const maxPronounceLength = 100
+
+var (
+	pronounceIPA      string
+	pronounceXSAMPA   string
+	pronounceVoice    string
+	pronounceSpeed    float64
+	pronounceOutput   string
+	pronounceFormat   string
+	pronounceLanguage string
+)
+
+// NewPronounceCmd creates the pronounce command
+func NewPronounceCmd() *cobra.Command {
+	pronounceCmd := &cobra.Command{
+		Use:   "pronounce WORD",
+		Short: "Hear how a word is pronounced",
+		Long: `Synthesize a single word or short phrase and play it at once, to check how a
+voice says it. With --ipa or --x-sampa, the word is spoken as the given
+phonemes using an SSML <phoneme> element, so a pronunciation can be tried out
+before it goes into a script.
+
+No file is written unless --output is given.
+
+Examples:
+  assistant-cli pronounce kubernetes
+  assistant-cli pronounce kubernetes --ipa "ˌkuːbərˈnɛtiːz"
+  assistant-cli pronounce Nguyen --x-sampa "N_wIn" --voice en-US-Neural2-F --speed 0.8
+  assistant-cli pronounce gif --ipa "dʒɪf" -o gif.mp3 --no-play`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runPronounce,
+	}
+
+	pronounceCmd.Flags().StringVar(&pronounceIPA, "ipa", "", "Pronunciation in the International Phonetic Alphabet")
+	pronounceCmd.Flags().StringVar(&pronounceXSAMPA, "x-sampa", "", "Pronunciation in X-SAMPA")
+	pronounceCmd.Flags().StringVarP(&pronounceVoice, "voice", "v", "", "Voice to use (default: tts.voice)")
+	pronounceCmd.Flags().StringVarP(&pronounceLanguage, "language", "l", "",
+		"Language code (default: the voice's language, or tts.language)")
+	pronounceCmd.Flags().Float64VarP(&pronounceSpeed, "speed", "s", 0, "Speaking rate (default: tts.speaking_rate)")
+	pronounceCmd.Flags().StringVarP(&pronounceOutput, "output", "o", "", "Also save the audio to this file")
+	pronounceCmd.Flags().StringVarP(&pronounceFormat, "format", "f", "MP3", "Audio format (MP3, OGG_OPUS, LINEAR16)")
+	pronounceCmd.MarkFlagsMutuallyExclusive("ipa", "x-sampa")
+	addPresetFlag(pronounceCmd)
+
+	return pronounceCmd
+}
+
+// pronounceResult is the machine-readable result of pronounce
+type pronounceResult struct {
+	Word     string  `json:"word"`
+	Phonemes string  `json:"phonemes,omitempty"`
+	Alphabet string  `json:"alphabet,omitempty"`
+	Voice    string  `json:"voice,omitempty"`
+	Language string  `json:"language,omitempty"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+	File     string  `json:"file,omitempty"`
+	Played   bool    `json:"played"`
+}
+
+func runPronounce(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	cfg := GetConfig().Get()
+	renderer := newRenderer(cmd)
+
+	word := strings.TrimSpace(args[0])
+	if word == "" {
+		return usageError(fmt.Errorf("give a word to pronounce"))
+	}
+	if utf8.RuneCountInString(word) > maxPronounceLength {
+		return usageError(fmt.Errorf("pronounce takes a word or short phrase of up to %d characters; "+
+			"use synthesize for longer text", maxPronounceLength))
+	}
+	if pronounceSpeed != 0 && (pronounceSpeed < 0.25 || pronounceSpeed > 4.0) {
+		return usageError(fmt.Errorf("--speed must be between 0.25 and 4.0, got %g", pronounceSpeed))
+	}
+	// Without playback or a file the synthesis would go unheard
+	if reason := playbackSkipReason(); reason != "" && pronounceOutput == "" {
+		return usageError(fmt.Errorf("playback is off (%s); give --output to save the audio instead", reason))
+	}
+
+	cfg, err := applyPreset(cmd, cfg, &pronounceFormat)
+	if err != nil {
+		return err
+	}
+	if err := checkLongTextFormat(pronounceFormat); err != nil {
+		return err
+	}
+	provider, req, err := createLongTextProvider(ctx, renderer, cfg, pronounceVoice, pronounceFormat)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = provider.Close() }()
+
+	if pronounceSpeed != 0 {
+		req.SpeakingRate = pronounceSpeed
+	}
+	if pronounceLanguage != "" {
+		req.LanguageCode = pronounceLanguage
+	} else if language := tts.VoiceLanguage(req.Voice); language != "" {
+		req.LanguageCode = language
+	}
+
+	result := &pronounceResult{Word: word, Voice: req.Voice, Language: req.LanguageCode}
+	switch {
+	case pronounceIPA != "":
+		result.Phonemes, result.Alphabet = pronounceIPA, "ipa"
+	case pronounceXSAMPA != "":
+		result.Phonemes, result.Alphabet = pronounceXSAMPA, "x-sampa"
+	}
+	req.Text = pronounceSSML(word, result.Alphabet, result.Phonemes)
+
+	// The audio goes to a temporary file for playback unless it is kept
+	path := pronounceOutput
+	if path == "" {
+		file, err := os.CreateTemp("", "assistant-cli-pronounce-*."+longTextExtension(req))
+		if err != nil {
+			return ioError(fmt.Errorf("failed to create temporary file: %w", err))
+		}
+		_ = file.Close()
+		path = file.Name()
+		defer func() { _ = os.Remove(path) }()
+	} else {
+		path = expandHomeDirs([]string{path})[0]
+	}
+	req.OutputFile = path
+
+	resp, err := newSynthesizer(provider, audio.Options{}, false).Synthesize(ctx, req)
+	if err != nil {
+		return fmt.Errorf("synthesis failed: %w", err)
+	}
+	result.Duration = resp.Duration.Seconds()
+	if pronounceOutput != "" {
+		result.File = resp.OutputFile
+	}
+
+	if !skipPlayback() {
+		if err := playAudioFile(renderer, resp.OutputFile); err != nil {
+			return fmt.Errorf("failed to play audio: %w", err)
+		}
+		result.Played = true
+	}
+
+	return renderer.Result(result, func(w io.Writer) {
+		if result.Phonemes != "" {
+			fmt.Fprintf(w, "%s /%s/ (%s)\n", result.Word, result.Phonemes, result.Alphabet)
+		}
+		if result.File != "" {
+			statusf(w, "%s Saved %s\n", styleFor(w).Success(), result.File)
+		}
+	})
+}
+
+// pronounceSSML returns the text to synthesize for word: an SSML document
+// speaking it as phonemes in alphabet when they are given, or else the word
+func pronounceSSML(word, alphabet, phonemes string) string {
+	if phonemes == "" {
+		return word
+	}
+	return fmt.Sprintf(`<speak><phoneme alphabet="%s" ph="%s">%s</phoneme></speak>`,
+		alphabet, html.EscapeString(phonemes), html.EscapeString(word))
+}
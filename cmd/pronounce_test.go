@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runPronounceCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		pronounceIPA = ""
+		pronounceXSAMPA = ""
+		pronounceVoice = ""
+		pronounceLanguage = ""
+		pronounceSpeed = 0
+		pronounceOutput = ""
+		pronounceFormat = "MP3"
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"pronounce"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestPronounceCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runPronounceCommand(t, "kubernetes", "--ipa", "ˌkuːbərˈnɛtiːz", "--config", config,
+		"--output-format", "json", "--format", "LINEAR16")
+	require.NoError(t, err)
+
+	var result struct {
+		Data pronounceResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "kubernetes", result.Data.Word)
+	assert.Equal(t, "ipa", result.Data.Alphabet)
+	assert.True(t, result.Data.Played)
+	assert.Empty(t, result.Data.File)
+
+	// The temporary file is removed once played
+	files := played()
+	require.Len(t, files, 1)
+	matches, err := filepath.Glob(filepath.Join(os.TempDir(), files[0]))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestPronounceCommandOutput(t *testing.T) {
+	fakeEspeakOnPath(t)
+	played := fakePlayerOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+	path := filepath.Join(t.TempDir(), "gif.wav")
+
+	stdout, err := runPronounceCommand(t, "gif", "--x-sampa", "dZIf", "--config", config,
+		"--output-format", "json", "--format", "LINEAR16", "-o", path, "--no-play")
+	require.NoError(t, err)
+
+	var result struct {
+		Data pronounceResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "x-sampa", result.Data.Alphabet)
+	assert.Equal(t, path, result.Data.File)
+	assert.False(t, result.Data.Played)
+	assert.FileExists(t, path)
+	assert.Empty(t, played())
+}
+
+func TestPronounceCommandErrors(t *testing.T) {
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	_, err := runPronounceCommand(t, "--config", config)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	_, err = runPronounceCommand(t, "gif", "--config", config, "--no-play")
+	assert.ErrorContains(t, err, "give --output")
+
+	_, err = runPronounceCommand(t, "gif", "--config", config, "--speed", "9")
+	assert.ErrorContains(t, err, "--speed must be between")
+
+	_, err = runPronounceCommand(t, "gif", "--config", config, "--ipa", "dʒɪf", "--x-sampa", "dZIf")
+	assert.Error(t, err)
+}
+
+func TestPronounceSSML(t *testing.T) {
+	assert.Equal(t, "kubernetes", pronounceSSML("kubernetes", "", ""))
+	assert.Equal(t, `<speak><phoneme alphabet="ipa" ph="dʒɪf">gif</phoneme></speak>`,
+		pronounceSSML("gif", "ipa", "dʒɪf"))
+	assert.Equal(t, `<speak><phoneme alphabet="x-sampa" ph="&#34;kA:&#34;">R&amp;D</phoneme></speak>`,
+		pronounceSSML("R&D", "x-sampa", `"kA:"`))
+}
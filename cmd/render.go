@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// Output format constants for the global --output-format flag
+const (
+	outputFormatText = "text"
+	outputFormatJSON = "json"
+)
+
+// outputFormat holds the value of the global --output-format flag
+var outputFormat = outputFormatText
+
+// CommandResult is the envelope written to stdout for every command in JSON mode
+type CommandResult struct {
+	Command string      `json:"command"`
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   *ErrorInfo  `json:"error,omitempty"`
+}
+
+// ErrorInfo describes a command failure in JSON mode
+type ErrorInfo struct {
+	Message  string `json:"message"`
+	Kind     string `json:"kind"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// Renderer writes command results in the selected output format. Results go to
+// stdout; human-readable status messages go to stdout in text mode and to stderr
+// in JSON mode so that stdout always contains a single parseable document.
+type Renderer struct {
+	command string
+	format  string
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// newRenderer creates a renderer for the given command using the global output format
+func newRenderer(cmd *cobra.Command) *Renderer {
+	return &Renderer{
+		command: cmd.CommandPath(),
+		format:  outputFormat,
+		stdout:  cmd.OutOrStdout(),
+		stderr:  cmd.ErrOrStderr(),
+	}
+}
+
+// IsJSON reports whether machine-readable JSON output was requested
+func (r *Renderer) IsJSON() bool {
+	return r.format == outputFormatJSON
+}
+
+// Logf writes a human-readable status message, unless --quiet
+func (r *Renderer) Logf(format string, args ...interface{}) {
+	statusf(r.status(), format, args...)
+}
+
+// Detailf writes a human-readable detail only with --verbose
+func (r *Renderer) Detailf(format string, args ...interface{}) {
+	detailf(r.status(), format, args...)
+}
+
+// Warnf writes a human-readable warning, which --quiet does not suppress
+func (r *Renderer) Warnf(format string, args ...interface{}) {
+	fmt.Fprintf(r.status(), format, args...)
+}
+
+// status returns the writer for status messages
+func (r *Renderer) status() io.Writer {
+	if r.IsJSON() {
+		return r.stderr
+	}
+	return r.stdout
+}
+
+// Result writes a successful result. In JSON mode data is encoded inside a
+// CommandResult envelope; in text mode the text function renders it instead.
+func (r *Renderer) Result(data interface{}, text func(w io.Writer)) error {
+	if r.IsJSON() {
+		return r.encode(&CommandResult{
+			Command: r.command,
+			Success: true,
+			Data:    data,
+		})
+	}
+
+	if text != nil {
+		text(r.stdout)
+	}
+	return nil
+}
+
+// Error writes a failure envelope in JSON mode, including any result data
+// attached with withResult. In text mode it is a no-op, since errors are
+// already reported on stderr.
+func (r *Renderer) Error(err error) {
+	if !r.IsJSON() || err == nil {
+		return
+	}
+
+	code := ExitCode(err)
+	result := &CommandResult{
+		Command: r.command,
+		Success: false,
+		Error: &ErrorInfo{
+			Message:  err.Error(),
+			Kind:     exitCodeName(code),
+			ExitCode: code,
+		},
+	}
+
+	var withData *resultError
+	if errors.As(err, &withData) {
+		result.Data = withData.data
+	}
+
+	_ = r.encode(result)
+}
+
+func (r *Renderer) encode(result *CommandResult) error {
+	encoder := json.NewEncoder(r.stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode JSON output: %w", err)
+	}
+	return nil
+}
+
+// validateOutputFormat checks the value of the global --output-format flag
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputFormatText, outputFormatJSON:
+		return nil
+	default:
+		return usageError(fmt.Errorf("unsupported output format: %s (supported: text, json)", outputFormat))
+	}
+}
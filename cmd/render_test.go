@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRenderer(format string) (*Renderer, *bytes.Buffer, *bytes.Buffer) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	return &Renderer{
+		command: "assistant-cli test",
+		format:  format,
+		stdout:  stdout,
+		stderr:  stderr,
+	}, stdout, stderr
+}
+
+func TestRendererResult(t *testing.T) {
+	t.Run("json mode writes envelope", func(t *testing.T) {
+		renderer, stdout, stderr := newTestRenderer(outputFormatJSON)
+
+		err := renderer.Result(map[string]int{"size": 42}, func(w io.Writer) {
+			fmt.Fprintln(w, "should not be printed")
+		})
+		require.NoError(t, err)
+
+		var result CommandResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, "assistant-cli test", result.Command)
+		assert.True(t, result.Success)
+		assert.Nil(t, result.Error)
+		assert.Equal(t, map[string]interface{}{"size": float64(42)}, result.Data)
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("text mode uses text renderer", func(t *testing.T) {
+		renderer, stdout, _ := newTestRenderer(outputFormatText)
+
+		err := renderer.Result("ignored", func(w io.Writer) {
+			fmt.Fprint(w, "human output")
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "human output", stdout.String())
+	})
+
+	t.Run("text mode without text renderer prints nothing", func(t *testing.T) {
+		renderer, stdout, _ := newTestRenderer(outputFormatText)
+
+		require.NoError(t, renderer.Result("ignored", nil))
+		assert.Empty(t, stdout.String())
+	})
+}
+
+func TestRendererLogf(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		wantStdout string
+		wantStderr string
+	}{
+		{"text mode logs to stdout", outputFormatText, "status 1\n", ""},
+		{"json mode logs to stderr", outputFormatJSON, "", "status 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer, stdout, stderr := newTestRenderer(tt.format)
+			renderer.Logf("status %d\n", 1)
+
+			assert.Equal(t, tt.wantStdout, stdout.String())
+			assert.Equal(t, tt.wantStderr, stderr.String())
+		})
+	}
+}
+
+func TestRendererError(t *testing.T) {
+	t.Run("json mode writes error envelope", func(t *testing.T) {
+		renderer, stdout, _ := newTestRenderer(outputFormatJSON)
+		renderer.Error(authError(errors.New("something broke")))
+
+		var result CommandResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.False(t, result.Success)
+		require.NotNil(t, result.Error)
+		assert.Equal(t, "something broke", result.Error.Message)
+		assert.Equal(t, "auth", result.Error.Kind)
+		assert.Equal(t, ExitAuth, result.Error.ExitCode)
+	})
+
+	t.Run("text mode is silent", func(t *testing.T) {
+		renderer, stdout, stderr := newTestRenderer(outputFormatText)
+		renderer.Error(errors.New("something broke"))
+
+		assert.Empty(t, stdout.String())
+		assert.Empty(t, stderr.String())
+	})
+}
+
+func TestOutputFormatFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"text format", []string{"--output-format", "text", "config", "show"}, false},
+		{"json format", []string{"--output-format", "json", "config", "show"}, false},
+		{"invalid format", []string{"--output-format", "xml", "synthesize"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { outputFormat = outputFormatText })
+			require.NoError(t, showConfigCmd.Flags().Set("help", "false"))
+
+			buf := new(bytes.Buffer)
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(buf)
+			rootCmd.SetErr(buf)
+			rootCmd.SetArgs(tt.args)
+
+			err := rootCmd.Execute()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "unsupported output format")
+				assert.Equal(t, ExitUsage, ExitCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfigShowJSONOutput(t *testing.T) {
+	t.Cleanup(func() { outputFormat = outputFormatText })
+	// showConfigCmd is shared across tests; clear any --help left over from earlier runs
+	require.NoError(t, showConfigCmd.Flags().Set("help", "false"))
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"--output-format", "json", "config", "show"})
+
+	require.NoError(t, rootCmd.Execute())
+
+	var result CommandResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.True(t, result.Success)
+
+	data, ok := result.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, data, "config")
+}
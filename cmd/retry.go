@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+// maxRetriesFlag is the most retries --retries accepts, as for tts.max_retries
+const maxRetriesFlag = 10
+
+var (
+	retriesFlag       int
+	retryDelayFlag    time.Duration
+	retryMaxDelayFlag time.Duration
+	retryBackoffFlag  string
+	totalTimeoutFlag  time.Duration
+	// retriesSet records that --retries was given, since 0 is a valid count
+	retriesSet bool
+)
+
+// validateRetryFlags checks the retry flags of cmd
+func validateRetryFlags(cmd *cobra.Command) error {
+	retriesSet = cmd.Flags().Changed("retries")
+	if retriesSet && (retriesFlag < 0 || retriesFlag > maxRetriesFlag) {
+		return usageError(fmt.Errorf("--retries must be between 0 and %d, got %d", maxRetriesFlag, retriesFlag))
+	}
+	if retryDelayFlag < 0 {
+		return usageError(fmt.Errorf("--retry-delay must not be negative, got %s", retryDelayFlag))
+	}
+	if retryMaxDelayFlag < 0 {
+		return usageError(fmt.Errorf("--retry-max-delay must not be negative, got %s", retryMaxDelayFlag))
+	}
+	if totalTimeoutFlag < 0 {
+		return usageError(fmt.Errorf("--total-timeout must not be negative, got %s", totalTimeoutFlag))
+	}
+	if _, err := tts.ParseBackoffStrategy(retryBackoffFlag); err != nil {
+		return usageError(fmt.Errorf("--retry-backoff: %w", err))
+	}
+	return nil
+}
+
+// applyRetryPolicy sets the retry policy of ttsConfig from the tts settings,
+// overridden by the retry flags when given
+func applyRetryPolicy(ttsConfig *tts.ClientConfig, ttsCfg config.TTSConfig) {
+	ttsConfig.RetryAttempts = ttsCfg.MaxRetries
+	ttsConfig.RetryDelay = ttsCfg.RetryDelay
+	ttsConfig.RetryMaxDelay = ttsCfg.MaxRetryDelay
+	ttsConfig.RetryBackoff = tts.BackoffStrategy(ttsCfg.RetryBackoff)
+	ttsConfig.TotalTimeout = ttsCfg.TotalTimeout
+
+	if retriesSet {
+		ttsConfig.RetryAttempts = retriesFlag
+	}
+	if retryDelayFlag > 0 {
+		ttsConfig.RetryDelay = retryDelayFlag
+	}
+	if retryMaxDelayFlag > 0 {
+		ttsConfig.RetryMaxDelay = retryMaxDelayFlag
+	}
+	if retryBackoffFlag != "" {
+		ttsConfig.RetryBackoff = tts.BackoffStrategy(retryBackoffFlag)
+	}
+	if totalTimeoutFlag > 0 {
+		ttsConfig.TotalTimeout = totalTimeoutFlag
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetRetryFlags(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		retriesFlag, retryDelayFlag, retryMaxDelayFlag, retryBackoffFlag = 0, 0, 0, ""
+		totalTimeoutFlag = 0
+		retriesSet = false
+	})
+}
+
+// retryFlagsCmd returns a command with the retry flags parsed from args
+func retryFlagsCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().IntVar(&retriesFlag, "retries", 0, "")
+	cmd.Flags().DurationVar(&retryDelayFlag, "retry-delay", 0, "")
+	cmd.Flags().DurationVar(&retryMaxDelayFlag, "retry-max-delay", 0, "")
+	cmd.Flags().StringVar(&retryBackoffFlag, "retry-backoff", "", "")
+	cmd.Flags().DurationVar(&totalTimeoutFlag, "total-timeout", 0, "")
+	require.NoError(t, cmd.ParseFlags(args))
+	return cmd
+}
+
+func TestApplyRetryPolicy_Config(t *testing.T) {
+	resetRetryFlags(t)
+	require.NoError(t, validateRetryFlags(retryFlagsCmd(t)))
+
+	cfg := config.GetDefaults().TTS
+	cfg.MaxRetries = 5
+	cfg.RetryDelay = 250 * time.Millisecond
+	cfg.MaxRetryDelay = 10 * time.Second
+	cfg.RetryBackoff = "jittered"
+
+	ttsConfig := createTTSConfig(cfg)
+	assert.Equal(t, 5, ttsConfig.RetryAttempts)
+	assert.Equal(t, 250*time.Millisecond, ttsConfig.RetryDelay)
+	assert.Equal(t, 10*time.Second, ttsConfig.RetryMaxDelay)
+	assert.Equal(t, tts.BackoffJittered, ttsConfig.RetryBackoff)
+	assert.Equal(t, cfg.Timeout, ttsConfig.Timeout)
+	assert.Equal(t, cfg.TotalTimeout, ttsConfig.TotalTimeout)
+}
+
+func TestApplyRetryPolicy_FlagsOverrideConfig(t *testing.T) {
+	resetRetryFlags(t)
+	cmd := retryFlagsCmd(t, "--retries", "0", "--retry-delay", "2s", "--retry-max-delay", "30s",
+		"--retry-backoff", "exponential", "--total-timeout", "45s")
+	require.NoError(t, validateRetryFlags(cmd))
+
+	ttsConfig := createTTSConfig(config.GetDefaults().TTS)
+	assert.Equal(t, 0, ttsConfig.RetryAttempts)
+	assert.Equal(t, 2*time.Second, ttsConfig.RetryDelay)
+	assert.Equal(t, 30*time.Second, ttsConfig.RetryMaxDelay)
+	assert.Equal(t, tts.BackoffExponential, ttsConfig.RetryBackoff)
+	assert.Equal(t, 45*time.Second, ttsConfig.TotalTimeout)
+}
+
+func TestValidateRetryFlags_Invalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"--retries", "-1"},
+		{"--retries", "11"},
+		{"--retry-delay", "-1s"},
+		{"--retry-max-delay", "-1s"},
+		{"--retry-backoff", "fibonacci"},
+		{"--total-timeout", "-1s"},
+	} {
+		t.Run(args[0]+"="+args[1], func(t *testing.T) {
+			resetRetryFlags(t)
+			err := validateRetryFlags(retryFlagsCmd(t, args...))
+			require.Error(t, err)
+			assert.Equal(t, ExitUsage, ExitCode(err))
+		})
+	}
+}
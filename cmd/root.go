@@ -1,16 +1,23 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
 	cfgFile      string
+	strictMode   bool
+	noPlay       bool
+	ignoreBudget bool
 	globalConfig *config.Manager
 )
 
@@ -42,12 +49,17 @@ It supports multiple authentication methods and provides various customization o
   assistant-cli login --validate
 
   # List available voices
-  assistant-cli synthesize --list-voices --language en-US
+  assistant-cli voices --language en-US
 
   # Use configuration file
   assistant-cli config generate ~/.assistant-cli.yaml
-  assistant-cli --config ~/.assistant-cli.yaml synthesize --help`,
+  assistant-cli --config ~/.assistant-cli.yaml synthesize --help
+
+  # Machine-readable output for scripts
+  echo "Hello" | assistant-cli --output-format json synthesize -o hello.mp3`,
 		Version: version,
+		// Errors are reported once by Execute together with a classified exit code
+		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			// If no subcommand is provided, show help
 			_ = cmd.Help()
@@ -56,14 +68,77 @@ It supports multiple authentication methods and provides various customization o
 
 	// Set up persistent flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.assistant-cli.yaml)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output-format", outputFormatText,
+		"Output format for command results (text, json)")
+	rootCmd.PersistentFlags().BoolVar(&strictMode, "strict", false, "Treat configuration warnings as errors")
+	rootCmd.PersistentFlags().BoolVar(&noPlay, "no-play", false,
+		"Never play audio, even when requested or auto_play is set")
+	rootCmd.PersistentFlags().BoolVar(&ignoreBudget, "ignore-budget", false,
+		"Synthesize past app.monthly_character_budget with a warning instead of refusing")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false,
+		"Print results, warnings, and errors only (overrides app.quiet)")
+	rootCmd.PersistentFlags().BoolVar(&verboseFlag, "verbose", false,
+		"Print details and debug logs (overrides app.verbose)")
+	rootCmd.PersistentFlags().IntVar(&retriesFlag, "retries", 0,
+		"Retries of failed API requests (overrides tts.max_retries)")
+	rootCmd.PersistentFlags().DurationVar(&retryDelayFlag, "retry-delay", 0,
+		"Delay before the first retry, e.g. 500ms (overrides tts.retry_delay)")
+	rootCmd.PersistentFlags().DurationVar(&retryMaxDelayFlag, "retry-max-delay", 0,
+		"Longest delay between retries (overrides tts.max_retry_delay)")
+	rootCmd.PersistentFlags().StringVar(&retryBackoffFlag, "retry-backoff", "",
+		"Retry backoff: linear, exponential, or jittered (overrides tts.retry_backoff)")
+	rootCmd.PersistentFlags().DurationVar(&totalTimeoutFlag, "total-timeout", 0,
+		"Deadline for a request across all its retries, e.g. 90s (overrides tts.total_timeout)")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if err := applyVerbosity(GetConfig().Get()); err != nil {
+			return err
+		}
+		if err := validateRetryFlags(cmd); err != nil {
+			return err
+		}
+		slog.Debug("loaded configuration", "files", GetConfig().ConfigFiles())
+		// Flags parsed fine, so any later failure is not a usage problem
+		cmd.SilenceUsage = true
+		startRunStats(cmd)
+		return checkConfigWarnings(cmd)
+	}
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
 
 	// Initialize config when root command is created
 	cobra.OnInitialize(initConfig)
+	registerStatsCleanup.Do(func() { cobra.OnFinalize(saveRunStats) })
 
 	// Add subcommands
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(NewSynthesizeCmd())
+	rootCmd.AddCommand(NewPronounceCmd())
+	rootCmd.AddCommand(NewSayCmd())
+	rootCmd.AddCommand(NewDaemonCmd())
+	rootCmd.AddCommand(NewEditorCmd())
+	rootCmd.AddCommand(NewNotifyCmd())
+	rootCmd.AddCommand(NewMQTTCmd())
+	rootCmd.AddCommand(NewVoicesCmd())
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(NewSelftestCmd())
+	rootCmd.AddCommand(NewHealthCmd())
+	rootCmd.AddCommand(NewSecretsCmd())
+	rootCmd.AddCommand(NewAudioCmd())
+	rootCmd.AddCommand(NewOutputCmd())
+	rootCmd.AddCommand(NewAudiobookCmd())
+	rootCmd.AddCommand(NewBatchCmd())
+	rootCmd.AddCommand(NewFeedCmd())
+	rootCmd.AddCommand(NewPresetCmd())
+	rootCmd.AddCommand(NewCompareCmd())
+	rootCmd.AddCommand(NewStatsCmd())
+	rootCmd.AddCommand(NewBenchCmd())
+	rootCmd.AddCommand(NewUsageCmd())
+	rootCmd.AddCommand(NewDocsCmd())
 
 	return rootCmd
 }
@@ -72,9 +147,17 @@ It supports multiple authentication methods and provides various customization o
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	rootCmd := NewRootCmd()
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	// Connections kept open for reuse are not needed past the command
+	tts.CloseConnections()
+	if err != nil {
+		err = explainAPIError(err)
+		fmt.Fprintf(os.Stderr, "%s %v\n", styleFor(os.Stderr).Error(), err)
+		if cmd == nil {
+			cmd = rootCmd
+		}
+		newRenderer(cmd).Error(err)
+		os.Exit(ExitCode(err))
 	}
 }
 
@@ -93,6 +176,9 @@ func initConfig() {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
 		// Don't exit here, as the app can still work with defaults
 	}
+	for _, report := range globalConfig.Migrations() {
+		printMigrationReport(os.Stderr, report)
+	}
 
 	// Keep the old viper functionality for backward compatibility
 	if cfgFile != "" {
@@ -130,3 +216,24 @@ func GetConfig() *config.Manager {
 	}
 	return globalConfig
 }
+
+// watchConfig reloads the configuration when its files change or on
+// SIGHUP until ctx is done, calling onChange after each reload that
+// changed a setting. Failed reloads keep the current configuration and are
+// reported through renderer.
+func watchConfig(ctx context.Context, renderer *Renderer, onChange func(*config.Change)) {
+	manager := GetConfig()
+	remove := manager.OnChange(func(change *config.Change) {
+		statusf(os.Stderr, "Reloaded the configuration (%s changed)\n", strings.Join(change.Keys, ", "))
+		onChange(change)
+	})
+	go func() {
+		defer remove()
+		err := manager.Watch(ctx, func(err error) {
+			renderer.Warnf("Warning: configuration not reloaded: %v\n", err)
+		})
+		if err != nil {
+			renderer.Warnf("Warning: configuration changes are not applied until restarted: %v\n", err)
+		}
+	}()
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sayVoice    string
+	sayLanguage string
+	saySpeed    float64
+	sayFormat   string
+	sayOutput   string
+)
+
+// NewSayCmd creates the say command
+func NewSayCmd() *cobra.Command {
+	sayCmd := &cobra.Command{
+		Use:   "say [TEXT...]",
+		Short: "Speak text aloud right away",
+		Long: `Speak text with the configured voice and play it at once: the quickest way to
+hear something, with nothing to set up beyond credentials. The text is the
+arguments, or STDIN when there are none. Without --output the audio goes to a
+temporary file that is removed after playback.
+
+When 'assistant-cli daemon' is running, say hands the text to it; the daemon
+keeps the API connection open, so speech starts much sooner, which suits
+editor integrations and scripts that speak often. Otherwise say synthesizes
+the text itself. With --socket, the daemon on that socket must be running.
+
+Examples:
+  assistant-cli say "Build finished"
+  git log -1 --format=%s | assistant-cli say --voice en-GB-Neural2-A
+  assistant-cli say "Saved for later" -o ~/notes/later.mp3 --no-play`,
+		RunE: runSay,
+	}
+
+	sayCmd.Flags().StringVarP(&sayVoice, "voice", "v", "", "Voice to use (default: tts.voice)")
+	sayCmd.Flags().StringVarP(&sayLanguage, "language", "l", "",
+		"Language code (default: the voice's language, or tts.language)")
+	sayCmd.Flags().Float64VarP(&saySpeed, "speed", "s", 0, "Speaking rate (default: tts.speaking_rate)")
+	sayCmd.Flags().StringVarP(&sayFormat, "format", "f", "", "Audio format (default: MP3, or LINEAR16 for espeak)")
+	sayCmd.Flags().StringVarP(&sayOutput, "output", "o", "", "Also save the audio to this file")
+	addSocketFlag(sayCmd)
+	addInputEncodingFlag(sayCmd)
+
+	return sayCmd
+}
+
+// sayResult is the machine-readable result of say
+type sayResult struct {
+	Voice    string  `json:"voice,omitempty"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+	File     string  `json:"file,omitempty"`
+	Played   bool    `json:"played"`
+}
+
+func runSay(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	renderer := newRenderer(cmd)
+
+	if reason := playbackSkipReason(); reason != "" && sayOutput == "" {
+		return usageError(fmt.Errorf("playback is off (%s); give --output to save the audio instead", reason))
+	}
+
+	request := &daemon.Request{
+		Voice:        sayVoice,
+		Language:     sayLanguage,
+		SpeakingRate: saySpeed,
+		Format:       sayFormat,
+	}
+	if sayOutput != "" {
+		output, err := filepath.Abs(expandHomeDirs([]string{sayOutput})[0])
+		if err != nil {
+			return ioError(fmt.Errorf("invalid --output: %w", err))
+		}
+		request.Output = output
+	}
+
+	if len(args) > 0 {
+		request.Text = strings.Join(args, " ")
+	} else {
+		text, err := processInput(GetConfig().Get().Input)
+		if err != nil {
+			return err
+		}
+		request.Text = text
+	}
+
+	resp, err := speak(ctx, renderer, request)
+	if err != nil {
+		return err
+	}
+	if resp.Temporary {
+		defer func() { _ = os.Remove(resp.File) }()
+	}
+
+	result := &sayResult{Voice: resp.Voice, Duration: resp.DurationSeconds}
+	if !resp.Temporary {
+		result.File = resp.File
+	}
+	if !skipPlayback() {
+		if err := playAudioFile(renderer, resp.File); err != nil {
+			return fmt.Errorf("failed to play audio: %w", err)
+		}
+		result.Played = true
+	}
+
+	return renderer.Result(result, func(w io.Writer) {
+		if result.File != "" {
+			statusf(w, "%s Saved %s\n", styleFor(w).Success(), result.File)
+		}
+	})
+}
+
+// speak synthesizes request with the daemon, or without --socket in this
+// process when no daemon is running
+func speak(ctx context.Context, renderer *Renderer, request *daemon.Request) (*daemon.Response, error) {
+	client, err := dialDaemon(ctx)
+	if err != nil {
+		if daemonSocket == "" && errors.Is(err, daemon.ErrNotRunning) {
+			detailf(os.Stderr, "No daemon is running; synthesizing in this process\n")
+			return speakInProcess(ctx, renderer, request)
+		}
+		return nil, err
+	}
+	defer func() { _ = client.Close() }()
+
+	resp, err := client.Synthesize(ctx, request)
+	if err != nil {
+		var remote *daemon.RemoteError
+		if errors.As(err, &remote) && remote.ExitCode != 0 {
+			return nil, newExitError(remote.ExitCode, err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// speakInProcess synthesizes request as the daemon would
+func speakInProcess(ctx context.Context, renderer *Renderer, request *daemon.Request) (*daemon.Response, error) {
+	cfg := GetConfig().Get()
+	if err := validateRequestVoice(request, cfg); err != nil {
+		return nil, err
+	}
+
+	handler, err := newDaemonHandler(ctx, renderer, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = handler.provider.Close() }()
+	return handler.synthesize(ctx, request)
+}
+
+// validateRequestVoice checks the voice of request against the cached voice
+// catalog, as the daemon checks it against the catalog loaded at startup
+func validateRequestVoice(request *daemon.Request, cfg *config.Config) error {
+	if request.Voice == "" {
+		return nil
+	}
+	voice := tts.ResolveVoiceAlias(cfg.TTS.VoiceAliases, request.Voice)
+	language := request.Language
+	if language == "" {
+		language = tts.VoiceLanguage(voice)
+	}
+	if language == "" {
+		language = cfg.TTS.Language
+	}
+	return validateVoiceOffline(voice, language, cfg.TTS.VoiceCacheTTL)
+}
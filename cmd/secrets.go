@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// NewSecretsCmd creates the secrets command and its subcommands
+func NewSecretsCmd() *cobra.Command {
+	secretsCmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Encrypt credentials stored in the config and token files",
+		Long: `Encrypt or decrypt the credentials kept at rest: the API key and OAuth2 client
+secret in the config file, and the OAuth2 token file. Encrypted values are
+decrypted transparently when they are read, so dotfiles can be synced to cloud
+storage without exposing them.
+
+The passphrase comes from ASSISTANT_CLI_PASSPHRASE, or else the OS keyring:
+the macOS Keychain or the Secret Service (secret-tool) entry with service
+"assistant-cli" and account "passphrase". Set auth.encrypt_token to keep new
+OAuth2 tokens encrypted too.`,
+	}
+
+	secretsCmd.AddCommand(newSecretsConvertCmd("encrypt", "Encrypt the credentials in the config and token files",
+		`Encrypt the credentials stored in plain text in the config file and the
+OAuth2 token file, in place. Values already encrypted are left alone.
+
+The config file given with --config is edited, otherwise the one that was
+loaded.
+
+Examples:
+  export ASSISTANT_CLI_PASSPHRASE='correct horse battery staple'
+  assistant-cli secrets encrypt
+  secret-tool store --label "assistant-cli" service assistant-cli account passphrase
+  assistant-cli secrets encrypt --config ~/dotfiles/assistant-cli.yaml`))
+	secretsCmd.AddCommand(newSecretsConvertCmd("decrypt", "Store the credentials in plain text again",
+		`Decrypt the encrypted credentials in the config file and the OAuth2 token
+file, in place, for example before changing the passphrase.
+
+Examples:
+  assistant-cli secrets decrypt`))
+	return secretsCmd
+}
+
+// newSecretsConvertCmd creates the encrypt or decrypt subcommand
+func newSecretsConvertCmd(use, short, long string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  long,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSecretsConvert(cmd, use == "encrypt")
+		},
+	}
+}
+
+// secretsResult is the machine-readable result of secrets encrypt and decrypt
+type secretsResult struct {
+	ConfigFile string   `json:"config_file,omitempty"`
+	Settings   []string `json:"settings"`
+	// TokenFile is set when the OAuth2 token file was converted
+	TokenFile string `json:"token_file,omitempty"`
+}
+
+func runSecretsConvert(cmd *cobra.Command, encrypt bool) error {
+	passphrase, err := secrets.Passphrase()
+	if err != nil {
+		return authError(err)
+	}
+
+	manager := GetConfig()
+	result := &secretsResult{Settings: []string{}}
+	path, err := configFileToEdit(manager)
+	if err != nil {
+		return ioError(err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		result.ConfigFile = path
+		convert := config.DecryptSecrets
+		if encrypt {
+			convert = config.EncryptSecrets
+		}
+		if result.Settings, err = convert(path, passphrase); err != nil {
+			return secretsError(err)
+		}
+	}
+
+	tokenFile := auth.TokenFile(manager.Get().Auth.OAuth2TokenFile)
+	converted, err := convertTokenFile(cmd.Context(), tokenFile, passphrase, encrypt)
+	if err != nil {
+		return secretsError(err)
+	}
+	if converted {
+		result.TokenFile = tokenFile
+	}
+
+	verb := "Decrypted"
+	if encrypt {
+		verb = "Encrypted"
+	}
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		if len(result.Settings) == 0 && result.TokenFile == "" {
+			fmt.Fprintf(w, "Nothing to %s\n", cmd.Name())
+			return
+		}
+		for _, key := range result.Settings {
+			statusf(w, "%s %s %s in %s\n", styleFor(w).Success(), verb, key, result.ConfigFile)
+		}
+		if result.TokenFile != "" {
+			statusf(w, "%s %s the OAuth2 token file %s\n", styleFor(w).Success(), verb, result.TokenFile)
+		}
+	})
+}
+
+// convertTokenFile encrypts or decrypts the token file at path in place,
+// holding its lock so a concurrent token refresh is not lost, and reports
+// whether it was changed. A missing file is left alone.
+func convertTokenFile(ctx context.Context, path, passphrase string, encrypt bool) (bool, error) {
+	return auth.RewriteTokenFile(ctx, path, func(data []byte) ([]byte, error) {
+		if secrets.IsEncrypted(string(data)) == encrypt {
+			return nil, nil
+		}
+		if !encrypt {
+			data, err := secrets.Decrypt(string(data), passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return data, nil
+		}
+		value, err := secrets.Encrypt(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(value + "\n"), nil
+	})
+}
+
+// secretsError classifies a failure to convert secrets: a wrong passphrase
+// is a credentials problem, anything else a file problem
+func secretsError(err error) error {
+	if errors.Is(err, secrets.ErrDecrypt) || errors.Is(err, secrets.ErrNoPassphrase) {
+		return authError(err)
+	}
+	return ioError(err)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSecretsCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"secrets"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestSecretsEncryptDecrypt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	t.Setenv(secrets.PassphraseEnv, "correct horse")
+	tokenFile := filepath.Join(t.TempDir(), "token.json")
+	token := `{"access_token": "ya29.token", "refresh_token": "1//refresh"}`
+	require.NoError(t, os.WriteFile(tokenFile, []byte(token), 0600))
+	path := writeTestConfig(t, "auth:\n  api_key: \"AIzaSyTestKey1234567890\"\n  oauth2_token_file: \""+
+		tokenFile+"\"\n")
+
+	stdout, err := runSecretsCommand(t, "encrypt", "--config", path, "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data secretsResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, []string{"auth.api_key"}, result.Data.Settings)
+	assert.Equal(t, tokenFile, result.Data.TokenFile)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "AIzaSy")
+	assert.Contains(t, string(data), secrets.Prefix)
+	data, err = os.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.True(t, secrets.IsEncrypted(string(data)))
+
+	// Encrypted values are read back transparently
+	manager := config.NewManager()
+	manager.SetConfigFile(path)
+	require.NoError(t, manager.Load())
+	assert.Equal(t, "AIzaSyTestKey1234567890", manager.Get().Auth.APIKey)
+
+	stdout, err = runSecretsCommand(t, "encrypt", "--config", path)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Nothing to encrypt")
+
+	_, err = runSecretsCommand(t, "decrypt", "--config", path)
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "AIzaSyTestKey1234567890")
+	data, err = os.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, token, string(data))
+}
+
+func TestSecretsEncrypt_NoPassphrase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(secrets.PassphraseEnv, "")
+	t.Setenv("PATH", t.TempDir())
+	path := writeTestConfig(t, "auth:\n  api_key: \"AIzaSyTestKey1234567890\"\n")
+
+	_, err := runSecretsCommand(t, "encrypt", "--config", path)
+	assert.Equal(t, ExitAuth, ExitCode(err))
+	assert.ErrorIs(t, err, secrets.ErrNoPassphrase)
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+// selftestText is synthesized by selftest; a single character keeps the
+// request within the smallest billing unit
+const selftestText = "a"
+
+var (
+	selftestDryRun bool
+	selftestPlay   bool
+	selftestKeep   bool
+)
+
+// NewSelftestCmd creates the selftest command
+func NewSelftestCmd() *cobra.Command {
+	selftestCmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Run an end-to-end smoke test of credentials and environment",
+		Long: `Run a minimal end-to-end check of the configured TTS provider, intended for CI pipelines.
+
+The self-test validates the configuration, authenticates (Google Cloud only),
+synthesizes a single character and writes it to a temporary file. With --dry-run
+the synthesis is skipped, so no quota is used; a placeholder file is written
+instead to verify the temporary directory is writable. Playback is skipped unless
+--play is given.
+
+The exit code identifies the first failed step (3 authentication, 4 validation,
+5 quota, 6 I/O, 7 service unavailable). Use --output-format json for a
+structured report of every step.
+
+Examples:
+  assistant-cli selftest
+  assistant-cli selftest --dry-run
+  assistant-cli --output-format json selftest`,
+		RunE: runSelftest,
+	}
+
+	selftestCmd.Flags().BoolVar(&selftestDryRun, "dry-run", false,
+		"Authenticate but skip synthesis, so no API quota is used")
+	selftestCmd.Flags().BoolVar(&selftestPlay, "play", false, "Also play the synthesized audio")
+	selftestCmd.Flags().BoolVar(&selftestKeep, "keep", false, "Keep the temporary audio file instead of deleting it")
+
+	return selftestCmd
+}
+
+// selftestReport is the machine-readable result of selftest
+type selftestReport struct {
+	Provider   string `json:"provider"`
+	Passed     bool   `json:"passed"`
+	DryRun     bool   `json:"dry_run"`
+	OutputFile string `json:"output_file,omitempty"`
+	checkList
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), onlineCheckTimeout)
+	defer cancel()
+
+	renderer := newRenderer(cmd)
+	manager := GetConfig()
+	report := &selftestReport{DryRun: selftestDryRun}
+
+	runSelftestChecks(ctx, renderer, manager, report)
+
+	err := report.firstError()
+	report.Passed = err == nil
+
+	text := func(w io.Writer) { printSelftestReport(w, report) }
+	if err != nil {
+		if !renderer.IsJSON() {
+			text(cmd.OutOrStdout())
+		}
+		return withResult(err, report)
+	}
+	return renderer.Result(report, text)
+}
+
+// runSelftestChecks runs each self-test step in order, skipping the steps
+// after the first failure
+func runSelftestChecks(ctx context.Context, renderer *Renderer, manager *config.Manager, report *selftestReport) {
+	steps := []string{"config", "auth", "synthesis", "output", "playback"}
+	skipRest := func(from int, reason string) {
+		for _, name := range steps[from:] {
+			report.skip(name, reason)
+		}
+	}
+
+	if err := manager.Validate(); err != nil {
+		var validationErrors config.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			err = fmt.Errorf("%d validation error(s); run 'assistant-cli config validate' for details: %w",
+				len(validationErrors), err)
+		}
+		report.fail("config", validationError(err))
+		skipRest(1, "invalid configuration")
+		return
+	}
+	report.pass("config", "static validation passed")
+
+	cfg := manager.Get()
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		report.fail("config", validationError(err))
+		skipRest(1, "unknown provider")
+		return
+	}
+	report.Provider = providerName
+
+	// Only Google Cloud needs credentials; a local provider that cannot
+	// start fails the synthesis step instead
+	ttsConfig := createTTSConfig(cfg.TTS)
+	provider, err := createProvider(ctx, providerName, cfg.Auth, ttsConfig)
+	switch {
+	case err != nil && providerName == tts.ProviderGoogle:
+		report.fail("auth", err)
+		skipRest(2, "authentication failed")
+		return
+	case err != nil:
+		report.skip("auth", fmt.Sprintf("not required by the %s provider", providerName))
+		report.fail("synthesis", err)
+		skipRest(3, "provider unavailable")
+		return
+	case providerName == tts.ProviderGoogle:
+		report.pass("auth", "credentials accepted")
+	default:
+		report.skip("auth", fmt.Sprintf("not required by the %s provider", providerName))
+	}
+	defer provider.Close()
+
+	dir, err := os.MkdirTemp("", "assistant-cli-selftest-")
+	if err != nil {
+		report.skip("synthesis", "no temporary directory")
+		report.fail("output", ioError(fmt.Errorf("failed to create temporary directory: %w", err)))
+		report.skip("playback", "no temporary directory")
+		return
+	}
+	if !selftestKeep {
+		defer os.RemoveAll(dir)
+	}
+
+	format := "MP3"
+	if formats := tts.SupportedFormats(providerName); formats != nil {
+		format = formats[0]
+	}
+	path := filepath.Join(dir, "selftest."+tts.FileExtension(format))
+
+	if selftestDryRun {
+		report.skip("synthesis", "dry run; no API quota used")
+		if err := os.WriteFile(path, []byte(selftestText), 0600); err != nil {
+			report.fail("output", ioError(fmt.Errorf("failed to write temporary file: %w", err)))
+		} else {
+			report.pass("output", "temporary directory is writable")
+		}
+		report.skip("playback", "dry run")
+		return
+	}
+
+	req := &tts.SynthesizeRequest{
+		Voice:        ttsConfig.Voice,
+		LanguageCode: ttsConfig.LanguageCode,
+		SpeakingRate: ttsConfig.SpeakingRate,
+		Pitch:        ttsConfig.Pitch,
+		VolumeGain:   ttsConfig.VolumeGain,
+		AudioFormat:  format,
+	}
+	resp, err := tts.NewSynthesizer(provider).SynthesizeText(ctx, selftestText, req)
+	if err != nil {
+		report.fail("synthesis", err)
+		skipRest(3, "synthesis failed")
+		return
+	}
+	report.pass("synthesis", fmt.Sprintf("synthesized %d bytes of %s audio", resp.Size, format))
+
+	if err := os.WriteFile(path, resp.AudioData, 0600); err != nil {
+		report.fail("output", ioError(fmt.Errorf("failed to write temporary file: %w", err)))
+		report.skip("playback", "no audio file")
+		return
+	}
+	report.pass("output", "wrote "+path)
+	if selftestKeep {
+		report.OutputFile = path
+	}
+
+	if !selftestPlay {
+		report.skip("playback", "use --play to test audio playback")
+		return
+	}
+	if reason := playbackSkipReason(); reason != "" {
+		report.skip("playback", reason)
+		return
+	}
+	if err := playAudioFile(renderer, path); err != nil {
+		report.fail("playback", newExitError(ExitUnavailable, err))
+		return
+	}
+	report.pass("playback", "audio played")
+}
+
+// printSelftestReport writes the human-readable self-test summary
+func printSelftestReport(w io.Writer, report *selftestReport) {
+	if report.Provider != "" {
+		fmt.Fprintf(w, "Self-test of the %s provider:\n", report.Provider)
+	} else {
+		fmt.Fprintln(w, "Self-test:")
+	}
+	printChecks(w, report.Checks)
+
+	if report.Passed {
+		fmt.Fprintln(w, styleFor(w).Success(), "Self-test passed")
+	} else {
+		fmt.Fprintln(w, styleFor(w).Failure(), "Self-test failed")
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEspeakOnPath installs a stand-in espeak-ng that writes placeholder audio
+func fakeEspeakOnPath(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake espeak binary is a shell script")
+	}
+
+	// Like espeak-ng --stdout, the header carries placeholder sizes
+	wav := append(output.WAVHeader(0, 22050, 1), 1, 0, 2, 0)
+	binary.LittleEndian.PutUint32(wav[4:8], 0x7ffff024)
+	binary.LittleEndian.PutUint32(wav[40:44], 0x7ffff000)
+
+	dir := t.TempDir()
+	wavPath := filepath.Join(dir, "espeak.wav")
+	require.NoError(t, os.WriteFile(wavPath, wav, 0600))
+	script := "#!/bin/sh\ncat >/dev/null\ncat '" + wavPath + "'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "espeak-ng"), []byte(script), 0700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func resetSelftestFlags(t *testing.T) {
+	t.Cleanup(func() {
+		selftestDryRun = false
+		selftestPlay = false
+		selftestKeep = false
+	})
+}
+
+func checkStatuses(report *selftestReport) map[string]string {
+	statuses := make(map[string]string)
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	return statuses
+}
+
+// runSelftestCommand executes selftest with JSON output and decodes the report
+func runSelftestCommand(t *testing.T, args ...string) (*selftestReport, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"--output-format", "json", "selftest"}, args...))
+
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		newRenderer(cmd).Error(err)
+	}
+
+	var result struct {
+		Success bool           `json:"success"`
+		Data    selftestReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, err == nil, result.Success)
+	return &result.Data, err
+}
+
+func TestSelftestCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeEspeakOnPath(t)
+	resetSelftestFlags(t)
+	path := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	report, err := runSelftestCommand(t, "--config", path, "--keep")
+	require.NoError(t, err)
+	assert.True(t, report.Passed)
+	assert.Equal(t, "espeak", report.Provider)
+	assert.Equal(t, map[string]string{
+		"config":    checkPass,
+		"auth":      checkSkip,
+		"synthesis": checkPass,
+		"output":    checkPass,
+		"playback":  checkSkip,
+	}, checkStatuses(report))
+
+	require.NotEmpty(t, report.OutputFile)
+	t.Cleanup(func() { _ = os.RemoveAll(filepath.Dir(report.OutputFile)) })
+	data, err := os.ReadFile(report.OutputFile)
+	require.NoError(t, err)
+	pcm, err := audio.DecodeWAV(data)
+	require.NoError(t, err)
+	assert.Equal(t, []int16{1, 2}, pcm.Samples)
+	assert.Equal(t, 22050, pcm.SampleRate)
+}
+
+func TestSelftestChecksDryRun(t *testing.T) {
+	fakeEspeakOnPath(t)
+	resetSelftestFlags(t)
+	useGlobalConfig(t, writeTestConfig(t, "tts:\n  provider: \"espeak\"\n"))
+	selftestDryRun = true
+
+	report := &selftestReport{}
+	runSelftestChecks(context.Background(), newRenderer(&cobra.Command{}), GetConfig(), report)
+
+	require.NoError(t, report.firstError())
+	assert.Equal(t, checkSkip, checkStatuses(report)["synthesis"])
+	assert.Equal(t, checkPass, checkStatuses(report)["output"])
+	assert.Empty(t, report.OutputFile)
+}
+
+func TestSelftestChecksInvalidConfig(t *testing.T) {
+	resetSelftestFlags(t)
+	useGlobalConfig(t, writeTestConfig(t, "tts:\n  provider: \"espeak\"\n"))
+	GetConfig().Get().TTS.SpeakingRate = 10
+
+	report := &selftestReport{}
+	runSelftestChecks(context.Background(), newRenderer(&cobra.Command{}), GetConfig(), report)
+
+	assert.Equal(t, ExitValidation, ExitCode(report.firstError()))
+	assert.Equal(t, checkFail, checkStatuses(report)["config"])
+	assert.Equal(t, checkSkip, checkStatuses(report)["synthesis"])
+	assert.Len(t, report.Checks, 5)
+}
+
+func TestSelftestCommandProviderUnavailable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("ASSISTANT_CLI_TTS_PROVIDER", "espeak")
+	resetSelftestFlags(t)
+
+	report, err := runSelftestCommand(t)
+	require.Error(t, err)
+	assert.Equal(t, ExitUnavailable, ExitCode(err))
+	assert.False(t, report.Passed)
+	assert.Equal(t, checkFail, checkStatuses(report)["synthesis"])
+	assert.Equal(t, checkSkip, checkStatuses(report)["output"])
+}
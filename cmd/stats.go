@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/stats"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+var statsReset bool
+
+// NewStatsCmd creates the stats command
+func NewStatsCmd() *cobra.Command {
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show performance and usage statistics",
+		Long: `Show the performance report of the last command that synthesized speech, and
+the characters synthesized, API calls made, and estimated cost of each month.
+
+Every command that synthesizes speech records its activity in
+~/.assistant-cli/stats.json. Costs are estimated from the Google Cloud list
+price of each voice tier, without the monthly free allowance; other providers
+cost nothing.
+
+Examples:
+  assistant-cli stats
+  assistant-cli --output-format json stats
+  assistant-cli stats --reset`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runStats,
+	}
+
+	statsCmd.Flags().BoolVar(&statsReset, "reset", false, "Forget all recorded statistics")
+
+	return statsCmd
+}
+
+// statsResult is the machine-readable result of stats
+type statsResult struct {
+	File    string         `json:"file"`
+	LastRun *stats.Run     `json:"last_run"`
+	Months  []*stats.Month `json:"months"`
+}
+
+func runStats(cmd *cobra.Command, _ []string) error {
+	path, err := stats.DefaultPath()
+	if err != nil {
+		return ioError(err)
+	}
+	recorded, err := stats.Load(path)
+	if err != nil {
+		return ioError(err)
+	}
+
+	if statsReset {
+		recorded.Reset()
+		if err := recorded.Save(); err != nil {
+			return ioError(err)
+		}
+	}
+
+	result := &statsResult{File: path, LastRun: recorded.LastRun(), Months: recorded.Months()}
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		if statsReset {
+			fmt.Fprintln(w, styleFor(w).Success(), "Statistics reset")
+			return
+		}
+		printStats(w, result)
+	})
+}
+
+// printStats writes the last run report and the monthly usage table
+func printStats(w io.Writer, result *statsResult) {
+	run := result.LastRun
+	if run == nil {
+		fmt.Fprintln(w, "No synthesis has been recorded yet.")
+		return
+	}
+
+	fmt.Fprintf(w, `Performance Report (last run)
+=============================
+Command: %s
+Providers: %s
+Started: %s
+Duration: %v
+Total Requests: %d
+Failed Requests: %d
+Characters: %d
+Estimated Cost: $%.4f
+
+Latency Metrics:
+  Average: %v
+  50th Percentile: %v
+  90th Percentile: %v
+  99th Percentile: %v
+`,
+		run.Command,
+		strings.Join(run.Providers, ", "),
+		run.StartedAt.Local().Format(time.DateTime),
+		time.Duration(run.DurationMs)*time.Millisecond,
+		run.Requests,
+		run.Failed,
+		run.Characters,
+		run.EstimatedCost,
+		time.Duration(run.AverageLatencyMs)*time.Millisecond,
+		time.Duration(run.P50LatencyMs)*time.Millisecond,
+		time.Duration(run.P90LatencyMs)*time.Millisecond,
+		time.Duration(run.P99LatencyMs)*time.Millisecond,
+	)
+
+	fmt.Fprintln(w, "\nUsage by Month")
+	fmt.Fprintln(w, "==============")
+	fmt.Fprintf(w, "%-8s %6s %10s %14s %12s\n", "Month", "Runs", "API Calls", "Characters", "Est. Cost")
+	for _, month := range result.Months {
+		fmt.Fprintf(w, "%-8s %6d %10d %14d %12s\n", month.Month, month.Runs, month.Requests, month.Characters,
+			fmt.Sprintf("$%.2f", month.EstimatedCost))
+	}
+}
+
+// runRecorder collects the synthesis activity of the running command
+type runRecorder struct {
+	command string
+	start   time.Time
+	monitor *tts.PerformanceMonitor
+
+	// ignoreBudget lets synthesis continue past the monthly character budget
+	ignoreBudget bool
+	// renderer writes the warnings of saving the statistics
+	renderer *Renderer
+
+	mu sync.Mutex
+	// usage has one ledger entry per provider and voice
+	usage  []*stats.Entry
+	budget characterBudget
+}
+
+var (
+	currentRun           *runRecorder
+	registerStatsCleanup sync.Once
+)
+
+// startRunStats begins recording the activity of a command
+func startRunStats(cmd *cobra.Command) {
+	command := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	currentRun = &runRecorder{command: command, start: time.Now(), ignoreBudget: ignoreBudget, renderer: newRenderer(cmd)}
+}
+
+// meter wraps a provider so its synthesis is recorded with the current run
+func meter(provider tts.Provider) tts.Provider {
+	if currentRun == nil {
+		return provider
+	}
+	return &meteredProvider{Provider: provider, run: currentRun}
+}
+
+// unwrapProvider returns the provider wrapped by meter
+func unwrapProvider(provider tts.Provider) tts.Provider {
+	if metered, ok := provider.(*meteredProvider); ok {
+		return metered.Provider
+	}
+	return provider
+}
+
+// meteredProvider records the requests, characters, and latency of a
+// provider
+type meteredProvider struct {
+	tts.Provider
+	run *runRecorder
+}
+
+func (p *meteredProvider) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audioConfig *texttospeechpb.AudioConfig) ([]byte, error) {
+	characters := utf8.RuneCountInString(text)
+	if err := p.run.reserve(p.Name(), characters); err != nil {
+		return nil, err
+	}
+
+	done := p.run.benchmark()
+	data, err := p.Provider.Synthesize(ctx, text, voice, audioConfig)
+	if err != nil {
+		done(false, err.Error())
+		p.run.release(p.Name(), characters)
+		return nil, err
+	}
+	done(true, "")
+	p.run.add(p.Name(), voice.GetName(), characters)
+	return data, nil
+}
+
+// benchmark starts timing a request, creating the monitor on first use
+func (r *runRecorder) benchmark() func(bool, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.monitor == nil {
+		r.monitor = tts.NewPerformanceMonitor(true)
+	}
+	return r.monitor.StartBenchmark("synthesize")
+}
+
+// add records a successful request. Only Google Cloud voices are billed.
+func (r *runRecorder) add(provider, voice string, characters int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budget.release(provider, characters)
+
+	var entry *stats.Entry
+	for _, e := range r.usage {
+		if e.Provider == provider && e.Voice == voice {
+			entry = e
+			break
+		}
+	}
+	if entry == nil {
+		tier := stats.TierLocal
+		if provider == tts.ProviderGoogle {
+			tier = stats.VoiceTier(voice)
+		}
+		entry = &stats.Entry{Time: r.start, Command: r.command, Provider: provider, Voice: voice, Tier: tier}
+		r.usage = append(r.usage, entry)
+	}
+	entry.Requests++
+	entry.Characters += characters
+	entry.EstimatedCost += stats.TierCost(entry.Tier, characters)
+}
+
+// run returns the recorded activity, or nil when nothing was synthesized
+func (r *runRecorder) run() *stats.Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.monitor == nil {
+		return nil
+	}
+
+	summary := r.monitor.GetReport().SummaryStats
+	run := &stats.Run{
+		Command:          r.command,
+		Providers:        []string{},
+		StartedAt:        r.start,
+		DurationMs:       time.Since(r.start).Milliseconds(),
+		Requests:         summary.TotalRequests,
+		Failed:           summary.FailedRequests,
+		AverageLatencyMs: summary.AverageLatency.Milliseconds(),
+		P50LatencyMs:     summary.P50Latency.Milliseconds(),
+		P90LatencyMs:     summary.P90Latency.Milliseconds(),
+		P99LatencyMs:     summary.P99Latency.Milliseconds(),
+	}
+	providers := make(map[string]bool)
+	for _, entry := range r.usage {
+		if !providers[entry.Provider] {
+			providers[entry.Provider] = true
+			run.Providers = append(run.Providers, entry.Provider)
+		}
+		run.Characters += entry.Characters
+		run.EstimatedCost += entry.EstimatedCost
+	}
+	sort.Strings(run.Providers)
+	return run
+}
+
+// saveRunStats adds the activity of the finished command to the stats file
+// and the usage ledger, then warns when the monthly budget is running out.
+// Failing to save never fails the command.
+func saveRunStats() {
+	recorder := currentRun
+	currentRun = nil
+	if recorder == nil {
+		return
+	}
+	run := recorder.run()
+	if run == nil {
+		return
+	}
+
+	path, err := stats.DefaultPath()
+	if err == nil {
+		var recorded *stats.Stats
+		if recorded, err = stats.Load(path); err == nil {
+			recorded.Record(run)
+			err = recorded.Save()
+		}
+	}
+	if err != nil {
+		recorder.renderer.Warnf("Warning: failed to record usage statistics: %v\n", err)
+	}
+
+	ledger, err := stats.DefaultLedgerPath()
+	if err == nil {
+		err = stats.AppendLedger(ledger, recorder.usage)
+	}
+	if err != nil {
+		recorder.renderer.Warnf("Warning: failed to record usage ledger: %v\n", err)
+		return
+	}
+	warnMonthlyBudget(recorder.renderer, ledger, stats.MonthOf(recorder.start))
+}
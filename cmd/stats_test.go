@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/stats"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runStatsCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		statsReset = false
+		outputFormat = outputFormatText
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"stats"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestStatsCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	t.Setenv("HOME", t.TempDir())
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, err := runStatsCommand(t)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No synthesis has been recorded yet")
+
+	_, err = runAudiobookCommand(t, writeTestEPUB(t), "--config", config, "--format", "LINEAR16", "-o", t.TempDir())
+	require.NoError(t, err)
+
+	stdout, err = runStatsCommand(t, "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data statsResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	run := result.Data.LastRun
+	require.NotNil(t, run)
+	assert.Equal(t, "audiobook", run.Command)
+	assert.Equal(t, []string{"espeak"}, run.Providers)
+	assert.Equal(t, 2, run.Requests)
+	assert.Positive(t, run.Characters)
+	// Local providers are free
+	assert.Zero(t, run.EstimatedCost)
+	require.Len(t, result.Data.Months, 1)
+	assert.Equal(t, run.Characters, result.Data.Months[0].Characters)
+
+	stdout, err = runStatsCommand(t)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Performance Report (last run)")
+	assert.Contains(t, stdout, "Command: audiobook")
+	assert.Contains(t, stdout, "Usage by Month")
+
+	_, err = runStatsCommand(t, "--reset")
+	require.NoError(t, err)
+	stdout, err = runStatsCommand(t)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No synthesis has been recorded yet")
+}
+
+func TestRunRecorder(t *testing.T) {
+	recorder := &runRecorder{command: "synthesize"}
+	assert.Nil(t, recorder.run())
+
+	done := recorder.benchmark()
+	done(true, "")
+	recorder.add(tts.ProviderGoogle, "en-US-Wavenet-D", 1000)
+	done = recorder.benchmark()
+	done(false, "quota exceeded")
+	recorder.add(tts.ProviderEspeak, "en", 500)
+	recorder.add(tts.ProviderGoogle, "en-US-Wavenet-D", 200)
+
+	run := recorder.run()
+	require.NotNil(t, run)
+	assert.Equal(t, []string{"espeak", "google"}, run.Providers)
+	assert.Equal(t, 2, run.Requests)
+	assert.Equal(t, 1, run.Failed)
+	assert.Equal(t, 1700, run.Characters)
+	assert.InDelta(t, 0.0192, run.EstimatedCost, 1e-9)
+
+	// The ledger has one entry per provider and voice
+	require.Len(t, recorder.usage, 2)
+	assert.Equal(t, stats.TierWaveNet, recorder.usage[0].Tier)
+	assert.Equal(t, 2, recorder.usage[0].Requests)
+	assert.Equal(t, 1200, recorder.usage[0].Characters)
+	assert.Equal(t, stats.TierLocal, recorder.usage[1].Tier)
+	assert.Zero(t, recorder.usage[1].EstimatedCost)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// remoteOutput is a cloud storage destination for a synthesized file. The
+// audio is written to a local temporary file first and uploaded once it is
+// complete.
+type remoteOutput struct {
+	dest    output.StorageURL
+	storage output.Storage
+	dir     string
+}
+
+// newRemoteOutput prepares an upload to the gs:// or s3:// URL rawURL,
+// checking the storage credentials before anything is synthesized
+func newRemoteOutput(ctx context.Context, cfg *config.Config, rawURL string) (*remoteOutput, error) {
+	dest, err := output.ParseStorageURL(rawURL)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	storage, err := newStorage(ctx, cfg, dest.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "assistant-cli-upload-")
+	if err != nil {
+		return nil, ioError(fmt.Errorf("failed to create staging directory: %w", err))
+	}
+	return &remoteOutput{dest: dest, storage: storage, dir: dir}, nil
+}
+
+// LocalPath returns the temporary file the audio is written to before upload
+func (r *remoteOutput) LocalPath() string {
+	return filepath.Join(r.dir, path.Base(r.dest.Key))
+}
+
+// UploadFile uploads the local file at localPath to the destination
+func (r *remoteOutput) UploadFile(ctx context.Context, localPath string) error {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return ioError(fmt.Errorf("failed to read %s for upload: %w", localPath, err))
+	}
+	if err := r.storage.Upload(ctx, r.dest, data, output.ContentType(r.dest.Key)); err != nil {
+		return ioError(err)
+	}
+	return nil
+}
+
+// UploadManifest uploads m next to the destination and returns its URL
+func (r *remoteOutput) UploadManifest(ctx context.Context, m *output.Manifest) (string, error) {
+	data, err := m.Encode()
+	if err != nil {
+		return "", ioError(err)
+	}
+	dest := r.dest.WithSuffix(output.ManifestSuffix)
+	if err := r.storage.Upload(ctx, dest, data, output.ContentType(dest.Key)); err != nil {
+		return "", ioError(err)
+	}
+	return dest.String(), nil
+}
+
+// Close removes the temporary files
+func (r *remoteOutput) Close() {
+	_ = os.RemoveAll(r.dir)
+}
+
+// newStorage creates the storage backend for scheme. GCS reuses the
+// configured auth credentials; S3 reads the AWS environment variables, with
+// output.storage overriding the region and endpoint.
+func newStorage(ctx context.Context, cfg *config.Config, scheme string) (output.Storage, error) {
+	chunkSize := cfg.Output.Storage.UploadChunkSize << 20
+	network := convertToNetworkConfig(cfg.Network)
+
+	if scheme == output.SchemeGCS {
+		authConfig := convertToAuthConfig(cfg.Auth)
+		authConfig.Network = network
+		client, err := auth.NewAuthManager(authConfig).HTTPClient(ctx, output.GCSScope)
+		if err != nil {
+			return nil, authError(fmt.Errorf("cloud storage authentication failed: %w", err))
+		}
+		return output.NewGCSStorage(client, "", chunkSize), nil
+	}
+
+	transport, err := httpTransport(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	s3Config := output.S3ConfigFromEnv()
+	if cfg.Output.Storage.S3Region != "" {
+		s3Config.Region = cfg.Output.Storage.S3Region
+	}
+	if cfg.Output.Storage.S3Endpoint != "" {
+		s3Config.Endpoint = cfg.Output.Storage.S3Endpoint
+	}
+	storage, err := output.NewS3Storage(&http.Client{Transport: transport}, s3Config, chunkSize)
+	if err != nil {
+		return nil, authError(err)
+	}
+	return storage, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRemoteOutput_InvalidURL(t *testing.T) {
+	_, err := newRemoteOutput(context.Background(), config.GetDefaults(), "gs://bucket/")
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
+
+func TestNewRemoteOutput_MissingS3Credentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	_, err := newRemoteOutput(context.Background(), config.GetDefaults(), "s3://bucket/out.mp3")
+	require.Error(t, err)
+	assert.Equal(t, ExitAuth, ExitCode(err))
+}
+
+func TestRemoteOutput_UploadToS3(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		uploaded = map[string]string{}
+		types    = map[string]string{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		uploaded[r.URL.Path] = string(body)
+		types[r.URL.Path] = r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	cfg := config.GetDefaults()
+	cfg.Output.Storage.S3Endpoint = server.URL
+
+	remote, err := newRemoteOutput(context.Background(), cfg, "s3://bucket/podcasts/episode.mp3")
+	require.NoError(t, err)
+	local := remote.LocalPath()
+	assert.Equal(t, "episode.mp3", filepath.Base(local))
+
+	require.NoError(t, os.WriteFile(local, []byte("audio"), 0644))
+	require.NoError(t, remote.UploadFile(context.Background(), local))
+	manifestURL, err := remote.UploadManifest(context.Background(), &output.Manifest{
+		File: &output.FileInfo{Path: "s3://bucket/podcasts/episode.mp3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "s3://bucket/podcasts/episode.mp3.meta.json", manifestURL)
+
+	assert.Equal(t, "audio", uploaded["/bucket/podcasts/episode.mp3"])
+	assert.Equal(t, "audio/mpeg", types["/bucket/podcasts/episode.mp3"])
+	assert.Contains(t, uploaded["/bucket/podcasts/episode.mp3.meta.json"], `"path": "s3://bucket/podcasts/episode.mp3"`)
+
+	remote.Close()
+	assert.NoDirExists(t, filepath.Dir(local))
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"io"
+	"os"
+)
+
+// ANSI escape sequences of the colors used for status symbols
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// terminalStyle renders the status symbols of human-readable output for one
+// writer: colored on terminals that support it, plain elsewhere, and in
+// ASCII on legacy Windows consoles that cannot show the Unicode symbols
+type terminalStyle struct {
+	color bool
+	ascii bool
+}
+
+// styleFor returns the style of output written to w. Colors need a terminal
+// and are turned off by app.color_output: false, NO_COLOR, or TERM=dumb.
+func styleFor(w io.Writer) terminalStyle {
+	f, ok := w.(*os.File)
+	if !ok || !isTerminal(f) {
+		return terminalStyle{}
+	}
+	if legacyConsole(f) {
+		return terminalStyle{ascii: true}
+	}
+
+	_, noColor := os.LookupEnv("NO_COLOR")
+	color := !noColor && os.Getenv("TERM") != "dumb" && GetConfig().Get().App.ColorOutput
+	return terminalStyle{color: color}
+}
+
+// Success returns the symbol of a completed step
+func (s terminalStyle) Success() string {
+	return s.symbol("✓", "OK", ansiGreen)
+}
+
+// Failure returns the symbol of a failed step
+func (s terminalStyle) Failure() string {
+	return s.symbol("❌", "X", ansiRed)
+}
+
+// Warning returns the symbol of a warning
+func (s terminalStyle) Warning() string {
+	return s.symbol("⚠", "!", ansiYellow)
+}
+
+// Error returns the label of an error message
+func (s terminalStyle) Error() string {
+	return s.symbol("Error:", "Error:", ansiRed)
+}
+
+// symbol returns unicode, or ascii on legacy consoles, in color when enabled
+func (s terminalStyle) symbol(unicode, ascii, color string) string {
+	text := unicode
+	if s.ascii {
+		text = ascii
+	}
+	if s.color {
+		return color + text + ansiReset
+	}
+	return text
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerminalStyle(t *testing.T) {
+	plain := terminalStyle{}
+	assert.Equal(t, "✓", plain.Success())
+	assert.Equal(t, "❌", plain.Failure())
+	assert.Equal(t, "⚠", plain.Warning())
+	assert.Equal(t, "Error:", plain.Error())
+
+	colored := terminalStyle{color: true}
+	assert.Equal(t, "\033[32m✓\033[0m", colored.Success())
+	assert.Equal(t, "\033[31mError:\033[0m", colored.Error())
+
+	ascii := terminalStyle{ascii: true}
+	assert.Equal(t, "OK", ascii.Success())
+	assert.Equal(t, "X", ascii.Failure())
+	assert.Equal(t, "!", ascii.Warning())
+}
+
+func TestStyleFor_NotATerminal(t *testing.T) {
+	assert.Equal(t, terminalStyle{}, styleFor(new(bytes.Buffer)))
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, terminalStyle{}, styleFor(f), "files get neither colors nor ASCII symbols")
+}
@@ -2,12 +2,20 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/audio/ffmpeg"
 	"github.com/mikefarmer/assistant-cli/internal/auth"
 	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/longtext"
 	"github.com/mikefarmer/assistant-cli/internal/output"
 	"github.com/mikefarmer/assistant-cli/internal/player"
 	"github.com/mikefarmer/assistant-cli/internal/tts"
@@ -17,30 +25,66 @@ import (
 )
 
 var (
-	voice        string
-	languageCode string
-	speakingRate float64
-	pitch        float64
-	volumeGain   float64
-	outputFile   string
-	audioFormat  string
-	playAudio    bool
-	listVoices   bool
+	voice         string
+	languageCode  string
+	speakingRate  float64
+	pitch         float64
+	volumeGain    float64
+	outputFile    string
+	audioFormat   string
+	sampleRate    int
+	playAudio     bool
+	listVoices    bool
+	normalize     bool
+	trimSilence   bool
+	fadeIn        time.Duration
+	fadeOut       time.Duration
+	writeManifest bool
+	autoLanguage  bool
+
+	paragraphPause time.Duration
+	sentencePause  time.Duration
+
+	musicFile   string
+	musicVolume string
+
+	speechMarksFile string
+	customVoice     string
+	dialogue        bool
 )
 
 func NewSynthesizeCmd() *cobra.Command {
 	synthesizeCmd := &cobra.Command{
 		Use:     "synthesize",
-		Aliases: []string{"tts", "speak", "say"},
+		Aliases: []string{"tts", "speak"},
 		Short:   "Convert text to speech using Google Cloud Text-to-Speech",
 		Long: `Convert text to speech using Google Cloud Text-to-Speech API.
 		
 Reads text from STDIN and generates an audio file with customizable voice settings.
+Set tts.provider to "espeak" to synthesize locally with espeak-ng instead, without
+Google credentials (LINEAR16 output only).
+
+Input starting with a YAML front-matter block may set voice, language,
+speaking_rate, pitch, volume_gain, and output (a file name template) under an
+"assistant-cli" key for that text; flags still override them, and the block is
+not read aloud.
+
+--profile telephony writes 8 kHz mu-law WAV files with the telephony effects
+profile, ready for Twilio; --profile asterisk writes the same audio as the
+headerless .ulaw files Asterisk plays. Give -f ALAW with either for A-law.
 
 Examples:
   echo "Hello, World!" | assistant-cli synthesize -o hello.mp3
   cat story.txt | assistant-cli synthesize --voice en-US-Wavenet-C --play
-  echo "<speak>Hello <break time='1s'/> World!</speak>" | assistant-cli synthesize`,
+  echo "<speak>Hello <break time='1s'/> World!</speak>" | assistant-cli synthesize
+  echo "Hello" | ASSISTANT_CLI_TTS_PROVIDER=espeak assistant-cli synthesize -f LINEAR16 -o hello.wav
+  echo "Hello" | assistant-cli synthesize -f LINEAR16 -o hello.wav --normalize --trim-silence --fade-out 500ms
+  cat intro.txt | assistant-cli synthesize -f LINEAR16 -o intro.wav --music bed.wav --music-volume -18dB
+  echo "Guten Morgen, wie geht es dir?" | assistant-cli synthesize --auto-language
+  cat episode.txt | assistant-cli synthesize --preset podcast -o episode.mp3
+  echo "Please hold" | assistant-cli synthesize --profile telephony -o hold.wav
+  cat essay.txt | assistant-cli synthesize --paragraph-pause 600ms --sentence-pause 200ms
+  assistant-cli synthesize < chapter-one.md`,
 		RunE: runSynthesize,
 	}
 
@@ -49,11 +93,41 @@ Examples:
 	synthesizeCmd.Flags().Float64VarP(&speakingRate, "speed", "s", 1.0, "Speaking rate (0.25 to 4.0)")
 	synthesizeCmd.Flags().Float64VarP(&pitch, "pitch", "p", 0.0, "Voice pitch (-20.0 to 20.0)")
 	synthesizeCmd.Flags().Float64VarP(&volumeGain, "volume", "g", 0.0, "Volume gain in dB (-96.0 to 16.0)")
-	synthesizeCmd.Flags().StringVarP(&outputFile, "output", "o", "output.mp3", "Output file path")
+	synthesizeCmd.Flags().StringVarP(&outputFile, "output", "o", "output.mp3",
+		"Output file path, or a gs://bucket/path or s3://bucket/path URL to upload to")
 	synthesizeCmd.Flags().StringVarP(&audioFormat, "format", "f", "MP3",
-		"Audio format (MP3, LINEAR16, OGG_OPUS, MULAW, ALAW, PCM)")
+		"Audio format (MP3, LINEAR16, WAV, OGG_OPUS, MULAW, ALAW, PCM; AAC, FLAC, OPUS, WEBM with ffmpeg)")
+	synthesizeCmd.Flags().IntVar(&sampleRate, "sample-rate", 0,
+		"Sample rate in Hz for LINEAR16, WAV and PCM (default: 24000 for WAV, otherwise the voice's rate)")
 	synthesizeCmd.Flags().BoolVar(&playAudio, "play", false, "Play audio immediately after synthesis")
 	synthesizeCmd.Flags().BoolVar(&listVoices, "list-voices", false, "List available voices for the language")
+	synthesizeCmd.Flags().BoolVar(&normalize, "normalize", false, "Normalize loudness (LINEAR16 only)")
+	synthesizeCmd.Flags().BoolVar(&trimSilence, "trim-silence", false,
+		"Trim leading and trailing silence (LINEAR16 only)")
+	synthesizeCmd.Flags().DurationVar(&fadeIn, "fade-in", 0, "Fade-in duration, e.g. 200ms (LINEAR16 only)")
+	synthesizeCmd.Flags().DurationVar(&fadeOut, "fade-out", 0, "Fade-out duration, e.g. 500ms (LINEAR16 only)")
+	synthesizeCmd.Flags().StringVar(&musicFile, "music", "",
+		"WAV file to mix under the narration, ducked while it speaks (LINEAR16 only)")
+	synthesizeCmd.Flags().StringVar(&musicVolume, "music-volume", "",
+		"Level of the --music, e.g. -18dB (default: output.post_process.music_volume)")
+	synthesizeCmd.Flags().BoolVar(&autoLanguage, "auto-language", false,
+		"Detect the language of the text and switch to a voice for it")
+	synthesizeCmd.Flags().BoolVar(&writeManifest, "manifest", false,
+		"Write a <output>.meta.json manifest recording how the file was produced")
+	synthesizeCmd.Flags().StringVar(&customVoice, "custom-voice", "",
+		"Custom Voice model to speak with, projects/PROJECT/locations/LOCATION/models/MODEL")
+	synthesizeCmd.Flags().BoolVar(&dialogue, "dialogue", false,
+		"Read \"NAME: line\" scripts with the voices input.speakers gives the speakers")
+	synthesizeCmd.Flags().StringVar(&speechMarksFile, "speech-marks", "",
+		"Write word and <mark> timings of the audio to this JSON file")
+	synthesizeCmd.Flags().DurationVar(&paragraphPause, "paragraph-pause", 0,
+		"Pause between paragraphs, e.g. 600ms (default: input.paragraph_pause)")
+	synthesizeCmd.Flags().DurationVar(&sentencePause, "sentence-pause", 0,
+		"Pause between sentences, e.g. 200ms (default: input.sentence_pause)")
+	addNotifyFlag(synthesizeCmd)
+	addPresetFlag(synthesizeCmd)
+	addProfileFlag(synthesizeCmd)
+	addInputEncodingFlag(synthesizeCmd)
 
 	// Bind flags to viper for backward compatibility
 	_ = viper.BindPFlag("tts.voice", synthesizeCmd.Flags().Lookup("voice"))
@@ -68,71 +142,359 @@ Examples:
 	return synthesizeCmd
 }
 
-func runSynthesize(cmd *cobra.Command, args []string) error {
+// synthesisResult is the machine-readable summary of a completed synthesis
+type synthesisResult struct {
+	Provider    string               `json:"provider"`
+	Fallback    bool                 `json:"fallback,omitempty"`
+	OutputFile  string               `json:"output_file"`
+	Format      string               `json:"format"`
+	Size        int                  `json:"size"`
+	Voice       string               `json:"voice,omitempty"`
+	Language    string               `json:"language,omitempty"`
+	Duration    float64              `json:"duration_seconds,omitempty"`
+	File        *output.FileInfo     `json:"file,omitempty"`
+	Metrics     *tts.MetricsSnapshot `json:"metrics,omitempty"`
+	Manifest    string               `json:"manifest,omitempty"`
+	SpeechMarks string               `json:"speech_marks,omitempty"`
+	Played      bool                 `json:"played"`
+}
+
+// voiceInfo is the machine-readable description of an available voice
+type voiceInfo struct {
+	Name                   string   `json:"name"`
+	Gender                 string   `json:"gender"`
+	LanguageCodes          []string `json:"language_codes"`
+	NaturalSampleRateHertz int32    `json:"natural_sample_rate_hertz"`
+}
+
+func runSynthesize(cmd *cobra.Command, args []string) (err error) {
 	ctx := context.Background()
 	cfg := GetConfig().Get()
+	renderer := newRenderer(cmd)
+
+	if listVoices {
+		return handleListVoices(ctx, cfg, languageCode, false, renderer)
+	}
+
+	notice, err := newCompletionNotice(cfg, "synthesize")
+	if err != nil {
+		return err
+	}
+	defer func() { notice.send(renderer, err) }()
+
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		return validationError(err)
+	}
 
-	authManager, err := setupAuthentication(ctx, cfg.Auth)
+	postProcess, err := createPostProcessOptions(cfg.Output.PostProcess)
 	if err != nil {
 		return err
 	}
 
+	text, err := processInput(cfg.Input)
+	if err != nil {
+		return err
+	}
+	frontMatter, text, err := utils.ParseFrontMatter(text)
+	if err != nil {
+		return validationError(err)
+	}
+	cfg = applyFrontMatter(cfg, frontMatter)
+	if cfg, err = applyPreset(cmd, cfg, &audioFormat); err != nil {
+		return err
+	}
+	if cfg, err = applyProfile(cmd, cfg, providerName, postProcess); err != nil {
+		return err
+	}
+	if err := checkTranscoder(audioFormat); err != nil {
+		return err
+	}
+	notice.payload.Characters = utf8.RuneCountInString(text)
+
 	ttsConfig := createTTSConfig(cfg.TTS)
-	ttsClient, err := createTTSClient(ctx, authManager, ttsConfig)
+	if err := checkCustomVoice(renderer, ttsConfig.CustomVoice, providerName, cfg.Auth); err != nil {
+		return err
+	}
+	if providerName == tts.ProviderGoogle && ttsConfig.CustomVoice == "" {
+		if err := validateVoiceOffline(ttsConfig.Voice, ttsConfig.LanguageCode, cfg.TTS.VoiceCacheTTL); err != nil {
+			return err
+		}
+	}
+
+	provider, err := createProvider(ctx, providerName, cfg.Auth, ttsConfig)
+	if err != nil {
+		if provider, err = fallBack(ctx, renderer, cfg.TTS, ttsConfig, err); err != nil {
+			return err
+		}
+	}
+	defer func() { _ = provider.Close() }()
+
+	inputCfg, err := applyPauseFlags(cfg.Input)
 	if err != nil {
 		return err
 	}
-	defer ttsClient.Close()
+	inputCfg.Dialogue = inputCfg.Dialogue || dialogue
+	if text, err = filterText(text, inputCfg, ttsConfig.LanguageCode); err != nil {
+		return err
+	}
 
-	if listVoices {
-		return handleListVoices(ctx, ttsClient, languageCode)
+	req, err := createSynthesizeRequest(ttsConfig, text, cfg.Output)
+	if err != nil {
+		return err
+	}
+	if autoLanguage {
+		req = languageRequest(req, utils.DetectLanguage(text))
+	}
+	if providerName == tts.ProviderGoogle {
+		if err := checkMarkupVoices(text, cfg.TTS.VoiceCacheTTL); err != nil {
+			return err
+		}
 	}
+	if provider.Name() != providerName {
+		adaptRequest(renderer, req, provider.Name())
+	}
+	// Voice markup is synthesized a segment at a time, so the times of
+	// its marks would not be from the start of the audio
+	req.Timepoints = speechMarksFile != "" && !utils.HasVoiceMarkup(text)
 
-	text, err := processInput(cfg.Input)
+	// Cloud storage destinations are synthesized to a local staging file
+	// and uploaded once complete
+	var remote *remoteOutput
+	if output.IsRemotePath(req.OutputFile) {
+		if remote, err = newRemoteOutput(ctx, cfg, req.OutputFile); err != nil {
+			return err
+		}
+		defer remote.Close()
+		req.OutputFile = remote.LocalPath()
+	}
+
+	// Settle an existing output file before spending a request on it
+	overwrite, err := newOverwriteHandler(cfg.Output, false)
 	if err != nil {
 		return err
 	}
+	existing := &output.FileInfo{}
+	if remote == nil {
+		if existing, err = overwrite.PrepareOverwrite(req.OutputFile); err != nil {
+			return prepareError(err)
+		}
+	}
 
-	req := createSynthesizeRequest(ttsConfig, text, cfg.Output)
-	resp, err := tts.NewSynthesizer(ttsClient).SynthesizeText(ctx, text, req)
+	slog.Debug("synthesizing", "provider", provider.Name(), "voice", req.Voice, "language", req.LanguageCode,
+		"characters", len(text), "output", req.OutputFile)
+
+	resp, err := newSynthesizer(withVoiceMarkup(provider, text), postProcess,
+		cfg.Output.WriteMetadata).SynthesizeText(ctx, text, req)
+	if err != nil && provider.Name() == providerName {
+		// The primary provider may fail only once the request is sent,
+		// e.g. when the network is down
+		fallback, fallbackErr := fallBack(ctx, renderer, cfg.TTS, ttsConfig, err)
+		if fallbackErr == nil {
+			_ = provider.Close()
+			provider = fallback
+			adaptRequest(renderer, req, provider.Name())
+			resp, err = newSynthesizer(withVoiceMarkup(provider, text), postProcess,
+				cfg.Output.WriteMetadata).SynthesizeText(ctx, text, req)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("synthesis failed: %w", err)
 	}
+	if remote != nil {
+		if err := remote.UploadFile(ctx, resp.OutputFile); err != nil {
+			return err
+		}
+	} else if _, err := overwrite.PruneBackups(resp.OutputFile); err != nil {
+		renderer.Warnf("Warning: %v\n", err)
+	}
+
+	result := buildSynthesisResult(resp, req, provider, false)
+	result.Fallback = provider.Name() != providerName
+	if result.File != nil {
+		result.File.Overwritten = existing.Overwritten
+		result.File.BackupPath = existing.BackupPath
+	}
+	if remote != nil {
+		result.OutputFile = remote.dest.String()
+		if result.File != nil {
+			result.File.Path = result.OutputFile
+		}
+	}
+	notice.payload.File = result.OutputFile
+	notice.payload.DurationSeconds = result.Duration
 
-	printSynthesisResults(resp)
+	if !renderer.IsJSON() {
+		printSynthesisResults(resp, result.OutputFile)
+		if existing.BackupPath != "" {
+			statusf(os.Stderr, "  Backup: %s\n", existing.BackupPath)
+		}
+	}
 
 	if playAudio || cfg.Playback.AutoPlay {
-		handleAudioPlayback(resp.OutputFile)
+		result.Played = handleAudioPlayback(renderer, resp.OutputFile)
 	}
 
-	return nil
+	if (writeManifest || cfg.Output.WriteManifest) && result.File != nil {
+		if remote != nil {
+			if result.Manifest, err = remote.UploadManifest(ctx, newSynthesisManifest(text, req, resp, result)); err != nil {
+				return err
+			}
+		} else if result.Manifest, err = writeSynthesisManifest(text, req, resp, result); err != nil {
+			return ioError(err)
+		}
+		if !renderer.IsJSON() {
+			statusf(os.Stderr, "  Manifest: %s\n", result.Manifest)
+		}
+	}
+
+	if speechMarksFile != "" {
+		if result.SpeechMarks, err = writeSpeechMarks(speechMarksFile, text, resp); err != nil {
+			return err
+		}
+		if !renderer.IsJSON() {
+			statusf(os.Stderr, "  Speech marks: %s\n", result.SpeechMarks)
+		}
+	}
+
+	return renderer.Result(result, nil)
+}
+
+// writeSpeechMarks writes the word and SSML mark timings of the audio
+// synthesized from text to path and returns the path. The times are
+// estimated from the length of the audio, with the marks moved to the
+// timepoints the API reported for them.
+func writeSpeechMarks(path, text string, resp *tts.SynthesizeResponse) (string, error) {
+	if resp.Duration <= 0 {
+		return "", validationError(fmt.Errorf(
+			"speech marks need the length of the audio, which cannot be read from %s output", resp.Format))
+	}
+	if utils.HasVoiceMarkup(text) {
+		var spoken []string
+		for _, segment := range utils.ParseVoiceMarkup(text) {
+			spoken = append(spoken, segment.Text)
+		}
+		text = strings.Join(spoken, "\n")
+	}
+
+	path = expandHomeDirs([]string{path})[0]
+	if err := output.WriteSpeechMarks(path, speechMarks(text, resp)); err != nil {
+		return "", ioError(err)
+	}
+	return path, nil
+}
+
+// speechMarks estimates the marks of the audio synthesized from text and
+// aligns them with its timepoints
+func speechMarks(text string, resp *tts.SynthesizeResponse) *output.SpeechMarks {
+	marks := output.EstimateSpeechMarks(text, resp.Duration)
+	if len(resp.Timepoints) > 0 {
+		times := make([]output.MarkTime, 0, len(resp.Timepoints))
+		for _, point := range resp.Timepoints {
+			times = append(times, output.MarkTime{Name: point.Mark, Time: point.Time})
+		}
+		output.AlignSpeechMarks(marks, times)
+	}
+	return marks
+}
+
+// writeSynthesisManifest writes the provenance manifest next to the
+// synthesized file and returns its path
+func writeSynthesisManifest(text string, req *tts.SynthesizeRequest, resp *tts.SynthesizeResponse,
+	result *synthesisResult) (string, error) {
+	return output.WriteManifest(newSynthesisManifest(text, req, resp, result))
 }
 
-func setupAuthentication(ctx context.Context, authCfg config.AuthConfig) (*auth.AuthManager, error) {
+// newSynthesisManifest records how the synthesized file was produced
+func newSynthesisManifest(text string, req *tts.SynthesizeRequest, resp *tts.SynthesizeResponse,
+	result *synthesisResult) *output.Manifest {
+	manifest := output.NewManifest(text, output.ManifestRequest{
+		Voice:        req.Voice,
+		Language:     req.LanguageCode,
+		SpeakingRate: req.SpeakingRate,
+		Pitch:        req.Pitch,
+		VolumeGain:   req.VolumeGain,
+		Format:       req.AudioFormat,
+		SampleRate:   req.SampleRate,
+	}, result.File, resp.Duration, resp.Latency)
+	manifest.Provider = result.Provider
+	manifest.Fallback = result.Fallback
+	return manifest
+}
+
+func buildSynthesisResult(resp *tts.SynthesizeResponse, req *tts.SynthesizeRequest, provider tts.Provider,
+	played bool) *synthesisResult {
+	result := &synthesisResult{
+		Provider:   provider.Name(),
+		OutputFile: resp.OutputFile,
+		Format:     resp.Format,
+		Size:       resp.Size,
+		Voice:      req.Voice,
+		Language:   req.LanguageCode,
+		Duration:   resp.Duration.Seconds(),
+		Played:     played,
+	}
+
+	if resp.OutputFile != "" {
+		if info, err := output.StatFile(resp.OutputFile); err == nil {
+			info.Engine = provider.Name()
+			info.DurationSeconds = result.Duration
+			result.File = info
+		}
+	}
+
+	if client, ok := unwrapProvider(provider).(*tts.Client); ok {
+		if metrics := client.GetMetrics(); metrics != nil {
+			snapshot := metrics.Snapshot()
+			result.Metrics = &snapshot
+		}
+	}
+
+	return result
+}
+
+func setupAuthentication(ctx context.Context, authCfg config.AuthConfig,
+	ttsConfig *tts.ClientConfig) (*auth.AuthManager, error) {
 	authConfig := convertToAuthConfig(authCfg)
+	authConfig.Network = convertToNetworkConfig(GetConfig().Get().Network)
+	authConfig.ClientOptions = tts.ConnectionOptions(ttsConfig)
 	authManager := auth.NewAuthManager(authConfig)
 
 	if err := authManager.Validate(ctx); err != nil {
-		return nil, fmt.Errorf("authentication failed: %w\nRun 'assistant-cli login' to set up authentication", err)
+		return nil, authError(
+			fmt.Errorf("authentication failed: %w\nRun 'assistant-cli login' to set up authentication", err))
 	}
 
 	return authManager, nil
 }
 
 func createTTSConfig(ttsCfg config.TTSConfig) *tts.ClientConfig {
+	defaults := tts.DefaultClientConfig()
 	ttsConfig := &tts.ClientConfig{
-		Voice:         ttsCfg.Voice,
-		LanguageCode:  ttsCfg.Language,
-		SpeakingRate:  ttsCfg.SpeakingRate,
-		Pitch:         ttsCfg.Pitch,
-		VolumeGain:    ttsCfg.VolumeGain,
-		AudioEncoding: ttsCfg.AudioEncoding,
+		Voice:             ttsCfg.Voice,
+		CustomVoice:       ttsCfg.CustomVoice,
+		LanguageCode:      ttsCfg.Language,
+		SpeakingRate:      ttsCfg.SpeakingRate,
+		Pitch:             ttsCfg.Pitch,
+		VolumeGain:        ttsCfg.VolumeGain,
+		AudioEncoding:     ttsCfg.AudioEncoding,
+		Timeout:           ttsCfg.Timeout,
+		RequestsPerMinute: ttsCfg.RequestsPerMinute,
+		EffectsProfile:    ttsCfg.EffectsProfile,
+		PoolMaxSize:       defaults.PoolMaxSize,
+		PoolIdleTimeout:   defaults.PoolIdleTimeout,
+		KeepAliveTime:     defaults.KeepAliveTime,
+		KeepAliveTimeout:  defaults.KeepAliveTimeout,
 	}
+	applyRetryPolicy(ttsConfig, ttsCfg)
 
 	// Override with command line flags if provided
 	if voice != "" {
 		ttsConfig.Voice = voice
 	}
+	if customVoice != "" {
+		ttsConfig.CustomVoice = customVoice
+	}
 	if languageCode != "en-US" {
 		ttsConfig.LanguageCode = languageCode
 	}
@@ -148,10 +510,173 @@ func createTTSConfig(ttsCfg config.TTSConfig) *tts.ClientConfig {
 	if audioFormat != "MP3" {
 		ttsConfig.AudioEncoding = audioFormat
 	}
+	ttsConfig.Voice = tts.ResolveVoiceAlias(ttsCfg.VoiceAliases, ttsConfig.Voice)
+
+	// A language without a voice for it is read by its favorite voice
+	// rather than the one the API picks
+	if provider, _ := tts.NormalizeProvider(ttsCfg.Provider); provider == tts.ProviderGoogle &&
+		voice == "" && ttsConfig.CustomVoice == "" &&
+		!strings.EqualFold(tts.VoiceLanguage(ttsConfig.Voice), ttsConfig.LanguageCode) {
+		if preferred, ok := tts.PreferredVoice(ttsCfg.LanguageVoices, ttsConfig.LanguageCode); ok {
+			ttsConfig.Voice, ttsConfig.LanguageCode = preferred, tts.VoiceLanguage(preferred)
+		}
+	}
 
 	return ttsConfig
 }
 
+// createPostProcessOptions merges the post-processing flags into the
+// configured settings and checks the requested audio format supports them
+func createPostProcessOptions(cfg config.PostProcessConfig) (audio.Options, error) {
+	opts := audio.Options{
+		Normalize:        cfg.Normalize || normalize,
+		TargetLevel:      cfg.TargetLevel,
+		TrimSilence:      cfg.TrimSilence || trimSilence,
+		SilenceThreshold: cfg.SilenceThreshold,
+		FadeIn:           cfg.FadeIn,
+		FadeOut:          cfg.FadeOut,
+	}
+	if fadeIn != 0 {
+		opts.FadeIn = fadeIn
+	}
+	if fadeOut != 0 {
+		opts.FadeOut = fadeOut
+	}
+
+	if opts.FadeIn < 0 || opts.FadeOut < 0 {
+		return opts, usageError(fmt.Errorf("fade durations cannot be negative"))
+	}
+	opts.MusicVolume, opts.MusicDucking = cfg.MusicVolume, cfg.MusicDucking
+	if musicVolume != "" {
+		gain, err := audio.ParseGain(musicVolume)
+		if err != nil {
+			return opts, usageError(fmt.Errorf("invalid --music-volume: %w", err))
+		}
+		opts.MusicVolume = gain
+	}
+	music := cfg.Music
+	if musicFile != "" {
+		music = musicFile
+	}
+	if music != "" {
+		var err error
+		if opts.Music, err = loadMusic(music); err != nil {
+			return opts, err
+		}
+	}
+	if opts.Enabled() && !audio.Supports(audioFormat) && !ffmpeg.IsFormat(audioFormat) {
+		return opts, validationError(fmt.Errorf(
+			"audio post-processing requires LINEAR16 output, got %s (use --format LINEAR16)", audioFormat))
+	}
+	return opts, nil
+}
+
+// checkCustomVoice validates the name of a custom voice model before
+// synthesis, warning when it belongs to a project other than that of the
+// credentials
+func checkCustomVoice(renderer *Renderer, model, providerName string, authCfg config.AuthConfig) error {
+	if model == "" {
+		return nil
+	}
+	parsed, err := tts.ParseCustomVoiceModel(model)
+	if err != nil {
+		return usageError(err)
+	}
+	if providerName != tts.ProviderGoogle {
+		return validationError(fmt.Errorf("tts.provider %s: %w", providerName, tts.ErrCustomVoiceUnsupported))
+	}
+	if err := parsed.CheckProject(auth.NewAuthManager(convertToAuthConfig(authCfg)).ProjectID()); err != nil {
+		renderer.Warnf("Warning: %v\n", err)
+	}
+	return nil
+}
+
+// checkTranscoder makes sure ffmpeg is installed when format is produced by
+// transcoding, before any synthesis is paid for
+func checkTranscoder(format string) error {
+	if !ffmpeg.IsFormat(format) {
+		return nil
+	}
+	if _, err := ffmpeg.Path(); err != nil {
+		return validationError(fmt.Errorf("--format %s needs ffmpeg: %w", strings.ToUpper(format), err))
+	}
+	return nil
+}
+
+// loadMusic reads the background music at path: a 16-bit WAV file, or any
+// audio file ffmpeg can decode when it is installed
+func loadMusic(path string) (*audio.PCM, error) {
+	data, err := os.ReadFile(expandHomeDirs([]string{path})[0])
+	if err != nil {
+		return nil, ioError(fmt.Errorf("failed to read music: %w", err))
+	}
+	music, err := audio.DecodeWAV(data)
+	if err != nil {
+		if _, lookErr := ffmpeg.Path(); lookErr == nil {
+			if data, err = ffmpeg.ToWAV(context.Background(), data); err != nil {
+				return nil, validationError(fmt.Errorf("music %s: %w", path, err))
+			}
+			music, err = audio.DecodeWAV(data)
+		}
+	}
+	if err != nil {
+		return nil, validationError(fmt.Errorf("music %s: %w (convert it to WAV, e.g. ffmpeg -i bed.mp3 bed.wav)", path, err))
+	}
+	return music, nil
+}
+
+// newSynthesizer creates a synthesizer for provider that applies the
+// selected post-processing and metadata tags to its output
+func newSynthesizer(provider tts.Provider, postProcess audio.Options, writeMetadata bool) *tts.Synthesizer {
+	synthesizer := tts.NewSynthesizer(provider)
+	synthesizer.SetWriteMetadata(writeMetadata)
+	if postProcess.Enabled() {
+		synthesizer.SetPostProcessor(func(data []byte, format string) ([]byte, error) {
+			return audio.Process(data, format, postProcess)
+		})
+	}
+	return synthesizer
+}
+
+// createProvider creates the synthesis backend named by providerName,
+// recording its use in the run statistics. Authentication is only set up for
+// providers that need it.
+func createProvider(ctx context.Context, providerName string, authCfg config.AuthConfig,
+	ttsConfig *tts.ClientConfig) (tts.Provider, error) {
+	if providerName == tts.ProviderEspeak {
+		provider, err := tts.NewEspeakProvider(ttsConfig)
+		if err != nil {
+			return nil, newExitError(ExitUnavailable, fmt.Errorf("failed to create TTS provider: %w", err))
+		}
+		return meter(provider), nil
+	}
+
+	client, err := newGoogleClient(ctx, authCfg, ttsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return meter(client), nil
+}
+
+// newGoogleClient authenticates and creates the Google Cloud client. When
+// tts.EnvRecording replays API responses from a cassette, no credentials
+// are needed and none are looked for.
+func newGoogleClient(ctx context.Context, authCfg config.AuthConfig, ttsConfig *tts.ClientConfig) (*tts.Client, error) {
+	recording, err := tts.RecordingFromEnv()
+	if err != nil {
+		return nil, usageError(err)
+	}
+	ttsConfig.Recording = recording
+
+	var authManager *auth.AuthManager
+	if !recording.Replaying() {
+		if authManager, err = setupAuthentication(ctx, authCfg, ttsConfig); err != nil {
+			return nil, err
+		}
+	}
+	return createTTSClient(ctx, authManager, ttsConfig)
+}
+
 func createTTSClient(ctx context.Context, authManager *auth.AuthManager, ttsConfig *tts.ClientConfig) (*tts.Client, error) {
 	ttsClient, err := tts.NewClient(ctx, authManager, ttsConfig)
 	if err != nil {
@@ -160,122 +685,334 @@ func createTTSClient(ctx context.Context, authManager *auth.AuthManager, ttsConf
 	return ttsClient, nil
 }
 
+// inputEncodingFlag is the --input-encoding flag of the commands that read
+// text from STDIN
+var inputEncodingFlag string
+
+// addInputEncodingFlag adds --input-encoding to a command that reads text
+// from STDIN
+func addInputEncodingFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&inputEncodingFlag, "input-encoding", "",
+		"Character encoding of the input: auto, utf-8, utf-16, utf-16le, utf-16be, latin1, windows-1252 "+
+			"(default: input.encoding)")
+}
+
 func processInput(inputCfg config.InputConfig) (string, error) {
-	fmt.Fprintln(os.Stderr, "Reading text from STDIN...")
+	statusf(os.Stderr, "Reading text from STDIN...\n")
 
 	inputProcessor := utils.NewInputProcessorWithConfig(os.Stdin, inputCfg.MaxLength)
-	text, err := inputProcessor.ReadText()
+	encoding := inputCfg.Encoding
+	if inputEncodingFlag != "" {
+		encoding = inputEncodingFlag
+	}
+	if err := inputProcessor.SetEncoding(encoding); err != nil {
+		return "", usageError(fmt.Errorf("invalid --input-encoding: %w", err))
+	}
+	// SSML documents are checked as a stream while they are read, so an
+	// oversized or malicious document is refused before it is held whole
+	var text string
+	var err error
+	if inputCfg.EnableSSMLSecurity {
+		text, err = inputProcessor.ReadValidatedText(newSSMLValidator(inputCfg))
+	} else {
+		text, err = inputProcessor.ReadText()
+	}
+	var validationErr *utils.ValidationError
+	if errors.As(err, &validationErr) {
+		return "", fmt.Errorf("input validation failed: %w", err)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to read input: %w", err)
 	}
+	if encoding := inputProcessor.Encoding(); encoding != utils.EncodingUTF8 {
+		detailf(os.Stderr, "Converted input from %s to UTF-8\n", encoding)
+	}
 
-	if inputCfg.EnableSSMLSecurity {
-		validator := utils.NewSSMLValidator()
-		if validationErr := validator.ValidateSSML(text); validationErr != nil {
+	if inputCfg.EnableSSMLSecurity && !longtext.IsSSML(text) {
+		if validationErr := newSSMLValidator(inputCfg).ValidateSSML(text); validationErr != nil {
 			return "", fmt.Errorf("input validation failed: %w", validationErr)
 		}
 	}
 
 	if inputCfg.ShowStats {
 		stats := inputProcessor.GetTextStats(text)
-		fmt.Fprintf(os.Stderr, "✓ Input processed: %s\n", stats.String())
+		statusf(os.Stderr, "%s Input processed: %s\n", styleFor(os.Stderr).Success(), stats.String())
 	}
 
 	return text, nil
 }
 
+// newSSMLValidator returns an SSML validator with the limits of cfg
+func newSSMLValidator(cfg config.InputConfig) *utils.SSMLValidator {
+	validator := utils.NewSSMLValidator()
+	validator.SetMaxBreakTime(cfg.MaxBreakTime)
+	validator.SetMaxNestingDepth(cfg.MaxSSMLDepth)
+	validator.SetMaxDocumentSize(cfg.MaxSSMLSize)
+	return validator
+}
+
+// applyFrontMatter returns a copy of cfg with the settings the front-matter
+// of the input gives in place of the configured ones; flags and --preset
+// still override them. The output name becomes the filename template used
+// without --output.
+func applyFrontMatter(cfg *config.Config, frontMatter utils.FrontMatter) *config.Config {
+	if frontMatter.IsZero() {
+		return cfg
+	}
+
+	result := *cfg
+	if frontMatter.Voice != "" {
+		result.TTS.Voice = frontMatter.Voice
+	}
+	if frontMatter.Language != "" {
+		result.TTS.Language = frontMatter.Language
+	}
+	if frontMatter.SpeakingRate != 0 {
+		result.TTS.SpeakingRate = frontMatter.SpeakingRate
+	}
+	if frontMatter.Pitch != 0 {
+		result.TTS.Pitch = frontMatter.Pitch
+	}
+	if frontMatter.VolumeGain != 0 {
+		result.TTS.VolumeGain = frontMatter.VolumeGain
+	}
+	if frontMatter.Output != "" {
+		result.Output.FilenameTemplate = frontMatter.Output
+	}
+	return &result
+}
+
+// inputFilters returns the filters input.* enables, in the order they are
+// applied: emoji, profanity, normalization for the locale input.normalize
+// sets or else language, then pauses
+func inputFilters(cfg config.InputConfig, language string) ([]utils.TextFilter, error) {
+	var filters []utils.TextFilter
+	if cfg.Emoji != "" && cfg.Emoji != utils.EmojiKeep {
+		emoji, err := utils.NewEmojiFilter(cfg.Emoji, cfg.EmojiNames)
+		if err != nil {
+			return nil, validationError(fmt.Errorf("input.emoji: %w", err))
+		}
+		filters = append(filters, emoji)
+	}
+	if cfg.ProfanityFilter != "" && cfg.ProfanityFilter != utils.ProfanityOff {
+		profanity, err := utils.NewProfanityFilter(cfg.ProfanityFilter, cfg.ProfanityWords)
+		if err != nil {
+			return nil, validationError(fmt.Errorf("input.profanity_filter: %w", err))
+		}
+		filters = append(filters, profanity)
+	}
+
+	if normalize := cfg.Normalize; normalize.Enabled {
+		opts := utils.NormalizeOptions{
+			Locale:   normalize.Locale,
+			SayAs:    normalize.Mode == "say-as",
+			Numbers:  normalize.Numbers,
+			Dates:    normalize.Dates,
+			Currency: normalize.Currency,
+			Units:    normalize.Units,
+		}
+		if opts.Locale == "" {
+			opts.Locale = language
+		}
+		filters = append(filters, utils.TextFilterFunc(func(text string) string {
+			return utils.NormalizeText(text, opts)
+		}))
+	}
+
+	if cfg.Dialogue {
+		filters = append(filters, utils.NewDialogueFilter(cfg.Speakers))
+	}
+
+	if cfg.ParagraphPause > 0 || cfg.SentencePause > 0 {
+		pauses, err := utils.NewPauseFilter(cfg.ParagraphPause, cfg.SentencePause)
+		if err != nil {
+			return nil, validationError(fmt.Errorf("input pauses: %w", err))
+		}
+		filters = append(filters, pauses)
+	}
+	return filters, nil
+}
+
+// applyPauseFlags returns cfg with the pauses --paragraph-pause and
+// --sentence-pause set
+func applyPauseFlags(cfg config.InputConfig) (config.InputConfig, error) {
+	if paragraphPause < 0 || sentencePause < 0 {
+		return cfg, usageError(fmt.Errorf("--paragraph-pause and --sentence-pause must not be negative"))
+	}
+	if paragraphPause != 0 {
+		cfg.ParagraphPause = paragraphPause
+	}
+	if sentencePause != 0 {
+		cfg.SentencePause = sentencePause
+	}
+	return cfg, nil
+}
+
+// filterText passes text through the filters input.* enables
+func filterText(text string, cfg config.InputConfig, language string) (string, error) {
+	filters, err := inputFilters(cfg, language)
+	if err != nil {
+		return "", err
+	}
+	return utils.ApplyFilters(text, filters...), nil
+}
+
 const defaultOutputFile = "output.mp3"
 
-func createSynthesizeRequest(ttsConfig *tts.ClientConfig, text string, outputCfg config.OutputConfig) *tts.SynthesizeRequest {
-	resolvedOutputFile := resolveOutputFile(text, outputCfg)
+func createSynthesizeRequest(ttsConfig *tts.ClientConfig, text string,
+	outputCfg config.OutputConfig) (*tts.SynthesizeRequest, error) {
+	resolvedOutputFile, err := resolveOutputFile(text, ttsConfig, outputCfg)
+	if err != nil {
+		return nil, err
+	}
 
 	return &tts.SynthesizeRequest{
-		Voice:        ttsConfig.Voice,
-		LanguageCode: ttsConfig.LanguageCode,
-		SpeakingRate: ttsConfig.SpeakingRate,
-		Pitch:        ttsConfig.Pitch,
-		VolumeGain:   ttsConfig.VolumeGain,
-		OutputFile:   resolvedOutputFile,
-		AudioFormat:  audioFormat,
+		Voice:          ttsConfig.Voice,
+		LanguageCode:   ttsConfig.LanguageCode,
+		SpeakingRate:   ttsConfig.SpeakingRate,
+		Pitch:          ttsConfig.Pitch,
+		VolumeGain:     ttsConfig.VolumeGain,
+		OutputFile:     resolvedOutputFile,
+		AudioFormat:    audioFormat,
+		SampleRate:     sampleRate,
+		EffectsProfile: ttsConfig.EffectsProfile,
+		CustomVoice:    ttsConfig.CustomVoice,
+		Container:      outputContainer,
+	}, nil
+}
+
+// resolveOutputFile returns the --output path, or without one renders the
+// configured filename template under output.default_path
+func resolveOutputFile(text string, ttsConfig *tts.ClientConfig, outputCfg config.OutputConfig) (string, error) {
+	if outputFile != defaultOutputFile {
+		return outputFile, nil
+	}
+
+	tmpl, err := output.ParseFilenameTemplate(filenameTemplate(outputCfg))
+	if err != nil {
+		return "", validationError(err)
+	}
+
+	data := output.NewFilenameData(text, ttsConfig.Voice, ttsConfig.LanguageCode, audioFormat,
+		tts.ContainerExtension(audioFormat, outputContainer), time.Now())
+	name, err := tmpl.Render(data, outputCfg.MaxFilenameLength)
+	if err != nil {
+		return "", validationError(err)
 	}
+	return filepath.Join(outputCfg.DefaultPath, filepath.FromSlash(name)), nil
 }
 
-func resolveOutputFile(text string, outputCfg config.OutputConfig) string {
-	if outputFile == defaultOutputFile && outputCfg.AutoFilename {
-		return output.GetSafeFilename(text[:min(50, len(text))], audioFormat)
-	} else if outputFile == defaultOutputFile {
-		return outputCfg.DefaultPath + "/output." + strings.ToLower(audioFormat)
+// filenameTemplate returns the template for generated output file names
+func filenameTemplate(outputCfg config.OutputConfig) string {
+	switch {
+	case outputCfg.FilenameTemplate != "":
+		return outputCfg.FilenameTemplate
+	case outputCfg.AutoFilename:
+		return output.AutoFilenameTemplate
+	default:
+		return output.DefaultFilenameTemplate
 	}
-	return outputFile
 }
 
-func printSynthesisResults(resp *tts.SynthesizeResponse) {
-	fmt.Fprintf(os.Stderr, "✓ Audio synthesized successfully\n")
-	fmt.Fprintf(os.Stderr, "  Output: %s\n", resp.OutputFile)
-	fmt.Fprintf(os.Stderr, "  Format: %s\n", resp.Format)
-	fmt.Fprintf(os.Stderr, "  Size: %d bytes\n", resp.Size)
+func printSynthesisResults(resp *tts.SynthesizeResponse, outputName string) {
+	statusf(os.Stderr, "%s Audio synthesized successfully\n", styleFor(os.Stderr).Success())
+	statusf(os.Stderr, "  Output: %s\n", outputName)
+	statusf(os.Stderr, "  Format: %s\n", resp.Format)
+	statusf(os.Stderr, "  Size: %d bytes\n", resp.Size)
+	if resp.Duration > 0 {
+		statusf(os.Stderr, "  Duration: %s\n", resp.Duration.Round(time.Millisecond))
+	}
+	detailf(os.Stderr, "  Latency: %s\n", resp.Latency.Round(time.Millisecond))
 }
 
-func handleAudioPlayback(filePath string) {
-	if err := playAudioFile(filePath); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to play audio: %v\n", err)
-	} else {
-		fmt.Fprintln(os.Stderr, "✓ Audio played successfully")
+func handleAudioPlayback(renderer *Renderer, filePath string) bool {
+	if skipPlayback() {
+		return false
 	}
+	if err := playAudioFile(renderer, filePath); err != nil {
+		renderer.Warnf("Warning: Failed to play audio: %v\n", err)
+		return false
+	}
+	statusf(os.Stderr, "%s Audio played successfully\n", styleFor(os.Stderr).Success())
+	return true
 }
 
-func handleListVoices(ctx context.Context, client *tts.Client, lang string) error {
-	voices, err := client.ListVoices(ctx, lang)
+// voiceGenderName converts an SSML gender enum value to a display name
+func voiceGenderName(gender int32) string {
+	switch gender {
+	case 1:
+		return "Male"
+	case 2:
+		return "Female"
+	case 3:
+		return "Neutral"
+	default:
+		return "Unspecified"
+	}
+}
+
+func playAudioFile(renderer *Renderer, filePath string) error {
+	audioPlayer, err := newConfiguredPlayer(renderer)
 	if err != nil {
-		return fmt.Errorf("failed to list voices: %w", err)
-	}
-
-	fmt.Printf("Available voices for language '%s':\n\n", lang)
-
-	for _, voice := range voices {
-		var gender string
-		switch voice.SsmlGender {
-		case 1:
-			gender = "Male"
-		case 2:
-			gender = "Female"
-		case 3:
-			gender = "Neutral"
-		default:
-			gender = "Unspecified"
-		}
+		return err
+	}
 
-		fmt.Printf("  %s\n", voice.Name)
-		fmt.Printf("    Gender: %s\n", gender)
-		fmt.Printf("    Languages: %v\n", voice.LanguageCodes)
-		fmt.Printf("    Sample Rate: %d Hz\n\n", voice.NaturalSampleRateHertz)
+	// Play the audio file in the background so it can be controlled
+	playback, err := audioPlayer.Start(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to play audio: %w", err)
+	}
+	if stopControls := startKeyControls(playback, "space: pause/resume, q: stop"); stopControls != nil {
+		<-playback.Done()
+		stopControls()
 	}
 
+	if err := playback.Wait(); err != nil {
+		return fmt.Errorf("failed to play audio: %w", err)
+	}
 	return nil
 }
 
-func playAudioFile(filePath string) error {
+// newConfiguredPlayer creates the configured or platform audio player with
+// the configured volume and speed, warning about settings it cannot apply
+func newConfiguredPlayer(renderer *Renderer) (*player.AudioPlayer, error) {
+	playbackCfg := GetConfig().Get().Playback
+
+	// Configured players are tried first, in order
+	var players []string
+	if playbackCfg.Player != "" {
+		players = append(players, playbackCfg.Player)
+	}
+	players = append(players, playbackCfg.Players...)
+
 	// Check if audio playback is supported on this platform
-	if !player.IsSupported() {
-		return fmt.Errorf("audio playback is not supported on this platform")
+	if len(players) == 0 && !player.IsSupported() {
+		return nil, fmt.Errorf("audio playback is not supported on this platform")
 	}
 
 	// Create audio player
-	audioPlayer, err := player.NewAudioPlayer()
+	audioPlayer, err := player.NewAudioPlayerWithOptions(player.Options{
+		Players:       players,
+		FormatPlayers: playbackCfg.FormatPlayers,
+		Fallback:      playbackCfg.EnableFallback,
+		Args:          playbackCfg.PlayerArgs,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to initialize audio player: %w", err)
+		return nil, fmt.Errorf("failed to initialize audio player: %w", err)
 	}
 
+	audioPlayer.SetVolume(playbackCfg.Volume)
+	audioPlayer.SetSpeed(playbackCfg.Speed)
+
 	// Get player info for debugging
 	info := audioPlayer.GetPlayerInfo()
-	fmt.Fprintf(os.Stderr, "Playing audio with %s on %s...\n", info.Command, info.Platform)
-
-	// Play the audio file
-	if err := audioPlayer.Play(filePath); err != nil {
-		return fmt.Errorf("failed to play audio: %w", err)
+	for _, setting := range audioPlayer.Unsupported() {
+		renderer.Warnf("Warning: %s does not support playback %s; ignoring playback.%s\n",
+			info.Command, setting, setting)
 	}
+	statusf(os.Stderr, "Playing audio with %s on %s...\n", info.Command, info.Platform)
 
-	return nil
+	return audioPlayer, nil
 }
 
 // convertToAuthConfig converts config.AuthConfig to auth.AuthConfig
@@ -286,6 +1023,8 @@ func convertToAuthConfig(cfg config.AuthConfig) auth.AuthConfig {
 		OAuth2ClientID:     cfg.OAuth2ClientID,
 		OAuth2ClientSecret: cfg.OAuth2ClientSecret,
 		OAuth2TokenFile:    cfg.OAuth2TokenFile,
+		EncryptToken:       cfg.EncryptToken,
+		RefreshWindow:      cfg.RefreshWindow,
 	}
 }
 
@@ -2,13 +2,21 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/mikefarmer/assistant-cli/internal/audio"
 	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/output"
 	"github.com/mikefarmer/assistant-cli/internal/player"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,7 +31,8 @@ func TestNewSynthesizeCmd(t *testing.T) {
 	assert.NotNil(t, cmd.RunE)
 
 	// Test flags exist
-	flags := []string{"voice", "language", "speed", "pitch", "volume", "output", "format", "play", "list-voices"}
+	flags := []string{"voice", "language", "speed", "pitch", "volume", "output", "format", "play", "list-voices",
+		"normalize", "trim-silence", "fade-in", "fade-out", "manifest", "speech-marks", "custom-voice", "dialogue"}
 	for _, flag := range flags {
 		assert.NotNil(t, cmd.Flags().Lookup(flag), "Flag %s should exist", flag)
 	}
@@ -137,6 +146,64 @@ func TestSynthesizeFlagValidation(t *testing.T) {
 	}
 }
 
+// stdinFrom replaces os.Stdin with a file holding data for the test
+func stdinFrom(t *testing.T, data []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	oldStdin := os.Stdin
+	os.Stdin = file
+	t.Cleanup(func() {
+		os.Stdin = oldStdin
+		file.Close()
+	})
+}
+
+func TestProcessInput_Encoding(t *testing.T) {
+	t.Cleanup(func() { inputEncodingFlag = "" })
+	inputCfg := config.InputConfig{MaxLength: 5000, Encoding: "auto"}
+
+	// UTF-16 with a byte order mark, as saved by Notepad
+	stdinFrom(t, []byte("\xff\xfeH\x00\xe9\x00l\x00l\x00o\x00"))
+	text, err := processInput(inputCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Héllo", text)
+
+	// Latin-1 read as UTF-8 is rejected
+	inputEncodingFlag = "utf-8"
+	stdinFrom(t, []byte("H\xe9llo"))
+	_, err = processInput(inputCfg)
+	assert.ErrorContains(t, err, "invalid UTF-8")
+
+	inputEncodingFlag = "latin1"
+	stdinFrom(t, []byte("H\xe9llo"))
+	text, err = processInput(inputCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Héllo", text)
+
+	inputEncodingFlag = "ebcdic"
+	_, err = processInput(inputCfg)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
+
+func TestProcessInput_SSML(t *testing.T) {
+	inputCfg := config.InputConfig{MaxLength: 5000, EnableSSMLSecurity: true, MaxSSMLDepth: 2,
+		MaxSSMLSize: 1024, MaxBreakTime: 10 * time.Second}
+
+	stdinFrom(t, []byte("<speak><p>Hello</p></speak>"))
+	text, err := processInput(inputCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "<speak><p>Hello</p></speak>", text)
+
+	// SSML documents are checked with the streaming validator
+	stdinFrom(t, []byte("<speak><p><s>Too deep</s></p></speak>"))
+	_, err = processInput(inputCfg)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "maximum depth of 2")
+}
+
 func TestConvertToAuthConfig(t *testing.T) {
 	configAuthConfig := config.AuthConfig{
 		APIKey:             "test-api-key",
@@ -186,25 +253,26 @@ func TestPlayAudioFile(t *testing.T) {
 	audioFile := filepath.Join(tempDir, "test.mp3")
 	err = os.WriteFile(audioFile, []byte("fake audio data"), 0644)
 	require.NoError(t, err)
+	renderer := newRenderer(&cobra.Command{})
 
 	t.Run("play audio when supported", func(t *testing.T) {
 		if player.IsSupported() {
 			// This will likely fail because we don't have a real audio file,
 			// but we can test that the function doesn't panic
-			err := playAudioFile(audioFile)
+			err := playAudioFile(renderer, audioFile)
 			// We expect this to fail with a real error about the audio format
 			// rather than a panic, so we just check that it returns an error
 			assert.Error(t, err)
 		} else {
 			// On unsupported platforms, it should return an error
-			err := playAudioFile(audioFile)
+			err := playAudioFile(renderer, audioFile)
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), "not supported")
 		}
 	})
 
 	t.Run("play non-existent file", func(t *testing.T) {
-		err := playAudioFile("/non/existent/file.mp3")
+		err := playAudioFile(renderer, "/non/existent/file.mp3")
 		assert.Error(t, err)
 	})
 }
@@ -308,3 +376,405 @@ func TestRunSynthesizeComponents(t *testing.T) {
 		}
 	})
 }
+
+func TestCreatePostProcessOptions(t *testing.T) {
+	t.Cleanup(func() {
+		audioFormat = "MP3"
+		normalize = false
+		trimSilence = false
+		fadeIn = 0
+		fadeOut = 0
+	})
+	configured := config.PostProcessConfig{TargetLevel: -20, SilenceThreshold: -50, FadeIn: 100 * time.Millisecond}
+
+	audioFormat = "MP3"
+	_, err := createPostProcessOptions(configured)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.Contains(t, err.Error(), "use --format LINEAR16")
+
+	audioFormat = "LINEAR16"
+	normalize = true
+	fadeOut = 500 * time.Millisecond
+	opts, err := createPostProcessOptions(configured)
+	require.NoError(t, err)
+	assert.True(t, opts.Normalize)
+	assert.False(t, opts.TrimSilence)
+	assert.Equal(t, -20.0, opts.TargetLevel)
+	assert.Equal(t, 100*time.Millisecond, opts.FadeIn)
+	assert.Equal(t, 500*time.Millisecond, opts.FadeOut)
+
+	fadeIn = -time.Second
+	_, err = createPostProcessOptions(configured)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	// Nothing selected leaves every format usable
+	audioFormat = "MP3"
+	normalize = false
+	fadeIn = 0
+	fadeOut = 0
+	opts, err = createPostProcessOptions(config.PostProcessConfig{TargetLevel: -20, SilenceThreshold: -50})
+	require.NoError(t, err)
+	assert.False(t, opts.Enabled())
+}
+
+func TestCreatePostProcessOptions_Music(t *testing.T) {
+	t.Cleanup(func() {
+		audioFormat = "MP3"
+		musicFile = ""
+		musicVolume = ""
+	})
+	dir := t.TempDir()
+	bed := filepath.Join(dir, "bed.wav")
+	require.NoError(t, os.WriteFile(bed, audio.EncodeWAV(&audio.PCM{SampleRate: 8000, Channels: 1, Samples: []int16{1, 2}}), 0600))
+	configured := config.GetDefaults().Output.PostProcess
+	configured.Music = bed
+
+	audioFormat = "LINEAR16"
+	opts, err := createPostProcessOptions(configured)
+	require.NoError(t, err)
+	require.NotNil(t, opts.Music)
+	assert.Equal(t, []int16{1, 2}, opts.Music.Samples)
+	assert.Equal(t, -18.0, opts.MusicVolume)
+	assert.Equal(t, 12.0, opts.MusicDucking)
+
+	musicVolume = "-24 dB"
+	opts, err = createPostProcessOptions(configured)
+	require.NoError(t, err)
+	assert.Equal(t, -24.0, opts.MusicVolume)
+
+	musicVolume = "quiet"
+	_, err = createPostProcessOptions(configured)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	musicVolume = ""
+
+	notWAV := filepath.Join(dir, "bed.mp3")
+	require.NoError(t, os.WriteFile(notWAV, []byte{0xFF, 0xFB, 0, 0}, 0600))
+	musicFile = notWAV
+	_, err = createPostProcessOptions(configured)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+
+	musicFile = filepath.Join(dir, "missing.wav")
+	_, err = createPostProcessOptions(configured)
+	assert.Equal(t, ExitIO, ExitCode(err))
+
+	// Music needs WAV output like the other steps
+	musicFile = ""
+	audioFormat = "MP3"
+	_, err = createPostProcessOptions(configured)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+// fakeFFmpegOnPath puts an ffmpeg script on PATH that ignores its input and
+// writes the file at output to its output file, its last argument
+func fakeFFmpegOnPath(t *testing.T, output string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg binary is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\ncat >/dev/null\ncp '" + output + "' \"$last\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestTranscodedFormats(t *testing.T) {
+	t.Cleanup(func() {
+		audioFormat = "MP3"
+		musicFile = ""
+	})
+	dir := t.TempDir()
+	bed := filepath.Join(dir, "bed.wav")
+	require.NoError(t, os.WriteFile(bed, audio.EncodeWAV(&audio.PCM{SampleRate: 8000, Channels: 1, Samples: []int16{3, 4}}), 0600))
+	notWAV := filepath.Join(dir, "bed.mp3")
+	require.NoError(t, os.WriteFile(notWAV, []byte{0xFF, 0xFB, 0, 0}, 0600))
+
+	assert.NoError(t, checkTranscoder("MP3"))
+	path := os.Getenv("PATH")
+	t.Setenv("PATH", t.TempDir())
+	err := checkTranscoder("flac")
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "--format FLAC needs ffmpeg")
+
+	t.Setenv("PATH", path)
+	fakeFFmpegOnPath(t, bed)
+	assert.NoError(t, checkTranscoder("FLAC"))
+
+	// Transcoded audio is post-processed as WAV first, and music beds in
+	// other formats are decoded with ffmpeg
+	audioFormat = "OPUS"
+	musicFile = notWAV
+	opts, err := createPostProcessOptions(config.GetDefaults().Output.PostProcess)
+	require.NoError(t, err)
+	require.NotNil(t, opts.Music)
+	assert.Equal(t, []int16{3, 4}, opts.Music.Samples)
+}
+
+func TestFilterText(t *testing.T) {
+	filter := func(text string, cfg config.InputConfig, language string) string {
+		t.Helper()
+		filtered, err := filterText(text, cfg, language)
+		require.NoError(t, err)
+		return filtered
+	}
+
+	const text = "Pay the damn $5 by 3/4/2025"
+	cfg := config.GetDefaults().Input
+	assert.Equal(t, text, filter(text, cfg, "en-US"), "disabled by default")
+
+	cfg.Normalize.Enabled = true
+	assert.Equal(t, "Pay the damn five dollars by March fourth, twenty twenty-five", filter(text, cfg, "en-US"))
+	assert.Equal(t, "Pay the damn five dollars by the third of April, twenty twenty-five", filter(text, cfg, "en-GB"))
+
+	cfg.Normalize.Locale = "en-US"
+	cfg.Normalize.Currency = false
+	cfg.ProfanityFilter = "remove"
+	assert.Equal(t, "Pay the $5 by March fourth, twenty twenty-five", filter(text, cfg, "en-GB"))
+
+	cfg.ProfanityFilter = "shout"
+	_, err := filterText(text, cfg, "en-US")
+	assert.Equal(t, ExitValidation, ExitCode(err))
+
+	cfg.ProfanityFilter = "off"
+	cfg.Emoji = "verbalize"
+	assert.Equal(t, "Paid thumbs up emoji by March fourth, twenty twenty-five", filter("Paid 👍🏽 by 3/4/2025", cfg, "en-US"))
+
+	// Dialogue scripts become voice markup after the other filters, so
+	// voice names are left alone
+	cfg.Dialogue = true
+	cfg.Speakers = map[string]string{"ALICE": "en-US-Neural2-F"}
+	script := filter("ALICE: I paid 👍 by 3/4/2025.\nBOB: Thanks!", cfg, "en-US")
+	assert.Equal(t, "@voice: en-US-Neural2-F\nI paid thumbs up emoji by March fourth, twenty twenty-five.\n\n"+
+		"@voice: default\nThanks!", script)
+	assert.Len(t, utils.ParseVoiceMarkup(script), 2)
+}
+
+func TestApplyPauseFlags(t *testing.T) {
+	t.Cleanup(func() {
+		paragraphPause = 0
+		sentencePause = 0
+	})
+	cfg := config.GetDefaults().Input
+	cfg.ParagraphPause = time.Second
+
+	sentencePause = 200 * time.Millisecond
+	got, err := applyPauseFlags(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, got.ParagraphPause)
+	assert.Equal(t, 200*time.Millisecond, got.SentencePause)
+	filtered, err := filterText("One. Two.\n\nThree.", got, "en-US")
+	require.NoError(t, err)
+	assert.Equal(t, `<speak>One.<break time="200ms"/> Two.<break time="1000ms"/>`+"\n\nThree.</speak>", filtered)
+
+	paragraphPause = -time.Second
+	_, err = applyPauseFlags(cfg)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+
+	cfg.SentencePause = 20 * time.Second
+	_, err = filterText("One. Two.", cfg, "en-US")
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+func TestApplyFrontMatter(t *testing.T) {
+	cfg := config.GetDefaults()
+	assert.Same(t, cfg, applyFrontMatter(cfg, utils.FrontMatter{}))
+
+	got := applyFrontMatter(cfg, utils.FrontMatter{Voice: "en-GB-News-K", SpeakingRate: 1.1, Output: "chapter-one.mp3"})
+	assert.Equal(t, "en-GB-News-K", got.TTS.Voice)
+	assert.Equal(t, 1.1, got.TTS.SpeakingRate)
+	assert.Equal(t, cfg.TTS.Pitch, got.TTS.Pitch)
+	assert.Equal(t, "chapter-one.mp3", got.Output.FilenameTemplate)
+	assert.NotEqual(t, "en-GB-News-K", cfg.TTS.Voice, "the configuration itself is unchanged")
+}
+
+func TestResolveOutputFile(t *testing.T) {
+	t.Cleanup(func() {
+		outputFile = defaultOutputFile
+		audioFormat = "MP3"
+	})
+	ttsConfig := &tts.ClientConfig{Voice: "en-US-Wavenet-D", LanguageCode: "en-US"}
+	audioFormat = "MP3"
+
+	tests := []struct {
+		name     string
+		flag     string
+		cfg      config.OutputConfig
+		expected string
+	}{
+		{
+			name:     "explicit output flag",
+			flag:     "custom.wav",
+			cfg:      config.OutputConfig{DefaultPath: "/tmp", FilenameTemplate: "{{.Hash}}.{{.Ext}}"},
+			expected: "custom.wav",
+		},
+		{
+			name:     "default name",
+			flag:     defaultOutputFile,
+			cfg:      config.OutputConfig{DefaultPath: "/tmp"},
+			expected: "/tmp/output.mp3",
+		},
+		{
+			name:     "auto filename",
+			flag:     defaultOutputFile,
+			cfg:      config.OutputConfig{DefaultPath: "/tmp", AutoFilename: true},
+			expected: "/tmp/Hello_there.mp3",
+		},
+		{
+			name: "template overrides auto filename",
+			flag: defaultOutputFile,
+			cfg: config.OutputConfig{DefaultPath: "/tmp", AutoFilename: true,
+				FilenameTemplate: "{{.Language}}/{{.Voice}}.{{.Ext}}"},
+			expected: "/tmp/en-US/en-US-Wavenet-D.mp3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFile = tt.flag
+			path, err := resolveOutputFile("Hello there", ttsConfig, tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tt.expected), path)
+		})
+	}
+
+	t.Run("invalid template", func(t *testing.T) {
+		outputFile = defaultOutputFile
+		_, err := resolveOutputFile("Hello", ttsConfig, config.OutputConfig{FilenameTemplate: "{{.Speaker}}"})
+		require.Error(t, err)
+		assert.Equal(t, ExitValidation, ExitCode(err))
+	})
+}
+
+func TestWriteSynthesisManifest(t *testing.T) {
+	audioPath := filepath.Join(t.TempDir(), "hello.wav")
+	wav := audio.EncodeWAV(&audio.PCM{SampleRate: 8000, Channels: 1, Samples: make([]int16, 4000)})
+	require.NoError(t, os.WriteFile(audioPath, wav, 0644))
+
+	file, err := output.StatFile(audioPath)
+	require.NoError(t, err)
+	req := &tts.SynthesizeRequest{Voice: "en-US-Wavenet-D", LanguageCode: "en-US", SpeakingRate: 1.25,
+		AudioFormat: "LINEAR16", SampleRate: 8000}
+	resp := &tts.SynthesizeResponse{AudioData: wav, Latency: 120 * time.Millisecond, Duration: 500 * time.Millisecond}
+	result := &synthesisResult{Provider: "espeak", Fallback: true, File: file}
+
+	path, err := writeSynthesisManifest("Hello", req, resp, result)
+	require.NoError(t, err)
+	assert.Equal(t, audioPath+".meta.json", path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var manifest output.Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+
+	assert.Equal(t, "espeak", manifest.Provider)
+	assert.True(t, manifest.Fallback)
+	assert.Equal(t, 1.25, manifest.Request.SpeakingRate)
+	assert.Equal(t, 8000, manifest.Request.SampleRate)
+	assert.Equal(t, 5, manifest.Characters)
+	assert.Equal(t, 0.5, manifest.DurationSeconds)
+	assert.Equal(t, int64(120), manifest.LatencyMillis)
+	assert.Equal(t, file.Size, manifest.File.Size)
+}
+
+func TestWriteSpeechMarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "marks.json")
+	resp := &tts.SynthesizeResponse{Format: "MP3", Duration: time.Second}
+
+	// Voice markup lines are not spoken, so they get no marks
+	written, err := writeSpeechMarks(path, "@voice: en-GB-News-K\nHi there", resp)
+	require.NoError(t, err)
+	assert.Equal(t, path, written)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var marks output.SpeechMarks
+	require.NoError(t, json.Unmarshal(data, &marks))
+	assert.Equal(t, output.MarksSourceEstimated, marks.Source)
+	require.Len(t, marks.Marks, 2)
+	assert.Equal(t, "Hi", marks.Marks[0].Value)
+	assert.Equal(t, "there", marks.Marks[1].Value)
+
+	resp.Duration = 0
+	_, err = writeSpeechMarks(path, "Hi", resp)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "cannot be read from MP3 output")
+}
+
+func TestSpeechMarks_Timepoints(t *testing.T) {
+	resp := &tts.SynthesizeResponse{
+		Duration:   2 * time.Second,
+		Timepoints: []tts.Timepoint{{Mark: "end", Time: 1200 * time.Millisecond}},
+	}
+	marks := speechMarks(`<speak>Hi there<mark name="end"/></speak>`, resp)
+	assert.Equal(t, output.MarksSourceTimepoints, marks.Source)
+	require.Len(t, marks.Marks, 3)
+	assert.Equal(t, "end", marks.Marks[2].Value)
+	assert.Equal(t, int64(1200), marks.Marks[2].TimeMillis)
+}
+
+func TestCheckCustomVoice(t *testing.T) {
+	const model = "projects/voice-project/locations/us-central1/models/narrator"
+	var out bytes.Buffer
+	cmd := NewSynthesizeCmd()
+	cmd.SetOut(&out)
+	renderer := newRenderer(cmd)
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+
+	assert.NoError(t, checkCustomVoice(renderer, "", tts.ProviderEspeak, config.AuthConfig{}))
+	assert.NoError(t, checkCustomVoice(renderer, model, tts.ProviderGoogle, config.AuthConfig{}))
+	assert.Empty(t, out.String())
+
+	err := checkCustomVoice(renderer, "narrator", tts.ProviderGoogle, config.AuthConfig{})
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	err = checkCustomVoice(renderer, model, tts.ProviderEspeak, config.AuthConfig{})
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorIs(t, err, tts.ErrCustomVoiceUnsupported)
+
+	// A model of another project works only if it was shared, so it is a
+	// warning
+	keyFile := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(keyFile, []byte(`{"type": "service_account", "project_id": "my-project",
+		"private_key": "key", "client_email": "tts@my-project.iam.gserviceaccount.com", "client_id": "1"}`), 0600))
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", keyFile)
+	require.NoError(t, checkCustomVoice(renderer, model, tts.ProviderGoogle, config.AuthConfig{ServiceAccountFile: keyFile}))
+	assert.Contains(t, out.String(), "Warning: custom voice model "+model+" belongs to project voice-project")
+}
+
+func TestCreateTTSConfig_LanguageVoices(t *testing.T) {
+	voice, languageCode = "", "en-US"
+	t.Cleanup(func() { voice, languageCode = "", "en-US" })
+
+	cfg := config.GetDefaults().TTS
+	cfg.LanguageVoices = map[string]string{"en-us": "Neural2-F", "es-es": "Neural2-A", "de": "de-DE-Neural2-B"}
+
+	ttsConfig := createTTSConfig(cfg)
+	assert.Equal(t, "en-US-Neural2-F", ttsConfig.Voice, "the configured language uses its favorite voice")
+
+	languageCode = "es-ES"
+	ttsConfig = createTTSConfig(cfg)
+	assert.Equal(t, "es-ES-Neural2-A", ttsConfig.Voice)
+	assert.Equal(t, "es-ES", ttsConfig.LanguageCode)
+
+	languageCode = "de-AT"
+	ttsConfig = createTTSConfig(cfg)
+	assert.Equal(t, "de-DE-Neural2-B", ttsConfig.Voice)
+	assert.Equal(t, "de-DE", ttsConfig.LanguageCode, "the language follows the voice")
+
+	languageCode = "fr-FR"
+	ttsConfig = createTTSConfig(cfg)
+	assert.Empty(t, ttsConfig.Voice, "languages without a favorite are left to the API")
+
+	voice = "es-ES-Wavenet-B"
+	languageCode = "es-ES"
+	assert.Equal(t, "es-ES-Wavenet-B", createTTSConfig(cfg).Voice, "--voice wins")
+
+	voice, languageCode = "", "en-US"
+	configured := cfg
+	configured.Voice = "en-US-Studio-O"
+	assert.Equal(t, "en-US-Studio-O", createTTSConfig(configured).Voice, "tts.voice wins for its language")
+
+	espeak := cfg
+	espeak.Provider = "espeak"
+	assert.Empty(t, createTTSConfig(espeak).Voice, "espeak has no Google voices")
+}
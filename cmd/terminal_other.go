@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package cmd
+
+import (
+	"errors"
+	"os"
+)
+
+// enableKeyInput is unsupported on this platform, so playback runs without
+// keyboard controls
+func enableKeyInput(_ *os.File) (func(), error) {
+	return nil, errors.New("keyboard controls are not supported on this platform")
+}
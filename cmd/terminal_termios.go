@@ -0,0 +1,31 @@
+//go:build linux || darwin
+
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// enableKeyInput switches the terminal to deliver key presses immediately
+// without echoing them, with reads returning after 100ms when no key was
+// pressed. Signal keys such as Ctrl-C keep working. The returned function
+// restores the previous settings.
+func enableKeyInput(terminal *os.File) (func(), error) {
+	fd := int(terminal.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *saved
+	raw.Lflag &^= unix.ICANON | unix.ECHO
+	raw.Cc[unix.VMIN] = 0
+	raw.Cc[unix.VTIME] = 1
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() { _ = unix.IoctlSetTermios(fd, ioctlSetTermios, saved) }, nil
+}
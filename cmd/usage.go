@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/stats"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+var usageMonth string
+
+// NewUsageCmd creates the usage command
+func NewUsageCmd() *cobra.Command {
+	usageCmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Summarize synthesized characters and estimated costs",
+		Long: `Summarize the characters synthesized in a month and their estimated cost per
+voice tier, read from the usage ledger in ~/.assistant-cli/usage.jsonl.
+
+Every command that synthesizes speech appends what it synthesized with each
+voice to the ledger. Costs are estimated from the Google Cloud list price of
+each voice tier, without the monthly free allowance; local providers are free.
+
+Set app.monthly_budget_usd to be warned once the estimated spend of the month
+reaches app.budget_warning_percent of the budget. Set
+app.monthly_character_budget to refuse Google Cloud synthesis that would take
+the month past that many characters, unless the command is given --force.
+
+Examples:
+  assistant-cli usage
+  assistant-cli usage --month 2024-06
+  assistant-cli --output-format json usage`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runUsage,
+	}
+
+	usageCmd.Flags().StringVar(&usageMonth, "month", "", "Month to summarize as YYYY-MM (default: current month)")
+
+	return usageCmd
+}
+
+// usageResult is the machine-readable result of usage
+type usageResult struct {
+	File            string                 `json:"file"`
+	Usage           *stats.Usage           `json:"usage"`
+	Budget          *budgetStatus          `json:"budget,omitempty"`
+	CharacterBudget *characterBudgetStatus `json:"character_budget,omitempty"`
+}
+
+// budgetStatus compares the estimated spend of a month with
+// app.monthly_budget_usd
+type budgetStatus struct {
+	LimitUSD       float64 `json:"limit_usd"`
+	UsedPercent    float64 `json:"used_percent"`
+	WarningPercent int     `json:"warning_percent"`
+	Warning        string  `json:"warning,omitempty"`
+}
+
+// characterBudgetStatus compares the Google Cloud characters of a month with
+// app.monthly_character_budget
+type characterBudgetStatus struct {
+	Limit int `json:"limit"`
+	Used  int `json:"used"`
+}
+
+func runUsage(cmd *cobra.Command, _ []string) error {
+	month := stats.MonthOf(time.Now())
+	if usageMonth != "" {
+		var err error
+		if month, err = stats.ParseMonth(usageMonth); err != nil {
+			return usageError(err)
+		}
+	}
+
+	path, err := stats.DefaultLedgerPath()
+	if err != nil {
+		return ioError(err)
+	}
+	entries, err := stats.ReadLedger(path, month)
+	if err != nil {
+		return ioError(err)
+	}
+
+	usage := stats.Summarize(month, entries)
+	result := &usageResult{File: path, Usage: usage, Budget: monthlyBudget(usage.EstimatedCost)}
+	if limit := GetConfig().Get().App.MonthlyCharacterBudget; limit > 0 {
+		result.CharacterBudget = &characterBudgetStatus{Limit: limit}
+		for _, entry := range entries {
+			if entry.Provider == tts.ProviderGoogle {
+				result.CharacterBudget.Used += entry.Characters
+			}
+		}
+	}
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		printUsage(w, result)
+	})
+}
+
+// printUsage writes the usage table of a month
+func printUsage(w io.Writer, result *usageResult) {
+	usage := result.Usage
+	if len(usage.Tiers) == 0 {
+		fmt.Fprintf(w, "No synthesis recorded in %s.\n", usage.Month)
+		return
+	}
+
+	title := "Usage for " + usage.Month
+	fmt.Fprintf(w, "%s\n%s\n", title, strings.Repeat("=", len(title)))
+	fmt.Fprintf(w, "%-10s %10s %14s %12s\n", "Tier", "API Calls", "Characters", "Est. Cost")
+	for _, tier := range usage.Tiers {
+		fmt.Fprintf(w, "%-10s %10d %14d %12s\n", tier.Tier, tier.Requests, tier.Characters,
+			fmt.Sprintf("$%.2f", tier.EstimatedCost))
+	}
+	fmt.Fprintf(w, "%-10s %10d %14d %12s\n", "Total", usage.Requests, usage.Characters,
+		fmt.Sprintf("$%.2f", usage.EstimatedCost))
+
+	if budget := result.Budget; budget != nil {
+		fmt.Fprintf(w, "\nBudget: $%.2f of $%.2f (%.0f%%)\n", usage.EstimatedCost, budget.LimitUSD, budget.UsedPercent)
+		if budget.Warning != "" {
+			fmt.Fprintf(w, "Warning: %s\n", budget.Warning)
+		}
+	}
+	if budget := result.CharacterBudget; budget != nil {
+		fmt.Fprintf(w, "\nCharacter budget: %d of %d Google Cloud characters (%.0f%%)\n", budget.Used, budget.Limit,
+			float64(budget.Used)/float64(budget.Limit)*100)
+	}
+}
+
+// monthlyBudget compares spent with app.monthly_budget_usd, or returns nil
+// when no budget is set
+func monthlyBudget(spent float64) *budgetStatus {
+	app := GetConfig().Get().App
+	if app.MonthlyBudgetUSD <= 0 {
+		return nil
+	}
+
+	budget := &budgetStatus{
+		LimitUSD:       app.MonthlyBudgetUSD,
+		UsedPercent:    spent / app.MonthlyBudgetUSD * 100,
+		WarningPercent: app.BudgetWarningPercent,
+	}
+	switch {
+	case spent >= budget.LimitUSD:
+		budget.Warning = fmt.Sprintf("estimated spend of $%.2f this month exceeds the $%.2f budget "+
+			"(app.monthly_budget_usd)", spent, budget.LimitUSD)
+	case budget.UsedPercent >= float64(budget.WarningPercent):
+		budget.Warning = fmt.Sprintf("estimated spend of $%.2f this month has reached %.0f%% of the $%.2f budget "+
+			"(app.monthly_budget_usd)", spent, budget.UsedPercent, budget.LimitUSD)
+	}
+	return budget
+}
+
+// warnMonthlyBudget prints the budget warning for month, if any
+func warnMonthlyBudget(renderer *Renderer, ledger, month string) {
+	if GetConfig().Get().App.MonthlyBudgetUSD <= 0 {
+		return
+	}
+	entries, err := stats.ReadLedger(ledger, month)
+	if err != nil {
+		renderer.Warnf("Warning: failed to check the monthly budget: %v\n", err)
+		return
+	}
+	if budget := monthlyBudget(stats.Summarize(month, entries).EstimatedCost); budget.Warning != "" {
+		renderer.Warnf("Warning: %s\n", budget.Warning)
+	}
+}
+
+// characterBudget tracks the Google Cloud characters of the month against
+// app.monthly_character_budget while a command runs
+type characterBudget struct {
+	// loaded is set once the ledger was read, which only happens when a
+	// budget is configured
+	loaded bool
+	// recorded counts the characters in the ledger before this run
+	recorded int
+	// inflight counts the characters of requests still being synthesized
+	inflight int
+	warned   bool
+}
+
+// release stops counting a finished request as in flight
+func (b *characterBudget) release(provider string, characters int) {
+	if provider == tts.ProviderGoogle && b.loaded {
+		b.inflight -= characters
+	}
+}
+
+// reserve checks a request of characters against app.monthly_character_budget
+// before it is sent, counting the ledger, this run, and the requests in
+// flight. Only Google Cloud requests are billed and limited. With --force,
+// going over the budget warns once instead of failing.
+func (r *runRecorder) reserve(provider string, characters int) error {
+	limit := GetConfig().Get().App.MonthlyCharacterBudget
+	if provider != tts.ProviderGoogle || limit <= 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	month := stats.MonthOf(r.start)
+	if !r.budget.loaded {
+		recorded, err := ledgerCharacters(month, tts.ProviderGoogle)
+		if err != nil {
+			return ioError(fmt.Errorf("failed to check the monthly character budget: %w", err))
+		}
+		r.budget.recorded, r.budget.loaded = recorded, true
+	}
+
+	used := r.budget.recorded + r.budget.inflight
+	for _, entry := range r.usage {
+		if entry.Provider == tts.ProviderGoogle {
+			used += entry.Characters
+		}
+	}
+	if used+characters > limit {
+		message := fmt.Sprintf("synthesizing %d more characters would exceed the monthly character budget: "+
+			"%d of %d used in %s (app.monthly_character_budget)", characters, used, limit, month)
+		if !r.ignoreBudget {
+			return newExitError(ExitQuota, errors.New(message+"; use --ignore-budget to synthesize anyway"))
+		}
+		if !r.budget.warned {
+			r.budget.warned = true
+			r.renderer.Warnf("Warning: %s\n", message)
+		}
+	}
+	r.budget.inflight += characters
+	return nil
+}
+
+// release stops counting a failed request against the budget
+func (r *runRecorder) release(provider string, characters int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budget.release(provider, characters)
+}
+
+// ledgerCharacters adds up the characters a provider synthesized in month
+func ledgerCharacters(month, provider string) (int, error) {
+	path, err := stats.DefaultLedgerPath()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := stats.ReadLedger(path, month)
+	if err != nil {
+		return 0, err
+	}
+
+	characters := 0
+	for _, entry := range entries {
+		if entry.Provider == provider {
+			characters += entry.Characters
+		}
+	}
+	return characters, nil
+}
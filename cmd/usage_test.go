@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/stats"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runUsageCommand(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		usageMonth = ""
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs(append([]string{"usage"}, args...))
+	err := rootCmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestUsageCommand(t *testing.T) {
+	fakeEspeakOnPath(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	config := writeTestConfig(t, "tts:\n  provider: \"espeak\"\n")
+
+	stdout, _, err := runUsageCommand(t, "--config", config)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No synthesis recorded in "+stats.MonthOf(time.Now()))
+
+	_, err = runAudiobookCommand(t, writeTestEPUB(t), "--config", config, "--format", "LINEAR16", "-o", t.TempDir())
+	require.NoError(t, err)
+
+	stdout, _, err = runUsageCommand(t, "--config", config, "--output-format", "json")
+	require.NoError(t, err)
+	var result struct {
+		Data usageResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, filepath.Join(home, ".assistant-cli", "usage.jsonl"), result.Data.File)
+	usage := result.Data.Usage
+	require.NotNil(t, usage)
+	assert.Equal(t, 2, usage.Requests)
+	require.Len(t, usage.Tiers, 1)
+	assert.Equal(t, stats.TierLocal, usage.Tiers[0].Tier)
+	assert.Nil(t, result.Data.Budget)
+
+	stdout, _, err = runUsageCommand(t, "--config", config, "--month", "2024-06")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No synthesis recorded in 2024-06")
+}
+
+func TestUsageCommand_Budget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	config := writeTestConfig(t, "app:\n  monthly_budget_usd: 1.0\n  budget_warning_percent: 50\n")
+
+	require.NoError(t, stats.AppendLedger(filepath.Join(home, ".assistant-cli", "usage.jsonl"), []*stats.Entry{
+		{Time: time.Date(2024, 6, 3, 10, 0, 0, 0, time.Local), Provider: "google", Voice: "en-US-Studio-O",
+			Tier: stats.TierStudio, Requests: 3, Characters: 5000, EstimatedCost: 0.8},
+	}))
+
+	stdout, _, err := runUsageCommand(t, "--config", config, "--month", "2024-06")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Usage for 2024-06")
+	assert.Contains(t, stdout, "Studio")
+	assert.Contains(t, stdout, "Budget: $0.80 of $1.00 (80%)")
+	assert.Contains(t, stdout, "has reached 80% of the $1.00 budget")
+}
+
+func TestUsageCommand_InvalidMonth(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, _, err := runUsageCommand(t, "--month", "June")
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+	assert.Contains(t, err.Error(), "expected YYYY-MM")
+}
+
+func TestMonthlyBudget(t *testing.T) {
+	useGlobalConfig(t, writeTestConfig(t, "app:\n  monthly_budget_usd: 10\n"))
+
+	budget := monthlyBudget(5)
+	require.NotNil(t, budget)
+	assert.Equal(t, 80, budget.WarningPercent)
+	assert.InDelta(t, 50, budget.UsedPercent, 1e-9)
+	assert.Empty(t, budget.Warning)
+
+	assert.Contains(t, monthlyBudget(8).Warning, "has reached 80% of the $10.00 budget")
+	assert.Contains(t, monthlyBudget(12.5).Warning, "estimated spend of $12.50 this month exceeds the $10.00 budget")
+
+	useGlobalConfig(t, writeTestConfig(t, "tts:\n  provider: \"espeak\"\n"))
+	assert.Nil(t, monthlyBudget(100))
+}
+
+func TestRunRecorder_CharacterBudget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	useGlobalConfig(t, writeTestConfig(t, "app:\n  monthly_character_budget: 1000\n"))
+
+	start := time.Now()
+	require.NoError(t, stats.AppendLedger(filepath.Join(home, ".assistant-cli", "usage.jsonl"), []*stats.Entry{
+		{Time: start, Provider: tts.ProviderGoogle, Tier: stats.TierStandard, Requests: 1, Characters: 600},
+		{Time: start, Provider: tts.ProviderEspeak, Tier: stats.TierLocal, Requests: 1, Characters: 5000},
+		{Time: start.AddDate(0, -1, 0), Provider: tts.ProviderGoogle, Tier: stats.TierStandard, Requests: 1,
+			Characters: 5000},
+	}))
+
+	recorder := &runRecorder{command: "synthesize", start: start, renderer: newRenderer(&cobra.Command{})}
+	require.NoError(t, recorder.reserve(tts.ProviderGoogle, 300))
+	// The request in flight counts against the budget
+	err := recorder.reserve(tts.ProviderGoogle, 200)
+	require.Error(t, err)
+	assert.Equal(t, ExitQuota, ExitCode(err))
+	assert.Contains(t, err.Error(), "900 of 1000 used")
+	assert.Contains(t, err.Error(), "use --ignore-budget")
+
+	recorder.add(tts.ProviderGoogle, "en-US-Standard-A", 300)
+	require.NoError(t, recorder.reserve(tts.ProviderGoogle, 100))
+	recorder.release(tts.ProviderGoogle, 100)
+	require.Error(t, recorder.reserve(tts.ProviderGoogle, 101))
+
+	// Local providers are not limited
+	assert.NoError(t, recorder.reserve(tts.ProviderEspeak, 10000))
+
+	recorder.ignoreBudget = true
+	assert.NoError(t, recorder.reserve(tts.ProviderGoogle, 500))
+}
+
+func TestStartRunStats_IgnoreBudget(t *testing.T) {
+	t.Cleanup(func() {
+		ignoreBudget = false
+		currentRun = nil
+	})
+
+	// --force of a command overwrites files; only --ignore-budget passes the budget
+	root := NewRootCmd()
+	compare, _, err := root.Find([]string{"compare"})
+	require.NoError(t, err)
+	require.NoError(t, compare.ParseFlags([]string{"--force"}))
+	startRunStats(compare)
+	assert.False(t, currentRun.ignoreBudget)
+
+	require.NoError(t, compare.ParseFlags([]string{"--ignore-budget"}))
+	startRunStats(compare)
+	assert.True(t, currentRun.ignoreBudget)
+}
+
+func TestUsageCommand_CharacterBudget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	config := writeTestConfig(t, "app:\n  monthly_character_budget: 10000\n")
+
+	require.NoError(t, stats.AppendLedger(filepath.Join(home, ".assistant-cli", "usage.jsonl"), []*stats.Entry{
+		{Time: time.Date(2024, 6, 3, 10, 0, 0, 0, time.Local), Provider: tts.ProviderGoogle, Tier: stats.TierStandard,
+			Requests: 1, Characters: 2500},
+	}))
+
+	stdout, _, err := runUsageCommand(t, "--config", config, "--month", "2024-06")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Character budget: 2500 of 10000 Google Cloud characters (25%)")
+}
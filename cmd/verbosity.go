@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+)
+
+// verbosityLevel controls how much status output commands print
+type verbosityLevel int
+
+const (
+	// verbosityQuiet prints results, warnings, and errors only
+	verbosityQuiet verbosityLevel = iota
+	verbosityNormal
+	// verbosityVerbose adds details and debug logging
+	verbosityVerbose
+)
+
+var (
+	quietFlag   bool
+	verboseFlag bool
+	verbosity   = verbosityNormal
+)
+
+// applyVerbosity sets the verbosity from --quiet and --verbose, or else from
+// app.quiet and app.verbose, and configures logging to match
+func applyVerbosity(cfg *config.Config) error {
+	if quietFlag && verboseFlag {
+		return usageError(fmt.Errorf("--quiet and --verbose cannot be used together"))
+	}
+
+	switch {
+	case quietFlag:
+		verbosity = verbosityQuiet
+	case verboseFlag:
+		verbosity = verbosityVerbose
+	case cfg.App.Quiet:
+		verbosity = verbosityQuiet
+	case cfg.App.Verbose:
+		verbosity = verbosityVerbose
+	default:
+		verbosity = verbosityNormal
+	}
+
+	return setupLogging(cfg.Logging)
+}
+
+// statusf writes a progress or status message, unless quiet
+func statusf(w io.Writer, format string, args ...interface{}) {
+	if verbosity > verbosityQuiet {
+		fmt.Fprintf(w, format, args...)
+	}
+}
+
+// detailf writes a message only when verbose
+func detailf(w io.Writer, format string, args ...interface{}) {
+	if verbosity >= verbosityVerbose {
+		fmt.Fprintf(w, format, args...)
+	}
+}
+
+// logLevel returns the log level of logging.level, lowered to debug when
+// verbose and raised to error when quiet
+func logLevel(level string) slog.Level {
+	switch verbosity {
+	case verbosityQuiet:
+		return slog.LevelError
+	case verbosityVerbose:
+		return slog.LevelDebug
+	}
+
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// setupLogging makes the default slog logger write at the level and in the
+// format and destination of the logging settings
+func setupLogging(cfg config.LoggingConfig) error {
+	var w io.Writer
+	switch cfg.Output {
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		// The log file stays open for the rest of the process
+		f, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return ioError(fmt.Errorf("failed to open log file: %w", err))
+		}
+		w = f
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel(cfg.Level), AddSource: cfg.Caller}
+	if !cfg.Timestamps {
+		options.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		}
+	}
+
+	var handler slog.Handler = slog.NewTextHandler(w, options)
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetVerbosity(t *testing.T) {
+	t.Helper()
+	logger := slog.Default()
+	t.Cleanup(func() {
+		quietFlag, verboseFlag, verbosity = false, false, verbosityNormal
+		slog.SetDefault(logger)
+	})
+}
+
+func TestApplyVerbosity(t *testing.T) {
+	tests := []struct {
+		name           string
+		quiet, verbose bool
+		appQuiet       bool
+		appVerbose     bool
+		want           verbosityLevel
+	}{
+		{"default", false, false, false, false, verbosityNormal},
+		{"quiet flag", true, false, false, false, verbosityQuiet},
+		{"verbose flag", false, true, false, false, verbosityVerbose},
+		{"app.quiet", false, false, true, false, verbosityQuiet},
+		{"app.verbose", false, false, false, true, verbosityVerbose},
+		{"flag overrides config", false, true, true, false, verbosityVerbose},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetVerbosity(t)
+			quietFlag, verboseFlag = tt.quiet, tt.verbose
+			cfg := config.GetDefaults()
+			cfg.App.Quiet, cfg.App.Verbose = tt.appQuiet, tt.appVerbose
+
+			require.NoError(t, applyVerbosity(cfg))
+			assert.Equal(t, tt.want, verbosity)
+		})
+	}
+}
+
+func TestApplyVerbosity_Conflict(t *testing.T) {
+	resetVerbosity(t)
+	quietFlag, verboseFlag = true, true
+
+	err := applyVerbosity(config.GetDefaults())
+	require.Error(t, err)
+	assert.Equal(t, ExitUsage, ExitCode(err))
+}
+
+func TestLogLevel(t *testing.T) {
+	resetVerbosity(t)
+	assert.Equal(t, slog.LevelWarn, logLevel("warn"))
+	assert.Equal(t, slog.LevelInfo, logLevel(""))
+
+	verbosity = verbosityVerbose
+	assert.Equal(t, slog.LevelDebug, logLevel("error"))
+	verbosity = verbosityQuiet
+	assert.Equal(t, slog.LevelError, logLevel("debug"))
+}
+
+func TestSetupLogging_File(t *testing.T) {
+	resetVerbosity(t)
+	path := filepath.Join(t.TempDir(), "cli.log")
+	cfg := config.GetDefaults().Logging
+	cfg.Output, cfg.Format, cfg.Timestamps = path, "json", false
+	verbosity = verbosityVerbose
+
+	require.NoError(t, setupLogging(cfg))
+	slog.Debug("hello", "key", "value")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"level":"DEBUG","msg":"hello","key":"value"}`, string(data))
+}
+
+func TestStatusOutput(t *testing.T) {
+	resetVerbosity(t)
+	buf := new(bytes.Buffer)
+
+	statusf(buf, "status\n")
+	detailf(buf, "detail\n")
+	assert.Equal(t, "status\n", buf.String())
+
+	buf.Reset()
+	verbosity = verbosityVerbose
+	statusf(buf, "status\n")
+	detailf(buf, "detail\n")
+	assert.Equal(t, "status\ndetail\n", buf.String())
+
+	buf.Reset()
+	verbosity = verbosityQuiet
+	statusf(buf, "status\n")
+	detailf(buf, "detail\n")
+	assert.Empty(t, buf.String())
+}
+
+func TestQuietFlag_ConfigSet(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTestConfig(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+
+	stdout, err := runConfigKeyCommand(t, "set", "tts.voice", "en-US-Wavenet-A", "--config", path, "--quiet")
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+
+	stdout, err = runConfigKeyCommand(t, "get", "tts.voice", "--config", path, "-q")
+	require.NoError(t, err)
+	assert.Equal(t, "en-US-Wavenet-A\n", stdout, "results are still printed when quiet")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+)
+
+// voiceRequest returns a copy of req read by voice, in the language its name
+// starts with
+func voiceRequest(req *tts.SynthesizeRequest, voice string) *tts.SynthesizeRequest {
+	voice = resolveVoice(voice)
+	switched := *req
+	switched.Voice = voice
+	if language := tts.VoiceLanguage(voice); language != "" {
+		switched.LanguageCode = language
+	}
+	return &switched
+}
+
+// checkMarkupVoices validates the voices named in the voice markup of text
+// against the voice cache, as for the configured voice
+func checkMarkupVoices(text string, cacheTTL time.Duration) error {
+	for _, segment := range utils.ParseVoiceMarkup(text) {
+		if segment.Voice == "" {
+			continue
+		}
+		voice := resolveVoice(segment.Voice)
+		if err := validateVoiceOffline(voice, tts.VoiceLanguage(voice), cacheTTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withVoiceMarkup returns provider wrapped to synthesize the voice markup of
+// text, if it has any
+func withVoiceMarkup(provider tts.Provider, text string) tts.Provider {
+	if !utils.HasVoiceMarkup(text) {
+		return provider
+	}
+	return &voiceMarkupProvider{provider}
+}
+
+// voiceMarkupProvider synthesizes text with voice markup one segment at a
+// time, each read by its voice, and joins the audio
+type voiceMarkupProvider struct {
+	tts.Provider
+}
+
+func (p *voiceMarkupProvider) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audioConfig *texttospeechpb.AudioConfig) ([]byte, error) {
+	segments := utils.ParseVoiceMarkup(text)
+	if len(segments) == 1 && segments[0].Voice == "" {
+		return p.Provider.Synthesize(ctx, segments[0].Text, voice, audioConfig)
+	}
+
+	parts := make([][]byte, 0, len(segments))
+	for i, segment := range segments {
+		segmentVoice := voice
+		if segment.Voice != "" {
+			name := resolveVoice(segment.Voice)
+			segmentVoice = &texttospeechpb.VoiceSelectionParams{
+				Name:         name,
+				LanguageCode: tts.VoiceLanguage(name),
+			}
+		}
+		data, err := p.Provider.Synthesize(ctx, segment.Text, segmentVoice, audioConfig)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d of %d (%s): %w", i+1, len(segments), segmentVoice.GetName(), err)
+		}
+		parts = append(parts, data)
+	}
+
+	joined, err := audio.Concat(parts, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join voices: %w", err)
+	}
+	return joined, nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// voiceRecordingProvider returns a short WAV file for every request and
+// records the voice of each
+type voiceRecordingProvider struct {
+	echoTTSClient
+	voices []string
+	texts  []string
+}
+
+func (p *voiceRecordingProvider) Synthesize(ctx context.Context, text string,
+	voice *texttospeechpb.VoiceSelectionParams, audioConfig *texttospeechpb.AudioConfig) ([]byte, error) {
+	p.voices = append(p.voices, voice.GetName()+"/"+voice.GetLanguageCode())
+	p.texts = append(p.texts, text)
+	return audio.EncodeWAV(&audio.PCM{SampleRate: 8000, Channels: 1, Samples: make([]int16, 800)}), nil
+}
+
+func (p *voiceRecordingProvider) Name() string {
+	return tts.ProviderGoogle
+}
+
+func TestVoiceMarkupProvider(t *testing.T) {
+	provider := &voiceRecordingProvider{}
+	assert.Same(t, tts.Provider(provider), withVoiceMarkup(provider, "No markup here"))
+
+	text := "Hello.\n@voice: en-GB-News-K\nGood evening.\n@voice: default\nBye."
+	data, err := withVoiceMarkup(provider, text).Synthesize(context.Background(), text,
+		&texttospeechpb.VoiceSelectionParams{Name: "en-US-Wavenet-D", LanguageCode: "en-US"}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Hello.", "Good evening.", "Bye."}, provider.texts)
+	assert.Equal(t, []string{"en-US-Wavenet-D/en-US", "en-GB-News-K/en-GB", "en-US-Wavenet-D/en-US"},
+		provider.voices)
+	pcm, err := audio.DecodeWAV(data)
+	require.NoError(t, err)
+	assert.Len(t, pcm.Samples, 3*800)
+}
+
+func TestLongTextPiecesVoiceMarkup(t *testing.T) {
+	req := &tts.SynthesizeRequest{Voice: "en-US-Wavenet-D", LanguageCode: "en-US"}
+	pieces, err := longTextPieces("Narrator.\n\n[[voice=de-DE-Neural2-B]]Hallo![[/voice]] Done.", req, "")
+	require.NoError(t, err)
+
+	require.Len(t, pieces, 3)
+	assert.Same(t, req, pieces[0].Request)
+	assert.Equal(t, "Hallo!", pieces[1].Text)
+	assert.Equal(t, "de-DE-Neural2-B", pieces[1].Request.Voice)
+	assert.Equal(t, "de-DE", pieces[1].Request.LanguageCode)
+	assert.Equal(t, "Done.", pieces[2].Text)
+	assert.Same(t, req, pieces[2].Request)
+}
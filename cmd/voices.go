@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils/suggest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	voicesLanguage string
+	voicesRefresh  bool
+)
+
+// NewVoicesCmd creates the voices command
+func NewVoicesCmd() *cobra.Command {
+	voicesCmd := &cobra.Command{
+		Use:   "voices",
+		Short: "List available Text-to-Speech voices",
+		Long: `List the voices available from the configured TTS provider.
+
+Google Cloud voices are cached on disk (~/.assistant-cli/cache/voices.json) so later
+invocations return instantly and work offline. Cached entries expire after
+tts.voice_cache_ttl; use --refresh to reload the list from the API. Voices of local
+providers such as espeak are always listed directly.
+
+Examples:
+  assistant-cli voices
+  assistant-cli voices --language en-GB
+  assistant-cli voices --refresh
+  assistant-cli voices alias add sarah en-US-Neural2-F`,
+		RunE: runVoices,
+	}
+
+	voicesCmd.Flags().StringVarP(&voicesLanguage, "language", "l", "",
+		"Only list voices for this language code (e.g., en-US)")
+	voicesCmd.Flags().BoolVar(&voicesRefresh, "refresh", false, "Ignore the cache and reload voices from the API")
+	voicesCmd.AddCommand(newVoicesAliasCmd())
+
+	return voicesCmd
+}
+
+// voiceListResult is the machine-readable form of a voice listing
+type voiceListResult struct {
+	Provider  string      `json:"provider"`
+	Language  string      `json:"language,omitempty"`
+	Voices    []voiceInfo `json:"voices"`
+	FetchedAt time.Time   `json:"fetched_at"`
+	Cached    bool        `json:"cached"`
+	Stale     bool        `json:"stale"`
+}
+
+func runVoices(cmd *cobra.Command, args []string) error {
+	cfg := GetConfig().Get()
+	return handleListVoices(context.Background(), cfg, voicesLanguage, voicesRefresh, newRenderer(cmd))
+}
+
+// handleListVoices lists voices through the persistent voice cache. The API is
+// only contacted, and authentication only set up, when the cache cannot answer.
+func handleListVoices(ctx context.Context, cfg *config.Config, lang string, refresh bool,
+	renderer *Renderer) error {
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		return validationError(err)
+	}
+
+	listing, err := fetchVoiceListing(ctx, cfg, providerName, lang, refresh)
+	if err != nil {
+		return err
+	}
+
+	result := &voiceListResult{
+		Provider:  providerName,
+		Language:  lang,
+		Voices:    make([]voiceInfo, 0, len(listing.Voices)),
+		FetchedAt: listing.FetchedAt,
+		Cached:    listing.FromCache,
+		Stale:     listing.Stale,
+	}
+	for _, voice := range listing.Voices {
+		result.Voices = append(result.Voices, voiceInfo{
+			Name:                   voice.Name,
+			Gender:                 voiceGenderName(int32(voice.SsmlGender)),
+			LanguageCodes:          voice.LanguageCodes,
+			NaturalSampleRateHertz: voice.NaturalSampleRateHertz,
+		})
+	}
+
+	if listing.Stale {
+		renderer.Warnf("Warning: could not reach the API; showing cached voices from %s\n",
+			listing.FetchedAt.Format(time.RFC3339))
+	}
+
+	return renderer.Result(result, func(w io.Writer) {
+		if lang == "" {
+			fmt.Fprintf(w, "Available voices:\n\n")
+		} else {
+			fmt.Fprintf(w, "Available voices for language '%s':\n\n", lang)
+		}
+
+		for _, info := range result.Voices {
+			fmt.Fprintf(w, "  %s\n", info.Name)
+			fmt.Fprintf(w, "    Gender: %s\n", info.Gender)
+			fmt.Fprintf(w, "    Languages: %v\n", info.LanguageCodes)
+			fmt.Fprintf(w, "    Sample Rate: %d Hz\n\n", info.NaturalSampleRateHertz)
+		}
+	})
+}
+
+// fetchVoiceListing lists voices from the configured provider. Google voices go
+// through the persistent cache; local providers are queried directly.
+func fetchVoiceListing(ctx context.Context, cfg *config.Config, providerName, lang string,
+	refresh bool) (*tts.VoiceListing, error) {
+	if providerName != tts.ProviderGoogle {
+		provider, err := createProvider(ctx, providerName, cfg.Auth, createTTSConfig(cfg.TTS))
+		if err != nil {
+			return nil, err
+		}
+		defer provider.Close()
+
+		voices, err := provider.ListVoices(ctx, lang)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list voices: %w", err)
+		}
+		return &tts.VoiceListing{Voices: voices, FetchedAt: time.Now()}, nil
+	}
+
+	client := &lazyVoiceClient{cfg: cfg}
+	defer client.Close()
+
+	cache, err := newPersistentVoiceCache(client, cfg.TTS)
+	if err != nil {
+		return nil, err
+	}
+
+	listing, err := cache.GetVoices(ctx, lang, refresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+	return listing, nil
+}
+
+// newPersistentVoiceCache creates the on-disk voice cache at its default location
+func newPersistentVoiceCache(client tts.VoiceListClient, ttsCfg config.TTSConfig) (*tts.PersistentVoiceCache, error) {
+	path, err := tts.DefaultVoiceCachePath()
+	if err != nil {
+		return nil, ioError(err)
+	}
+	return tts.NewPersistentVoiceCache(client, path, ttsCfg.VoiceCacheTTL), nil
+}
+
+// resolveVoice returns the voice of a tts.voice_aliases name, or name
+// itself when it is no alias
+func resolveVoice(name string) string {
+	return tts.ResolveVoiceAlias(GetConfig().Get().TTS.VoiceAliases, name)
+}
+
+// validateVoiceOffline checks voiceName against the cached voice catalog so
+// that a mistyped voice fails fast with a suggestion instead of an API error.
+// Nothing is checked until a catalog has been cached by listing voices.
+func validateVoiceOffline(voiceName, languageCode string, cacheTTL time.Duration) error {
+	if voiceName == "" {
+		return nil
+	}
+
+	path, err := tts.DefaultVoiceCachePath()
+	if err != nil {
+		return nil
+	}
+	cache := tts.NewPersistentVoiceCache(nil, path, cacheTTL)
+
+	listing, ok := cache.Cached(languageCode)
+	if !ok {
+		return nil
+	}
+
+	names := voiceNames(listing.Voices)
+	if slices.Contains(names, voiceName) {
+		return nil
+	}
+
+	if all, ok := cache.Cached(""); ok && slices.Contains(voiceNames(all.Voices), voiceName) {
+		return validationError(fmt.Errorf("voice %s does not support language %s", voiceName, languageCode))
+	}
+
+	message := fmt.Sprintf("voice %s not found for language %s", voiceName, languageCode)
+	if match, ok := suggest.Closest(voiceName, names); ok {
+		message += fmt.Sprintf("; did you mean %s?", match)
+	}
+	return validationError(fmt.Errorf("%s\nRun 'assistant-cli voices --refresh' if the voice was added recently",
+		message))
+}
+
+func voiceNames(voices []*texttospeechpb.Voice) []string {
+	names := make([]string, 0, len(voices))
+	for _, v := range voices {
+		names = append(names, v.GetName())
+	}
+	return names
+}
+
+// lazyVoiceClient defers authentication and client creation until the voice
+// list actually has to be fetched from the API
+type lazyVoiceClient struct {
+	cfg    *config.Config
+	client *tts.Client
+}
+
+func (l *lazyVoiceClient) ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
+	if l.client == nil {
+		client, err := newGoogleClient(ctx, l.cfg.Auth, createTTSConfig(l.cfg.TTS))
+		if err != nil {
+			return nil, err
+		}
+		l.client = client
+	}
+
+	return l.client.ListVoices(ctx, languageCode)
+}
+
+// Close releases the underlying client if one was created
+func (l *lazyVoiceClient) Close() {
+	if l.client != nil {
+		_ = l.client.Close()
+	}
+}
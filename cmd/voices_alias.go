@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils/suggest"
+	"github.com/spf13/cobra"
+)
+
+// newVoicesAliasCmd creates the voices alias command and its subcommands
+func newVoicesAliasCmd() *cobra.Command {
+	aliasCmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage short names for voices",
+		Long: `Manage the short names in tts.voice_aliases, such as "sarah" for
+en-US-Neural2-F. An alias is accepted wherever a voice name is: --voice,
+presets, voice markup, input.speakers, and daemon requests.`,
+	}
+
+	aliasCmd.AddCommand(newVoicesAliasAddCmd())
+	aliasCmd.AddCommand(newVoicesAliasListCmd())
+	return aliasCmd
+}
+
+func newVoicesAliasAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add NAME VOICE",
+		Short: "Save a short name for a voice",
+		Long: `Save NAME as an alias of VOICE in the config file, replacing an alias of
+that name. VOICE is checked against the voice catalog of the provider, which
+is read from the cache when it is fresh.
+
+The config file given with --config is edited, otherwise the one that was
+loaded, otherwise ~/.assistant-cli.yaml is created.
+
+Examples:
+  assistant-cli voices alias add sarah en-US-Neural2-F
+  assistant-cli say --voice sarah "Good morning"`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runVoicesAliasAdd,
+	}
+}
+
+func newVoicesAliasListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the configured voice aliases",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.NoArgs(cmd, args); err != nil {
+				return usageError(err)
+			}
+			return nil
+		},
+		RunE: runVoicesAliasList,
+	}
+}
+
+// voiceAlias is one alias in the machine-readable results of voices alias
+type voiceAlias struct {
+	Name  string `json:"name"`
+	Voice string `json:"voice"`
+}
+
+// voiceAliasListResult is the machine-readable result of voices alias list
+type voiceAliasListResult struct {
+	Aliases []voiceAlias `json:"aliases"`
+}
+
+// voiceAliasAddResult is the machine-readable result of voices alias add
+type voiceAliasAddResult struct {
+	File  string     `json:"file"`
+	Alias voiceAlias `json:"alias"`
+}
+
+func runVoicesAliasAdd(cmd *cobra.Command, args []string) error {
+	name, voice := strings.ToLower(args[0]), args[1]
+	renderer := newRenderer(cmd)
+	if err := checkVoiceInCatalog(context.Background(), voice, renderer); err != nil {
+		return err
+	}
+
+	manager := GetConfig()
+	path, err := configFileToEdit(manager)
+	if err != nil {
+		return ioError(err)
+	}
+	if err := manager.SetVoiceAlias(path, name, voice); err != nil {
+		return err
+	}
+
+	result := &voiceAliasAddResult{File: path, Alias: voiceAlias{Name: name, Voice: voice}}
+	return renderer.Result(result, func(w io.Writer) {
+		statusf(w, "%s Saved alias %s for %s in %s\n", styleFor(w).Success(), name, voice, path)
+	})
+}
+
+// checkVoiceInCatalog rejects a voice the provider does not have, with a
+// suggestion. When the catalog cannot be read, the voice is saved unchecked
+// with a warning.
+func checkVoiceInCatalog(ctx context.Context, voice string, renderer *Renderer) error {
+	cfg := GetConfig().Get()
+	providerName, err := tts.NormalizeProvider(cfg.TTS.Provider)
+	if err != nil {
+		return validationError(err)
+	}
+	listing, err := fetchVoiceListing(ctx, cfg, providerName, "", false)
+	if err != nil {
+		renderer.Warnf("Warning: voice %s not checked: %v\n", voice, err)
+		return nil
+	}
+
+	names := voiceNames(listing.Voices)
+	if slices.Contains(names, voice) {
+		return nil
+	}
+	message := fmt.Sprintf("voice %s not found", voice)
+	if match, ok := suggest.Closest(voice, names); ok {
+		message += fmt.Sprintf("; did you mean %s?", match)
+	}
+	return validationError(fmt.Errorf("%s\nRun 'assistant-cli voices --refresh' if the voice was added recently",
+		message))
+}
+
+func runVoicesAliasList(cmd *cobra.Command, args []string) error {
+	aliases := GetConfig().Get().TTS.VoiceAliases
+
+	result := &voiceAliasListResult{Aliases: []voiceAlias{}}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result.Aliases = append(result.Aliases, voiceAlias{Name: name, Voice: aliases[name]})
+	}
+
+	return newRenderer(cmd).Result(result, func(w io.Writer) {
+		if len(result.Aliases) == 0 {
+			fmt.Fprintln(w, "No voice aliases configured; add one with 'assistant-cli voices alias add NAME VOICE'")
+			return
+		}
+		width := 0
+		for _, alias := range result.Aliases {
+			width = max(width, len(alias.Name))
+		}
+		for _, alias := range result.Aliases {
+			fmt.Fprintf(w, "%-*s  %s\n", width, alias.Name, alias.Voice)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/daemon"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runVoicesAliasCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	t.Cleanup(func() {
+		outputFormat = outputFormatText
+		cfgFile = ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs(append([]string{"voices", "alias"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestVoicesAliasAddAndList(t *testing.T) {
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	seedVoiceCache(t, time.Now())
+	path := writeTestConfig(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+
+	stdout, err := runVoicesAliasCommand(t, "list", "--config", path)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "No voice aliases configured")
+
+	stdout, err = runVoicesAliasCommand(t, "add", "Sarah", "en-US-Wavenet-D", "--config", path)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Saved alias sarah for en-US-Wavenet-D")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "voice_aliases:")
+
+	stdout, err = runVoicesAliasCommand(t, "list", "--config", path)
+	require.NoError(t, err)
+	assert.Equal(t, "sarah  en-US-Wavenet-D\n", stdout)
+
+	_, err = runVoicesAliasCommand(t, "add", "sam", "en-US-Wavnet-D", "--config", path)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.ErrorContains(t, err, "did you mean en-US-Wavenet-D?")
+
+	_, err = runVoicesAliasCommand(t, "add", "de-DE-Wavenet-A", "en-US-Wavenet-D", "--config", path)
+	assert.ErrorContains(t, err, "must not look like voice names")
+}
+
+func TestVoiceAliasesResolve(t *testing.T) {
+	seedVoiceCache(t, time.Now())
+	useGlobalConfig(t, writeTestConfig(t, `
+tts:
+  voice: sarah
+  voice_aliases:
+    sarah: en-US-Wavenet-D
+    hans: de-DE-Wavenet-A
+    klaus: de-DE-Wavnet-A
+`))
+	cfg := GetConfig().Get()
+	assert.Equal(t, "en-US-Wavenet-D", createTTSConfig(cfg.TTS).Voice)
+
+	switched := voiceRequest(&tts.SynthesizeRequest{Voice: "en-US-Wavenet-D", LanguageCode: "en-US"}, "Hans")
+	assert.Equal(t, "de-DE-Wavenet-A", switched.Voice)
+	assert.Equal(t, "de-DE", switched.LanguageCode)
+
+	// Aliases are checked against the catalog by the voice they name
+	assert.NoError(t, validateRequestVoice(&daemon.Request{Voice: "hans"}, cfg))
+	assert.NoError(t, checkMarkupVoices("[[voice=hans]]Hallo[[/voice]]", time.Hour))
+	err := validateRequestVoice(&daemon.Request{Voice: "klaus"}, cfg)
+	assert.ErrorContains(t, err, "did you mean de-DE-Wavenet-A?")
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedVoiceCache points HOME at a temporary directory and writes a voice
+// cache holding an all-languages catalog fetched at fetchedAt
+func seedVoiceCache(t *testing.T, fetchedAt time.Time) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cacheFile := filepath.Join(home, ".assistant-cli", "cache", "voices.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(cacheFile), 0700))
+	seed := map[string]interface{}{
+		"version": 1,
+		"entries": map[string]interface{}{
+			"*": map[string]interface{}{
+				"fetched_at": fetchedAt.UTC().Format(time.RFC3339),
+				"voices": []map[string]interface{}{
+					{"name": "en-US-Wavenet-D", "language_codes": []string{"en-US"}, "ssml_gender": 1,
+						"natural_sample_rate_hertz": 24000},
+					{"name": "de-DE-Wavenet-A", "language_codes": []string{"de-DE"}, "ssml_gender": 2,
+						"natural_sample_rate_hertz": 24000},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(seed)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cacheFile, data, 0600))
+}
+
+func TestVoicesCommandHelp(t *testing.T) {
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"voices", "--help"})
+
+	require.NoError(t, rootCmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "List the voices available")
+	assert.Contains(t, output, "--language")
+	assert.Contains(t, output, "--refresh")
+}
+
+func TestVoicesCommandUsesPersistentCache(t *testing.T) {
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	t.Cleanup(func() { outputFormat = outputFormatText })
+
+	// No credentials are configured, so the command can only succeed by
+	// reading the seeded cache
+	seedVoiceCache(t, time.Now())
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"--output-format", "json", "voices", "--language", "de-DE"})
+
+	require.NoError(t, rootCmd.Execute())
+
+	var result struct {
+		Success bool            `json:"success"`
+		Data    voiceListResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.True(t, result.Success)
+	assert.True(t, result.Data.Cached)
+	assert.False(t, result.Data.Stale)
+	require.Len(t, result.Data.Voices, 1)
+	assert.Equal(t, "de-DE-Wavenet-A", result.Data.Voices[0].Name)
+	assert.Equal(t, "Female", result.Data.Voices[0].Gender)
+}
+
+func TestValidateVoiceOffline(t *testing.T) {
+	tests := []struct {
+		name      string
+		voice     string
+		language  string
+		wantErr   string
+		wantMatch string
+	}{
+		{name: "no voice", voice: "", language: "en-US"},
+		{name: "known voice", voice: "en-US-Wavenet-D", language: "en-US"},
+		{name: "typo", voice: "en-US-Wavnet-D", language: "en-US", wantErr: "not found",
+			wantMatch: "did you mean en-US-Wavenet-D?"},
+		{name: "no close match", voice: "totally-unknown-voice", language: "en-US", wantErr: "not found"},
+		{name: "wrong language", voice: "de-DE-Wavenet-A", language: "en-US",
+			wantErr: "does not support language en-US"},
+		{name: "language without cached voices", voice: "fr-FR-Wavenet-A", language: "fr-FR",
+			wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seedVoiceCache(t, time.Now())
+
+			err := validateVoiceOffline(tt.voice, tt.language, time.Hour)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Equal(t, ExitValidation, ExitCode(err))
+			assert.Contains(t, err.Error(), tt.wantErr)
+			if tt.wantMatch != "" {
+				assert.Contains(t, err.Error(), tt.wantMatch)
+			} else {
+				assert.NotContains(t, err.Error(), "did you mean")
+			}
+		})
+	}
+}
+
+func TestValidateVoiceOfflineWithoutCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Without a cached catalog the API is left to judge the voice
+	assert.NoError(t, validateVoiceOffline("en-US-Wavnet-D", "en-US", time.Hour))
+}
+
+func TestValidateVoiceOfflineStaleCache(t *testing.T) {
+	seedVoiceCache(t, time.Now().Add(-30*24*time.Hour))
+
+	err := validateVoiceOffline("en-US-Wavnet-D", "en-US", time.Hour)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did you mean en-US-Wavenet-D?")
+}
+
+func TestVoicesCommandEspeakUnavailable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("ASSISTANT_CLI_TTS_PROVIDER", "espeak")
+	t.Cleanup(func() { outputFormat = outputFormatText })
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"voices"})
+
+	// The espeak provider never needs credentials, so the failure is about
+	// the missing binary rather than authentication
+	err := rootCmd.Execute()
+	require.Error(t, err)
+	assert.Equal(t, ExitUnavailable, ExitCode(err))
+	assert.Contains(t, err.Error(), "espeak")
+}
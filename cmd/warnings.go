@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// checkConfigWarnings prints non-fatal findings about the loaded configuration
+// to stderr, or fails with them when --strict is set
+func checkConfigWarnings(cmd *cobra.Command) error {
+	// config validate reports warnings as part of its own output
+	if cmd == validateConfigCmd {
+		return nil
+	}
+
+	warnings := GetConfig().ValidationWarnings(isInteractive())
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	if strictMode {
+		return validationError(strictWarningsError(warnings))
+	}
+
+	printWarnings(cmd.ErrOrStderr(), warnings, "Warning: ")
+	return nil
+}
+
+// strictWarningsError promotes warnings to an error for --strict
+func strictWarningsError(warnings config.ValidationErrors) error {
+	return fmt.Errorf("%d configuration warning(s) treated as errors (--strict): %w", len(warnings), warnings)
+}
+
+// printWarnings writes one line per warning, starting with prefix, followed
+// by its suggestion
+func printWarnings(w io.Writer, warnings config.ValidationErrors, prefix string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, warning.Field, warning.Message)
+		if warning.Suggestion != "" {
+			fmt.Fprintf(w, "  Suggestion: %s\n", warning.Suggestion)
+		}
+	}
+}
+
+// printMigrationReport tells the user how their config file was upgraded
+func printMigrationReport(w io.Writer, report *config.MigrationReport) {
+	from := report.From
+	if from == "" {
+		from = "an unversioned schema"
+	}
+	fmt.Fprintf(w, "Migrated config file %s from %s to %s:\n", report.File, from, report.To)
+	for _, change := range report.Changes {
+		fmt.Fprintf(w, "  - %s\n", change)
+	}
+	if report.WriteErr != nil {
+		fmt.Fprintf(w, "Warning: the upgrade only applies to this run: %v\n", report.WriteErr)
+		return
+	}
+	fmt.Fprintf(w, "The previous file was saved as %s\n", report.Backup)
+}
+
+// isInteractive reports whether stdin is a terminal a user could answer prompts on
+func isInteractive() bool {
+	return isTerminal(os.Stdin)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configWithAPIKey = `auth:
+  method: "apikey"
+  api_key: "AIzaSyExampleKeyThatIsLongEnough123456"
+tts:
+  language: "en-US"
+`
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func useGlobalConfig(t *testing.T, path string) {
+	t.Helper()
+
+	manager := config.NewManager()
+	manager.SetConfigFile(path)
+	require.NoError(t, manager.Load())
+
+	previous := globalConfig
+	globalConfig = manager
+	t.Cleanup(func() { globalConfig = previous })
+}
+
+func TestCheckConfigWarnings(t *testing.T) {
+	useGlobalConfig(t, writeTestConfig(t, configWithAPIKey))
+
+	stderr := new(bytes.Buffer)
+	cmd := &cobra.Command{Use: "test"}
+	cmd.SetErr(stderr)
+
+	require.NoError(t, checkConfigWarnings(cmd))
+	assert.Contains(t, stderr.String(), "Warning: auth.api_key: credential is stored in the config file")
+	assert.Contains(t, stderr.String(), "ASSISTANT_CLI_API_KEY")
+}
+
+func TestCheckConfigWarningsStrict(t *testing.T) {
+	useGlobalConfig(t, writeTestConfig(t, configWithAPIKey))
+	strictMode = true
+	t.Cleanup(func() { strictMode = false })
+
+	stderr := new(bytes.Buffer)
+	cmd := &cobra.Command{Use: "test"}
+	cmd.SetErr(stderr)
+
+	err := checkConfigWarnings(cmd)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	assert.Contains(t, err.Error(), "treated as errors (--strict)")
+	assert.Empty(t, stderr.String())
+}
+
+func TestCheckConfigWarningsClean(t *testing.T) {
+	useGlobalConfig(t, writeTestConfig(t, "tts:\n  language: \"en-US\"\n"))
+	strictMode = true
+	t.Cleanup(func() { strictMode = false })
+
+	stderr := new(bytes.Buffer)
+	cmd := &cobra.Command{Use: "test"}
+	cmd.SetErr(stderr)
+
+	assert.NoError(t, checkConfigWarnings(cmd))
+	assert.Empty(t, stderr.String())
+}
+
+func TestConfigValidateWarnings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTestConfig(t, configWithAPIKey)
+	t.Cleanup(func() {
+		validateFormat = "text"
+		strictMode = false
+	})
+
+	runValidate := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs(append([]string{"config", "validate", path, "--format", "json"}, args...))
+		_ = validateConfigCmd.Flags().Set("help", "false")
+		err := rootCmd.Execute()
+		return buf.String(), err
+	}
+
+	output, err := runValidate()
+	require.NoError(t, err)
+
+	var report struct {
+		Valid    bool `json:"valid"`
+		Warnings []struct {
+			Field string `json:"field"`
+		} `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+	assert.True(t, report.Valid)
+	require.Len(t, report.Warnings, 1)
+	assert.Equal(t, "auth.api_key", report.Warnings[0].Field)
+
+	output, err = runValidate("--strict")
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+	assert.False(t, report.Valid)
+}
+
+func TestPrintMigrationReport(t *testing.T) {
+	report := &config.MigrationReport{
+		File:    "/home/user/.assistant-cli.yaml",
+		To:      config.CurrentConfigVersion,
+		Backup:  "/home/user/.assistant-cli.yaml.v0.bak",
+		Changes: []string{"renamed audio.voice to tts.voice"},
+	}
+
+	buf := new(bytes.Buffer)
+	printMigrationReport(buf, report)
+	assert.Equal(t, "Migrated config file /home/user/.assistant-cli.yaml from an unversioned schema to "+
+		config.CurrentConfigVersion+":\n  - renamed audio.voice to tts.voice\n"+
+		"The previous file was saved as /home/user/.assistant-cli.yaml.v0.bak\n", buf.String())
+
+	buf.Reset()
+	report.From = "1.0.0"
+	report.WriteErr = errors.New("permission denied")
+	printMigrationReport(buf, report)
+	assert.Contains(t, buf.String(), "from 1.0.0 to")
+	assert.Contains(t, buf.String(), "Warning: the upgrade only applies to this run: permission denied")
+}
@@ -0,0 +1,266 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// Container identifies the file structure of encoded audio
+type Container string
+
+// Supported audio containers
+const (
+	ContainerWAV Container = "wav"
+	ContainerMP3 Container = "mp3"
+	ContainerOgg Container = "ogg"
+)
+
+// DetectContainer identifies the container of audio data by its signature
+func DetectContainer(data []byte) (Container, error) {
+	switch {
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return ContainerWAV, nil
+	case len(data) >= 4 && string(data[0:4]) == "OggS":
+		return ContainerOgg, nil
+	case len(data) >= 3 && string(data[0:3]) == "ID3",
+		len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return ContainerMP3, nil
+	default:
+		return "", fmt.Errorf("%w: unrecognized audio container", ErrUnsupportedFormat)
+	}
+}
+
+// Concat joins audio files that share a container into one file, inserting
+// gap of silence between consecutive files. Each container has its own rules:
+//
+//   - WAV files must share their encoding, sample rate and channel count; the
+//     sample data is joined under a single header.
+//   - MP3 frame streams are joined directly, keeping only the first file's
+//     ID3v2 tag and the last file's ID3v1 tag. Gaps are silent frames in
+//     the format of the first file's first frame.
+//   - Ogg files are chained as consecutive logical streams, with serial
+//     numbers made unique as the format requires.
+//
+// Gaps need a known sample format and are only supported for WAV and MP3.
+func Concat(files [][]byte, gap time.Duration) ([]byte, error) {
+	return ConcatWith(files, Separator{Gap: gap})
+}
+
+// ConcatWith joins audio files like Concat, inserting sep between
+// consecutive files. Tones need sample data and are only supported for WAV.
+func ConcatWith(files [][]byte, sep Separator) ([]byte, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no audio to concatenate")
+	}
+	if err := sep.validate(); err != nil {
+		return nil, err
+	}
+
+	container, err := DetectContainer(files[0])
+	if err != nil {
+		return nil, fmt.Errorf("file 1: %w", err)
+	}
+	for i, data := range files[1:] {
+		other, err := DetectContainer(data)
+		if err != nil {
+			return nil, fmt.Errorf("file %d: %w", i+2, err)
+		}
+		if other != container {
+			return nil, fmt.Errorf("file %d: cannot join %s audio to %s audio", i+2, other, container)
+		}
+	}
+
+	switch {
+	case sep.Tone > 0 && container != ContainerWAV:
+		return nil, fmt.Errorf("%w: tones are only supported for WAV audio, not %s", ErrUnsupportedFormat, container)
+	case sep.Gap > 0 && container == ContainerOgg:
+		return nil, fmt.Errorf("%w: gaps are only supported for WAV and MP3 audio, not %s", ErrUnsupportedFormat, container)
+	}
+
+	switch container {
+	case ContainerWAV:
+		return concatWAV(files, sep)
+	case ContainerOgg:
+		return concatOgg(files)
+	default:
+		return concatMP3(files, sep.Gap)
+	}
+}
+
+func concatWAV(files [][]byte, sep Separator) ([]byte, error) {
+	var joined *wavFile
+	for i, data := range files {
+		wav, err := parseWAV(data)
+		if err != nil {
+			return nil, fmt.Errorf("file %d: %w", i+1, err)
+		}
+
+		if joined == nil {
+			joined = &wavFile{fmtChunk: wav.fmtChunk, format: wav.format, channels: wav.channels,
+				sampleRate: wav.sampleRate, blockAlign: wav.blockAlign, bits: wav.bits}
+			joined.data = append([]byte{}, wav.data...)
+			continue
+		}
+
+		if wav.format != joined.format || wav.bits != joined.bits ||
+			wav.channels != joined.channels || wav.sampleRate != joined.sampleRate {
+			return nil, fmt.Errorf("file %d: audio format differs from file 1 (%s vs %s)", i+1,
+				wav.describe(), joined.describe())
+		}
+		separator, err := joined.separator(sep)
+		if err != nil {
+			return nil, err
+		}
+		joined.data = append(joined.data, separator...)
+		joined.data = append(joined.data, wav.data...)
+	}
+	return joined.bytes(), nil
+}
+
+// describe summarizes the sample format for error messages
+func (w *wavFile) describe() string {
+	return fmt.Sprintf("encoding %d, %d-bit, %d Hz, %d channel(s)", w.format, w.bits, w.sampleRate, w.channels)
+}
+
+// silence returns sample data for d of silence in the file's encoding
+func (w *wavFile) silence(d time.Duration) []byte {
+	frames := int(d * time.Duration(w.sampleRate) / time.Second)
+	value := byte(0)
+	switch {
+	case w.format == wavFormatMULaw:
+		value = 0xFF
+	case w.format == wavFormatALaw:
+		value = 0xD5
+	case w.format == wavFormatPCM && w.bits == 8:
+		// 8-bit PCM is unsigned
+		value = 0x80
+	}
+	return bytes.Repeat([]byte{value}, frames*w.blockAlign)
+}
+
+// id3v1Size is the size of a trailing ID3v1 tag
+const id3v1Size = 128
+
+func concatMP3(files [][]byte, gap time.Duration) ([]byte, error) {
+	var silence []byte
+	if gap > 0 {
+		var err error
+		if silence, err = mp3Silence(files[0], gap); err != nil {
+			return nil, fmt.Errorf("file 1: %w", err)
+		}
+	}
+
+	var joined []byte
+	for i, data := range files {
+		if i > 0 {
+			data = data[output.ID3v2Size(data):]
+			joined = append(joined, silence...)
+		}
+		if i < len(files)-1 && len(data) >= id3v1Size && string(data[len(data)-id3v1Size:][:3]) == "TAG" {
+			data = data[:len(data)-id3v1Size]
+		}
+		joined = append(joined, data...)
+	}
+	return joined, nil
+}
+
+// mp3SampleRates are the sample rates in Hz by sample rate index, for
+// MPEG-1, MPEG-2 and MPEG-2.5
+var mp3SampleRates = [3][3]int{
+	{44100, 48000, 32000},
+	{22050, 24000, 16000},
+	{11025, 12000, 8000},
+}
+
+// mp3Silence returns d of silent Layer III frames in the format of the
+// first frame of data: the same MPEG version, sample rate, channel mode and
+// bitrate, so that a constant bitrate stream stays one and its duration is
+// still known. The side information of each frame is zero, which decodes
+// to silence without taking data from the bit reservoir.
+func mp3Silence(data []byte, d time.Duration) ([]byte, error) {
+	frames := data[output.ID3v2Size(data):]
+	if len(frames) < 4 || frames[0] != 0xFF || frames[1]&0xE0 != 0xE0 {
+		return nil, fmt.Errorf("%w: no MP3 frame found", ErrUnsupportedFormat)
+	}
+
+	version := frames[1] >> 3 & 0x03
+	layer := frames[1] >> 1 & 0x03
+	bitrateIndex := int(frames[2] >> 4)
+	rateIndex := int(frames[2] >> 2 & 0x03)
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return nil, fmt.Errorf("%w: unsupported MP3 frame header", ErrUnsupportedFormat)
+	}
+
+	// MPEG-1 frames hold 1152 samples, MPEG-2 and MPEG-2.5 frames 576,
+	// after side information whose size depends on the channel mode
+	mono := frames[3]>>6 == 3
+	table, rates, samples, sideInfo := 0, 0, 1152, 32
+	if mono {
+		sideInfo = 17
+	}
+	if version != 3 {
+		table, rates, samples, sideInfo = 1, 1, 576, 17
+		if mono {
+			sideInfo = 9
+		}
+		if version == 0 {
+			rates = 2
+		}
+	}
+	sampleRate := mp3SampleRates[rates][rateIndex]
+	size := samples / 8 * mp3Bitrates[table][bitrateIndex] * 1000 / sampleRate
+	if size < 4+sideInfo {
+		return nil, fmt.Errorf("%w: MP3 frames too small for silence", ErrUnsupportedFormat)
+	}
+
+	// The frame keeps the header of data without a CRC or padding
+	frame := make([]byte, size)
+	frame[0] = 0xFF
+	frame[1] = frames[1] | 0x01
+	frame[2] = frames[2] &^ 0x02
+	frame[3] = frames[3]
+	count := int((int64(d)*int64(sampleRate)/int64(time.Second) + int64(samples)/2) / int64(samples))
+	return bytes.Repeat(frame, count), nil
+}
+
+// oggSerialOffset is the position of the stream serial number in an Ogg page
+const oggSerialOffset = 14
+
+func concatOgg(files [][]byte) ([]byte, error) {
+	var joined []byte
+	used := make(map[uint32]bool)
+	for i, data := range files {
+		pages, err := output.OggPages(data)
+		if err != nil {
+			return nil, fmt.Errorf("file %d: %w", i+1, err)
+		}
+
+		// Give each logical stream of the file, which may itself be a
+		// chain, a serial no earlier stream uses
+		serials := make(map[uint32]uint32)
+		for _, page := range pages {
+			serial := binary.LittleEndian.Uint32(page[oggSerialOffset:])
+			renumbered, seen := serials[serial]
+			if !seen {
+				renumbered = serial
+				for used[renumbered] {
+					renumbered++
+				}
+				used[renumbered] = true
+				serials[serial] = renumbered
+			}
+
+			if renumbered != serial {
+				page = append([]byte{}, page...)
+				binary.LittleEndian.PutUint32(page[oggSerialOffset:], renumbered)
+				output.SetOggChecksum(page)
+			}
+			joined = append(joined, page...)
+		}
+	}
+	return joined, nil
+}
@@ -0,0 +1,181 @@
+package audio
+
+import (
+	"bytes"
+	"flag"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// mp3Part builds an MP3 file of frames, each a frame header followed by
+// payload, with optional ID3v2 and ID3v1 tags
+func mp3Part(payload string, id3v2, id3v1 bool) []byte {
+	var data []byte
+	if id3v2 {
+		data = append(data, "ID3\x04\x00\x00\x00\x00\x00\x02ab"...)
+	}
+	for i := 0; i < len(payload); i++ {
+		data = append(data, 0xFF, 0xFB, payload[i], payload[i])
+	}
+	if id3v1 {
+		tag := make([]byte, id3v1Size)
+		copy(tag, "TAG"+payload)
+		data = append(data, tag...)
+	}
+	return data
+}
+
+// sine returns frames of a 16-bit tone, deterministic for golden files
+func sine(frames, channels int, step int16) []int16 {
+	samples := make([]int16, frames*channels)
+	for i := range samples {
+		samples[i] = int16(i/channels) * step
+	}
+	return samples
+}
+
+func TestConcatGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		files [][]byte
+		gap   time.Duration
+	}{
+		{
+			"wav-mono-gap",
+			[][]byte{
+				EncodeWAV(&PCM{SampleRate: 1000, Channels: 1, Samples: sine(8, 1, 100)}),
+				EncodeWAV(&PCM{SampleRate: 1000, Channels: 1, Samples: sine(4, 1, -50)}),
+				EncodeWAV(&PCM{SampleRate: 1000, Channels: 1, Samples: sine(2, 1, 7)}),
+			},
+			5 * time.Millisecond,
+		},
+		{
+			"wav-stereo",
+			[][]byte{
+				EncodeWAV(&PCM{SampleRate: 2000, Channels: 2, Samples: sine(3, 2, 1000)}),
+				EncodeWAV(&PCM{SampleRate: 2000, Channels: 2, Samples: sine(3, 2, -1000)}),
+			},
+			0,
+		},
+		{
+			"mp3-tags",
+			[][]byte{mp3Part("abc", true, true), mp3Part("de", true, false), mp3Part("f", false, true)},
+			0,
+		},
+		{
+			"ogg-chain",
+			[][]byte{
+				append(oggPage(7, 0, "OpusHead"), oggPage(7, 1, "first")...),
+				append(oggPage(7, 0, "OpusHead"), oggPage(7, 1, "second")...),
+				oggPage(8, 0, "third"),
+			},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			joined, err := Concat(tt.files, tt.gap)
+			require.NoError(t, err)
+
+			path := filepath.Join("testdata", "concat", tt.name+".golden")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(path, joined, 0644))
+				return
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err, "run go test with -update to create the golden file")
+			assert.True(t, bytes.Equal(want, joined),
+				"output differs from %s; run go test -update if the change is intended", path)
+		})
+	}
+}
+
+func TestConcat_Properties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	randomWAV := func(sampleRate, channels int) []byte {
+		samples := make([]int16, rng.IntN(50)*channels)
+		for i := range samples {
+			samples[i] = int16(rng.IntN(1 << 16))
+		}
+		return EncodeWAV(&PCM{SampleRate: sampleRate, Channels: channels, Samples: samples})
+	}
+
+	for i := 0; i < 100; i++ {
+		sampleRate, channels := 1000*(1+rng.IntN(48)), 1+rng.IntN(2)
+		files := make([][]byte, 1+rng.IntN(5))
+		var want time.Duration
+		for j := range files {
+			files[j] = randomWAV(sampleRate, channels)
+			d, err := Duration(files[j])
+			require.NoError(t, err)
+			want += d
+		}
+		gap := time.Duration(rng.IntN(20)) * time.Millisecond
+		want += time.Duration(len(files)-1) * gap
+
+		joined, err := Concat(files, gap)
+		require.NoError(t, err)
+
+		// The length is the sum of the parts and the gaps, to the frame
+		got, err := Duration(joined)
+		require.NoError(t, err)
+		frame := time.Second / time.Duration(sampleRate)
+		assert.InDelta(t, want, got, float64(time.Duration(len(files))*frame))
+
+		// The samples of each part appear unchanged and in order
+		pcm, err := DecodeWAV(joined)
+		require.NoError(t, err)
+		offset := 0
+		for j, file := range files {
+			part, err := DecodeWAV(file)
+			require.NoError(t, err)
+			require.Equal(t, part.Samples, pcm.Samples[offset:offset+len(part.Samples)], "part %d", j+1)
+			offset += len(part.Samples)
+			if j < len(files)-1 {
+				offset += int(gap*time.Duration(sampleRate)/time.Second) * channels
+			}
+		}
+		assert.Equal(t, len(pcm.Samples), offset)
+	}
+}
+
+func TestConcat_Associative(t *testing.T) {
+	wav := func(step int16) []byte {
+		return EncodeWAV(&PCM{SampleRate: 8000, Channels: 1, Samples: sine(5, 1, step)})
+	}
+	ogg := func(serial uint32, payload string) []byte {
+		return append(oggPage(serial, 0, "OpusHead"), oggPage(serial, 1, payload)...)
+	}
+
+	tests := []struct {
+		name    string
+		a, b, c []byte
+	}{
+		{"wav", wav(1), wav(2), wav(3)},
+		{"mp3", mp3Part("ab", true, true), mp3Part("cd", true, true), mp3Part("e", true, true)},
+		{"ogg", ogg(1, "a"), ogg(1, "b"), ogg(1, "c")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all, err := Concat([][]byte{tt.a, tt.b, tt.c}, 0)
+			require.NoError(t, err)
+
+			// Joining a joined file again gives the same result, so long
+			// documents can be built up in steps
+			left, err := Concat([][]byte{tt.a, tt.b}, 0)
+			require.NoError(t, err)
+			stepwise, err := Concat([][]byte{left, tt.c}, 0)
+			require.NoError(t, err)
+			assert.Equal(t, all, stepwise)
+		})
+	}
+}
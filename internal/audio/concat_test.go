@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oggPage builds a valid single-segment Ogg page
+func oggPage(serial uint32, sequence uint32, payload string) []byte {
+	// A 27-byte header followed by one lacing value
+	page := make([]byte, 28, 28+len(payload))
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint32(page[oggSerialOffset:], serial)
+	binary.LittleEndian.PutUint32(page[18:], sequence)
+	page[26] = 1
+	page[27] = byte(len(payload))
+	page = append(page, payload...)
+	output.SetOggChecksum(page)
+	return page
+}
+
+func TestDetectContainer(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Container
+	}{
+		{"wav", EncodeWAV(&PCM{SampleRate: 8000, Channels: 1}), ContainerWAV},
+		{"ogg", oggPage(1, 0, "x"), ContainerOgg},
+		{"mp3 with ID3", []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), ContainerMP3},
+		{"mp3 frame sync", []byte{0xFF, 0xFB, 0x90, 0x00}, ContainerMP3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectContainer(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := DetectContainer([]byte("plain text"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestConcatWAV(t *testing.T) {
+	first := EncodeWAV(&PCM{SampleRate: 1000, Channels: 1, Samples: []int16{1, 2}})
+	second := EncodeWAV(&PCM{SampleRate: 1000, Channels: 1, Samples: []int16{3}})
+
+	joined, err := Concat([][]byte{first, second}, 3*time.Millisecond)
+	require.NoError(t, err)
+
+	pcm, err := DecodeWAV(joined)
+	require.NoError(t, err)
+	assert.Equal(t, []int16{1, 2, 0, 0, 0, 3}, pcm.Samples)
+	assert.Equal(t, uint32(len(joined)-8), binary.LittleEndian.Uint32(joined[4:8]))
+}
+
+func TestConcatWAVMuLawSilence(t *testing.T) {
+	wav := &wavFile{format: wavFormatMULaw, channels: 1, sampleRate: 1000, blockAlign: 1, bits: 8,
+		fmtChunk: make([]byte, 16), data: []byte{0x10}}
+	binary.LittleEndian.PutUint16(wav.fmtChunk[0:2], wavFormatMULaw)
+	binary.LittleEndian.PutUint16(wav.fmtChunk[2:4], 1)
+	binary.LittleEndian.PutUint32(wav.fmtChunk[4:8], 1000)
+	binary.LittleEndian.PutUint16(wav.fmtChunk[12:14], 1)
+	binary.LittleEndian.PutUint16(wav.fmtChunk[14:16], 8)
+
+	joined, err := Concat([][]byte{wav.bytes(), wav.bytes()}, 2*time.Millisecond)
+	require.NoError(t, err)
+
+	parsed, err := parseWAV(joined)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x10, 0xFF, 0xFF, 0x10}, parsed.data)
+}
+
+func TestConcatWAVFormatMismatch(t *testing.T) {
+	first := EncodeWAV(&PCM{SampleRate: 24000, Channels: 1, Samples: []int16{1}})
+	second := EncodeWAV(&PCM{SampleRate: 16000, Channels: 1, Samples: []int16{1}})
+
+	_, err := Concat([][]byte{first, second}, 0)
+	assert.ErrorContains(t, err, "file 2: audio format differs from file 1")
+}
+
+func TestConcatMP3(t *testing.T) {
+	frames := func(b byte) []byte { return []byte{0xFF, 0xFB, b, b} }
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+	id3v2 := []byte("ID3\x04\x00\x00\x00\x00\x00\x02ab")
+
+	first := append(append(append([]byte{}, id3v2...), frames(1)...), tag...)
+	second := append(append(append([]byte{}, id3v2...), frames(2)...), tag...)
+
+	joined, err := Concat([][]byte{first, second}, 0)
+	require.NoError(t, err)
+
+	want := append(append(append(append([]byte{}, id3v2...), frames(1)...), frames(2)...), tag...)
+	assert.Equal(t, want, joined)
+
+	// Free-format frames have no known size to match with silence
+	_, err = Concat([][]byte{first, second}, time.Second)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestConcatMP3Gap(t *testing.T) {
+	// MPEG-2 Layer III, 32 kbit/s, 24000 Hz, mono: 96-byte frames of 24ms
+	header := []byte{0xFF, 0xF3, 0x44, 0xC4}
+	frame := append(append([]byte{}, header...), bytes.Repeat([]byte{0x55}, 92)...)
+	first := bytes.Repeat(frame, 10)
+	second := bytes.Repeat(frame, 5)
+
+	joined, err := Concat([][]byte{first, second}, 480*time.Millisecond)
+	require.NoError(t, err)
+
+	// 480ms is 20 silent frames, in the format of the first file
+	silent := append([]byte{0xFF, 0xF3, 0x44, 0xC4}, make([]byte, 92)...)
+	assert.Equal(t, append(append(append([]byte{}, first...), bytes.Repeat(silent, 20)...), second...), joined)
+	d, err := Duration(joined)
+	require.NoError(t, err)
+	assert.Equal(t, 840*time.Millisecond, d)
+
+	// Silent frames drop the CRC and padding of the stream
+	crc := append([]byte{0xFF, 0xFA, 0x92, 0x00}, make([]byte, 413)...)
+	joined, err = Concat([][]byte{crc, crc}, 26*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xFF, 0xFB, 0x90, 0x00}, joined[417:421])
+	assert.Len(t, joined, 2*417+417)
+
+	_, err = ConcatWith([][]byte{first, second}, Separator{Tone: time.Second})
+	assert.ErrorContains(t, err, "tones are only supported for WAV audio")
+	_, err = Concat([][]byte{oggPage(1, 0, "x"), oggPage(2, 0, "y")}, time.Second)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestConcatOgg(t *testing.T) {
+	first := append(oggPage(7, 0, "head"), oggPage(7, 1, "body")...)
+	second := oggPage(7, 0, "next")
+
+	joined, err := Concat([][]byte{first, second}, 0)
+	require.NoError(t, err)
+
+	pages, err := output.OggPages(joined)
+	require.NoError(t, err)
+	require.Len(t, pages, 3)
+	assert.Equal(t, first, append(append([]byte{}, pages[0]...), pages[1]...))
+
+	// The chained stream gets a new serial and a matching checksum
+	assert.Equal(t, uint32(8), binary.LittleEndian.Uint32(pages[2][oggSerialOffset:]))
+	assert.Equal(t, oggPage(8, 0, "next"), pages[2])
+}
+
+func TestConcatMixedContainers(t *testing.T) {
+	wav := EncodeWAV(&PCM{SampleRate: 8000, Channels: 1})
+	_, err := Concat([][]byte{wav, oggPage(1, 0, "x")}, 0)
+	assert.ErrorContains(t, err, "cannot join ogg audio to wav audio")
+
+	_, err = Concat(nil, 0)
+	assert.Error(t, err)
+}
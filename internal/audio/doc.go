@@ -0,0 +1,5 @@
+// Package audio provides processing of synthesized audio.
+// It decodes and encodes 16-bit PCM WAV data and applies a post-processing
+// chain (silence trimming, loudness normalization, fades) after synthesis,
+// and joins files, optionally separated by generated silence or tones.
+package audio
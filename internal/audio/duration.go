@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// opusSampleRate is the rate of Ogg Opus granule positions, whatever the
+// rate of the encoded audio
+const opusSampleRate = 48000
+
+// mp3Bitrates are the Layer III bitrates in kbit/s by bitrate index, for
+// MPEG-1 and for MPEG-2/2.5
+var mp3Bitrates = [2][15]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// Duration returns the playing time of encoded WAV, Ogg Opus or MP3 audio.
+// MP3 durations are estimated from the first frame's bitrate, which is exact
+// for the constant bitrate streams produced by synthesis.
+func Duration(data []byte) (time.Duration, error) {
+	container, err := DetectContainer(data)
+	if err != nil {
+		return 0, err
+	}
+
+	switch container {
+	case ContainerWAV:
+		wav, err := parseWAV(data)
+		if err != nil {
+			return 0, err
+		}
+		frames := len(wav.data) / wav.blockAlign
+		return time.Duration(frames) * time.Second / time.Duration(wav.sampleRate), nil
+	case ContainerOgg:
+		return opusDuration(data)
+	default:
+		return mp3Duration(data)
+	}
+}
+
+// opusDuration reads the duration from the granule position of the last
+// page, less the pre-skip samples declared in the OpusHead header
+func opusDuration(data []byte) (time.Duration, error) {
+	pages, err := output.OggPages(data)
+	if err != nil {
+		return 0, err
+	}
+
+	// The first page's body follows its 27-byte header and lacing values.
+	// OpusHead: magic, version, channel count, then the 16-bit pre-skip
+	head := pages[0][27+int(pages[0][26]):]
+	if len(head) < 12 || string(head[0:8]) != "OpusHead" {
+		return 0, fmt.Errorf("%w: Ogg stream is not Opus", ErrUnsupportedFormat)
+	}
+	preSkip := int64(binary.LittleEndian.Uint16(head[10:12]))
+
+	// The granule position is the 64-bit sample count at byte 6
+	granule := int64(binary.LittleEndian.Uint64(pages[len(pages)-1][6:14]))
+	samples := max(granule-preSkip, 0)
+	return time.Duration(samples) * time.Second / opusSampleRate, nil
+}
+
+// mp3Duration estimates the duration of a Layer III stream from the
+// bitrate of its first frame
+func mp3Duration(data []byte) (time.Duration, error) {
+	frames := data[output.ID3v2Size(data):]
+	if len(frames) >= id3v1Size && string(frames[len(frames)-id3v1Size:][:3]) == "TAG" {
+		frames = frames[:len(frames)-id3v1Size]
+	}
+	if len(frames) < 4 || frames[0] != 0xFF || frames[1]&0xE0 != 0xE0 {
+		return 0, fmt.Errorf("%w: no MP3 frame found", ErrUnsupportedFormat)
+	}
+
+	version := frames[1] >> 3 & 0x03
+	layer := frames[1] >> 1 & 0x03
+	index := int(frames[2] >> 4)
+	if version == 1 || layer != 1 || index == 0 || index == 15 {
+		return 0, fmt.Errorf("%w: unsupported MP3 frame header", ErrUnsupportedFormat)
+	}
+
+	table := 0
+	if version != 3 {
+		// MPEG-2 and MPEG-2.5
+		table = 1
+	}
+	bitsPerSecond := int64(mp3Bitrates[table][index]) * 1000
+	return time.Duration(int64(len(frames)) * 8 * int64(time.Second) / bitsPerSecond), nil
+}
@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationWAV(t *testing.T) {
+	wav := EncodeWAV(&PCM{SampleRate: 8000, Channels: 2, Samples: make([]int16, 8000)})
+
+	d, err := Duration(wav)
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, d)
+}
+
+func TestDurationOpus(t *testing.T) {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1
+	head[9] = 1
+	binary.LittleEndian.PutUint16(head[10:], 312)
+
+	first := oggPage(1, 0, string(head))
+	last := oggPage(1, 1, "audio")
+	// Two seconds of audio after the pre-skip
+	binary.LittleEndian.PutUint64(last[6:], 312+2*opusSampleRate)
+	output.SetOggChecksum(last)
+
+	d, err := Duration(append(first, last...))
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, d)
+
+	_, err = Duration(append(oggPage(1, 0, "vorbis"), last...))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestDurationMP3(t *testing.T) {
+	// MPEG-2 Layer III at 32 kbit/s: 4000 bytes per second
+	frames := append([]byte{0xFF, 0xF3, 0x40, 0xC4}, bytes.Repeat([]byte{0}, 7996)...)
+	id3 := []byte("ID3\x04\x00\x00\x00\x00\x00\x02ab")
+
+	d, err := Duration(append(id3, frames...))
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, d)
+
+	// MPEG-1 Layer III at 128 kbit/s
+	frames = append([]byte{0xFF, 0xFB, 0x90, 0x00}, bytes.Repeat([]byte{0}, 15996)...)
+	d, err = Duration(frames)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, d)
+
+	_, err = Duration([]byte{0xFF, 0xFB, 0xF0, 0x00})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestDurationUnknownContainer(t *testing.T) {
+	_, err := Duration([]byte{1, 2, 3, 4})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,6 @@
+// Package ffmpeg transcodes audio with a local ffmpeg binary.
+// It adds output formats the synthesis APIs do not produce, such as AAC,
+// FLAC, and Opus in Ogg or WebM containers, by encoding their WAV output,
+// and decodes arbitrary audio files to WAV. ffmpeg is optional: it is
+// looked up on PATH when a transcoded format is requested.
+package ffmpeg
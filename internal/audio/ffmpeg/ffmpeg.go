@@ -0,0 +1,125 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound reports that no ffmpeg binary is on PATH
+var ErrNotFound = errors.New("ffmpeg not found on PATH")
+
+// Format is an output format produced by transcoding WAV audio
+type Format struct {
+	// Name is the --format value, e.g. "FLAC"
+	Name string
+	// Extension is the file extension, without a dot
+	Extension string
+	// args select the codec and container
+	args []string
+}
+
+// formats lists the transcoded formats in the order they are documented
+var formats = []Format{
+	{Name: "AAC", Extension: "m4a", args: []string{"-c:a", "aac", "-b:a", "128k", "-f", "ipod"}},
+	{Name: "FLAC", Extension: "flac", args: []string{"-c:a", "flac", "-f", "flac"}},
+	{Name: "OPUS", Extension: "opus", args: []string{"-c:a", "libopus", "-b:a", "64k", "-f", "ogg"}},
+	{Name: "WEBM", Extension: "webm", args: []string{"-c:a", "libopus", "-b:a", "64k", "-f", "webm"}},
+}
+
+// Formats returns the names of the transcoded formats
+func Formats() []string {
+	names := make([]string, len(formats))
+	for i, format := range formats {
+		names[i] = format.Name
+	}
+	return names
+}
+
+// Lookup returns the transcoded format named name, ignoring case
+func Lookup(name string) (Format, bool) {
+	for _, format := range formats {
+		if strings.EqualFold(format.Name, name) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// IsFormat reports whether name is a format produced by transcoding
+func IsFormat(name string) bool {
+	_, ok := Lookup(name)
+	return ok
+}
+
+// Path returns the ffmpeg binary on PATH, or an error wrapping ErrNotFound
+// that says how to install it
+func Path() (string, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("%w; install it (e.g. brew install ffmpeg or apt install ffmpeg) "+
+			"or use a format the API produces directly: MP3, LINEAR16, or OGG_OPUS", ErrNotFound)
+	}
+	return path, nil
+}
+
+// Transcode encodes WAV audio in format
+func Transcode(ctx context.Context, wav []byte, format Format) ([]byte, error) {
+	if len(format.args) == 0 {
+		return nil, fmt.Errorf("unknown transcoded format %q", format.Name)
+	}
+	data, err := run(ctx, wav, format.Extension, format.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcode to %s: %w", format.Name, err)
+	}
+	return data, nil
+}
+
+// ToWAV decodes audio in any format ffmpeg reads to a 16-bit PCM WAV file
+func ToWAV(ctx context.Context, data []byte) ([]byte, error) {
+	wav, err := run(ctx, data, "wav", "-c:a", "pcm_s16le", "-f", "wav")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+	return wav, nil
+}
+
+// run feeds input to ffmpeg on stdin and returns the file it writes. Some
+// containers, like MP4 and WAV, need a seekable output, so ffmpeg writes to
+// a temporary file rather than stdout.
+func run(ctx context.Context, input []byte, extension string, args ...string) ([]byte, error) {
+	binary, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "assistant-cli-ffmpeg-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out."+extension)
+
+	argv := append([]string{"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-vn"}, args...)
+	cmd := exec.CommandContext(ctx, binary, append(argv, "-y", out)...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("ffmpeg: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("ffmpeg: %w", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg wrote no output: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,66 @@
+package ffmpeg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFFmpeg puts an ffmpeg script on PATH that writes its arguments and
+// input to the output file, its last argument
+func fakeFFmpeg(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg binary is a shell script")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte("#!/bin/sh\n"+script), 0700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestLookup(t *testing.T) {
+	format, ok := Lookup("flac")
+	require.True(t, ok)
+	assert.Equal(t, "FLAC", format.Name)
+	assert.Equal(t, "flac", format.Extension)
+
+	assert.True(t, IsFormat("webm"))
+	assert.False(t, IsFormat("MP3"))
+	assert.Equal(t, []string{"AAC", "FLAC", "OPUS", "WEBM"}, Formats())
+}
+
+func TestTranscode(t *testing.T) {
+	fakeFFmpeg(t, "for last; do :; done\n{ echo \"$@\"; cat; } > \"$last\"\n")
+
+	format, _ := Lookup("OPUS")
+	data, err := Transcode(context.Background(), []byte("RIFF"), format)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "-i pipe:0 -vn -c:a libopus -b:a 64k -f ogg -y ")
+	assert.Contains(t, string(data), "out.opus\nRIFF")
+
+	data, err = ToWAV(context.Background(), []byte("ID3"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "-c:a pcm_s16le -f wav -y ")
+	assert.Contains(t, string(data), "out.wav\nID3")
+}
+
+func TestTranscode_Errors(t *testing.T) {
+	fakeFFmpeg(t, "echo 'Unknown encoder libopus' >&2\nexit 1\n")
+	format, _ := Lookup("WEBM")
+	_, err := Transcode(context.Background(), []byte("RIFF"), format)
+	assert.ErrorContains(t, err, "failed to transcode to WEBM")
+	assert.ErrorContains(t, err, "Unknown encoder libopus")
+
+	t.Setenv("PATH", t.TempDir())
+	_, err = Transcode(context.Background(), []byte("RIFF"), format)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorContains(t, err, "install it")
+
+	_, err = Transcode(context.Background(), nil, Format{Name: "MP3"})
+	assert.ErrorContains(t, err, `unknown transcoded format "MP3"`)
+}
@@ -0,0 +1,132 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ducking lowers the music while the narration speaks. The narration level
+// is measured in windows of duckWindow; the music starts going down one
+// window before speech so it is fully ducked when the speech starts.
+const (
+	duckWindow    = 50 * time.Millisecond
+	duckThreshold = -45.0
+	duckAttack    = 50 * time.Millisecond
+	duckRelease   = 400 * time.Millisecond
+)
+
+// MixMusic mixes music under the narration, looped to its length and
+// converted to its sample rate and channels. The music plays at volume dB
+// relative to its own level, lowered by ducking dB more while the narration
+// is speaking.
+func MixMusic(music *PCM, volume, ducking float64) Processor {
+	return func(p *PCM) {
+		if p.Frames() == 0 || music == nil || music.Frames() == 0 {
+			return
+		}
+		bed := convertPCM(music, p.SampleRate, p.Channels)
+		speech := speechWindows(p)
+		window := max(int(duckWindow*time.Duration(p.SampleRate)/time.Second), 1)
+		attack := ducking / math.Max(float64(duckAttack)*float64(p.SampleRate)/float64(time.Second), 1)
+		release := ducking / math.Max(float64(duckRelease)*float64(p.SampleRate)/float64(time.Second), 1)
+
+		level := volume
+		for frame := 0; frame < p.Frames(); frame++ {
+			target := volume
+			if speech[frame/window] {
+				target = volume - ducking
+			}
+			switch {
+			case level > target:
+				level = math.Max(level-attack, target)
+			case level < target:
+				level = math.Min(level+release, target)
+			}
+
+			gain := dbToAmplitude(level)
+			source := bed.Samples[(frame%bed.Frames())*p.Channels:]
+			for c := 0; c < p.Channels; c++ {
+				i := frame*p.Channels + c
+				v := math.Round(float64(p.Samples[i]) + float64(source[c])*gain)
+				p.Samples[i] = int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, v)))
+			}
+		}
+	}
+}
+
+// speechWindows reports for each window of the narration whether it, or
+// the window after it, is louder than duckThreshold
+func speechWindows(p *PCM) []bool {
+	window := max(int(duckWindow*time.Duration(p.SampleRate)/time.Second), 1)
+	count := (p.Frames() + window - 1) / window
+	speech := make([]bool, count)
+	limit := dbToAmplitude(duckThreshold) * fullScale
+
+	for w := 0; w < count; w++ {
+		samples := p.Samples[w*window*p.Channels : min((w+1)*window, p.Frames())*p.Channels]
+		var sumSquares float64
+		for _, s := range samples {
+			sumSquares += float64(s) * float64(s)
+		}
+		if math.Sqrt(sumSquares/float64(len(samples))) > limit {
+			speech[w] = true
+			if w > 0 {
+				speech[w-1] = true
+			}
+		}
+	}
+	return speech
+}
+
+// convertPCM returns p at sampleRate with channels, resampled by linear
+// interpolation. Mono is copied to every channel; otherwise going down to
+// mono averages the channels and other counts reuse the source channels in
+// turn.
+func convertPCM(p *PCM, sampleRate, channels int) *PCM {
+	if p.SampleRate == sampleRate && p.Channels == channels {
+		return p
+	}
+
+	frames := int(int64(p.Frames()) * int64(sampleRate) / int64(p.SampleRate))
+	frames = max(frames, 1)
+	out := &PCM{SampleRate: sampleRate, Channels: channels, Samples: make([]int16, frames*channels)}
+	sample := func(frame, c int) float64 {
+		frame = min(frame, p.Frames()-1)
+		if channels == 1 && p.Channels > 1 {
+			var sum float64
+			for i := 0; i < p.Channels; i++ {
+				sum += float64(p.Samples[frame*p.Channels+i])
+			}
+			return sum / float64(p.Channels)
+		}
+		return float64(p.Samples[frame*p.Channels+c%p.Channels])
+	}
+
+	ratio := float64(p.SampleRate) / float64(sampleRate)
+	for frame := 0; frame < frames; frame++ {
+		position := float64(frame) * ratio
+		source := int(position)
+		fraction := position - float64(source)
+		for c := 0; c < channels; c++ {
+			v := sample(source, c)*(1-fraction) + sample(source+1, c)*fraction
+			out.Samples[frame*channels+c] = int16(math.Round(v))
+		}
+	}
+	return out
+}
+
+// ParseGain parses a gain in decibels such as "-18dB", "-18 dB", or "-18"
+func ParseGain(s string) (float64, error) {
+	value := strings.TrimSpace(s)
+	if len(value) > 2 && strings.EqualFold(value[len(value)-2:], "db") {
+		value = strings.TrimSpace(value[:len(value)-2])
+	}
+	gain, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(gain) || math.IsInf(gain, 0) {
+		return 0, fmt.Errorf("invalid gain %q (want decibels, e.g. -18dB)", s)
+	}
+	return gain, nil
+}
@@ -0,0 +1,60 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMixMusic(t *testing.T) {
+	// One second of silence, one of speech, and one of silence again
+	narration := constant(3000, 0)
+	for i := 1000; i < 2000; i++ {
+		narration.Samples[i] = 10000
+	}
+	music := constant(250, 1000)
+
+	MixMusic(music, -6, 20)(narration)
+	require.Len(t, narration.Samples, 3000)
+
+	// The music loops at half its level before the speech, is ducked by
+	// 20 dB more under it, and comes back up after it
+	assert.InDelta(t, 501, narration.Samples[500], 1)
+	assert.InDelta(t, 10050, narration.Samples[1500], 1)
+	assert.InDelta(t, 501, narration.Samples[2900], 1)
+
+	// The ducking starts before the speech and releases gradually after it
+	assert.Less(t, narration.Samples[999], int16(501))
+	assert.Greater(t, narration.Samples[2100], int16(60))
+	assert.Less(t, narration.Samples[2100], int16(501))
+}
+
+func TestMixMusic_Converts(t *testing.T) {
+	narration := &PCM{SampleRate: 2000, Channels: 2, Samples: make([]int16, 8)}
+	music := &PCM{SampleRate: 1000, Channels: 1, Samples: []int16{100, 300}}
+
+	MixMusic(music, 0, 0)(narration)
+	assert.Equal(t, []int16{100, 100, 200, 200, 300, 300, 300, 300}, narration.Samples)
+
+	// Stereo music is averaged to mono
+	mono := &PCM{SampleRate: 1000, Channels: 1, Samples: make([]int16, 2)}
+	MixMusic(&PCM{SampleRate: 1000, Channels: 2, Samples: []int16{100, 300, -100, -300}}, 0, 0)(mono)
+	assert.Equal(t, []int16{200, -200}, mono.Samples)
+
+	// Empty music leaves the narration alone
+	MixMusic(&PCM{SampleRate: 1000, Channels: 1}, 0, 0)(mono)
+	assert.Equal(t, []int16{200, -200}, mono.Samples)
+}
+
+func TestParseGain(t *testing.T) {
+	for input, want := range map[string]float64{"-18dB": -18, "-18 dB": -18, "-6.5": -6.5, " 3DB ": 3} {
+		got, err := ParseGain(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"", "dB", "loud", "NaN"} {
+		_, err := ParseGain(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,189 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// peakCeiling is the highest level in dBFS normalization may raise peaks to
+const peakCeiling = -1.0
+
+// fullScale is the magnitude of a 0 dBFS sample
+const fullScale = 32768.0
+
+// Options selects the post-processing steps applied after synthesis
+type Options struct {
+	// Normalize scales the audio so its RMS level matches TargetLevel (dBFS),
+	// without raising peaks above -1 dBFS
+	Normalize   bool
+	TargetLevel float64
+
+	// TrimSilence removes leading and trailing audio quieter than
+	// SilenceThreshold (dBFS)
+	TrimSilence      bool
+	SilenceThreshold float64
+
+	// FadeIn and FadeOut apply linear fades of the given length
+	FadeIn  time.Duration
+	FadeOut time.Duration
+
+	// Music is mixed under the narration at MusicVolume dB, ducked by
+	// MusicDucking dB more while the narration speaks
+	Music        *PCM
+	MusicVolume  float64
+	MusicDucking float64
+}
+
+// Enabled reports whether any processing step is selected
+func (o Options) Enabled() bool {
+	return o.Normalize || o.TrimSilence || o.FadeIn > 0 || o.FadeOut > 0 || o.Music != nil
+}
+
+// Processor transforms audio in place
+type Processor func(p *PCM)
+
+// Chain returns the processors selected by opts in the order they must run:
+// trimming first so silence does not skew the loudness measurement, music
+// after normalization so it sits at a fixed level under the narration, fades
+// last so they shape the final edges
+func Chain(opts Options) []Processor {
+	var chain []Processor
+	if opts.TrimSilence {
+		chain = append(chain, TrimSilence(opts.SilenceThreshold))
+	}
+	if opts.Normalize {
+		chain = append(chain, Normalize(opts.TargetLevel))
+	}
+	if opts.Music != nil {
+		chain = append(chain, MixMusic(opts.Music, opts.MusicVolume, opts.MusicDucking))
+	}
+	if opts.FadeIn > 0 {
+		chain = append(chain, FadeIn(opts.FadeIn))
+	}
+	if opts.FadeOut > 0 {
+		chain = append(chain, FadeOut(opts.FadeOut))
+	}
+	return chain
+}
+
+// Supports reports whether audio in the given synthesis format can be processed
+func Supports(format string) bool {
+	switch strings.ToUpper(format) {
+	case "LINEAR16", "WAV":
+		return true
+	default:
+		return false
+	}
+}
+
+// Process decodes data in the given synthesis format, applies the processing
+// chain selected by opts and re-encodes it
+func Process(data []byte, format string, opts Options) ([]byte, error) {
+	if !opts.Enabled() {
+		return data, nil
+	}
+	if !Supports(format) {
+		return nil, fmt.Errorf("%w: post-processing requires LINEAR16 output, got %s", ErrUnsupportedFormat, format)
+	}
+
+	pcm, err := DecodeWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, process := range Chain(opts) {
+		process(pcm)
+	}
+	return EncodeWAV(pcm), nil
+}
+
+// Normalize scales audio to the target RMS level in dBFS. The gain is limited
+// so peaks stay below -1 dBFS; silent audio is left unchanged.
+func Normalize(targetLevel float64) Processor {
+	return func(p *PCM) {
+		var sumSquares float64
+		peak := 0.0
+		for _, s := range p.Samples {
+			v := float64(s)
+			sumSquares += v * v
+			peak = math.Max(peak, math.Abs(v))
+		}
+		if peak == 0 {
+			return
+		}
+
+		rms := math.Sqrt(sumSquares / float64(len(p.Samples)))
+		gain := dbToAmplitude(targetLevel) * fullScale / rms
+		gain = math.Min(gain, dbToAmplitude(peakCeiling)*fullScale/peak)
+		applyGain(p.Samples, func(int) float64 { return gain }, p.Channels)
+	}
+}
+
+// TrimSilence removes leading and trailing frames in which every channel is
+// quieter than threshold (dBFS). Audio that is silent throughout is kept.
+func TrimSilence(threshold float64) Processor {
+	return func(p *PCM) {
+		limit := dbToAmplitude(threshold) * fullScale
+		loud := func(frame int) bool {
+			for _, s := range p.Samples[frame*p.Channels : (frame+1)*p.Channels] {
+				if math.Abs(float64(s)) > limit {
+					return true
+				}
+			}
+			return false
+		}
+
+		frames := p.Frames()
+		first := 0
+		for first < frames && !loud(first) {
+			first++
+		}
+		if first == frames {
+			return
+		}
+		last := frames - 1
+		for last > first && !loud(last) {
+			last--
+		}
+
+		p.Samples = p.Samples[first*p.Channels : (last+1)*p.Channels]
+	}
+}
+
+// FadeIn ramps the volume linearly from silence over the given duration
+func FadeIn(d time.Duration) Processor {
+	return func(p *PCM) {
+		n := p.framesFor(d)
+		if n == 0 {
+			return
+		}
+		head := p.Samples[:n*p.Channels]
+		applyGain(head, func(frame int) float64 { return float64(frame) / float64(n) }, p.Channels)
+	}
+}
+
+// FadeOut ramps the volume linearly down to silence over the given duration
+func FadeOut(d time.Duration) Processor {
+	return func(p *PCM) {
+		n := p.framesFor(d)
+		if n == 0 {
+			return
+		}
+		tail := p.Samples[len(p.Samples)-n*p.Channels:]
+		applyGain(tail, func(frame int) float64 { return float64(n-1-frame) / float64(n) }, p.Channels)
+	}
+}
+
+// applyGain multiplies each frame of samples by gain(frame), clipping to the
+// 16-bit range
+func applyGain(samples []int16, gain func(frame int) float64, channels int) {
+	for i, s := range samples {
+		v := math.Round(float64(s) * gain(i/channels))
+		samples[i] = int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, v)))
+	}
+}
+
+func dbToAmplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
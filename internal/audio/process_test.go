@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rms(samples []int16) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func constant(frames int, value int16) *PCM {
+	samples := make([]int16, frames)
+	for i := range samples {
+		samples[i] = value
+	}
+	return &PCM{SampleRate: 1000, Channels: 1, Samples: samples}
+}
+
+func TestNormalize(t *testing.T) {
+	pcm := constant(100, 1000)
+	Normalize(-20)(pcm)
+
+	level := 20 * math.Log10(rms(pcm.Samples)/fullScale)
+	assert.InDelta(t, -20.0, level, 0.01)
+}
+
+func TestNormalizeLimitsPeaks(t *testing.T) {
+	// One loud click in quiet audio would clip if the RMS alone set the gain
+	pcm := constant(1000, 10)
+	pcm.Samples[500] = 16000
+	Normalize(-6)(pcm)
+
+	ceiling := int16(dbToAmplitude(peakCeiling) * fullScale)
+	assert.InDelta(t, ceiling, pcm.Samples[500], 1)
+}
+
+func TestNormalizeSilence(t *testing.T) {
+	pcm := constant(10, 0)
+	Normalize(-20)(pcm)
+	assert.Equal(t, constant(10, 0), pcm)
+}
+
+func TestTrimSilence(t *testing.T) {
+	pcm := &PCM{SampleRate: 1000, Channels: 2, Samples: []int16{
+		0, 0,
+		3, -2, // below the threshold
+		0, 500,
+		200, 0,
+		1, 0,
+		0, 0,
+	}}
+	TrimSilence(-50)(pcm) // about 104 at full scale
+
+	assert.Equal(t, []int16{0, 500, 200, 0}, pcm.Samples)
+}
+
+func TestTrimSilenceKeepsSilentAudio(t *testing.T) {
+	pcm := constant(10, 0)
+	TrimSilence(-50)(pcm)
+	assert.Len(t, pcm.Samples, 10)
+}
+
+func TestFades(t *testing.T) {
+	pcm := constant(10, 1000)
+	FadeIn(4 * time.Millisecond)(pcm)
+	assert.Equal(t, []int16{0, 250, 500, 750, 1000}, pcm.Samples[:5])
+
+	pcm = constant(10, 1000)
+	FadeOut(4 * time.Millisecond)(pcm)
+	assert.Equal(t, []int16{1000, 750, 500, 250, 0}, pcm.Samples[5:])
+
+	// Fades longer than the audio cover all of it
+	pcm = constant(2, 1000)
+	FadeOut(time.Second)(pcm)
+	assert.Equal(t, []int16{500, 0}, pcm.Samples)
+}
+
+func TestChainOrder(t *testing.T) {
+	assert.Empty(t, Chain(Options{}))
+	all := Options{Normalize: true, TrimSilence: true, FadeIn: time.Millisecond, FadeOut: time.Millisecond,
+		Music: constant(1, 1)}
+	assert.True(t, all.Enabled())
+	assert.Len(t, Chain(all), 5)
+	assert.False(t, Options{TargetLevel: -20, SilenceThreshold: -50}.Enabled())
+}
+
+func TestProcess(t *testing.T) {
+	data := EncodeWAV(&PCM{SampleRate: 1000, Channels: 1, Samples: []int16{0, 0, 1000, 1000, 0}})
+
+	processed, err := Process(data, "LINEAR16", Options{TrimSilence: true, SilenceThreshold: -50})
+	require.NoError(t, err)
+	pcm, err := DecodeWAV(processed)
+	require.NoError(t, err)
+	assert.Equal(t, []int16{1000, 1000}, pcm.Samples)
+
+	unchanged, err := Process([]byte("mp3"), "MP3", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("mp3"), unchanged)
+
+	_, err = Process([]byte("mp3"), "MP3", Options{Normalize: true})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
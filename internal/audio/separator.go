@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultToneFrequency is the pitch of a separator tone when none is given
+const DefaultToneFrequency = 880.0
+
+// toneLevel is the peak amplitude of a tone, about -12 dBFS, so it stands
+// out from speech without startling
+const toneLevel = 0.25
+
+// toneRamp is the fade at each end of a tone that keeps it from clicking
+const toneRamp = 5 * time.Millisecond
+
+// Separator is the audio ConcatWith inserts between joined files: Gap of
+// silence, with a Tone of Frequency in the middle of it when Tone is set
+type Separator struct {
+	Gap       time.Duration
+	Tone      time.Duration
+	Frequency float64
+}
+
+// Duration returns the playing time of the separator
+func (s Separator) Duration() time.Duration {
+	return s.Gap + s.Tone
+}
+
+// validate checks that the lengths are not negative and fills in the
+// default frequency
+func (s *Separator) validate() error {
+	if s.Gap < 0 || s.Tone < 0 {
+		return fmt.Errorf("gap cannot be negative")
+	}
+	if s.Frequency < 0 {
+		return fmt.Errorf("tone frequency cannot be negative")
+	}
+	if s.Frequency == 0 {
+		s.Frequency = DefaultToneFrequency
+	}
+	return nil
+}
+
+// Tone returns d of a sine tone at frequency Hz, faded in and out
+func Tone(sampleRate, channels int, frequency float64, d time.Duration) *PCM {
+	frames := int(d * time.Duration(sampleRate) / time.Second)
+	tone := &PCM{SampleRate: sampleRate, Channels: channels, Samples: make([]int16, frames*channels)}
+	for frame := 0; frame < frames; frame++ {
+		v := int16(math.Round(toneLevel * math.MaxInt16 * math.Sin(2*math.Pi*frequency*float64(frame)/float64(sampleRate))))
+		for c := 0; c < channels; c++ {
+			tone.Samples[frame*channels+c] = v
+		}
+	}
+	ramp := min(toneRamp, d/2)
+	FadeIn(ramp)(tone)
+	FadeOut(ramp)(tone)
+	return tone
+}
+
+// separator returns the sample data of sep in the file's encoding. Tones
+// need 16-bit PCM and a frequency below the Nyquist limit.
+func (w *wavFile) separator(sep Separator) ([]byte, error) {
+	if sep.Tone <= 0 {
+		return w.silence(sep.Gap), nil
+	}
+	if w.format != wavFormatPCM || w.bits != wavBitsPerSample {
+		return nil, fmt.Errorf("%w: tones need 16-bit PCM audio, not %s", ErrUnsupportedFormat, w.describe())
+	}
+	if sep.Frequency >= float64(w.sampleRate)/2 {
+		return nil, fmt.Errorf("tone frequency %g Hz is too high for %d Hz audio", sep.Frequency, w.sampleRate)
+	}
+
+	tone := Tone(w.sampleRate, w.channels, sep.Frequency, sep.Tone)
+	data := w.silence(sep.Gap / 2)
+	for _, s := range tone.Samples {
+		data = binary.LittleEndian.AppendUint16(data, uint16(s))
+	}
+	return append(data, w.silence(sep.Gap-sep.Gap/2)...), nil
+}
@@ -0,0 +1,69 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTone(t *testing.T) {
+	tone := Tone(8000, 2, 1000, 100*time.Millisecond)
+	assert.Equal(t, 800, tone.Frames())
+	assert.Equal(t, 100*time.Millisecond, tone.Duration())
+
+	// Faded at both ends, at full level in between, the same on each channel
+	assert.Zero(t, tone.Samples[0])
+	assert.Zero(t, tone.Samples[len(tone.Samples)-1])
+	peak := int16(0)
+	for frame := 0; frame < tone.Frames(); frame++ {
+		require.Equal(t, tone.Samples[frame*2], tone.Samples[frame*2+1])
+		peak = max(peak, tone.Samples[frame*2])
+	}
+	assert.InDelta(t, toneLevel*math.MaxInt16, float64(peak), 1)
+}
+
+func TestConcatWithTone(t *testing.T) {
+	first := EncodeWAV(&PCM{SampleRate: 8000, Channels: 1, Samples: []int16{1, 2}})
+	second := EncodeWAV(&PCM{SampleRate: 8000, Channels: 1, Samples: []int16{3}})
+	sep := Separator{Gap: time.Millisecond, Tone: 10 * time.Millisecond, Frequency: 1000}
+
+	joined, err := ConcatWith([][]byte{first, second}, sep)
+	require.NoError(t, err)
+	pcm, err := DecodeWAV(joined)
+	require.NoError(t, err)
+
+	// Half the gap, the tone, and the other half of the gap
+	require.Len(t, pcm.Samples, 2+4+80+4+1)
+	assert.Equal(t, []int16{1, 2, 0, 0, 0, 0}, pcm.Samples[:6])
+	assert.Equal(t, Tone(8000, 1, 1000, 10*time.Millisecond).Samples, pcm.Samples[6:86])
+	assert.Equal(t, []int16{0, 0, 0, 0, 3}, pcm.Samples[86:])
+	assert.Equal(t, 11*time.Millisecond, sep.Duration())
+}
+
+func TestConcatWithToneErrors(t *testing.T) {
+	wav := EncodeWAV(&PCM{SampleRate: 8000, Channels: 1, Samples: []int16{1}})
+	tone := Separator{Tone: 10 * time.Millisecond}
+
+	_, err := ConcatWith([][]byte{wav, wav}, Separator{Tone: -time.Second})
+	assert.ErrorContains(t, err, "negative")
+	_, err = ConcatWith([][]byte{wav, wav}, Separator{Tone: time.Millisecond, Frequency: 4000})
+	assert.ErrorContains(t, err, "too high")
+
+	mp3 := []byte{0xFF, 0xFB, 1, 1}
+	_, err = ConcatWith([][]byte{mp3, mp3}, tone)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	muLaw := &wavFile{format: wavFormatMULaw, channels: 1, sampleRate: 8000, blockAlign: 1, bits: 8}
+	_, err = muLaw.separator(tone)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	// Without a frequency, tones play at the default pitch
+	joined, err := ConcatWith([][]byte{wav, wav}, tone)
+	require.NoError(t, err)
+	pcm, err := DecodeWAV(joined)
+	require.NoError(t, err)
+	assert.Equal(t, Tone(8000, 1, DefaultToneFrequency, 10*time.Millisecond).Samples, pcm.Samples[1:81])
+}
@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// WAV format constants
+const (
+	wavHeaderSize    = 44
+	wavFormatPCM     = 1
+	wavFormatALaw    = 6
+	wavFormatMULaw   = 7
+	wavBitsPerSample = 16
+	bytesPerSample   = wavBitsPerSample / 8
+)
+
+// ErrUnsupportedFormat is returned for audio that cannot be decoded
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// PCM is 16-bit linear PCM audio with interleaved channels
+type PCM struct {
+	SampleRate int
+	Channels   int
+	Samples    []int16
+}
+
+// Frames returns the number of sample frames, one sample per channel each
+func (p *PCM) Frames() int {
+	if p.Channels == 0 {
+		return 0
+	}
+	return len(p.Samples) / p.Channels
+}
+
+// Duration returns the playing time of the audio
+func (p *PCM) Duration() time.Duration {
+	if p.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(p.Frames()) * time.Second / time.Duration(p.SampleRate)
+}
+
+// framesFor converts a duration to a frame count, capped at the audio length
+func (p *PCM) framesFor(d time.Duration) int {
+	frames := int(d * time.Duration(p.SampleRate) / time.Second)
+	return min(frames, p.Frames())
+}
+
+// wavFile holds the format and sample data of a WAV file without decoding
+// the samples
+type wavFile struct {
+	format     uint16
+	channels   int
+	sampleRate int
+	blockAlign int
+	bits       int
+	fmtChunk   []byte
+	data       []byte
+}
+
+// parseWAV reads the "fmt " and "data" chunks of a RIFF/WAVE file. Other
+// chunks are ignored.
+func parseWAV(data []byte) (*wavFile, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%w: not a WAV file", ErrUnsupportedFormat)
+	}
+
+	wav := &wavFile{}
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := data[offset+8 : min(offset+8+size, len(data))]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, fmt.Errorf("invalid WAV fmt chunk")
+			}
+			wav.fmtChunk = body
+			wav.format = binary.LittleEndian.Uint16(body[0:2])
+			wav.channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			wav.sampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			wav.blockAlign = int(binary.LittleEndian.Uint16(body[12:14]))
+			wav.bits = int(binary.LittleEndian.Uint16(body[14:16]))
+		case "data":
+			wav.data = body
+		}
+
+		// Chunks are padded to an even size
+		offset += 8 + size + size%2
+	}
+
+	if wav.fmtChunk == nil || wav.data == nil {
+		return nil, fmt.Errorf("invalid WAV file: missing fmt or data chunk")
+	}
+	if wav.channels == 0 || wav.sampleRate == 0 || wav.blockAlign == 0 {
+		return nil, fmt.Errorf("invalid WAV file: %d channels at %d Hz", wav.channels, wav.sampleRate)
+	}
+	return wav, nil
+}
+
+// bytes encodes the file with only its fmt and data chunks
+func (w *wavFile) bytes() []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, 20+len(w.fmtChunk)+len(w.data)+2))
+	buf.WriteString("RIFF")
+	writeLE(buf, uint32(4+8+len(w.fmtChunk)+len(w.fmtChunk)%2+8+len(w.data)+len(w.data)%2))
+	buf.WriteString("WAVE")
+	writeChunk(buf, "fmt ", w.fmtChunk)
+	writeChunk(buf, "data", w.data)
+	return buf.Bytes()
+}
+
+// DecodeWAV decodes a RIFF/WAVE file holding 16-bit PCM audio
+func DecodeWAV(data []byte) (*PCM, error) {
+	wav, err := parseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if wav.format != wavFormatPCM || wav.bits != wavBitsPerSample {
+		return nil, fmt.Errorf("%w: WAV encoding %d with %d bits per sample (only 16-bit PCM is supported)",
+			ErrUnsupportedFormat, wav.format, wav.bits)
+	}
+
+	pcm := &PCM{
+		SampleRate: wav.sampleRate,
+		Channels:   wav.channels,
+		Samples:    make([]int16, len(wav.data)/bytesPerSample),
+	}
+	for i := range pcm.Samples {
+		pcm.Samples[i] = int16(binary.LittleEndian.Uint16(wav.data[i*bytesPerSample:]))
+	}
+	return pcm, nil
+}
+
+// EncodeWAV encodes audio as a canonical 44-byte header RIFF/WAVE file
+func EncodeWAV(p *PCM) []byte {
+	dataSize := len(p.Samples) * bytesPerSample
+
+	buf := bytes.NewBuffer(make([]byte, 0, wavHeaderSize+dataSize))
+	buf.Write(output.WAVHeader(dataSize, p.SampleRate, p.Channels))
+	writeLE(buf, p.Samples)
+	return buf.Bytes()
+}
+
+func writeChunk(buf *bytes.Buffer, id string, body []byte) {
+	buf.WriteString(id)
+	writeLE(buf, uint32(len(body)))
+	buf.Write(body)
+	if len(body)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func writeLE(buf *bytes.Buffer, v interface{}) {
+	// Writes to a bytes.Buffer cannot fail
+	_ = binary.Write(buf, binary.LittleEndian, v)
+}
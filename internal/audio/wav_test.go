@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAVRoundTrip(t *testing.T) {
+	pcm := &PCM{SampleRate: 24000, Channels: 2, Samples: []int16{0, 1, -1, 32767, -32768, 100}}
+
+	data := EncodeWAV(pcm)
+	assert.Len(t, data, wavHeaderSize+len(pcm.Samples)*bytesPerSample)
+
+	decoded, err := DecodeWAV(data)
+	require.NoError(t, err)
+	assert.Equal(t, pcm, decoded)
+	assert.Equal(t, 3, decoded.Frames())
+}
+
+func TestDecodeWAVSkipsUnknownChunks(t *testing.T) {
+	data := EncodeWAV(&PCM{SampleRate: 8000, Channels: 1, Samples: []int16{1, 2, 3}})
+
+	// Insert an odd-sized LIST chunk, padded to an even length, before "data"
+	extra := append([]byte("LIST"), 3, 0, 0, 0, 'a', 'b', 'c', 0)
+	withList := append(append(append([]byte{}, data[:36]...), extra...), data[36:]...)
+	binary.LittleEndian.PutUint32(withList[4:8], uint32(len(withList)-8))
+
+	decoded, err := DecodeWAV(withList)
+	require.NoError(t, err)
+	assert.Equal(t, []int16{1, 2, 3}, decoded.Samples)
+}
+
+func TestDecodeWAVErrors(t *testing.T) {
+	_, err := DecodeWAV([]byte("ID3\x04 not a wav file"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	mulaw := EncodeWAV(&PCM{SampleRate: 8000, Channels: 1, Samples: []int16{1}})
+	binary.LittleEndian.PutUint16(mulaw[20:22], 7)
+	_, err = DecodeWAV(mulaw)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = DecodeWAV(EncodeWAV(&PCM{SampleRate: 8000, Channels: 1})[:36])
+	assert.ErrorContains(t, err, "missing fmt or data chunk")
+}
+
+func TestPCMDuration(t *testing.T) {
+	pcm := &PCM{SampleRate: 1000, Channels: 2, Samples: make([]int16, 3000)}
+	assert.Equal(t, 1500*time.Millisecond, pcm.Duration())
+	assert.Equal(t, 500, pcm.framesFor(500*time.Millisecond))
+	assert.Equal(t, 1500, pcm.framesFor(time.Hour))
+}
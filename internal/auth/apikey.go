@@ -13,8 +13,10 @@ import (
 
 // APIKeyProvider implements authentication using Google Cloud API keys
 type APIKeyProvider struct {
-	apiKey string
-	client *texttospeech.Client
+	apiKey  string
+	network NetworkConfig
+	options []option.ClientOption
+	client  *texttospeech.Client
 }
 
 // NewAPIKeyProvider creates a new API key authentication provider
@@ -40,7 +42,11 @@ func (p *APIKeyProvider) GetClient(ctx context.Context) (*texttospeech.Client, e
 	}
 
 	// Create client with API key
-	client, err := texttospeech.NewClient(ctx, option.WithAPIKey(p.apiKey))
+	opts, err := p.network.clientOptions(option.WithAPIKey(p.apiKey), p.options...)
+	if err != nil {
+		return nil, err
+	}
+	client, err := texttospeech.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TTS client with API key: %w", err)
 	}
@@ -112,7 +118,11 @@ func (p *APIKeyProvider) ValidateAPIKey(ctx context.Context) error {
 	}
 
 	// Create a temporary client to test the API key
-	client, err := texttospeech.NewClient(ctx, option.WithAPIKey(p.apiKey))
+	opts, err := p.network.clientOptions(option.WithAPIKey(p.apiKey), p.options...)
+	if err != nil {
+		return err
+	}
+	client, err := texttospeech.NewClient(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create client for validation: %w", err)
 	}
@@ -123,7 +133,7 @@ func (p *APIKeyProvider) ValidateAPIKey(ctx context.Context) error {
 	req := &texttospeechpb.ListVoicesRequest{}
 	_, err = client.ListVoices(ctx, req)
 	if err != nil {
-		return fmt.Errorf("API key validation failed: %w", err)
+		return fmt.Errorf("API key validation failed: %w", ExplainAPIKeyError(err))
 	}
 
 	return nil
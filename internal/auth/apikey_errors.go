@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reasons Google reports in the ErrorInfo of a request made with a rejected
+// API key
+const (
+	ReasonKeyInvalid        = "API_KEY_INVALID"
+	ReasonKeyExpired        = "API_KEY_EXPIRED"
+	ReasonReferrerBlocked   = "API_KEY_HTTP_REFERRER_BLOCKED"
+	ReasonIPAddressBlocked  = "API_KEY_IP_ADDRESS_BLOCKED"
+	ReasonAndroidAppBlocked = "API_KEY_ANDROID_APP_BLOCKED"
+	ReasonIOSAppBlocked     = "API_KEY_IOS_APP_BLOCKED"
+	ReasonServiceBlocked    = "API_KEY_SERVICE_BLOCKED"
+	ReasonServiceDisabled   = "SERVICE_DISABLED"
+	ReasonBillingDisabled   = "BILLING_DISABLED"
+)
+
+const (
+	// credentialsConsoleURL is the Cloud console page managing API keys
+	credentialsConsoleURL = "https://console.cloud.google.com/apis/credentials"
+	// textToSpeechService is the service name of the Text-to-Speech API
+	textToSpeechService = "texttospeech.googleapis.com"
+)
+
+// APIKeyError explains why the API rejected an API key and how to fix it
+type APIKeyError struct {
+	// Reason is the ErrorInfo reason, e.g. API_KEY_HTTP_REFERRER_BLOCKED
+	Reason  string
+	Message string
+	Hint    string
+	Err     error
+}
+
+func (e *APIKeyError) Error() string {
+	return fmt.Sprintf("API key rejected (%s): %s; %s", e.Reason, e.Message, e.Hint)
+}
+
+func (e *APIKeyError) Unwrap() error {
+	return e.Err
+}
+
+// ExplainAPIKeyError turns an API error caused by the API key into an
+// APIKeyError, using the reason in the error details or, for older
+// responses without details, the status message. Other errors are
+// returned unchanged.
+func ExplainAPIKeyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var keyErr *APIKeyError
+	if errors.As(err, &keyErr) {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	reason, metadata := errorInfo(st)
+	if reason == "" {
+		reason = reasonFromMessage(st)
+	}
+
+	keyErr = &APIKeyError{Reason: reason, Err: err}
+	switch reason {
+	case ReasonKeyInvalid:
+		keyErr.Message = "the key does not exist or was deleted"
+		keyErr.Hint = "check that the whole key was copied, or create a new one at " + credentialsConsoleURL
+	case ReasonKeyExpired:
+		keyErr.Message = "the key has expired"
+		keyErr.Hint = "renew it or create a new one at " + credentialsConsoleURL
+	case ReasonReferrerBlocked:
+		keyErr.Message = "the key is restricted to HTTP referrers (websites), and the CLI sends none"
+		keyErr.Hint = "set the key's application restrictions to None or IP addresses at " + credentialsConsoleURL
+	case ReasonIPAddressBlocked:
+		keyErr.Message = "the key is restricted to IP addresses that do not include this machine's"
+		keyErr.Hint = "add this machine's public IP address to the key's restrictions at " + credentialsConsoleURL
+	case ReasonAndroidAppBlocked, ReasonIOSAppBlocked:
+		keyErr.Message = "the key is restricted to mobile apps"
+		keyErr.Hint = "use a key without application restrictions, or restricted to IP addresses"
+	case ReasonServiceBlocked:
+		keyErr.Message = "the key's API restrictions do not include the Cloud Text-to-Speech API"
+		keyErr.Hint = "add Cloud Text-to-Speech API to the key's API restrictions at " + credentialsConsoleURL
+	case ReasonServiceDisabled:
+		keyErr.Message = "the Cloud Text-to-Speech API is not enabled in the key's project"
+		keyErr.Hint = "enable it at " + activationURL(metadata)
+	case ReasonBillingDisabled:
+		keyErr.Message = "billing is not enabled in the key's project"
+		keyErr.Hint = "enable billing for the project at https://console.cloud.google.com/billing"
+	default:
+		return err
+	}
+	return keyErr
+}
+
+// errorInfo returns the reason and metadata of the ErrorInfo detail of st
+func errorInfo(st *status.Status) (string, map[string]string) {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason(), info.GetMetadata()
+		}
+	}
+	return "", nil
+}
+
+// reasonFromMessage recognizes key rejections in a status without details
+func reasonFromMessage(st *status.Status) string {
+	message := strings.ToLower(st.Message())
+	switch {
+	case strings.Contains(message, "api key not valid"):
+		return ReasonKeyInvalid
+	case strings.Contains(message, "api key expired"):
+		return ReasonKeyExpired
+	case st.Code() != codes.PermissionDenied:
+		return ""
+	case strings.Contains(message, "referer") || strings.Contains(message, "referrer"):
+		return ReasonReferrerBlocked
+	case strings.Contains(message, "ip address"):
+		return ReasonIPAddressBlocked
+	case strings.Contains(message, "has not been used in project") || strings.Contains(message, "is disabled"):
+		return ReasonServiceDisabled
+	case strings.Contains(message, "are blocked"):
+		return ReasonServiceBlocked
+	default:
+		return ""
+	}
+}
+
+// activationURL returns the page enabling the API for the key's project
+func activationURL(metadata map[string]string) string {
+	if url := metadata["activationUrl"]; url != "" {
+		return url
+	}
+	url := "https://console.cloud.google.com/apis/library/" + textToSpeechService
+	if project := strings.TrimPrefix(metadata["consumer"], "projects/"); project != "" {
+		url += "?project=" + project
+	}
+	return url
+}
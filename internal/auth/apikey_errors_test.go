@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// keyRejection builds an API error with an ErrorInfo detail like Google sends
+func keyRejection(t *testing.T, code codes.Code, reason string, metadata map[string]string) error {
+	t.Helper()
+	st, err := status.New(code, "request rejected").WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   "googleapis.com",
+		Metadata: metadata,
+	})
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestExplainAPIKeyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+		wantText   string
+	}{
+		{
+			"referrer restriction",
+			keyRejection(t, codes.PermissionDenied, ReasonReferrerBlocked, nil),
+			ReasonReferrerBlocked,
+			"restricted to HTTP referrers",
+		},
+		{
+			"IP restriction",
+			keyRejection(t, codes.PermissionDenied, ReasonIPAddressBlocked, nil),
+			ReasonIPAddressBlocked,
+			"public IP address",
+		},
+		{
+			"API restriction",
+			keyRejection(t, codes.PermissionDenied, ReasonServiceBlocked, nil),
+			ReasonServiceBlocked,
+			"API restrictions do not include the Cloud Text-to-Speech API",
+		},
+		{
+			"disabled service with activation URL",
+			keyRejection(t, codes.PermissionDenied, ReasonServiceDisabled,
+				map[string]string{"activationUrl": "https://console.example/enable"}),
+			ReasonServiceDisabled,
+			"enable it at https://console.example/enable",
+		},
+		{
+			"disabled service with project",
+			keyRejection(t, codes.PermissionDenied, ReasonServiceDisabled, map[string]string{"consumer": "projects/42"}),
+			ReasonServiceDisabled,
+			"texttospeech.googleapis.com?project=42",
+		},
+		{
+			"invalid key from message",
+			status.Error(codes.InvalidArgument, "API key not valid. Please pass a valid API key."),
+			ReasonKeyInvalid,
+			"does not exist",
+		},
+		{
+			"referrer from message",
+			status.Error(codes.PermissionDenied, "Requests from referer <empty> are blocked."),
+			ReasonReferrerBlocked,
+			"sends none",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExplainAPIKeyError(fmt.Errorf("failed to list voices: %w", tt.err))
+
+			var keyErr *APIKeyError
+			require.True(t, errors.As(err, &keyErr), "got %v", err)
+			assert.Equal(t, tt.wantReason, keyErr.Reason)
+			assert.Contains(t, err.Error(), tt.wantText)
+			assert.Equal(t, status.Code(tt.err), status.Code(err), "the API status stays in the chain")
+		})
+	}
+}
+
+func TestExplainAPIKeyError_Unrelated(t *testing.T) {
+	quota := status.Error(codes.ResourceExhausted, "quota exceeded")
+	assert.Equal(t, quota, ExplainAPIKeyError(quota))
+
+	plain := errors.New("network down")
+	assert.Equal(t, plain, ExplainAPIKeyError(plain))
+	assert.NoError(t, ExplainAPIKeyError(nil))
+
+	denied := status.Error(codes.PermissionDenied, "caller lacks permission")
+	assert.Equal(t, denied, ExplainAPIKeyError(denied))
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// HTTPClient returns an HTTP client authorizing requests to other Google
+// Cloud APIs, such as Cloud Storage, with the given scopes. Service accounts
+// and OAuth2 reuse the configured credentials. An API key cannot authorize
+// such requests, so application default credentials are used instead.
+// Requests go through the configured proxy and CA bundle.
+func (am *AuthManager) HTTPClient(ctx context.Context, scopes ...string) (*http.Client, error) {
+	method, err := am.SelectAuthMethod()
+	if err != nil {
+		return nil, fmt.Errorf("failed to select auth method: %w", err)
+	}
+
+	// Token requests go through the configured proxy and CA bundle too
+	ctx, err = am.config.Network.oauth2Context(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var source oauth2.TokenSource
+	switch provider := am.providers[method].(type) {
+	case *ServiceAccountProvider:
+		if !provider.IsConfigured() {
+			return nil, fmt.Errorf("service account file is not configured or invalid")
+		}
+		data, err := os.ReadFile(provider.serviceAccountFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account file: %w", err)
+		}
+		jwt, err := google.JWTConfigFromJSON(data, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse service account file: %w", err)
+		}
+		source = jwt.TokenSource(ctx)
+	case *OAuth2Provider:
+		if !provider.IsConfigured() {
+			return nil, fmt.Errorf("OAuth2 is not configured")
+		}
+		token, err := provider.getValidToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get valid token: %w", err)
+		}
+		source = provider.config.TokenSource(ctx, token)
+	default:
+		creds, err := google.FindDefaultCredentials(ctx, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("an API key cannot authorize this request and no application default "+
+				"credentials were found (log in with a service account or OAuth2): %w", err)
+		}
+		source = creds.TokenSource
+	}
+
+	transport, err := am.config.Network.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: &oauth2.Transport{Source: source, Base: transport}}, nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSigningServiceAccount writes a service account file with a usable
+// private key whose tokens are issued by tokenURL
+func writeSigningServiceAccount(t *testing.T, tokenURL string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "test-project",
+		"private_key_id": "key123",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "test@test-project.iam.gserviceaccount.com",
+		"client_id":      "123456789",
+		"token_uri":      tokenURL,
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestAuthManager_HTTPClient_ServiceAccount(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"access_token":"storage-token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	manager := NewAuthManager(AuthConfig{
+		Method:             AuthMethodServiceAccount,
+		ServiceAccountFile: writeSigningServiceAccount(t, tokenServer.URL),
+	})
+	client, err := manager.HTTPClient(context.Background(), "https://www.googleapis.com/auth/devstorage.read_write")
+	require.NoError(t, err)
+
+	resp, err := client.Get(api.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer storage-token", string(body))
+}
+
+func TestAuthManager_HTTPClient_MissingServiceAccount(t *testing.T) {
+	manager := NewAuthManager(AuthConfig{
+		Method:             AuthMethodServiceAccount,
+		ServiceAccountFile: filepath.Join(t.TempDir(), "missing.json"),
+	})
+	_, err := manager.HTTPClient(context.Background())
+	assert.ErrorContains(t, err, "service account file is not configured")
+}
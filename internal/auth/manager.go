@@ -2,10 +2,14 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"time"
 
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"google.golang.org/api/option"
 )
 
 // AuthMethod represents the different authentication methods available
@@ -29,9 +33,13 @@ func (a AuthMethod) String() string {
 		return "serviceaccount"
 	case AuthMethodOAuth2:
 		return "oauth2"
-	default:
-		return "unknown"
 	}
+	for _, provider := range registeredProviders() {
+		if provider.method == a {
+			return provider.name
+		}
+	}
+	return "unknown"
 }
 
 // AuthConfig holds the configuration for authentication
@@ -42,6 +50,13 @@ type AuthConfig struct {
 	OAuth2ClientID     string
 	OAuth2ClientSecret string
 	OAuth2TokenFile    string
+	// EncryptToken stores the OAuth2 token file encrypted
+	EncryptToken bool
+	// RefreshWindow refreshes OAuth2 tokens this long before they expire
+	RefreshWindow time.Duration
+	Network       NetworkConfig
+	// ClientOptions are added to every TTS client created, e.g. gRPC keepalive
+	ClientOptions []option.ClientOption
 }
 
 // AuthProvider interface defines the contract for authentication providers
@@ -71,43 +86,69 @@ func NewAuthManager(config AuthConfig) *AuthManager {
 	}
 
 	// Initialize providers
-	manager.providers[AuthMethodAPIKey] = NewAPIKeyProvider(config.APIKey)
-	manager.providers[AuthMethodServiceAccount] = NewServiceAccountProvider(config.ServiceAccountFile)
-	manager.providers[AuthMethodOAuth2] = NewOAuth2Provider(config.OAuth2ClientID,
-		config.OAuth2ClientSecret, config.OAuth2TokenFile)
+	apiKeyProvider := NewAPIKeyProvider(config.APIKey)
+	apiKeyProvider.network = config.Network
+	apiKeyProvider.options = config.ClientOptions
+	serviceAccountProvider := NewServiceAccountProvider(config.ServiceAccountFile)
+	serviceAccountProvider.network = config.Network
+	serviceAccountProvider.options = config.ClientOptions
+	oauth2Provider := NewOAuth2Provider(config.OAuth2ClientID, config.OAuth2ClientSecret, config.OAuth2TokenFile)
+	oauth2Provider.network = config.Network
+	oauth2Provider.encryptToken = config.EncryptToken
+	oauth2Provider.refreshWindow = config.RefreshWindow
+	oauth2Provider.options = config.ClientOptions
+
+	manager.providers[AuthMethodAPIKey] = apiKeyProvider
+	manager.providers[AuthMethodServiceAccount] = serviceAccountProvider
+	manager.providers[AuthMethodOAuth2] = oauth2Provider
+	for _, registered := range registeredProviders() {
+		if provider := registered.factory(config); provider != nil {
+			manager.providers[registered.method] = provider
+		}
+	}
 
 	return manager
 }
 
 // SelectAuthMethod determines the best authentication method to use
-// Priority: explicit config > environment variables > auto-detection
+// Priority: explicit config > environment variables > auto-detection, with
+// registered providers tried in the order of their priority
 func (am *AuthManager) SelectAuthMethod() (AuthMethod, error) {
 	// If method is explicitly set, use it
 	if am.config.Method != AuthMethodAPIKey || am.config.APIKey != "" {
 		return am.config.Method, nil
 	}
 
-	// Check for API key in environment
-	if apiKey := os.Getenv("ASSISTANT_CLI_API_KEY"); apiKey != "" {
-		return AuthMethodAPIKey, nil
-	}
-
-	// Check for service account file
-	if serviceAccountFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); serviceAccountFile != "" {
-		if _, err := os.Stat(serviceAccountFile); err == nil {
-			return AuthMethodServiceAccount, nil
+	for _, candidate := range am.autoCandidates() {
+		if candidate.detect() {
+			return candidate.method, nil
 		}
 	}
 
-	// Check for OAuth2 configuration
-	if am.config.OAuth2ClientID != "" && am.config.OAuth2ClientSecret != "" {
-		return AuthMethodOAuth2, nil
-	}
-
 	// Default to API key method (user will need to provide key)
 	return AuthMethodAPIKey, nil
 }
 
+// detectAPIKey checks for an API key in the environment
+func (am *AuthManager) detectAPIKey() bool {
+	return os.Getenv("ASSISTANT_CLI_API_KEY") != ""
+}
+
+// detectServiceAccount checks for a service account file
+func (am *AuthManager) detectServiceAccount() bool {
+	serviceAccountFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if serviceAccountFile == "" {
+		return false
+	}
+	_, err := os.Stat(serviceAccountFile)
+	return err == nil
+}
+
+// detectOAuth2 checks for an OAuth2 configuration
+func (am *AuthManager) detectOAuth2() bool {
+	return am.config.OAuth2ClientID != "" && am.config.OAuth2ClientSecret != ""
+}
+
 // GetClient returns an authenticated Google Cloud TTS client
 func (am *AuthManager) GetClient(ctx context.Context) (*texttospeech.Client, error) {
 	if am.active == nil {
@@ -136,6 +177,29 @@ func (am *AuthManager) GetClient(ctx context.Context) (*texttospeech.Client, err
 	return am.active.GetClient(ctx)
 }
 
+// ConnectionKey identifies the credentials and network settings the
+// manager's clients connect with, so that a connection can be shared with
+// managers configured alike. The key is a hash and reveals no secrets.
+func (am *AuthManager) ConnectionKey() (string, error) {
+	method, err := am.SelectAuthMethod()
+	if err != nil {
+		return "", fmt.Errorf("failed to select auth method: %w", err)
+	}
+
+	var credentials string
+	switch provider := am.providers[method].(type) {
+	case *APIKeyProvider:
+		credentials = provider.apiKey
+	case *ServiceAccountProvider:
+		credentials = provider.serviceAccountFile
+	case *OAuth2Provider:
+		credentials = provider.clientID + "\x00" + provider.tokenFile
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%+v", method, credentials, am.config.Network)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // GetActiveMethod returns the currently active authentication method
 func (am *AuthManager) GetActiveMethod() AuthMethod {
 	if am.active != nil {
@@ -144,6 +208,24 @@ func (am *AuthManager) GetActiveMethod() AuthMethod {
 	return AuthMethodAPIKey // Default
 }
 
+// ProjectID returns the project of the credentials that would be used, or
+// an empty string when they do not name one, as with API keys and OAuth2
+func (am *AuthManager) ProjectID() string {
+	method, err := am.SelectAuthMethod()
+	if err != nil {
+		return ""
+	}
+	provider, ok := am.providers[method].(*ServiceAccountProvider)
+	if !ok {
+		return ""
+	}
+	project, err := provider.GetProjectID()
+	if err != nil {
+		return ""
+	}
+	return project
+}
+
 // IsConfigured returns true if any authentication method is properly configured
 func (am *AuthManager) IsConfigured() bool {
 	for _, provider := range am.providers {
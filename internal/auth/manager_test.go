@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,12 +24,42 @@ func TestNewAuthManager(t *testing.T) {
 
 	assert.NotNil(t, manager)
 	assert.Equal(t, config, manager.config)
-	assert.Len(t, manager.providers, 3)
+	assert.Len(t, manager.providers, 3+len(registeredProviders()))
 	assert.Contains(t, manager.providers, AuthMethodAPIKey)
 	assert.Contains(t, manager.providers, AuthMethodServiceAccount)
 	assert.Contains(t, manager.providers, AuthMethodOAuth2)
 }
 
+func TestNewAuthManager_Network(t *testing.T) {
+	network := NetworkConfig{Proxy: "http://proxy.example.com:3128", Endpoint: "texttospeech.example.com:443"}
+	manager := NewAuthManager(AuthConfig{Network: network})
+
+	assert.Equal(t, network, manager.providers[AuthMethodAPIKey].(*APIKeyProvider).network)
+	assert.Equal(t, network, manager.providers[AuthMethodServiceAccount].(*ServiceAccountProvider).network)
+	assert.Equal(t, network, manager.providers[AuthMethodOAuth2].(*OAuth2Provider).network)
+}
+
+func TestAuthManager_ConnectionKey(t *testing.T) {
+	key := func(config AuthConfig) string {
+		t.Helper()
+		k, err := NewAuthManager(config).ConnectionKey()
+		require.NoError(t, err)
+		return k
+	}
+
+	base := AuthConfig{Method: AuthMethodAPIKey, APIKey: "AIzaSyTestKey1234567890"}
+	assert.Equal(t, key(base), key(base))
+	assert.NotContains(t, key(base), base.APIKey)
+
+	otherKey := base
+	otherKey.APIKey = "AIzaSyOtherKey123456789"
+	assert.NotEqual(t, key(base), key(otherKey))
+
+	proxied := base
+	proxied.Network.Proxy = "http://proxy.example.com:3128"
+	assert.NotEqual(t, key(base), key(proxied))
+}
+
 func TestAuthMethod_String(t *testing.T) {
 	testCases := []struct {
 		method   AuthMethod
@@ -220,6 +251,19 @@ func TestAuthManager_GetActiveMethod(t *testing.T) {
 	assert.Equal(t, AuthMethodServiceAccount, method)
 }
 
+func TestAuthManager_ProjectID(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "service-account.json")
+	key := `{"type": "service_account", "project_id": "narration-prod", "private_key": "key",
+		"client_email": "tts@narration-prod.iam.gserviceaccount.com", "client_id": "1"}`
+	require.NoError(t, os.WriteFile(file, []byte(key), 0600))
+
+	manager := NewAuthManager(AuthConfig{Method: AuthMethodServiceAccount, ServiceAccountFile: file})
+	assert.Equal(t, "narration-prod", manager.ProjectID())
+
+	manager = NewAuthManager(AuthConfig{Method: AuthMethodAPIKey, APIKey: "test-api-key"})
+	assert.Empty(t, manager.ProjectID(), "API keys do not name a project")
+}
+
 func TestAuthManager_GetClient_NoCredentials(t *testing.T) {
 	// Test with empty config (no credentials)
 	config := AuthConfig{}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// NetworkConfig holds the settings for reaching Google Cloud from networks
+// that only allow egress through a proxy or a private endpoint
+type NetworkConfig struct {
+	// Proxy is the URL of an HTTP proxy tunnelling API connections with
+	// CONNECT. When empty, HTTPS_PROXY and NO_PROXY from the environment
+	// apply to HTTP requests; gRPC honors them on its own.
+	Proxy string
+	// Endpoint overrides the Text-to-Speech API endpoint as host:port, e.g.
+	// for Private Google Access or a VPC Service Controls perimeter
+	Endpoint string
+	// CABundle is a PEM file of CA certificates trusted in addition to the
+	// system ones, e.g. for a TLS-inspecting proxy
+	CABundle string
+}
+
+// ProxyURL parses the configured proxy, returning nil when none is set
+func (n NetworkConfig) ProxyURL() (*url.URL, error) {
+	if n.Proxy == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(n.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", n.Proxy, err)
+	}
+	if proxy.Scheme != "http" || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: expected http://host:port", n.Proxy)
+	}
+	return proxy, nil
+}
+
+// CertPool returns the system CA certificates plus those of the configured
+// bundle, or nil when no bundle is set
+func (n NetworkConfig) CertPool() (*x509.CertPool, error) {
+	if n.CABundle == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(n.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", n.CABundle)
+	}
+	return pool, nil
+}
+
+// ClientOptions returns the options applying the settings to a TTS client,
+// to be passed after the credentials option
+func (n NetworkConfig) ClientOptions() ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	if n.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(n.Endpoint))
+	}
+
+	pool, err := n.CertPool()
+	if err != nil {
+		return nil, err
+	}
+	if pool != nil {
+		creds := credentials.NewTLS(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithTransportCredentials(creds)))
+	}
+
+	proxy, err := n.ProxyURL()
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithContextDialer(connectDialer(proxy))))
+	}
+	return opts, nil
+}
+
+// clientOptions returns the credentials option followed by the options of
+// the settings and any extra options
+func (n NetworkConfig) clientOptions(creds option.ClientOption,
+	extra ...option.ClientOption) ([]option.ClientOption, error) {
+	opts, err := n.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]option.ClientOption{creds}, opts...)
+	return append(opts, extra...), nil
+}
+
+// Transport returns an HTTP transport applying the settings, for requests
+// made outside the TTS client such as OAuth2 token exchanges
+func (n NetworkConfig) Transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := n.ProxyURL()
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	pool, err := n.CertPool()
+	if err != nil {
+		return nil, err
+	}
+	if pool != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return transport, nil
+}
+
+// oauth2Context returns ctx carrying an HTTP client that applies the
+// settings to the OAuth2 token requests made with it
+func (n NetworkConfig) oauth2Context(ctx context.Context) (context.Context, error) {
+	if n.Proxy == "" && n.CABundle == "" {
+		return ctx, nil
+	}
+	transport, err := n.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport}), nil
+}
+
+// connectDialer returns a gRPC dialer tunnelling connections through an
+// HTTP proxy with CONNECT
+func connectDialer(proxy *url.URL) func(ctx context.Context, addr string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		proxyAddr := proxy.Host
+		if proxy.Port() == "" {
+			proxyAddr = net.JoinHostPort(proxy.Hostname(), "80")
+		}
+
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyAddr, err)
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		tunnel, err := openTunnel(conn, proxy, addr)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		_ = conn.SetDeadline(time.Time{})
+		return tunnel, nil
+	}
+}
+
+// openTunnel sends a CONNECT request for addr over conn and waits for the
+// proxy to accept it
+func openTunnel(conn net.Conn, proxy *url.URL, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		userinfo := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+userinfo)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", addr, resp.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read ahead while
+// reading the proxy response
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads the buffered bytes before those of the connection
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
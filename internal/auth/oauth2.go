@@ -3,7 +3,9 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -12,6 +14,7 @@ import (
 
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	texttospeechpb "cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
@@ -22,9 +25,30 @@ type OAuth2Provider struct {
 	clientID     string
 	clientSecret string
 	tokenFile    string
-	config       *oauth2.Config
-	token        *oauth2.Token
-	client       *texttospeech.Client
+	// encryptToken stores the token file encrypted, as it is when it was
+	// read encrypted
+	encryptToken bool
+	// refreshWindow refreshes the token this long before it expires
+	refreshWindow time.Duration
+	network       NetworkConfig
+	options       []option.ClientOption
+	config        *oauth2.Config
+	token         *oauth2.Token
+	client        *texttospeech.Client
+}
+
+// TokenFile returns the OAuth2 token file in use: tokenFile when set,
+// otherwise ASSISTANT_CLI_OAUTH2_TOKEN_FILE or the default location in the
+// home directory
+func TokenFile(tokenFile string) string {
+	if tokenFile != "" {
+		return tokenFile
+	}
+	if tokenFile = os.Getenv("ASSISTANT_CLI_OAUTH2_TOKEN_FILE"); tokenFile != "" {
+		return tokenFile
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".assistant-cli-oauth2-token.json")
 }
 
 // NewOAuth2Provider creates a new OAuth2 authentication provider
@@ -36,19 +60,11 @@ func NewOAuth2Provider(clientID, clientSecret, tokenFile string) *OAuth2Provider
 	if clientSecret == "" {
 		clientSecret = os.Getenv("ASSISTANT_CLI_OAUTH2_CLIENT_SECRET")
 	}
-	if tokenFile == "" {
-		tokenFile = os.Getenv("ASSISTANT_CLI_OAUTH2_TOKEN_FILE")
-		if tokenFile == "" {
-			// Default token file location
-			home, _ := os.UserHomeDir()
-			tokenFile = filepath.Join(home, ".assistant-cli-oauth2-token.json")
-		}
-	}
 
 	provider := &OAuth2Provider{
 		clientID:     clientID,
 		clientSecret: clientSecret,
-		tokenFile:    tokenFile,
+		tokenFile:    TokenFile(tokenFile),
 	}
 
 	if provider.isOAuth2Configured() {
@@ -76,17 +92,26 @@ func (p *OAuth2Provider) GetClient(ctx context.Context) (*texttospeech.Client, e
 		return nil, fmt.Errorf("OAuth2 is not configured")
 	}
 
+	// Token requests go through the configured proxy and CA bundle too
+	ctx, err := p.network.oauth2Context(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get valid token
 	token, err := p.getValidToken(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get valid token: %w", err)
 	}
 
-	// Create HTTP client with OAuth2 token
-	httpClient := p.config.Client(ctx, token)
-
-	// Create TTS client with OAuth2 HTTP client
-	client, err := texttospeech.NewClient(ctx, option.WithHTTPClient(httpClient))
+	// Create TTS client with a token source refreshing the token ahead of
+	// its expiry and saving it for other processes
+	tokenSource := option.WithTokenSource(&refreshingTokenSource{ctx: ctx, provider: p, token: token})
+	opts, err := p.network.clientOptions(tokenSource, p.options...)
+	if err != nil {
+		return nil, err
+	}
+	client, err := texttospeech.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TTS client with OAuth2: %w", err)
 	}
@@ -119,9 +144,8 @@ func (p *OAuth2Provider) Authenticate(ctx context.Context) error {
 
 	// If token exists but is expired, try to refresh it
 	if p.token != nil && !p.token.Valid() {
-		if refreshed, err := p.refreshToken(ctx); err == nil {
-			p.token = refreshed
-			return p.saveToken()
+		if err := p.refreshLocked(ctx); err == nil || errors.Is(err, errLocked) {
+			return err
 		}
 	}
 
@@ -152,6 +176,12 @@ func (p *OAuth2Provider) loadToken() error {
 	if err != nil {
 		return err
 	}
+	if secrets.IsEncrypted(string(data)) {
+		if data, err = decryptToken(data); err != nil {
+			return err
+		}
+		p.encryptToken = true
+	}
 
 	token := &oauth2.Token{}
 	if err := json.Unmarshal(data, token); err != nil {
@@ -178,8 +208,39 @@ func (p *OAuth2Provider) saveToken() error {
 	if err != nil {
 		return err
 	}
+	if p.encryptToken {
+		if data, err = encryptToken(data); err != nil {
+			return err
+		}
+	}
+
+	return writeTokenFile(p.tokenFile, data)
+}
 
-	return os.WriteFile(p.tokenFile, data, 0600)
+// encryptToken seals token file data with the secrets passphrase
+func encryptToken(data []byte) ([]byte, error) {
+	passphrase, err := secrets.Passphrase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	encrypted, err := secrets.Encrypt(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt token: %w", err)
+	}
+	return []byte(encrypted + "\n"), nil
+}
+
+// decryptToken opens token file data sealed by encryptToken
+func decryptToken(data []byte) ([]byte, error) {
+	passphrase, err := secrets.Passphrase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	plaintext, err := secrets.Decrypt(string(data), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	return plaintext, nil
 }
 
 // getValidToken returns a valid OAuth2 token, refreshing if necessary
@@ -188,22 +249,58 @@ func (p *OAuth2Provider) getValidToken(ctx context.Context) (*oauth2.Token, erro
 		return nil, fmt.Errorf("failed to load token: %w", err)
 	}
 
-	if p.token.Valid() {
+	if !p.needsRefresh(p.token) {
 		return p.token, nil
 	}
 
-	// Try to refresh the token
-	refreshed, err := p.refreshToken(ctx)
+	if err := p.refreshLocked(ctx); err != nil {
+		if p.token.Valid() {
+			// Not expired yet: the next request tries again
+			slog.Warn("OAuth2 token refresh failed, using the current token until it expires",
+				"expires_in", time.Until(p.token.Expiry).Round(time.Second), "error", err)
+			return p.token, nil
+		}
+		return nil, err
+	}
+	return p.token, nil
+}
+
+// refreshLocked refreshes the token and saves it while holding the token
+// file lock, so that concurrent processes do not refresh at once and
+// clobber each other's token file. A token another process refreshed while
+// this one waited for the lock is used as is.
+func (p *OAuth2Provider) refreshLocked(ctx context.Context) error {
+	release, err := lockTokenFile(ctx, p.tokenFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to refresh token: %w", err)
+		return err
+	}
+	defer release()
+
+	previous := p.token
+	p.token = nil
+	if err := p.loadToken(); err == nil && !p.needsRefresh(p.token) {
+		slog.Debug("using OAuth2 token refreshed by another process", "expires_in",
+			time.Until(p.token.Expiry).Round(time.Second))
+		return nil
+	}
+	if p.token == nil {
+		p.token = previous
 	}
 
+	start := time.Now()
+	slog.Debug("refreshing OAuth2 token", "expires_in", time.Until(p.token.Expiry).Round(time.Second))
+	refreshed, err := p.refreshToken(ctx)
+	if err != nil {
+		slog.Warn("OAuth2 token refresh failed", "elapsed", time.Since(start), "error", err)
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
 	p.token = refreshed
+	slog.Info("refreshed OAuth2 token", "elapsed", time.Since(start),
+		"expires_in", time.Until(refreshed.Expiry).Round(time.Second))
 	if err := p.saveToken(); err != nil {
-		return nil, fmt.Errorf("failed to save refreshed token: %w", err)
+		return fmt.Errorf("failed to save refreshed token: %w", err)
 	}
-
-	return p.token, nil
+	return nil
 }
 
 // refreshToken attempts to refresh the OAuth2 token
@@ -212,12 +309,20 @@ func (p *OAuth2Provider) refreshToken(ctx context.Context) (*oauth2.Token, error
 		return nil, fmt.Errorf("no refresh token available")
 	}
 
-	tokenSource := p.config.TokenSource(ctx, p.token)
+	// Without an access token the token source refreshes even a token that
+	// has not expired yet
+	tokenSource := p.config.TokenSource(ctx, &oauth2.Token{RefreshToken: p.token.RefreshToken})
 	return tokenSource.Token()
 }
 
 // performOAuth2Flow performs the complete OAuth2 flow
 func (p *OAuth2Provider) performOAuth2Flow(ctx context.Context) error {
+	// The code exchange goes through the configured proxy and CA bundle
+	exchangeCtx, err := p.network.oauth2Context(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Generate authorization URL
 	state := fmt.Sprintf("state-%d", time.Now().Unix())
 	authURL := p.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
@@ -268,7 +373,7 @@ func (p *OAuth2Provider) performOAuth2Flow(ctx context.Context) error {
 	select {
 	case authCode := <-code:
 		// Exchange code for token
-		token, err := p.config.Exchange(ctx, authCode)
+		token, err := p.config.Exchange(exchangeCtx, authCode)
 		if err != nil {
 			if shutdownErr := server.Shutdown(ctx); shutdownErr != nil {
 				fmt.Fprintf(os.Stderr, "Warning: server shutdown error: %v\n", shutdownErr)
@@ -280,7 +385,13 @@ func (p *OAuth2Provider) performOAuth2Flow(ctx context.Context) error {
 		_ = server.Shutdown(ctx) // Ignore shutdown errors in cleanup
 
 		// Save token
-		if err := p.saveToken(); err != nil {
+		release, err := lockTokenFile(ctx, p.tokenFile)
+		if err != nil {
+			return err
+		}
+		err = p.saveToken()
+		release()
+		if err != nil {
 			return fmt.Errorf("failed to save token: %w", err)
 		}
 
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
@@ -131,6 +132,31 @@ func TestOAuth2Provider_saveToken(t *testing.T) {
 	assert.Equal(t, provider.token.RefreshToken, newProvider.token.RefreshToken)
 }
 
+func TestOAuth2Provider_encryptedToken(t *testing.T) {
+	t.Setenv(secrets.PassphraseEnv, "correct horse")
+	tokenFile := filepath.Join(t.TempDir(), "token.json")
+
+	provider := NewOAuth2Provider("client-id", "client-secret", tokenFile)
+	provider.encryptToken = true
+	provider.token = &oauth2.Token{AccessToken: "test-access-token", RefreshToken: "test-refresh-token"}
+	require.NoError(t, provider.saveToken())
+
+	data, err := os.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.True(t, secrets.IsEncrypted(string(data)))
+	assert.NotContains(t, string(data), "test-refresh-token")
+
+	// A token read encrypted is saved encrypted again
+	newProvider := NewOAuth2Provider("client-id", "client-secret", tokenFile)
+	require.NoError(t, newProvider.loadToken())
+	assert.Equal(t, "test-refresh-token", newProvider.token.RefreshToken)
+	assert.True(t, newProvider.encryptToken)
+
+	t.Setenv(secrets.PassphraseEnv, "battery staple")
+	err = NewOAuth2Provider("client-id", "client-secret", tokenFile).loadToken()
+	assert.ErrorIs(t, err, secrets.ErrDecrypt)
+}
+
 func TestOAuth2Provider_hasValidToken(t *testing.T) {
 	testCases := []struct {
 		name     string
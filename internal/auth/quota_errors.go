@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReasonRateLimitExceeded is the ErrorInfo reason of a request refused
+// because a per-minute quota is used up
+const ReasonRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
+
+// AccessError explains why the API refused a request for quota or
+// permission reasons, naming the project the request counted against and
+// how to raise the limit or grant access
+type AccessError struct {
+	Code codes.Code
+	// Project is the project ID or number the request counted against,
+	// empty when neither the response nor the credentials name one
+	Project string
+	// Limit describes the exceeded quota, e.g.
+	// "CharactersPerMinutePerProject (150000 per minute)"
+	Limit   string
+	Message string
+	Hint    string
+	Err     error
+}
+
+func (e *AccessError) Error() string {
+	title := "permission denied"
+	if e.Code == codes.ResourceExhausted {
+		title = "quota exceeded"
+	}
+	return fmt.Sprintf("%s: %s; %s", title, e.Message, e.Hint)
+}
+
+func (e *AccessError) Unwrap() error {
+	return e.Err
+}
+
+// ExplainAccessError turns a RESOURCE_EXHAUSTED or PERMISSION_DENIED API
+// error into an AccessError stating the exceeded quota and the project,
+// taken from the error details or else from project, the project of the
+// credentials in use. API key rejections explained by ExplainAPIKeyError
+// and other errors are returned unchanged.
+func ExplainAccessError(err error, project string) error {
+	if err == nil {
+		return nil
+	}
+	var keyErr *APIKeyError
+	var accessErr *AccessError
+	if errors.As(err, &keyErr) || errors.As(err, &accessErr) {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	reason, metadata := errorInfo(st)
+	if consumer := strings.TrimPrefix(metadata["consumer"], "projects/"); consumer != "" {
+		project = consumer
+	}
+	inProject := ""
+	if project != "" {
+		inProject = " in project " + project
+	}
+
+	accessErr = &AccessError{Code: st.Code(), Project: project, Err: err}
+	switch st.Code() {
+	case codes.ResourceExhausted:
+		accessErr.Limit = quotaLimit(st, metadata)
+		if accessErr.Limit != "" {
+			accessErr.Message = fmt.Sprintf("the request exceeded the Cloud Text-to-Speech quota %s%s",
+				accessErr.Limit, inProject)
+		} else {
+			accessErr.Message = "the Cloud Text-to-Speech quota" + inProject + " is used up"
+		}
+		accessErr.Hint = "view usage and request a higher limit at " + projectURL(quotasConsoleURL, project)
+		if reason == ReasonRateLimitExceeded || strings.Contains(accessErr.Limit, "per minute") {
+			accessErr.Hint = "wait a minute or raise tts.max_retries to back off longer, or " + accessErr.Hint
+		}
+	case codes.PermissionDenied:
+		accessErr.Message = "the credentials lack permission to use the Cloud Text-to-Speech API" + inProject
+		accessErr.Hint = "grant the account the roles/serviceusage.serviceUsageConsumer role at " +
+			projectURL(iamConsoleURL, project)
+	default:
+		return err
+	}
+	return accessErr
+}
+
+const (
+	// quotasConsoleURL is the Cloud console page listing the quotas of the
+	// Text-to-Speech API
+	quotasConsoleURL = "https://console.cloud.google.com/apis/api/" + textToSpeechService + "/quotas"
+	// iamConsoleURL is the Cloud console page granting roles in a project
+	iamConsoleURL = "https://console.cloud.google.com/iam-admin/iam"
+)
+
+// projectURL selects project on a Cloud console page
+func projectURL(url, project string) string {
+	if project == "" {
+		return url
+	}
+	return url + "?project=" + project
+}
+
+// quotaLimit describes the exceeded quota from the ErrorInfo metadata or,
+// for responses without it, the QuotaFailure detail of st
+func quotaLimit(st *status.Status, metadata map[string]string) string {
+	name := metadata["quota_limit"]
+	if name == "" {
+		name = metadata["quota_metric"]
+	}
+	if name != "" {
+		if value := metadata["quota_limit_value"]; value != "" {
+			if period := quotaPeriod(metadata["quota_unit"]); period != "" {
+				value += " " + period
+			}
+			name += " (" + value + ")"
+		}
+		return name
+	}
+
+	for _, detail := range st.Details() {
+		if failure, ok := detail.(*errdetails.QuotaFailure); ok {
+			for _, violation := range failure.GetViolations() {
+				if description := violation.GetDescription(); description != "" {
+					return description
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// quotaPeriod reads the period of a quota unit such as "1/min/{project}"
+func quotaPeriod(unit string) string {
+	switch {
+	case strings.Contains(unit, "/min/"):
+		return "per minute"
+	case strings.Contains(unit, "/d/"):
+		return "per day"
+	case strings.Contains(unit, "/s/"):
+		return "per second"
+	default:
+		return ""
+	}
+}
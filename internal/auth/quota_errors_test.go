@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExplainAccessError(t *testing.T) {
+	quotaFailure, err := status.New(codes.ResourceExhausted, "quota exceeded").WithDetails(&errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{Description: "Daily character limit reached"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		err         error
+		project     string
+		wantProject string
+		wantLimit   string
+		wantText    []string
+	}{
+		{
+			"rate limit with details",
+			keyRejection(t, codes.ResourceExhausted, ReasonRateLimitExceeded, map[string]string{
+				"consumer":          "projects/42",
+				"quota_limit":       "CharactersPerMinutePerProject",
+				"quota_limit_value": "150000",
+				"quota_unit":        "1/min/{project}",
+			}),
+			"narration-prod",
+			"42",
+			"CharactersPerMinutePerProject (150000 per minute)",
+			[]string{
+				"quota exceeded: the request exceeded the Cloud Text-to-Speech quota " +
+					"CharactersPerMinutePerProject (150000 per minute) in project 42",
+				"wait a minute",
+				"texttospeech.googleapis.com/quotas?project=42",
+			},
+		},
+		{
+			"quota failure without error info",
+			quotaFailure.Err(),
+			"narration-prod",
+			"narration-prod",
+			"Daily character limit reached",
+			[]string{"Daily character limit reached in project narration-prod", "quotas?project=narration-prod"},
+		},
+		{
+			"bare quota error without project",
+			status.Error(codes.ResourceExhausted, "quota exceeded"),
+			"",
+			"",
+			"",
+			[]string{"quota is used up; view usage", "texttospeech.googleapis.com/quotas"},
+		},
+		{
+			"permission denied",
+			status.Error(codes.PermissionDenied, "caller lacks permission"),
+			"narration-prod",
+			"narration-prod",
+			"",
+			[]string{"permission denied: the credentials lack permission", "iam?project=narration-prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExplainAccessError(fmt.Errorf("synthesis failed: %w", tt.err), tt.project)
+
+			var accessErr *AccessError
+			require.True(t, errors.As(err, &accessErr), "got %v", err)
+			assert.Equal(t, tt.wantProject, accessErr.Project)
+			assert.Equal(t, tt.wantLimit, accessErr.Limit)
+			for _, text := range tt.wantText {
+				assert.Contains(t, err.Error(), text)
+			}
+			assert.Equal(t, status.Code(tt.err), status.Code(err), "the API status stays in the chain")
+		})
+	}
+}
+
+func TestExplainAccessError_Unrelated(t *testing.T) {
+	invalid := status.Error(codes.InvalidArgument, "bad voice")
+	assert.Equal(t, invalid, ExplainAccessError(invalid, "p"))
+
+	plain := errors.New("network down")
+	assert.Equal(t, plain, ExplainAccessError(plain, "p"))
+	assert.NoError(t, ExplainAccessError(nil, "p"))
+
+	keyErr := ExplainAPIKeyError(keyRejection(t, codes.PermissionDenied, ReasonReferrerBlocked, nil))
+	assert.Equal(t, keyErr, ExplainAccessError(keyErr, "p"), "API key rejections keep their explanation")
+}
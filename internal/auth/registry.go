@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Priorities of the built-in providers in auto-selection. A registered
+// provider with a lower priority is tried before the built-in one.
+const (
+	PriorityAPIKey         = 100
+	PriorityServiceAccount = 200
+	PriorityOAuth2         = 300
+)
+
+// ProviderFactory creates a provider for an AuthManager from its
+// configuration
+type ProviderFactory func(config AuthConfig) AuthProvider
+
+// registeredProvider is a provider added with RegisterProvider
+type registeredProvider struct {
+	method   AuthMethod
+	name     string
+	priority int
+	factory  ProviderFactory
+}
+
+// firstRegisteredMethod is the method of the first registered provider,
+// leaving room for more built-in methods
+const firstRegisteredMethod AuthMethod = 100
+
+var (
+	registryMu sync.RWMutex
+	registry   []registeredProvider
+)
+
+// RegisterProvider adds a custom authentication provider, e.g. a corporate
+// token exchange, to every AuthManager created afterwards, and returns its
+// method. Call it from an init function, in a file behind a build tag if
+// need be:
+//
+//	var AuthMethodSTS = auth.RegisterProvider("sts", 50, newSTSProvider)
+//
+// The provider is used when its method is configured or given to login
+// --method NAME, and in auto-selection when it IsConfigured: providers are
+// tried in ascending priority order, with the built-in ones at
+// PriorityAPIKey, PriorityServiceAccount, and PriorityOAuth2. Registering an
+// empty name, a nil factory, or a name already in use panics.
+func RegisterProvider(name string, priority int, factory ProviderFactory) AuthMethod {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || factory == nil {
+		panic("auth: RegisterProvider needs a name and a factory")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, taken := range append(builtinMethodNames, registryNames()...) {
+		if name == taken {
+			panic(fmt.Sprintf("auth: provider %q is already registered", name))
+		}
+	}
+	method := firstRegisteredMethod + AuthMethod(len(registry))
+	registry = append(registry, registeredProvider{method: method, name: name, priority: priority, factory: factory})
+	return method
+}
+
+// builtinMethodNames are the names and aliases of the built-in methods
+var builtinMethodNames = []string{"apikey", "api-key", "serviceaccount", "service-account", "oauth2", "oauth"}
+
+// registryNames returns the registered names; the caller holds registryMu
+func registryNames() []string {
+	names := make([]string, len(registry))
+	for i, provider := range registry {
+		names[i] = provider.name
+	}
+	return names
+}
+
+// registeredProviders returns a copy of the registry
+func registeredProviders() []registeredProvider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]registeredProvider(nil), registry...)
+}
+
+// ParseAuthMethod returns the method named name, built-in or registered,
+// accepting "api-key", "service-account", and "oauth" as aliases
+func ParseAuthMethod(name string) (AuthMethod, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "apikey", "api-key":
+		return AuthMethodAPIKey, nil
+	case "serviceaccount", "service-account":
+		return AuthMethodServiceAccount, nil
+	case "oauth2", "oauth":
+		return AuthMethodOAuth2, nil
+	}
+	for _, provider := range registeredProviders() {
+		if provider.name == name {
+			return provider.method, nil
+		}
+	}
+	return AuthMethodAPIKey, fmt.Errorf("invalid authentication method: %s", name)
+}
+
+// AuthMethods returns the names of all methods, built-in and registered
+func AuthMethods() []string {
+	methods := []string{AuthMethodAPIKey.String(), AuthMethodServiceAccount.String(), AuthMethodOAuth2.String()}
+	for _, provider := range registeredProviders() {
+		methods = append(methods, provider.name)
+	}
+	return methods
+}
+
+// autoCandidate is a method auto-selection may pick, when detect reports
+// that its credentials are available
+type autoCandidate struct {
+	method   AuthMethod
+	priority int
+	detect   func() bool
+}
+
+// autoCandidates returns the built-in and registered methods in the order
+// auto-selection tries them
+func (am *AuthManager) autoCandidates() []autoCandidate {
+	candidates := []autoCandidate{
+		{AuthMethodAPIKey, PriorityAPIKey, am.detectAPIKey},
+		{AuthMethodServiceAccount, PriorityServiceAccount, am.detectServiceAccount},
+		{AuthMethodOAuth2, PriorityOAuth2, am.detectOAuth2},
+	}
+	for _, registered := range registeredProviders() {
+		provider, ok := am.providers[registered.method]
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, autoCandidate{registered.method, registered.priority, provider.IsConfigured})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].priority < candidates[j].priority })
+	return candidates
+}
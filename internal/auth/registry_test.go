@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stsProvider stands in for a custom provider registered by a fork
+type stsProvider struct {
+	name       string
+	configured *bool
+}
+
+func (p *stsProvider) GetClient(ctx context.Context) (*texttospeech.Client, error) {
+	return nil, errors.New("not implemented")
+}
+func (p *stsProvider) IsConfigured() bool { return *p.configured }
+func (p *stsProvider) GetMethod() AuthMethod {
+	method, _ := ParseAuthMethod(p.name)
+	return method
+}
+func (p *stsProvider) Authenticate(ctx context.Context) error { return nil }
+
+var (
+	// Registered providers stay for the whole test binary, so they are
+	// only configured while a test says so
+	earlyConfigured, lateConfigured bool
+
+	authMethodEarly = RegisterProvider("early-sts", 50, func(AuthConfig) AuthProvider {
+		return &stsProvider{name: "early-sts", configured: &earlyConfigured}
+	})
+	authMethodLate = RegisterProvider("Late-STS", 400, func(AuthConfig) AuthProvider {
+		return &stsProvider{name: "late-sts", configured: &lateConfigured}
+	})
+)
+
+func TestRegisterProvider(t *testing.T) {
+	assert.Equal(t, "early-sts", authMethodEarly.String())
+	assert.Equal(t, "late-sts", authMethodLate.String())
+	assert.NotEqual(t, authMethodEarly, authMethodLate)
+
+	method, err := ParseAuthMethod("LATE-sts")
+	require.NoError(t, err)
+	assert.Equal(t, authMethodLate, method)
+	method, err = ParseAuthMethod("service-account")
+	require.NoError(t, err)
+	assert.Equal(t, AuthMethodServiceAccount, method)
+	_, err = ParseAuthMethod("kerberos")
+	assert.Error(t, err)
+
+	assert.Subset(t, AuthMethods(), []string{"apikey", "serviceaccount", "oauth2", "early-sts", "late-sts"})
+
+	assert.Panics(t, func() { RegisterProvider("early-sts", 1, func(AuthConfig) AuthProvider { return nil }) })
+	assert.Panics(t, func() { RegisterProvider("oauth", 1, func(AuthConfig) AuthProvider { return nil }) })
+	assert.Panics(t, func() { RegisterProvider("", 1, func(AuthConfig) AuthProvider { return nil }) })
+	assert.Panics(t, func() { RegisterProvider("nil-factory", 1, nil) })
+}
+
+func TestSelectAuthMethod_RegisteredPriority(t *testing.T) {
+	t.Setenv("ASSISTANT_CLI_API_KEY", "AIzaSyTestKey1234567890")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Cleanup(func() { earlyConfigured, lateConfigured = false, false })
+
+	manager := NewAuthManager(AuthConfig{})
+	method, err := manager.SelectAuthMethod()
+	require.NoError(t, err)
+	assert.Equal(t, AuthMethodAPIKey, method, "unconfigured providers are skipped")
+
+	lateConfigured = true
+	method, err = manager.SelectAuthMethod()
+	require.NoError(t, err)
+	assert.Equal(t, AuthMethodAPIKey, method, "built-in providers with a lower priority win")
+
+	earlyConfigured = true
+	method, err = manager.SelectAuthMethod()
+	require.NoError(t, err)
+	assert.Equal(t, authMethodEarly, method)
+
+	earlyConfigured = false
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	method, err = manager.SelectAuthMethod()
+	require.NoError(t, err)
+	assert.Equal(t, authMethodLate, method)
+
+	method, err = NewAuthManager(AuthConfig{Method: authMethodLate}).SelectAuthMethod()
+	require.NoError(t, err)
+	assert.Equal(t, authMethodLate, method, "an explicit method is used as is")
+}
@@ -14,6 +14,8 @@ import (
 // ServiceAccountProvider implements authentication using Google Cloud service account JSON files
 type ServiceAccountProvider struct {
 	serviceAccountFile string
+	network            NetworkConfig
+	options            []option.ClientOption
 	client             *texttospeech.Client
 }
 
@@ -40,7 +42,12 @@ func (p *ServiceAccountProvider) GetClient(ctx context.Context) (*texttospeech.C
 	}
 
 	// Create client with service account credentials
-	client, err := texttospeech.NewClient(ctx, option.WithCredentialsFile(p.serviceAccountFile))
+	credentials := option.WithCredentialsFile(p.serviceAccountFile)
+	opts, err := p.network.clientOptions(credentials, p.options...)
+	if err != nil {
+		return nil, err
+	}
+	client, err := texttospeech.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TTS client with service account: %w", err)
 	}
@@ -150,7 +157,12 @@ func (p *ServiceAccountProvider) ValidateServiceAccount(ctx context.Context) err
 	}
 
 	// Create a temporary client to test the service account
-	client, err := texttospeech.NewClient(ctx, option.WithCredentialsFile(p.serviceAccountFile))
+	credentials := option.WithCredentialsFile(p.serviceAccountFile)
+	opts, err := p.network.clientOptions(credentials, p.options...)
+	if err != nil {
+		return err
+	}
+	client, err := texttospeech.NewClient(ctx, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create client for validation: %w", err)
 	}
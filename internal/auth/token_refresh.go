@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// needsRefresh reports whether token has expired or expires within the
+// refresh window. Tokens without an expiry never need refreshing.
+func (p *OAuth2Provider) needsRefresh(token *oauth2.Token) bool {
+	if !token.Valid() {
+		return true
+	}
+	return !token.Expiry.IsZero() && time.Until(token.Expiry) < p.refreshWindow
+}
+
+// refreshingTokenSource hands the TTS client the provider's token,
+// refreshing it once it is within the refresh window of expiry, so that long
+// jobs never send a request with a token about to expire. Refreshed tokens
+// are saved to the token file under its lock.
+type refreshingTokenSource struct {
+	ctx      context.Context
+	provider *OAuth2Provider
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns a token valid for longer than the refresh window, or the
+// current token while it is still valid and a refresh fails
+func (s *refreshingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.provider.needsRefresh(s.token) {
+		return s.token, nil
+	}
+	s.provider.token = s.token
+	token, err := s.provider.getValidToken(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
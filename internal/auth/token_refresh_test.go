@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestNeedsRefresh(t *testing.T) {
+	provider := &OAuth2Provider{refreshWindow: 5 * time.Minute}
+
+	assert.True(t, provider.needsRefresh(nil))
+	assert.True(t, provider.needsRefresh(&oauth2.Token{AccessToken: "a", Expiry: time.Now().Add(-time.Minute)}))
+	assert.True(t, provider.needsRefresh(&oauth2.Token{AccessToken: "a", Expiry: time.Now().Add(2 * time.Minute)}))
+	assert.False(t, provider.needsRefresh(&oauth2.Token{AccessToken: "a", Expiry: time.Now().Add(time.Hour)}))
+	assert.False(t, provider.needsRefresh(&oauth2.Token{AccessToken: "a"}), "tokens without an expiry are kept")
+
+	provider.refreshWindow = 0
+	assert.False(t, provider.needsRefresh(&oauth2.Token{AccessToken: "a", Expiry: time.Now().Add(2 * time.Minute)}))
+}
+
+// fakeTokenEndpoint serves refreshed tokens, or errors when fail is set,
+// counting the requests
+func fakeTokenEndpoint(t *testing.T, fail *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"refreshed","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRefreshingTokenSource(t *testing.T) {
+	var fail atomic.Bool
+	server, requests := fakeTokenEndpoint(t, &fail)
+
+	tokenFile := filepath.Join(t.TempDir(), "token.json")
+	provider := NewOAuth2Provider("client-id", "client-secret", tokenFile)
+	provider.config.Endpoint = oauth2.Endpoint{TokenURL: server.URL, AuthStyle: oauth2.AuthStyleInParams}
+	provider.refreshWindow = 5 * time.Minute
+
+	current := &oauth2.Token{AccessToken: "current", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}
+	source := &refreshingTokenSource{ctx: context.Background(), provider: provider, token: current}
+
+	token, err := source.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "current", token.AccessToken)
+	assert.Zero(t, requests.Load(), "no refresh outside the window")
+
+	// Within the window, a failed refresh keeps the still valid token
+	source.token = &oauth2.Token{AccessToken: "current", RefreshToken: "refresh", Expiry: time.Now().Add(2 * time.Minute)}
+	fail.Store(true)
+	token, err = source.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "current", token.AccessToken)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// and a successful one replaces and saves it before it expires
+	fail.Store(false)
+	token, err = source.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", token.AccessToken)
+	assert.Equal(t, int32(2), requests.Load())
+
+	saved := NewOAuth2Provider("client-id", "client-secret", tokenFile)
+	require.NoError(t, saved.loadToken())
+	assert.Equal(t, "refreshed", saved.token.AccessToken)
+
+	// An expired token that cannot be refreshed is an error
+	source.token = &oauth2.Token{AccessToken: "expired", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Minute)}
+	provider.token = nil
+	require.NoError(t, writeTokenFile(tokenFile, []byte(`{"access_token":"expired","refresh_token":"refresh","expiry":"2020-01-01T00:00:00Z"}`)))
+	fail.Store(true)
+	_, err = source.Token()
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+const (
+	// tokenLockWait bounds the wait for another process holding the token
+	// file lock, e.g. while it refreshes the token
+	tokenLockWait = 30 * time.Second
+	// tokenLockPoll is how often a held lock is tried again
+	tokenLockPoll = 50 * time.Millisecond
+)
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("locked by another process")
+
+// lockTokenFile takes an exclusive advisory lock for tokenFile, waiting
+// while another process holds it, and returns the function releasing it.
+// The lock is held on a separate ".lock" file, since the token file itself
+// is replaced on every write.
+func lockTokenFile(ctx context.Context, tokenFile string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(tokenFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to lock token file: %w", err)
+	}
+	lock, err := os.OpenFile(tokenFile+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock token file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenLockWait)
+	defer cancel()
+	for {
+		err := tryLock(lock)
+		if err == nil {
+			return func() {
+				_ = unlock(lock)
+				_ = lock.Close()
+			}, nil
+		}
+		if !errors.Is(err, errLocked) {
+			_ = lock.Close()
+			return nil, fmt.Errorf("failed to lock token file: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = lock.Close()
+			return nil, fmt.Errorf("token file %s is %w: %w", tokenFile, errLocked, ctx.Err())
+		case <-time.After(tokenLockPoll):
+		}
+	}
+}
+
+// writeTokenFile replaces the token file at path in one step, so that
+// other processes never read a partly written token, and only the current
+// user can read it
+func writeTokenFile(path string, data []byte) error {
+	return output.WriteFileAtomic(path, data, 0600)
+}
+
+// RewriteTokenFile replaces the token file at path with the result of
+// convert while holding its lock, so a token refreshed by another process
+// at the same time is neither lost nor overwritten. convert returns nil to
+// leave the file as it is. A missing file is left alone. It reports
+// whether the file was changed.
+func RewriteTokenFile(ctx context.Context, path string, convert func([]byte) ([]byte, error)) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	release, err := lockTokenFile(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read token file: %w", err)
+	}
+	data, err = convert(data)
+	if err != nil || data == nil {
+		return false, err
+	}
+	if err := writeTokenFile(path, data); err != nil {
+		return false, fmt.Errorf("failed to write token file: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestLockTokenFile_WaitsForRelease(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token.json")
+
+	release, err := lockTokenFile(context.Background(), tokenFile)
+	require.NoError(t, err)
+
+	acquired := make(chan func())
+	go func() {
+		second, err := lockTokenFile(context.Background(), tokenFile)
+		assert.NoError(t, err)
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lock acquired while the first was held")
+	case <-time.After(4 * tokenLockPoll):
+	}
+
+	release()
+	select {
+	case second := <-acquired:
+		second()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second lock not acquired after release")
+	}
+}
+
+func TestLockTokenFile_Timeout(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token.json")
+
+	release, err := lockTokenFile(context.Background(), tokenFile)
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*tokenLockPoll)
+	defer cancel()
+	_, err = lockTokenFile(ctx, tokenFile)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errLocked))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestWriteTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	require.NoError(t, writeTokenFile(path, []byte("new")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
+
+func TestRefreshLocked_UsesTokenRefreshedByAnotherProcess(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token.json")
+	provider := NewOAuth2Provider("client-id", "client-secret", tokenFile)
+	provider.token = &oauth2.Token{AccessToken: "stale", Expiry: time.Now().Add(-time.Hour)}
+
+	// Another process refreshed the token while this one waited for the lock
+	fresh := &oauth2.Token{AccessToken: "fresh", Expiry: time.Now().Add(time.Hour)}
+	data, err := json.Marshal(fresh)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(tokenFile, data, 0600))
+
+	// No refresh is attempted: the provider has no OAuth2 config to make one
+	provider.config = nil
+	require.NoError(t, provider.refreshLocked(context.Background()))
+	assert.Equal(t, "fresh", provider.token.AccessToken)
+}
+
+func TestRewriteTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+
+	// A missing token file is left alone, without a lock file
+	changed, err := RewriteTokenFile(context.Background(), path, func(data []byte) ([]byte, error) {
+		t.Fatal("convert called for a missing file")
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, writeTokenFile(path, []byte("old")))
+	changed, err = RewriteTokenFile(context.Background(), path, func(data []byte) ([]byte, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// The rewrite waits for the lock and sees the token written under it
+	release, err := lockTokenFile(context.Background(), path)
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(4 * tokenLockPoll)
+		assert.NoError(t, writeTokenFile(path, []byte("refreshed")))
+		release()
+	}()
+	changed, err = RewriteTokenFile(context.Background(), path, func(data []byte) ([]byte, error) {
+		return append(data, "+converted"...), nil
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed+converted", string(data))
+}
@@ -0,0 +1,134 @@
+package bench
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// reportVersion identifies the on-disk report format
+const reportVersion = 1
+
+// Report is a set of results with the build and machine that produced
+// them. Saved, it is the baseline later runs are compared with.
+type Report struct {
+	Version    int       `json:"version"`
+	CLIVersion string    `json:"cli_version"`
+	GoVersion  string    `json:"go_version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	CPUs       int       `json:"cpus"`
+	CreatedAt  time.Time `json:"created_at"`
+	Results    []Result  `json:"results"`
+}
+
+// NewReport returns a report of results from this build and machine
+func NewReport(cliVersion string, createdAt time.Time, results []Result) *Report {
+	return &Report{
+		Version:    reportVersion,
+		CLIVersion: cliVersion,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		CreatedAt:  createdAt,
+		Results:    results,
+	}
+}
+
+// Result returns the result of the named case
+func (r *Report) Result(name string) (Result, bool) {
+	for _, result := range r.Results {
+		if result.Name == name {
+			return result, true
+		}
+	}
+	return Result{}, false
+}
+
+// SameMachine reports whether other was produced on the same platform and
+// CPU count, without which timings are hardly comparable
+func (r *Report) SameMachine(other *Report) bool {
+	return r.OS == other.OS && r.Arch == other.Arch && r.CPUs == other.CPUs
+}
+
+// DefaultBaselinePath returns ~/.assistant-cli/bench-baseline.json
+func DefaultBaselinePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".assistant-cli", "bench-baseline.json"), nil
+}
+
+// LoadReport reads the report at path. A missing file returns nil and no
+// error, as there is no baseline yet.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if report.Version != reportVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", report.Version, path)
+	}
+	return &report, nil
+}
+
+// Save writes the report to path atomically
+func (r *Report) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := output.WriteFileAtomic(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to save baseline: %w", err)
+	}
+	return nil
+}
+
+// Comparison is the change of a case from the baseline
+type Comparison struct {
+	Name string `json:"name"`
+	// BaselineNsPerOp is zero for a case missing from the baseline
+	BaselineNsPerOp float64 `json:"baseline_ns_per_op,omitempty"`
+	NsPerOp         float64 `json:"ns_per_op"`
+	// Change is the relative change of the time per operation, e.g. 0.25
+	// for 25% slower
+	Change float64 `json:"change"`
+	// Regressed is set when a local case got slower than the threshold
+	Regressed bool `json:"regressed"`
+}
+
+// Compare compares the results of current with baseline. A case is
+// regressed when its time per operation grew by more than threshold, a
+// fraction such as 0.1; live cases never are.
+func Compare(baseline, current *Report, threshold float64) []Comparison {
+	comparisons := make([]Comparison, 0, len(current.Results))
+	for _, result := range current.Results {
+		comparison := Comparison{Name: result.Name, NsPerOp: result.NsPerOp}
+		if before, ok := baseline.Result(result.Name); ok && before.NsPerOp > 0 {
+			comparison.BaselineNsPerOp = before.NsPerOp
+			comparison.Change = result.NsPerOp/before.NsPerOp - 1
+			comparison.Regressed = !result.Live && comparison.Change > threshold
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
@@ -0,0 +1,64 @@
+package bench
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "baseline.json")
+
+	missing, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	report := NewReport("1.2.0", created, []Result{{Name: "chunking/text", Iterations: 10, NsPerOp: 1500}})
+	require.NoError(t, report.Save(path))
+
+	loaded, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, report, loaded)
+	assert.True(t, loaded.SameMachine(report))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99}`), 0600))
+	_, err = LoadReport(path)
+	assert.ErrorContains(t, err, "unsupported baseline version 99")
+}
+
+func TestCompare(t *testing.T) {
+	baseline := NewReport("1.0.0", time.Now(), []Result{
+		{Name: "faster", NsPerOp: 1000},
+		{Name: "slower", NsPerOp: 1000},
+		{Name: "noisy", NsPerOp: 1000},
+		{Name: "api", NsPerOp: 1000, Live: true},
+	})
+	current := NewReport("1.1.0", time.Now(), []Result{
+		{Name: "faster", NsPerOp: 500},
+		{Name: "slower", NsPerOp: 1500},
+		{Name: "noisy", NsPerOp: 1050},
+		{Name: "api", NsPerOp: 3000, Live: true},
+		{Name: "new", NsPerOp: 100},
+	})
+
+	comparisons := Compare(baseline, current, 0.1)
+	require.Len(t, comparisons, 5)
+	byName := make(map[string]Comparison)
+	for _, c := range comparisons {
+		byName[c.Name] = c
+	}
+
+	assert.InDelta(t, -0.5, byName["faster"].Change, 1e-9)
+	assert.False(t, byName["faster"].Regressed)
+	assert.InDelta(t, 0.5, byName["slower"].Change, 1e-9)
+	assert.True(t, byName["slower"].Regressed)
+	assert.False(t, byName["noisy"].Regressed, "within the threshold")
+	assert.False(t, byName["api"].Regressed, "live cases are not compared")
+	assert.Zero(t, byName["new"].BaselineNsPerOp)
+	assert.False(t, byName["new"].Regressed)
+}
@@ -0,0 +1,132 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// maxIterations bounds the operations run for a case, however fast
+const maxIterations = 1_000_000_000
+
+// Case is an operation measured by Run
+type Case struct {
+	// Name identifies the case in reports and baselines, e.g. "chunking/text"
+	Name string
+	// Bytes is the input size of one operation, for throughput; zero leaves
+	// the throughput out
+	Bytes int64
+	// Iterations runs the operation exactly this many times instead of for
+	// the bench time, for slow operations such as API calls
+	Iterations int
+	// Live marks a case that calls a remote service; its timings depend on
+	// the network, so it is never counted as a regression
+	Live bool
+	// Op runs the operation once
+	Op func(ctx context.Context) error
+}
+
+// Result is the measured performance of a case
+type Result struct {
+	Name        string  `json:"name"`
+	Iterations  int     `json:"iterations"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	MBPerSec    float64 `json:"mb_per_sec,omitempty"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	Live        bool    `json:"live,omitempty"`
+}
+
+// PerOp returns the time of one operation
+func (r Result) PerOp() time.Duration {
+	return time.Duration(r.NsPerOp)
+}
+
+// Run measures each case in order. Cases without fixed iterations run
+// repeatedly until they have taken at least benchTime, like go test -bench.
+func Run(ctx context.Context, cases []Case, benchTime time.Duration) ([]Result, error) {
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		result, err := runCase(ctx, c, benchTime)
+		if err != nil {
+			return results, fmt.Errorf("benchmark %s: %w", c.Name, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runCase measures one case
+func runCase(ctx context.Context, c Case, benchTime time.Duration) (Result, error) {
+	// A first run warms caches and catches a failing operation early
+	if err := c.Op(ctx); err != nil {
+		return Result{}, err
+	}
+
+	n := c.Iterations
+	if n <= 0 {
+		n = 1
+	}
+	for {
+		elapsed, mem, err := measure(ctx, c.Op, n)
+		if err != nil {
+			return Result{}, err
+		}
+		if c.Iterations > 0 || elapsed >= benchTime || n >= maxIterations {
+			return newResult(c, n, elapsed, mem), nil
+		}
+		n = nextIterations(n, elapsed, benchTime)
+	}
+}
+
+// measure runs op n times, returning the elapsed time and the memory
+// allocated meanwhile
+func measure(ctx context.Context, op func(context.Context) error, n int) (time.Duration, runtime.MemStats, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return 0, after, err
+		}
+		if err := op(ctx); err != nil {
+			return 0, after, err
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	after.Mallocs -= before.Mallocs
+	after.TotalAlloc -= before.TotalAlloc
+	return elapsed, after, nil
+}
+
+// nextIterations predicts the operations that fill benchTime from a run
+// of n that took elapsed, growing by at most 100x per round
+func nextIterations(n int, elapsed, benchTime time.Duration) int {
+	next := n * 100
+	if elapsed > 0 {
+		// Aim 20% over, so that the next round usually suffices
+		predicted := int(int64(n) * int64(benchTime) * 6 / 5 / int64(elapsed))
+		next = min(next, predicted)
+	}
+	return min(max(next, n+1), maxIterations)
+}
+
+// newResult computes the per-operation figures of n operations
+func newResult(c Case, n int, elapsed time.Duration, mem runtime.MemStats) Result {
+	result := Result{
+		Name:        c.Name,
+		Iterations:  n,
+		NsPerOp:     float64(elapsed.Nanoseconds()) / float64(n),
+		BytesPerOp:  int64(mem.TotalAlloc) / int64(n),
+		AllocsPerOp: int64(mem.Mallocs) / int64(n),
+		Live:        c.Live,
+	}
+	if c.Bytes > 0 && elapsed > 0 {
+		result.MBPerSec = float64(c.Bytes) * float64(n) / 1e6 / elapsed.Seconds()
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_FixedIterations(t *testing.T) {
+	calls := 0
+	results, err := Run(context.Background(), []Case{{
+		Name:       "api",
+		Iterations: 3,
+		Live:       true,
+		Op: func(context.Context) error {
+			calls++
+			return nil
+		},
+	}}, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 3, results[0].Iterations)
+	assert.True(t, results[0].Live)
+	// One warm-up call precedes the measured ones
+	assert.Equal(t, 4, calls)
+}
+
+func TestRun_FillsBenchTime(t *testing.T) {
+	results, err := Run(context.Background(), []Case{{
+		Name:  "sleep",
+		Bytes: 1000,
+		Op: func(context.Context) error {
+			time.Sleep(100 * time.Microsecond)
+			return nil
+		},
+	}}, 20*time.Millisecond)
+	require.NoError(t, err)
+	result := results[0]
+	assert.Greater(t, result.Iterations, 10)
+	assert.GreaterOrEqual(t, result.PerOp(), 100*time.Microsecond)
+	assert.Positive(t, result.MBPerSec)
+}
+
+func TestRun_Error(t *testing.T) {
+	errBroken := errors.New("broken")
+	results, err := Run(context.Background(), []Case{
+		{Name: "ok", Iterations: 1, Op: func(context.Context) error { return nil }},
+		{Name: "broken", Op: func(context.Context) error { return errBroken }},
+	}, time.Millisecond)
+	assert.ErrorIs(t, err, errBroken)
+	assert.ErrorContains(t, err, "benchmark broken")
+	assert.Len(t, results, 1)
+}
+
+func TestNextIterations(t *testing.T) {
+	// Predicted from the rate, with 20% to spare
+	assert.Equal(t, 1200, nextIterations(100, 100*time.Millisecond, time.Second))
+	// Growth is capped at 100x
+	assert.Equal(t, 100, nextIterations(1, time.Nanosecond, time.Second))
+	assert.Equal(t, 100, nextIterations(1, 0, time.Second))
+	// Always grows
+	assert.Equal(t, 11, nextIterations(10, time.Second, time.Millisecond))
+	assert.Equal(t, maxIterations, nextIterations(maxIterations, time.Nanosecond, time.Second))
+}
+
+func TestStandard(t *testing.T) {
+	cases := Standard(t.TempDir())
+	results, err := Run(context.Background(), cases, time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, results, len(cases))
+	for _, result := range results {
+		assert.Positive(t, result.NsPerOp, result.Name)
+		assert.Positive(t, result.MBPerSec, result.Name)
+		assert.False(t, result.Live, result.Name)
+	}
+}
@@ -0,0 +1,137 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+)
+
+// The inputs of the standard cases. They are fixed, so results stay
+// comparable across releases; changing them invalidates saved baselines.
+const (
+	// chunkLimit is the chunk size of the Google Cloud request limit
+	chunkLimit = 5000
+	// documentParagraphs sizes the chunking inputs at about 60 KB
+	documentParagraphs = 120
+	// audioParts and partLength shape the joined audio: ten seconds of
+	// 24 kHz mono speech in one-second chunks
+	audioParts = 10
+	partLength = time.Second
+	// fileSize is the audio written by the file case
+	fileSize = 1 << 20
+)
+
+// paragraph is the prose repeated to build the chunking inputs
+const paragraph = "The committee met on Tuesday to review the proposal. After a long " +
+	"discussion, which touched on the budget, the schedule, and the staffing plan, " +
+	"the members agreed to revisit the question in the spring. Dr. Smith, who chaired " +
+	"the session, thanked everyone for their patience; the minutes will follow."
+
+// Standard returns the standard local cases. The file case writes to a
+// temporary file in dir.
+func Standard(dir string) []Case {
+	text := strings.TrimSpace(strings.Repeat(paragraph+"\n\n", documentParagraphs))
+	ssml := standardSSML()
+	parts := standardAudio()
+	audioBytes := 0
+	for _, part := range parts {
+		audioBytes += len(part)
+	}
+	data := make([]byte, fileSize)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	processor := utils.NewInputProcessor(nil)
+	validator := utils.NewSSMLValidator()
+	path := filepath.Join(dir, "assistant-cli-bench.wav")
+
+	return []Case{
+		{
+			Name:  "chunking/text",
+			Bytes: int64(len(text)),
+			Op: func(context.Context) error {
+				if chunks := processor.SplitByLength(text, chunkLimit); len(chunks) < 2 {
+					return fmt.Errorf("expected several chunks, got %d", len(chunks))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "chunking/ssml",
+			Bytes: int64(len(ssml)),
+			Op: func(context.Context) error {
+				_, err := processor.SplitSSML(ssml, chunkLimit)
+				return err
+			},
+		},
+		{
+			Name:  "validation/ssml",
+			Bytes: int64(len(ssml)),
+			Op: func(context.Context) error {
+				return validator.ValidateSSML(ssml)
+			},
+		},
+		{
+			Name:  "validation/sanitize",
+			Bytes: int64(len(ssml)),
+			Op: func(context.Context) error {
+				validator.SanitizeText(ssml)
+				return nil
+			},
+		},
+		{
+			Name:  "audio/concat",
+			Bytes: int64(audioBytes),
+			Op: func(context.Context) error {
+				_, err := audio.Concat(parts, 300*time.Millisecond)
+				return err
+			},
+		},
+		{
+			Name:  "file/write",
+			Bytes: fileSize,
+			Op: func(context.Context) error {
+				if err := output.WriteFileAtomic(path, data, 0600); err != nil {
+					return err
+				}
+				return os.Remove(path)
+			},
+		},
+	}
+}
+
+// standardSSML returns a document of paragraphs with the markup commonly
+// found in long texts
+func standardSSML() string {
+	var b strings.Builder
+	b.WriteString("<speak>")
+	for i := 0; i < documentParagraphs; i++ {
+		fmt.Fprintf(&b, `<p><s>%s</s><break time="500ms"/><prosody rate="slow">`+
+			`<emphasis level="moderate">Item %d</emphasis> is <say-as interpret-as="cardinal">%d</say-as>.`+
+			`</prosody></p>`, paragraph, i+1, i*1000)
+	}
+	b.WriteString("</speak>")
+	return b.String()
+}
+
+// standardAudio returns the WAV chunks joined by the concat case
+func standardAudio() [][]byte {
+	const sampleRate = 24000
+	parts := make([][]byte, audioParts)
+	for i := range parts {
+		samples := make([]int16, int(partLength.Seconds()*sampleRate))
+		for j := range samples {
+			samples[j] = int16((j * (i + 1) * 37) % 20000)
+		}
+		parts[i] = audio.EncodeWAV(&audio.PCM{SampleRate: sampleRate, Channels: 1, Samples: samples})
+	}
+	return parts
+}
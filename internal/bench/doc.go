@@ -0,0 +1,5 @@
+// Package bench runs standardized benchmarks of the local work done for
+// every synthesis (chunking, SSML validation, audio joining, and file
+// writes), and compares the results with a saved baseline so that
+// performance regressions between releases are visible.
+package bench
@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+	assert.Equal(t, start, fake.Now())
+
+	fake.Advance(time.Minute)
+	assert.Equal(t, time.Minute, fake.Since(start))
+
+	fired := <-fake.After(2 * time.Second)
+	assert.Equal(t, start.Add(time.Minute+2*time.Second), fired, "waiting advances the clock at once")
+	assert.Equal(t, fired, fake.Now())
+
+	<-fake.After(0)
+	assert.Equal(t, []time.Duration{2 * time.Second, 0}, fake.Waits())
+
+	fake.Set(start)
+	assert.Equal(t, start, fake.Now())
+}
+
+func TestOrReal(t *testing.T) {
+	assert.Equal(t, Real, OrReal(nil))
+	fake := NewFake(time.Time{})
+	assert.Equal(t, Clock(fake), OrReal(fake))
+	assert.WithinDuration(t, time.Now(), Real.Now(), time.Second)
+}
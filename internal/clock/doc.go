@@ -0,0 +1,4 @@
+// Package clock abstracts the system clock, so that code which measures or
+// waits for time, such as retry backoff, cache expiry, and backup pruning,
+// can be tested instantly and deterministically with a Fake.
+package clock
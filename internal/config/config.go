@@ -6,8 +6,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mikefarmer/assistant-cli/internal/output"
 	"github.com/spf13/viper"
 )
 
@@ -29,6 +31,9 @@ type Config struct {
 	// Authentication settings
 	Auth AuthConfig `mapstructure:"auth" yaml:"auth" json:"auth"`
 
+	// Network settings for proxies and private endpoints
+	Network NetworkConfig `mapstructure:"network" yaml:"network" json:"network"`
+
 	// Text-to-Speech settings
 	TTS TTSConfig `mapstructure:"tts" yaml:"tts" json:"tts"`
 
@@ -41,6 +46,15 @@ type Config struct {
 	// Input processing settings
 	Input InputConfig `mapstructure:"input" yaml:"input" json:"input"`
 
+	// Spoken alert settings
+	Alerts AlertsConfig `mapstructure:"alerts" yaml:"alerts" json:"alerts"`
+
+	// Recurring jobs of the daemon
+	Scheduler SchedulerConfig `mapstructure:"scheduler" yaml:"scheduler" json:"scheduler"`
+
+	// MQTT announcement settings
+	MQTT MQTTConfig `mapstructure:"mqtt" yaml:"mqtt" json:"mqtt"`
+
 	// Logging settings
 	Logging LoggingConfig `mapstructure:"logging" yaml:"logging" json:"logging"`
 
@@ -51,22 +65,28 @@ type Config struct {
 // AuthConfig contains authentication-related configuration
 type AuthConfig struct {
 	// Preferred authentication method: "apikey", "serviceaccount", "oauth2", "auto"
-	Method string `mapstructure:"method" yaml:"method" json:"method" validate:"oneof=apikey serviceaccount oauth2 auto"`
+	Method string `mapstructure:"method" yaml:"method" json:"method" validate:"omitempty,oneof=auto apikey serviceaccount oauth2"`
 
 	// API Key for authentication (prefer environment variable)
-	APIKey string `mapstructure:"api_key" yaml:"api_key,omitempty" json:"api_key,omitempty"`
+	APIKey string `mapstructure:"api_key" yaml:"api_key,omitempty" json:"api_key,omitempty" env:"ASSISTANT_CLI_API_KEY" sensitive:"true"`
 
 	// Path to service account JSON file
-	ServiceAccountFile string `mapstructure:"service_account_file" yaml:"service_account_file,omitempty"`
+	ServiceAccountFile string `mapstructure:"service_account_file" yaml:"service_account_file,omitempty" json:"service_account_file,omitempty"`
 
 	// OAuth2 client ID (prefer environment variable)
-	OAuth2ClientID string `mapstructure:"oauth2_client_id" yaml:"oauth2_client_id,omitempty"`
+	OAuth2ClientID string `mapstructure:"oauth2_client_id" yaml:"oauth2_client_id,omitempty" json:"oauth2_client_id,omitempty" env:"ASSISTANT_CLI_OAUTH2_CLIENT_ID"`
 
 	// OAuth2 client secret (prefer environment variable)
-	OAuth2ClientSecret string `mapstructure:"oauth2_client_secret" yaml:"oauth2_client_secret,omitempty"`
+	OAuth2ClientSecret string `mapstructure:"oauth2_client_secret" yaml:"oauth2_client_secret,omitempty" json:"oauth2_client_secret,omitempty" env:"ASSISTANT_CLI_OAUTH2_CLIENT_SECRET" sensitive:"true"`
 
 	// OAuth2 token file path
-	OAuth2TokenFile string `mapstructure:"oauth2_token_file" yaml:"oauth2_token_file,omitempty"`
+	OAuth2TokenFile string `mapstructure:"oauth2_token_file" yaml:"oauth2_token_file,omitempty" json:"oauth2_token_file,omitempty" env:"ASSISTANT_CLI_OAUTH2_TOKEN_FILE"`
+
+	// Store the OAuth2 token file encrypted with the ASSISTANT_CLI_PASSPHRASE or keyring passphrase
+	EncryptToken bool `mapstructure:"encrypt_token" yaml:"encrypt_token" json:"encrypt_token"`
+
+	// Refresh OAuth2 tokens this long before they expire, so that long jobs never use an expired token
+	RefreshWindow time.Duration `mapstructure:"refresh_window" yaml:"refresh_window" json:"refresh_window" validate:"min=0s,max=30m"`
 
 	// Connection timeout for authentication
 	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
@@ -75,25 +95,57 @@ type AuthConfig struct {
 	RetryAttempts int `mapstructure:"retry_attempts" yaml:"retry_attempts" json:"retry_attempts" validate:"min=0,max=10"`
 }
 
+// NetworkConfig contains settings for reaching Google Cloud through
+// corporate egress proxies and private endpoints
+type NetworkConfig struct {
+	// HTTP proxy URL for API connections; when empty HTTPS_PROXY applies
+	Proxy string `mapstructure:"proxy" yaml:"proxy,omitempty" json:"proxy,omitempty"`
+
+	// Text-to-Speech API endpoint as host:port, e.g. for Private Google Access
+	// or a VPC Service Controls perimeter
+	TTSEndpoint string `mapstructure:"tts_endpoint" yaml:"tts_endpoint,omitempty" json:"tts_endpoint,omitempty"`
+
+	// PEM file of extra CA certificates to trust, e.g. a TLS-inspecting proxy's
+	CABundle string `mapstructure:"ca_bundle" yaml:"ca_bundle,omitempty" json:"ca_bundle,omitempty"`
+}
+
 // TTSConfig contains text-to-speech configuration
 type TTSConfig struct {
+	// Speech synthesis backend ("google" or "espeak")
+	Provider string `mapstructure:"provider" yaml:"provider" json:"provider" validate:"omitempty,oneof=google espeak"`
+
+	// Local provider used when the primary provider fails with a network or
+	// authentication error (empty disables the fallback)
+	FallbackProvider string `mapstructure:"fallback_provider" yaml:"fallback_provider" json:"fallback_provider" validate:"omitempty,oneof=espeak"`
+
 	// Default voice name (e.g., "en-US-Wavenet-D")
 	Voice string `mapstructure:"voice" yaml:"voice" json:"voice"`
 
+	// Short names accepted wherever a voice name is (e.g., "sarah": "en-US-Neural2-F")
+	VoiceAliases map[string]string `mapstructure:"voice_aliases" yaml:"voice_aliases" json:"voice_aliases"`
+
+	// Favorite voice for each language code or language, used when a
+	// language is given or detected without a voice (e.g., "en-US": "Neural2-F")
+	LanguageVoices map[string]string `mapstructure:"language_voices" yaml:"language_voices" json:"language_voices"`
+
+	// Custom Voice model spoken with instead of voice, named
+	// "projects/PROJECT/locations/LOCATION/models/MODEL" (google provider only)
+	CustomVoice string `mapstructure:"custom_voice" yaml:"custom_voice" json:"custom_voice"`
+
 	// Default language code (e.g., "en-US")
 	Language string `mapstructure:"language" yaml:"language" json:"language" validate:"required"`
 
 	// Speaking rate (0.25 to 4.0)
-	SpeakingRate float64 `mapstructure:"speaking_rate" yaml:"speaking_rate" validate:"min=0.25,max=4.0"`
+	SpeakingRate float64 `mapstructure:"speaking_rate" yaml:"speaking_rate" json:"speaking_rate" validate:"min=0.25,max=4.0"`
 
 	// Voice pitch (-20.0 to 20.0)
-	Pitch float64 `mapstructure:"pitch" yaml:"pitch" json:"pitch" validate:"min=-20,max=20"`
+	Pitch float64 `mapstructure:"pitch" yaml:"pitch" json:"pitch" validate:"min=-20.0,max=20.0"`
 
 	// Volume gain in dB (-96.0 to 16.0)
-	VolumeGain float64 `mapstructure:"volume_gain" yaml:"volume_gain" json:"volume_gain" validate:"min=-96,max=16"`
+	VolumeGain float64 `mapstructure:"volume_gain" yaml:"volume_gain" json:"volume_gain" validate:"min=-96.0,max=16.0"`
 
 	// Audio encoding format
-	AudioEncoding string `mapstructure:"audio_encoding" yaml:"audio_encoding"`
+	AudioEncoding string `mapstructure:"audio_encoding" yaml:"audio_encoding" json:"audio_encoding" validate:"omitempty,oneof=MP3 LINEAR16 OGG_OPUS MULAW ALAW PCM AAC FLAC OPUS WEBM"`
 
 	// Effects profile ID
 	EffectsProfile []string `mapstructure:"effects_profile" yaml:"effects_profile" json:"effects_profile"`
@@ -101,11 +153,47 @@ type TTSConfig struct {
 	// Request timeout
 	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
 
+	// Deadline for a request across all its retries and the delays between them (0 disables)
+	TotalTimeout time.Duration `mapstructure:"total_timeout" yaml:"total_timeout" json:"total_timeout" validate:"min=0s"`
+
 	// Maximum retry attempts
 	MaxRetries int `mapstructure:"max_retries" yaml:"max_retries" json:"max_retries" validate:"min=0,max=10"`
 
+	// Delay before the first retry, grown by the backoff strategy
+	RetryDelay time.Duration `mapstructure:"retry_delay" yaml:"retry_delay" json:"retry_delay" validate:"min=0s,max=1m"`
+
+	// Longest delay between retries
+	MaxRetryDelay time.Duration `mapstructure:"max_retry_delay" yaml:"max_retry_delay" json:"max_retry_delay" validate:"min=0s,max=10m"`
+
+	// How the delay grows between retries: "linear", "exponential", "jittered"
+	RetryBackoff string `mapstructure:"retry_backoff" yaml:"retry_backoff" json:"retry_backoff" validate:"omitempty,oneof=linear exponential jittered"`
+
 	// Enable SSML validation
-	EnableSSMLValidation bool `mapstructure:"enable_ssml_validation" yaml:"enable_ssml_validation"`
+	EnableSSMLValidation bool `mapstructure:"enable_ssml_validation" yaml:"enable_ssml_validation" json:"enable_ssml_validation"`
+
+	// Client-side request limit per minute (0 disables rate limiting)
+	RequestsPerMinute int `mapstructure:"requests_per_minute" yaml:"requests_per_minute" json:"requests_per_minute" validate:"min=0,max=60000"`
+
+	// How long the on-disk voice list stays fresh (0 always refreshes when online)
+	VoiceCacheTTL time.Duration `mapstructure:"voice_cache_ttl" yaml:"voice_cache_ttl" json:"voice_cache_ttl" validate:"min=0s,max=720h"`
+
+	// Pieces of a long text synthesized at the same time
+	Concurrency int `mapstructure:"concurrency" yaml:"concurrency" json:"concurrency" validate:"min=1,max=16"`
+
+	// Named sets of synthesis parameters selected with --preset
+	Presets map[string]VoicePreset `mapstructure:"presets" yaml:"presets" json:"presets"`
+}
+
+// VoicePreset is a named set of synthesis parameters. Settings left empty or
+// zero keep the configured value.
+type VoicePreset struct {
+	Voice        string   `mapstructure:"voice" yaml:"voice,omitempty" json:"voice,omitempty"`
+	Language     string   `mapstructure:"language" yaml:"language,omitempty" json:"language,omitempty"`
+	SpeakingRate float64  `mapstructure:"speaking_rate" yaml:"speaking_rate,omitempty" json:"speaking_rate,omitempty"`
+	Pitch        float64  `mapstructure:"pitch" yaml:"pitch,omitempty" json:"pitch,omitempty" validate:"min=-20.0,max=20.0"`
+	VolumeGain   float64  `mapstructure:"volume_gain" yaml:"volume_gain,omitempty" json:"volume_gain,omitempty" validate:"min=-96.0,max=16.0"`
+	Format       string   `mapstructure:"format" yaml:"format,omitempty" json:"format,omitempty" validate:"omitempty,oneof=MP3 LINEAR16 WAV OGG_OPUS MULAW ALAW PCM AAC FLAC OPUS WEBM"`
+	Effects      []string `mapstructure:"effects_profile" yaml:"effects_profile,omitempty" json:"effects_profile,omitempty"`
 }
 
 // OutputConfig contains output-related configuration
@@ -114,10 +202,18 @@ type OutputConfig struct {
 	DefaultPath string `mapstructure:"default_path" yaml:"default_path" json:"default_path"`
 
 	// Default audio format
-	Format string `mapstructure:"format" yaml:"format" validate:"oneof=MP3 LINEAR16 WAV OGG_OPUS MULAW ALAW PCM"`
+	Format string `mapstructure:"format" yaml:"format" json:"format" validate:"omitempty,oneof=MP3 LINEAR16 WAV OGG_OPUS MULAW ALAW PCM AAC FLAC OPUS WEBM"`
 
 	// File overwrite behavior: "never", "always", "prompt", "backup"
-	OverwriteMode string `mapstructure:"overwrite_mode" yaml:"overwrite_mode" validate:"oneof=never always prompt backup"`
+	OverwriteMode string `mapstructure:"overwrite_mode" yaml:"overwrite_mode" json:"overwrite_mode" validate:"omitempty,oneof=never always prompt backup"`
+
+	// Overwrite behavior applied instead of "prompt" without a terminal to
+	// ask on: "never", "always", "backup"
+	OverwriteFallback string `mapstructure:"overwrite_fallback" yaml:"overwrite_fallback" json:"overwrite_fallback" validate:"omitempty,oneof=never always backup"`
+
+	// Backups kept of each file in "backup" mode, as a count (e.g. "5") or
+	// a maximum age (e.g. "720h" or "30d"); empty keeps every backup
+	BackupRetention string `mapstructure:"backup_retention" yaml:"backup_retention" json:"backup_retention"`
 
 	// File permissions (octal)
 	FilePermissions string `mapstructure:"file_permissions" yaml:"file_permissions" json:"file_permissions"`
@@ -128,11 +224,97 @@ type OutputConfig struct {
 	// Enable automatic filename generation
 	AutoFilename bool `mapstructure:"auto_filename" yaml:"auto_filename" json:"auto_filename"`
 
+	// Template for generated file names (empty uses "output.{{.Ext}}", or
+	// "{{.Text}}.{{.Ext}}" when auto_filename is set)
+	FilenameTemplate string `mapstructure:"filename_template" yaml:"filename_template" json:"filename_template"`
+
 	// Maximum filename length
-	MaxFilenameLength int `mapstructure:"max_filename_length" yaml:"max_filename_length" validate:"min=10,max=255"`
+	MaxFilenameLength int `mapstructure:"max_filename_length" yaml:"max_filename_length" json:"max_filename_length" validate:"min=10,max=255"`
 
 	// Create directories automatically
 	CreateDirs bool `mapstructure:"create_dirs" yaml:"create_dirs" json:"create_dirs"`
+
+	// Embed title, voice, language, date and text hash tags in MP3 and OGG_OPUS files
+	WriteMetadata bool `mapstructure:"write_metadata" yaml:"write_metadata" json:"write_metadata"`
+
+	// Write a <file>.meta.json manifest recording how each file was produced
+	WriteManifest bool `mapstructure:"write_manifest" yaml:"write_manifest" json:"write_manifest"`
+
+	// Audio processing applied after synthesis
+	PostProcess PostProcessConfig `mapstructure:"post_process" yaml:"post_process" json:"post_process"`
+
+	// Directories and extensions output files may be written to
+	PathPolicy PathPolicyConfig `mapstructure:"path_policy" yaml:"path_policy" json:"path_policy"`
+
+	// Uploads of output written to gs:// and s3:// destinations
+	Storage StorageConfig `mapstructure:"storage" yaml:"storage" json:"storage"`
+
+	// URL a JSON summary is posted to when a synthesis or batch job finishes
+	NotifyWebhook string `mapstructure:"notify_webhook" yaml:"notify_webhook" json:"notify_webhook"`
+}
+
+// PostProcessConfig contains audio post-processing settings. Processing
+// requires LINEAR16 (WAV) output.
+type PostProcessConfig struct {
+	// Normalize loudness to TargetLevel
+	Normalize bool `mapstructure:"normalize" yaml:"normalize" json:"normalize"`
+
+	// Target RMS level in dBFS for normalization (-60.0 to 0.0)
+	TargetLevel float64 `mapstructure:"target_level" yaml:"target_level" json:"target_level" validate:"min=-60.0,max=0.0"`
+
+	// Trim leading and trailing silence
+	TrimSilence bool `mapstructure:"trim_silence" yaml:"trim_silence" json:"trim_silence"`
+
+	// Level in dBFS below which audio counts as silence (-96.0 to 0.0)
+	SilenceThreshold float64 `mapstructure:"silence_threshold" yaml:"silence_threshold" json:"silence_threshold" validate:"min=-96.0,max=0.0"`
+
+	// Fade-in duration (0 disables)
+	FadeIn time.Duration `mapstructure:"fade_in" yaml:"fade_in" json:"fade_in" validate:"min=0s,max=10s"`
+
+	// Fade-out duration (0 disables)
+	FadeOut time.Duration `mapstructure:"fade_out" yaml:"fade_out" json:"fade_out" validate:"min=0s,max=10s"`
+
+	// WAV file mixed as background music under the narration (empty disables)
+	Music string `mapstructure:"music" yaml:"music" json:"music"`
+
+	// Level of the music in dB relative to the file (-60.0 to 0.0)
+	MusicVolume float64 `mapstructure:"music_volume" yaml:"music_volume" json:"music_volume" validate:"min=-60.0,max=0.0"`
+
+	// How far in dB the music is lowered while the narration speaks (0.0 to 40.0)
+	MusicDucking float64 `mapstructure:"music_ducking" yaml:"music_ducking" json:"music_ducking" validate:"min=0.0,max=40.0"`
+}
+
+// StorageConfig contains settings for uploading output to cloud storage.
+// GCS uploads use the configured auth credentials; S3 uploads use the
+// standard AWS environment variables.
+type StorageConfig struct {
+	// Size in MiB of the chunks large files are uploaded in (S3 parts are at
+	// least 5 MiB)
+	UploadChunkSize int `mapstructure:"upload_chunk_size" yaml:"upload_chunk_size" json:"upload_chunk_size" validate:"min=1,max=512"`
+
+	// S3 region, overriding AWS_REGION
+	S3Region string `mapstructure:"s3_region" yaml:"s3_region" json:"s3_region"`
+
+	// S3 compatible endpoint such as MinIO, overriding AWS_ENDPOINT_URL_S3
+	S3Endpoint string `mapstructure:"s3_endpoint" yaml:"s3_endpoint" json:"s3_endpoint"`
+}
+
+// PathPolicyConfig decides where output files may be written. Paths in a
+// blocked directory or with a blocked extension are refused unless a more
+// specific allowed directory or an allowed extension permits them; a
+// non-empty allowlist refuses everything it does not list.
+type PathPolicyConfig struct {
+	// Directories output may be written to (empty allows any)
+	AllowedDirs []string `mapstructure:"allowed_dirs" yaml:"allowed_dirs" json:"allowed_dirs"`
+
+	// Directories output may not be written to, e.g. system directories
+	BlockedDirs []string `mapstructure:"blocked_dirs" yaml:"blocked_dirs" json:"blocked_dirs"`
+
+	// File extensions output may have, e.g. ".mp3" (empty allows any)
+	AllowedExtensions []string `mapstructure:"allowed_extensions" yaml:"allowed_extensions" json:"allowed_extensions"`
+
+	// File extensions output may not have, e.g. executables and scripts
+	BlockedExtensions []string `mapstructure:"blocked_extensions" yaml:"blocked_extensions" json:"blocked_extensions"`
 }
 
 // PlaybackConfig contains audio playback configuration
@@ -143,16 +325,133 @@ type PlaybackConfig struct {
 	// Preferred audio player (auto-detected if empty)
 	Player string `mapstructure:"player" yaml:"player" json:"player"`
 
+	// Further preferred players, tried in order after Player
+	Players []string `mapstructure:"players" yaml:"players" json:"players"`
+
+	// Players by file extension (e.g. ogg: mpv), used when installed
+	FormatPlayers map[string]string `mapstructure:"format_players" yaml:"format_players" json:"format_players"`
+
 	// Player arguments
 	PlayerArgs []string `mapstructure:"player_args" yaml:"player_args" json:"player_args"`
 
 	// Volume level (0.0 to 1.0)
-	Volume float64 `mapstructure:"volume" yaml:"volume" json:"volume" validate:"min=0,max=1"`
+	Volume float64 `mapstructure:"volume" yaml:"volume" json:"volume" validate:"min=0.0,max=1.0"`
+
+	// Playback speed multiplier (0.5 to 2.0)
+	Speed float64 `mapstructure:"speed" yaml:"speed" json:"speed" validate:"min=0.5,max=2.0"`
 
-	// Enable fallback players
+	// Fall back to platform detection when no configured player is
+	// installed
 	EnableFallback bool `mapstructure:"enable_fallback" yaml:"enable_fallback" json:"enable_fallback"`
 }
 
+// AlertsConfig contains the settings of notify, which speaks the lines of
+// a watched command's output that match a pattern
+type AlertsConfig struct {
+	// Regular expressions of the lines to speak. A line matching a pattern
+	// with groups is spoken as its groups, otherwise as the whole line.
+	Patterns []string `mapstructure:"patterns" yaml:"patterns" json:"patterns"`
+
+	// Alerts spoken per minute at most; 0 for no limit
+	RateLimit int `mapstructure:"rate_limit" yaml:"rate_limit" json:"rate_limit" validate:"min=0"`
+
+	// Time within which a repeated alert is spoken only once; 0 speaks every
+	// repetition
+	DedupWindow time.Duration `mapstructure:"dedup_window" yaml:"dedup_window" json:"dedup_window" validate:"min=0"`
+}
+
+// SchedulerConfig contains the recurring jobs the daemon runs
+type SchedulerConfig struct {
+	// Jobs run by 'assistant-cli daemon' on their schedules
+	Jobs []ScheduledJobConfig `mapstructure:"jobs" yaml:"jobs" json:"jobs"`
+
+	// File the runs of each job are recorded in (empty uses
+	// ~/.assistant-cli/scheduler.json)
+	HistoryFile string `mapstructure:"history_file" yaml:"history_file" json:"history_file"`
+
+	// Runs kept in the history of each job
+	HistoryLimit int `mapstructure:"history_limit" yaml:"history_limit" json:"history_limit" validate:"min=1,max=10000"`
+}
+
+// ScheduledJobConfig is a job synthesizing text from one source on a
+// schedule
+type ScheduledJobConfig struct {
+	// Name identifies the job in logs, history, and notifications
+	Name string `mapstructure:"name" yaml:"name" json:"name"`
+
+	// Cron expression ("30 7 * * mon-fri"), @daily, @hourly, ..., or
+	// "@every 2h", in local time
+	Schedule string `mapstructure:"schedule" yaml:"schedule" json:"schedule"`
+
+	// Source of the text: a URL to fetch (HTML is read as text), a shell
+	// command whose output is read, or fixed text; exactly one is set
+	URL     string `mapstructure:"url" yaml:"url" json:"url"`
+	Command string `mapstructure:"command" yaml:"command" json:"command"`
+	Text    string `mapstructure:"text" yaml:"text" json:"text"`
+
+	// Voice and audio format (empty uses tts.voice and the daemon's format)
+	Voice  string `mapstructure:"voice" yaml:"voice" json:"voice"`
+	Format string `mapstructure:"format" yaml:"format" json:"format"`
+
+	// Output file, as a filename template like output.filename_template;
+	// relative paths are under output.default_path. Empty plays the audio
+	// from a temporary file.
+	Output string `mapstructure:"output" yaml:"output" json:"output"`
+
+	// Play the audio when the job finishes
+	Play bool `mapstructure:"play" yaml:"play" json:"play"`
+
+	// URL a JSON summary is posted to when the job fails (empty uses
+	// output.notify_webhook)
+	NotifyURL string `mapstructure:"notify_url" yaml:"notify_url" json:"notify_url"`
+}
+
+// MQTTConfig contains the settings of the mqtt command, which speaks the
+// messages of a topic, e.g. announcements from Home Assistant
+type MQTTConfig struct {
+	// Broker URL: mqtt://host:1883, or mqtts://host:8883 for TLS
+	Broker string `mapstructure:"broker" yaml:"broker" json:"broker"`
+
+	// Client identifier; brokers disconnect an older client with the same one
+	ClientID string `mapstructure:"client_id" yaml:"client_id" json:"client_id"`
+
+	// Credentials (prefer the environment variable for the password)
+	Username string `mapstructure:"username" yaml:"username,omitempty" json:"username,omitempty"`
+	Password string `mapstructure:"password" yaml:"password,omitempty" json:"password,omitempty" sensitive:"true"`
+
+	// Topic filter whose messages are spoken; a message is the text, or a
+	// JSON object with text, voice, language, speaking_rate, and format
+	Topic string `mapstructure:"topic" yaml:"topic" json:"topic"`
+
+	// Topic each result is published to as JSON (empty publishes nothing)
+	ResultTopic string `mapstructure:"result_topic" yaml:"result_topic" json:"result_topic"`
+
+	// QoS of the subscription and results: 0 or 1
+	QoS int `mapstructure:"qos" yaml:"qos" json:"qos" validate:"min=0,max=1"`
+
+	// Play each message on this machine
+	Play bool `mapstructure:"play" yaml:"play" json:"play"`
+
+	// Output file of each message, as a filename template like
+	// output.filename_template; relative paths are under output.default_path.
+	// Empty plays the audio from a temporary file.
+	Output string `mapstructure:"output" yaml:"output" json:"output"`
+
+	// PEM file of the CA certificates the broker's certificate is checked
+	// against, in addition to the system ones
+	CAFile string `mapstructure:"ca_file" yaml:"ca_file,omitempty" json:"ca_file,omitempty"`
+
+	// Client certificate and key for brokers requiring TLS client auth
+	CertFile string `mapstructure:"cert_file" yaml:"cert_file,omitempty" json:"cert_file,omitempty"`
+	KeyFile  string `mapstructure:"key_file" yaml:"key_file,omitempty" json:"key_file,omitempty"`
+
+	// Skip verifying the broker's certificate (self-signed test brokers only)
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify" yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+
+	// Longest time without traffic before the broker is pinged
+	KeepAlive time.Duration `mapstructure:"keep_alive" yaml:"keep_alive" json:"keep_alive" validate:"min=5s,max=18h"`
+}
+
 // InputConfig contains input processing configuration
 type InputConfig struct {
 	// Maximum text length for processing
@@ -161,6 +460,10 @@ type InputConfig struct {
 	// Buffer size for reading input
 	BufferSize int `mapstructure:"buffer_size" yaml:"buffer_size" json:"buffer_size" validate:"min=1024,max=65536"`
 
+	// Character encoding of input text: "auto" detects byte order marks,
+	// UTF-16, and UTF-8, and reads anything else as Windows-1252
+	Encoding string `mapstructure:"encoding" yaml:"encoding" json:"encoding" validate:"omitempty,oneof=auto utf-8 utf-16 utf-16le utf-16be latin1 windows-1252"`
+
 	// Enable automatic text cleaning
 	AutoClean bool `mapstructure:"auto_clean" yaml:"auto_clean" json:"auto_clean"`
 
@@ -172,15 +475,83 @@ type InputConfig struct {
 
 	// Show input statistics
 	ShowStats bool `mapstructure:"show_stats" yaml:"show_stats" json:"show_stats"`
+
+	// Maximum SSML <break> duration (Google Cloud TTS caps breaks at 10s)
+	MaxBreakTime time.Duration `mapstructure:"max_break_time" yaml:"max_break_time" json:"max_break_time" validate:"min=0s,max=10s"`
+
+	// Maximum SSML element nesting depth
+	MaxSSMLDepth int `mapstructure:"max_ssml_depth" yaml:"max_ssml_depth" json:"max_ssml_depth" validate:"min=1,max=256"`
+
+	// Maximum SSML document size in bytes. The long-text commands accept
+	// documents of at least 16 MiB.
+	MaxSSMLSize int `mapstructure:"max_ssml_size" yaml:"max_ssml_size" json:"max_ssml_size" validate:"min=1024,max=16777216"`
+
+	// Expansion of numbers, dates, currency, and units before synthesis
+	Normalize NormalizeConfig `mapstructure:"normalize" yaml:"normalize" json:"normalize"`
+
+	// Profanity handling: "off", "bleep" to mark it up as an expletive, or
+	// "remove"
+	ProfanityFilter string `mapstructure:"profanity_filter" yaml:"profanity_filter" json:"profanity_filter" validate:"omitempty,oneof=off bleep remove"`
+
+	// Words filtered in addition to the built-in list
+	ProfanityWords []string `mapstructure:"profanity_words" yaml:"profanity_words" json:"profanity_words"`
+
+	// Emoji and symbol handling: "keep", "strip", or "verbalize" to read
+	// them by name ("thumbs up emoji")
+	Emoji string `mapstructure:"emoji" yaml:"emoji" json:"emoji" validate:"omitempty,oneof=keep strip verbalize"`
+
+	// Names read for emoji and symbols, added to or replacing the built-in
+	// table; an empty name drops the emoji
+	EmojiNames map[string]string `mapstructure:"emoji_names" yaml:"emoji_names" json:"emoji_names"`
+
+	// Pause inserted between paragraphs (0 for none)
+	ParagraphPause time.Duration `mapstructure:"paragraph_pause" yaml:"paragraph_pause" json:"paragraph_pause" validate:"min=0s,max=10s"`
+
+	// Pause inserted between the sentences of a paragraph (0 for none)
+	SentencePause time.Duration `mapstructure:"sentence_pause" yaml:"sentence_pause" json:"sentence_pause" validate:"min=0s,max=10s"`
+
+	// Read "NAME: line" scripts as dialogue, each speaker with the voice
+	// Speakers maps them to
+	Dialogue bool `mapstructure:"dialogue" yaml:"dialogue" json:"dialogue"`
+
+	// Voices of the speakers of dialogue scripts, by name ignoring case;
+	// "narrator" reads lines without a speaker
+	Speakers map[string]string `mapstructure:"speakers" yaml:"speakers" json:"speakers"`
+}
+
+// NormalizeConfig contains settings for rewriting numbers, dates, currency
+// amounts, and units into speakable text before synthesis
+type NormalizeConfig struct {
+	// Enable the normalization stage
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+
+	// "expand" writes matches out as words, "say-as" wraps them in SSML
+	// <say-as> (always used for languages other than English)
+	Mode string `mapstructure:"mode" yaml:"mode" json:"mode" validate:"omitempty,oneof=expand say-as"`
+
+	// Locale deciding date order and spelling (empty uses tts.language)
+	Locale string `mapstructure:"locale" yaml:"locale" json:"locale"`
+
+	// Rewrite cardinal and ordinal numbers
+	Numbers bool `mapstructure:"numbers" yaml:"numbers" json:"numbers"`
+
+	// Rewrite dates and times
+	Dates bool `mapstructure:"dates" yaml:"dates" json:"dates"`
+
+	// Rewrite currency amounts
+	Currency bool `mapstructure:"currency" yaml:"currency" json:"currency"`
+
+	// Rewrite numbers with units such as "5 km" or "20%"
+	Units bool `mapstructure:"units" yaml:"units" json:"units"`
 }
 
 // LoggingConfig contains logging configuration
 type LoggingConfig struct {
 	// Log level: "debug", "info", "warn", "error"
-	Level string `mapstructure:"level" yaml:"level" json:"level" validate:"oneof=debug info warn error"`
+	Level string `mapstructure:"level" yaml:"level" json:"level" validate:"omitempty,oneof=debug info warn error"`
 
 	// Log format: "text", "json"
-	Format string `mapstructure:"format" yaml:"format" json:"format" validate:"oneof=text json"`
+	Format string `mapstructure:"format" yaml:"format" json:"format" validate:"omitempty,oneof=text json"`
 
 	// Log output: "stdout", "stderr", or file path
 	Output string `mapstructure:"output" yaml:"output" json:"output"`
@@ -209,6 +580,10 @@ type AppConfig struct {
 	// Enable progress indicators
 	ShowProgress bool `mapstructure:"show_progress" yaml:"show_progress" json:"show_progress"`
 
+	// Show a desktop notification when an interactive audiobook, feed, or
+	// batch run finishes or fails
+	DesktopNotifications bool `mapstructure:"desktop_notifications" yaml:"desktop_notifications" json:"desktop_notifications"`
+
 	// Quiet mode (minimal output)
 	Quiet bool `mapstructure:"quiet" yaml:"quiet" json:"quiet"`
 
@@ -219,21 +594,37 @@ type AppConfig struct {
 	CheckUpdates bool `mapstructure:"check_updates" yaml:"check_updates" json:"check_updates"`
 
 	// Update check interval
-	UpdateCheckInterval time.Duration `mapstructure:"update_check_interval" yaml:"update_check_interval"`
+	UpdateCheckInterval time.Duration `mapstructure:"update_check_interval" yaml:"update_check_interval" json:"update_check_interval"`
+
+	// Estimated monthly spend in USD to warn about (0 disables budget warnings)
+	MonthlyBudgetUSD float64 `mapstructure:"monthly_budget_usd" yaml:"monthly_budget_usd" json:"monthly_budget_usd"`
+
+	// Percentage of the monthly budget at which warnings start
+	BudgetWarningPercent int `mapstructure:"budget_warning_percent" yaml:"budget_warning_percent" json:"budget_warning_percent" validate:"min=1,max=100"`
+
+	// Google Cloud characters allowed per month before synthesis is refused (0 disables the limit)
+	MonthlyCharacterBudget int `mapstructure:"monthly_character_budget" yaml:"monthly_character_budget" json:"monthly_character_budget"`
 }
 
 // Manager handles configuration loading, validation, and management
 type Manager struct {
+	// mu guards config, viper, files, and sources, which Reload replaces
+	mu              sync.RWMutex
 	config          *Config
 	viper           *viper.Viper
 	configFileIsSet bool
+	listeners       []*listener
+	files           []string
+	sources         map[string]Source
+	migrations      []*MigrationReport
 }
 
 // NewManager creates a new configuration manager
 func NewManager() *Manager {
 	return &Manager{
-		config: &Config{},
-		viper:  viper.New(),
+		config:  &Config{},
+		viper:   viper.New(),
+		sources: make(map[string]Source),
 	}
 }
 
@@ -244,8 +635,10 @@ func GetDefaults() *Config {
 			Method:        "auto",
 			Timeout:       30 * time.Second,
 			RetryAttempts: 3,
+			RefreshWindow: 5 * time.Minute,
 		},
 		TTS: TTSConfig{
+			Provider:             "google",
 			Language:             "en-US",
 			SpeakingRate:         1.0,
 			Pitch:                0.0,
@@ -253,31 +646,88 @@ func GetDefaults() *Config {
 			AudioEncoding:        "MP3",
 			EffectsProfile:       []string{"headphone-class-device"},
 			Timeout:              30 * time.Second,
+			TotalTimeout:         2 * time.Minute,
 			MaxRetries:           3,
+			RetryDelay:           1 * time.Second,
+			MaxRetryDelay:        60 * time.Second,
+			RetryBackoff:         "linear",
 			EnableSSMLValidation: true,
+			RequestsPerMinute:    0,
+			VoiceCacheTTL:        24 * time.Hour,
+			Concurrency:          1,
 		},
 		Output: OutputConfig{
 			DefaultPath:       ".",
 			Format:            "MP3",
 			OverwriteMode:     "backup",
+			OverwriteFallback: "never",
 			FilePermissions:   "0644",
 			DirPermissions:    "0755",
 			AutoFilename:      false,
 			MaxFilenameLength: 100,
 			CreateDirs:        true,
+			PostProcess: PostProcessConfig{
+				TargetLevel:      -20.0,
+				SilenceThreshold: -50.0,
+				MusicVolume:      -18.0,
+				MusicDucking:     12.0,
+			},
+			PathPolicy: PathPolicyConfig{
+				AllowedDirs:       []string{},
+				BlockedDirs:       output.DefaultPathPolicy().BlockedDirs,
+				AllowedExtensions: []string{},
+				BlockedExtensions: output.DefaultPathPolicy().BlockedExtensions,
+			},
+			Storage: StorageConfig{
+				UploadChunkSize: output.DefaultChunkSize >> 20,
+			},
 		},
 		Playback: PlaybackConfig{
 			AutoPlay:       false,
 			Volume:         1.0,
+			Speed:          1.0,
 			EnableFallback: true,
 		},
 		Input: InputConfig{
 			MaxLength:          5000,
 			BufferSize:         4096,
+			Encoding:           "auto",
 			AutoClean:          true,
 			EnableValidation:   true,
 			EnableSSMLSecurity: true,
 			ShowStats:          false,
+			MaxBreakTime:       10 * time.Second,
+			MaxSSMLDepth:       32,
+			MaxSSMLSize:        1048576,
+			Normalize: NormalizeConfig{
+				Enabled:  false,
+				Mode:     "expand",
+				Numbers:  true,
+				Dates:    true,
+				Currency: true,
+				Units:    true,
+			},
+			ProfanityFilter: "off",
+			ProfanityWords:  []string{},
+			Emoji:           "keep",
+		},
+		Alerts: AlertsConfig{
+			Patterns:    []string{},
+			RateLimit:   6,
+			DedupWindow: 5 * time.Minute,
+		},
+		Scheduler: SchedulerConfig{
+			Jobs:         []ScheduledJobConfig{},
+			HistoryFile:  "",
+			HistoryLimit: 50,
+		},
+		MQTT: MQTTConfig{
+			Broker:    "",
+			ClientID:  "assistant-cli",
+			Topic:     "assistant-cli/say",
+			QoS:       1,
+			Play:      true,
+			KeepAlive: 60 * time.Second,
 		},
 		Logging: LoggingConfig{
 			Level:       "info",
@@ -288,14 +738,18 @@ func GetDefaults() *Config {
 			Performance: false,
 		},
 		App: AppConfig{
-			Name:                "assistant-cli",
-			ConfigVersion:       "1.5.0",
-			ColorOutput:         true,
-			ShowProgress:        true,
-			Quiet:               false,
-			Verbose:             false,
-			CheckUpdates:        true,
-			UpdateCheckInterval: 24 * time.Hour,
+			Name:                   "assistant-cli",
+			ConfigVersion:          CurrentConfigVersion,
+			ColorOutput:            true,
+			ShowProgress:           true,
+			DesktopNotifications:   false,
+			Quiet:                  false,
+			Verbose:                false,
+			CheckUpdates:           true,
+			UpdateCheckInterval:    24 * time.Hour,
+			MonthlyBudgetUSD:       0,
+			BudgetWarningPercent:   80,
+			MonthlyCharacterBudget: 0,
 		},
 	}
 }
@@ -321,32 +775,28 @@ func (m *Manager) Load() error {
 	m.viper.SetEnvPrefix("ASSISTANT_CLI")
 	m.viper.AutomaticEnv()
 	m.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	m.bindEnv()
 
-	// Only set config search paths if no specific config file was set
+	// A specific config file replaces the search for the user-level and
+	// project-local files
+	files := []string{m.viper.ConfigFileUsed()}
 	if !m.configFileIsSet {
-		// Set config file search paths
-		m.viper.SetConfigName(".assistant-cli")
-		m.viper.SetConfigType("yaml")
-
-		// Add search paths
-		if home, err := os.UserHomeDir(); err == nil {
-			m.viper.AddConfigPath(home)
-		}
-		m.viper.AddConfigPath(".")
+		files = findConfigFiles()
 	}
 
-	// Try to read config file
-	if err := m.viper.ReadInConfig(); err != nil {
-		// Config file not found is not an error
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return fmt.Errorf("error reading config file: %w", err)
-		}
+	// Config file not found is not an error
+	if err := m.readConfigFiles(files); err != nil {
+		return err
 	}
+	m.trackEnvSources()
 
 	// Unmarshal into config struct
 	if err := m.viper.Unmarshal(m.config); err != nil {
 		return fmt.Errorf("error unmarshaling config: %w", err)
 	}
+	if err := decryptSecrets(m.config); err != nil {
+		return err
+	}
 
 	// Validate configuration
 	if err := m.Validate(); err != nil {
@@ -356,6 +806,12 @@ func (m *Manager) Load() error {
 	return nil
 }
 
+// Migrations returns how config files were upgraded by Load, empty when
+// they were already current
+func (m *Manager) Migrations() []*MigrationReport {
+	return m.migrations
+}
+
 // setDefaults sets default values in viper
 func (m *Manager) setDefaults(config *Config) {
 	// Use reflection to set defaults from the struct
@@ -450,11 +906,16 @@ func (m *Manager) Validate() error {
 
 // Get returns the current configuration
 func (m *Manager) Get() *Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config
 }
 
-// GetConfigFilePath returns the path of the config file being used
+// GetConfigFilePath returns the path of the config file being used, the
+// project-local one when it overrides a user-level file
 func (m *Manager) GetConfigFilePath() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.viper.ConfigFileUsed()
 }
 
@@ -497,20 +958,53 @@ auth:
   # Number of retry attempts for authentication
   retry_attempts: 3
   
+  # Store the OAuth2 token file encrypted with the passphrase from
+  # ASSISTANT_CLI_PASSPHRASE or the OS keyring
+  encrypt_token: false
+  
+  # Refresh OAuth2 tokens this long before they expire, rather than when a
+  # request fails, so that long batch jobs keep running
+  refresh_window: "5m"
+  
   # Note: Sensitive credentials should be set via environment variables:
   # ASSISTANT_CLI_API_KEY="your-api-key"
   # GOOGLE_APPLICATION_CREDENTIALS="/path/to/service-account.json"
   # ASSISTANT_CLI_OAUTH2_CLIENT_ID="your-client-id"
   # ASSISTANT_CLI_OAUTH2_CLIENT_SECRET="your-client-secret"
+  # Credentials kept in this file can be encrypted in place with
+  # 'assistant-cli secrets encrypt'
+
+# Network settings for corporate proxies and private endpoints
+network:
+  # HTTP proxy for API connections (defaults to the HTTPS_PROXY environment variable)
+  # proxy: "http://proxy.example.com:3128"
+  
+  # Text-to-Speech API endpoint, e.g. for Private Google Access or VPC Service Controls
+  # tts_endpoint: "texttospeech.googleapis.com:443"
+  
+  # PEM file of extra CA certificates to trust, e.g. for a TLS-inspecting proxy
+  # ca_bundle: "/etc/ssl/certs/corporate-ca.pem"
 
 # Text-to-Speech settings
 tts:
+  # Speech synthesis backend: "google" (Google Cloud, requires credentials) or
+  # "espeak" (local espeak-ng, offline, LINEAR16 output only)
+  provider: "google"
+  
+  # Local provider used when Google Cloud is unreachable or authentication fails,
+  # so scripts still produce audio ("espeak", or empty to disable). Fallback audio
+  # is always LINEAR16 (WAV).
+  fallback_provider: ""
+  
   # Default language code (required)
   language: "en-US"
   
   # Default voice name (optional, will use language default if not set)
   # voice: "en-US-Wavenet-D"
   
+  # Custom Voice model to speak with instead of voice (google provider only)
+  # custom_voice: "projects/my-project/locations/us-central1/models/my-voice"
+  
   # Speaking rate (0.25 to 4.0)
   speaking_rate: 1.0
   
@@ -530,11 +1024,64 @@ tts:
   # Request timeout
   timeout: "30s"
   
+  # Deadline for a request across all its retries and the delays between
+  # them, split between the attempts left ("0s" lets each attempt take the
+  # full timeout)
+  total_timeout: "2m"
+  
   # Maximum retry attempts
   max_retries: 3
   
+  # Delay before the first retry of a failed request
+  retry_delay: "1s"
+  
+  # Longest delay between retries
+  max_retry_delay: "60s"
+  
+  # How the delay grows between retries: "linear", "exponential", or "jittered"
+  # (exponential with random spread). Quota errors always back off jittered.
+  retry_backoff: "linear"
+  
   # Enable SSML validation
   enable_ssml_validation: true
+  
+  # Client-side request limit per minute, keeping batch jobs within API quota
+  # (0 disables rate limiting; the Google Cloud default quota is 1000)
+  requests_per_minute: 0
+  
+  # How long the voice list cached in ~/.assistant-cli/cache stays fresh
+  # (0 always refreshes when online; the cache is still used offline)
+  voice_cache_ttl: "24h"
+  
+  # Pieces of a long text (audiobook chapters, feed items) synthesized at the
+  # same time; the audio is always joined in order
+  concurrency: 1
+  
+  # Short names for voices, accepted wherever a voice name is (--voice,
+  # presets, voice markup, input.speakers, daemon requests); add them with
+  # "assistant-cli voices alias add NAME VOICE"
+  voice_aliases: {}
+  #   sarah: "en-US-Neural2-F"
+  #   narrator: "en-GB-Studio-B"
+  
+  # Favorite voice for each language, used instead of the API's choice when
+  # a language is given with --language, or detected with --auto-language,
+  # without a voice. Voices of a code with a region may leave the code out;
+  # a language without a region, such as "de", needs the full voice name.
+  language_voices: {}
+  #   en-US: "Neural2-F"
+  #   es-ES: "Neural2-A"
+  #   de: "de-DE-Neural2-B"
+  
+  # Named sets of synthesis parameters, selected with --preset NAME or saved
+  # with "assistant-cli preset save NAME --voice ... --speed ..."; settings
+  # left out keep the values above
+  presets: {}
+  #   podcast:
+  #     voice: "en-US-Studio-O"
+  #     speaking_rate: 1.1
+  #     pitch: -1.0
+  #     effects_profile: ["large-home-entertainment-class-device"]
 
 # Output settings
 output:
@@ -547,6 +1094,15 @@ output:
   # File overwrite behavior: "never", "always", "prompt", "backup"
   overwrite_mode: "backup"
   
+  # Behavior used instead of "prompt" when no terminal is attached, e.g. in
+  # scripts and pipelines: "never", "always", "backup"
+  overwrite_fallback: "never"
+  
+  # Backups kept of each file in "backup" mode: a count such as "5" or a
+  # maximum age such as "720h" or "30d" (empty keeps every backup). Older
+  # ones are removed after each write; see "output clean-backups".
+  backup_retention: ""
+  
   # File permissions (octal notation)
   file_permissions: "0644"
   
@@ -556,27 +1112,99 @@ output:
   # Enable automatic filename generation from input text
   auto_filename: false
   
+  # Template for generated file names when no --output is given. Fields:
+  # {{.Text}} {{.Voice}} {{.Language}} {{.Format}} {{.Ext}} {{.Date}} {{.Time}}
+  # {{.Timestamp}} {{.Hash}}; "/" creates subdirectories of default_path.
+  # Example: "{{.Date}}/{{.Voice}}_{{.Hash}}.{{.Ext}}"
+  filename_template: ""
+  
   # Maximum filename length
   max_filename_length: 100
   
   # Create directories automatically
   create_dirs: true
+  
+  # Embed metadata (title, voice, language, synthesis date, source text hash)
+  # as ID3v2 tags in MP3 files and Vorbis comments in OGG_OPUS files
+  write_metadata: false
+  
+  # Write a sidecar manifest (e.g. audio.mp3.meta.json) with the request
+  # parameters, input hash, character count, duration, API latency and file info
+  write_manifest: false
+  
+  # Audio post-processing after synthesis (LINEAR16 output only)
+  post_process:
+    # Normalize loudness to target_level (RMS, dBFS)
+    normalize: false
+    target_level: -20.0
+    
+    # Trim leading and trailing audio quieter than silence_threshold (dBFS)
+    trim_silence: false
+    silence_threshold: -50.0
+    
+    # Fade durations (e.g., "200ms"; "0s" disables)
+    fade_in: "0s"
+    fade_out: "0s"
+    
+    # Background music: a WAV file looped under the narration at
+    # music_volume (dB), lowered by music_ducking (dB) while speech plays
+    music: ""
+    music_volume: -18.0
+    music_ducking: 12.0
+  
+  # Where output files may be written. Paths in blocked_dirs or with an
+  # extension in blocked_extensions are refused unless a more specific
+  # allowed_dirs entry or an allowed_extensions entry permits them; a
+  # non-empty allowlist refuses everything it does not list. The blocked
+  # lists default to system directories and executable or script extensions;
+  # setting one replaces its defaults.
+  path_policy:
+    # allowed_dirs: ["~/audio", "/usr/local/share/sounds"]
+    # allowed_extensions: [".mp3", ".wav", ".ogg", ".json"]
+    # blocked_dirs: ["/etc", "/usr/bin"]
+    # blocked_extensions: [".exe", ".js"]
+  
+  # Uploads when --output names a gs://bucket/path or s3://bucket/path
+  # destination. GCS uses the auth credentials above (an API key falls back
+  # to application default credentials); S3 reads AWS_ACCESS_KEY_ID,
+  # AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN from the environment.
+  storage:
+    # Files larger than this many MiB are uploaded in chunks
+    upload_chunk_size: 8
+    # s3_region: "us-east-1"
+    # s3_endpoint: "http://localhost:9000"
+  
+  # URL a JSON summary (status, file path or URL, duration, characters) is
+  # POSTed to when synthesize, audiobook, feed, or batch finishes (or --notify-url)
+  notify_webhook: ""
 
 # Audio playback settings
 playback:
   # Automatically play audio after synthesis
   auto_play: false
   
-  # Preferred audio player (auto-detected if empty)
+  # Preferred audio player (auto-detected if empty); "builtin" plays WAV
+  # audio without an external player
   # player: ""
   
-  # Additional player arguments
+  # Further players to try in order when the preferred one is not installed
+  # players: ["mpv", "ffplay"]
+  
+  # Players for specific file extensions, used when installed
+  # format_players:
+  #   ogg: "mpv"
+  #   wav: "aplay"
+  
+  # Additional arguments passed to every external player
   # player_args: []
   
-  # Volume level (0.0 to 1.0)
+  # Volume level (0.0 to 1.0), applied by players that support it
   volume: 1.0
   
-  # Enable fallback players if primary player fails
+  # Playback speed multiplier (0.5 to 2.0), applied by players that support it
+  speed: 1.0
+  
+  # Fall back to auto-detection when no configured player is installed
   enable_fallback: true
 
 # Input processing settings
@@ -587,6 +1215,11 @@ input:
   # Buffer size for reading input
   buffer_size: 4096
   
+  # Character encoding of input text: "auto" detects byte order marks,
+  # UTF-16, and UTF-8, and reads anything else as Windows-1252; or one of
+  # utf-8, utf-16, utf-16le, utf-16be, latin1, windows-1252
+  encoding: "auto"
+  
   # Enable automatic text cleaning
   auto_clean: true
   
@@ -598,6 +1231,130 @@ input:
   
   # Show input statistics
   show_stats: false
+  
+  # Maximum SSML <break> duration (must not exceed 10s, the API limit)
+  max_break_time: "10s"
+  
+  # Maximum SSML element nesting depth (1-256)
+  max_ssml_depth: 32
+  
+  # Maximum SSML document size in bytes (1024-16777216); batch and
+  # audiobook, which split documents, accept at least 16 MiB
+  max_ssml_size: 1048576
+  
+  # Rewrite numbers, dates, currency amounts, and units into speakable text
+  # before synthesis, e.g. "$5 on 2024-03-05" as "five dollars on March
+  # fifth, twenty twenty-four"
+  normalize:
+    enabled: false
+    
+    # "expand" writes them out as words; "say-as" wraps them in SSML <say-as>
+    # for the voice to read (always used for languages other than English)
+    mode: "expand"
+    
+    # Locale deciding date order and spelling, e.g. "en-GB" reads 07/04 as
+    # the seventh of April (empty uses tts.language)
+    locale: ""
+    
+    numbers: true
+    dates: true
+    currency: true
+    units: true
+  
+  # Profanity: "off", "bleep" to have the voice bleep it (SSML
+  # <say-as interpret-as="expletive">), or "remove"
+  profanity_filter: "off"
+  
+  # Words filtered in addition to the built-in list
+  profanity_words: []
+  
+  # Emoji and special symbols: "keep", "strip", or "verbalize" to read
+  # them by name ("thumbs up emoji", "copyright")
+  emoji: "keep"
+  
+  # Names for emoji and symbols, added to or replacing the built-in table
+  # (e.g. "🚀": "launch"); an empty name drops the emoji
+  emoji_names: {}
+  
+  # Pauses inserted as SSML <break> elements between paragraphs (blank
+  # lines, or <p> elements) and between sentences, e.g. "600ms" ("0s" for none)
+  paragraph_pause: "0s"
+  sentence_pause: "0s"
+  
+  # Read screenplay-style "NAME: line" scripts as dialogue, each speaker
+  # with its voice from speakers (others use the configured voice)
+  dialogue: false
+  
+  # Voices of dialogue speakers, ignoring case; "narrator" reads lines
+  # without a speaker (e.g. ALICE: "en-US-Neural2-F")
+  speakers: {}
+
+# Spoken alerts from watched commands (assistant-cli notify)
+alerts:
+  # Regular expressions of the output lines to speak; a pattern with groups
+  # speaks its groups, otherwise the whole line
+  # patterns: ["Warning\\s+(\\w+)\\s+.*?(pod/\\S+)", "(?i)error"]
+  patterns: []
+  
+  # Alerts spoken per minute at most; 0 for no limit
+  rate_limit: 6
+  
+  # A repeated alert is spoken once within this time; 0 speaks every one
+  dedup_window: "5m"
+
+# Recurring jobs run by 'assistant-cli daemon'
+scheduler:
+  # Each job synthesizes text from a url, command, or text on a schedule:
+  # a cron expression ("30 7 * * mon-fri"), @daily, @hourly, or "@every 2h".
+  # output is a filename template like output.filename_template; without
+  # one the audio is played from a temporary file. Failures are posted to
+  # notify_url, or output.notify_webhook.
+  # jobs:
+  #   - name: "briefing"
+  #     schedule: "0 7 * * mon-fri"
+  #     command: "curl -s https://wttr.in/?format=3"
+  #     play: true
+  #   - name: "news"
+  #     schedule: "@daily"
+  #     url: "https://example.com/news/today"
+  #     output: "news/{{.Date}}.{{.Ext}}"
+  jobs: []
+  
+  # Runs recorded in the job history (empty file: ~/.assistant-cli/scheduler.json)
+  history_file: ""
+  history_limit: 50
+
+# Announcements from MQTT, e.g. Home Assistant (assistant-cli mqtt)
+mqtt:
+  # Broker URL: mqtt://host:1883, or mqtts://host:8883 for TLS
+  broker: ""
+  client_id: "assistant-cli"
+  # username: "assistant"
+  # Prefer ASSISTANT_CLI_MQTT_PASSWORD, or 'assistant-cli secrets encrypt'
+  # password: ""
+  
+  # Topic whose messages are spoken: plain text, or JSON such as
+  # {"text": "Dinner is ready", "voice": "en-GB-Neural2-A"}
+  topic: "assistant-cli/say"
+  
+  # Topic results (file, duration, or error) are published to as JSON
+  result_topic: ""
+  
+  # QoS of the subscription and results: 0 or 1
+  qos: 1
+  
+  # Play messages on this machine, and/or keep their audio (a filename
+  # template under output.default_path, e.g. "announcements/{{.Date}}_{{.Hash}}.{{.Ext}}")
+  play: true
+  output: ""
+  
+  # TLS: extra CA certificates, client certificate and key
+  # ca_file: "~/.mqtt/ca.pem"
+  # cert_file: "~/.mqtt/client.pem"
+  # key_file: "~/.mqtt/client.key"
+  insecure_skip_verify: false
+  
+  keep_alive: "60s"
 
 # Logging settings
 logging:
@@ -633,6 +1390,11 @@ app:
   # Show progress indicators
   show_progress: true
   
+  # Show a desktop notification (osascript on macOS, notify-send on Linux,
+  # a toast on Windows) when an audiobook, feed, or batch run started from
+  # a terminal finishes or fails
+  desktop_notifications: false
+  
   # Quiet mode (minimal output)
   quiet: false
   
@@ -644,5 +1406,16 @@ app:
   
   # Update check interval
   update_check_interval: "24h"
+  
+  # Estimated monthly spend in USD to warn about, from the usage ledger
+  # (see assistant-cli usage); 0 disables budget warnings
+  monthly_budget_usd: 0
+  
+  # Percentage of the monthly budget at which warnings start
+  budget_warning_percent: 80
+  
+  # Google Cloud characters allowed per month; synthesis that would exceed it
+  # is refused unless --force is given. 0 disables the limit.
+  monthly_character_budget: 0
 `
 }
@@ -1,4 +1,6 @@
 // Package config provides configuration management for the TTS CLI.
 // It handles loading configuration from multiple sources including
 // command-line flags, environment variables, and configuration files.
+// Long-running commands can reload the configuration while they run with
+// Manager.Reload or Manager.Watch.
 package config
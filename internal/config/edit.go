@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Set changes the setting named by key to raw in the config file at path,
+// creating the file when it does not exist, and returns the parsed value.
+// The value is validated together with the rest of the current
+// configuration before anything is written, and a YAML file keeps its
+// comments.
+func (m *Manager) Set(path, key, raw string) (interface{}, error) {
+	candidate := *m.Get()
+	value, err := SetValue(&candidate, key, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &Manager{config: &candidate, viper: viper.New()}
+	if err := check.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := writeSetting(path, key, value); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.config = &candidate
+	m.mu.Unlock()
+	return value, nil
+}
+
+// SetPreset stores preset as tts.presets.<name> in the config file at path,
+// replacing a preset of that name. Like Set, the preset is validated with
+// the rest of the current configuration before anything is written; only
+// its non-empty settings are written.
+func (m *Manager) SetPreset(path, name string, preset VoicePreset) error {
+	if name == "" || strings.ContainsAny(name, ". \t") {
+		return &ValidationError{
+			Field:   "tts.presets",
+			Value:   name,
+			Message: "preset names must not be empty or contain dots or spaces",
+		}
+	}
+
+	candidate := *m.Get()
+	presets := make(map[string]VoicePreset, len(candidate.TTS.Presets)+1)
+	for key, value := range candidate.TTS.Presets {
+		presets[key] = value
+	}
+	presets[name] = preset
+	candidate.TTS.Presets = presets
+
+	check := &Manager{config: &candidate, viper: viper.New()}
+	if err := check.Validate(); err != nil {
+		return err
+	}
+
+	settings := settingsDocument(reflect.ValueOf(preset))
+	for key, value := range settings {
+		if v := reflect.ValueOf(value); v.IsZero() || v.Kind() == reflect.Slice && v.Len() == 0 {
+			delete(settings, key)
+		}
+	}
+	if err := writeSetting(path, "tts.presets."+name, settings); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.config = &candidate
+	m.mu.Unlock()
+	return nil
+}
+
+// SetVoiceAlias stores voice as tts.voice_aliases.<name> in the config file
+// at path, replacing an alias of that name. Like Set, the alias is validated
+// with the rest of the current configuration before anything is written.
+func (m *Manager) SetVoiceAlias(path, name, voice string) error {
+	if err := ValidateVoiceAliasName(name); err != nil {
+		return err
+	}
+
+	candidate := *m.Get()
+	aliases := make(map[string]string, len(candidate.TTS.VoiceAliases)+1)
+	for key, value := range candidate.TTS.VoiceAliases {
+		aliases[key] = value
+	}
+	aliases[name] = voice
+	candidate.TTS.VoiceAliases = aliases
+
+	check := &Manager{config: &candidate, viper: viper.New()}
+	if err := check.Validate(); err != nil {
+		return err
+	}
+
+	if err := writeSetting(path, "tts.voice_aliases."+name, voice); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.config = &candidate
+	m.mu.Unlock()
+	return nil
+}
+
+// writeSetting stores value under key in the config file at path
+func writeSetting(path, key string, value interface{}) error {
+	// Durations are written the way they are read, e.g. 30s
+	if d, ok := value.(time.Duration); ok {
+		value = d.String()
+	}
+
+	// Edit the target of a symlinked config file rather than replacing the link
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	mode := os.FileMode(0600)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	switch FileFormat(path) {
+	case FormatJSON:
+		data, err = setJSONSetting(data, key, value)
+	case FormatTOML:
+		data, err = setTOMLSetting(data, key, value)
+	default:
+		data, err = setYAMLSetting(data, key, value)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// An interrupted write never leaves a truncated config file behind
+	if err := output.WriteFileAtomic(path, data, mode); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// setYAMLSetting sets key in a YAML document, keeping its comments
+func setYAMLSetting(data []byte, key string, value interface{}) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(value); err != nil {
+		return nil, err
+	}
+	if err := setNode(&doc, key, valueNode); err != nil {
+		return nil, err
+	}
+
+	return encodeDocument(&doc, false)
+}
+
+// setJSONSetting sets key in a JSON document
+func setJSONSetting(data []byte, key string, value interface{}) ([]byte, error) {
+	doc := make(map[string]interface{})
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := setMapSetting(doc, key, value); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+}
+
+// setTOMLSetting sets key in a TOML document
+func setTOMLSetting(data []byte, key string, value interface{}) ([]byte, error) {
+	doc := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err := setMapSetting(doc, key, value); err != nil {
+		return nil, err
+	}
+	return toml.Marshal(doc)
+}
+
+// setMapSetting sets a dotted key in a decoded document, creating sections
+// as needed
+func setMapSetting(doc map[string]interface{}, key string, value interface{}) error {
+	section := doc
+	parts := strings.Split(key, ".")
+	for i, part := range parts[:len(parts)-1] {
+		child, ok := section[part]
+		if !ok || child == nil {
+			child = make(map[string]interface{})
+			section[part] = child
+		}
+		next, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not a section", strings.Join(parts[:i+1], "."))
+		}
+		section = next
+	}
+	section[parts[len(parts)-1]] = value
+	return nil
+}
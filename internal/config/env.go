@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// EnvBinding describes the environment variables a setting is read from
+type EnvBinding struct {
+	Key string `json:"key"`
+	// Variables lists the names read, the first one set winning: the name
+	// derived from the key, then any older name from the env struct tag
+	Variables []string `json:"variables"`
+	// Supported is false for settings that cannot be parsed from a single
+	// variable, such as maps
+	Supported bool `json:"supported"`
+	// Sensitive settings hold credentials, marked by the sensitive struct tag
+	Sensitive bool `json:"sensitive"`
+}
+
+// EnvVar returns the environment variable derived from a setting, e.g.
+// ASSISTANT_CLI_TTS_VOICE for tts.voice
+func EnvVar(key string) string {
+	return "ASSISTANT_CLI_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// EnvBindings returns the environment variables of every setting, sorted by
+// key. They are derived from the Config struct, so new settings are covered
+// without further changes.
+func EnvBindings() []EnvBinding {
+	var bindings []EnvBinding
+	collectEnvBindings("", reflect.TypeOf(Config{}), &bindings)
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].Key < bindings[j].Key })
+	return bindings
+}
+
+// collectEnvBindings adds the bindings of the settings of a config struct
+// type
+func collectEnvBindings(prefix string, t reflect.Type, bindings *[]EnvBinding) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() != "time" {
+			collectEnvBindings(key, field.Type, bindings)
+			continue
+		}
+
+		binding := EnvBinding{
+			Key:       key,
+			Variables: []string{EnvVar(key)},
+			Supported: field.Type.Kind() != reflect.Map,
+			Sensitive: field.Tag.Get("sensitive") == "true",
+		}
+		if alias := field.Tag.Get("env"); alias != "" {
+			binding.Variables = append(binding.Variables, alias)
+		}
+		*bindings = append(*bindings, binding)
+	}
+}
+
+// bindEnv makes viper read every supported variable, including those of
+// settings without a default, which AutomaticEnv alone would miss
+func (m *Manager) bindEnv() {
+	for _, binding := range EnvBindings() {
+		if binding.Supported {
+			_ = m.viper.BindEnv(append([]string{binding.Key}, binding.Variables...)...)
+		}
+	}
+}
+
+// trackEnvSources records the settings set by environment variables
+func (m *Manager) trackEnvSources() {
+	for _, binding := range EnvBindings() {
+		if !binding.Supported {
+			continue
+		}
+		for _, variable := range binding.Variables {
+			if os.Getenv(variable) != "" {
+				m.sources[binding.Key] = Source{Kind: SourceEnv, Name: variable}
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnvBindings(t *testing.T) {
+	bindings := make(map[string]EnvBinding)
+	for _, binding := range EnvBindings() {
+		bindings[binding.Key] = binding
+	}
+
+	if len(bindings) != len(Keys()) {
+		t.Errorf("Expected a binding for each of the %d settings, got %d", len(Keys()), len(bindings))
+	}
+
+	tests := []struct {
+		key  string
+		want EnvBinding
+	}{
+		{"tts.voice", EnvBinding{Key: "tts.voice", Variables: []string{"ASSISTANT_CLI_TTS_VOICE"}, Supported: true}},
+		{"auth.api_key", EnvBinding{Key: "auth.api_key",
+			Variables: []string{"ASSISTANT_CLI_AUTH_API_KEY", "ASSISTANT_CLI_API_KEY"}, Supported: true, Sensitive: true}},
+		{"playback.format_players", EnvBinding{Key: "playback.format_players",
+			Variables: []string{"ASSISTANT_CLI_PLAYBACK_FORMAT_PLAYERS"}}},
+	}
+	for _, tt := range tests {
+		if got := bindings[tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Binding of %s = %+v, want %+v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_ReadsEnvWithoutDefaults(t *testing.T) {
+	t.Setenv("ASSISTANT_CLI_PLAYBACK_PLAYER", "mpv")
+	t.Setenv("ASSISTANT_CLI_PLAYBACK_PLAYERS", "ffplay,builtin")
+	t.Setenv("ASSISTANT_CLI_OAUTH2_CLIENT_ID", "client-id")
+
+	manager, _ := loadConfigFile(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+	cfg := manager.Get()
+
+	if cfg.Playback.Player != "mpv" {
+		t.Errorf("Expected player mpv, got %q", cfg.Playback.Player)
+	}
+	if want := []string{"ffplay", "builtin"}; !reflect.DeepEqual(cfg.Playback.Players, want) {
+		t.Errorf("Expected players %v, got %v", want, cfg.Playback.Players)
+	}
+	if cfg.Auth.OAuth2ClientID != "client-id" {
+		t.Errorf("Expected the client ID from ASSISTANT_CLI_OAUTH2_CLIENT_ID, got %q", cfg.Auth.OAuth2ClientID)
+	}
+	want := Source{Kind: SourceEnv, Name: "ASSISTANT_CLI_OAUTH2_CLIENT_ID"}
+	if got := manager.Source("auth.oauth2_client_id"); got != want {
+		t.Errorf("Source(auth.oauth2_client_id) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// configExtensions are the config file extensions searched for, in order of
+// preference when several exist
+var configExtensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// FileFormat returns the format of a config file from its extension. Files
+// without a .json or .toml extension are read as YAML.
+func FileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// Marshal encodes cfg as a config file in format, which Load reads back
+// unchanged. Durations are written as strings such as 30s.
+func Marshal(cfg *Config, format string) ([]byte, error) {
+	settings := settingsDocument(reflect.ValueOf(cfg).Elem())
+
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(settings)
+	case FormatJSON:
+		data, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case FormatTOML:
+		return toml.Marshal(settings)
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s (supported: yaml, json, toml)", format)
+	}
+}
+
+// settingsDocument converts a config struct to nested maps keyed by the
+// mapstructure tags that Load reads
+func settingsDocument(v reflect.Value) map[string]interface{} {
+	settings := make(map[string]interface{})
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+
+		field := v.Field(i)
+		switch value := field.Interface().(type) {
+		case time.Duration:
+			settings[tag] = value.String()
+		case []string:
+			// Empty lists are written as [] rather than null
+			settings[tag] = append([]string{}, value...)
+		case map[string]string:
+			pairs := make(map[string]string, len(value))
+			for key, val := range value {
+				pairs[key] = val
+			}
+			settings[tag] = pairs
+		default:
+			switch {
+			case field.Kind() == reflect.Struct:
+				settings[tag] = settingsDocument(field)
+			case field.Kind() == reflect.Map && isSection(field.Type().Elem()):
+				// Named sections such as tts.presets
+				sections := make(map[string]interface{}, field.Len())
+				iter := field.MapRange()
+				for iter.Next() {
+					sections[iter.Key().String()] = settingsDocument(iter.Value())
+				}
+				settings[tag] = sections
+			default:
+				settings[tag] = value
+			}
+		}
+	}
+	return settings
+}
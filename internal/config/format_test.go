@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"config.yaml", FormatYAML},
+		{"config.yml", FormatYAML},
+		{"config.JSON", FormatJSON},
+		{"/etc/assistant-cli/config.toml", FormatTOML},
+		{".assistant-cli", FormatYAML},
+	}
+
+	for _, tt := range tests {
+		if got := FileFormat(tt.path); got != tt.want {
+			t.Errorf("FileFormat(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	want := GetDefaults()
+	want.TTS.Voice = "en-US-Neural2-F"
+	want.Auth.Timeout = 45 * time.Second
+	want.Playback.Players = []string{"mpv", "ffplay"}
+	want.Playback.PlayerArgs = []string{"--no-video"}
+	want.Playback.FormatPlayers = map[string]string{"ogg": "mpv"}
+	want.TTS.Presets = map[string]VoicePreset{
+		"podcast": {Voice: "en-US-Studio-O", SpeakingRate: 1.1, Pitch: -1, Effects: []string{"headphone-class-device"}},
+	}
+
+	for _, format := range []string{FormatYAML, FormatJSON, FormatTOML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := Marshal(want, format)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if !strings.Contains(string(data), "45s") {
+				t.Errorf("Expected durations written as strings, got:\n%s", data)
+			}
+
+			configFile := filepath.Join(t.TempDir(), "config."+format)
+			if err := os.WriteFile(configFile, data, 0600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			manager := NewManager()
+			manager.SetConfigFile(configFile)
+			if err := manager.Load(); err != nil {
+				t.Fatalf("Load() failed: %v\n%s", err, data)
+			}
+			if got := manager.Get(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Loaded configuration differs from the marshaled one, changed keys: %v",
+					changedKeys(want, got))
+			}
+		})
+	}
+}
+
+func TestMarshal_UnsupportedFormat(t *testing.T) {
+	if _, err := Marshal(GetDefaults(), "ini"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestLoad_FindsConfigFileByExtension(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", ".assistant-cli.yaml", "tts:\n  voice: \"en-US-Neural2-F\"\n"},
+		{"json", ".assistant-cli.json", `{"tts": {"voice": "en-US-Neural2-F"}}`},
+		{"toml", ".assistant-cli.toml", "[tts]\nvoice = \"en-US-Neural2-F\"\n"},
+		{"no extension", ".assistant-cli", "tts:\n  voice: \"en-US-Neural2-F\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			configFile := filepath.Join(home, tt.file)
+			if err := os.WriteFile(configFile, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			manager := NewManager()
+			if err := manager.Load(); err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if got := manager.GetConfigFilePath(); got != configFile {
+				t.Errorf("Expected config file %s, got %s", configFile, got)
+			}
+			if got := manager.Get().TTS.Voice; got != "en-US-Neural2-F" {
+				t.Errorf("Expected voice en-US-Neural2-F, got %q", got)
+			}
+		})
+	}
+}
+
+func TestManagerSet_TOML(t *testing.T) {
+	manager := NewManager()
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configFile, []byte("[tts]\nvoice = \"en-US-Wavenet-D\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	if _, err := manager.Set(configFile, "playback.volume", "0.5"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	loaded := NewManager()
+	loaded.SetConfigFile(configFile)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := loaded.Get(); got.Playback.Volume != 0.5 || got.TTS.Voice != "en-US-Wavenet-D" {
+		t.Errorf("Expected volume 0.5 and voice en-US-Wavenet-D, got %v and %q", got.Playback.Volume, got.TTS.Voice)
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/pkg/utils/suggest"
+)
+
+// KeyError reports a configuration key that does not name a setting
+type KeyError struct {
+	Key        string
+	Suggestion string
+}
+
+func (e *KeyError) Error() string {
+	message := fmt.Sprintf("unknown configuration key %q", e.Key)
+	if e.Suggestion != "" {
+		message += fmt.Sprintf("; did you mean %q?", e.Suggestion)
+	}
+	return message
+}
+
+// Keys returns every configuration key, e.g. tts.voice, sorted
+func Keys() []string {
+	settings := make(map[string]interface{})
+	flattenSettings("", reflect.ValueOf(GetDefaults()).Elem(), settings)
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetValue returns the value of the setting named by key
+func GetValue(cfg *Config, key string) (interface{}, error) {
+	field, err := settingField(cfg, key)
+	if err != nil {
+		return nil, err
+	}
+	return field.Interface(), nil
+}
+
+// SetValue parses raw as the type of the setting named by key and stores it
+// in cfg, returning the parsed value. Lists are comma-separated and maps are
+// comma-separated key=value pairs.
+func SetValue(cfg *Config, key, raw string) (interface{}, error) {
+	field, err := settingField(cfg, key)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parseSetting(field.Type(), raw)
+	if err != nil {
+		return nil, &ValidationError{Field: key, Value: raw, Message: err.Error()}
+	}
+	field.Set(value)
+	return value.Interface(), nil
+}
+
+// FormatValue renders a setting value the way SetValue parses it
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case map[string]string:
+		pairs := make([]string, 0, len(v))
+		for key, val := range v {
+			pairs = append(pairs, key+"="+val)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// settingField finds the struct field of a dotted key by its mapstructure
+// tags
+func settingField(cfg *Config, key string) (reflect.Value, error) {
+	v := reflect.ValueOf(cfg).Elem()
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, unknownKey(key)
+		}
+		next := reflect.Value{}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Tag.Get("mapstructure") == part {
+				next = v.Field(i)
+				break
+			}
+		}
+		if !next.IsValid() {
+			return reflect.Value{}, unknownKey(key)
+		}
+		v = next
+	}
+
+	// Sections such as tts are not settings themselves
+	if v.Kind() == reflect.Struct {
+		return reflect.Value{}, unknownKey(key)
+	}
+	return v, nil
+}
+
+// unknownKey reports key, suggesting the closest existing key
+func unknownKey(key string) *KeyError {
+	keys := Keys()
+	if match, ok := suggest.Closest(key, keys); ok {
+		return &KeyError{Key: key, Suggestion: match}
+	}
+	// A section name suggests its first setting
+	for _, candidate := range keys {
+		if strings.HasPrefix(candidate, key+".") {
+			return &KeyError{Key: key, Suggestion: candidate}
+		}
+	}
+	return &KeyError{Key: key}
+}
+
+// parseSetting converts raw to a value of type t
+func parseSetting(t reflect.Type, raw string) (reflect.Value, error) {
+	raw = strings.TrimSpace(raw)
+
+	if t == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be a duration such as 30s or 24h")
+		}
+		return reflect.ValueOf(d), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(raw).Convert(t), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be true or false")
+		}
+		return reflect.ValueOf(b), nil
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be a whole number")
+		}
+		return reflect.ValueOf(n), nil
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be a number")
+		}
+		return reflect.ValueOf(f), nil
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return reflect.ValueOf(items), nil
+	case reflect.Map:
+		if t.Elem().Kind() != reflect.String {
+			return reflect.Value{}, fmt.Errorf("settings of type %s cannot be set", t)
+		}
+		pairs := make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return reflect.Value{}, fmt.Errorf("must be comma-separated key=value pairs")
+			}
+			pairs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		return reflect.ValueOf(pairs), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("settings of type %s cannot be set", t)
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetAndSetValue(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  string
+		want interface{}
+	}{
+		{"tts.voice", "en-US-Neural2-F", "en-US-Neural2-F"},
+		{"tts.speaking_rate", "1.25", 1.25},
+		{"tts.max_retries", "5", 5},
+		{"tts.timeout", "45s", 45 * time.Second},
+		{"playback.auto_play", "true", true},
+		{"playback.players", "mpv, builtin,", []string{"mpv", "builtin"}},
+		{"playback.format_players", "ogg=mpv, wav=aplay", map[string]string{"ogg": "mpv", "wav": "aplay"}},
+		{"output.post_process.target_level", "-18", -18.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			cfg := GetDefaults()
+			value, err := SetValue(cfg, tt.key, tt.raw)
+			if err != nil {
+				t.Fatalf("SetValue() failed: %v", err)
+			}
+			if !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("SetValue() = %#v, want %#v", value, tt.want)
+			}
+
+			got, err := GetValue(cfg, tt.key)
+			if err != nil {
+				t.Fatalf("GetValue() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetValue_Errors(t *testing.T) {
+	tests := []struct {
+		key     string
+		raw     string
+		wantKey bool
+	}{
+		{"tts.voise", "x", true},
+		{"tts", "x", true},
+		{"tts.voice.name", "x", true},
+		{"tts.max_retries", "many", false},
+		{"playback.auto_play", "maybe", false},
+		{"tts.timeout", "45", false},
+		{"playback.format_players", "ogg", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.raw, func(t *testing.T) {
+			_, err := SetValue(GetDefaults(), tt.key, tt.raw)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			var keyErr *KeyError
+			if errors.As(err, &keyErr) != tt.wantKey {
+				t.Errorf("unexpected error type %T: %v", err, err)
+			}
+		})
+	}
+
+	_, err := GetValue(GetDefaults(), "tts.voise")
+	if err == nil || !strings.Contains(err.Error(), `did you mean "tts.voice"?`) {
+		t.Errorf("expected a suggestion, got %v", err)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"en-US", "en-US"},
+		{1.5, "1.5"},
+		{30 * time.Second, "30s"},
+		{[]string{"mpv", "builtin"}, "mpv,builtin"},
+		{map[string]string{"wav": "aplay", "ogg": "mpv"}, "ogg=mpv,wav=aplay"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatValue(tt.value); got != tt.want {
+			t.Errorf("FormatValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestManagerSet(t *testing.T) {
+	manager, configFile := loadConfigFile(t, "# Voice settings\ntts:\n  voice: \"en-US-Wavenet-D\" # preferred\n")
+
+	if _, err := manager.Set(configFile, "tts.voice", "en-US-Neural2-F"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if _, err := manager.Set(configFile, "tts.timeout", "45s"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if _, err := manager.Set(configFile, "playback.players", "mpv,builtin"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if got := manager.Get().TTS.Voice; got != "en-US-Neural2-F" {
+		t.Errorf("Expected the manager to use the new voice, got %q", got)
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	want := "# Voice settings\ntts:\n  voice: en-US-Neural2-F # preferred\n  timeout: 45s\n" +
+		"playback:\n  players:\n    - mpv\n    - builtin\n"
+	if string(data) != want {
+		t.Errorf("Unexpected config file:\n%s\nwant:\n%s", data, want)
+	}
+
+	reloaded, _ := loadConfigFile(t, string(data))
+	if reloaded.Get().TTS.Timeout != 45*time.Second {
+		t.Errorf("Expected the written timeout to load as 45s, got %v", reloaded.Get().TTS.Timeout)
+	}
+
+	if _, err := manager.Set(configFile, "tts.pitch", "50"); err == nil {
+		t.Error("Expected Set() to reject an out-of-range pitch")
+	}
+}
+
+func TestManagerSetPreset(t *testing.T) {
+	manager, configFile := loadConfigFile(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n  presets: {}\n")
+
+	preset := VoicePreset{Voice: "en-US-Studio-O", SpeakingRate: 1.1, Effects: []string{"headphone-class-device"}}
+	if err := manager.SetPreset(configFile, "podcast", preset); err != nil {
+		t.Fatalf("SetPreset() failed: %v", err)
+	}
+	if got := manager.Get().TTS.Presets["podcast"]; !reflect.DeepEqual(got, preset) {
+		t.Errorf("Expected the manager to use the new preset, got %+v", got)
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	reloaded, _ := loadConfigFile(t, string(data))
+	if got := reloaded.Get().TTS.Presets["podcast"]; !reflect.DeepEqual(got, preset) {
+		t.Errorf("Expected the written preset to load as %+v, got %+v\n%s", preset, got, data)
+	}
+	if strings.Contains(string(data), "pitch") {
+		t.Errorf("Expected unset preset settings to be left out:\n%s", data)
+	}
+
+	if err := manager.SetPreset(configFile, "loud", VoicePreset{VolumeGain: 40}); err == nil {
+		t.Error("Expected SetPreset() to reject an out-of-range volume gain")
+	}
+	if err := manager.SetPreset(configFile, "my.preset", preset); err == nil {
+		t.Error("Expected SetPreset() to reject a name with a dot")
+	}
+}
+
+func TestManagerSetVoiceAlias(t *testing.T) {
+	manager, configFile := loadConfigFile(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+
+	if err := manager.SetVoiceAlias(configFile, "sarah", "en-US-Neural2-F"); err != nil {
+		t.Fatalf("SetVoiceAlias() failed: %v", err)
+	}
+	if got := manager.Get().TTS.VoiceAliases["sarah"]; got != "en-US-Neural2-F" {
+		t.Errorf("Expected the manager to use the new alias, got %q", got)
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	reloaded, _ := loadConfigFile(t, string(data))
+	if got := reloaded.Get().TTS.VoiceAliases["sarah"]; got != "en-US-Neural2-F" {
+		t.Errorf("Expected the written alias to load, got %q\n%s", got, data)
+	}
+
+	if err := manager.SetVoiceAlias(configFile, "sally", "sarah"); err == nil {
+		t.Error("Expected SetVoiceAlias() to reject an alias of an alias")
+	}
+	if err := manager.SetVoiceAlias(configFile, "my.voice", "en-US-Neural2-F"); err == nil {
+		t.Error("Expected SetVoiceAlias() to reject a name with a dot")
+	}
+}
+
+func TestManagerSet_JSON(t *testing.T) {
+	manager := NewManager()
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"tts": {"voice": "en-US-Wavenet-D"}}`), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	if _, err := manager.Set(configFile, "playback.volume", "0.5"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	want := "{\n  \"playback\": {\n    \"volume\": 0.5\n  },\n  \"tts\": {\n    \"voice\": \"en-US-Wavenet-D\"\n  }\n}\n"
+	if string(data) != want {
+		t.Errorf("Unexpected config file:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// Kinds of Source
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "environment"
+)
+
+// Source describes where the value of a setting came from
+type Source struct {
+	// Kind is SourceDefault, SourceFile, or SourceEnv
+	Kind string `json:"kind"`
+	// Name is the config file path or environment variable
+	Name string `json:"name,omitempty"`
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFile:
+		return s.Name
+	case SourceEnv:
+		return "env " + s.Name
+	default:
+		return SourceDefault
+	}
+}
+
+// ConfigFiles returns the config files Load merged, the user-level file
+// first and the project-local file, which overrides it, last
+func (m *Manager) ConfigFiles() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.files...)
+}
+
+// Source returns where the loaded value of a setting came from
+func (m *Manager) Source(key string) Source {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if source, ok := m.sources[key]; ok {
+		return source
+	}
+	return Source{Kind: SourceDefault}
+}
+
+// findConfigFiles returns the config files to merge, lowest precedence
+// first: the user-level file in the home directory, then the nearest
+// project-local file in the current directory or one of its parents
+func findConfigFiles() []string {
+	var files []string
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if path := findConfigFileIn(home); path != "" {
+			files = append(files, path)
+		}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return files
+	}
+	for {
+		// Above the home directory, its file is the user-level one
+		if home != "" && dir == home {
+			break
+		}
+		if path := findConfigFileIn(dir); path != "" {
+			return append(files, path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return files
+}
+
+// findConfigFileIn returns the .assistant-cli config file in dir, trying
+// each supported extension and then no extension, or "" when there is none
+func findConfigFileIn(dir string) string {
+	for _, ext := range append(configExtensions, "") {
+		path := filepath.Join(dir, ".assistant-cli"+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// readConfigFiles merges files into viper in order, upgrading each one
+// written for an older config_version, and records which file set each
+// setting
+func (m *Manager) readConfigFiles(files []string) error {
+	for i, path := range files {
+		data, err := m.readConfigFile(path)
+		if err != nil {
+			return err
+		}
+
+		// The format follows the extension, and files without one are YAML
+		m.viper.SetConfigType(FileFormat(path))
+		if i == 0 {
+			err = m.viper.ReadConfig(bytes.NewReader(data))
+		} else {
+			err = m.viper.MergeConfig(bytes.NewReader(data))
+		}
+		if err != nil {
+			return fmt.Errorf("error reading config file %s: %w", path, err)
+		}
+
+		layer := viper.New()
+		layer.SetConfigType(FileFormat(path))
+		if err := layer.ReadConfig(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("error reading config file %s: %w", path, err)
+		}
+		for _, key := range Keys() {
+			if layer.IsSet(key) {
+				m.sources[key] = Source{Kind: SourceFile, Name: path}
+			}
+		}
+	}
+
+	if len(files) > 0 {
+		m.viper.SetConfigFile(files[len(files)-1])
+	}
+	m.files = files
+	return nil
+}
+
+// readConfigFile returns the settings document of a config file, upgrading
+// it first when it was written for an older config_version
+func (m *Manager) readConfigFile(path string) ([]byte, error) {
+	migrated, report, err := migrateFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		m.migrations = append(m.migrations, report)
+		return migrated, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// chdir changes the working directory for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(previous) })
+}
+
+func TestLoad_ProjectConfigOverridesUserConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	userFile := filepath.Join(home, ".assistant-cli.yaml")
+	userConfig := "tts:\n  voice: \"en-US-Wavenet-D\"\n  language: \"en-GB\"\nplayback:\n  volume: 0.5\n"
+	if err := os.WriteFile(userFile, []byte(userConfig), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	project := filepath.Join(home, "src", "project")
+	workdir := filepath.Join(project, "docs")
+	if err := os.MkdirAll(workdir, 0755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	projectFile := filepath.Join(project, ".assistant-cli.toml")
+	if err := os.WriteFile(projectFile, []byte("[tts]\nvoice = \"en-GB-Neural2-A\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	chdir(t, workdir)
+	t.Setenv("ASSISTANT_CLI_PLAYBACK_SPEED", "1.5")
+
+	manager := NewManager()
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	cfg := manager.Get()
+	if cfg.TTS.Voice != "en-GB-Neural2-A" || cfg.TTS.Language != "en-GB" || cfg.Playback.Volume != 0.5 {
+		t.Errorf("Expected the project voice over the user settings, got voice %q, language %q, volume %v",
+			cfg.TTS.Voice, cfg.TTS.Language, cfg.Playback.Volume)
+	}
+	if want := []string{userFile, projectFile}; !reflect.DeepEqual(manager.ConfigFiles(), want) {
+		t.Errorf("Expected config files %v, got %v", want, manager.ConfigFiles())
+	}
+	if got := manager.GetConfigFilePath(); got != projectFile {
+		t.Errorf("Expected config file path %s, got %s", projectFile, got)
+	}
+
+	sources := map[string]Source{
+		"tts.voice":       {Kind: SourceFile, Name: projectFile},
+		"tts.language":    {Kind: SourceFile, Name: userFile},
+		"playback.volume": {Kind: SourceFile, Name: userFile},
+		"playback.speed":  {Kind: SourceEnv, Name: "ASSISTANT_CLI_PLAYBACK_SPEED"},
+		"tts.pitch":       {Kind: SourceDefault},
+	}
+	for key, want := range sources {
+		if got := manager.Source(key); got != want {
+			t.Errorf("Source(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestLoad_ConfigFileFlagSkipsLayers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, ".assistant-cli.yaml"), []byte("tts:\n  language: \"en-GB\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	manager, configFile := loadConfigFile(t, "tts:\n  voice: \"en-US-Neural2-F\"\n")
+	if got := manager.Get().TTS.Language; got != "en-US" {
+		t.Errorf("Expected the user-level file to be ignored, got language %q", got)
+	}
+	if want := []string{configFile}; !reflect.DeepEqual(manager.ConfigFiles(), want) {
+		t.Errorf("Expected config files %v, got %v", want, manager.ConfigFiles())
+	}
+}
+
+func TestSourceString(t *testing.T) {
+	tests := []struct {
+		source Source
+		want   string
+	}{
+		{Source{Kind: SourceDefault}, "default"},
+		{Source{Kind: SourceFile, Name: "/home/user/.assistant-cli.yaml"}, "/home/user/.assistant-cli.yaml"},
+		{Source{Kind: SourceEnv, Name: "ASSISTANT_CLI_TTS_VOICE"}, "env ASSISTANT_CLI_TTS_VOICE"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.source.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
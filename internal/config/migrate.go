@@ -0,0 +1,359 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the config_version of the current schema
+const CurrentConfigVersion = "1.5.0"
+
+// MigrationReport describes how a config file was upgraded on load
+type MigrationReport struct {
+	File string
+	// From is the config_version the file had, empty when it had none
+	From string
+	To   string
+	// Backup is the copy of the file before the upgrade
+	Backup string
+	// Changes lists what was changed, one line each
+	Changes []string
+	// WriteErr is set when the upgraded file could not be saved; the
+	// upgrade then only applies in memory
+	WriteErr error
+}
+
+// migration upgrades a config document to the schema of version
+type migration struct {
+	version string
+	steps   []migrationStep
+}
+
+// migrationStep changes doc and describes each change it made
+type migrationStep func(doc *yaml.Node) []string
+
+// migrations are applied in order to files older than their version
+var migrations = []migration{
+	{
+		// The pre-release schema of the design document
+		version: "1.5.0",
+		steps: []migrationStep{
+			renameValues("auth.method", map[string]string{"api_key": "apikey", "service_account": "serviceaccount"}),
+			moveKey("auth.credentials_file", "auth.service_account_file"),
+			moveKey("auth.token_cache_path", "auth.oauth2_token_file"),
+			moveKey("audio.volume_gain_db", "tts.volume_gain"),
+			moveKey("audio.voice", "tts.voice"),
+			moveKey("audio.language", "tts.language"),
+			moveKey("audio.speaking_rate", "tts.speaking_rate"),
+			moveKey("audio.pitch", "tts.pitch"),
+			convertOverwrite,
+			moveKey("playback.command", "playback.player"),
+		},
+	},
+}
+
+// migrateFile upgrades the config file at path when it was written for an
+// older config_version, keeping a backup. It returns the upgraded document,
+// or nil data and report when nothing needed to change.
+func migrateFile(path string) ([]byte, *MigrationReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Migrations edit the YAML node tree, which JSON parses into as well;
+	// TOML files are read as they are
+	format := FileFormat(path)
+	if format == FormatTOML {
+		return nil, nil, nil
+	}
+
+	migrated, report, err := migrateDocument(data, format == FormatJSON)
+	if err != nil || report == nil {
+		return nil, nil, err
+	}
+	report.File = path
+
+	backup := path + ".v" + versionLabel(report.From) + ".bak"
+	mode := os.FileMode(0600)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(backup, data, mode); err != nil {
+		report.WriteErr = fmt.Errorf("failed to back up config file: %w", err)
+		return migrated, report, nil
+	}
+	report.Backup = backup
+	if err := output.WriteFileAtomic(path, migrated, mode); err != nil {
+		report.WriteErr = fmt.Errorf("failed to write config file: %w", err)
+	}
+	return migrated, report, nil
+}
+
+// migrateDocument applies the migrations newer than the config_version of
+// a YAML or JSON document
+func migrateDocument(data []byte, isJSON bool) ([]byte, *MigrationReport, error) {
+	// Documents that do not parse are left for the config loader to report
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, nil
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, nil
+	}
+
+	from := ""
+	if node := findNode(&doc, "app.config_version"); node != nil {
+		from = node.Value
+	}
+	if from != "" && !isValidSemanticVersion(from) {
+		return nil, nil, nil
+	}
+
+	report := &MigrationReport{From: from, To: CurrentConfigVersion}
+	for _, m := range migrations {
+		if from != "" && compareVersions(from, m.version) >= 0 {
+			continue
+		}
+		for _, step := range m.steps {
+			report.Changes = append(report.Changes, step(&doc)...)
+		}
+	}
+	// Files without changes keep their version, even an old or missing one
+	if len(report.Changes) == 0 {
+		return nil, nil, nil
+	}
+	version := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: CurrentConfigVersion}
+	if err := setNode(&doc, "app.config_version", version); err != nil {
+		return nil, nil, fmt.Errorf("failed to migrate config file: %w", err)
+	}
+
+	migrated, err := encodeDocument(&doc, isJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	return migrated, report, nil
+}
+
+// encodeDocument writes doc back in the format it was read from
+func encodeDocument(doc *yaml.Node, isJSON bool) ([]byte, error) {
+	if isJSON {
+		var value interface{}
+		if err := doc.Decode(&value); err != nil {
+			return nil, err
+		}
+		encoded, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(encoded, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// moveKey moves a setting to a new key. A value already set under the new
+// key wins over the old one.
+func moveKey(from, to string) migrationStep {
+	return func(doc *yaml.Node) []string {
+		value := removeNode(doc, from)
+		if value == nil {
+			return nil
+		}
+		if findNode(doc, to) != nil {
+			return []string{fmt.Sprintf("removed %s, since %s is already set", from, to)}
+		}
+		if err := setNode(doc, to, value); err != nil {
+			return []string{fmt.Sprintf("removed %s: %v", from, err)}
+		}
+		return []string{fmt.Sprintf("renamed %s to %s", from, to)}
+	}
+}
+
+// renameValues replaces old enum values of a setting
+func renameValues(key string, renamed map[string]string) migrationStep {
+	return func(doc *yaml.Node) []string {
+		node := findNode(doc, key)
+		if node == nil || node.Kind != yaml.ScalarNode {
+			return nil
+		}
+		value, ok := renamed[node.Value]
+		if !ok {
+			return nil
+		}
+		change := fmt.Sprintf("changed %s from %q to %q", key, node.Value, value)
+		node.Value = value
+		return []string{change}
+	}
+}
+
+// convertOverwrite replaces the output.overwrite flag with
+// output.overwrite_mode
+func convertOverwrite(doc *yaml.Node) []string {
+	node := findNode(doc, "output.overwrite")
+	if node == nil {
+		return nil
+	}
+	overwrite, err := strconv.ParseBool(node.Value)
+	if err != nil {
+		return nil
+	}
+	removeNode(doc, "output.overwrite")
+	if findNode(doc, "output.overwrite_mode") != nil {
+		return []string{"removed output.overwrite, since output.overwrite_mode is already set"}
+	}
+
+	mode := "never"
+	if overwrite {
+		mode = "always"
+	}
+	err = setNode(doc, "output.overwrite_mode", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: mode,
+		HeadComment: node.HeadComment, LineComment: node.LineComment})
+	if err != nil {
+		return []string{fmt.Sprintf("removed output.overwrite: %v", err)}
+	}
+	return []string{fmt.Sprintf("replaced output.overwrite: %s with output.overwrite_mode: %s", node.Value, mode)}
+}
+
+// findNode returns the value node of a dotted key, or nil
+func findNode(doc *yaml.Node, key string) *yaml.Node {
+	node := doc.Content[0]
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		index := mappingIndex(node, part)
+		if index < 0 {
+			return nil
+		}
+		node = node.Content[index+1]
+	}
+	return node
+}
+
+// removeNode deletes a dotted key and returns its value node, or nil. A
+// section left empty is deleted too.
+func removeNode(doc *yaml.Node, key string) *yaml.Node {
+	parts := strings.Split(key, ".")
+	parent := doc.Content[0]
+	if len(parts) > 1 {
+		parent = findNode(doc, strings.Join(parts[:len(parts)-1], "."))
+	}
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil
+	}
+	index := mappingIndex(parent, parts[len(parts)-1])
+	if index < 0 {
+		return nil
+	}
+
+	keyNode, value := parent.Content[index], parent.Content[index+1]
+	parent.Content = append(parent.Content[:index], parent.Content[index+2:]...)
+	// Keep the comment above the key with the value it is moved with
+	if value.HeadComment == "" {
+		value.HeadComment = keyNode.HeadComment
+	}
+
+	if len(parent.Content) == 0 && len(parts) > 1 {
+		removeNode(doc, strings.Join(parts[:len(parts)-1], "."))
+	}
+	return value
+}
+
+// setNode stores value under a dotted key, creating sections as needed. A
+// replaced value passes its comments on to value.
+func setNode(doc *yaml.Node, key string, value *yaml.Node) error {
+	parts := strings.Split(key, ".")
+	node := doc.Content[0]
+	for i, part := range parts {
+		// An empty section such as "tts:" holds null
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+		}
+		if node.Kind != yaml.MappingNode {
+			if i == 0 {
+				return fmt.Errorf("the document is not a mapping of settings")
+			}
+			return fmt.Errorf("%s is not a section", strings.Join(parts[:i], "."))
+		}
+
+		index := mappingIndex(node, part)
+		if i < len(parts)-1 {
+			if index < 0 {
+				child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, child)
+				node = child
+				continue
+			}
+			node = node.Content[index+1]
+			continue
+		}
+
+		if index >= 0 {
+			previous := node.Content[index+1]
+			if value.HeadComment == "" && value.LineComment == "" && value.FootComment == "" {
+				value.HeadComment = previous.HeadComment
+				value.LineComment = previous.LineComment
+				value.FootComment = previous.FootComment
+			}
+			node.Content[index+1] = value
+			return nil
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part, HeadComment: value.HeadComment}
+		value.HeadComment = ""
+		// A section written empty as {} grows into block style
+		node.Style &^= yaml.FlowStyle
+		node.Content = append(node.Content, keyNode, value)
+	}
+	return nil
+}
+
+// mappingIndex returns the index of key in a mapping node's content, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// compareVersions compares two major.minor.patch versions
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < 3; i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionLabel names a config_version in backup file names
+func versionLabel(version string) string {
+	if version == "" {
+		return "0"
+	}
+	return version
+}
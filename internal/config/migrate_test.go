@@ -0,0 +1,186 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const designSchemaConfig = `# Written for the first release
+auth:
+  method: "api_key"
+  credentials_file: "~/.gcp/service-account.json"
+
+# Voice settings
+audio:
+  voice: "en-US-Wavenet-D"
+  volume_gain_db: 2.0
+
+output:
+  overwrite: true # replace old files
+
+playback:
+  command: "mpv"
+`
+
+func TestMigrateDocument(t *testing.T) {
+	migrated, report, err := migrateDocument([]byte(designSchemaConfig), false)
+	if err != nil {
+		t.Fatalf("migrateDocument() failed: %v", err)
+	}
+	if report == nil {
+		t.Fatal("Expected the design schema to be migrated")
+	}
+
+	wantChanges := []string{
+		`changed auth.method from "api_key" to "apikey"`,
+		"renamed auth.credentials_file to auth.service_account_file",
+		"renamed audio.volume_gain_db to tts.volume_gain",
+		"renamed audio.voice to tts.voice",
+		"replaced output.overwrite: true with output.overwrite_mode: always",
+		"renamed playback.command to playback.player",
+	}
+	if !reflect.DeepEqual(report.Changes, wantChanges) {
+		t.Errorf("Changes = %q, want %q", report.Changes, wantChanges)
+	}
+	if report.From != "" || report.To != CurrentConfigVersion {
+		t.Errorf("Expected a migration from no version to %s, got %q to %q", CurrentConfigVersion, report.From, report.To)
+	}
+
+	content := string(migrated)
+	for _, want := range []string{"# Written for the first release", `method: "apikey"`,
+		`voice: "en-US-Wavenet-D"`, "overwrite_mode: always # replace old files", `player: "mpv"`,
+		"config_version: " + CurrentConfigVersion} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Migrated config is missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "audio:") {
+		t.Errorf("Expected the emptied audio section to be removed:\n%s", content)
+	}
+
+	// Migrating again changes nothing
+	if _, again, err := migrateDocument(migrated, false); err != nil || again != nil {
+		t.Errorf("Expected a migrated config to stay unchanged, got %v, %v", again, err)
+	}
+}
+
+func TestMigrateDocument_CurrentUnchanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"current schema without version", "tts:\n  voice: \"en-US-Wavenet-D\"\n"},
+		{"current version", "app:\n  config_version: \"" + CurrentConfigVersion + "\"\noutput:\n  overwrite: true\n"},
+		{"invalid version", "app:\n  config_version: \"latest\"\nplayback:\n  command: mpv\n"},
+		{"empty", ""},
+		{"not yaml", "tts: [unclosed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, report, err := migrateDocument([]byte(tt.content), false)
+			if err != nil || report != nil || migrated != nil {
+				t.Errorf("Expected no migration, got %v, %v", report, err)
+			}
+		})
+	}
+}
+
+func TestMigrateDocument_NewKeyWins(t *testing.T) {
+	content := "app:\n  config_version: \"1.0.0\"\naudio:\n  voice: old\ntts:\n  voice: new\n"
+	migrated, report, err := migrateDocument([]byte(content), false)
+	if err != nil || report == nil {
+		t.Fatalf("Expected a migration, got %v, %v", report, err)
+	}
+	if report.From != "1.0.0" {
+		t.Errorf("Expected From 1.0.0, got %q", report.From)
+	}
+	if want := []string{"removed audio.voice, since tts.voice is already set"}; !reflect.DeepEqual(report.Changes, want) {
+		t.Errorf("Changes = %q, want %q", report.Changes, want)
+	}
+	if !strings.Contains(string(migrated), "voice: new") || strings.Contains(string(migrated), "old") {
+		t.Errorf("Unexpected migrated config:\n%s", migrated)
+	}
+}
+
+func TestLoad_MigratesConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	serviceAccount := filepath.Join(dir, "service-account.json")
+	if err := os.WriteFile(serviceAccount, []byte("{}"), 0600); err != nil {
+		t.Fatalf("Failed to write service account file: %v", err)
+	}
+	original := strings.Replace(designSchemaConfig, "~/.gcp/service-account.json", serviceAccount, 1)
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte(original), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	manager := NewManager()
+	manager.SetConfigFile(configFile)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	cfg := manager.Get()
+	if cfg.Auth.Method != "apikey" || cfg.TTS.Voice != "en-US-Wavenet-D" || cfg.TTS.VolumeGain != 2.0 ||
+		cfg.Output.OverwriteMode != "always" || cfg.Playback.Player != "mpv" {
+		t.Errorf("Migrated settings were not loaded: %+v", cfg)
+	}
+
+	reports := manager.Migrations()
+	if len(reports) != 1 {
+		t.Fatalf("Expected one migration report, got %d", len(reports))
+	}
+	report := reports[0]
+	if report.WriteErr != nil {
+		t.Fatalf("Failed to write migrated config: %v", report.WriteErr)
+	}
+	backup, err := os.ReadFile(report.Backup)
+	if err != nil || string(backup) != original {
+		t.Errorf("Expected the backup %s to hold the original file, got %v", report.Backup, err)
+	}
+
+	// The upgraded file loads without another migration
+	reloaded := NewManager()
+	reloaded.SetConfigFile(configFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(reloaded.Migrations()) != 0 {
+		t.Error("Expected the upgraded file to be current")
+	}
+}
+
+func TestMigrateDocument_JSON(t *testing.T) {
+	content := `{"audio": {"voice": "en-US-Wavenet-D"}, "output": {"overwrite": false}}`
+	migrated, report, err := migrateDocument([]byte(content), true)
+	if err != nil || report == nil {
+		t.Fatalf("Expected a migration, got %v, %v", report, err)
+	}
+	want := "{\n  \"app\": {\n    \"config_version\": \"" + CurrentConfigVersion + "\"\n  },\n" +
+		"  \"output\": {\n    \"overwrite_mode\": \"never\"\n  },\n  \"tts\": {\n    \"voice\": \"en-US-Wavenet-D\"\n  }\n}\n"
+	if string(migrated) != want {
+		t.Errorf("Unexpected migrated config:\n%s\nwant:\n%s", migrated, want)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.5.0", -1},
+		{"1.5.0", "1.5.0", 0},
+		{"1.10.0", "1.5.0", 1},
+		{"2.0.0", "1.99.99", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
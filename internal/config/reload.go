@@ -0,0 +1,206 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the burst of file events an editor save produces
+// into a single reload
+const reloadDebounce = 100 * time.Millisecond
+
+// Change describes a successful configuration reload
+type Change struct {
+	Old *Config
+	New *Config
+	// Keys lists the settings whose values changed, e.g. tts.voice
+	Keys []string
+}
+
+// Changed reports whether key, or any setting below it such as tts for
+// tts.voice, changed
+func (c *Change) Changed(key string) bool {
+	for _, changed := range c.Keys {
+		if changed == key || strings.HasPrefix(changed, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// listener is a function registered with OnChange
+type listener struct {
+	fn func(*Change)
+}
+
+// OnChange registers fn to be called after every reload that changed a
+// setting, until the returned function removes it. Listeners run on the
+// goroutine that called Reload.
+func (m *Manager) OnChange(fn func(*Change)) (remove func()) {
+	registered := &listener{fn: fn}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, registered)
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, l := range m.listeners {
+			if l == registered {
+				// Reload may be calling a copy of the list
+				m.listeners = append(m.listeners[:i:i], m.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload reads every configuration source again and validates the result.
+// A valid configuration replaces the current one at once, so Get never
+// returns a half-updated configuration, and listeners are notified when a
+// setting changed. On error the current configuration stays in effect.
+func (m *Manager) Reload() (*Change, error) {
+	next := NewManager()
+	m.mu.RLock()
+	if m.configFileIsSet {
+		next.SetConfigFile(m.viper.ConfigFileUsed())
+	}
+	m.mu.RUnlock()
+
+	if err := next.Load(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	change := &Change{Old: m.config, New: next.config, Keys: changedKeys(m.config, next.config)}
+	m.config = next.config
+	m.viper = next.viper
+	m.files = next.files
+	m.sources = next.sources
+	listeners := append([]*listener{}, m.listeners...)
+	m.mu.Unlock()
+
+	if len(change.Keys) > 0 {
+		for _, l := range listeners {
+			l.fn(change)
+		}
+	}
+	return change, nil
+}
+
+// Watch reloads the configuration whenever a config file is written or
+// the process receives SIGHUP, until ctx is done. Failed reloads keep the
+// current configuration and are passed to onError.
+func (m *Manager) Watch(ctx context.Context, onError func(error)) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var events chan fsnotify.Event
+	var watchErrors chan error
+	if files := m.ConfigFiles(); len(files) > 0 {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("failed to watch config file: %w", err)
+		}
+		defer func() { _ = watcher.Close() }()
+
+		// Watch the directories, since editors often replace the file on save
+		for _, path := range files {
+			if err := watcher.Add(filepath.Dir(path)); err != nil {
+				return fmt.Errorf("failed to watch config file: %w", err)
+			}
+		}
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	reload := func() {
+		if _, err := m.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			reload()
+		case event := <-events:
+			if m.isConfigFile(event.Name) && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				debounce.Reset(reloadDebounce)
+			}
+		case err := <-watchErrors:
+			if onError != nil {
+				onError(fmt.Errorf("failed to watch config file: %w", err))
+			}
+		case <-debounce.C:
+			reload()
+		}
+	}
+}
+
+// isConfigFile reports whether name is one of the loaded config files
+func (m *Manager) isConfigFile(name string) bool {
+	for _, path := range m.ConfigFiles() {
+		if filepath.Clean(name) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedKeys lists the settings that differ between two configurations,
+// sorted
+func changedKeys(previous, current *Config) []string {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	flattenSettings("", reflect.ValueOf(previous).Elem(), before)
+	flattenSettings("", reflect.ValueOf(current).Elem(), after)
+
+	var keys []string
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flattenSettings collects the settings of a config struct by their dotted
+// mapstructure keys
+func flattenSettings(prefix string, v reflect.Value, settings map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			flattenSettings(key, field, settings)
+			continue
+		}
+		settings[key] = field.Interface()
+	}
+}
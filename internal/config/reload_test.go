@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// loadConfigFile writes content to a config file and loads a manager from it
+func loadConfigFile(t *testing.T, content string) (*Manager, string) {
+	t.Helper()
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	manager := NewManager()
+	manager.SetConfigFile(configFile)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	return manager, configFile
+}
+
+func TestReload(t *testing.T) {
+	manager, configFile := loadConfigFile(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+	original := manager.Get()
+
+	var notified []*Change
+	remove := manager.OnChange(func(change *Change) { notified = append(notified, change) })
+
+	content := "tts:\n  voice: \"en-US-Neural2-F\"\n  speaking_rate: 1.25\n"
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	change, err := manager.Reload()
+	if err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+
+	if got := manager.Get().TTS.Voice; got != "en-US-Neural2-F" {
+		t.Errorf("Expected reloaded voice en-US-Neural2-F, got %q", got)
+	}
+	if original.TTS.Voice != "en-US-Wavenet-D" {
+		t.Errorf("Reload modified the previous configuration: voice %q", original.TTS.Voice)
+	}
+	if want := []string{"tts.speaking_rate", "tts.voice"}; !reflect.DeepEqual(change.Keys, want) {
+		t.Errorf("Expected changed keys %v, got %v", want, change.Keys)
+	}
+	if len(notified) != 1 || notified[0] != change {
+		t.Fatalf("Expected one change notification, got %d", len(notified))
+	}
+	if !change.Changed("tts") || !change.Changed("tts.voice") || change.Changed("playback") {
+		t.Errorf("Changed() does not match keys %v", change.Keys)
+	}
+
+	// Reloading an unchanged file notifies nobody
+	if _, err := manager.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("Expected no notification for an unchanged file, got %d", len(notified))
+	}
+
+	// Removed listeners are not notified
+	remove()
+	if err := os.WriteFile(configFile, []byte("tts:\n  voice: \"en-GB-Neural2-A\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := manager.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("Expected no notification after removing the listener, got %d", len(notified))
+	}
+}
+
+func TestReload_InvalidKeepsCurrent(t *testing.T) {
+	manager, configFile := loadConfigFile(t, "tts:\n  speaking_rate: 1.5\n")
+
+	if err := os.WriteFile(configFile, []byte("tts:\n  speaking_rate: 9.0\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := manager.Reload(); err == nil {
+		t.Fatal("Expected Reload() to fail for an invalid speaking rate")
+	}
+	if got := manager.Get().TTS.SpeakingRate; got != 1.5 {
+		t.Errorf("Expected the current speaking rate 1.5 to stay in effect, got %v", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	manager, configFile := loadConfigFile(t, "tts:\n  voice: \"en-US-Wavenet-D\"\n")
+
+	changes := make(chan *Change, 1)
+	manager.OnChange(func(change *Change) { changes <- change })
+	errs := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- manager.Watch(ctx, func(err error) { errs <- err }) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch() failed: %v", err)
+		}
+	}()
+
+	// Give the watcher time to start before writing
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(200 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		if err := os.WriteFile(configFile, []byte("tts:\n  voice: \"en-US-Neural2-F\"\n"), 0600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		select {
+		case change := <-changes:
+			if !change.Changed("tts.voice") || manager.Get().TTS.Voice != "en-US-Neural2-F" {
+				t.Errorf("Unexpected change %v", change.Keys)
+			}
+			return
+		case err := <-errs:
+			t.Fatalf("Reload failed: %v", err)
+		case <-deadline:
+			t.Fatal("Timed out waiting for the config file change")
+		case <-tick.C:
+		}
+	}
+}
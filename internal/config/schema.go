@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaURI identifies the JSON Schema dialect of Schema
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches durations the way time.ParseDuration reads them
+const durationPattern = `^-?([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// settingRule is the constraint of a setting, parsed from its validate tag
+// such as "min=0.25,max=4.0" or "omitempty,oneof=never always"
+type settingRule struct {
+	// required strings must not be empty; the section validators check
+	// them, since their messages depend on the setting
+	required bool
+	// omitEmpty allows the empty string besides oneOf
+	omitEmpty bool
+	oneOf     []string
+	// min and max are kept as written, e.g. -20.0 or 10s, for messages
+	min, max string
+}
+
+// parseRule parses a validate tag
+func parseRule(tag string) settingRule {
+	var rule settingRule
+	for _, part := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "required":
+			rule.required = true
+		case "omitempty":
+			rule.omitEmpty = true
+		case "oneof":
+			rule.oneOf = strings.Fields(value)
+		case "min":
+			rule.min = value
+		case "max":
+			rule.max = value
+		}
+	}
+	return rule
+}
+
+// validateRules checks the enums and ranges of the validate tags of a config
+// section struct, and of the sections nested in it
+func validateRules(prefix string, v reflect.Value) []*ValidationError {
+	var errors []*ValidationError
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+		key := prefix + "." + tag
+
+		if isSection(field.Type) {
+			errors = append(errors, validateRules(key, v.Field(i))...)
+			continue
+		}
+		if err := checkRule(key, v.Field(i), parseRule(field.Tag.Get("validate"))); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return errors
+}
+
+// checkRule checks one setting value against its rule
+func checkRule(key string, value reflect.Value, rule settingRule) *ValidationError {
+	if len(rule.oneOf) > 0 {
+		s := value.String()
+		if (s == "" && rule.omitEmpty) || contains(rule.oneOf, s) {
+			return nil
+		}
+		return enumError(key, s, rule.oneOf)
+	}
+	if rule.min == "" && rule.max == "" {
+		return nil
+	}
+
+	number := ruleNumber(value)
+	low, hasLow := parseBound(value, rule.min)
+	high, hasHigh := parseBound(value, rule.max)
+	switch {
+	case hasLow && hasHigh && (number < low || number > high):
+		return rangeError(key, value.Interface(), rule.min, rule.max)
+	case hasLow && !hasHigh && number < low:
+		return boundError(key, value.Interface(), "at least "+rule.min)
+	case hasHigh && !hasLow && number > high:
+		return boundError(key, value.Interface(), "at most "+rule.max)
+	}
+	return nil
+}
+
+// boundError reports a value beyond a one-sided bound
+func boundError(field string, value interface{}, constraint string) *ValidationError {
+	return &ValidationError{
+		Field:      field,
+		Value:      value,
+		Message:    "must be " + constraint,
+		Constraint: constraint,
+		Suggestion: fmt.Sprintf("set %s to a value %s", field, constraint),
+	}
+}
+
+// ruleNumber returns a numeric setting as a float64, durations in
+// nanoseconds
+func ruleNumber(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Float64:
+		return value.Float()
+	default:
+		return 0
+	}
+}
+
+// parseBound parses a min or max bound for the type of value
+func parseBound(value reflect.Value, bound string) (float64, bool) {
+	if bound == "" {
+		return 0, false
+	}
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(bound)
+		return float64(d), err == nil
+	}
+	n, err := strconv.ParseFloat(bound, 64)
+	return n, err == nil
+}
+
+// isSection reports whether a field type is a nested config section
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Duration(0))
+}
+
+// Schema returns a JSON Schema of the config file, generated from the Config
+// struct: the type, default, allowed values, and range of every setting.
+// Editors use it to complete and check config files.
+func Schema() map[string]interface{} {
+	schema := objectSchema(reflect.ValueOf(GetDefaults()).Elem())
+	schema["$schema"] = SchemaURI
+	schema["title"] = "assistant-cli configuration"
+	return schema
+}
+
+// objectSchema describes a config section struct
+func objectSchema(defaults reflect.Value) map[string]interface{} {
+	properties := make(map[string]interface{})
+	t := defaults.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+		if isSection(field.Type) {
+			properties[tag] = objectSchema(defaults.Field(i))
+			continue
+		}
+		properties[tag] = settingSchema(field, defaults.Field(i))
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// settingSchema describes one setting
+func settingSchema(field reflect.StructField, defaultValue reflect.Value) map[string]interface{} {
+	rule := parseRule(field.Tag.Get("validate"))
+	schema := make(map[string]interface{})
+
+	switch {
+	case field.Type == reflect.TypeOf(time.Duration(0)):
+		schema["type"] = "string"
+		schema["pattern"] = durationPattern
+		schema["default"] = time.Duration(defaultValue.Int()).String()
+		description := "Duration such as 30s, 5m, or 24h"
+		if rule.min != "" && rule.max != "" {
+			description += fmt.Sprintf(", between %s and %s", rule.min, rule.max)
+		}
+		schema["description"] = description
+		return schema
+	case field.Type.Kind() == reflect.String:
+		schema["type"] = "string"
+		if len(rule.oneOf) > 0 {
+			values := append([]string{}, rule.oneOf...)
+			if rule.omitEmpty {
+				values = append(values, "")
+			}
+			schema["enum"] = values
+		}
+		if rule.required {
+			schema["minLength"] = 1
+		}
+	case field.Type.Kind() == reflect.Bool:
+		schema["type"] = "boolean"
+	case field.Type.Kind() == reflect.Int:
+		schema["type"] = "integer"
+	case field.Type.Kind() == reflect.Float64:
+		schema["type"] = "number"
+	case field.Type.Kind() == reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = map[string]interface{}{"type": "string"}
+	case field.Type.Kind() == reflect.Map && isSection(field.Type.Elem()):
+		schema["type"] = "object"
+		schema["additionalProperties"] = objectSchema(reflect.New(field.Type.Elem()).Elem())
+	case field.Type.Kind() == reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = map[string]interface{}{"type": "string"}
+	}
+
+	if low, ok := parseBound(defaultValue, rule.min); ok {
+		schema["minimum"] = low
+	}
+	if high, ok := parseBound(defaultValue, rule.max); ok {
+		schema["maximum"] = high
+	}
+	schema["default"] = schemaDefault(defaultValue)
+	return schema
+}
+
+// schemaDefault returns a default value as it is written in a config file
+func schemaDefault(value reflect.Value) interface{} {
+	switch v := value.Interface().(type) {
+	case []string:
+		return append([]string{}, v...)
+	case map[string]string:
+		if v == nil {
+			return map[string]string{}
+		}
+		return v
+	case map[string]VoicePreset:
+		if v == nil {
+			return map[string]interface{}{}
+		}
+		return v
+	default:
+		return v
+	}
+}
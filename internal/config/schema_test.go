@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type ruleSection struct {
+	Rate  float64       `mapstructure:"rate" validate:"min=0.5,max=2.0"`
+	Wait  time.Duration `mapstructure:"wait" validate:"min=0s,max=10s"`
+	Mode  string        `mapstructure:"mode" validate:"omitempty,oneof=fast slow"`
+	Count int           `mapstructure:"count" validate:"min=1"`
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		section    ruleSection
+		wantField  string
+		constraint string
+	}{
+		{"valid", ruleSection{Rate: 1, Wait: time.Second, Count: 1}, "", ""},
+		{"empty enum allowed", ruleSection{Rate: 1, Mode: "", Count: 1}, "", ""},
+		{"float range", ruleSection{Rate: 2.5, Count: 1}, "section.rate", "between 0.5 and 2.0"},
+		{"duration range", ruleSection{Rate: 1, Wait: time.Minute, Count: 1}, "section.wait", "between 0s and 10s"},
+		{"enum", ruleSection{Rate: 1, Mode: "medium", Count: 1}, "section.mode", "one of: fast, slow"},
+		{"one-sided bound", ruleSection{Rate: 1, Count: 0}, "section.count", "at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validateRules("section", reflect.ValueOf(tt.section))
+			if tt.wantField == "" {
+				if len(errors) != 0 {
+					t.Errorf("Expected no errors, got %v", ValidationErrors(errors))
+				}
+				return
+			}
+			if len(errors) != 1 {
+				t.Fatalf("Expected one error, got %v", ValidationErrors(errors))
+			}
+			if errors[0].Field != tt.wantField || errors[0].Constraint != tt.constraint {
+				t.Errorf("Expected %s %q, got %s %q", tt.wantField, tt.constraint, errors[0].Field, errors[0].Constraint)
+			}
+		})
+	}
+}
+
+func TestValidateTTS_Presets(t *testing.T) {
+	cfg := GetDefaults()
+	cfg.TTS.Presets = map[string]VoicePreset{
+		"fine":  {Voice: "en-GB-News-K", SpeakingRate: 1.2},
+		"empty": {},
+		"bad":   {SpeakingRate: 5, Pitch: -30, Format: "AIFF", Language: "english"},
+	}
+
+	var fields []string
+	for _, err := range (&Manager{}).validateTTS(&cfg.TTS) {
+		fields = append(fields, err.Field)
+	}
+	sort.Strings(fields)
+	want := []string{"tts.presets.bad.format", "tts.presets.bad.language", "tts.presets.bad.pitch",
+		"tts.presets.bad.speaking_rate"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected errors for %v, got %v", want, fields)
+	}
+}
+
+// Every bound in a validate tag must parse for the type of its setting, or
+// the rule would silently not be checked
+func TestValidateTags_BoundsParse(t *testing.T) {
+	var check func(prefix string, v reflect.Value)
+	check = func(prefix string, v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key := prefix + field.Tag.Get("mapstructure")
+			if isSection(field.Type) {
+				check(key+".", v.Field(i))
+				continue
+			}
+			rule := parseRule(field.Tag.Get("validate"))
+			for _, bound := range []string{rule.min, rule.max} {
+				if _, ok := parseBound(v.Field(i), bound); bound != "" && !ok {
+					t.Errorf("%s: bound %q does not parse as %s", key, bound, field.Type)
+				}
+			}
+		}
+	}
+	check("", reflect.ValueOf(GetDefaults()).Elem())
+}
+
+func TestSchema(t *testing.T) {
+	// Round-trip through JSON to inspect the schema the way editors see it
+	data, err := json.Marshal(Schema())
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+
+	if schema["$schema"] != SchemaURI || schema["additionalProperties"] != false {
+		t.Errorf("Unexpected schema header: %v, additionalProperties %v", schema["$schema"], schema["additionalProperties"])
+	}
+
+	property := func(section, key string) map[string]interface{} {
+		t.Helper()
+		sections := schema["properties"].(map[string]interface{})
+		settings := sections[section].(map[string]interface{})["properties"].(map[string]interface{})
+		setting, ok := settings[key].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Schema has no %s.%s", section, key)
+		}
+		return setting
+	}
+
+	rate := property("tts", "speaking_rate")
+	if rate["type"] != "number" || rate["minimum"] != 0.25 || rate["maximum"] != 4.0 || rate["default"] != 1.0 {
+		t.Errorf("Unexpected tts.speaking_rate schema: %v", rate)
+	}
+
+	method := property("auth", "method")
+	wantMethods := []interface{}{"auto", "apikey", "serviceaccount", "oauth2", ""}
+	if !reflect.DeepEqual(method["enum"], wantMethods) {
+		t.Errorf("Expected auth.method enum %v, got %v", wantMethods, method["enum"])
+	}
+
+	timeout := property("auth", "timeout")
+	if timeout["type"] != "string" || timeout["default"] != "30s" || timeout["pattern"] != durationPattern {
+		t.Errorf("Unexpected auth.timeout schema: %v", timeout)
+	}
+
+	if language := property("tts", "language"); language["minLength"] != 1.0 {
+		t.Errorf("Expected tts.language to be required, got %v", language)
+	}
+	if players := property("playback", "format_players"); players["type"] != "object" {
+		t.Errorf("Expected playback.format_players to be an object, got %v", players)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
+	"github.com/spf13/viper"
+)
+
+// SensitiveKeys returns the settings holding credentials, marked by the
+// sensitive struct tag
+func SensitiveKeys() []string {
+	var keys []string
+	for _, binding := range EnvBindings() {
+		if binding.Sensitive {
+			keys = append(keys, binding.Key)
+		}
+	}
+	return keys
+}
+
+// decryptSecrets replaces the encrypted sensitive settings of cfg with their
+// plain text. The passphrase is only looked up when a setting is encrypted.
+// Settings that cannot be decrypted are cleared, so that the encrypted text
+// is never sent as a credential.
+func decryptSecrets(cfg *Config) error {
+	var passphrase string
+	var firstErr error
+	for _, key := range SensitiveKeys() {
+		field, err := settingField(cfg, key)
+		if err != nil || field.Kind() != reflect.String || !secrets.IsEncrypted(field.String()) {
+			continue
+		}
+
+		if passphrase == "" && firstErr == nil {
+			passphrase, err = secrets.Passphrase()
+		}
+		var plaintext []byte
+		if err == nil && passphrase != "" {
+			plaintext, err = secrets.Decrypt(field.String(), passphrase)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		field.SetString(string(plaintext))
+	}
+	return firstErr
+}
+
+// EncryptSecrets encrypts the sensitive settings stored in plain text in the
+// config file at path, in place, and returns their keys
+func EncryptSecrets(path, passphrase string) ([]string, error) {
+	return rewriteSecrets(path, func(value string) (string, bool, error) {
+		if secrets.IsEncrypted(value) {
+			return "", false, nil
+		}
+		encrypted, err := secrets.Encrypt([]byte(value), passphrase)
+		return encrypted, true, err
+	})
+}
+
+// DecryptSecrets stores the encrypted sensitive settings of the config file
+// at path in plain text again, in place, and returns their keys
+func DecryptSecrets(path, passphrase string) ([]string, error) {
+	return rewriteSecrets(path, func(value string) (string, bool, error) {
+		if !secrets.IsEncrypted(value) {
+			return "", false, nil
+		}
+		plaintext, err := secrets.Decrypt(value, passphrase)
+		return string(plaintext), true, err
+	})
+}
+
+// rewriteSecrets replaces each sensitive setting set in the config file at
+// path with the result of convert, when it reports a change
+func rewriteSecrets(path string, convert func(value string) (string, bool, error)) ([]string, error) {
+	file := viper.New()
+	file.SetConfigFile(path)
+	if err := file.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var changed []string
+	for _, key := range SensitiveKeys() {
+		value := file.GetString(key)
+		if !file.InConfig(key) || value == "" {
+			continue
+		}
+		converted, ok, err := convert(value)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", key, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := writeSetting(path, key, converted); err != nil {
+			return changed, err
+		}
+		changed = append(changed, key)
+	}
+	return changed, nil
+}
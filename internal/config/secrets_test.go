@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
+)
+
+func TestSensitiveKeys(t *testing.T) {
+	keys := strings.Join(SensitiveKeys(), ",")
+	if keys != "auth.api_key,auth.oauth2_client_secret,mqtt.password" {
+		t.Errorf("SensitiveKeys() = %s", keys)
+	}
+}
+
+func TestLoad_EncryptedSecrets(t *testing.T) {
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	encrypted, err := secrets.Encrypt([]byte("AIzaSyExampleKeyThatIsLongEnough123456"), "correct horse")
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("auth:\n  api_key: \""+encrypted+"\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	t.Setenv(secrets.PassphraseEnv, "correct horse")
+	manager := NewManager()
+	manager.SetConfigFile(configFile)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := manager.Get().Auth.APIKey; got != "AIzaSyExampleKeyThatIsLongEnough123456" {
+		t.Errorf("APIKey = %q, want the decrypted key", got)
+	}
+
+	t.Setenv(secrets.PassphraseEnv, "battery staple")
+	manager = NewManager()
+	manager.SetConfigFile(configFile)
+	err = manager.Load()
+	if !errors.Is(err, secrets.ErrDecrypt) {
+		t.Errorf("Load() error = %v, want ErrDecrypt", err)
+	}
+	if got := manager.Get().Auth.APIKey; got != "" {
+		t.Errorf("APIKey = %q, want it cleared when it cannot be decrypted", got)
+	}
+}
+
+func TestEncryptSecrets(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := "# my settings\nauth:\n  api_key: \"AIzaSyExampleKeyThatIsLongEnough123456\"\n"
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	keys, err := EncryptSecrets(configFile, "correct horse")
+	if err != nil {
+		t.Fatalf("EncryptSecrets() failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "auth.api_key" {
+		t.Errorf("EncryptSecrets() = %v, want [auth.api_key]", keys)
+	}
+	data, _ := os.ReadFile(configFile)
+	if strings.Contains(string(data), "AIzaSy") || !strings.Contains(string(data), "# my settings") {
+		t.Errorf("unexpected config file after encryption:\n%s", data)
+	}
+
+	if _, err := DecryptSecrets(configFile, "battery staple"); !errors.Is(err, secrets.ErrDecrypt) {
+		t.Errorf("DecryptSecrets() with the wrong passphrase: %v", err)
+	}
+	if _, err := DecryptSecrets(configFile, "correct horse"); err != nil {
+		t.Fatalf("DecryptSecrets() failed: %v", err)
+	}
+	data, _ = os.ReadFile(configFile)
+	if !strings.Contains(string(data), "AIzaSyExampleKeyThatIsLongEnough123456") {
+		t.Errorf("key not decrypted:\n%s", data)
+	}
+}
@@ -1,26 +1,90 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/scheduler"
+	"github.com/mikefarmer/assistant-cli/pkg/utils/suggest"
 )
 
-// ValidationError represents a configuration validation error
+// ValidationError represents a configuration validation error. Constraint
+// and Suggestion are optional and describe the accepted values and a likely fix.
 type ValidationError struct {
-	Field   string
-	Value   interface{}
-	Message string
+	Field      string      `json:"field" yaml:"field"`
+	Value      interface{} `json:"value" yaml:"value"`
+	Message    string      `json:"message" yaml:"message"`
+	Constraint string      `json:"constraint,omitempty" yaml:"constraint,omitempty"`
+	Suggestion string      `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
 }
 
 func (e *ValidationError) Error() string {
 	return fmt.Sprintf("config validation error for field '%s': %s (value: %v)", e.Field, e.Message, e.Value)
 }
 
+// validationErrorDoc is the serialized form of a ValidationError
+type validationErrorDoc ValidationError
+
+// MarshalJSON renders durations in the value as strings (e.g. "1m30s") rather
+// than nanosecond counts, matching how they are written in config files
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.document())
+}
+
+// MarshalYAML renders the error like MarshalJSON
+func (e *ValidationError) MarshalYAML() (interface{}, error) {
+	return e.document(), nil
+}
+
+func (e *ValidationError) document() *validationErrorDoc {
+	doc := validationErrorDoc(*e)
+	if d, ok := e.Value.(time.Duration); ok {
+		doc.Value = d.String()
+	}
+	return &doc
+}
+
+// rangeError reports a value outside the inclusive range [low, high]
+func rangeError(field string, value interface{}, low, high string) *ValidationError {
+	constraint := fmt.Sprintf("between %s and %s", low, high)
+	return &ValidationError{
+		Field:      field,
+		Value:      value,
+		Message:    "must be " + constraint,
+		Constraint: constraint,
+		Suggestion: fmt.Sprintf("set %s to a value %s", field, constraint),
+	}
+}
+
+// enumError reports a value that is not one of allowed, suggesting the closest
+// allowed value when the input looks like a typo
+func enumError(field, value string, allowed []string) *ValidationError {
+	constraint := "one of: " + strings.Join(allowed, ", ")
+	suggestion := fmt.Sprintf("set %s to %s", field, constraint)
+	if match, ok := suggest.Closest(value, allowed); ok {
+		suggestion = fmt.Sprintf("did you mean %q?", match)
+	}
+
+	return &ValidationError{
+		Field:      field,
+		Value:      value,
+		Message:    "must be " + constraint,
+		Constraint: constraint,
+		Suggestion: suggestion,
+	}
+}
+
 // ValidationErrors represents multiple validation errors
 type ValidationErrors []*ValidationError
 
@@ -43,6 +107,11 @@ func (m *Manager) ValidateComprehensive() error {
 		errors = append(errors, authErrors...)
 	}
 
+	// Validate Network configuration
+	if networkErrors := m.validateNetwork(&config.Network); networkErrors != nil {
+		errors = append(errors, networkErrors...)
+	}
+
 	// Validate TTS configuration
 	if ttsErrors := m.validateTTS(&config.TTS); ttsErrors != nil {
 		errors = append(errors, ttsErrors...)
@@ -63,6 +132,21 @@ func (m *Manager) ValidateComprehensive() error {
 		errors = append(errors, inputErrors...)
 	}
 
+	// Validate Alerts configuration
+	if alertErrors := m.validateAlerts(&config.Alerts); alertErrors != nil {
+		errors = append(errors, alertErrors...)
+	}
+
+	// Validate Scheduler configuration
+	if schedulerErrors := m.validateScheduler(&config.Scheduler); schedulerErrors != nil {
+		errors = append(errors, schedulerErrors...)
+	}
+
+	// Validate MQTT configuration
+	if mqttErrors := m.validateMQTT(&config.MQTT); mqttErrors != nil {
+		errors = append(errors, mqttErrors...)
+	}
+
 	// Validate Logging configuration
 	if loggingErrors := m.validateLogging(&config.Logging); loggingErrors != nil {
 		errors = append(errors, loggingErrors...)
@@ -82,17 +166,8 @@ func (m *Manager) ValidateComprehensive() error {
 
 // validateAuth validates authentication configuration
 func (m *Manager) validateAuth(auth *AuthConfig) []*ValidationError {
-	var errors []*ValidationError
-
-	// Validate method
-	validMethods := []string{"auto", "apikey", "serviceaccount", "oauth2"}
-	if auth.Method != "" && !contains(validMethods, auth.Method) {
-		errors = append(errors, &ValidationError{
-			Field:   "auth.method",
-			Value:   auth.Method,
-			Message: fmt.Sprintf("must be one of: %s", strings.Join(validMethods, ", ")),
-		})
-	}
+	// Enums and ranges come from the validate tags
+	errors := validateRules("auth", reflect.ValueOf(auth).Elem())
 
 	// Validate service account file if specified
 	if auth.ServiceAccountFile != "" {
@@ -106,9 +181,10 @@ func (m *Manager) validateAuth(auth *AuthConfig) []*ValidationError {
 
 		if _, err := os.Stat(expandPath(auth.ServiceAccountFile)); os.IsNotExist(err) {
 			errors = append(errors, &ValidationError{
-				Field:   "auth.service_account_file",
-				Value:   auth.ServiceAccountFile,
-				Message: "file does not exist",
+				Field:      "auth.service_account_file",
+				Value:      auth.ServiceAccountFile,
+				Message:    "file does not exist",
+				Suggestion: "check the path or run 'assistant-cli login' to set up credentials",
 			})
 		}
 	}
@@ -128,26 +204,62 @@ func (m *Manager) validateAuth(auth *AuthConfig) []*ValidationError {
 	// Validate timeout
 	if auth.Timeout < 0 {
 		errors = append(errors, &ValidationError{
-			Field:   "auth.timeout",
-			Value:   auth.Timeout,
-			Message: "must be non-negative",
+			Field:      "auth.timeout",
+			Value:      auth.Timeout,
+			Message:    "must be non-negative",
+			Constraint: "non-negative",
 		})
 	}
 	if auth.Timeout > 5*time.Minute {
 		errors = append(errors, &ValidationError{
-			Field:   "auth.timeout",
-			Value:   auth.Timeout,
-			Message: "timeout too long (max 5 minutes)",
+			Field:      "auth.timeout",
+			Value:      auth.Timeout,
+			Message:    "timeout too long (max 5 minutes)",
+			Constraint: "at most 5m",
 		})
 	}
 
-	// Validate retry attempts
-	if auth.RetryAttempts < 0 || auth.RetryAttempts > 10 {
-		errors = append(errors, &ValidationError{
-			Field:   "auth.retry_attempts",
-			Value:   auth.RetryAttempts,
-			Message: "must be between 0 and 10",
-		})
+	return errors
+}
+
+// validateNetwork validates network configuration
+func (m *Manager) validateNetwork(network *NetworkConfig) []*ValidationError {
+	errors := validateRules("network", reflect.ValueOf(network).Elem())
+
+	// Validate proxy URL; only plain HTTP proxies tunnelling with CONNECT are supported
+	if network.Proxy != "" {
+		proxy, err := url.Parse(network.Proxy)
+		if err != nil || proxy.Scheme != "http" || proxy.Host == "" {
+			errors = append(errors, &ValidationError{
+				Field:      "network.proxy",
+				Value:      network.Proxy,
+				Message:    "must be an HTTP proxy URL",
+				Constraint: "http://host:port",
+			})
+		}
+	}
+
+	// Validate endpoint
+	if network.TTSEndpoint != "" {
+		if _, _, err := net.SplitHostPort(network.TTSEndpoint); err != nil {
+			errors = append(errors, &ValidationError{
+				Field:      "network.tts_endpoint",
+				Value:      network.TTSEndpoint,
+				Message:    "must be a host:port address",
+				Constraint: "host:port",
+			})
+		}
+	}
+
+	// Validate CA bundle
+	if network.CABundle != "" {
+		if _, err := os.Stat(expandPath(network.CABundle)); os.IsNotExist(err) {
+			errors = append(errors, &ValidationError{
+				Field:   "network.ca_bundle",
+				Value:   network.CABundle,
+				Message: "file does not exist",
+			})
+		}
 	}
 
 	return errors
@@ -155,82 +267,126 @@ func (m *Manager) validateAuth(auth *AuthConfig) []*ValidationError {
 
 // validateTTS validates TTS configuration
 func (m *Manager) validateTTS(tts *TTSConfig) []*ValidationError {
-	var errors []*ValidationError
+	errors := validateRules("tts", reflect.ValueOf(tts).Elem())
 
 	// Validate language (required)
 	if tts.Language == "" {
 		errors = append(errors, &ValidationError{
-			Field:   "tts.language",
-			Value:   tts.Language,
-			Message: "is required",
+			Field:      "tts.language",
+			Value:      tts.Language,
+			Message:    "is required",
+			Suggestion: "set tts.language to a language code such as en-US",
 		})
 	} else if !isValidLanguageCode(tts.Language) {
 		errors = append(errors, &ValidationError{
-			Field:   "tts.language",
-			Value:   tts.Language,
-			Message: "invalid language code format (expected format: en-US)",
+			Field:      "tts.language",
+			Value:      tts.Language,
+			Message:    "invalid language code format (expected format: en-US)",
+			Constraint: "language code such as en-US",
 		})
 	}
 
-	// Validate speaking rate
-	if tts.SpeakingRate < 0.25 || tts.SpeakingRate > 4.0 {
+	// Validate timeout
+	if tts.Timeout < 0 {
 		errors = append(errors, &ValidationError{
-			Field:   "tts.speaking_rate",
-			Value:   tts.SpeakingRate,
-			Message: "must be between 0.25 and 4.0",
+			Field:      "tts.timeout",
+			Value:      tts.Timeout,
+			Message:    "must be non-negative",
+			Constraint: "non-negative",
 		})
 	}
-
-	// Validate pitch
-	if tts.Pitch < -20.0 || tts.Pitch > 20.0 {
+	if tts.Timeout > 10*time.Minute {
 		errors = append(errors, &ValidationError{
-			Field:   "tts.pitch",
-			Value:   tts.Pitch,
-			Message: "must be between -20.0 and 20.0",
+			Field:      "tts.timeout",
+			Value:      tts.Timeout,
+			Message:    "timeout too long (max 10 minutes)",
+			Constraint: "at most 10m",
 		})
 	}
 
-	// Validate volume gain
-	if tts.VolumeGain < -96.0 || tts.VolumeGain > 16.0 {
-		errors = append(errors, &ValidationError{
-			Field:   "tts.volume_gain",
-			Value:   tts.VolumeGain,
-			Message: "must be between -96.0 and 16.0",
-		})
+	for alias, voice := range tts.VoiceAliases {
+		key := "tts.voice_aliases." + alias
+		if err := ValidateVoiceAliasName(alias); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		switch _, chained := tts.VoiceAliases[strings.ToLower(voice)]; {
+		case strings.TrimSpace(voice) == "":
+			errors = append(errors, &ValidationError{
+				Field:      key,
+				Value:      voice,
+				Message:    "is empty",
+				Suggestion: "give a voice name such as en-US-Neural2-F, or remove the alias",
+			})
+		case chained:
+			errors = append(errors, &ValidationError{
+				Field:      key,
+				Value:      voice,
+				Message:    "names another alias",
+				Suggestion: fmt.Sprintf("give the voice of %s itself", voice),
+			})
+		}
 	}
 
-	// Validate audio encoding
-	validEncodings := []string{"MP3", "LINEAR16", "OGG_OPUS", "MULAW", "ALAW", "PCM"}
-	if tts.AudioEncoding != "" && !contains(validEncodings, tts.AudioEncoding) {
-		errors = append(errors, &ValidationError{
-			Field:   "tts.audio_encoding",
-			Value:   tts.AudioEncoding,
-			Message: fmt.Sprintf("must be one of: %s", strings.Join(validEncodings, ", ")),
-		})
+	for language, voice := range tts.LanguageVoices {
+		key := "tts.language_voices." + language
+		switch {
+		case !languageVoiceKeyPattern.MatchString(language):
+			errors = append(errors, &ValidationError{
+				Field:      key,
+				Value:      language,
+				Message:    "invalid language code format (expected format: en-US or en)",
+				Constraint: "language code such as en-US, or language such as en",
+			})
+		case strings.TrimSpace(voice) == "":
+			errors = append(errors, &ValidationError{
+				Field:      key,
+				Value:      voice,
+				Message:    "is empty",
+				Suggestion: "give a voice name such as Neural2-F, or remove the entry",
+			})
+		case !strings.Contains(language, "-") && !voiceLanguagePattern.MatchString(voice):
+			errors = append(errors, &ValidationError{
+				Field:      key,
+				Value:      voice,
+				Message:    "must be a full voice name for a language without a region",
+				Suggestion: fmt.Sprintf("give the voice with its language code, such as %s-XX-Neural2-A", language),
+			})
+		}
 	}
 
-	// Validate timeout
-	if tts.Timeout < 0 {
-		errors = append(errors, &ValidationError{
-			Field:   "tts.timeout",
-			Value:   tts.Timeout,
-			Message: "must be non-negative",
-		})
+	for name, preset := range tts.Presets {
+		key := "tts.presets." + name
+		errors = append(errors, validateRules(key, reflect.ValueOf(preset))...)
+		if preset.SpeakingRate != 0 && (preset.SpeakingRate < 0.25 || preset.SpeakingRate > 4.0) {
+			errors = append(errors, rangeError(key+".speaking_rate", preset.SpeakingRate, "0.25", "4.0"))
+		}
+		if preset.Language != "" && !isValidLanguageCode(preset.Language) {
+			errors = append(errors, &ValidationError{
+				Field:      key + ".language",
+				Value:      preset.Language,
+				Message:    "invalid language code format (expected format: en-US)",
+				Constraint: "language code such as en-US",
+			})
+		}
 	}
-	if tts.Timeout > 10*time.Minute {
+
+	// Validate retry delays
+	if tts.MaxRetryDelay > 0 && tts.RetryDelay > tts.MaxRetryDelay {
 		errors = append(errors, &ValidationError{
-			Field:   "tts.timeout",
-			Value:   tts.Timeout,
-			Message: "timeout too long (max 10 minutes)",
+			Field:      "tts.retry_delay",
+			Value:      tts.RetryDelay,
+			Message:    "must not exceed tts.max_retry_delay",
+			Constraint: fmt.Sprintf("at most %s", tts.MaxRetryDelay),
 		})
 	}
 
-	// Validate max retries
-	if tts.MaxRetries < 0 || tts.MaxRetries > 10 {
+	if tts.TotalTimeout > 0 && tts.TotalTimeout < tts.Timeout {
 		errors = append(errors, &ValidationError{
-			Field:   "tts.max_retries",
-			Value:   tts.MaxRetries,
-			Message: "must be between 0 and 10",
+			Field:      "tts.total_timeout",
+			Value:      tts.TotalTimeout,
+			Message:    "must not be shorter than tts.timeout",
+			Constraint: fmt.Sprintf("at least %s, or 0s", tts.Timeout),
 		})
 	}
 
@@ -239,7 +395,7 @@ func (m *Manager) validateTTS(tts *TTSConfig) []*ValidationError {
 
 // validateOutput validates output configuration
 func (m *Manager) validateOutput(output *OutputConfig) []*ValidationError {
-	var errors []*ValidationError
+	errors := validateRules("output", reflect.ValueOf(output).Elem())
 
 	// Validate default path
 	if output.DefaultPath != "" {
@@ -253,26 +409,6 @@ func (m *Manager) validateOutput(output *OutputConfig) []*ValidationError {
 		}
 	}
 
-	// Validate format
-	validFormats := []string{"MP3", "LINEAR16", "WAV", "OGG_OPUS", "MULAW", "ALAW", "PCM"}
-	if output.Format != "" && !contains(validFormats, output.Format) {
-		errors = append(errors, &ValidationError{
-			Field:   "output.format",
-			Value:   output.Format,
-			Message: fmt.Sprintf("must be one of: %s", strings.Join(validFormats, ", ")),
-		})
-	}
-
-	// Validate overwrite mode
-	validModes := []string{"never", "always", "prompt", "backup"}
-	if output.OverwriteMode != "" && !contains(validModes, output.OverwriteMode) {
-		errors = append(errors, &ValidationError{
-			Field:   "output.overwrite_mode",
-			Value:   output.OverwriteMode,
-			Message: fmt.Sprintf("must be one of: %s", strings.Join(validModes, ", ")),
-		})
-	}
-
 	// Validate file permissions
 	if output.FilePermissions != "" {
 		if err := validateOctalPermissions(output.FilePermissions); err != nil {
@@ -295,94 +431,321 @@ func (m *Manager) validateOutput(output *OutputConfig) []*ValidationError {
 		}
 	}
 
-	// Validate max filename length
-	if output.MaxFilenameLength < 10 || output.MaxFilenameLength > 255 {
-		errors = append(errors, &ValidationError{
-			Field:   "output.max_filename_length",
-			Value:   output.MaxFilenameLength,
-			Message: "must be between 10 and 255",
-		})
+	// Validate backup retention
+	if err := validateBackupRetention(output.BackupRetention); err != nil {
+		errors = append(errors, err)
+	}
+
+	// Validate path policy
+	errors = append(errors, validatePathPolicy(&output.PathPolicy)...)
+
+	// Validate S3 endpoint and webhook
+	if err := validateHTTPURL("output.storage.s3_endpoint", output.Storage.S3Endpoint); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateHTTPURL("output.notify_webhook", output.NotifyWebhook); err != nil {
+		errors = append(errors, err)
+	}
+
+	// Validate filename template
+	if output.FilenameTemplate != "" {
+		if err := validateFilenameTemplate("output.filename_template", output.FilenameTemplate); err != nil {
+			errors = append(errors, err)
+		}
 	}
 
 	return errors
 }
 
-// validatePlayback validates playback configuration
-func (m *Manager) validatePlayback(playback *PlaybackConfig) []*ValidationError {
+// validatePathPolicy checks that the policy lists hold directories and
+// extensions starting with a dot
+func validatePathPolicy(policy *PathPolicyConfig) []*ValidationError {
 	var errors []*ValidationError
+	checkDirs := func(key string, dirs []string) {
+		for _, dir := range dirs {
+			if strings.TrimSpace(dir) == "" {
+				errors = append(errors, &ValidationError{
+					Field:   "output.path_policy." + key,
+					Value:   dir,
+					Message: "directory cannot be empty",
+				})
+			}
+		}
+	}
+	checkExtensions := func(key string, extensions []string) {
+		for _, ext := range extensions {
+			if len(ext) < 2 || !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, `/\`) {
+				errors = append(errors, &ValidationError{
+					Field:      "output.path_policy." + key,
+					Value:      ext,
+					Message:    "must be a file extension starting with a dot",
+					Suggestion: `e.g. ".mp3"`,
+				})
+			}
+		}
+	}
 
-	// Validate volume
-	if playback.Volume < 0.0 || playback.Volume > 1.0 {
-		errors = append(errors, &ValidationError{
-			Field:   "playback.volume",
-			Value:   playback.Volume,
-			Message: "must be between 0.0 and 1.0",
-		})
+	checkDirs("allowed_dirs", policy.AllowedDirs)
+	checkDirs("blocked_dirs", policy.BlockedDirs)
+	checkExtensions("allowed_extensions", policy.AllowedExtensions)
+	checkExtensions("blocked_extensions", policy.BlockedExtensions)
+	return errors
+}
+
+// validateFilenameTemplate checks that pattern parses and only uses known fields
+func validateFilenameTemplate(field, pattern string) *ValidationError {
+	if _, err := output.ParseFilenameTemplate(pattern); err != nil {
+		return &ValidationError{
+			Field:      field,
+			Value:      pattern,
+			Message:    err.Error(),
+			Suggestion: "available fields: .Text .Voice .Language .Format .Ext .Date .Time .Timestamp .Hash",
+		}
+	}
+	return nil
+}
+
+// validateHTTPURL checks that a non-empty value is an HTTP or HTTPS URL
+func validateHTTPURL(field, value string) *ValidationError {
+	if value == "" {
+		return nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return &ValidationError{
+			Field:      field,
+			Value:      value,
+			Message:    "must be an HTTP or HTTPS URL",
+			Constraint: "http(s)://host[:port]/path",
+		}
+	}
+	return nil
+}
+
+// validateBackupRetention checks that retention is a count or an age
+func validateBackupRetention(retention string) *ValidationError {
+	if _, err := output.ParseBackupRetention(retention); err != nil {
+		return &ValidationError{
+			Field:      "output.backup_retention",
+			Value:      retention,
+			Message:    err.Error(),
+			Suggestion: `use a count such as "5" or an age such as "720h" or "30d"`,
+		}
 	}
+	return nil
+}
+
+// validatePlayback validates playback configuration
+func (m *Manager) validatePlayback(playback *PlaybackConfig) []*ValidationError {
+	errors := validateRules("playback", reflect.ValueOf(playback).Elem())
 
 	// Validate player if specified
 	if playback.Player != "" {
-		// Check if it's a valid command (basic validation)
-		if strings.ContainsAny(playback.Player, "<>:\"|?*") {
-			errors = append(errors, &ValidationError{
-				Field:   "playback.player",
-				Value:   playback.Player,
-				Message: "contains invalid characters for a command",
-			})
+		if err := validatePlayerName("playback.player", playback.Player); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	for i, player := range playback.Players {
+		if err := validatePlayerName(fmt.Sprintf("playback.players[%d]", i), player); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	formats := make([]string, 0, len(playback.FormatPlayers))
+	for format := range playback.FormatPlayers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	for _, format := range formats {
+		if err := validatePlayerName("playback.format_players."+format, playback.FormatPlayers[format]); err != nil {
+			errors = append(errors, err)
 		}
 	}
 
 	return errors
 }
 
+// validatePlayerName checks that a player is a plausible command name
+func validatePlayerName(field, player string) *ValidationError {
+	switch {
+	case strings.TrimSpace(player) == "":
+		return &ValidationError{Field: field, Value: player, Message: "player cannot be empty"}
+	case strings.ContainsAny(player, "<>:\"|?*"):
+		// Check if it's a valid command (basic validation)
+		return &ValidationError{Field: field, Value: player, Message: "contains invalid characters for a command"}
+	}
+	return nil
+}
+
 // validateInput validates input configuration
 func (m *Manager) validateInput(input *InputConfig) []*ValidationError {
-	var errors []*ValidationError
+	// Lengths, sizes, and the maximum break time only have ranges
+	return validateRules("input", reflect.ValueOf(input).Elem())
+}
 
-	// Validate max length
-	if input.MaxLength <= 0 || input.MaxLength > 100000 {
-		errors = append(errors, &ValidationError{
-			Field:   "input.max_length",
-			Value:   input.MaxLength,
-			Message: "must be between 1 and 100000",
-		})
-	}
+// validateAlerts validates the alert patterns
+func (m *Manager) validateAlerts(alerts *AlertsConfig) []*ValidationError {
+	errors := validateRules("alerts", reflect.ValueOf(alerts).Elem())
 
-	// Validate buffer size
-	if input.BufferSize < 1024 || input.BufferSize > 65536 {
-		errors = append(errors, &ValidationError{
-			Field:   "input.buffer_size",
-			Value:   input.BufferSize,
-			Message: "must be between 1024 and 65536",
-		})
+	for i, pattern := range alerts.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errors = append(errors, &ValidationError{
+				Field:      fmt.Sprintf("alerts.patterns[%d]", i),
+				Value:      pattern,
+				Message:    fmt.Sprintf("invalid regular expression: %v", err),
+				Suggestion: "escape backslashes in YAML double-quoted strings, e.g. \"\\\\d+\"",
+			})
+		}
 	}
-
 	return errors
 }
 
-// validateLogging validates logging configuration
-func (m *Manager) validateLogging(logging *LoggingConfig) []*ValidationError {
-	var errors []*ValidationError
+// validateScheduler validates the scheduled jobs
+func (m *Manager) validateScheduler(schedulerConfig *SchedulerConfig) []*ValidationError {
+	errors := validateRules("scheduler", reflect.ValueOf(schedulerConfig).Elem())
+
+	names := make(map[string]bool)
+	for i, job := range schedulerConfig.Jobs {
+		field := fmt.Sprintf("scheduler.jobs[%d]", i)
+		switch {
+		case strings.TrimSpace(job.Name) == "":
+			errors = append(errors, &ValidationError{
+				Field:   field + ".name",
+				Message: "is required",
+			})
+		case names[job.Name]:
+			errors = append(errors, &ValidationError{
+				Field:      field + ".name",
+				Value:      job.Name,
+				Message:    "is used by another job",
+				Suggestion: "give each job its own name",
+			})
+		}
+		names[job.Name] = true
+
+		if _, err := scheduler.Parse(job.Schedule); err != nil {
+			errors = append(errors, &ValidationError{
+				Field:      field + ".schedule",
+				Value:      job.Schedule,
+				Message:    err.Error(),
+				Suggestion: `use a cron expression such as "30 7 * * mon-fri", @daily, or "@every 2h"`,
+			})
+		}
+
+		sources := 0
+		for _, source := range []string{job.URL, job.Command, job.Text} {
+			if strings.TrimSpace(source) != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			errors = append(errors, &ValidationError{
+				Field:      field,
+				Value:      job.Name,
+				Message:    "needs exactly one of url, command, and text",
+				Suggestion: "set the one source the job reads its text from",
+			})
+		}
+		if err := validateHTTPURL(field+".url", job.URL); err != nil {
+			errors = append(errors, err)
+		}
+		if err := validateHTTPURL(field+".notify_url", job.NotifyURL); err != nil {
+			errors = append(errors, err)
+		}
+
+		if job.Output != "" {
+			if err := validateFilenameTemplate(field+".output", job.Output); err != nil {
+				errors = append(errors, err)
+			}
+		} else if !job.Play {
+			errors = append(errors, &ValidationError{
+				Field:      field,
+				Value:      job.Name,
+				Message:    "neither writes nor plays its audio",
+				Suggestion: "set output, play, or both",
+			})
+		}
+	}
+	return errors
+}
 
-	// Validate level
-	validLevels := []string{"debug", "info", "warn", "error"}
-	if logging.Level != "" && !contains(validLevels, logging.Level) {
+// validateMQTT validates the broker and TLS settings of the mqtt command
+func (m *Manager) validateMQTT(mqtt *MQTTConfig) []*ValidationError {
+	errors := validateRules("mqtt", reflect.ValueOf(mqtt).Elem())
+
+	if mqtt.Broker != "" {
+		parsed, err := url.Parse(mqtt.Broker)
+		valid := err == nil && parsed.Host != ""
+		if valid {
+			switch parsed.Scheme {
+			case "mqtt", "tcp", "mqtts", "ssl", "tls":
+			default:
+				valid = false
+			}
+		}
+		if !valid {
+			errors = append(errors, &ValidationError{
+				Field:      "mqtt.broker",
+				Value:      mqtt.Broker,
+				Message:    "must be an MQTT broker URL",
+				Constraint: "mqtt://host[:port] or mqtts://host[:port]",
+			})
+		}
+	}
+	if strings.TrimSpace(mqtt.Topic) == "" {
+		errors = append(errors, &ValidationError{
+			Field:   "mqtt.topic",
+			Message: "is required",
+		})
+	}
+	if strings.ContainsAny(mqtt.ResultTopic, "#+") {
+		errors = append(errors, &ValidationError{
+			Field:      "mqtt.result_topic",
+			Value:      mqtt.ResultTopic,
+			Message:    "cannot contain the wildcards # and +",
+			Suggestion: "publish results to a plain topic such as assistant-cli/result",
+		})
+	}
+	if (mqtt.CertFile == "") != (mqtt.KeyFile == "") {
 		errors = append(errors, &ValidationError{
-			Field:   "logging.level",
-			Value:   logging.Level,
-			Message: fmt.Sprintf("must be one of: %s", strings.Join(validLevels, ", ")),
+			Field:      "mqtt.cert_file",
+			Value:      mqtt.CertFile,
+			Message:    "needs mqtt.key_file, and the key the certificate",
+			Suggestion: "set both cert_file and key_file, or neither",
 		})
 	}
+	for field, path := range map[string]string{"ca_file": mqtt.CAFile, "cert_file": mqtt.CertFile, "key_file": mqtt.KeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(expandPath(path)); os.IsNotExist(err) {
+			errors = append(errors, &ValidationError{
+				Field:   "mqtt." + field,
+				Value:   path,
+				Message: "file does not exist",
+			})
+		}
+	}
 
-	// Validate format
-	validFormats := []string{"text", "json"}
-	if logging.Format != "" && !contains(validFormats, logging.Format) {
+	if mqtt.Output != "" {
+		if err := validateFilenameTemplate("mqtt.output", mqtt.Output); err != nil {
+			errors = append(errors, err)
+		}
+	} else if !mqtt.Play {
 		errors = append(errors, &ValidationError{
-			Field:   "logging.format",
-			Value:   logging.Format,
-			Message: fmt.Sprintf("must be one of: %s", strings.Join(validFormats, ", ")),
+			Field:      "mqtt.play",
+			Value:      mqtt.Play,
+			Message:    "messages would neither be written nor played",
+			Suggestion: "set mqtt.output, mqtt.play, or both",
 		})
 	}
+	return errors
+}
+
+// validateLogging validates logging configuration
+func (m *Manager) validateLogging(logging *LoggingConfig) []*ValidationError {
+	errors := validateRules("logging", reflect.ValueOf(logging).Elem())
 
 	// Validate output
 	if logging.Output != "" && logging.Output != "stdout" && logging.Output != "stderr" {
@@ -403,7 +766,7 @@ func (m *Manager) validateLogging(logging *LoggingConfig) []*ValidationError {
 
 // validateApp validates app configuration
 func (m *Manager) validateApp(app *AppConfig) []*ValidationError {
-	var errors []*ValidationError
+	errors := validateRules("app", reflect.ValueOf(app).Elem())
 
 	// Validate config version format
 	if app.ConfigVersion != "" {
@@ -419,16 +782,35 @@ func (m *Manager) validateApp(app *AppConfig) []*ValidationError {
 	// Validate update check interval
 	if app.UpdateCheckInterval < 0 {
 		errors = append(errors, &ValidationError{
-			Field:   "app.update_check_interval",
-			Value:   app.UpdateCheckInterval,
-			Message: "must be non-negative",
+			Field:      "app.update_check_interval",
+			Value:      app.UpdateCheckInterval,
+			Message:    "must be non-negative",
+			Constraint: "non-negative",
 		})
 	}
 	if app.UpdateCheckInterval > 0 && app.UpdateCheckInterval < time.Hour {
 		errors = append(errors, &ValidationError{
-			Field:   "app.update_check_interval",
-			Value:   app.UpdateCheckInterval,
-			Message: "minimum interval is 1 hour",
+			Field:      "app.update_check_interval",
+			Value:      app.UpdateCheckInterval,
+			Message:    "minimum interval is 1 hour",
+			Constraint: "0 (disabled) or at least 1h",
+		})
+	}
+
+	if app.MonthlyBudgetUSD < 0 {
+		errors = append(errors, &ValidationError{
+			Field:      "app.monthly_budget_usd",
+			Value:      app.MonthlyBudgetUSD,
+			Message:    "must be non-negative",
+			Constraint: "0 (disabled) or a positive amount",
+		})
+	}
+	if app.MonthlyCharacterBudget < 0 {
+		errors = append(errors, &ValidationError{
+			Field:      "app.monthly_character_budget",
+			Value:      app.MonthlyCharacterBudget,
+			Message:    "must be non-negative",
+			Constraint: "0 (disabled) or a positive character count",
 		})
 	}
 
@@ -458,6 +840,35 @@ func expandPath(path string) string {
 }
 
 // isValidLanguageCode checks if a string is a valid language code (e.g., en-US)
+// ValidateVoiceAliasName checks the name of a tts.voice_aliases entry,
+// which must not be mistaken for a voice name
+func ValidateVoiceAliasName(name string) *ValidationError {
+	switch {
+	case name == "" || strings.ContainsAny(name, ". \t"):
+		return &ValidationError{
+			Field:   "tts.voice_aliases",
+			Value:   name,
+			Message: "alias names must not be empty or contain dots or spaces",
+		}
+	case voiceLanguagePattern.MatchString(name):
+		return &ValidationError{
+			Field:      "tts.voice_aliases",
+			Value:      name,
+			Message:    "alias names must not look like voice names",
+			Suggestion: "use a short name such as sarah",
+		}
+	}
+	return nil
+}
+
+// languageVoiceKeyPattern matches the keys of tts.language_voices, which
+// arrive lowercased, such as "en-us" and "de"
+var languageVoiceKeyPattern = regexp.MustCompile(`(?i)^[a-z]{2,3}(-[a-z0-9]{2,3})?$`)
+
+// voiceLanguagePattern matches voice names that start with a language code,
+// such as "de-DE-Neural2-B"
+var voiceLanguagePattern = regexp.MustCompile(`(?i)^[a-z]{2,3}-[a-z0-9]{2,3}-.+`)
+
 func isValidLanguageCode(code string) bool {
 	// Simple validation for language-COUNTRY format
 	matched, _ := regexp.MatchString(`^[a-z]{2}-[A-Z]{2}$`, code)
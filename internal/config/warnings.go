@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
+)
+
+// defaultRequestQuota is Google Cloud Text-to-Speech's default per-minute quota
+const defaultRequestQuota = 1000
+
+// ValidationWarnings returns findings that do not stop the CLI from running
+// but are likely mistakes. interactive reports whether a user is present to
+// answer prompts. Warnings use the same structure as validation errors.
+func (m *Manager) ValidationWarnings(interactive bool) ValidationErrors {
+	var warnings ValidationErrors
+	config := m.config
+
+	// Secrets in a config file tend to end up in backups and dotfile repos
+	credentials := []struct {
+		key, env string
+		value    string
+	}{
+		{"auth.api_key", "ASSISTANT_CLI_API_KEY", config.Auth.APIKey},
+		{"auth.oauth2_client_secret", "ASSISTANT_CLI_OAUTH2_CLIENT_SECRET", config.Auth.OAuth2ClientSecret},
+	}
+	for _, secret := range credentials {
+		// Encrypted credentials are safe to sync
+		if secret.value != "" && m.viper.InConfig(secret.key) && !secrets.IsEncrypted(m.viper.GetString(secret.key)) {
+			warnings = append(warnings, &ValidationError{
+				Field:      secret.key,
+				Value:      "********",
+				Message:    "credential is stored in the config file",
+				Suggestion: fmt.Sprintf("remove it from the file and set %s instead, or run 'assistant-cli secrets encrypt'", secret.env),
+			})
+		}
+	}
+
+	if config.TTS.RequestsPerMinute > defaultRequestQuota {
+		warnings = append(warnings, &ValidationError{
+			Field:      "tts.requests_per_minute",
+			Value:      config.TTS.RequestsPerMinute,
+			Message:    fmt.Sprintf("exceeds the default API quota of %d requests per minute", defaultRequestQuota),
+			Suggestion: "lower it unless your project has a raised quota, or requests will fail with quota errors",
+		})
+	}
+
+	if config.Output.OverwriteMode == "prompt" && !interactive {
+		message := fmt.Sprintf("cannot prompt without an interactive terminal; output.overwrite_fallback (%s) "+
+			"applies instead", config.Output.OverwriteFallback)
+		warnings = append(warnings, &ValidationError{
+			Field:      "output.overwrite_mode",
+			Value:      config.Output.OverwriteMode,
+			Message:    message,
+			Suggestion: "set output.overwrite_fallback, or use never, always, or backup for scripts and pipelines",
+		})
+	}
+
+	return warnings
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikefarmer/assistant-cli/internal/secrets"
+)
+
+func warningFields(warnings ValidationErrors) map[string]*ValidationError {
+	fields := make(map[string]*ValidationError)
+	for _, w := range warnings {
+		fields[w.Field] = w
+	}
+	return fields
+}
+
+func TestValidationWarnings_Defaults(t *testing.T) {
+	manager := NewManager()
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if warnings := manager.ValidationWarnings(false); len(warnings) != 0 {
+		t.Errorf("expected no warnings for the defaults, got %v", warnings)
+	}
+}
+
+func TestValidationWarnings_APIKeyInConfigFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+auth:
+  method: "apikey"
+  api_key: "AIzaSyExampleKeyThatIsLongEnough123456"
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	manager := NewManager()
+	manager.SetConfigFile(configFile)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	warning := warningFields(manager.ValidationWarnings(true))["auth.api_key"]
+	if warning == nil {
+		t.Fatal("expected a warning for auth.api_key")
+	}
+	if warning.Value != "********" {
+		t.Errorf("credential not masked in warning: %v", warning.Value)
+	}
+}
+
+func TestValidationWarnings_EncryptedAPIKey(t *testing.T) {
+	t.Setenv(secrets.PassphraseEnv, "correct horse")
+	encrypted, err := secrets.Encrypt([]byte("AIzaSyExampleKeyThatIsLongEnough123456"), "correct horse")
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("auth:\n  api_key: \""+encrypted+"\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	manager := NewManager()
+	manager.SetConfigFile(configFile)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if _, ok := warningFields(manager.ValidationWarnings(true))["auth.api_key"]; ok {
+		t.Error("unexpected warning for an encrypted API key")
+	}
+}
+
+func TestValidationWarnings_APIKeyNotFromFile(t *testing.T) {
+	manager := NewManager()
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	// Keys supplied by other means (environment, flags) are not a concern
+	manager.Get().Auth.APIKey = "AIzaSyExampleKeyThatIsLongEnough123456"
+	if _, ok := warningFields(manager.ValidationWarnings(true))["auth.api_key"]; ok {
+		t.Error("unexpected warning for an API key not read from the config file")
+	}
+}
+
+func TestValidationWarnings_Settings(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*Config)
+		interactive bool
+		wantField   string
+	}{
+		{"high request rate", func(c *Config) { c.TTS.RequestsPerMinute = 5000 }, true, "tts.requests_per_minute"},
+		{"request rate within quota", func(c *Config) { c.TTS.RequestsPerMinute = 1000 }, true, ""},
+		{"prompt without terminal", func(c *Config) { c.Output.OverwriteMode = "prompt" }, false,
+			"output.overwrite_mode"},
+		{"prompt with terminal", func(c *Config) { c.Output.OverwriteMode = "prompt" }, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			if err := manager.Load(); err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			tt.modify(manager.Get())
+
+			warnings := manager.ValidationWarnings(tt.interactive)
+			if tt.wantField == "" {
+				if len(warnings) != 0 {
+					t.Errorf("expected no warnings, got %v", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || warnings[0].Field != tt.wantField {
+				t.Fatalf("expected one warning for %s, got %v", tt.wantField, warnings)
+			}
+			if warnings[0].Suggestion == "" {
+				t.Error("expected a suggestion")
+			}
+		})
+	}
+}
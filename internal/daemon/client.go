@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// Client sends requests to a daemon over one connection. Synthesize is not
+// safe for concurrent use; Send and Receive may be called from two
+// goroutines, one each, to pipeline requests.
+type Client struct {
+	conn    net.Conn
+	decoder *json.Decoder
+	encoder *json.Encoder
+}
+
+// Dial connects to the daemon listening on the socket at path. It returns
+// an error wrapping ErrNotRunning when nothing listens there.
+func Dial(ctx context.Context, path string) (*Client, error) {
+	// Another user listening on the socket would read every request and
+	// could name files for the client to remove
+	if err := checkSocketOwner(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w on %s", ErrNotRunning, path)
+		}
+		return nil, err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("%w on %s", ErrNotRunning, path)
+		}
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	return &Client{conn: conn, decoder: json.NewDecoder(conn), encoder: json.NewEncoder(conn)}, nil
+}
+
+// Synthesize sends req and waits for its response. A request the daemon
+// failed returns a *RemoteError.
+func (c *Client) Synthesize(ctx context.Context, req *Request) (*Response, error) {
+	// Closing the connection is the only way to interrupt a blocked read
+	stop := context.AfterFunc(ctx, func() { _ = c.conn.Close() })
+	defer stop()
+
+	if err := c.Send(req); err != nil {
+		return nil, c.connError(ctx, err)
+	}
+	resp, err := c.Receive()
+	if err != nil {
+		return nil, c.connError(ctx, err)
+	}
+	switch {
+	case resp.Canceled:
+		return nil, ErrCanceled
+	case resp.Error != "":
+		return nil, &RemoteError{Message: resp.Error, ExitCode: resp.ExitCode}
+	}
+	return resp, nil
+}
+
+// Send sends req without waiting for its response. A request sent while an
+// earlier one is in flight cancels that one.
+func (c *Client) Send(req *Request) error {
+	if err := c.encoder.Encode(req); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	return nil
+}
+
+// Receive waits for the next response, failed or not. It returns io.EOF
+// once the daemon closes the connection.
+func (c *Client) Receive() (*Response, error) {
+	var resp Response
+	if err := c.decoder.Decode(&resp); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Temporary && !IsTemporaryFile(resp.File) {
+		return nil, fmt.Errorf("daemon named %s as a temporary file, which it did not create", resp.File)
+	}
+	return &resp, nil
+}
+
+// connError reports a failed exchange, preferring the cancellation of ctx
+// that caused it
+func (c *Client) connError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("daemon closed the connection: %w", err)
+	}
+	return err
+}
+
+// CloseSend tells the daemon no more requests follow. It answers those in
+// flight and then closes the connection, ending Receive with io.EOF.
+func (c *Client) CloseSend() error {
+	if conn, ok := c.conn.(interface{ CloseWrite() error }); ok {
+		if err := conn.CloseWrite(); err != nil {
+			return fmt.Errorf("failed to close connection: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
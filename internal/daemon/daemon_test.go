@@ -0,0 +1,263 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath returns a socket path short enough for the platform limit,
+// which test temp directories can exceed
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "acd")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "d.sock")
+}
+
+// startServer serves handler on a new socket until the test ends
+func startServer(t *testing.T, server *Server) string {
+	t.Helper()
+	path := socketPath(t)
+	listener, err := Listen(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	return path
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	path := startServer(t, NewServer(func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Text == "fail" {
+			return nil, errors.New("synthesis failed")
+		}
+		return &Response{File: tempFile(req.Text), Voice: req.Voice, Temporary: true}, nil
+	}))
+
+	client, err := Dial(context.Background(), path)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	// Several requests share a connection
+	resp, err := client.Synthesize(context.Background(), &Request{ID: "1", Text: "hello", Voice: "en-US-Neural2-F"})
+	require.NoError(t, err)
+	assert.Equal(t, &Response{ID: "1", File: tempFile("hello"), Voice: "en-US-Neural2-F", Temporary: true}, resp)
+
+	_, err = client.Synthesize(context.Background(), &Request{Text: "fail"})
+	var remote *RemoteError
+	require.ErrorAs(t, err, &remote)
+	assert.Equal(t, "synthesis failed", remote.Message)
+	assert.Zero(t, remote.ExitCode)
+
+	resp, err = client.Synthesize(context.Background(), &Request{Text: "again"})
+	require.NoError(t, err)
+	assert.Equal(t, tempFile("again"), resp.File)
+}
+
+// tempFile returns the path of a temporary file of the daemon
+func tempFile(name string) string {
+	return filepath.Join(os.TempDir(), TempFilePrefix+name+".mp3")
+}
+
+func TestClientRejectsForeignTemporaryFile(t *testing.T) {
+	victim := filepath.Join(t.TempDir(), "notes.txt")
+	path := startServer(t, NewServer(func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{File: req.Text, Temporary: true}, nil
+	}))
+	client, err := Dial(context.Background(), path)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	// Only files the daemon creates in the temporary directory are for the
+	// client to remove
+	for _, file := range []string{victim, filepath.Join(os.TempDir(), "notes.txt"),
+		filepath.Join(os.TempDir(), "sub", TempFilePrefix+"x.mp3")} {
+		_, err := client.Synthesize(context.Background(), &Request{Text: file})
+		assert.ErrorContains(t, err, "did not create", file)
+	}
+}
+
+func TestServerExitCode(t *testing.T) {
+	server := NewServer(func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, errors.New("bad voice")
+	})
+	server.ExitCode = func(err error) int { return 4 }
+	path := startServer(t, server)
+
+	client, err := Dial(context.Background(), path)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	_, err = client.Synthesize(context.Background(), &Request{Text: "hello"})
+	var remote *RemoteError
+	require.ErrorAs(t, err, &remote)
+	assert.Equal(t, 4, remote.ExitCode)
+	assert.EqualError(t, err, "daemon: bad voice")
+}
+
+func TestServerInvalidRequest(t *testing.T) {
+	path := startServer(t, NewServer(func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{}, nil
+	}))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("not json\n"))
+	require.NoError(t, err)
+
+	var resp Response
+	require.NoError(t, json.NewDecoder(conn).Decode(&resp))
+	assert.Contains(t, resp.Error, "invalid request")
+}
+
+func TestClientCancel(t *testing.T) {
+	release := make(chan struct{})
+	path := startServer(t, NewServer(func(ctx context.Context, req *Request) (*Response, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return &Response{}, nil
+	}))
+	defer close(release)
+
+	client, err := Dial(context.Background(), path)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Synthesize(ctx, &Request{Text: "slow"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServerCancelsSupersededRequest(t *testing.T) {
+	path := startServer(t, NewServer(func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Text == "slow" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &Response{File: req.Text}, nil
+	}))
+
+	client, err := Dial(context.Background(), path)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	require.NoError(t, client.Send(&Request{ID: "1", Text: "slow"}))
+	require.NoError(t, client.Send(&Request{ID: "2", Text: "fast"}))
+
+	resp, err := client.Receive()
+	require.NoError(t, err)
+	assert.Equal(t, "1", resp.ID)
+	assert.True(t, resp.Canceled)
+
+	resp, err = client.Receive()
+	require.NoError(t, err)
+	assert.Equal(t, &Response{ID: "2", File: "fast"}, resp)
+
+	// A cancel message is not answered; the next response is the request
+	// after it
+	require.NoError(t, client.Send(&Request{ID: "3", Text: "slow"}))
+	require.NoError(t, client.Send(&Request{Cancel: true}))
+	_, err = client.Synthesize(context.Background(), &Request{ID: "4", Text: "last"})
+	assert.ErrorIs(t, err, ErrCanceled)
+	resp, err = client.Receive()
+	require.NoError(t, err)
+	assert.Equal(t, "4", resp.ID)
+
+	// Closing the sending side still delivers the response in flight
+	require.NoError(t, client.Send(&Request{ID: "5", Text: "final"}))
+	require.NoError(t, client.CloseSend())
+	resp, err = client.Receive()
+	require.NoError(t, err)
+	assert.Equal(t, "final", resp.File)
+	_, err = client.Receive()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDialNotRunning(t *testing.T) {
+	_, err := Dial(context.Background(), socketPath(t))
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestListenDefaultSocketDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory modes are not enforced on Windows")
+	}
+	tmp, err := os.MkdirTemp("", "acd")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(tmp) })
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	path := DefaultSocketPath()
+	assert.Equal(t, userSocketDir(), filepath.Dir(path))
+	listener, err := Listen(path)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+	info, err := os.Stat(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	// A directory others can write to is refused
+	require.NoError(t, os.Chmod(filepath.Dir(path), 0777))
+	_, err = Listen(path)
+	assert.ErrorContains(t, err, "has mode 777")
+}
+
+func TestDialChecksSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("socket owners are not checked on Windows")
+	}
+	path := socketPath(t)
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+	_, err := Dial(context.Background(), path)
+	assert.ErrorContains(t, err, "is not a socket")
+}
+
+func TestListen(t *testing.T) {
+	path := startServer(t, NewServer(func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{}, nil
+	}))
+
+	// A second daemon on the same socket is refused
+	_, err := Listen(path)
+	assert.ErrorIs(t, err, ErrRunning)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// A socket left by a daemon that died is taken over
+	stale := socketPath(t)
+	listener, err := net.Listen("unix", stale)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, listener.Close())
+	require.FileExists(t, stale)
+
+	listener, err = Listen(stale)
+	require.NoError(t, err)
+	assert.NoError(t, listener.Close())
+}
@@ -0,0 +1,5 @@
+// Package daemon runs synthesis in a long-lived process that keeps the TTS
+// client, credentials, and voice catalog warm, and serves requests from thin
+// clients over a local socket, so repeated synthesis from editors and
+// scripts skips the startup and connection cost of a new process.
+package daemon
@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Requests and responses are single-line JSON objects. A connection works
+// on one request at a time: a request arriving while another is in flight
+// cancels it, so an editor can send each new selection without waiting, and
+// the canceled request is answered with Canceled set before the new one.
+
+// Request asks the daemon to synthesize text. Empty fields keep the daemon's
+// configured settings.
+type Request struct {
+	// ID is copied to the response, to match responses to requests
+	ID string `json:"id,omitempty"`
+	// Cancel only cancels the request in flight. It is not answered.
+	Cancel bool   `json:"cancel,omitempty"`
+	Text   string `json:"text"`
+	// Selection and Cursor select the region of Text to speak, for editors
+	// sending a whole buffer: see Region
+	Selection *Range    `json:"selection,omitempty"`
+	Cursor    *Position `json:"cursor,omitempty"`
+	// SpeechMarks asks for the timing of each word of the region
+	SpeechMarks bool   `json:"speech_marks,omitempty"`
+	Voice       string `json:"voice,omitempty"`
+	Language    string `json:"language,omitempty"`
+	// SpeakingRate is 0 for the configured rate
+	SpeakingRate float64 `json:"speaking_rate,omitempty"`
+	Format       string  `json:"format,omitempty"`
+	// Output is an absolute path to write the audio to. Without one the
+	// daemon writes a temporary file, which the client removes once used.
+	Output string `json:"output,omitempty"`
+}
+
+// Response is the outcome of a request: either the written audio or an error
+type Response struct {
+	ID string `json:"id,omitempty"`
+	// File is the path of the written audio
+	File string `json:"file,omitempty"`
+	// Temporary says File is a temporary file for the client to remove
+	Temporary       bool    `json:"temporary,omitempty"`
+	Voice           string  `json:"voice,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	// Range is the region that was spoken, when the request selected one
+	Range *Range `json:"range,omitempty"`
+	// Marks are the words of the region with their estimated start in the
+	// audio, when requested
+	Marks []Mark `json:"marks,omitempty"`
+	// Canceled says a later request canceled this one
+	Canceled bool   `json:"canceled,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// ExitCode classifies Error like the exit code of the CLI would
+	ExitCode int `json:"exit_code,omitempty"`
+}
+
+// Mark is a word, or SSML mark, of the spoken region and where it starts in
+// the audio
+type Mark struct {
+	Type       string `json:"type"`
+	TimeMillis int64  `json:"time_ms"`
+	Value      string `json:"value"`
+	// Range is where the word is in the request text
+	Range Range `json:"range"`
+}
+
+// TempFilePrefix starts the names of the temporary files the daemon writes
+// in os.TempDir for requests without an output
+const TempFilePrefix = "assistant-cli-say-"
+
+// IsTemporaryFile reports whether path names a temporary file of the
+// daemon, which clients may remove
+func IsTemporaryFile(path string) bool {
+	return filepath.IsAbs(path) && filepath.Dir(path) == filepath.Clean(os.TempDir()) &&
+		strings.HasPrefix(filepath.Base(path), TempFilePrefix)
+}
+
+// ErrNotRunning reports that no daemon listens on the socket
+var ErrNotRunning = errors.New("daemon is not running")
+
+// ErrCanceled reports a request canceled by a later one on the connection
+var ErrCanceled = errors.New("request canceled by a newer request")
+
+// ErrRunning reports that another daemon already listens on the socket
+var ErrRunning = errors.New("daemon is already running")
+
+// RemoteError is a request the daemon failed
+type RemoteError struct {
+	Message string
+	// ExitCode is the exit code the daemon classified the failure as, or 0
+	ExitCode int
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("daemon: %s", e.Message)
+}
@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is a place in a document as editors report it: a zero-based line
+// and a zero-based character within it, counted in Unicode code points
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is the part of a document from Start up to End
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Region returns the part of the request text to speak and its byte offset
+// in the text: the selection when there is a non-empty one, else the
+// paragraph around the cursor, else the whole text
+func (r *Request) Region() (string, int, error) {
+	switch {
+	case r.Selection != nil && r.Selection.Start != r.Selection.End:
+		start, err := Offset(r.Text, r.Selection.Start)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid selection start: %w", err)
+		}
+		end, err := Offset(r.Text, r.Selection.End)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid selection end: %w", err)
+		}
+		if end < start {
+			start, end = end, start
+		}
+		return r.Text[start:end], start, nil
+	case r.Cursor != nil:
+		offset, err := Offset(r.Text, *r.Cursor)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid cursor: %w", err)
+		}
+		start, end := paragraphAt(r.Text, offset)
+		return r.Text[start:end], start, nil
+	default:
+		return r.Text, 0, nil
+	}
+}
+
+// blankLine matches the line break and blank lines separating paragraphs
+var blankLine = regexp.MustCompile(`\n(?:[ \t\r]*\n)+`)
+
+// paragraphAt returns the bounds of the blank-line separated paragraph
+// containing offset
+func paragraphAt(text string, offset int) (int, int) {
+	start, end := 0, len(text)
+	for _, loc := range blankLine.FindAllStringIndex(text, -1) {
+		if loc[1] <= offset {
+			start = loc[1]
+		} else if loc[0] >= offset {
+			end = loc[0]
+			break
+		}
+	}
+	return start, end
+}
+
+// Offset returns the byte offset of pos in text. A character past the end
+// of its line is clamped to the end of the line, as editors do.
+func Offset(text string, pos Position) (int, error) {
+	if pos.Line < 0 || pos.Character < 0 {
+		return 0, fmt.Errorf("negative position %d:%d", pos.Line, pos.Character)
+	}
+
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return 0, fmt.Errorf("line %d is past the end of the text", pos.Line)
+		}
+		offset += i + 1
+	}
+
+	for character := 0; character < pos.Character && offset < len(text); character++ {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if r == '\n' {
+			break
+		}
+		offset += size
+	}
+	return offset, nil
+}
+
+// PositionAt returns the position of the byte offset in text
+func PositionAt(text string, offset int) Position {
+	offset = min(max(offset, 0), len(text))
+	line := strings.Count(text[:offset], "\n")
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+	return Position{Line: line, Character: utf8.RuneCountInString(text[lineStart:offset])}
+}
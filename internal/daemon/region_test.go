@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetAndPositionAt(t *testing.T) {
+	text := "Grüße aus\nKöln.\n\nEnde"
+
+	tests := []struct {
+		pos    Position
+		offset int
+	}{
+		{Position{0, 0}, 0},
+		{Position{0, 4}, 6}, // ü and ß take two bytes each
+		{Position{1, 0}, 12},
+		{Position{1, 2}, 15},
+		{Position{3, 4}, len(text)},
+	}
+	for _, tt := range tests {
+		offset, err := Offset(text, tt.pos)
+		require.NoError(t, err)
+		assert.Equal(t, tt.offset, offset, "%+v", tt.pos)
+		assert.Equal(t, tt.pos, PositionAt(text, offset))
+	}
+
+	// Characters past the end of a line stop at the line break
+	offset, err := Offset(text, Position{1, 40})
+	require.NoError(t, err)
+	assert.Equal(t, 18, offset)
+
+	_, err = Offset(text, Position{9, 0})
+	assert.ErrorContains(t, err, "past the end")
+	_, err = Offset(text, Position{-1, 0})
+	assert.Error(t, err)
+}
+
+func TestRequestRegion(t *testing.T) {
+	text := "First paragraph.\n\nSecond one,\nstill second.\n \nThird."
+
+	region, offset, err := (&Request{Text: text}).Region()
+	require.NoError(t, err)
+	assert.Equal(t, text, region)
+	assert.Zero(t, offset)
+
+	// The selection, even when made backwards
+	req := &Request{Text: text, Selection: &Range{Start: Position{3, 5}, End: Position{2, 0}}}
+	region, offset, err = req.Region()
+	require.NoError(t, err)
+	assert.Equal(t, "Second one,\nstill", region)
+	assert.Equal(t, 18, offset)
+
+	// An empty selection falls back to the paragraph at the cursor
+	req = &Request{Text: text, Selection: &Range{Start: Position{2, 3}, End: Position{2, 3}},
+		Cursor: &Position{3, 2}}
+	region, offset, err = req.Region()
+	require.NoError(t, err)
+	assert.Equal(t, "Second one,\nstill second.", region)
+	assert.Equal(t, 18, offset)
+
+	region, _, err = (&Request{Text: text, Cursor: &Position{5, 0}}).Region()
+	require.NoError(t, err)
+	assert.Equal(t, "Third.", region)
+
+	region, _, err = (&Request{Text: text, Cursor: &Position{0, 16}}).Region()
+	require.NoError(t, err)
+	assert.Equal(t, "First paragraph.", region)
+
+	_, _, err = (&Request{Text: text, Cursor: &Position{20, 0}}).Region()
+	assert.ErrorContains(t, err, "invalid cursor")
+}
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// Handler synthesizes a request. It is called concurrently for requests on
+// different connections.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Server answers requests from clients with a Handler
+type Server struct {
+	handler Handler
+	// ExitCode classifies a handler error for Response.ExitCode; nil leaves
+	// it 0
+	ExitCode func(err error) int
+	// Logf, when set, is told about failed connections
+	Logf func(format string, args ...interface{})
+}
+
+// NewServer creates a server calling handler for each request
+func NewServer(handler Handler) *Server {
+	return &Server{handler: handler}
+}
+
+// Serve answers connections on listener until ctx is done, then closes the
+// listener, cancels the requests in flight, and waits for them to return
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn answers the requests of one client until it disconnects. A
+// request arriving while another is in flight cancels that one first.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	var mu sync.Mutex
+	encoder := json.NewEncoder(conn)
+	send := func(resp *Response) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(resp); err != nil && ctx.Err() == nil {
+			s.logf("failed to send response: %v", err)
+		}
+	}
+
+	// The request in flight, if any
+	var current *inflight
+	wait := func(cancelFirst bool) {
+		if current == nil {
+			return
+		}
+		if cancelFirst {
+			current.cancel()
+		}
+		<-current.done
+		current.cancel()
+		current = nil
+	}
+	// A client that stops sending still gets its last response
+	defer wait(false)
+
+	decoder := json.NewDecoder(conn)
+	for {
+		req := &Request{}
+		if err := decoder.Decode(req); err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				// The stream cannot be resynchronized after malformed JSON
+				wait(true)
+				send(&Response{Error: fmt.Sprintf("invalid request: %v", err)})
+				s.logf("invalid request: %v", err)
+			}
+			return
+		}
+
+		wait(true)
+		if req.Cancel {
+			continue
+		}
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		current = &inflight{cancel: cancel, done: make(chan struct{})}
+		go func(done chan struct{}) {
+			defer close(done)
+			send(s.handle(reqCtx, req))
+		}(current.done)
+	}
+}
+
+// inflight is a request being handled
+type inflight struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// handle calls the handler, turning an error into an error response
+func (s *Server) handle(ctx context.Context, req *Request) *Response {
+	resp, err := s.handler(ctx, req)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		resp = &Response{Canceled: true, Error: ErrCanceled.Error()}
+	case err != nil:
+		resp = &Response{Error: err.Error()}
+		if s.ExitCode != nil {
+			resp.ExitCode = s.ExitCode(err)
+		}
+	case resp == nil:
+		resp = &Response{}
+	}
+	resp.ID = req.ID
+	return resp
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
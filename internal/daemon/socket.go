@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// probeTimeout bounds the check whether a socket left behind belongs to a
+// daemon that is still running
+const probeTimeout = time.Second
+
+// DefaultSocketPath returns the socket the daemon listens on by default:
+// assistant-cli.sock in $XDG_RUNTIME_DIR, or daemon.sock in a per-user
+// directory of the temporary directory, which only its owner may use. Go's
+// Unix sockets also work on Windows 10 and later, so the same path scheme
+// serves every platform.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "assistant-cli.sock")
+	}
+	return filepath.Join(userSocketDir(), "daemon.sock")
+}
+
+// userSocketDir is the per-user directory of the default socket when
+// $XDG_RUNTIME_DIR is not set
+func userSocketDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("assistant-cli-%d", os.Getuid()))
+}
+
+// Listen listens on the socket at path, readable only by the current user.
+// A socket file left behind by a daemon that exited uncleanly is replaced;
+// one a daemon still answers on fails with ErrRunning.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	// Anyone can create the per-user directory in the shared temporary
+	// directory first
+	if filepath.Dir(path) == userSocketDir() {
+		if err := checkPrivateDir(filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, probeTimeout); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%w on %s", ErrRunning, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := listenPrivate(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,37 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// listenPrivate listens on the socket at path, readable only by the
+// current user as far as the platform's file modes allow
+func listenPrivate(path string) (net.Listener, error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil && !errors.Is(err, os.ErrNotExist) {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// checkSocketOwner only checks that the socket exists, since file owners
+// are not Unix user IDs on this platform
+func checkSocketOwner(path string) error {
+	_, err := os.Lstat(path)
+	return err
+}
+
+// checkPrivateDir accepts dir, which MkdirAll created with the platform's
+// default permissions
+func checkPrivateDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build unix
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes the umask changes of listenPrivate, since the umask
+// is shared by the whole process
+var umaskMu sync.Mutex
+
+// listenPrivate listens on the socket at path, creating it under a umask
+// that leaves it readable and writable only by the current user. Setting
+// the mode after creating the socket would leave a window in which others
+// could connect.
+func listenPrivate(path string) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	previous := syscall.Umask(0o177)
+	defer syscall.Umask(previous)
+	return net.Listen("unix", path)
+}
+
+// checkSocketOwner checks that the socket at path belongs to the current
+// user
+func checkSocketOwner(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("%s is not a socket", path)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("socket %s belongs to another user", path)
+	}
+	return nil
+}
+
+// checkPrivateDir checks that dir is a directory, not a symlink, that only
+// the current user can use
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("socket directory %s is not a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("socket directory %s belongs to another user", dir)
+	}
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("socket directory %s has mode %o; want 700", dir, info.Mode().Perm())
+	}
+	return nil
+}
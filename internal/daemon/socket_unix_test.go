@@ -0,0 +1,21 @@
+//go:build unix
+
+package daemon
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenRestoresUmask(t *testing.T) {
+	previous := syscall.Umask(0o022)
+	defer syscall.Umask(previous)
+
+	listener, err := Listen(socketPath(t))
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+	assert.Equal(t, 0o022, syscall.Umask(0o022))
+}
@@ -0,0 +1,5 @@
+// Package document extracts readable text from e-book and document files.
+// EPUB files are split into their spine documents (usually chapters), PDF
+// files into pages, and Markdown files at their chapter headings, giving
+// ordered segments ready for synthesis.
+package document
@@ -0,0 +1,80 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedDocument is returned for files that cannot be read as text
+var ErrUnsupportedDocument = errors.New("unsupported document")
+
+// Segment is one ordered unit of a document's text, such as a chapter or page
+type Segment struct {
+	Title string
+	Text  string
+}
+
+// Document is the text of a document split into segments
+type Document struct {
+	Title    string
+	Segments []Segment
+}
+
+// Open extracts the text of an EPUB, PDF, or Markdown file, chosen by its
+// extension.
+// Segments without text, such as cover pages, are left out.
+func Open(path string) (*Document, error) {
+	var (
+		doc *Document
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epub":
+		doc, err = OpenEPUB(path)
+	case ".pdf":
+		doc, err = OpenPDF(path)
+	case ".md", ".markdown":
+		doc, err = OpenMarkdown(path)
+	default:
+		return nil, fmt.Errorf("%w: %s (expected .epub, .pdf, or .md)", ErrUnsupportedDocument, filepath.Base(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Title == "" {
+		doc.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if len(doc.Segments) == 0 {
+		return nil, fmt.Errorf("%w: no text found in %s", ErrUnsupportedDocument, filepath.Base(path))
+	}
+	return doc, nil
+}
+
+// normalizeText collapses runs of whitespace within paragraphs and
+// separates paragraphs, which are delimited by blank lines, with one blank
+// line
+func normalizeText(text string) string {
+	var paragraphs []string
+	var words []string
+	flush := func() {
+		if len(words) > 0 {
+			paragraphs = append(paragraphs, strings.Join(words, " "))
+			words = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			flush()
+			continue
+		}
+		words = append(words, fields...)
+	}
+	flush()
+
+	return strings.Join(paragraphs, "\n\n")
+}
@@ -0,0 +1,44 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	path := writeEPUB(t, map[string]string{
+		"META-INF/container.xml":     testContainer,
+		"OEBPS/content.opf":          testOPF,
+		"OEBPS/cover.xhtml":          `<html><body></body></html>`,
+		"OEBPS/text/chapter 1.xhtml": `<html><body><p>One</p></body></html>`,
+		"OEBPS/text/ch2.xhtml":       `<html><body><p>Two</p></body></html>`,
+		"OEBPS/notes.xhtml":          `<html><body></body></html>`,
+	})
+	renamed := filepath.Join(filepath.Dir(path), "Book.EPUB")
+	require.NoError(t, os.Rename(path, renamed))
+
+	doc, err := Open(renamed)
+	require.NoError(t, err)
+	assert.Equal(t, "The Test Book", doc.Title)
+	assert.Equal(t, []Segment{{Title: "Chapter 1", Text: "Two"}, {Title: "Chapter 2", Text: "One"}}, doc.Segments)
+}
+
+func TestOpenUnsupported(t *testing.T) {
+	_, err := Open("notes.txt")
+	assert.ErrorIs(t, err, ErrUnsupportedDocument)
+
+	// Documents without any text are rejected
+	path := filepath.Join(t.TempDir(), "empty.pdf")
+	require.NoError(t, os.WriteFile(path, buildPDF(""), 0644))
+	_, err = Open(path)
+	assert.ErrorIs(t, err, ErrUnsupportedDocument)
+}
+
+func TestNormalizeText(t *testing.T) {
+	assert.Equal(t, "one two\n\nthree", normalizeText("  one\n  two \n\n\n \t\nthree  "))
+	assert.Equal(t, "", normalizeText(" \n\n "))
+}
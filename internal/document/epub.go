@@ -0,0 +1,240 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// epubContainerPath is the fixed location of the file naming the package
+// document
+const epubContainerPath = "META-INF/container.xml"
+
+// maxEPUBEntrySize bounds the decompressed size of a single EPUB entry
+const maxEPUBEntrySize = 64 << 20
+
+// epubContainer is META-INF/container.xml
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage is the package (OPF) document listing the book's files and
+// their reading order
+type epubPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef  string `xml:"idref,attr"`
+		Linear string `xml:"linear,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// OpenEPUB extracts the text of an EPUB file, one segment per document in
+// the reading order. Segment titles come from each document's first
+// heading or its <title>.
+func OpenEPUB(filename string) (*Document, error) {
+	archive, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var container epubContainer
+	if err := decodeEPUBEntry(files, epubContainerPath, &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("invalid EPUB: %s names no package document", epubContainerPath)
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	var pkg epubPackage
+	if err := decodeEPUBEntry(files, opfPath, &pkg); err != nil {
+		return nil, err
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if strings.Contains(item.MediaType, "html") {
+			hrefs[item.ID] = item.Href
+		}
+	}
+
+	doc := &Document{}
+	if len(pkg.Title) > 0 {
+		doc.Title = strings.TrimSpace(pkg.Title[0])
+	}
+
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok || ref.Linear == "no" {
+			continue
+		}
+
+		// Manifest hrefs are URLs relative to the package document
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		data, err := readEPUBEntry(files, path.Join(path.Dir(opfPath), href))
+		if err != nil {
+			return nil, err
+		}
+		segment, err := htmlSegment(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", href, err)
+		}
+		if segment.Text == "" {
+			continue
+		}
+		if segment.Title == "" {
+			segment.Title = fmt.Sprintf("Chapter %d", len(doc.Segments)+1)
+		}
+		doc.Segments = append(doc.Segments, segment)
+	}
+
+	return doc, nil
+}
+
+// readEPUBEntry reads a file from the EPUB archive
+func readEPUBEntry(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return nil, fmt.Errorf("invalid EPUB: missing %s", name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxEPUBEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(data) > maxEPUBEntrySize {
+		return nil, fmt.Errorf("invalid EPUB: %s is larger than %d bytes", name, maxEPUBEntrySize)
+	}
+	return data, nil
+}
+
+// decodeEPUBEntry reads and decodes an XML file from the EPUB archive
+func decodeEPUBEntry(files map[string]*zip.File, name string, v interface{}) error {
+	data, err := readEPUBEntry(files, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid EPUB: failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// htmlBlocks are elements that start a new paragraph
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "blockquote": true, "section": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// htmlSkipped are elements whose content is not read aloud
+var htmlSkipped = map[string]bool{"head": true, "script": true, "style": true}
+
+// htmlSegment extracts the text of an (X)HTML document and its title
+func htmlSegment(data []byte) (Segment, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var (
+		text, heading strings.Builder
+		title         string
+		skipDepth     int
+		inTitle       bool
+		inHeading     bool
+		headingDone   bool
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Segment{}, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "title":
+				inTitle = true
+			case htmlSkipped[name]:
+				skipDepth++
+			case !headingDone && (name == "h1" || name == "h2" || name == "h3"):
+				inHeading = true
+			}
+			if htmlBlocks[name] {
+				text.WriteString("\n\n")
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "title":
+				inTitle = false
+			case htmlSkipped[name]:
+				skipDepth = max(skipDepth-1, 0)
+			case inHeading && (name == "h1" || name == "h2" || name == "h3"):
+				inHeading = false
+				headingDone = strings.TrimSpace(heading.String()) != ""
+			}
+			if htmlBlocks[name] {
+				text.WriteString("\n\n")
+			}
+		case xml.CharData:
+			switch {
+			case inTitle:
+				title += string(t)
+			case skipDepth == 0:
+				text.Write(t)
+				if inHeading {
+					heading.Write(t)
+				}
+			}
+		}
+	}
+
+	segment := Segment{Text: normalizeText(text.String())}
+	segment.Title = strings.Join(strings.Fields(heading.String()), " ")
+	if segment.Title == "" {
+		segment.Title = strings.Join(strings.Fields(title), " ")
+	}
+	return segment, nil
+}
+
+// HTMLText extracts the readable text of an HTML document or fragment, such
+// as the body of a feed item, with paragraphs separated by blank lines
+func HTMLText(html string) (string, error) {
+	segment, err := htmlSegment([]byte(html))
+	if err != nil {
+		return "", err
+	}
+	return segment.Text, nil
+}
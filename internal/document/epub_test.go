@@ -0,0 +1,109 @@
+package document
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEPUB writes an EPUB archive with the given files to a temporary path
+func writeEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return path
+}
+
+const testContainer = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+
+const testOPF = `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>The Test Book</dc:title></metadata>
+  <manifest>
+    <item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch1" href="text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine>
+    <itemref idref="cover"/>
+    <itemref idref="ch2"/>
+    <itemref idref="ch1"/>
+    <itemref idref="notes" linear="no"/>
+  </spine>
+</package>`
+
+func TestOpenEPUB(t *testing.T) {
+	path := writeEPUB(t, map[string]string{
+		"mimetype":               "application/epub+zip",
+		"META-INF/container.xml": testContainer,
+		"OEBPS/content.opf":      testOPF,
+		"OEBPS/cover.xhtml":      `<html><body><img src="cover.jpg"/></body></html>`,
+		"OEBPS/text/chapter 1.xhtml": `<html><head><title>Ignored</title><style>p { color: red }</style></head>
+<body><h1>Chapter  One</h1><p>It was a   dark&nbsp;night.</p><p>The end<br/>of one.</p>
+<script>alert("x")</script></body></html>`,
+		"OEBPS/text/ch2.xhtml": `<html><head><title>Prologue</title></head><body><p>Before it all.</p></body></html>`,
+		"OEBPS/notes.xhtml":    `<html><body><p>Notes.</p></body></html>`,
+	})
+
+	doc, err := OpenEPUB(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "The Test Book", doc.Title)
+	require.Len(t, doc.Segments, 2, "cover without text and non-linear items are skipped")
+	assert.Equal(t, Segment{Title: "Prologue", Text: "Before it all."}, doc.Segments[0])
+	assert.Equal(t, "Chapter One", doc.Segments[1].Title)
+	assert.Equal(t, "Chapter One\n\nIt was a dark night.\n\nThe end\n\nof one.", doc.Segments[1].Text)
+}
+
+func TestOpenEPUBErrors(t *testing.T) {
+	_, err := OpenEPUB(writeEPUB(t, map[string]string{"mimetype": "application/epub+zip"}))
+	assert.ErrorContains(t, err, "missing META-INF/container.xml")
+
+	_, err = OpenEPUB(writeEPUB(t, map[string]string{"META-INF/container.xml": testContainer}))
+	assert.ErrorContains(t, err, "missing OEBPS/content.opf")
+
+	_, err = OpenEPUB(filepath.Join(t.TempDir(), "missing.epub"))
+	assert.Error(t, err)
+}
+
+func TestHTMLText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "plain text", html: "Just words.", want: "Just words."},
+		{name: "fragment", html: "<p>First &amp; <b>bold</b>.</p><p>Second<br>line</p>",
+			want: "First & bold.\n\nSecond\n\nline"},
+		{name: "bare ampersand", html: "Salt & pepper", want: "Salt & pepper"},
+		{name: "scripts skipped", html: "<p>Read</p><script>alert(1)</script>", want: "Read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTMLText(tt.html)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package document
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Markdown syntax rewritten to the text it displays
+var (
+	markdownATXHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	markdownSetextRule = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	markdownThematic   = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	markdownFence      = regexp.MustCompile("^ {0,3}(```|~~~)")
+	markdownListMarker = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d{1,9}[.)])[ \t]+`)
+	markdownQuote      = regexp.MustCompile(`^[ \t]*(?:>[ \t]?)+`)
+	markdownImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink       = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	markdownEmphasis   = regexp.MustCompile("(\\*{1,3}|_{1,3}|~~|`+)")
+	markdownHTMLTag    = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+)
+
+// markdownHeading is a heading line of a Markdown document
+type markdownHeading struct {
+	line  int
+	level int
+	text  string
+}
+
+// OpenMarkdown extracts the text of a Markdown file, one segment per
+// chapter. Chapters start at the headings of the highest level used more
+// than once, or else the highest level; a single top-level heading at the
+// start names the document instead. Text before the first chapter becomes a
+// segment of its own, and code blocks are left out.
+func OpenMarkdown(filename string) (*Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Markdown: %w", err)
+	}
+	return parseMarkdown(string(data)), nil
+}
+
+// parseMarkdown splits a Markdown document into chapters
+func parseMarkdown(source string) *Document {
+	lines, headings := markdownLines(stripFrontMatter(source))
+	doc := &Document{}
+
+	// A lone top-level heading opening the document is its title
+	if len(headings) > 0 && headings[0].level == 1 && strings.TrimSpace(strings.Join(lines[:headings[0].line], "")) == "" {
+		count := 0
+		for _, heading := range headings {
+			if heading.level == 1 {
+				count++
+			}
+		}
+		if count == 1 {
+			doc.Title = headings[0].text
+			lines[headings[0].line] = ""
+			headings = headings[1:]
+		}
+	}
+
+	level := chapterLevel(headings)
+	start, title := 0, doc.Title
+	for _, heading := range headings {
+		if heading.level != level {
+			continue
+		}
+		doc.addSegment(title, lines[start:heading.line])
+		start, title = heading.line, heading.text
+	}
+	doc.addSegment(title, lines[start:])
+	return doc
+}
+
+// addSegment adds the text of lines as a segment, unless it has none
+func (d *Document) addSegment(title string, lines []string) {
+	text := normalizeText(strings.Join(lines, "\n"))
+	if text == "" {
+		return
+	}
+	if title == "" {
+		title = fmt.Sprintf("Part %d", len(d.Segments)+1)
+	}
+	d.Segments = append(d.Segments, Segment{Title: title, Text: text})
+}
+
+// stripFrontMatter blanks out a YAML front-matter block opening source,
+// keeping its lines so line numbers stay the same
+func stripFrontMatter(source string) string {
+	if !strings.HasPrefix(source, "---\n") && !strings.HasPrefix(source, "---\r\n") {
+		return source
+	}
+	lines := strings.Split(source, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Repeat("\n", i+1) + strings.Join(lines[i+1:], "\n")
+		}
+	}
+	return source
+}
+
+// chapterLevel returns the highest heading level used more than once, or
+// else the highest level used at all
+func chapterLevel(headings []markdownHeading) int {
+	var counts [7]int
+	for _, heading := range headings {
+		counts[heading.level]++
+	}
+	for level := 1; level <= 6; level++ {
+		if counts[level] > 1 {
+			return level
+		}
+	}
+	for level := 1; level <= 6; level++ {
+		if counts[level] > 0 {
+			return level
+		}
+	}
+	return 0
+}
+
+// markdownLines returns the lines of a Markdown document as plain text,
+// each heading on a line of its own set off by blank lines, along with the
+// headings
+func markdownLines(source string) ([]string, []markdownHeading) {
+	raw := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	lines := make([]string, len(raw))
+	var headings []markdownHeading
+	inFence := ""
+
+	for i, line := range raw {
+		if inFence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), inFence) {
+				inFence = ""
+			}
+			continue
+		}
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			inFence = m[1]
+			continue
+		}
+
+		if m := markdownATXHeading.FindStringSubmatch(line); m != nil {
+			text := inlineMarkdown(m[2])
+			lines[i] = "\n" + text + "\n"
+			if text != "" {
+				headings = append(headings, markdownHeading{line: i, level: len(m[1]), text: text})
+			}
+			continue
+		}
+		// A setext underline makes the paragraph line above a heading
+		if m := markdownSetextRule.FindStringSubmatch(line); m != nil && i > 0 && strings.TrimSpace(lines[i-1]) != "" &&
+			!markdownListMarker.MatchString(raw[i-1]) && (i < 2 || strings.TrimSpace(raw[i-2]) == "") {
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			text := strings.TrimSpace(lines[i-1])
+			lines[i-1] = "\n" + text + "\n"
+			headings = append(headings, markdownHeading{line: i - 1, level: level, text: text})
+			continue
+		}
+		if markdownThematic.MatchString(line) {
+			lines[i] = "\n"
+			continue
+		}
+
+		line = markdownQuote.ReplaceAllString(line, "")
+		line = markdownListMarker.ReplaceAllString(line, "")
+		lines[i] = inlineMarkdown(line)
+	}
+	return lines, headings
+}
+
+// inlineMarkdown removes the inline markup of a line: images, link targets,
+// emphasis, code spans, and HTML tags
+func inlineMarkdown(line string) string {
+	line = markdownImage.ReplaceAllString(line, "")
+	line = markdownLink.ReplaceAllString(line, "$1")
+	line = markdownHTMLTag.ReplaceAllString(line, "")
+	line = markdownEmphasis.ReplaceAllString(line, "")
+	return strings.TrimSpace(line)
+}
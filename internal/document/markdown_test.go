@@ -0,0 +1,76 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		title    string
+		segments []Segment
+	}{
+		{
+			name: "title and chapters",
+			source: "---\nassistant-cli:\n  voice: en-GB-News-K\n---\n# The Book\n\nA *short* preface.\n\n" +
+				"## One\n\nIt began [here](http://example.com).\n\n### Aside\n\n- a list\n- of **things**\n\n" +
+				"## Two ##\n\n```go\nfmt.Println(\"skipped\")\n```\n\n> Quoted `text`.\n",
+			title: "The Book",
+			segments: []Segment{
+				{Title: "The Book", Text: "A short preface."},
+				{Title: "One", Text: "One\n\nIt began here.\n\nAside\n\na list of things"},
+				{Title: "Two", Text: "Two\n\nQuoted text."},
+			},
+		},
+		{
+			name:   "top-level chapters",
+			source: "Intro\n\n# First\nText one.\n# Second\nText two.\n",
+			segments: []Segment{
+				{Title: "Part 1", Text: "Intro"},
+				{Title: "First", Text: "First\n\nText one."},
+				{Title: "Second", Text: "Second\n\nText two."},
+			},
+		},
+		{
+			name:   "setext headings",
+			source: "Story\n=====\n\nChapter A\n---------\nAlpha.\n\nChapter B\n---------\nBeta.\n\n***\n\nEnd.\n",
+			title:  "Story",
+			segments: []Segment{
+				{Title: "Chapter A", Text: "Chapter A\n\nAlpha."},
+				{Title: "Chapter B", Text: "Chapter B\n\nBeta.\n\nEnd."},
+			},
+		},
+		{
+			name:     "no headings",
+			source:   "Just ![a picture](p.png)text.\n",
+			segments: []Segment{{Title: "Part 1", Text: "Just text."}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseMarkdown(tt.source)
+			assert.Equal(t, tt.title, doc.Title)
+			assert.Equal(t, tt.segments, doc.Segments)
+		})
+	}
+}
+
+func TestOpenMarkdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	require.NoError(t, os.WriteFile(path, []byte("## A\nOne.\n## B\nTwo.\n"), 0600))
+
+	doc, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, "notes", doc.Title, "titled after the file without a title heading")
+	assert.Len(t, doc.Segments, 2)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.md"))
+	assert.Error(t, err)
+}
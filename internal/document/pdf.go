@@ -0,0 +1,622 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxPDFStreamSize bounds the decoded size of a single PDF stream
+const maxPDFStreamSize = 64 << 20
+
+// maxFormDepth bounds the nesting of form XObjects drawn from content streams
+const maxFormDepth = 8
+
+// maxPageDepth bounds the nesting of the page tree
+const maxPageDepth = 64
+
+// pdfObjectPattern matches the start of an indirect object definition
+var pdfObjectPattern = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// inlineImageEnd matches the EI keyword ending inline image data
+var inlineImageEnd = regexp.MustCompile(`\sEI(\s|$)`)
+
+// pdfFile holds the indirect objects of a PDF file
+type pdfFile struct {
+	objects map[int]interface{}
+}
+
+// OpenPDF extracts the text of a PDF file, one segment per page. It reads
+// unencrypted files with uncompressed, Flate, ASCIIHex or ASCII85 encoded
+// content. Text drawn with fonts that have neither a standard encoding nor
+// a ToUnicode map, and text in images, cannot be recovered.
+func OpenPDF(filename string) (*Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	return parsePDF(data)
+}
+
+func parsePDF(data []byte) (*Document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("%w: not a PDF file", ErrUnsupportedDocument)
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, fmt.Errorf("%w: encrypted PDF files are not supported", ErrUnsupportedDocument)
+	}
+
+	pdf := &pdfFile{objects: make(map[int]interface{})}
+	pdf.readObjects(data)
+	pdf.readObjectStreams()
+
+	trailer := pdf.trailer(data)
+	catalog := pdf.dict(trailer["Root"])
+	if catalog == nil {
+		catalog = pdf.catalog()
+	}
+	if catalog == nil {
+		return nil, fmt.Errorf("invalid PDF: no document catalog found")
+	}
+
+	doc := &Document{}
+	if title, ok := pdf.resolve(pdf.dict(trailer["Info"])["Title"]).(pdfString); ok {
+		doc.Title = normalizeText(decodeTextString(title))
+	}
+	for i, page := range pdf.pages(catalog) {
+		text, err := pdf.pageText(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		// Line breaks within a page rarely mark paragraphs
+		if text = strings.Join(strings.Fields(text), " "); text != "" {
+			doc.Segments = append(doc.Segments, Segment{Title: fmt.Sprintf("Page %d", i+1), Text: text})
+		}
+	}
+	return doc, nil
+}
+
+// readObjects reads the indirect objects defined in the file body. Later
+// definitions of an object replace earlier ones, as incremental updates do.
+func (p *pdfFile) readObjects(data []byte) {
+	for pos := 0; pos < len(data); {
+		match := pdfObjectPattern.FindSubmatchIndex(data[pos:])
+		if match == nil {
+			return
+		}
+
+		num, _ := strconv.Atoi(string(data[pos+match[2] : pos+match[3]]))
+		lexer := &pdfLexer{data: data, pos: pos + match[1]}
+		pos += match[1]
+
+		value, err := lexer.readObject()
+		if err != nil {
+			continue
+		}
+
+		lexer.skipSpace()
+		if dict, ok := value.(pdfDict); ok && bytes.HasPrefix(data[lexer.pos:], []byte("stream")) {
+			raw, end := streamData(data, lexer.pos+len("stream"), dict)
+			value = &pdfStream{dict: dict, raw: raw}
+			lexer.pos = end
+		}
+
+		p.objects[num] = value
+		pos = lexer.pos
+	}
+}
+
+// streamData returns the data of a stream starting after the "stream"
+// keyword at start, and the position after it
+func streamData(data []byte, start int, dict pdfDict) ([]byte, int) {
+	// The keyword is followed by CRLF or LF
+	if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+		start += 2
+	} else if start < len(data) && (data[start] == '\n' || data[start] == '\r') {
+		start++
+	}
+
+	if length, ok := dict["Length"].(float64); ok && length >= 0 {
+		// A corrupt length may point anywhere, or overflow
+		end := start + int(length)
+		if end >= start && end <= len(data) && bytes.HasPrefix(bytes.TrimLeft(data[end:], "\r\n "), []byte("endstream")) {
+			return data[start:end], end
+		}
+	}
+
+	// Indirect or wrong lengths: the data runs to the endstream keyword
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		return data[start:], len(data)
+	}
+	return bytes.TrimRight(data[start:start+end], "\r\n"), start + end
+}
+
+// readObjectStreams reads objects stored compressed in object streams
+func (p *pdfFile) readObjectStreams() {
+	for _, v := range p.objects {
+		stream, ok := v.(*pdfStream)
+		if !ok || stream.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data, err := p.decode(stream)
+		if err != nil {
+			continue
+		}
+		count, _ := p.resolve(stream.dict["N"]).(float64)
+		first, _ := p.resolve(stream.dict["First"]).(float64)
+
+		// The header holds pairs of object numbers and offsets from First
+		header := &pdfLexer{data: data}
+		for range int(count) {
+			num, err1 := header.readObject()
+			offset, err2 := header.readObject()
+			n, ok1 := num.(float64)
+			o, ok2 := offset.(float64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+
+			lexer := &pdfLexer{data: data, pos: int(first) + int(o)}
+			if lexer.pos < 0 || lexer.pos >= len(data) {
+				continue
+			}
+			if value, err := lexer.readObject(); err == nil {
+				if _, defined := p.objects[int(n)]; !defined {
+					p.objects[int(n)] = value
+				}
+			}
+		}
+	}
+}
+
+// resolve follows indirect references
+func (p *pdfFile) resolve(v interface{}) interface{} {
+	for range 32 {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = p.objects[ref.num]
+	}
+	return nil
+}
+
+// dict resolves v to a dictionary, using a stream's dictionary for streams
+func (p *pdfFile) dict(v interface{}) pdfDict {
+	switch d := p.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case *pdfStream:
+		return d.dict
+	default:
+		return nil
+	}
+}
+
+// trailer returns the trailer dictionary of the last update, from a
+// trailer section or a cross-reference stream
+func (p *pdfFile) trailer(data []byte) pdfDict {
+	if i := bytes.LastIndex(data, []byte("trailer")); i >= 0 {
+		lexer := &pdfLexer{data: data, pos: i + len("trailer")}
+		if d, err := lexer.readObject(); err == nil {
+			if dict, ok := d.(pdfDict); ok && dict["Root"] != nil {
+				return dict
+			}
+		}
+	}
+
+	var trailer pdfDict
+	for _, num := range p.objectNumbers() {
+		if stream, ok := p.objects[num].(*pdfStream); ok && stream.dict["Type"] == pdfName("XRef") {
+			trailer = stream.dict
+		}
+	}
+	return trailer
+}
+
+// catalog finds the document catalog when the trailer does not name it
+func (p *pdfFile) catalog() pdfDict {
+	var catalog pdfDict
+	for _, num := range p.objectNumbers() {
+		if d, ok := p.objects[num].(pdfDict); ok && d["Type"] == pdfName("Catalog") && d["Pages"] != nil {
+			catalog = d
+		}
+	}
+	return catalog
+}
+
+// objectNumbers returns the defined object numbers in ascending order
+func (p *pdfFile) objectNumbers() []int {
+	nums := make([]int, 0, len(p.objects))
+	for num := range p.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// pdfPage is a page dictionary with its inherited resources
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in document order
+func (p *pdfFile) pages(catalog pdfDict) []pdfPage {
+	var pages []pdfPage
+	visited := make(map[int]bool)
+
+	var walk func(node interface{}, resources pdfDict, depth int)
+	walk = func(node interface{}, resources pdfDict, depth int) {
+		// Malformed page trees may contain cycles
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref.num] {
+				return
+			}
+			visited[ref.num] = true
+		}
+		dict := p.dict(node)
+		if dict == nil || depth > maxPageDepth {
+			return
+		}
+
+		if r := p.dict(dict["Resources"]); r != nil {
+			resources = r
+		}
+		if dict["Type"] == pdfName("Page") || dict["Kids"] == nil {
+			pages = append(pages, pdfPage{dict: dict, resources: resources})
+			return
+		}
+		kids, _ := p.resolve(dict["Kids"]).(pdfArray)
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+	walk(catalog["Pages"], nil, 0)
+	return pages
+}
+
+// decode returns the decoded data of a stream
+func (p *pdfFile) decode(stream *pdfStream) ([]byte, error) {
+	var filters pdfArray
+	switch f := p.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = pdfArray{f}
+	case pdfArray:
+		filters = f
+	}
+
+	data := stream.raw
+	for _, filter := range filters {
+		var r io.Reader
+		switch p.resolve(filter) {
+		case pdfName("FlateDecode"):
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Flate stream: %w", err)
+			}
+			defer func() { _ = zr.Close() }()
+			r = zr
+		case pdfName("ASCIIHexDecode"):
+			if end := bytes.IndexByte(data, '>'); end >= 0 {
+				data = data[:end]
+			}
+			r = hex.NewDecoder(bytes.NewReader(bytes.Join(bytes.Fields(data), nil)))
+		case pdfName("ASCII85Decode"):
+			data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+			if end := bytes.Index(data, []byte("~>")); end >= 0 {
+				data = data[:end]
+			}
+			r = ascii85.NewDecoder(bytes.NewReader(data))
+		default:
+			return nil, fmt.Errorf("%w: PDF stream filter %v", ErrUnsupportedDocument, filter)
+		}
+
+		decoded, err := io.ReadAll(io.LimitReader(r, maxPDFStreamSize+1))
+		if err != nil && len(decoded) == 0 {
+			return nil, fmt.Errorf("failed to decode PDF stream: %w", err)
+		}
+		if len(decoded) > maxPDFStreamSize {
+			return nil, fmt.Errorf("PDF stream is larger than %d bytes", maxPDFStreamSize)
+		}
+		data = decoded
+	}
+	return data, nil
+}
+
+// pageText extracts the text drawn by a page's content streams
+func (p *pdfFile) pageText(page pdfPage) (string, error) {
+	var streams []interface{}
+	switch contents := p.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		streams = append(streams, contents)
+	case pdfArray:
+		streams = contents
+	}
+
+	var content []byte
+	for _, s := range streams {
+		stream, ok := p.resolve(s).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := p.decode(stream)
+		if err != nil {
+			return "", err
+		}
+		content = append(append(content, data...), '\n')
+	}
+
+	var text bytes.Buffer
+	p.contentText(&text, content, page.resources, 0)
+	return text.String(), nil
+}
+
+// pdfFont decodes the strings shown with a font
+type pdfFont struct {
+	// toUnicode maps character codes of codeLength bytes to text
+	toUnicode  map[uint32]string
+	codeLength int
+	// composite fonts without a ToUnicode map cannot be decoded
+	composite bool
+}
+
+// font loads a font from a resource dictionary
+func (p *pdfFile) font(resources pdfDict, name pdfName) *pdfFont {
+	dict := p.dict(p.dict(resources["Font"])[name])
+	font := &pdfFont{composite: dict["Subtype"] == pdfName("Type0")}
+	if stream, ok := p.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := p.decode(stream); err == nil {
+			font.toUnicode, font.codeLength = parseToUnicode(data)
+		}
+	}
+	return font
+}
+
+// decode converts a shown string to text
+func (f *pdfFont) decode(s pdfString) string {
+	if f == nil || f.toUnicode == nil {
+		if f != nil && f.composite {
+			return ""
+		}
+		return decodePDFDocEncoding(s)
+	}
+
+	var text []rune
+	for i := 0; i+f.codeLength <= len(s); i += f.codeLength {
+		var code uint32
+		for _, b := range []byte(s[i : i+f.codeLength]) {
+			code = code<<8 | uint32(b)
+		}
+		text = append(text, []rune(f.toUnicode[code])...)
+	}
+	return string(text)
+}
+
+// contentText appends the text shown by a content stream to text, in the
+// order it is drawn
+func (p *pdfFile) contentText(text *bytes.Buffer, content []byte, resources pdfDict, depth int) {
+	lexer := &pdfLexer{data: content}
+	fonts := make(map[pdfName]*pdfFont)
+	var font *pdfFont
+	var operands []interface{}
+
+	for {
+		v, err := lexer.readObject()
+		if err != nil {
+			return
+		}
+		op, ok := v.(pdfKeyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+
+		switch op {
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					if fonts[name] == nil {
+						fonts[name] = p.font(resources, name)
+					}
+					font = fonts[name]
+				}
+			}
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				text.WriteByte('\n')
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					text.WriteString(font.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[len(operands)-1].(pdfArray)
+				for _, item := range items {
+					switch item := item.(type) {
+					case pdfString:
+						text.WriteString(font.decode(item))
+					case float64:
+						// Large negative adjustments move right by about a space
+						if item < -250 {
+							text.WriteByte(' ')
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, ok := operands[len(operands)-1].(float64); ok && ty != 0 {
+					text.WriteByte('\n')
+				} else {
+					text.WriteByte(' ')
+				}
+			}
+		case "T*", "Tm", "ET":
+			text.WriteByte('\n')
+		case "BI":
+			// Skip inline image data, which ends at an EI keyword
+			end := inlineImageEnd.FindIndex(content[lexer.pos:])
+			if end == nil {
+				return
+			}
+			lexer.pos += end[1]
+		case "Do":
+			if len(operands) > 0 && depth < maxFormDepth {
+				p.formText(text, operands[len(operands)-1], resources, depth)
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// formText appends the text of a form XObject drawn with the Do operator
+func (p *pdfFile) formText(text *bytes.Buffer, operand interface{}, resources pdfDict, depth int) {
+	name, ok := operand.(pdfName)
+	if !ok {
+		return
+	}
+	form, ok := p.resolve(p.dict(resources["XObject"])[name]).(*pdfStream)
+	if !ok || form.dict["Subtype"] != pdfName("Form") {
+		return
+	}
+	data, err := p.decode(form)
+	if err != nil {
+		return
+	}
+	if r := p.dict(form.dict["Resources"]); r != nil {
+		resources = r
+	}
+	p.contentText(text, data, resources, depth+1)
+}
+
+// parseToUnicode reads the bfchar and bfrange mappings of a ToUnicode CMap
+// and the length of its character codes
+func parseToUnicode(data []byte) (map[uint32]string, int) {
+	mapping := make(map[uint32]string)
+	codeLength := 0
+	lexer := &pdfLexer{data: data}
+	var operands []interface{}
+
+	code := func(v interface{}) (uint32, bool) {
+		s, ok := v.(pdfString)
+		if !ok || len(s) == 0 || len(s) > 4 {
+			return 0, false
+		}
+		if codeLength == 0 {
+			codeLength = len(s)
+		}
+		var c uint32
+		for _, b := range []byte(s) {
+			c = c<<8 | uint32(b)
+		}
+		return c, true
+	}
+
+	for {
+		v, err := lexer.readObject()
+		if err != nil {
+			break
+		}
+		op, ok := v.(pdfKeyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+
+		switch op {
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok := code(operands[i])
+				if dst, isString := operands[i+1].(pdfString); ok && isString {
+					mapping[src] = decodeUTF16(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := code(operands[i])
+				hi, ok2 := code(operands[i+1])
+				if !ok1 || !ok2 || hi < lo || hi-lo > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					// Consecutive codes map to consecutive characters
+					base := []rune(decodeUTF16(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for c := lo; c <= hi; c++ {
+						last := base[len(base)-1] + rune(c-lo)
+						mapping[c] = string(append(append([]rune{}, base[:len(base)-1]...), last))
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && lo+uint32(j) <= hi {
+							mapping[lo+uint32(j)] = decodeUTF16(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+
+	if codeLength == 0 {
+		codeLength = 1
+	}
+	return mapping, codeLength
+}
+
+// decodeUTF16 decodes big-endian UTF-16 text
+func decodeUTF16(s pdfString) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeTextString decodes a PDF text string, which is UTF-16 with a byte
+// order mark or PDFDocEncoding
+func decodeTextString(s pdfString) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		return decodeUTF16(s[2:])
+	}
+	return decodePDFDocEncoding(s)
+}
+
+// pdfDocHigh maps the PDFDocEncoding and WinAnsiEncoding characters in
+// 0x80-0x9F that are common in text; other bytes are read as Latin-1
+var pdfDocHigh = map[byte]rune{
+	0x80: '•', 0x84: '—', 0x85: '–', 0x8B: '-', 0x8C: '“', 0x8D: '”', 0x8E: '‘', 0x8F: '’',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x83: '…',
+}
+
+// decodePDFDocEncoding decodes single-byte text, dropping control characters
+func decodePDFDocEncoding(s pdfString) string {
+	text := make([]rune, 0, len(s))
+	for _, b := range []byte(s) {
+		switch r, ok := pdfDocHigh[b]; {
+		case ok:
+			text = append(text, r)
+		case b == '\t' || b == '\n' || b == '\r':
+			text = append(text, ' ')
+		case b >= 0x20 && b != 0x7F && (b < 0x80 || b >= 0xA0):
+			text = append(text, rune(b))
+		}
+	}
+	return string(text)
+}
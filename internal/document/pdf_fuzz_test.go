@@ -0,0 +1,20 @@
+package document
+
+import (
+	"testing"
+)
+
+func FuzzParsePDF(f *testing.F) {
+	f.Add(buildPDF("BT /F1 12 Tf (Hello) Tj ET", "q Q"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj << /Length -1000 >>\nstream\nx\nendstream\nendobj\n"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj << /Type /ObjStm /N 1 /First -50 /Length 5 >>\nstream\n1 0 x\nendstream\nendobj\n"))
+	f.Add([]byte("%PDF-1.7\ntrailer << /Root 1 0 R >>\n%%EOF\n"))
+	f.Add([]byte("not a pdf"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := parsePDF(data)
+		if err == nil && doc == nil {
+			t.Fatal("parsePDF returned neither a document nor an error")
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package document
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// PDF object types produced by pdfLexer
+type (
+	pdfName    string
+	pdfString  string
+	pdfKeyword string
+	pdfDict    map[pdfName]interface{}
+	pdfArray   []interface{}
+	pdfRef     struct{ num, gen int }
+)
+
+// pdfStream is a stream object with its still-encoded data
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+// errPDFEnd is returned when the lexer runs out of input
+var errPDFEnd = errors.New("unexpected end of PDF data")
+
+// pdfLexer reads PDF objects and content stream operators. Numbers are
+// float64, booleans bool and null nil; other values use the pdf* types.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(b byte) bool {
+	return b == 0 || b == '\t' || b == '\n' || b == '\f' || b == '\r' || b == ' '
+}
+
+func isPDFDelimiter(b byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), b) >= 0
+}
+
+// skipSpace skips whitespace and comments
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch b := l.data[l.pos]; {
+		case isPDFSpace(b):
+			l.pos++
+		case b == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readObject reads the next object or operator keyword. Array and dict
+// ends are returned as the keywords "]" and ">>".
+func (l *pdfLexer) readObject() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errPDFEnd
+	}
+
+	switch b := l.data[l.pos]; {
+	case b == '/':
+		return l.readName(), nil
+	case b == '(':
+		return l.readLiteralString()
+	case b == '<' && l.peek(1) == '<':
+		l.pos += 2
+		return l.readDict()
+	case b == '<':
+		return l.readHexString()
+	case b == '>' && l.peek(1) == '>':
+		l.pos += 2
+		return pdfKeyword(">>"), nil
+	case b == '[':
+		l.pos++
+		return l.readArray()
+	case b == ']' || b == '{' || b == '}' || b == ')' || b == '>':
+		l.pos++
+		return pdfKeyword(l.data[l.pos-1 : l.pos]), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if n, err := strconv.ParseFloat(word, 64); err == nil {
+		return l.readReference(n), nil
+	}
+	return pdfKeyword(word), nil
+}
+
+// readReference turns "num gen R" into a pdfRef, or returns n unchanged
+func (l *pdfLexer) readReference(n float64) interface{} {
+	if n != float64(int(n)) || n < 0 {
+		return n
+	}
+
+	save := l.pos
+	l.skipSpace()
+	if next := l.peek(0); next < '0' || next > '9' {
+		l.pos = save
+		return n
+	}
+	gen, err := l.readObject()
+	if g, ok := gen.(float64); ok && err == nil {
+		if r, err := l.readObject(); err == nil && r == pdfKeyword("R") {
+			return pdfRef{num: int(n), gen: int(g)}
+		}
+	}
+	l.pos = save
+	return n
+}
+
+func (l *pdfLexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *pdfLexer) readName() pdfName {
+	l.pos++
+	var name []byte
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		b := l.data[l.pos]
+		if b == '#' && l.pos+2 < len(l.data) {
+			if decoded, err := hex.DecodeString(string(l.data[l.pos+1 : l.pos+3])); err == nil {
+				name = append(name, decoded[0])
+				l.pos += 3
+				continue
+			}
+		}
+		name = append(name, b)
+		l.pos++
+	}
+	return pdfName(name)
+}
+
+// pdfEscapes maps the characters after a backslash in literal strings
+var pdfEscapes = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', 'b': '\b', 'f': '\f', '(': '(', ')': ')', '\\': '\\'}
+
+func (l *pdfLexer) readLiteralString() (pdfString, error) {
+	l.pos++
+	var s []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		l.pos++
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return pdfString(s), nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return "", errPDFEnd
+			}
+			next := l.data[l.pos]
+			l.pos++
+			if escaped, ok := pdfEscapes[next]; ok {
+				s = append(s, escaped)
+				continue
+			}
+			switch {
+			case next >= '0' && next <= '7':
+				// Up to three octal digits
+				value := int(next - '0')
+				for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+					value = value*8 + int(l.data[l.pos]-'0')
+					l.pos++
+				}
+				s = append(s, byte(value))
+			case next == '\r':
+				// Line continuation
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case next != '\n':
+				s = append(s, next)
+			}
+			continue
+		}
+		s = append(s, b)
+	}
+	return "", errPDFEnd
+}
+
+func (l *pdfLexer) readHexString() (pdfString, error) {
+	l.pos++
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		return "", errPDFEnd
+	}
+
+	digits := make([]byte, 0, end)
+	for _, b := range l.data[l.pos : l.pos+end] {
+		if !isPDFSpace(b) {
+			digits = append(digits, b)
+		}
+	}
+	l.pos += end + 1
+
+	// An odd final digit is followed by an implied 0
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	decoded, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex string: %w", err)
+	}
+	return pdfString(decoded), nil
+}
+
+func (l *pdfLexer) readArray() (pdfArray, error) {
+	var array pdfArray
+	for {
+		v, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		if v == pdfKeyword("]") {
+			return array, nil
+		}
+		array = append(array, v)
+	}
+}
+
+func (l *pdfLexer) readDict() (pdfDict, error) {
+	dict := make(pdfDict)
+	for {
+		key, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		if key == pdfKeyword(">>") {
+			return dict, nil
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			return nil, fmt.Errorf("invalid dictionary key %v", key)
+		}
+		value, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		dict[name] = value
+	}
+}
@@ -0,0 +1,227 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pdfBuilder assembles a PDF file from object definitions
+type pdfBuilder struct {
+	objects []string
+	trailer string
+}
+
+// add appends an object and returns its number
+func (b *pdfBuilder) add(object string) int {
+	b.objects = append(b.objects, object)
+	return len(b.objects)
+}
+
+// set replaces the object with number num
+func (b *pdfBuilder) set(num int, object string) {
+	b.objects[num-1] = object
+}
+
+// stream returns a stream object holding data
+func stream(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func flate(data string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, _ = w.Write([]byte(data))
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func (b *pdfBuilder) bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+	for i, object := range b.objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	if b.trailer != "" {
+		fmt.Fprintf(&buf, "trailer\n%s\n", b.trailer)
+	}
+	buf.WriteString("%%EOF\n")
+	return buf.Bytes()
+}
+
+// buildPDF builds a PDF with one page per content stream, using a simple
+// Helvetica font named F1
+func buildPDF(contents ...string) []byte {
+	b := &pdfBuilder{}
+	catalog := b.add("")
+	pages := b.add("")
+	font := b.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+	var kids []string
+	for _, content := range contents {
+		data := b.add(stream("", []byte(content)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", b.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /Contents %d 0 R >>", pages, data))))
+	}
+
+	b.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	b.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /Resources << /Font << /F1 %d 0 R >> >> >>",
+		strings.Join(kids, " "), len(kids), font))
+	info := b.add("<< /Title (Test \\(PDF\\) Document) /Producer (test) >>")
+	b.trailer = fmt.Sprintf("<< /Size %d /Root %d 0 R /Info %d 0 R >>", len(b.objects)+1, catalog, info)
+	return b.bytes()
+}
+
+func TestParsePDF(t *testing.T) {
+	data := buildPDF(
+		"BT /F1 12 Tf 72 720 Td (Hello,) Tj ( world!) Tj 0 -14 Td (Second line) Tj ET",
+		"q 0 0 1 rg Q",
+		"BT /F1 12 Tf [(Kern)-20(ed)-600(text)] TJ T* (Next\\051 \\(escaped\\)) ' ET",
+	)
+
+	doc, err := parsePDF(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Test (PDF) Document", doc.Title)
+	assert.Equal(t, []Segment{
+		{Title: "Page 1", Text: "Hello, world! Second line"},
+		{Title: "Page 3", Text: "Kerned text Next) (escaped)"},
+	}, doc.Segments, "pages without text are skipped")
+}
+
+func TestParsePDFCompressedStreams(t *testing.T) {
+	b := &pdfBuilder{}
+	content := b.add(stream("/Filter /FlateDecode", flate("BT /F1 10 Tf (Compressed) Tj ET")))
+	hexContent := b.add(stream("/Filter /ASCIIHexDecode", []byte("4254202848657829 20546A204554>")))
+
+	// Page tree objects live in a compressed object stream
+	pagesObject := "<< /Type /Pages /Kids [11 0 R] /Count 1 >>"
+	pageObject := fmt.Sprintf("<< /Type /Page /Parent 10 0 R /Contents [%d 0 R %d 0 R] >>", content, hexContent)
+	header := fmt.Sprintf("10 0 11 %d ", len(pagesObject)+1)
+	b.add(stream(fmt.Sprintf("/Type /ObjStm /N 2 /First %d /Filter /FlateDecode", len(header)),
+		flate(header+pagesObject+" "+pageObject)))
+	b.add("<< /Type /Catalog /Pages 10 0 R >>")
+
+	doc, err := parsePDF(b.bytes())
+	require.NoError(t, err)
+	require.Len(t, doc.Segments, 1)
+	assert.Equal(t, "Compressed Hex", doc.Segments[0].Text)
+}
+
+func TestParsePDFToUnicode(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0003> <0020>
+<0011> <00E9>
+endbfchar
+1 beginbfrange
+<0024> <0026> <0041>
+endbfrange
+endcmap`
+
+	b := &pdfBuilder{}
+	toUnicode := b.add(stream("", []byte(cmap)))
+	font := b.add(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /ToUnicode %d 0 R >>", toUnicode))
+	opaque := b.add("<< /Type /Font /Subtype /Type0 >>")
+	form := b.add(stream("/Type /XObject /Subtype /Form", []byte("BT /F2 9 Tf <0025> Tj ET")))
+	content := b.add(stream("", []byte("BT /F1 12 Tf <00240025002600030011> Tj /F3 12 Tf <0102> Tj ET /Fm1 Do")))
+	page := b.add(fmt.Sprintf("<< /Type /Page /Contents %d 0 R /Resources << "+
+		"/Font << /F1 %d 0 R /F2 %d 0 R /F3 %d 0 R >> /XObject << /Fm1 %d 0 R >> >> >>",
+		content, font, font, opaque, form))
+	pages := b.add(fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+	catalog := b.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	b.trailer = fmt.Sprintf("<< /Root %d 0 R >>", catalog)
+
+	doc, err := parsePDF(b.bytes())
+	require.NoError(t, err)
+	require.Len(t, doc.Segments, 1)
+	assert.Equal(t, "ABC é B", doc.Segments[0].Text, "composite fonts without ToUnicode are skipped")
+}
+
+func TestParsePDFErrors(t *testing.T) {
+	_, err := parsePDF([]byte("not a pdf"))
+	assert.ErrorIs(t, err, ErrUnsupportedDocument)
+
+	encrypted := append(buildPDF("BT (x) Tj ET"), []byte("trailer << /Encrypt 9 0 R >>")...)
+	_, err = parsePDF(encrypted)
+	assert.ErrorContains(t, err, "encrypted")
+
+	_, err = parsePDF([]byte("%PDF-1.4\n1 0 obj << /Type /Font >> endobj\n"))
+	assert.ErrorContains(t, err, "no document catalog")
+
+	b := &pdfBuilder{}
+	content := b.add(stream("/Filter /LZWDecode", []byte("xx")))
+	b.add(fmt.Sprintf("<< /Type /Page /Contents %d 0 R >>", content))
+	b.add("<< /Type /Pages /Kids [2 0 R] >>")
+	b.add("<< /Type /Catalog /Pages 3 0 R >>")
+	_, err = parsePDF(b.bytes())
+	assert.ErrorContains(t, err, "LZWDecode")
+}
+
+func TestParsePDFCorruptOffsets(t *testing.T) {
+	// A negative length falls back to the endstream keyword
+	b := &pdfBuilder{}
+	content := b.add("<< /Length -1000 >>\nstream\nBT (Still read) Tj ET\nendstream")
+	b.add(fmt.Sprintf("<< /Type /Page /Parent 3 0 R /Contents %d 0 R >>", content))
+	b.add("<< /Type /Pages /Kids [2 0 R] /Count 1 >>")
+	b.add("<< /Type /Catalog /Pages 3 0 R >>")
+	doc, err := parsePDF(b.bytes())
+	require.NoError(t, err)
+	require.Len(t, doc.Segments, 1)
+	assert.Equal(t, "Still read", doc.Segments[0].Text)
+
+	// Negative offsets in an object stream are skipped
+	for _, tt := range []struct {
+		header string
+		first  int
+	}{
+		{header: "10 -50 ", first: 7},
+		{header: "10 0 ", first: -50},
+	} {
+		b := &pdfBuilder{}
+		b.add(stream(fmt.Sprintf("/Type /ObjStm /N 1 /First %d", tt.first),
+			[]byte(tt.header+"<< /Type /Pages /Kids [] /Count 0 >>")))
+		b.add("<< /Type /Catalog /Pages 10 0 R >>")
+		assert.NotPanics(t, func() { _, _ = parsePDF(b.bytes()) }, tt.header)
+	}
+}
+
+func TestPDFPageTreeCycle(t *testing.T) {
+	b := &pdfBuilder{}
+	b.add("<< /Type /Pages /Kids [1 0 R 2 0 R] >>")
+	b.add(stream("", []byte("BT (Only once) Tj ET")))
+	b.set(2, "<< /Type /Page /Contents 3 0 R >>")
+	b.add(stream("", []byte("BT (Only once) Tj ET")))
+	b.add("<< /Type /Catalog /Pages 1 0 R >>")
+
+	doc, err := parsePDF(b.bytes())
+	require.NoError(t, err)
+	assert.Len(t, doc.Segments, 1)
+}
+
+func TestPDFLexer(t *testing.T) {
+	lexer := &pdfLexer{data: []byte(`<< /A#20B [1 2.5 -3 (a(b)c\101) <48 65 6C>] /R 12 0 R >> % comment
+true null Tj`)}
+
+	v, err := lexer.readObject()
+	require.NoError(t, err)
+	assert.Equal(t, pdfDict{
+		"A B": pdfArray{1.0, 2.5, -3.0, pdfString("a(b)cA"), pdfString("Hel")},
+		"R":   pdfRef{num: 12},
+	}, v)
+
+	for _, want := range []interface{}{true, nil, pdfKeyword("Tj")} {
+		v, err = lexer.readObject()
+		require.NoError(t, err)
+		assert.Equal(t, want, v)
+	}
+	_, err = lexer.readObject()
+	assert.ErrorIs(t, err, errPDFEnd)
+}
@@ -0,0 +1,3 @@
+// Package feed reads RSS and Atom feeds for narration, remembers which items
+// have already been synthesized, and builds podcast feeds of the results.
+package feed
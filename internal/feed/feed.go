@@ -0,0 +1,344 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/document"
+)
+
+// maxFeedSize bounds the size of a downloaded feed
+const maxFeedSize = 16 << 20
+
+// ErrInvalidFeed is returned for documents that are not RSS or Atom feeds
+var ErrInvalidFeed = errors.New("invalid feed")
+
+// Feed is a parsed RSS or Atom feed
+type Feed struct {
+	Title string
+	Link  string
+	// Items are ordered oldest first
+	Items []Item
+}
+
+// Item is one article of a feed
+type Item struct {
+	// ID identifies the item across fetches: its guid, link, or a hash of
+	// its title and date
+	ID        string
+	Title     string
+	Link      string
+	Published time.Time
+	// Text is the readable text of the article body
+	Text string
+}
+
+// rssDocument is an RSS 2.0 feed
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Link  string    `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// atomDocument is an Atom feed
+type atomDocument struct {
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+}
+
+// atomText is an Atom text construct, holding escaped HTML or, with type
+// "xhtml", inline markup
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) html() string {
+	if t.Type == "xhtml" {
+		return t.Inner
+	}
+	return t.Text
+}
+
+// Fetch downloads and parses the feed at rawURL, which must be an http or
+// https URL
+func Fetch(ctx context.Context, client *http.Client, rawURL string) (*Feed, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q is not an http or https URL", ErrInvalidFeed, rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create feed request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	if len(data) > maxFeedSize {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrInvalidFeed, maxFeedSize)
+	}
+	return Parse(data)
+}
+
+// Parse parses an RSS 2.0 or Atom feed
+func Parse(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var f *Feed
+	switch root {
+	case "rss":
+		var doc rssDocument
+		if err := unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		f, err = fromRSS(&doc)
+	case "feed":
+		var doc atomDocument
+		if err := unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		f, err = fromAtom(&doc)
+	default:
+		return nil, fmt.Errorf("%w: unexpected <%s> document", ErrInvalidFeed, root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sortItems(f.Items)
+	return f, nil
+}
+
+func fromRSS(doc *rssDocument) (*Feed, error) {
+	f := &Feed{Title: strings.TrimSpace(doc.Channel.Title), Link: strings.TrimSpace(doc.Channel.Link)}
+	for _, entry := range doc.Channel.Items {
+		body := entry.Content
+		if strings.TrimSpace(body) == "" {
+			body = entry.Description
+		}
+		item, err := newItem(entry.GUID, entry.Link, entry.Title, parseDate(entry.PubDate), body)
+		if err != nil {
+			return nil, err
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f, nil
+}
+
+func fromAtom(doc *atomDocument) (*Feed, error) {
+	f := &Feed{Title: strings.TrimSpace(doc.Title), Link: alternateLink(doc.Links)}
+	for _, entry := range doc.Entries {
+		body := entry.Content.html()
+		if strings.TrimSpace(body) == "" {
+			body = entry.Summary.html()
+		}
+		published := parseDate(entry.Published)
+		if published.IsZero() {
+			published = parseDate(entry.Updated)
+		}
+		item, err := newItem(entry.ID, alternateLink(entry.Links), entry.Title, published, body)
+		if err != nil {
+			return nil, err
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f, nil
+}
+
+func newItem(id, link, title string, published time.Time, body string) (Item, error) {
+	text, err := document.HTMLText(body)
+	if err != nil {
+		return Item{}, fmt.Errorf("%w: unreadable item %q: %v", ErrInvalidFeed, title, err)
+	}
+
+	item := Item{
+		ID:        strings.TrimSpace(id),
+		Title:     strings.Join(strings.Fields(title), " "),
+		Link:      strings.TrimSpace(link),
+		Published: published,
+		Text:      text,
+	}
+	if item.ID == "" {
+		item.ID = item.Link
+	}
+	if item.ID == "" {
+		sum := sha256.Sum256([]byte(item.Title + "\n" + published.UTC().Format(time.RFC3339)))
+		item.ID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return item, nil
+}
+
+// alternateLink returns the page link among Atom links
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// sortItems orders items oldest first. Feeds list items newest first, which
+// is assumed when some items are undated.
+func sortItems(items []Item) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	for _, item := range items {
+		if item.Published.IsZero() {
+			return
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.Before(items[j].Published)
+	})
+}
+
+// dateLayouts are the date formats seen in RSS (RFC 822 and variants) and
+// Atom (RFC 3339) feeds
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+// parseDate parses a feed date, returning the zero time when it is missing
+// or unrecognized
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// rootElement returns the local name of the document's root element
+func rootElement(data []byte) (string, error) {
+	decoder := newDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidFeed, err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func unmarshal(data []byte, v interface{}) error {
+	if err := newDecoder(data).Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFeed, err)
+	}
+	return nil
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charsetReader
+	return decoder
+}
+
+// charsetReader decodes the legacy single-byte encodings still used by some
+// feeds; encoding/xml handles UTF-8 itself
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1":
+		return singleByteReader(input, nil)
+	case "windows-1252", "cp1252":
+		return singleByteReader(input, &cp1252)
+	default:
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+}
+
+// cp1252 maps the Windows-1252 bytes 0x80-0x9F, which Latin-1 leaves as
+// control characters, mostly to typographic punctuation
+var cp1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// singleByteReader decodes Latin-1, with high control characters replaced
+// from high when it is set
+func singleByteReader(input io.Reader, high *[32]rune) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	for _, c := range data {
+		if high != nil && c >= 0x80 && c < 0xa0 {
+			b.WriteRune(high[c-0x80])
+			continue
+		}
+		b.WriteRune(rune(c))
+	}
+	return strings.NewReader(b.String()), nil
+}
@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Daily News</title>
+    <link>https://news.example.com/</link>
+    <item>
+      <title>Second   story</title>
+      <link>https://news.example.com/2</link>
+      <guid>news-2</guid>
+      <pubDate>Tue, 03 Mar 2026 08:00:00 +0000</pubDate>
+      <description>Short summary</description>
+      <content:encoded><![CDATA[<p>Full <em>second</em> story.</p><p>More.</p>]]></content:encoded>
+    </item>
+    <item>
+      <title>First story</title>
+      <link>https://news.example.com/1</link>
+      <pubDate>Mon, 2 Mar 2026 08:00:00 GMT</pubDate>
+      <description>&lt;p&gt;The first story.&lt;/p&gt;</description>
+    </item>
+  </channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Engineering Blog</title>
+  <link href="https://blog.example.com/feed.xml" rel="self"/>
+  <link href="https://blog.example.com/"/>
+  <entry>
+    <id>tag:blog.example.com,2026:1</id>
+    <title>Hello Atom</title>
+    <link href="https://blog.example.com/hello" rel="alternate"/>
+    <updated>2026-03-02T10:00:00Z</updated>
+    <summary>Summary only</summary>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Inline <b>markup</b>.</p></div></content>
+  </entry>
+  <entry>
+    <id>tag:blog.example.com,2026:2</id>
+    <title>Escaped</title>
+    <published>2026-03-01T10:00:00Z</published>
+    <summary type="html">&lt;p&gt;Escaped &amp;amp; summary&lt;/p&gt;</summary>
+  </entry>
+</feed>`
+
+func TestParseRSS(t *testing.T) {
+	f, err := Parse([]byte(testRSS))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Daily News", f.Title)
+	assert.Equal(t, "https://news.example.com/", f.Link)
+	require.Len(t, f.Items, 2)
+
+	// Oldest first
+	first, second := f.Items[0], f.Items[1]
+	assert.Equal(t, "https://news.example.com/1", first.ID)
+	assert.Equal(t, "First story", first.Title)
+	assert.Equal(t, "The first story.", first.Text)
+	assert.Equal(t, time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC), first.Published.UTC())
+
+	assert.Equal(t, "news-2", second.ID)
+	assert.Equal(t, "Second story", second.Title)
+	assert.Equal(t, "Full second story.\n\nMore.", second.Text)
+}
+
+func TestParseAtom(t *testing.T) {
+	f, err := Parse([]byte(testAtom))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Engineering Blog", f.Title)
+	assert.Equal(t, "https://blog.example.com/", f.Link)
+	require.Len(t, f.Items, 2)
+
+	assert.Equal(t, "Escaped", f.Items[0].Title)
+	assert.Equal(t, "Escaped & summary", f.Items[0].Text)
+	assert.Empty(t, f.Items[0].Link)
+
+	assert.Equal(t, "tag:blog.example.com,2026:1", f.Items[1].ID)
+	assert.Equal(t, "https://blog.example.com/hello", f.Items[1].Link)
+	assert.Equal(t, "Inline markup.", f.Items[1].Text)
+}
+
+func TestParseUndatedItemsReversed(t *testing.T) {
+	f, err := Parse([]byte(`<rss><channel><title>T</title>
+<item><title>Newest</title><description>b</description></item>
+<item><title>Oldest</title><description>a</description></item>
+</channel></rss>`))
+	require.NoError(t, err)
+
+	require.Len(t, f.Items, 2)
+	assert.Equal(t, "Oldest", f.Items[0].Title)
+	assert.Equal(t, "Newest", f.Items[1].Title)
+	// Without guid or link, the ID is derived from the title
+	assert.Contains(t, f.Items[0].ID, "sha256:")
+	assert.NotEqual(t, f.Items[0].ID, f.Items[1].ID)
+}
+
+func TestParseCharsets(t *testing.T) {
+	data := []byte("<?xml version=\"1.0\" encoding=\"windows-1252\"?>\n" +
+		"<rss><channel><title>Caf\xe9 \x93news\x94</title></channel></rss>")
+
+	f, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "Café “news”", f.Title)
+
+	_, err = Parse([]byte(`<?xml version="1.0" encoding="shift_jis"?><rss/>`))
+	assert.ErrorIs(t, err, ErrInvalidFeed)
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "HTML page", data: "<html><body>Not a feed</body></html>"},
+		{name: "empty", data: ""},
+		{name: "truncated", data: "<rss><channel><title>T"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			assert.ErrorIs(t, err, ErrInvalidFeed)
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer server.Close()
+
+	f, err := Fetch(context.Background(), server.Client(), server.URL+"/feed.xml")
+	require.NoError(t, err)
+	assert.Equal(t, "Daily News", f.Title)
+
+	_, err = Fetch(context.Background(), server.Client(), server.URL+"/missing")
+	assert.ErrorContains(t, err, "404")
+
+	_, err = Fetch(context.Background(), server.Client(), "file:///etc/passwd")
+	assert.True(t, errors.Is(err, ErrInvalidFeed))
+}
@@ -0,0 +1,122 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// PodcastFile is the name of the generated podcast feed in the output
+// directory
+const PodcastFile = "podcast.xml"
+
+// Podcast describes the generated feed
+type Podcast struct {
+	Title string
+	// Link is the page of the narrated feed
+	Link string
+	// BaseURL is where the audio files are published; enclosure URLs are
+	// the file names resolved against it
+	BaseURL string
+}
+
+type podcastRSS struct {
+	XMLName xml.Name       `xml:"rss"`
+	Version string         `xml:"version,attr"`
+	Itunes  string         `xml:"xmlns:itunes,attr"`
+	Channel podcastChannel `xml:"channel"`
+}
+
+type podcastChannel struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description"`
+	Generator   string        `xml:"generator"`
+	Items       []podcastItem `xml:"item"`
+}
+
+type podcastItem struct {
+	Title     string           `xml:"title"`
+	Link      string           `xml:"link,omitempty"`
+	GUID      podcastGUID      `xml:"guid"`
+	PubDate   string           `xml:"pubDate,omitempty"`
+	Enclosure podcastEnclosure `xml:"enclosure"`
+	Duration  int              `xml:"itunes:duration,omitempty"`
+}
+
+type podcastGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type podcastEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// BuildPodcast renders an RSS 2.0 podcast feed of the narrated episodes,
+// newest first
+func BuildPodcast(p Podcast, episodes []*Episode) ([]byte, error) {
+	base, err := url.Parse(strings.TrimSuffix(p.BaseURL, "/") + "/")
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid podcast base URL %q: must be an http or https URL", p.BaseURL)
+	}
+
+	channel := podcastChannel{
+		Title:       p.Title,
+		Link:        p.Link,
+		Description: fmt.Sprintf("Narrated articles from %s", p.Title),
+		Generator:   "assistant-cli",
+	}
+	for i := len(episodes) - 1; i >= 0; i-- {
+		episode := episodes[i]
+		if episode.File == "" {
+			continue
+		}
+
+		published := episode.Published
+		if published.IsZero() {
+			published = episode.Processed
+		}
+		channel.Items = append(channel.Items, podcastItem{
+			Title:   episode.Title,
+			Link:    episode.Link,
+			GUID:    podcastGUID{Value: episode.ID},
+			PubDate: published.UTC().Format(time.RFC1123Z),
+			Enclosure: podcastEnclosure{
+				URL:    base.ResolveReference(&url.URL{Path: episode.File}).String(),
+				Length: episode.Size,
+				Type:   audioMIMEType(episode.File),
+			},
+			Duration: int(episode.DurationSeconds + 0.5),
+		})
+	}
+
+	data, err := xml.MarshalIndent(podcastRSS{
+		Version: "2.0",
+		Itunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		Channel: channel,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode podcast feed: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// audioMIMEType returns the enclosure type of an audio file
+func audioMIMEType(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".ogg":
+		return "audio/ogg"
+	case ".wav":
+		return "audio/wav"
+	default:
+		return "application/octet-stream"
+	}
+}
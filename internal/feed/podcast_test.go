@@ -0,0 +1,47 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPodcast(t *testing.T) {
+	processed := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	episodes := []*Episode{
+		{ID: "a", Title: "First & best", Link: "https://news.example.com/1", File: "001_First_best.mp3", Size: 1234,
+			Published: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC), Processed: processed, DurationSeconds: 61.6},
+		{ID: "b", Title: "Skipped", Processed: processed},
+		{ID: "c", Title: "Second", File: "002_Second.ogg", Size: 99, Processed: processed},
+	}
+
+	data, err := BuildPodcast(Podcast{
+		Title:   "Daily News",
+		Link:    "https://news.example.com/",
+		BaseURL: "https://cdn.example.com/audio",
+	}, episodes)
+	require.NoError(t, err)
+	xml := string(data)
+
+	assert.Contains(t, xml, `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, xml, `<title>Daily News</title>`)
+	assert.Contains(t, xml, `<title>First &amp; best</title>`)
+	assert.Contains(t, xml,
+		`<enclosure url="https://cdn.example.com/audio/001_First_best.mp3" length="1234" type="audio/mpeg"></enclosure>`)
+	assert.Contains(t, xml, `<itunes:duration>62</itunes:duration>`)
+	assert.Contains(t, xml, `<pubDate>Mon, 02 Mar 2026 08:00:00 +0000</pubDate>`)
+	// Undated episodes use the processing time
+	assert.Contains(t, xml, `<pubDate>Wed, 04 Mar 2026 12:00:00 +0000</pubDate>`)
+	assert.NotContains(t, xml, "Skipped")
+
+	// Newest first
+	assert.Less(t, strings.Index(xml, "002_Second.ogg"), strings.Index(xml, "001_First_best.mp3"))
+}
+
+func TestBuildPodcastInvalidBaseURL(t *testing.T) {
+	_, err := BuildPodcast(Podcast{Title: "T", BaseURL: "cdn.example.com"}, nil)
+	assert.ErrorContains(t, err, "invalid podcast base URL")
+}
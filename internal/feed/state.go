@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// stateVersion identifies the on-disk state format
+const stateVersion = 1
+
+// Episode records a narrated feed item. File is empty for items that were
+// skipped because they had no text.
+type Episode struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link,omitempty"`
+	Published time.Time `json:"published,omitempty"`
+	Processed time.Time `json:"processed"`
+	File      string    `json:"file,omitempty"`
+	Size      int64     `json:"size,omitempty"`
+	// DurationSeconds is the playing time, when it could be read
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+}
+
+// FeedState is the narration history of one feed
+type FeedState struct {
+	Title    string     `json:"title"`
+	Episodes []*Episode `json:"episodes"`
+}
+
+// Seen reports whether the item with id has already been processed
+func (f *FeedState) Seen(id string) bool {
+	for _, episode := range f.Episodes {
+		if episode.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Narrated returns the episodes that produced an audio file, in the order
+// they were processed
+func (f *FeedState) Narrated() []*Episode {
+	var narrated []*Episode
+	for _, episode := range f.Episodes {
+		if episode.File != "" {
+			narrated = append(narrated, episode)
+		}
+	}
+	return narrated
+}
+
+// stateFile is the layout of the state file
+type stateFile struct {
+	Version int                   `json:"version"`
+	Feeds   map[string]*FeedState `json:"feeds"`
+}
+
+// State remembers the processed items of every narrated feed, keyed by
+// feed URL, so that later runs only narrate new items
+type State struct {
+	path string
+	file stateFile
+}
+
+// DefaultStatePath returns ~/.assistant-cli/feeds.json
+func DefaultStatePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".assistant-cli", "feeds.json"), nil
+}
+
+// LoadState reads the state file at path. A missing file is an empty state.
+func LoadState(path string) (*State, error) {
+	s := &State{path: path, file: stateFile{Version: stateVersion, Feeds: make(map[string]*FeedState)}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read feed state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.file); err != nil {
+		return nil, fmt.Errorf("failed to parse feed state %s: %w", path, err)
+	}
+	if s.file.Version != stateVersion {
+		return nil, fmt.Errorf("unsupported feed state version %d in %s", s.file.Version, path)
+	}
+	if s.file.Feeds == nil {
+		s.file.Feeds = make(map[string]*FeedState)
+	}
+	return s, nil
+}
+
+// Feed returns the history of the feed at url, creating it when needed
+func (s *State) Feed(url string) *FeedState {
+	f, ok := s.file.Feeds[url]
+	if !ok {
+		f = &FeedState{}
+		s.file.Feeds[url] = f
+	}
+	return f
+}
+
+// Path returns the location of the state file
+func (s *State) Path() string {
+	return s.path
+}
+
+// Save writes the state atomically, so that an interrupted run never
+// leaves a truncated file behind
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create feed state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(&s.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode feed state: %w", err)
+	}
+
+	if err := output.WriteFileAtomic(s.path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write feed state: %w", err)
+	}
+	return nil
+}
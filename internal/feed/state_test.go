@@ -0,0 +1,55 @@
+package feed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "feeds.json")
+
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	f := state.Feed("https://news.example.com/rss")
+	assert.False(t, f.Seen("a"))
+
+	f.Title = "Daily News"
+	f.Episodes = append(f.Episodes,
+		&Episode{ID: "a", Title: "First", File: "001_First.mp3", Size: 10, Processed: time.Now()},
+		&Episode{ID: "b", Title: "Empty", Processed: time.Now()},
+	)
+	require.NoError(t, state.Save())
+
+	reloaded, err := LoadState(path)
+	require.NoError(t, err)
+	f = reloaded.Feed("https://news.example.com/rss")
+	assert.Equal(t, "Daily News", f.Title)
+	assert.True(t, f.Seen("a"))
+	assert.True(t, f.Seen("b"))
+	assert.False(t, f.Seen("c"))
+
+	narrated := f.Narrated()
+	require.Len(t, narrated, 1)
+	assert.Equal(t, "001_First.mp3", narrated[0].File)
+
+	assert.Empty(t, reloaded.Feed("https://other.example.com/rss").Episodes)
+}
+
+func TestLoadStateErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{"), 0600))
+	_, err := LoadState(corrupt)
+	assert.ErrorContains(t, err, "failed to parse feed state")
+
+	future := filepath.Join(dir, "future.json")
+	require.NoError(t, os.WriteFile(future, []byte(`{"version": 99, "feeds": {}}`), 0600))
+	_, err = LoadState(future)
+	assert.ErrorContains(t, err, "unsupported feed state version 99")
+}
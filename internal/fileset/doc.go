@@ -0,0 +1,6 @@
+// Package fileset selects the input files of a run over many files.
+// It expands glob patterns, walks directories with include and exclude
+// filters, maps each input to a path in an output tree that mirrors the
+// source directories, and tells which outputs are already newer than their
+// sources, so that a run only redoes the files that changed.
+package fileset
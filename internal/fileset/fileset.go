@@ -0,0 +1,217 @@
+package fileset
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File is an input file
+type File struct {
+	// Path is the file as found, joined to the argument it was found under
+	Path string
+	// Rel is the slash-separated path of the file below the directory it was
+	// found in, or its base name when it was named directly
+	Rel string
+}
+
+// Filter selects the files found in directories. A pattern without a slash
+// matches the base name of a file, as '*.md' does; one with a slash matches
+// its path below the directory, where '**' matches any number of
+// directories, as in 'drafts/**'. Files named directly, or by a glob
+// argument, are not filtered.
+type Filter struct {
+	// Include are the patterns of the files to take, all files when empty
+	Include []string
+	// Exclude are the patterns of the files to skip, taking precedence
+	Exclude []string
+}
+
+// Validate reports the first malformed pattern
+func (f Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Selects reports whether the filter takes the file at rel
+func (f Filter) Selects(rel string) bool {
+	if matchAny(f.Exclude, rel) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, rel)
+}
+
+// Collect returns the files of args, which are files, directories searched
+// recursively, or glob patterns, in the order of their Rel paths. Two inputs
+// with the same Rel path are rejected, as their outputs would overwrite each
+// other; a file found more than once is returned once.
+func Collect(args []string, filter Filter) ([]File, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]string)
+	var files []File
+	add := func(file File) error {
+		abs, err := filepath.Abs(file.Path)
+		if err != nil {
+			return err
+		}
+		if previous, ok := seen[file.Rel]; ok {
+			if previous == abs {
+				return nil
+			}
+			return fmt.Errorf("%s and %s would both be written as %s", previous, abs, file.Rel)
+		}
+		seen[file.Rel] = abs
+		files = append(files, file)
+		return nil
+	}
+
+	for _, arg := range args {
+		matches := []string{arg}
+		if hasMeta(arg) {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+		}
+		for _, match := range matches {
+			found, err := walk(match, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range found {
+				if err := add(file); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Rel < files[j].Rel })
+	return files, nil
+}
+
+// walk returns root when it is a file, and the files below it that filter
+// selects when it is a directory
+func walk(root string, filter Filter) ([]File, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []File{{Path: root, Rel: filepath.Base(root)}}, nil
+	}
+
+	var files []File
+	err = filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			// Hidden directories such as .git hold no input
+			if name != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if filter.Selects(rel) {
+			files = append(files, File{Path: name, Rel: rel})
+		}
+		return nil
+	})
+	return files, err
+}
+
+// OutputPath returns the path of the output of file in dir, keeping the
+// directories of its Rel path and replacing its extension with ext
+func OutputPath(dir string, file File, ext string) string {
+	rel := strings.TrimSuffix(file.Rel, path.Ext(file.Rel))
+	return filepath.Join(dir, filepath.FromSlash(rel)+"."+strings.TrimPrefix(ext, "."))
+}
+
+// UpToDate reports whether output exists and was modified after source, in
+// which case a run can skip it as make does
+func UpToDate(source, output string) (bool, error) {
+	outputInfo, err := os.Stat(output)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return false, err
+	}
+	return outputInfo.ModTime().After(sourceInfo.ModTime()), nil
+}
+
+// matchAny reports whether one of patterns matches rel
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// match matches rel against pattern as Filter describes
+func match(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches the directories and name of a path against those of
+// a pattern, a '**' segment matching any number of them
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// hasMeta reports whether arg is a glob pattern
+func hasMeta(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
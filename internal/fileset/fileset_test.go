@@ -0,0 +1,129 @@
+package fileset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates the files at the slash-separated paths below dir
+func writeTree(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, name := range paths {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte("text"), 0o644))
+	}
+}
+
+func rels(files []File) []string {
+	result := make([]string, len(files))
+	for i, file := range files {
+		result[i] = file.Rel
+	}
+	return result
+}
+
+func TestFilterSelects(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		rel    string
+		want   bool
+	}{
+		{"no patterns", Filter{}, "a/b.txt", true},
+		{"base name include", Filter{Include: []string{"*.md"}}, "guide/intro.md", true},
+		{"base name include miss", Filter{Include: []string{"*.md"}}, "guide/intro.txt", false},
+		{"directory exclude", Filter{Exclude: []string{"drafts/**"}}, "drafts/old/a.md", false},
+		{"directory exclude is anchored", Filter{Exclude: []string{"drafts/**"}}, "book/drafts/a.md", true},
+		{"double star in the middle", Filter{Include: []string{"book/**/*.md"}}, "book/a.md", true},
+		{"double star deeper", Filter{Include: []string{"book/**/*.md"}}, "book/part1/ch1/a.md", true},
+		{"leading dot slash", Filter{Include: []string{"./book/*.md"}}, "book/a.md", true},
+		{"exclude wins", Filter{Include: []string{"*.md"}, Exclude: []string{"README.md"}}, "README.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Selects(tt.rel))
+		})
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	assert.NoError(t, Filter{Include: []string{"**/*.md"}, Exclude: []string{"drafts/**"}}.Validate())
+	assert.Error(t, Filter{Exclude: []string{"[a-"}}.Validate())
+}
+
+func TestCollect_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "intro.md", "part1/ch1.md", "part1/notes.txt", "drafts/ch9.md", ".git/HEAD.md")
+
+	files, err := Collect([]string{dir}, Filter{Include: []string{"*.md"}, Exclude: []string{"drafts/**"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"intro.md", "part1/ch1.md"}, rels(files))
+	assert.Equal(t, filepath.Join(dir, "part1", "ch1.md"), files[1].Path)
+}
+
+func TestCollect_GlobAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt", "b.txt", "c.md", "sub/d.txt")
+
+	// Files matched by a glob argument are not filtered
+	files, err := Collect([]string{filepath.Join(dir, "*.txt"), filepath.Join(dir, "c.md")},
+		Filter{Include: []string{"*.md"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "b.txt", "c.md"}, rels(files))
+
+	_, err = Collect([]string{filepath.Join(dir, "*.pdf")}, Filter{})
+	assert.ErrorContains(t, err, "no files match")
+
+	_, err = Collect([]string{filepath.Join(dir, "missing.txt")}, Filter{})
+	assert.Error(t, err)
+}
+
+func TestCollect_Conflicts(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "one/a.txt", "two/a.txt")
+
+	// The same file found twice is taken once
+	files, err := Collect([]string{filepath.Join(dir, "one"), filepath.Join(dir, "one", "a.txt")}, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	_, err = Collect([]string{filepath.Join(dir, "one"), filepath.Join(dir, "two")}, Filter{})
+	assert.ErrorContains(t, err, "would both be written as a.txt")
+}
+
+func TestOutputPath(t *testing.T) {
+	file := File{Rel: "part1/ch1.md"}
+	assert.Equal(t, filepath.Join("out", "part1", "ch1.mp3"), OutputPath("out", file, "mp3"))
+	assert.Equal(t, filepath.Join("out", "notes.wav"), OutputPath("out", File{Rel: "notes"}, ".wav"))
+}
+
+func TestUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "ch1.md")
+	output := filepath.Join(dir, "ch1.mp3")
+	writeTree(t, dir, "ch1.md")
+
+	upToDate, err := UpToDate(source, output)
+	require.NoError(t, err)
+	assert.False(t, upToDate, "missing output")
+
+	writeTree(t, dir, "ch1.mp3")
+	now := time.Now()
+	require.NoError(t, os.Chtimes(source, now, now.Add(-time.Hour)))
+	require.NoError(t, os.Chtimes(output, now, now))
+	upToDate, err = UpToDate(source, output)
+	require.NoError(t, err)
+	assert.True(t, upToDate, "output newer than source")
+
+	require.NoError(t, os.Chtimes(source, now, now.Add(time.Hour)))
+	upToDate, err = UpToDate(source, output)
+	require.NoError(t, err)
+	assert.False(t, upToDate, "source edited after output")
+}
@@ -0,0 +1,5 @@
+// Package longtext synthesizes texts too long for one request. It splits
+// them into request-sized pieces, plain text at paragraph, sentence, or word
+// boundaries and SSML between elements, and synthesizes the pieces a bounded
+// number at a time, returning their audio in order.
+package longtext
@@ -0,0 +1,110 @@
+package longtext
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+)
+
+// ChunkSize is the largest piece of a long text sent in one request, safely
+// below the API's 5000 byte limit
+const ChunkSize = 4500
+
+// Piece is a piece of a long text with the request settings it is
+// synthesized with
+type Piece struct {
+	Text    string
+	Request *tts.SynthesizeRequest
+}
+
+// IsSSML reports whether text is an SSML document, which Split splits
+// between elements
+func IsSSML(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "<speak")
+}
+
+// Split splits text into pieces of at most size bytes. SSML is split
+// between elements into complete documents, and fails when it cannot be
+// parsed rather than being split, and read, as plain text.
+func Split(text string, size int) ([]string, error) {
+	processor := utils.NewInputProcessor(nil)
+	if IsSSML(text) {
+		chunks, err := processor.SplitSSML(strings.TrimSpace(text), size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSML: %w", err)
+		}
+		return chunks, nil
+	}
+	return processor.SplitByLength(text, size), nil
+}
+
+// result is the outcome of synthesizing one piece
+type result struct {
+	index int
+	resp  *tts.SynthesizeResponse
+	err   error
+}
+
+// Synthesize synthesizes pieces with at most concurrency requests in flight
+// and returns their responses in the order of pieces, however the requests
+// finish. done, when not nil, is called with each response as it arrives,
+// from the calling goroutine. The first failure cancels the pieces still
+// running.
+func Synthesize(ctx context.Context, synthesizer *tts.Synthesizer, pieces []Piece, concurrency int,
+	done func(index int, resp *tts.SynthesizeResponse)) ([]*tts.SynthesizeResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered for every piece, so no worker blocks after a failure returns
+	results := make(chan result, len(pieces))
+	slots := make(chan struct{}, max(concurrency, 1))
+	go func() {
+		for i, piece := range pieces {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int, piece Piece) {
+				defer func() { <-slots }()
+				// Synthesize stores the text in the request, so each piece
+				// needs its own
+				req := *piece.Request
+				resp, err := synthesizer.SynthesizeText(ctx, piece.Text, &req)
+				results <- result{index: i, resp: resp, err: err}
+			}(i, piece)
+		}
+	}()
+
+	responses := make([]*tts.SynthesizeResponse, len(pieces))
+	for range pieces {
+		r := <-results
+		if r.err != nil {
+			if len(pieces) > 1 {
+				return nil, fmt.Errorf("piece %d of %d: %w", r.index+1, len(pieces), r.err)
+			}
+			return nil, r.err
+		}
+		responses[r.index] = r.resp
+		if r.resp.Retries > 0 {
+			slog.Debug("retried piece", "piece", r.index+1, "retries", r.resp.Retries)
+		}
+		if done != nil {
+			done(r.index, r.resp)
+		}
+	}
+	return responses, nil
+}
+
+// Audio returns the audio of responses, in order
+func Audio(responses []*tts.SynthesizeResponse) [][]byte {
+	parts := make([][]byte, len(responses))
+	for i, resp := range responses {
+		parts[i] = resp.AudioData
+	}
+	return parts
+}
@@ -0,0 +1,134 @@
+package longtext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoClient returns the text as audio, finishing earlier pieces last so
+// that concurrent requests complete out of order
+type echoClient struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	fail     string
+}
+
+func (c *echoClient) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audio *texttospeechpb.AudioConfig) ([]byte, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	if text == c.fail {
+		return nil, errors.New("unavailable")
+	}
+	select {
+	case <-time.After(time.Duration(10-int(text[len(text)-1]-'0')) * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []byte(text), nil
+}
+
+func (c *echoClient) ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
+	return nil, nil
+}
+
+func (c *echoClient) Close() error {
+	return nil
+}
+
+func TestSynthesize(t *testing.T) {
+	req := &tts.SynthesizeRequest{SpeakingRate: 1.0, AudioFormat: "MP3"}
+	pieces := make([]Piece, 8)
+	for i := range pieces {
+		pieces[i] = Piece{Text: fmt.Sprintf("piece %d", i), Request: req}
+	}
+
+	for _, concurrency := range []int{1, 3, 16} {
+		client := &echoClient{}
+		var done []int
+		responses, err := Synthesize(context.Background(), tts.NewSynthesizer(client), pieces, concurrency,
+			func(index int, resp *tts.SynthesizeResponse) { done = append(done, index) })
+		require.NoError(t, err, concurrency)
+
+		parts := Audio(responses)
+		require.Len(t, parts, len(pieces))
+		for i, part := range parts {
+			assert.Equal(t, pieces[i].Text, string(part), concurrency)
+		}
+		assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, done)
+		assert.LessOrEqual(t, client.peak, concurrency)
+		assert.Empty(t, req.Text, "the shared request is not modified")
+	}
+}
+
+func TestSynthesize_Failure(t *testing.T) {
+	req := &tts.SynthesizeRequest{SpeakingRate: 1.0, AudioFormat: "MP3"}
+	pieces := make([]Piece, 8)
+	for i := range pieces {
+		pieces[i] = Piece{Text: fmt.Sprintf("piece %d", i), Request: req}
+	}
+
+	client := &echoClient{fail: "piece 5"}
+	_, err := Synthesize(context.Background(), tts.NewSynthesizer(client), pieces, 4, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "piece 6 of 8")
+
+	// A single piece fails with the error of its request
+	_, err = Synthesize(context.Background(), tts.NewSynthesizer(client), pieces[5:6], 1, nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "piece")
+}
+
+func TestSplit_SSML(t *testing.T) {
+	paragraph := "<p>" + strings.Repeat("Words to read aloud. ", 50) + "</p>"
+	ssml := "<speak>" + strings.Repeat(paragraph, 10) + "</speak>"
+
+	chunks, err := Split("\n"+ssml+"\n", ChunkSize)
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 1)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), ChunkSize)
+		assert.True(t, strings.HasPrefix(chunk, "<speak><p>"), chunk)
+		assert.True(t, strings.HasSuffix(chunk, "</p></speak>"), chunk)
+	}
+}
+
+func TestSplit_InvalidSSML(t *testing.T) {
+	// Malformed SSML is not sent as plain text, markup and all
+	ssml := "<speak>" + strings.Repeat("<p>Words to read aloud.</p>", 200) + "<p>Unclosed</speak>"
+	_, err := Split(ssml, ChunkSize)
+	assert.ErrorContains(t, err, "invalid SSML")
+}
+
+func TestSplit_PlainText(t *testing.T) {
+	chunks, err := Split("Short text.", ChunkSize)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Short text."}, chunks)
+
+	text := strings.Repeat("This sentence is part of a long chapter. ", 300)
+	chunks, err = Split(text, ChunkSize)
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 1)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), ChunkSize)
+	}
+}
@@ -0,0 +1,437 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultKeepAlive is the keep-alive interval used when Options leaves it 0
+const DefaultKeepAlive = 60 * time.Second
+
+// messageBuffer is the number of received messages waiting to be read from
+// Messages, beyond which messages are dropped
+const messageBuffer = 64
+
+// ErrClosed reports use of a closed client
+var ErrClosed = errors.New("mqtt: connection closed")
+
+// ErrRefused reports a connection the broker refused, e.g. for bad
+// credentials; retrying does not help
+var ErrRefused = errors.New("broker refused the connection")
+
+// connackErrors are the reasons a broker refuses a connection
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Options configure a connection to a broker
+type Options struct {
+	// Broker is the URL of the broker: mqtt:// or tcp:// (port 1883 by
+	// default), or mqtts://, ssl:// or tls:// (port 8883 by default)
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	// TLSConfig is used for TLS connections; nil uses the system roots
+	TLSConfig *tls.Config
+	// KeepAlive is the longest time without traffic before a ping
+	KeepAlive time.Duration
+	// Logf, when set, is told about messages dropped because Messages was
+	// not read fast enough
+	Logf func(format string, args ...interface{})
+}
+
+// Message is a message received on a subscribed topic
+type Message struct {
+	Topic    string
+	Payload  []byte
+	Retained bool
+}
+
+// Client is a connection to a broker. Its methods are safe for concurrent
+// use.
+type Client struct {
+	conn      net.Conn
+	keepAlive time.Duration
+	messages  chan Message
+	logf      func(format string, args ...interface{})
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint16
+	pending map[uint16]chan *packet
+	err     error
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the broker and completes the MQTT handshake
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	address, useTLS, err := brokerAddress(opts.Broker)
+	if err != nil {
+		return nil, err
+	}
+	keepAlive := opts.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = DefaultKeepAlive
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", opts.Broker, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if useTLS {
+		config := opts.TLSConfig
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config = config.Clone()
+			config.ServerName, _, _ = net.SplitHostPort(address)
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("TLS handshake with %s failed: %w", opts.Broker, err)
+		}
+		conn = tlsConn
+	}
+
+	reader := bufio.NewReader(conn)
+	if err := handshake(conn, reader, opts, keepAlive); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	c := &Client{
+		conn:      conn,
+		keepAlive: keepAlive,
+		messages:  make(chan Message, messageBuffer),
+		logf:      opts.Logf,
+		pending:   make(map[uint16]chan *packet),
+		done:      make(chan struct{}),
+	}
+	received := make(chan struct{}, 1)
+	go c.readLoop(reader, received)
+	go c.pingLoop(received)
+	return c, nil
+}
+
+// brokerAddress returns the host and port of a broker URL and whether it
+// uses TLS
+func brokerAddress(broker string) (string, bool, error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("invalid broker URL %q: want mqtt://host:port or mqtts://host:port", broker)
+	}
+	var useTLS bool
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTLS, port = true, "8883"
+	default:
+		return "", false, fmt.Errorf("unsupported broker scheme %q: use mqtt:// or mqtts://", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+// handshake sends CONNECT and waits for the broker to accept it
+func handshake(conn net.Conn, reader *bufio.Reader, opts Options, keepAlive time.Duration) error {
+	var flags byte = 0x02 // clean session
+	if opts.Username != "" {
+		flags |= 0x80
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(min(keepAlive/time.Second, 0xffff)))
+	body = appendString(body, opts.ClientID)
+	if opts.Username != "" {
+		body = appendString(body, opts.Username)
+	}
+	if opts.Password != "" {
+		body = appendString(body, opts.Password)
+	}
+	connect := &packet{kind: packetConnect, body: body}
+	if _, err := conn.Write(connect.encode()); err != nil {
+		return fmt.Errorf("failed to send CONNECT: %w", err)
+	}
+
+	ack, err := readPacket(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read CONNACK: %w", err)
+	}
+	if ack.kind != packetConnack || len(ack.body) != 2 {
+		return fmt.Errorf("broker answered CONNECT with packet type %d", ack.kind)
+	}
+	if code := ack.body[1]; code != 0 {
+		reason, ok := connackErrors[code]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", code)
+		}
+		return fmt.Errorf("%w: %s", ErrRefused, reason)
+	}
+	return nil
+}
+
+// Messages returns the messages of the subscribed topics. It is closed when
+// the connection ends.
+func (c *Client) Messages() <-chan Message {
+	return c.messages
+}
+
+// Done is closed when the connection ends
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, once Done is closed
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Subscribe subscribes to the topic filter at qos 0 or 1
+func (c *Client) Subscribe(ctx context.Context, filter string, qos byte) error {
+	if qos > 1 {
+		return fmt.Errorf("unsupported QoS %d: use 0 or 1", qos)
+	}
+	id, ack := c.track()
+	defer c.untrack(id)
+
+	body := binary.BigEndian.AppendUint16(nil, id)
+	body = appendString(body, filter)
+	body = append(body, qos)
+	if err := c.write(&packet{kind: packetSubscribe, flags: 0x02, body: body}); err != nil {
+		return err
+	}
+
+	resp, err := c.wait(ctx, ack)
+	if err != nil {
+		return err
+	}
+	if resp.kind != packetSuback || len(resp.body) < 3 || resp.body[2] == 0x80 {
+		return fmt.Errorf("broker refused the subscription to %s", filter)
+	}
+	return nil
+}
+
+// Publish sends payload to topic at qos 0 or 1. At QoS 1 it returns once
+// the broker acknowledged the message.
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte, qos byte, retain bool) error {
+	if qos > 1 {
+		return fmt.Errorf("unsupported QoS %d: use 0 or 1", qos)
+	}
+	flags := qos << 1
+	if retain {
+		flags |= 0x01
+	}
+	body := appendString(nil, topic)
+	if qos == 0 {
+		return c.write(&packet{kind: packetPublish, flags: flags, body: append(body, payload...)})
+	}
+
+	id, ack := c.track()
+	defer c.untrack(id)
+	body = binary.BigEndian.AppendUint16(body, id)
+	if err := c.write(&packet{kind: packetPublish, flags: flags, body: append(body, payload...)}); err != nil {
+		return err
+	}
+	_, err := c.wait(ctx, ack)
+	return err
+}
+
+// Close disconnects from the broker
+func (c *Client) Close() error {
+	_ = c.write(&packet{kind: packetDisconnect})
+	c.close(ErrClosed)
+	return nil
+}
+
+// track reserves a packet identifier and returns the channel its
+// acknowledgement is delivered to
+func (c *Client) track() (uint16, chan *packet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		c.nextID++
+		if _, used := c.pending[c.nextID]; c.nextID != 0 && !used {
+			break
+		}
+	}
+	ack := make(chan *packet, 1)
+	c.pending[c.nextID] = ack
+	return c.nextID, ack
+}
+
+func (c *Client) untrack(id uint16) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// wait waits for an acknowledgement
+func (c *Client) wait(ctx context.Context, ack chan *packet) (*packet, error) {
+	select {
+	case resp := <-ack:
+		return resp, nil
+	case <-c.done:
+		return nil, c.Err()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// write sends a packet
+func (c *Client) write(p *packet) error {
+	select {
+	case <-c.done:
+		return c.Err()
+	default:
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.keepAlive))
+	if _, err := c.conn.Write(p.encode()); err != nil {
+		c.close(fmt.Errorf("mqtt: connection lost: %w", err))
+		return c.Err()
+	}
+	return nil
+}
+
+// readLoop dispatches the packets of the broker until the connection ends
+func (c *Client) readLoop(reader *bufio.Reader, received chan<- struct{}) {
+	defer close(c.messages)
+	for {
+		p, err := readPacket(reader)
+		if err != nil {
+			c.close(fmt.Errorf("mqtt: connection lost: %w", err))
+			return
+		}
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+
+		switch p.kind {
+		case packetPublish:
+			if err := c.deliver(p); err != nil {
+				c.close(err)
+				return
+			}
+		case packetPuback, packetSuback:
+			id, _, err := readID(p.body)
+			if err != nil {
+				c.close(err)
+				return
+			}
+			c.mu.Lock()
+			ack := c.pending[id]
+			c.mu.Unlock()
+			// A repeated acknowledgement finds the channel full and is
+			// dropped rather than stopping the loop
+			select {
+			case ack <- p:
+			default:
+			}
+		case packetPingresp:
+		default:
+			c.close(fmt.Errorf("mqtt: unexpected packet type %d", p.kind))
+			return
+		}
+	}
+}
+
+// deliver passes a received message on, acknowledging it at QoS 1. It
+// never waits for Messages to be read, so that a slow reader does not stop
+// acknowledgements and pings; when the buffer is full the message is
+// dropped.
+func (c *Client) deliver(p *packet) error {
+	qos := (p.flags >> 1) & 0x03
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return err
+	}
+	var id uint16
+	if qos > 0 {
+		if id, rest, err = readID(rest); err != nil {
+			return err
+		}
+	}
+	if qos > 1 {
+		return fmt.Errorf("mqtt: broker sent a QoS %d message to a QoS 1 subscription", qos)
+	}
+
+	select {
+	case c.messages <- Message{Topic: topic, Payload: rest, Retained: p.flags&0x01 != 0}:
+	default:
+		if c.logf != nil {
+			c.logf("mqtt: message on %s dropped: %d messages are waiting to be read", topic, len(c.messages))
+		}
+	}
+	// Dropped messages are acknowledged too, since a broker holds back
+	// further messages while too many are unacknowledged
+	if qos == 1 {
+		return c.write(&packet{kind: packetPuback, body: binary.BigEndian.AppendUint16(nil, id)})
+	}
+	return nil
+}
+
+// pingLoop pings the broker within the keep-alive interval and ends the
+// connection when the broker stops answering
+func (c *Client) pingLoop(received <-chan struct{}) {
+	ticker := time.NewTicker(c.keepAlive * 3 / 4)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-received:
+			last = time.Now()
+		case <-ticker.C:
+			if time.Since(last) > c.keepAlive*3/2 {
+				c.close(errors.New("mqtt: broker stopped responding"))
+				return
+			}
+			_ = c.write(&packet{kind: packetPingreq})
+		}
+	}
+}
+
+// close ends the connection for err
+func (c *Client) close(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
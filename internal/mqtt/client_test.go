@@ -0,0 +1,238 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBroker accepts one connection and hands its packets to the test
+type testBroker struct {
+	listener net.Listener
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	return &testBroker{listener: listener}
+}
+
+func (b *testBroker) url() string {
+	return "mqtt://" + b.listener.Addr().String()
+}
+
+// accept reads the CONNECT packet and answers it with code
+func (b *testBroker) accept(t *testing.T, code byte) *packet {
+	t.Helper()
+	conn, err := b.listener.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	b.conn, b.reader = conn, bufio.NewReader(conn)
+
+	connect := b.read(t)
+	require.Equal(t, packetConnect, connect.kind)
+	b.send(t, &packet{kind: packetConnack, body: []byte{0, code}})
+	return connect
+}
+
+func (b *testBroker) read(t *testing.T) *packet {
+	t.Helper()
+	p, err := readPacket(b.reader)
+	require.NoError(t, err)
+	return p
+}
+
+func (b *testBroker) send(t *testing.T, p *packet) {
+	t.Helper()
+	_, err := b.conn.Write(p.encode())
+	require.NoError(t, err)
+}
+
+func TestClientSubscribeAndPublish(t *testing.T) {
+	broker := newTestBroker(t)
+	connected := make(chan *packet, 1)
+	go func() { connected <- broker.accept(t, 0) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := Dial(ctx, Options{Broker: broker.url(), ClientID: "speaker", Username: "home", Password: "secret"})
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	connect := <-connected
+	name, rest, err := readString(connect.body)
+	require.NoError(t, err)
+	assert.Equal(t, "MQTT", name)
+	assert.Equal(t, byte(0xc2), rest[1], "clean session with user name and password")
+	id, rest, err := readString(rest[4:])
+	require.NoError(t, err)
+	assert.Equal(t, "speaker", id)
+	user, rest, _ := readString(rest)
+	password, _, _ := readString(rest)
+	assert.Equal(t, []string{"home", "secret"}, []string{user, password})
+
+	// Subscribe
+	subscribed := make(chan error, 1)
+	go func() { subscribed <- client.Subscribe(ctx, "home/announce", 1) }()
+	subscribe := broker.read(t)
+	assert.Equal(t, packetSubscribe, subscribe.kind)
+	assert.Equal(t, byte(0x02), subscribe.flags)
+	filter, rest, err := readString(subscribe.body[2:])
+	require.NoError(t, err)
+	assert.Equal(t, "home/announce", filter)
+	assert.Equal(t, []byte{1}, rest)
+	broker.send(t, &packet{kind: packetSuback, body: append(append([]byte{}, subscribe.body[:2]...), 1)})
+	require.NoError(t, <-subscribed)
+
+	// A QoS 1 message is delivered and acknowledged
+	body := appendString(nil, "home/announce")
+	body = binary.BigEndian.AppendUint16(body, 7)
+	broker.send(t, &packet{kind: packetPublish, flags: 0x02, body: append(body, "Dinner is ready"...)})
+	message := <-client.Messages()
+	assert.Equal(t, Message{Topic: "home/announce", Payload: []byte("Dinner is ready")}, message)
+	puback := broker.read(t)
+	assert.Equal(t, packetPuback, puback.kind)
+	assert.Equal(t, []byte{0, 7}, puback.body)
+
+	// Publish waits for the acknowledgement
+	published := make(chan error, 1)
+	go func() { published <- client.Publish(ctx, "home/announce/result", []byte(`{"file":"a.mp3"}`), 1, false) }()
+	publish := broker.read(t)
+	assert.Equal(t, packetPublish, publish.kind)
+	topic, rest, err := readString(publish.body)
+	require.NoError(t, err)
+	assert.Equal(t, "home/announce/result", topic)
+	assert.Equal(t, `{"file":"a.mp3"}`, string(rest[2:]))
+	broker.send(t, &packet{kind: packetPuback, body: rest[:2]})
+	require.NoError(t, <-published)
+
+	// The connection ends when the broker goes away
+	_ = broker.conn.Close()
+	<-client.Done()
+	assert.ErrorContains(t, client.Err(), "connection lost")
+	_, open := <-client.Messages()
+	assert.False(t, open)
+}
+
+// dialTestBroker connects a client with opts to a new test broker
+func dialTestBroker(t *testing.T, opts Options) (*Client, *testBroker) {
+	t.Helper()
+	broker := newTestBroker(t)
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		broker.accept(t, 0)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts.Broker = broker.url()
+	client, err := Dial(ctx, opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	<-accepted
+	return client, broker
+}
+
+func TestClientDropsMessagesWhenFull(t *testing.T) {
+	dropped := make(chan string, 1)
+	client, broker := dialTestBroker(t, Options{ClientID: "speaker", Logf: func(format string, args ...interface{}) {
+		dropped <- fmt.Sprintf(format, args...)
+	}})
+
+	// Nothing reads Messages, yet every message is acknowledged
+	for id := uint16(1); id <= messageBuffer+1; id++ {
+		body := appendString(nil, "home/announce")
+		body = binary.BigEndian.AppendUint16(body, id)
+		broker.send(t, &packet{kind: packetPublish, flags: 0x02, body: append(body, fmt.Sprint(id)...)})
+		puback := broker.read(t)
+		assert.Equal(t, packetPuback, puback.kind)
+		assert.Equal(t, id, binary.BigEndian.Uint16(puback.body))
+	}
+	assert.Contains(t, <-dropped, "message on home/announce dropped")
+
+	// The messages that fit are kept in order
+	for i := 1; i <= messageBuffer; i++ {
+		assert.Equal(t, fmt.Sprint(i), string((<-client.Messages()).Payload))
+	}
+	select {
+	case <-client.Done():
+		t.Fatalf("connection ended: %v", client.Err())
+	default:
+	}
+}
+
+func TestClientIgnoresRepeatedAck(t *testing.T) {
+	client, broker := dialTestBroker(t, Options{ClientID: "speaker"})
+
+	// Nobody takes the acknowledgements of the tracked identifier
+	id, _ := client.track()
+	puback := &packet{kind: packetPuback, body: binary.BigEndian.AppendUint16(nil, id)}
+	broker.send(t, puback)
+	broker.send(t, puback)
+
+	// The loop goes on reading
+	broker.send(t, &packet{kind: packetPublish, body: append(appendString(nil, "home/announce"), "Hello"...)})
+	select {
+	case message := <-client.Messages():
+		assert.Equal(t, "Hello", string(message.Payload))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the message after the repeated acknowledgement was not delivered")
+	}
+}
+
+func TestDialRefused(t *testing.T) {
+	broker := newTestBroker(t)
+	go broker.accept(t, 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := Dial(ctx, Options{Broker: broker.url(), ClientID: "speaker"})
+	assert.ErrorContains(t, err, "bad user name or password")
+}
+
+func TestBrokerAddress(t *testing.T) {
+	tests := []struct {
+		broker  string
+		address string
+		tls     bool
+	}{
+		{"mqtt://homeassistant.local", "homeassistant.local:1883", false},
+		{"tcp://10.0.0.2:1884", "10.0.0.2:1884", false},
+		{"mqtts://broker.example.com", "broker.example.com:8883", true},
+		{"ssl://[::1]:8884", "[::1]:8884", true},
+	}
+	for _, tt := range tests {
+		address, useTLS, err := brokerAddress(tt.broker)
+		require.NoError(t, err, tt.broker)
+		assert.Equal(t, tt.address, address)
+		assert.Equal(t, tt.tls, useTLS)
+	}
+
+	_, _, err := brokerAddress("ws://broker:9001")
+	assert.ErrorContains(t, err, "unsupported broker scheme")
+	_, _, err = brokerAddress("broker:1883")
+	assert.Error(t, err)
+}
+
+func TestPacketLength(t *testing.T) {
+	p := &packet{kind: packetPublish, body: make([]byte, 321)}
+	encoded := p.encode()
+	assert.Equal(t, []byte{0x30, 0xc1, 0x02}, encoded[:3])
+
+	decoded, err := readPacket(bufio.NewReader(bytes.NewReader(encoded)))
+	require.NoError(t, err)
+	assert.Len(t, decoded.body, 321)
+}
@@ -0,0 +1,4 @@
+// Package mqtt is a small MQTT 3.1.1 client, enough to subscribe to a topic
+// for announcements from home automation systems such as Home Assistant and
+// to publish results back, over TCP or TLS, at QoS 0 and 1.
+package mqtt
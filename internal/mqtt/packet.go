@@ -0,0 +1,114 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Packet types of MQTT 3.1.1
+const (
+	packetConnect    byte = 1
+	packetConnack    byte = 2
+	packetPublish    byte = 3
+	packetPuback     byte = 4
+	packetSubscribe  byte = 8
+	packetSuback     byte = 9
+	packetPingreq    byte = 12
+	packetPingresp   byte = 13
+	packetDisconnect byte = 14
+)
+
+// maxRemainingBytes is the most bytes the remaining length is encoded in
+const maxRemainingBytes = 4
+
+// maxPacketSize bounds the packets read, so that a misbehaving broker
+// cannot make the client allocate without limit
+const maxPacketSize = 16 << 20
+
+// packet is a control packet: its type, the flags of its fixed header, and
+// the rest of it
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// readPacket reads one control packet
+func readPacket(r *bufio.Reader) (*packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == maxRemainingBytes {
+			return nil, errors.New("malformed packet length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("packet of %d bytes exceeds the limit of %d", length, maxPacketSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &packet{kind: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+// encode returns the packet with its fixed header
+func (p *packet) encode() []byte {
+	buf := []byte{p.kind<<4 | p.flags}
+	length := len(p.body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if length == 0 {
+			break
+		}
+	}
+	return append(buf, p.body...)
+}
+
+// appendString appends s with its two-byte length
+func appendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// readString reads a string with its two-byte length from the start of buf
+// and returns the rest of buf
+func readString(buf []byte) (string, []byte, error) {
+	if len(buf) < 2 {
+		return "", nil, errors.New("malformed packet: truncated string")
+	}
+	n := int(binary.BigEndian.Uint16(buf))
+	if len(buf) < 2+n {
+		return "", nil, errors.New("malformed packet: truncated string")
+	}
+	return string(buf[2 : 2+n]), buf[2+n:], nil
+}
+
+// readID reads a packet identifier from the start of buf
+func readID(buf []byte) (uint16, []byte, error) {
+	if len(buf) < 2 {
+		return 0, nil, errors.New("malformed packet: missing packet identifier")
+	}
+	return binary.BigEndian.Uint16(buf), buf[2:], nil
+}
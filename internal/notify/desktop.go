@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Platform constants
+const (
+	platformDarwin  = "darwin"
+	platformLinux   = "linux"
+	platformWindows = "windows"
+)
+
+// desktopTimeout bounds the notification command, which returns as soon as
+// the notification is handed to the desktop
+const desktopTimeout = 5 * time.Second
+
+// powershellAppID is the application ID of PowerShell, which Windows shows
+// toasts for without registering an application of our own
+const powershellAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// Desktop shows native desktop notifications with the notification command
+// of the platform: osascript on macOS, notify-send on Linux, and a
+// PowerShell toast on Windows
+type Desktop struct {
+	platform string
+	run      func(ctx context.Context, name string, args ...string) error
+}
+
+// NewDesktop creates a notifier for the current platform
+func NewDesktop() *Desktop {
+	return &Desktop{platform: runtime.GOOS, run: runCommand}
+}
+
+// Notify shows a notification with title and message
+func (d *Desktop) Notify(ctx context.Context, title, message string) error {
+	name, args, err := desktopCommand(d.platform, title, message)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, desktopTimeout)
+	defer cancel()
+	if err := d.run(ctx, name, args...); err != nil {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+// desktopCommand returns the command showing a notification on platform
+func desktopCommand(platform, title, message string) (string, []string, error) {
+	switch platform {
+	case platformDarwin:
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message),
+			appleScriptString(title))
+		return "osascript", []string{"-e", script}, nil
+	case platformLinux:
+		return "notify-send", []string{"--app-name=assistant-cli", title, message}, nil
+	case platformWindows:
+		script := strings.Join([]string{
+			"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, " +
+				"ContentType = WindowsRuntime] > $null",
+			"$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent(" +
+				"[Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+			"$text = $template.GetElementsByTagName('text')",
+			"$text.Item(0).AppendChild($template.CreateTextNode(" + powershellString(title) + ")) > $null",
+			"$text.Item(1).AppendChild($template.CreateTextNode(" + powershellString(message) + ")) > $null",
+			"$toast = [Windows.UI.Notifications.ToastNotification]::new($template)",
+			"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(" +
+				powershellString(powershellAppID) + ").Show($toast)",
+		}, "; ")
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}, nil
+	default:
+		return "", nil, fmt.Errorf("desktop notifications are not supported on %s", platform)
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// powershellString quotes s as a PowerShell single-quoted string, in which
+// nothing but a doubled quote is special
+func powershellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// runCommand runs a command, including its output in the error
+func runCommand(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil && len(strings.TrimSpace(string(out))) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDesktopCommand(t *testing.T) {
+	name, args, err := desktopCommand(platformDarwin, `Book "A"`, `Wrote C:\audio`)
+	require.NoError(t, err)
+	assert.Equal(t, "osascript", name)
+	assert.Equal(t, []string{"-e", `display notification "Wrote C:\\audio" with title "Book \"A\""`}, args)
+
+	name, args, err = desktopCommand(platformLinux, "Done", "Wrote 3 files")
+	require.NoError(t, err)
+	assert.Equal(t, "notify-send", name)
+	assert.Equal(t, []string{"--app-name=assistant-cli", "Done", "Wrote 3 files"}, args)
+
+	name, args, err = desktopCommand(platformWindows, "Done", "It's ready")
+	require.NoError(t, err)
+	assert.Equal(t, "powershell", name)
+	require.Len(t, args, 4)
+	assert.Contains(t, args[3], "CreateTextNode('Done')")
+	assert.Contains(t, args[3], "CreateTextNode('It''s ready')")
+
+	_, _, err = desktopCommand("plan9", "Done", "ready")
+	assert.ErrorContains(t, err, "not supported on plan9")
+}
+
+func TestDesktopNotify(t *testing.T) {
+	var ran []string
+	desktop := &Desktop{platform: platformLinux, run: func(ctx context.Context, name string, args ...string) error {
+		ran = append([]string{name}, args...)
+		return nil
+	}}
+	require.NoError(t, desktop.Notify(context.Background(), "Done", "ready"))
+	assert.Equal(t, []string{"notify-send", "--app-name=assistant-cli", "Done", "ready"}, ran)
+
+	desktop.run = func(ctx context.Context, name string, args ...string) error {
+		return errors.New("exit status 1")
+	}
+	assert.ErrorContains(t, desktop.Notify(context.Background(), "Done", "ready"), "notify-send failed")
+}
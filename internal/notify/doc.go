@@ -0,0 +1,6 @@
+// Package notify reports the outcome of finished synthesis jobs: it posts
+// them to webhooks, so home automation and CI systems learn about new audio
+// without polling, and shows native desktop notifications for interactive
+// runs. It also picks the lines of a watched command's output to speak as
+// alerts.
+package notify
@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+)
+
+// Verdict says what became of a line checked by a Watcher
+type Verdict int
+
+const (
+	// Ignored lines match no pattern
+	Ignored Verdict = iota
+	// Alert lines are to be spoken
+	Alert
+	// Duplicate lines repeat an alert spoken within the dedup window
+	Duplicate
+	// RateLimited lines came after the per-minute limit was reached
+	RateLimited
+)
+
+// ansiEscape matches the terminal color and cursor sequences of colored
+// command output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// Watcher picks the lines of a command's output to speak: those matching
+// one of its patterns, at most rateLimit a minute, and each alert once
+// within the dedup window. It is not safe for concurrent use.
+type Watcher struct {
+	patterns    []*regexp.Regexp
+	rateLimit   int
+	dedupWindow time.Duration
+	clock       clock.Clock
+
+	// spoken holds when the alerts of the last minute were spoken
+	spoken []time.Time
+	// recent holds when each alert was last spoken
+	recent map[string]time.Time
+}
+
+// NewWatcher creates a watcher for lines matching any of patterns. A
+// rateLimit of 0 speaks any number of alerts a minute, and a dedupWindow of
+// 0 speaks every repetition.
+func NewWatcher(patterns []string, rateLimit int, dedupWindow time.Duration) (*Watcher, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no patterns to watch for")
+	}
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return &Watcher{
+		patterns:    compiled,
+		rateLimit:   rateLimit,
+		dedupWindow: dedupWindow,
+		clock:       clock.Real,
+		recent:      make(map[string]time.Time),
+	}, nil
+}
+
+// SetClock makes the watcher measure time with c
+func (w *Watcher) SetClock(c clock.Clock) {
+	w.clock = clock.OrReal(c)
+}
+
+// Check returns the alert to speak for line and the verdict on it. The
+// alert is the groups of the first matching pattern joined by spaces, or
+// the whole line when it has none; it is returned for duplicates and rate
+// limited lines too, for reporting.
+func (w *Watcher) Check(line string) (string, Verdict) {
+	line = strings.TrimSpace(ansiEscape.ReplaceAllString(line, ""))
+	message, ok := w.match(line)
+	if !ok {
+		return "", Ignored
+	}
+
+	now := w.clock.Now()
+	key := strings.ToLower(message)
+	if last, ok := w.recent[key]; ok && w.dedupWindow > 0 && now.Sub(last) < w.dedupWindow {
+		return message, Duplicate
+	}
+
+	if w.rateLimit > 0 {
+		cutoff := now.Add(-time.Minute)
+		kept := w.spoken[:0]
+		for _, at := range w.spoken {
+			if at.After(cutoff) {
+				kept = append(kept, at)
+			}
+		}
+		w.spoken = kept
+		if len(w.spoken) >= w.rateLimit {
+			return message, RateLimited
+		}
+		w.spoken = append(w.spoken, now)
+	}
+
+	w.recent[key] = now
+	w.forget(now)
+	return message, Alert
+}
+
+// match returns the alert of the first pattern matching line
+func (w *Watcher) match(line string) (string, bool) {
+	for _, re := range w.patterns {
+		groups := re.FindStringSubmatch(line)
+		if groups == nil {
+			continue
+		}
+		var parts []string
+		for _, group := range groups[1:] {
+			if group = strings.TrimSpace(group); group != "" {
+				parts = append(parts, group)
+			}
+		}
+		if len(parts) == 0 {
+			return strings.Join(strings.Fields(line), " "), line != ""
+		}
+		return strings.Join(parts, " "), true
+	}
+	return "", false
+}
+
+// forget drops alerts spoken before the dedup window, so watching a busy
+// command for days does not grow the watcher without bound
+func (w *Watcher) forget(now time.Time) {
+	for key, at := range w.recent {
+		if now.Sub(at) >= w.dedupWindow {
+			delete(w.recent, key)
+		}
+	}
+}
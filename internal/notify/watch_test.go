@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherMessages(t *testing.T) {
+	watcher, err := NewWatcher([]string{`Warning\s+(\w+)\s+.*?(pod/\S+)`, `(?i)disk\s+full`}, 0, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		line    string
+		message string
+		verdict Verdict
+	}{
+		{"5s  Warning  BackOff  pod/web-1  Back-off restarting", "BackOff pod/web-1", Alert},
+		{"\x1b[31mDISK   FULL\x1b[0m on /var  ", "DISK FULL on /var", Alert},
+		{"5s  Normal  Pulled  pod/web-1", "", Ignored},
+		{"", "", Ignored},
+	}
+	for _, tt := range tests {
+		message, verdict := watcher.Check(tt.line)
+		assert.Equal(t, tt.message, message, tt.line)
+		assert.Equal(t, tt.verdict, verdict, tt.line)
+	}
+}
+
+func TestWatcherDeduplicates(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	watcher, err := NewWatcher([]string{`error: (.*)`}, 0, time.Minute)
+	require.NoError(t, err)
+	watcher.SetClock(fake)
+
+	_, verdict := watcher.Check("error: disk full")
+	assert.Equal(t, Alert, verdict)
+	_, verdict = watcher.Check("error: Disk full")
+	assert.Equal(t, Duplicate, verdict)
+	_, verdict = watcher.Check("error: out of memory")
+	assert.Equal(t, Alert, verdict)
+
+	fake.Advance(time.Minute)
+	_, verdict = watcher.Check("error: disk full")
+	assert.Equal(t, Alert, verdict)
+}
+
+func TestWatcherRateLimit(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	watcher, err := NewWatcher([]string{`error`}, 2, 0)
+	require.NoError(t, err)
+	watcher.SetClock(fake)
+
+	for _, want := range []Verdict{Alert, Alert, RateLimited} {
+		_, verdict := watcher.Check("error")
+		assert.Equal(t, want, verdict)
+	}
+
+	fake.Advance(30 * time.Second)
+	_, verdict := watcher.Check("error")
+	assert.Equal(t, RateLimited, verdict)
+
+	fake.Advance(31 * time.Second)
+	_, verdict = watcher.Check("error")
+	assert.Equal(t, Alert, verdict)
+}
+
+func TestNewWatcherErrors(t *testing.T) {
+	_, err := NewWatcher(nil, 0, 0)
+	assert.ErrorContains(t, err, "no patterns")
+
+	_, err = NewWatcher([]string{`(unclosed`}, 0, 0)
+	assert.ErrorContains(t, err, `invalid pattern "(unclosed"`)
+}
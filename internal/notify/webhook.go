@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Job statuses reported in a payload
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// DefaultTimeout bounds a webhook request, so an unreachable endpoint
+// cannot hold up the command
+const DefaultTimeout = 10 * time.Second
+
+// Payload is the JSON body posted when a job finishes
+type Payload struct {
+	// Command is the command that ran, e.g. "synthesize" or "audiobook"
+	Command string `json:"command"`
+	// Job names the scheduled job that ran, for jobs of the daemon
+	Job string `json:"job,omitempty"`
+	// Status is StatusSuccess or StatusFailure, with the failure in Error
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// File is the written file or upload URL; batch jobs name their output
+	// directory and list the files they wrote in Files
+	File  string   `json:"file,omitempty"`
+	Files []string `json:"files,omitempty"`
+	// DurationSeconds is the playing time of the audio, when known
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	// Characters is the length of the synthesized text in characters
+	Characters int `json:"characters"`
+	// StartedAt and FinishedAt bound the run of the job
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Webhook posts payloads to a URL
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook creates a webhook posting to url through client, or through
+// http.DefaultClient when client is nil
+func NewWebhook(url string, client *http.Client) *Webhook {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Webhook{url: url, client: client}
+}
+
+// Send posts payload as JSON and fails unless the endpoint answers with a
+// 2xx status
+func (w *Webhook) Send(ctx context.Context, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "assistant-cli")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
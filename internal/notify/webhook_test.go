@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSend(t *testing.T) {
+	var got Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	started := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	payload := Payload{
+		Command:         "synthesize",
+		Status:          StatusSuccess,
+		File:            "/tmp/hello.mp3",
+		DurationSeconds: 1.5,
+		Characters:      12,
+		StartedAt:       started,
+		FinishedAt:      started.Add(2 * time.Second),
+	}
+	require.NoError(t, NewWebhook(server.URL, nil).Send(context.Background(), payload))
+	assert.Equal(t, payload, got)
+}
+
+func TestWebhookSend_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL, nil).Send(context.Background(), Payload{Status: StatusFailure})
+	assert.ErrorContains(t, err, "502")
+}
+
+func TestWebhookSend_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	err := NewWebhook(url, nil).Send(context.Background(), Payload{Status: StatusSuccess})
+	assert.ErrorContains(t, err, "webhook request failed")
+}
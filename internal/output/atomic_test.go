@@ -0,0 +1,101 @@
+package output
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dirEntries returns the names of the files in dir
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audio.mp3")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first"), 0640))
+	require.NoError(t, WriteFileAtomic(path, []byte("second"), 0640))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+	assert.Equal(t, []string{"audio.mp3"}, dirEntries(t, dir), "no temporary files are left behind")
+
+	if runtime.GOOS != "windows" {
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+	}
+}
+
+func TestWriteFileAtomic_FailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audio.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("original"), 0644))
+
+	err := writeAtomic(path, 0644, func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return errors.New("interrupted")
+	})
+	require.Error(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+	assert.Equal(t, []string{"audio.mp3"}, dirEntries(t, dir))
+}
+
+func TestWriteFileAtomic_MissingDirectory(t *testing.T) {
+	err := WriteFileAtomic(filepath.Join(t.TempDir(), "missing", "audio.mp3"), []byte("data"), 0644)
+	assert.Error(t, err)
+}
+
+func TestWriteFileAtomic_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.mp3")
+	link := filepath.Join(dir, "link.mp3")
+	require.NoError(t, os.WriteFile(target, []byte("old"), 0644))
+	require.NoError(t, os.Symlink(target, link))
+
+	require.NoError(t, WriteFileAtomic(link, []byte("new"), 0644))
+
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link is kept")
+}
+
+func TestCopyFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "audio.mp3")
+	dst := filepath.Join(dir, "audio.mp3.backup")
+	require.NoError(t, os.WriteFile(src, []byte("audio data"), 0644))
+
+	require.NoError(t, copyFileAtomic(dst, src, 0644))
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "audio data", string(data))
+
+	assert.Error(t, copyFileAtomic(dst, filepath.Join(dir, "missing.mp3"), 0644))
+}
@@ -0,0 +1,193 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupSuffix matches the suffix createBackup appends to the name of the
+// file it backs up: .backup_<YYYYMMDD_HHMMSS>, optionally followed by _<n>
+var backupSuffix = regexp.MustCompile(`\.backup_\d{8}_\d{6}(_\d+)?$`)
+
+// BackupRetention limits the backups kept of each file to the MaxCount
+// newest, none of them older than MaxAge. A zero limit does not apply.
+type BackupRetention struct {
+	MaxCount int
+	MaxAge   time.Duration
+}
+
+// IsZero reports whether the retention keeps every backup
+func (r BackupRetention) IsZero() bool {
+	return r.MaxCount <= 0 && r.MaxAge <= 0
+}
+
+// ParseBackupRetention parses a retention given as a number of backups to
+// keep of each file, e.g. "5", or as their maximum age, e.g. "72h" or
+// "30d". An empty string keeps every backup.
+func ParseBackupRetention(s string) (BackupRetention, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return BackupRetention{}, nil
+	}
+
+	if count, err := strconv.Atoi(s); err == nil {
+		if count < 1 {
+			return BackupRetention{}, fmt.Errorf("backup retention must keep at least 1 backup, got %d", count)
+		}
+		return BackupRetention{MaxCount: count}, nil
+	}
+
+	age, err := parseAge(s)
+	if err != nil || age <= 0 {
+		return BackupRetention{}, fmt.Errorf("invalid backup retention %q: expected a count such as 5 "+
+			"or an age such as 72h or 30d", s)
+	}
+	return BackupRetention{MaxAge: age}, nil
+}
+
+// parseAge parses a duration, also accepting whole days such as "30d"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Backup describes a backup of a file made in OverwriteBackup mode
+type Backup struct {
+	Path     string    `json:"path"`
+	Original string    `json:"original"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// FindBackups lists the backups in dir, newest first for each original
+// file. Only regular files named the way backups are named are listed, so
+// other files and symlinks in dir are never mistaken for backups.
+func FindBackups(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		loc := backupSuffix.FindStringIndex(entry.Name())
+		if loc == nil || loc[0] == 0 {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			Path:     filepath.Join(dir, entry.Name()),
+			Original: filepath.Join(dir, entry.Name()[:loc[0]]),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].Original != backups[j].Original {
+			return backups[i].Original < backups[j].Original
+		}
+		if !backups[i].Modified.Equal(backups[j].Modified) {
+			return backups[i].Modified.After(backups[j].Modified)
+		}
+		return backups[i].Path > backups[j].Path
+	})
+	return backups, nil
+}
+
+// Expired returns the backups the retention does not keep as of now, given
+// backups ordered as FindBackups returns them
+func (r BackupRetention) Expired(backups []Backup, now time.Time) []Backup {
+	var expired []Backup
+	kept := make(map[string]int)
+	for _, backup := range backups {
+		tooMany := r.MaxCount > 0 && kept[backup.Original] >= r.MaxCount
+		tooOld := r.MaxAge > 0 && now.Sub(backup.Modified) > r.MaxAge
+		if tooMany || tooOld {
+			expired = append(expired, backup)
+			continue
+		}
+		kept[backup.Original]++
+	}
+	return expired
+}
+
+// CleanBackups removes the backups in dir the retention does not keep and
+// returns them. With dryRun set nothing is removed.
+func CleanBackups(dir string, retention BackupRetention, dryRun bool) ([]Backup, error) {
+	backups, err := FindBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	return removeBackups(retention.Expired(backups, time.Now()), dryRun)
+}
+
+// PruneBackups removes the backups of the file at path the retention does
+// not keep and returns them
+func PruneBackups(path string, retention BackupRetention) ([]Backup, error) {
+	return pruneBackups(path, retention, time.Now())
+}
+
+// pruneBackups is PruneBackups aging the backups as of now
+func pruneBackups(path string, retention BackupRetention, now time.Time) ([]Backup, error) {
+	if retention.IsZero() {
+		return nil, nil
+	}
+	backups, err := FindBackups(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.Join(filepath.Dir(path), filepath.Base(path))
+	var own []Backup
+	for _, backup := range backups {
+		if backup.Original == path {
+			own = append(own, backup)
+		}
+	}
+	return removeBackups(retention.Expired(own, now), false)
+}
+
+// removeBackups removes backups, stopping at the first failure
+func removeBackups(backups []Backup, dryRun bool) ([]Backup, error) {
+	if dryRun {
+		return backups, nil
+	}
+	for i, backup := range backups {
+		if err := os.Remove(backup.Path); err != nil {
+			return backups[:i], fmt.Errorf("failed to remove backup: %w", err)
+		}
+	}
+	return backups, nil
+}
+
+// SetBackupRetention sets how many backups are kept of each file written in
+// OverwriteBackup mode; older ones are pruned after each write
+func (h *FileHandler) SetBackupRetention(retention BackupRetention) {
+	h.backupRetention = retention
+}
+
+// PruneBackups removes the backups of the file at path that the backup
+// retention does not keep, e.g. once a caller has written the file after
+// PrepareOverwrite backed it up
+func (h *FileHandler) PruneBackups(path string) ([]Backup, error) {
+	return pruneBackups(path, h.backupRetention, h.clock.Now())
+}
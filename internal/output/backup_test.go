@@ -0,0 +1,159 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackupRetention(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    BackupRetention
+		wantErr bool
+	}{
+		{"", BackupRetention{}, false},
+		{"5", BackupRetention{MaxCount: 5}, false},
+		{"72h", BackupRetention{MaxAge: 72 * time.Hour}, false},
+		{"30d", BackupRetention{MaxAge: 30 * 24 * time.Hour}, false},
+		{"0", BackupRetention{}, true},
+		{"-2h", BackupRetention{}, true},
+		{"forever", BackupRetention{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			retention, err := ParseBackupRetention(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, retention)
+		})
+	}
+}
+
+// writeBackup creates a backup of original last modified age ago
+func writeBackup(t *testing.T, original, stamp string, age time.Duration) string {
+	t.Helper()
+	path := original + ".backup_" + stamp
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0600))
+	modified := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, modified, modified))
+	return path
+}
+
+func TestFindBackups(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.mp3")
+	older := writeBackup(t, a, "20260101_120000", 2*time.Hour)
+	newer := writeBackup(t, a, "20260101_120000_1", time.Hour)
+	other := writeBackup(t, filepath.Join(dir, "b.mp3"), "20260102_120000", time.Hour)
+
+	// Files merely resembling backups are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.mp3.backup_old"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".backup_20260101_120000"), nil, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "c.backup_20260101_120000"), 0700))
+	require.NoError(t, os.Symlink(older, filepath.Join(dir, "d.backup_20260101_120000")))
+
+	backups, err := FindBackups(dir)
+	require.NoError(t, err)
+	var paths []string
+	for _, backup := range backups {
+		paths = append(paths, backup.Path)
+	}
+	assert.Equal(t, []string{newer, older, other}, paths)
+	assert.Equal(t, a, backups[0].Original)
+	assert.Equal(t, int64(3), backups[0].Size)
+}
+
+func TestBackupRetention_Expired(t *testing.T) {
+	now := time.Now()
+	backups := []Backup{
+		{Path: "a.1", Original: "a", Modified: now.Add(-time.Hour)},
+		{Path: "a.2", Original: "a", Modified: now.Add(-48 * time.Hour)},
+		{Path: "a.3", Original: "a", Modified: now.Add(-72 * time.Hour)},
+		{Path: "b.1", Original: "b", Modified: now.Add(-72 * time.Hour)},
+	}
+
+	expiredPaths := func(r BackupRetention) []string {
+		var paths []string
+		for _, backup := range r.Expired(backups, now) {
+			paths = append(paths, backup.Path)
+		}
+		return paths
+	}
+
+	assert.Empty(t, expiredPaths(BackupRetention{}))
+	assert.Equal(t, []string{"a.2", "a.3"}, expiredPaths(BackupRetention{MaxCount: 1}))
+	assert.Equal(t, []string{"a.3", "b.1"}, expiredPaths(BackupRetention{MaxAge: 60 * time.Hour}))
+	assert.Equal(t, []string{"a.2", "a.3", "b.1"}, expiredPaths(BackupRetention{MaxCount: 2, MaxAge: 24 * time.Hour}))
+}
+
+func TestCleanBackups(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.mp3")
+	keep := writeBackup(t, a, "20260101_120000_1", time.Hour)
+	old := writeBackup(t, a, "20260101_120000", 2*time.Hour)
+
+	removed, err := CleanBackups(dir, BackupRetention{MaxCount: 1}, true)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, old, removed[0].Path)
+	assert.FileExists(t, old)
+
+	removed, err = CleanBackups(dir, BackupRetention{MaxCount: 1}, false)
+	require.NoError(t, err)
+	assert.Len(t, removed, 1)
+	assert.NoFileExists(t, old)
+	assert.FileExists(t, keep)
+}
+
+func TestFileHandler_WriteFile_PrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.mp3")
+	other := filepath.Join(dir, "b.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0600))
+	otherBackup := writeBackup(t, other, "20260101_120000", 24*time.Hour)
+	stale := writeBackup(t, path, "20200101_120000", 24*time.Hour)
+
+	handler := NewFileHandlerWithOptions(dir, false, OverwriteBackup)
+	handler.SetBackupRetention(BackupRetention{MaxCount: 1})
+
+	info, err := handler.WriteFile(path, []byte("v2"))
+	require.NoError(t, err)
+	require.NotEmpty(t, info.BackupPath)
+
+	// Only the new backup of the written file is kept
+	assert.FileExists(t, info.BackupPath)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, otherBackup)
+}
+
+func TestFileHandler_PruneBackups_Clock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.mp3")
+	backup := writeBackup(t, path, "20260101_120000", time.Hour)
+
+	fake := clock.NewFake(time.Now())
+	handler := NewFileHandlerWithOptions(dir, false, OverwriteBackup)
+	handler.SetClock(fake)
+	handler.SetBackupRetention(BackupRetention{MaxAge: 24 * time.Hour})
+
+	removed, err := handler.PruneBackups(path)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+
+	// A day later by the handler's clock the backup is too old
+	fake.Advance(24 * time.Hour)
+	removed, err = handler.PruneBackups(path)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.NoFileExists(t, backup)
+}
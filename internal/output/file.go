@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
 )
 
 // FileHandler manages safe file output operations
@@ -16,6 +18,19 @@ type FileHandler struct {
 	overwriteMode   OverwriteMode
 	filePermissions fs.FileMode
 	dirPermissions  fs.FileMode
+	// prompter confirms overwrites in OverwritePrompt mode; without one,
+	// promptFallback applies instead
+	prompter       Prompter
+	promptFallback OverwriteMode
+	// answerAll is a "to all" answer covering every later file
+	answerAll   OverwriteAnswer
+	answeredAll bool
+	// backupRetention limits the backups kept of each file
+	backupRetention BackupRetention
+	// policy decides where files may be written
+	policy PathPolicy
+	// clock timestamps written files and ages backups for pruning
+	clock clock.Clock
 }
 
 // OverwriteMode defines how to handle existing files
@@ -51,6 +66,10 @@ type FileInfo struct {
 	Overwritten bool      `json:"overwritten"`
 	BackupPath  string    `json:"backup_path,omitempty"`
 	Permissions string    `json:"permissions"`
+	// Engine is the TTS provider that produced the file, when known
+	Engine string `json:"engine,omitempty"`
+	// DurationSeconds is the playing time of the audio, when known
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
 }
 
 // NewFileHandler creates a new file handler with default settings
@@ -61,6 +80,8 @@ func NewFileHandler() *FileHandler {
 		overwriteMode:   OverwriteBackup,
 		filePermissions: 0644,
 		dirPermissions:  0755,
+		policy:          DefaultPathPolicy(),
+		clock:           clock.Real,
 	}
 }
 
@@ -72,6 +93,8 @@ func NewFileHandlerWithOptions(baseDir string, createDirs bool, mode OverwriteMo
 		overwriteMode:   mode,
 		filePermissions: 0644,
 		dirPermissions:  0755,
+		policy:          DefaultPathPolicy(),
+		clock:           clock.Real,
 	}
 }
 
@@ -81,6 +104,39 @@ func (h *FileHandler) SetPermissions(filePerms, dirPerms fs.FileMode) {
 	h.dirPermissions = dirPerms
 }
 
+// SetPrompter sets how OverwritePrompt confirms overwriting a file. A nil
+// prompter, e.g. when no terminal is attached, applies fallback instead.
+func (h *FileHandler) SetPrompter(prompter Prompter, fallback OverwriteMode) {
+	h.prompter = prompter
+	h.promptFallback = fallback
+}
+
+// SetPathPolicy sets where files may be written
+func (h *FileHandler) SetPathPolicy(policy PathPolicy) {
+	h.policy = policy
+}
+
+// SetClock sets the clock that timestamps written files and decides the age
+// of backups when pruning them
+func (h *FileHandler) SetClock(c clock.Clock) {
+	h.clock = clock.OrReal(c)
+}
+
+// PrepareOverwrite checks the path policy and applies the overwrite mode to
+// the file at path before something else writes it: it fails when the file
+// must be kept and backs it up in OverwriteBackup mode. A missing file needs
+// no preparation.
+func (h *FileHandler) PrepareOverwrite(path string) (*FileInfo, error) {
+	if err := h.policy.Check(path); err != nil {
+		return nil, &FileError{
+			Operation: "validation",
+			Path:      path,
+			Err:       err,
+		}
+	}
+	return h.handleExistingFile(path)
+}
+
 // WriteFile writes data to a file with safety checks
 func (h *FileHandler) WriteFile(filename string, data []byte) (*FileInfo, error) {
 	// Validate and sanitize filename
@@ -110,8 +166,8 @@ func (h *FileHandler) WriteFile(filename string, data []byte) (*FileInfo, error)
 		return nil, err
 	}
 
-	// Write the file
-	if writeErr := os.WriteFile(safePath, data, h.filePermissions); writeErr != nil {
+	// Write the file; an interrupted write leaves any existing file intact
+	if writeErr := WriteFileAtomic(safePath, data, h.filePermissions); writeErr != nil {
 		return nil, &FileError{
 			Operation: "write",
 			Path:      safePath,
@@ -119,6 +175,11 @@ func (h *FileHandler) WriteFile(filename string, data []byte) (*FileInfo, error)
 		}
 	}
 
+	// Old backups are pruned only once the new content is safely written
+	if info.BackupPath != "" {
+		_, _ = h.PruneBackups(safePath)
+	}
+
 	// Get file stats
 	stat, err := os.Stat(safePath)
 	if err != nil {
@@ -126,7 +187,7 @@ func (h *FileHandler) WriteFile(filename string, data []byte) (*FileInfo, error)
 		return &FileInfo{
 			Path:        safePath,
 			Size:        int64(len(data)),
-			Created:     time.Now(),
+			Created:     h.clock.Now(),
 			Overwritten: info.Overwritten,
 			BackupPath:  info.BackupPath,
 			Permissions: h.filePermissions.String(),
@@ -145,6 +206,11 @@ func (h *FileHandler) WriteFile(filename string, data []byte) (*FileInfo, error)
 
 // WriteFileStream writes data from a stream to a file
 func (h *FileHandler) WriteFileStream(filename string, data []byte, append bool) (*FileInfo, error) {
+	if !append {
+		// Replacing the whole file goes through WriteFile, which is atomic
+		return h.WriteFile(filename, data)
+	}
+
 	// Validate path
 	safePath, err := h.validatePath(filename)
 	if err != nil {
@@ -166,22 +232,7 @@ func (h *FileHandler) WriteFileStream(filename string, data []byte, append bool)
 		}
 	}
 
-	flags := os.O_CREATE | os.O_WRONLY
-	if append {
-		flags |= os.O_APPEND
-	} else {
-		flags |= os.O_TRUNC
-
-		// Handle existing file for non-append mode
-		info, handleErr := h.handleExistingFile(safePath)
-		if handleErr != nil {
-			return nil, handleErr
-		}
-		// Store backup info for later use
-		_ = info
-	}
-
-	file, err := os.OpenFile(safePath, flags, h.filePermissions)
+	file, err := os.OpenFile(safePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, h.filePermissions)
 	if err != nil {
 		return nil, &FileError{
 			Operation: "open",
@@ -206,7 +257,7 @@ func (h *FileHandler) WriteFileStream(filename string, data []byte, append bool)
 		return &FileInfo{
 			Path:        safePath,
 			Size:        int64(written),
-			Created:     time.Now(),
+			Created:     h.clock.Now(),
 			Permissions: h.filePermissions.String(),
 		}, nil
 	}
@@ -246,50 +297,9 @@ func (h *FileHandler) validatePath(filename string) (string, error) {
 	return cleaned, nil
 }
 
-// validatePathSecurity performs additional security validation
+// validatePathSecurity checks path against the path policy
 func (h *FileHandler) validatePathSecurity(path string) error {
-	// Check for prohibited file extensions or patterns
-	prohibitedExtensions := []string{
-		".exe", ".bat", ".cmd", ".com", ".scr", ".pif",
-		".vbs", ".vbe", ".js", ".jse", ".wsf", ".wsh",
-		".msc", ".cpl", ".dll", ".sys",
-	}
-
-	ext := strings.ToLower(filepath.Ext(path))
-	for _, prohibited := range prohibitedExtensions {
-		if ext == prohibited {
-			return fmt.Errorf("file extension not allowed: %s", ext)
-		}
-	}
-
-	// Check for system directories (Unix/Linux)
-	prohibitedPaths := []string{
-		"/etc/", "/bin/", "/sbin/", "/usr/bin/", "/usr/sbin/",
-		"/var/log/", "/proc/", "/sys/", "/dev/",
-	}
-
-	for _, prohibited := range prohibitedPaths {
-		if strings.HasPrefix(path, prohibited) {
-			return fmt.Errorf("access to system directory not allowed: %s", prohibited)
-		}
-	}
-
-	// Windows system directories
-	if len(path) >= 3 && path[1] == ':' {
-		winProhibited := []string{
-			"C:\\Windows\\", "C:\\Program Files\\", "C:\\Program Files (x86)\\",
-			"C:\\System32\\", "C:\\SysWOW64\\",
-		}
-
-		upperPath := strings.ToUpper(path)
-		for _, prohibited := range winProhibited {
-			if strings.HasPrefix(upperPath, strings.ToUpper(prohibited)) {
-				return fmt.Errorf("access to system directory not allowed: %s", prohibited)
-			}
-		}
-	}
-
-	return nil
+	return h.policy.Check(path)
 }
 
 // ensureDirectoryExists creates directory if it doesn't exist
@@ -330,7 +340,18 @@ func (h *FileHandler) handleExistingFile(path string) (*FileInfo, error) {
 	}
 
 	// File exists, handle based on mode
-	switch h.overwriteMode {
+	mode := h.overwriteMode
+	if mode == OverwritePrompt {
+		if mode, err = h.confirmOverwrite(path); err != nil {
+			return nil, &FileError{
+				Operation: "overwrite_check",
+				Path:      path,
+				Err:       err,
+			}
+		}
+	}
+
+	switch mode {
 	case OverwriteNever:
 		return nil, &FileError{
 			Operation: "overwrite_check",
@@ -342,15 +363,6 @@ func (h *FileHandler) handleExistingFile(path string) (*FileInfo, error) {
 		info.Overwritten = true
 		return info, nil
 
-	case OverwritePrompt:
-		// For CLI tools, we'll treat this as "never" for safety
-		// In a more sophisticated implementation, you could add actual prompting
-		return nil, &FileError{
-			Operation: "overwrite_check",
-			Path:      path,
-			Err:       fmt.Errorf("file already exists, user confirmation required"),
-		}
-
 	case OverwriteBackup:
 		backupPath, err := h.createBackup(path, stat)
 		if err != nil {
@@ -391,13 +403,8 @@ func (h *FileHandler) createBackup(originalPath string, stat fs.FileInfo) (strin
 		}
 	}
 
-	// Copy original file to backup location
-	originalData, err := os.ReadFile(originalPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read original file for backup: %v", err)
-	}
-
-	if err := os.WriteFile(backupPath, originalData, stat.Mode()); err != nil {
+	// Copy original file to backup location; the backup appears only once complete
+	if err := copyFileAtomic(backupPath, originalPath, stat.Mode().Perm()); err != nil {
 		return "", fmt.Errorf("failed to create backup file: %v", err)
 	}
 
@@ -449,6 +456,25 @@ func FileExists(path string) bool {
 	return !os.IsNotExist(err)
 }
 
+// StatFile returns information about an existing file
+func StatFile(path string) (*FileInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, &FileError{
+			Operation: "stat",
+			Path:      path,
+			Err:       err,
+		}
+	}
+
+	return &FileInfo{
+		Path:        path,
+		Size:        stat.Size(),
+		Created:     stat.ModTime(),
+		Permissions: stat.Mode().String(),
+	}, nil
+}
+
 // GetFileSize returns the size of a file in bytes
 func GetFileSize(path string) (int64, error) {
 	stat, err := os.Stat(path)
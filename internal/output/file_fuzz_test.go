@@ -0,0 +1,59 @@
+package output
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func FuzzValidatePath(f *testing.F) {
+	for _, seed := range []string{
+		"out.mp3",
+		"audio/chapter 1.wav",
+		"./a/./b/../c.ogg",
+		"../../etc/passwd",
+		"a/..",
+		"a..b.mp3",
+		"/etc/cron.d/job",
+		"/tmp/out.mp3",
+		"evil.EXE",
+		"name.mp3.bat",
+		`C:\Windows\System32\x.dll`,
+		`..\..\boot.ini`,
+		"out\x00.mp3",
+		"//double//slash.mp3",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	baseDir := f.TempDir()
+	handler := NewFileHandlerWithOptions(baseDir, false, OverwriteAlways)
+	f.Fuzz(func(t *testing.T, filename string) {
+		path, err := handler.validatePath(filename)
+		if err != nil {
+			if path != "" {
+				t.Fatalf("validatePath(%q) returned %q with error %v", filename, path, err)
+			}
+			return
+		}
+
+		if path != filepath.Clean(path) {
+			t.Fatalf("validatePath(%q) = %q is not clean", filename, path)
+		}
+		for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+			if part == ".." {
+				t.Fatalf("validatePath(%q) = %q traverses upward", filename, path)
+			}
+		}
+		if !filepath.IsAbs(filepath.Clean(filename)) {
+			rel, relErr := filepath.Rel(baseDir, path)
+			if relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				t.Fatalf("validatePath(%q) = %q escapes the base directory %s", filename, path, baseDir)
+			}
+		}
+		if policyErr := handler.policy.Check(path); policyErr != nil {
+			t.Fatalf("validatePath(%q) = %q, which the path policy refuses: %v", filename, path, policyErr)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// ManifestSuffix is appended to an audio file's path to name its manifest
+const ManifestSuffix = ".meta.json"
+
+// Manifest records how an audio file was produced, for auditing and for
+// reproducing a synthesis run
+type Manifest struct {
+	// Created is the time the audio was synthesized
+	Created time.Time `json:"created"`
+	// Provider is the engine that produced the audio, and Fallback is set
+	// when it replaced the configured provider
+	Provider string `json:"provider"`
+	Fallback bool   `json:"fallback,omitempty"`
+	// Request holds the synthesis parameters
+	Request ManifestRequest `json:"request"`
+	// InputHash is the hex SHA-256 digest of the input text, and Characters
+	// its length in characters
+	InputHash  string `json:"input_hash"`
+	Characters int    `json:"characters"`
+	// DurationSeconds is the playing time, when it can be read from the audio
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	// LatencyMillis is the time the provider took to return the audio
+	LatencyMillis int64 `json:"latency_ms"`
+	// File describes the written audio file, including any backup made of a
+	// file it replaced
+	File *FileInfo `json:"file"`
+}
+
+// ManifestRequest is the voice and encoding configuration of a synthesis
+type ManifestRequest struct {
+	Voice        string  `json:"voice,omitempty"`
+	Language     string  `json:"language,omitempty"`
+	SpeakingRate float64 `json:"speaking_rate"`
+	Pitch        float64 `json:"pitch"`
+	VolumeGain   float64 `json:"volume_gain"`
+	Format       string  `json:"format"`
+	SampleRate   int     `json:"sample_rate,omitempty"`
+}
+
+// NewManifest creates a manifest for audio synthesized from text into file
+func NewManifest(text string, req ManifestRequest, file *FileInfo, duration, latency time.Duration) *Manifest {
+	sum := sha256.Sum256([]byte(text))
+
+	return &Manifest{
+		Created:         time.Now(),
+		Request:         req,
+		InputHash:       hex.EncodeToString(sum[:]),
+		Characters:      utf8.RuneCountInString(text),
+		DurationSeconds: duration.Seconds(),
+		LatencyMillis:   latency.Milliseconds(),
+		File:            file,
+	}
+}
+
+// ManifestPath returns the manifest path for an audio file
+func ManifestPath(audioPath string) string {
+	return audioPath + ManifestSuffix
+}
+
+// WriteManifest writes m next to the audio file it describes and returns
+// the manifest path
+func WriteManifest(m *Manifest) (string, error) {
+	if m.File == nil || m.File.Path == "" {
+		return "", fmt.Errorf("manifest has no audio file")
+	}
+
+	data, err := m.Encode()
+	if err != nil {
+		return "", err
+	}
+
+	path := ManifestPath(m.File.Path)
+	if err := WriteFileAtomic(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return path, nil
+}
+
+// Encode returns the manifest as indented JSON
+func (m *Manifest) Encode() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewManifest(t *testing.T) {
+	file := &FileInfo{Path: "/tmp/audio.mp3", Size: 10}
+	req := ManifestRequest{Voice: "en-US-Wavenet-D", SpeakingRate: 1.0, Format: "MP3"}
+
+	m := NewManifest("Héllo", req, file, 1500*time.Millisecond, 250*time.Millisecond)
+
+	assert.Equal(t, req, m.Request)
+	assert.Len(t, m.InputHash, 64)
+	assert.Equal(t, NewManifest("Héllo", req, file, 0, 0).InputHash, m.InputHash)
+	assert.NotEqual(t, NewManifest("Hello", req, file, 0, 0).InputHash, m.InputHash)
+	assert.Equal(t, 5, m.Characters)
+	assert.Equal(t, 1.5, m.DurationSeconds)
+	assert.Equal(t, int64(250), m.LatencyMillis)
+	assert.Same(t, file, m.File)
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "audio.mp3")
+	file := &FileInfo{Path: audioPath, Size: 3, BackupPath: audioPath + ".bak"}
+
+	m := NewManifest("Hello", ManifestRequest{Format: "MP3"}, file, 0, time.Second)
+	m.Provider = "google"
+
+	path, err := WriteManifest(m)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "audio.mp3.meta.json"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "google", decoded["provider"])
+	assert.Equal(t, float64(1000), decoded["latency_ms"])
+	assert.NotContains(t, decoded, "duration_seconds", "unknown durations are omitted")
+	assert.Equal(t, audioPath+".bak", decoded["file"].(map[string]interface{})["backup_path"])
+
+	_, err = WriteManifest(&Manifest{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,286 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Speech mark types
+const (
+	// MarkWord is a spoken word
+	MarkWord = "word"
+	// MarkSSML is a <mark name="..."/> element of SSML input
+	MarkSSML = "ssml"
+)
+
+// MarksSourceEstimated says the mark times were estimated from the length of
+// the audio, spread over the words by their length and punctuation, because
+// the synthesis API returned no timepoints
+const MarksSourceEstimated = "estimated"
+
+// MarksSourceTimepoints says the SSML mark times are the timepoints the
+// synthesis API returned, and the word times between them were estimated
+const MarksSourceTimepoints = "timepoints"
+
+// MarkTime is the time at which the audio reaches a named SSML mark
+type MarkTime struct {
+	Name string
+	Time time.Duration
+}
+
+// SpeechMarks is the word and mark level timing of synthesized audio, for
+// highlighting text as it is read or for captioning
+type SpeechMarks struct {
+	// Source says where the times come from
+	Source string `json:"source"`
+	// DurationSeconds is the playing time of the audio
+	DurationSeconds float64      `json:"duration_seconds"`
+	Marks           []SpeechMark `json:"marks"`
+}
+
+// SpeechMark is a point in the audio where a word starts or an SSML mark is
+// reached
+type SpeechMark struct {
+	Type string `json:"type"`
+	// TimeMillis is the offset into the audio
+	TimeMillis int64 `json:"time_ms"`
+	// Start and End are the byte offsets of the word or mark element in the
+	// synthesized text
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Value is the word, or the name of the mark
+	Value string `json:"value"`
+}
+
+// Relative lengths of the pauses after punctuation, in characters
+const (
+	clausePauseWeight   = 2
+	sentencePauseWeight = 5
+)
+
+// Break lengths for <break> elements given only a strength
+var breakStrengths = map[string]time.Duration{
+	"none":     0,
+	"x-weak":   100 * time.Millisecond,
+	"weak":     250 * time.Millisecond,
+	"medium":   500 * time.Millisecond,
+	"strong":   750 * time.Millisecond,
+	"x-strong": time.Second,
+}
+
+var (
+	markNamePattern      = regexp.MustCompile(`\bname\s*=\s*["']([^"']*)["']`)
+	breakTimePattern     = regexp.MustCompile(`\btime\s*=\s*["']([^"']*)["']`)
+	breakStrengthPattern = regexp.MustCompile(`\bstrength\s*=\s*["']([^"']*)["']`)
+)
+
+// markToken is a word, SSML mark, or break found in the text
+type markToken struct {
+	mark SpeechMark
+	// weight is the share of speaking time of a word; pause is the fixed
+	// length of a break
+	weight float64
+	pause  time.Duration
+}
+
+// EstimateSpeechMarks returns marks for the words of text, which may be
+// SSML, and its <mark> elements, spread over audio of the given duration.
+// Each word takes time in proportion to its length, punctuation adds a
+// pause, and <break> elements take the time they state.
+func EstimateSpeechMarks(text string, duration time.Duration) *SpeechMarks {
+	tokens := markTokens(text)
+
+	var weight float64
+	var pauses time.Duration
+	for _, token := range tokens {
+		weight += token.weight
+		pauses += token.pause
+	}
+	speech := duration - pauses
+	if speech < 0 {
+		speech = 0
+	}
+
+	marks := &SpeechMarks{
+		Source:          MarksSourceEstimated,
+		DurationSeconds: duration.Seconds(),
+		Marks:           []SpeechMark{},
+	}
+	var elapsed time.Duration
+	var spoken float64
+	for _, token := range tokens {
+		at := elapsed
+		if weight > 0 {
+			at += time.Duration(spoken / weight * float64(speech))
+		}
+		if token.mark.Type != "" {
+			token.mark.TimeMillis = min(at, duration).Milliseconds()
+			marks.Marks = append(marks.Marks, token.mark)
+		}
+		spoken += token.weight
+		elapsed += token.pause
+	}
+	return marks
+}
+
+// AlignSpeechMarks sets the SSML marks of estimated marks to the times
+// reported for them, matching names in order, and stretches the word times
+// between each pair of known marks to fit. Marks without a reported time
+// keep their estimates.
+func AlignSpeechMarks(marks *SpeechMarks, times []MarkTime) {
+	duration := time.Duration(marks.DurationSeconds * float64(time.Second)).Milliseconds()
+
+	// Anchors map estimated times to reported ones, rising in both
+	type anchor struct{ estimated, actual int64 }
+	anchors := []anchor{{0, 0}}
+	next := 0
+	for i := range marks.Marks {
+		mark := &marks.Marks[i]
+		if mark.Type != MarkSSML {
+			continue
+		}
+		for j := next; j < len(times); j++ {
+			if times[j].Name != mark.Value {
+				continue
+			}
+			next = j + 1
+			actual := times[j].Time.Milliseconds()
+			last := anchors[len(anchors)-1]
+			if mark.TimeMillis >= last.estimated && actual >= last.actual {
+				anchors = append(anchors, anchor{mark.TimeMillis, actual})
+			}
+			mark.TimeMillis = actual
+			marks.Source = MarksSourceTimepoints
+			break
+		}
+	}
+	if marks.Source != MarksSourceTimepoints {
+		return
+	}
+	if last := anchors[len(anchors)-1]; duration >= last.estimated && duration >= last.actual {
+		anchors = append(anchors, anchor{duration, duration})
+	}
+
+	for i := range marks.Marks {
+		mark := &marks.Marks[i]
+		if mark.Type != MarkWord {
+			continue
+		}
+		// Words past the last anchor move with it
+		k := len(anchors) - 1
+		for j := 1; j < len(anchors); j++ {
+			if mark.TimeMillis < anchors[j].estimated {
+				k = j - 1
+				break
+			}
+		}
+		from := anchors[k]
+		if k+1 == len(anchors) {
+			mark.TimeMillis = from.actual + mark.TimeMillis - from.estimated
+			continue
+		}
+		to := anchors[k+1]
+		span := to.estimated - from.estimated
+		mark.TimeMillis = from.actual + (mark.TimeMillis-from.estimated)*(to.actual-from.actual)/span
+	}
+}
+
+// markTokens splits text into words, skipping markup except <mark> and
+// <break> elements
+func markTokens(text string) []markToken {
+	var tokens []markToken
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '<':
+			end := strings.IndexByte(text[i:], '>')
+			if end < 0 {
+				end = len(text) - i - 1
+			}
+			if token, ok := elementToken(text[i:i+end+1], i); ok {
+				tokens = append(tokens, token)
+			}
+			i += end + 1
+		case unicode.IsSpace(r):
+			i += size
+		default:
+			end := i
+			for end < len(text) {
+				r, size := utf8.DecodeRuneInString(text[end:])
+				if unicode.IsSpace(r) || r == '<' {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, wordToken(text[i:end], i))
+			i = end
+		}
+	}
+	return tokens
+}
+
+// wordToken weighs a word by its letters and the pause after its
+// punctuation
+func wordToken(word string, start int) markToken {
+	token := markToken{mark: SpeechMark{Type: MarkWord, Start: start, End: start + len(word), Value: word}}
+	for _, r := range word {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			token.weight++
+		}
+	}
+	last, _ := utf8.DecodeLastRuneInString(word)
+	switch last {
+	case '.', '!', '?', ';':
+		token.weight += sentencePauseWeight
+	case ',', ':':
+		token.weight += clausePauseWeight
+	}
+	return token
+}
+
+// elementToken returns the token of a <mark> or <break> element
+func elementToken(element string, start int) (markToken, bool) {
+	name := strings.TrimPrefix(element, "<")
+	if i := strings.IndexAny(name, " \t\n/>"); i >= 0 {
+		name = name[:i]
+	}
+
+	switch name {
+	case "mark":
+		match := markNamePattern.FindStringSubmatch(element)
+		if match == nil {
+			return markToken{}, false
+		}
+		return markToken{mark: SpeechMark{Type: MarkSSML, Start: start, End: start + len(element),
+			Value: match[1]}}, true
+	case "break":
+		if match := breakTimePattern.FindStringSubmatch(element); match != nil {
+			if d, err := time.ParseDuration(match[1]); err == nil && d > 0 {
+				return markToken{pause: d}, true
+			}
+		}
+		strength := "medium"
+		if match := breakStrengthPattern.FindStringSubmatch(element); match != nil {
+			strength = match[1]
+		}
+		return markToken{pause: breakStrengths[strength]}, true
+	}
+	return markToken{}, false
+}
+
+// WriteSpeechMarks writes marks to path as indented JSON
+func WriteSpeechMarks(path string, marks *SpeechMarks) error {
+	data, err := json.MarshalIndent(marks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode speech marks: %w", err)
+	}
+	if err := WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write speech marks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSpeechMarks(t *testing.T) {
+	text := "Hello there. Bye"
+	marks := EstimateSpeechMarks(text, 2*time.Second)
+	assert.Equal(t, MarksSourceEstimated, marks.Source)
+	assert.Equal(t, 2.0, marks.DurationSeconds)
+	require.Len(t, marks.Marks, 3)
+
+	// Words take time by their letters, and the full stop adds a pause:
+	// weights 5, 5+5, 3 of 18
+	assert.Equal(t, SpeechMark{Type: MarkWord, TimeMillis: 0, Start: 0, End: 5, Value: "Hello"}, marks.Marks[0])
+	assert.Equal(t, SpeechMark{Type: MarkWord, TimeMillis: 555, Start: 6, End: 12, Value: "there."}, marks.Marks[1])
+	assert.Equal(t, SpeechMark{Type: MarkWord, TimeMillis: 1666, Start: 13, End: 16, Value: "Bye"}, marks.Marks[2])
+	for _, mark := range marks.Marks {
+		assert.Equal(t, mark.Value, text[mark.Start:mark.End])
+	}
+}
+
+func TestEstimateSpeechMarks_SSML(t *testing.T) {
+	ssml := `<speak>One <mark name="middle"/><break time="1s"/>two<break strength="none"/></speak>`
+	marks := EstimateSpeechMarks(ssml, 3*time.Second)
+	require.Len(t, marks.Marks, 3)
+
+	assert.Equal(t, "One", marks.Marks[0].Value)
+	assert.Equal(t, int64(0), marks.Marks[0].TimeMillis)
+
+	// The mark falls after the first word, and the break holds off the next
+	assert.Equal(t, MarkSSML, marks.Marks[1].Type)
+	assert.Equal(t, "middle", marks.Marks[1].Value)
+	assert.Equal(t, int64(1000), marks.Marks[1].TimeMillis)
+	assert.Equal(t, `<mark name="middle"/>`, ssml[marks.Marks[1].Start:marks.Marks[1].End])
+
+	assert.Equal(t, "two", marks.Marks[2].Value)
+	assert.Equal(t, int64(2000), marks.Marks[2].TimeMillis)
+}
+
+func TestEstimateSpeechMarks_Empty(t *testing.T) {
+	marks := EstimateSpeechMarks("<speak></speak>", time.Second)
+	assert.Empty(t, marks.Marks)
+
+	// Breaks longer than the audio squeeze the words to its end
+	marks = EstimateSpeechMarks(`<break time="5s"/>word`, time.Second)
+	require.Len(t, marks.Marks, 1)
+	assert.Equal(t, int64(1000), marks.Marks[0].TimeMillis)
+}
+
+func TestAlignSpeechMarks(t *testing.T) {
+	ssml := `<speak>One <mark name="middle"/><break time="1s"/>two<break strength="none"/></speak>`
+	marks := EstimateSpeechMarks(ssml, 3*time.Second)
+	AlignSpeechMarks(marks, []MarkTime{{Name: "middle", Time: 1500 * time.Millisecond}})
+	assert.Equal(t, MarksSourceTimepoints, marks.Source)
+	require.Len(t, marks.Marks, 3)
+
+	// The mark takes its reported time, and the word after it is
+	// stretched between the mark and the end of the audio
+	assert.Equal(t, int64(0), marks.Marks[0].TimeMillis)
+	assert.Equal(t, int64(1500), marks.Marks[1].TimeMillis)
+	assert.Equal(t, int64(2250), marks.Marks[2].TimeMillis)
+}
+
+func TestAlignSpeechMarks_Unknown(t *testing.T) {
+	ssml := `<speak>One <mark name="middle"/>two</speak>`
+	marks := EstimateSpeechMarks(ssml, time.Second)
+	want := append([]SpeechMark(nil), marks.Marks...)
+	AlignSpeechMarks(marks, []MarkTime{{Name: "other", Time: 800 * time.Millisecond}})
+	assert.Equal(t, MarksSourceEstimated, marks.Source)
+	assert.Equal(t, want, marks.Marks)
+}
+
+func TestWriteSpeechMarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "marks.json")
+	require.NoError(t, WriteSpeechMarks(path, EstimateSpeechMarks("Hi", time.Second)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "estimated", decoded["source"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"type": "word", "time_ms": 0.0, "start": 0.0, "end": 2.0, "value": "Hi",
+	}}, decoded["marks"])
+}
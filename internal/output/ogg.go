@@ -0,0 +1,85 @@
+package output
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Ogg page layout
+const (
+	oggHeaderSize     = 27
+	oggHeaderType     = 5
+	oggGranuleOffset  = 6
+	oggSerialOffset   = 14
+	oggSequenceOffset = 18
+	oggCRCOffset      = 22
+	oggSegments       = 26
+)
+
+// oggContinued flags a page that starts with the rest of a packet
+const oggContinued = 0x01
+
+// OggPages splits an Ogg stream into its pages
+func OggPages(data []byte) ([][]byte, error) {
+	var pages [][]byte
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < oggHeaderSize || string(data[offset:offset+4]) != "OggS" {
+			return nil, fmt.Errorf("invalid Ogg page at byte %d", offset)
+		}
+
+		segments := int(data[offset+oggSegments])
+		headerEnd := offset + oggHeaderSize + segments
+		if headerEnd > len(data) {
+			return nil, fmt.Errorf("truncated Ogg page at byte %d", offset)
+		}
+		size := headerEnd - offset
+		for _, lacing := range data[offset+oggHeaderSize : headerEnd] {
+			size += int(lacing)
+		}
+		if offset+size > len(data) {
+			return nil, fmt.Errorf("truncated Ogg page at byte %d", offset)
+		}
+
+		pages = append(pages, data[offset:offset+size])
+		offset += size
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("empty Ogg stream")
+	}
+	return pages, nil
+}
+
+// SetOggChecksum recomputes the checksum of a page after its header or
+// body has been changed
+func SetOggChecksum(page []byte) {
+	binary.LittleEndian.PutUint32(page[oggCRCOffset:], 0)
+	binary.LittleEndian.PutUint32(page[oggCRCOffset:], oggCRC(page))
+}
+
+// oggCRCTable is the lookup table for the Ogg page checksum, a CRC-32 with
+// polynomial 0x04c11db7 computed without bit reflection
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// oggCRC computes the checksum of a page whose checksum field is zeroed
+func oggCRC(page []byte) uint32 {
+	var crc uint32
+	for _, b := range page {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,29 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOggCRC(t *testing.T) {
+	// CRC-32 with polynomial 0x04c11db7, zero initial value and no final XOR
+	assert.Equal(t, uint32(0x89A1897F), oggCRC([]byte("123456789")))
+}
+
+func TestOggPagesErrors(t *testing.T) {
+	_, err := OggPages(nil)
+	assert.Error(t, err)
+
+	_, err = OggPages([]byte("not an ogg stream at all, really"))
+	assert.Error(t, err)
+
+	page := oggPaginate(oggTemplate(1), []byte("payload"), 0)[0]
+	_, err = OggPages(page[:len(page)-1])
+	assert.ErrorContains(t, err, "truncated")
+
+	pages, err := OggPages(page)
+	require.NoError(t, err)
+	assert.Len(t, pages, 1)
+}
@@ -0,0 +1,111 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPathNotAllowed reports a path the output path policy does not allow
+var ErrPathNotAllowed = errors.New("path not allowed by the output path policy")
+
+// PathPolicy decides where output files may be written. A path is refused
+// when it lies in a blocked directory or has a blocked extension, unless it
+// also lies in a more specific allowed directory or has an allowed
+// extension. A non-empty allowlist also refuses everything it does not list.
+type PathPolicy struct {
+	AllowedDirs       []string
+	BlockedDirs       []string
+	AllowedExtensions []string
+	BlockedExtensions []string
+}
+
+// DefaultPathPolicy returns the policy refusing executable and script
+// extensions and system directories
+func DefaultPathPolicy() PathPolicy {
+	return PathPolicy{
+		BlockedDirs: []string{
+			"/etc", "/bin", "/sbin", "/usr/bin", "/usr/sbin", "/var/log", "/proc", "/sys", "/dev",
+			`C:\Windows`, `C:\Program Files`, `C:\Program Files (x86)`, `C:\System32`, `C:\SysWOW64`,
+		},
+		BlockedExtensions: []string{
+			".exe", ".bat", ".cmd", ".com", ".scr", ".pif",
+			".vbs", ".vbe", ".js", ".jse", ".wsf", ".wsh",
+			".msc", ".cpl", ".dll", ".sys",
+		},
+	}
+}
+
+// Check returns an error wrapping ErrPathNotAllowed when path may not be
+// written. A relative path is checked as it resolves from the working
+// directory.
+func (p PathPolicy) Check(path string) error {
+	if abs, err := filepath.Abs(path); err == nil && !isWindowsPath(path) {
+		path = abs
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	allowedExt := containsExtension(p.AllowedExtensions, ext)
+	if len(p.AllowedExtensions) > 0 && !allowedExt {
+		return fmt.Errorf("%w: file extension not allowed: %s", ErrPathNotAllowed, ext)
+	}
+	if !allowedExt && containsExtension(p.BlockedExtensions, ext) {
+		return fmt.Errorf("%w: file extension not allowed: %s", ErrPathNotAllowed, ext)
+	}
+
+	allowedDir := longestDirMatch(path, p.AllowedDirs)
+	if len(p.AllowedDirs) > 0 && allowedDir == "" {
+		return fmt.Errorf("%w: %s is outside the allowed directories", ErrPathNotAllowed, path)
+	}
+	if blockedDir := longestDirMatch(path, p.BlockedDirs); blockedDir != "" && len(blockedDir) >= len(allowedDir) {
+		return fmt.Errorf("%w: access to system directory not allowed: %s", ErrPathNotAllowed, blockedDir)
+	}
+	return nil
+}
+
+// longestDirMatch returns the longest of dirs containing path, or "" when
+// none does
+func longestDirMatch(path string, dirs []string) string {
+	match := ""
+	for _, dir := range dirs {
+		if len(dir) > len(match) && withinDir(path, dir) {
+			match = dir
+		}
+	}
+	return match
+}
+
+// withinDir reports whether path is dir or lies below it, resolving a
+// relative dir from the working directory. Windows paths, which start with
+// a drive letter, compare case-insensitively.
+func withinDir(path, dir string) bool {
+	if isWindowsPath(dir) {
+		path = strings.ToUpper(strings.ReplaceAll(path, "/", `\`))
+		dir = strings.TrimRight(strings.ToUpper(strings.ReplaceAll(dir, "/", `\`)), `\`)
+		return path == dir || strings.HasPrefix(path, dir+`\`)
+	}
+
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if dir == string(filepath.Separator) {
+		return filepath.IsAbs(path)
+	}
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// isWindowsPath reports whether path starts with a drive letter
+func isWindowsPath(path string) bool {
+	return len(path) >= 2 && path[1] == ':'
+}
+
+// containsExtension reports whether ext is in extensions, ignoring case
+func containsExtension(extensions []string, ext string) bool {
+	for _, e := range extensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
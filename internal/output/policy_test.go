@@ -0,0 +1,83 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PathPolicy
+		path    string
+		wantErr string
+	}{
+		{"default allows audio", DefaultPathPolicy(), "/tmp/out.mp3", ""},
+		{"default allows /usr/local", DefaultPathPolicy(), "/usr/local/share/out.mp3", ""},
+		{"default blocks system directory", DefaultPathPolicy(), "/etc/out.mp3", "system directory not allowed"},
+		{"directory prefix is not enough", DefaultPathPolicy(), "/etcetera/out.mp3", ""},
+		{"default blocks scripts", DefaultPathPolicy(), "/tmp/out.js", "file extension not allowed"},
+		{"windows directory", DefaultPathPolicy(), `c:\windows\out.mp3`, "system directory not allowed"},
+		{"allowed extension overrides block",
+			PathPolicy{BlockedExtensions: []string{".js"}, AllowedExtensions: []string{".js", ".mp3"}}, "/tmp/meta.js", ""},
+		{"extension allowlist", PathPolicy{AllowedExtensions: []string{".mp3"}}, "/tmp/out.wav",
+			"file extension not allowed"},
+		{"more specific allowed directory",
+			PathPolicy{BlockedDirs: []string{"/usr"}, AllowedDirs: []string{"/usr/local"}}, "/usr/local/out.mp3", ""},
+		{"more specific blocked directory",
+			PathPolicy{BlockedDirs: []string{"/srv/audio/private"}, AllowedDirs: []string{"/srv/audio"}},
+			"/srv/audio/private/out.mp3", "system directory not allowed"},
+		{"directory allowlist", PathPolicy{AllowedDirs: []string{"/srv/audio"}}, "/tmp/out.mp3",
+			"outside the allowed directories"},
+		{"empty policy", PathPolicy{}, "/etc/out.exe", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.path)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrPathNotAllowed)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPathPolicy_CheckRelative(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	previous, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(previous) })
+
+	policy := PathPolicy{AllowedDirs: []string{"audio"}}
+
+	assert.NoError(t, policy.Check(filepath.Join("audio", "out.mp3")))
+	assert.NoError(t, policy.Check(filepath.Join(dir, "audio", "out.mp3")))
+	assert.Error(t, policy.Check("out.mp3"))
+}
+
+func TestFileHandler_PrepareOverwrite_PathPolicy(t *testing.T) {
+	dir := t.TempDir()
+	handler := NewFileHandlerWithOptions(dir, false, OverwriteAlways)
+
+	_, err := handler.PrepareOverwrite(filepath.Join(dir, "out.js"))
+	assert.ErrorIs(t, err, ErrPathNotAllowed)
+
+	handler.SetPathPolicy(PathPolicy{AllowedExtensions: []string{".js"}})
+	_, err = handler.PrepareOverwrite(filepath.Join(dir, "out.js"))
+	assert.NoError(t, err)
+
+	info, err := handler.WriteFile("meta.js", []byte("{}"))
+	assert.NoError(t, err)
+	assert.FileExists(t, info.Path)
+	_, err = os.Stat(filepath.Join(dir, "meta.js"))
+	assert.NoError(t, err)
+}
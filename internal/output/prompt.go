@@ -0,0 +1,104 @@
+package output
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrOverwriteDeclined reports that the user chose to keep an existing file
+var ErrOverwriteDeclined = errors.New("file already exists and was kept")
+
+// OverwriteAnswer is a reply to an overwrite confirmation
+type OverwriteAnswer int
+
+const (
+	AnswerNo       OverwriteAnswer = iota // Keep this file
+	AnswerYes                             // Overwrite this file
+	AnswerNoToAll                         // Keep this and every later file
+	AnswerYesToAll                        // Overwrite this and every later file
+)
+
+// Prompter asks whether to overwrite the existing file at path
+type Prompter func(path string) (OverwriteAnswer, error)
+
+// NewTerminalPrompter returns a prompter asking on out and reading the
+// answer from in, asking again until the answer is understood. An empty
+// answer keeps the file; the end of input keeps it and every later one.
+func NewTerminalPrompter(in io.Reader, out io.Writer) Prompter {
+	reader := bufio.NewReader(in)
+	return func(path string) (OverwriteAnswer, error) {
+		for {
+			fmt.Fprintf(out, "Overwrite %s? [y]es, [N]o, [a]ll, [d]on't overwrite any: ", path)
+			line, err := reader.ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return AnswerNo, fmt.Errorf("failed to read answer: %w", err)
+			}
+			if errors.Is(err, io.EOF) && line == "" {
+				fmt.Fprintln(out)
+				return AnswerNoToAll, nil
+			}
+
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "", "n", "no":
+				return AnswerNo, nil
+			case "y", "yes":
+				return AnswerYes, nil
+			case "a", "all":
+				return AnswerYesToAll, nil
+			case "d", "none":
+				return AnswerNoToAll, nil
+			}
+			if errors.Is(err, io.EOF) {
+				return AnswerNoToAll, nil
+			}
+		}
+	}
+}
+
+// ParseOverwriteMode parses an overwrite mode as named in the configuration
+func ParseOverwriteMode(s string) (OverwriteMode, error) {
+	switch strings.ToLower(s) {
+	case "never":
+		return OverwriteNever, nil
+	case "always":
+		return OverwriteAlways, nil
+	case "prompt":
+		return OverwritePrompt, nil
+	case "", "backup":
+		return OverwriteBackup, nil
+	default:
+		return OverwriteNever, fmt.Errorf("unknown overwrite mode %q (expected never, always, prompt or backup)", s)
+	}
+}
+
+// confirmOverwrite resolves OverwritePrompt for path to the mode to apply,
+// asking the prompter unless an earlier answer covers every file
+func (h *FileHandler) confirmOverwrite(path string) (OverwriteMode, error) {
+	if h.prompter == nil {
+		if h.promptFallback == OverwritePrompt {
+			return OverwriteNever, nil
+		}
+		return h.promptFallback, nil
+	}
+
+	answer := h.answerAll
+	if !h.answeredAll {
+		var err error
+		if answer, err = h.prompter(path); err != nil {
+			return OverwriteNever, err
+		}
+	}
+
+	switch answer {
+	case AnswerYesToAll, AnswerNoToAll:
+		h.answerAll = answer
+		h.answeredAll = true
+	}
+	if answer == AnswerYes || answer == AnswerYesToAll {
+		return OverwriteAlways, nil
+	}
+	return OverwriteNever, ErrOverwriteDeclined
+}
@@ -0,0 +1,166 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTerminalPrompter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  OverwriteAnswer
+	}{
+		{"yes", "y\n", AnswerYes},
+		{"no", "no\n", AnswerNo},
+		{"default", "\n", AnswerNo},
+		{"all", "A\n", AnswerYesToAll},
+		{"none", "d\n", AnswerNoToAll},
+		{"asks again", "maybe\ny\n", AnswerYes},
+		{"end of input", "", AnswerNoToAll},
+		{"answer without newline", "y", AnswerYes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			answer, err := NewTerminalPrompter(strings.NewReader(tt.input), &out)("out.mp3")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, answer)
+			assert.Contains(t, out.String(), "Overwrite out.mp3?")
+		})
+	}
+}
+
+func TestParseOverwriteMode(t *testing.T) {
+	for name, want := range map[string]OverwriteMode{
+		"never": OverwriteNever, "always": OverwriteAlways, "prompt": OverwritePrompt,
+		"backup": OverwriteBackup, "": OverwriteBackup, "BACKUP": OverwriteBackup,
+	} {
+		mode, err := ParseOverwriteMode(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, mode, name)
+	}
+
+	_, err := ParseOverwriteMode("sometimes")
+	assert.Error(t, err)
+}
+
+// writeExisting creates the named files in a temporary directory
+func writeExisting(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(paths[i], []byte("old"), 0600))
+	}
+	return paths
+}
+
+func TestFileHandler_OverwritePrompt(t *testing.T) {
+	paths := writeExisting(t, "a.mp3", "b.mp3", "c.mp3")
+
+	var asked []string
+	answers := []OverwriteAnswer{AnswerNo, AnswerYesToAll}
+	handler := NewFileHandlerWithOptions(".", false, OverwritePrompt)
+	handler.SetPrompter(func(path string) (OverwriteAnswer, error) {
+		asked = append(asked, path)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}, OverwriteNever)
+
+	_, err := handler.PrepareOverwrite(paths[0])
+	assert.ErrorIs(t, err, ErrOverwriteDeclined)
+
+	info, err := handler.PrepareOverwrite(paths[1])
+	require.NoError(t, err)
+	assert.True(t, info.Overwritten)
+
+	// "yes to all" covers later files without asking
+	info, err = handler.PrepareOverwrite(paths[2])
+	require.NoError(t, err)
+	assert.True(t, info.Overwritten)
+	assert.Equal(t, paths[:2], asked)
+
+	// A missing file needs no answer
+	_, err = handler.PrepareOverwrite(filepath.Join(t.TempDir(), "new.mp3"))
+	require.NoError(t, err)
+	assert.Len(t, asked, 2)
+}
+
+func TestFileHandler_OverwritePromptNoToAll(t *testing.T) {
+	paths := writeExisting(t, "a.mp3", "b.mp3")
+
+	calls := 0
+	handler := NewFileHandlerWithOptions(".", false, OverwritePrompt)
+	handler.SetPrompter(func(string) (OverwriteAnswer, error) {
+		calls++
+		return AnswerNoToAll, nil
+	}, OverwriteNever)
+
+	for _, path := range paths {
+		_, err := handler.PrepareOverwrite(path)
+		assert.ErrorIs(t, err, ErrOverwriteDeclined)
+	}
+	assert.Equal(t, 1, calls)
+
+	// The write fails and leaves the file alone
+	_, err := handler.WriteFile(paths[0], []byte("new"))
+	assert.ErrorIs(t, err, ErrOverwriteDeclined)
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(data))
+}
+
+func TestFileHandler_OverwritePromptError(t *testing.T) {
+	paths := writeExisting(t, "a.mp3")
+
+	handler := NewFileHandlerWithOptions(".", false, OverwritePrompt)
+	handler.SetPrompter(func(string) (OverwriteAnswer, error) {
+		return AnswerNo, errors.New("terminal closed")
+	}, OverwriteAlways)
+
+	_, err := handler.PrepareOverwrite(paths[0])
+	assert.ErrorContains(t, err, "terminal closed")
+}
+
+func TestFileHandler_OverwritePromptFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		fallback OverwriteMode
+		wantErr  bool
+		backup   bool
+	}{
+		{"never", OverwriteNever, true, false},
+		{"always", OverwriteAlways, false, false},
+		{"backup", OverwriteBackup, false, true},
+		{"prompt", OverwritePrompt, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeExisting(t, "a.mp3")[0]
+			handler := NewFileHandlerWithOptions(".", false, OverwritePrompt)
+			handler.SetPrompter(nil, tt.fallback)
+
+			info, err := handler.PrepareOverwrite(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrOverwriteDeclined)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, info.Overwritten)
+			assert.Equal(t, tt.backup, info.BackupPath != "")
+		})
+	}
+}
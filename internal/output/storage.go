@@ -0,0 +1,88 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Storage schemes of remote output destinations
+const (
+	SchemeGCS = "gs"
+	SchemeS3  = "s3"
+)
+
+// DefaultChunkSize is the size of the pieces large files are uploaded in
+const DefaultChunkSize = 8 << 20
+
+// Storage uploads output files to a remote destination
+type Storage interface {
+	// Upload writes data as the object at dest, replacing any existing one.
+	// Files larger than the chunk size are uploaded in pieces.
+	Upload(ctx context.Context, dest StorageURL, data []byte, contentType string) error
+}
+
+// StorageURL names an object in a bucket, e.g. gs://bucket/path/file.mp3
+type StorageURL struct {
+	Scheme string
+	Bucket string
+	Key    string
+}
+
+// String formats the URL as scheme://bucket/key
+func (u StorageURL) String() string {
+	return u.Scheme + "://" + u.Bucket + "/" + u.Key
+}
+
+// WithSuffix returns the URL of the object whose key adds suffix to this one
+func (u StorageURL) WithSuffix(suffix string) StorageURL {
+	u.Key += suffix
+	return u
+}
+
+// IsRemotePath reports whether path names a cloud storage object rather
+// than a local file
+func IsRemotePath(path string) bool {
+	return strings.HasPrefix(path, SchemeGCS+"://") || strings.HasPrefix(path, SchemeS3+"://")
+}
+
+// ParseStorageURL parses a gs:// or s3:// URL naming an object
+func ParseStorageURL(raw string) (StorageURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return StorageURL{}, fmt.Errorf("invalid storage URL %q: %w", raw, err)
+	}
+	if u.Scheme != SchemeGCS && u.Scheme != SchemeS3 {
+		return StorageURL{}, fmt.Errorf("invalid storage URL %q: expected gs:// or s3://", raw)
+	}
+	if u.Host == "" {
+		return StorageURL{}, fmt.Errorf("invalid storage URL %q: missing bucket", raw)
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		return StorageURL{}, fmt.Errorf("invalid storage URL %q: missing object name", raw)
+	}
+	if cleaned := path.Clean(key); cleaned != key {
+		return StorageURL{}, fmt.Errorf("invalid storage URL %q: object name must not contain . or .. segments", raw)
+	}
+	return StorageURL{Scheme: u.Scheme, Bucket: u.Host, Key: key}, nil
+}
+
+// ContentType returns the media type an uploaded file is stored with
+func ContentType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".ogg", ".opus":
+		return "audio/ogg"
+	case ".wav":
+		return "audio/wav"
+	case ".json":
+		return "application/json"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,184 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GCSScope is the OAuth2 scope needed to upload to Cloud Storage
+const GCSScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// DefaultGCSEndpoint is the Cloud Storage JSON API upload endpoint
+const DefaultGCSEndpoint = "https://storage.googleapis.com/upload/storage/v1"
+
+// gcsChunkAlignment is the multiple every chunk but the last must be sized in
+const gcsChunkAlignment = 256 << 10
+
+// gcsMaxStalledChunks is how many chunks in a row may be sent without the
+// server persisting more of the file before the upload is given up
+const gcsMaxStalledChunks = 3
+
+// GCSStorage uploads to Google Cloud Storage with the JSON API. Files larger
+// than the chunk size use a resumable upload session, sent one chunk at a
+// time.
+type GCSStorage struct {
+	client    *http.Client
+	endpoint  string
+	chunkSize int
+}
+
+// NewGCSStorage creates a Cloud Storage backend. The client must authorize
+// requests, e.g. one from the auth package's HTTPClient with GCSScope. An
+// empty endpoint uses DefaultGCSEndpoint; a chunkSize that is not positive
+// uses DefaultChunkSize.
+func NewGCSStorage(client *http.Client, endpoint string, chunkSize int) *GCSStorage {
+	if endpoint == "" {
+		endpoint = DefaultGCSEndpoint
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	// Cloud Storage rejects chunks that are not a multiple of 256 KiB
+	if chunkSize%gcsChunkAlignment != 0 {
+		chunkSize += gcsChunkAlignment - chunkSize%gcsChunkAlignment
+	}
+	return &GCSStorage{client: client, endpoint: strings.TrimSuffix(endpoint, "/"), chunkSize: chunkSize}
+}
+
+// Upload writes data as the object at dest
+func (s *GCSStorage) Upload(ctx context.Context, dest StorageURL, data []byte, contentType string) error {
+	var err error
+	if len(data) <= s.chunkSize {
+		err = s.uploadSimple(ctx, dest, data, contentType)
+	} else {
+		err = s.uploadResumable(ctx, dest, data, contentType)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", dest, err)
+	}
+	return nil
+}
+
+// objectURL returns the upload URL for dest with the given upload type
+func (s *GCSStorage) objectURL(dest StorageURL, uploadType string) string {
+	query := url.Values{"uploadType": {uploadType}, "name": {dest.Key}}
+	return s.endpoint + "/b/" + url.PathEscape(dest.Bucket) + "/o?" + query.Encode()
+}
+
+// uploadSimple sends data in a single request
+func (s *GCSStorage) uploadSimple(ctx context.Context, dest StorageURL, data []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.objectURL(dest, "media"), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return gcsResponseError(resp, http.StatusOK)
+}
+
+// uploadResumable starts a resumable upload session and sends data to it in
+// chunks
+func (s *GCSStorage) uploadResumable(ctx context.Context, dest StorageURL, data []byte, contentType string) error {
+	metadata, err := json.Marshal(map[string]string{"name": dest.Key, "contentType": contentType})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.objectURL(dest, "resumable"), bytes.NewReader(metadata))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", contentType)
+	req.Header.Set("X-Upload-Content-Length", fmt.Sprint(len(data)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if err := gcsResponseError(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("failed to start resumable upload: %w", err)
+	}
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return fmt.Errorf("failed to start resumable upload: no session URL returned")
+	}
+
+	stalled := 0
+	for offset := 0; offset < len(data); {
+		end := min(offset+s.chunkSize, len(data))
+		next, err := s.uploadChunk(ctx, session, data[offset:end], offset, len(data))
+		if err != nil {
+			return err
+		}
+		if next <= offset {
+			if stalled++; stalled >= gcsMaxStalledChunks {
+				return fmt.Errorf("resumable upload stalled at byte %d of %d", next, len(data))
+			}
+		} else {
+			stalled = 0
+		}
+		offset = next
+	}
+	return nil
+}
+
+// uploadChunk sends chunk, which starts at offset of a file of total bytes,
+// to the session and returns the offset the server expects next
+func (s *GCSStorage) uploadChunk(ctx context.Context, session string, chunk []byte, offset, total int) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, session, bytes.NewReader(chunk))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+len(chunk)-1, total))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return total, nil
+	case http.StatusPermanentRedirect:
+		// The Range header reports the bytes persisted so far, which may be
+		// fewer than were sent; without it none were
+		var last int
+		if _, err := fmt.Sscanf(resp.Header.Get("Range"), "bytes=0-%d", &last); err != nil {
+			return 0, nil
+		}
+		return last + 1, nil
+	default:
+		return 0, gcsResponseError(resp, http.StatusOK)
+	}
+}
+
+// gcsResponseError returns an error describing resp unless it has the
+// expected status
+func gcsResponseError(resp *http.Response, expected int) error {
+	if resp.StatusCode == expected {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		return fmt.Errorf("%s: %s", resp.Status, apiErr.Error.Message)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
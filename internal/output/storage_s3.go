@@ -0,0 +1,349 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3MinPartSize is the smallest part S3 accepts in a multipart upload, other
+// than the last one
+const s3MinPartSize = 5 << 20
+
+// S3Config holds the credentials and location of an S3 compatible service
+type S3Config struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	// Endpoint overrides the AWS endpoint, e.g. for MinIO. Buckets on a
+	// custom endpoint are addressed path-style.
+	Endpoint string
+}
+
+// S3ConfigFromEnv reads S3 credentials from the standard AWS environment
+// variables
+func S3ConfigFromEnv() S3Config {
+	cfg := S3Config{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Region:          os.Getenv("AWS_REGION"),
+		Endpoint:        os.Getenv("AWS_ENDPOINT_URL_S3"),
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	return cfg
+}
+
+// S3Storage uploads to Amazon S3 or a compatible service. Files larger than
+// the chunk size use a multipart upload, which is aborted if a part fails.
+type S3Storage struct {
+	client    *http.Client
+	config    S3Config
+	chunkSize int
+	now       func() time.Time
+}
+
+// NewS3Storage creates an S3 backend sending requests through client. A
+// chunkSize that is not positive uses DefaultChunkSize.
+func NewS3Storage(client *http.Client, cfg S3Config, chunkSize int) (*S3Storage, error) {
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 credentials not found (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	chunkSize = max(chunkSize, s3MinPartSize)
+	return &S3Storage{client: client, config: cfg, chunkSize: chunkSize, now: time.Now}, nil
+}
+
+// Upload writes data as the object at dest
+func (s *S3Storage) Upload(ctx context.Context, dest StorageURL, data []byte, contentType string) error {
+	var err error
+	if len(data) <= s.chunkSize {
+		_, err = s.do(ctx, http.MethodPut, dest, nil, data, map[string]string{"Content-Type": contentType})
+	} else {
+		err = s.uploadMultipart(ctx, dest, data, contentType)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", dest, err)
+	}
+	return nil
+}
+
+// completedPart is a part listed in a CompleteMultipartUpload request
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadMultipart uploads data in parts of the chunk size
+func (s *S3Storage) uploadMultipart(ctx context.Context, dest StorageURL, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPost, dest, url.Values{"uploads": {""}}, nil,
+		map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp, &initiated); err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("failed to start multipart upload: invalid response")
+	}
+
+	if err := s.uploadParts(ctx, dest, initiated.UploadID, data); err != nil {
+		// Abort so the uploaded parts are not stored (and billed) forever
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		_, _ = s.do(abortCtx, http.MethodDelete, dest, url.Values{"uploadId": {initiated.UploadID}}, nil, nil)
+		return err
+	}
+	return nil
+}
+
+// uploadParts sends the parts of an initiated multipart upload and completes it
+func (s *S3Storage) uploadParts(ctx context.Context, dest StorageURL, uploadID string, data []byte) error {
+	var parts []completedPart
+	for offset, number := 0, 1; offset < len(data); offset, number = offset+s.chunkSize, number+1 {
+		end := min(offset+s.chunkSize, len(data))
+		query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {uploadID}}
+		etag, err := s.doETag(ctx, dest, query, data[offset:end])
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: etag})
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, dest, url.Values{"uploadId": {uploadID}}, body,
+		map[string]string{"Content-Type": "application/xml"})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	// S3 reports some completion failures in a 200 response
+	if bytes.Contains(resp, []byte("<Error>")) {
+		return fmt.Errorf("failed to complete multipart upload: %s", s3ErrorMessage(resp))
+	}
+	return nil
+}
+
+// doETag sends a part upload and returns the ETag of the stored part
+func (s *S3Storage) doETag(ctx context.Context, dest StorageURL, query url.Values, part []byte) (string, error) {
+	req, err := s.newRequest(ctx, http.MethodPut, dest, query, part, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", resp.Status, s3ErrorMessage(body))
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// do sends a signed request and returns the response body
+func (s *S3Storage) do(ctx context.Context, method string, dest StorageURL, query url.Values, body []byte,
+	headers map[string]string) ([]byte, error) {
+	req, err := s.newRequest(ctx, method, dest, query, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, s3ErrorMessage(data))
+	}
+	return data, nil
+}
+
+// newRequest builds a signed request for the object at dest
+func (s *S3Storage) newRequest(ctx context.Context, method string, dest StorageURL, query url.Values, body []byte,
+	headers map[string]string) (*http.Request, error) {
+	target, err := s.objectURL(dest)
+	if err != nil {
+		return nil, err
+	}
+	// S3 expects %20 rather than + for spaces in the query
+	target.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+	signV4(req, body, s.config, s.now())
+	return req, nil
+}
+
+// objectURL returns the URL of the object at dest
+func (s *S3Storage) objectURL(dest StorageURL) (*url.URL, error) {
+	key := "/" + s3EscapePath(dest.Key)
+	if s.config.Endpoint == "" {
+		return &url.URL{
+			Scheme:  "https",
+			Host:    fmt.Sprintf("%s.s3.%s.amazonaws.com", dest.Bucket, s.config.Region),
+			Path:    "/" + dest.Key,
+			RawPath: key,
+		}, nil
+	}
+
+	endpoint, err := url.Parse(s.config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", s.config.Endpoint)
+	}
+	base := strings.TrimSuffix(endpoint.Path, "/")
+	endpoint.Path = base + "/" + dest.Bucket + "/" + dest.Key
+	endpoint.RawPath = base + "/" + s3EscapePath(dest.Bucket) + key
+	return endpoint, nil
+}
+
+// s3ErrorMessage extracts the message from an S3 XML error response
+func s3ErrorMessage(body []byte) string {
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+		return s3Err.Code + ": " + s3Err.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header to req, signing
+// the host and every header already set
+func signV4(req *http.Request, body []byte, cfg S3Config, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query sorted by name with RFC 3986 escaping
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3EscapePath escapes each segment of an object key
+func s3EscapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape percent-encodes everything but the RFC 3986 unreserved characters
+func s3Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,379 @@
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// titleLength is the number of input characters used for the title tag
+const titleLength = 80
+
+// markupPattern matches SSML elements, which are dropped from titles
+var markupPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Tags is the metadata embedded in generated audio files
+type Tags struct {
+	// Title is an excerpt of the start of the input text
+	Title string
+	// Artist is the voice that spoke the text
+	Artist string
+	// Language is the language code of the voice, e.g. "en-US"
+	Language string
+	// Date is the time of synthesis in ISO 8601 format
+	Date string
+	// SourceHash is the hex SHA-256 digest of the input text, identifying
+	// the text an audio file was generated from
+	SourceHash string
+}
+
+// NewTags collects the metadata for audio synthesized from text
+func NewTags(text, voice, language string, now time.Time) Tags {
+	title := []rune(strings.Join(strings.Fields(markupPattern.ReplaceAllString(text, " ")), " "))
+	if len(title) > titleLength {
+		title = append(title[:titleLength-3], []rune("...")...)
+	}
+
+	sum := sha256.Sum256([]byte(text))
+
+	return Tags{
+		Title:      string(title),
+		Artist:     voice,
+		Language:   language,
+		Date:       now.UTC().Format("2006-01-02T15:04:05"),
+		SourceHash: hex.EncodeToString(sum[:]),
+	}
+}
+
+// SupportsTags reports whether WriteTags can embed metadata in format
+func SupportsTags(format string) bool {
+	switch strings.ToUpper(format) {
+	case "MP3", "OGG_OPUS", "OGG":
+		return true
+	default:
+		return false
+	}
+}
+
+// WriteTags embeds tags in encoded audio: an ID3v2.4 tag for MP3, replacing
+// any existing one and giving the language as an ISO 639-2 code, and Vorbis comments in the OpusTags header for
+// OGG_OPUS, replacing existing comments with the same names. Audio in other
+// formats is returned unchanged.
+func WriteTags(data []byte, format string, tags Tags) ([]byte, error) {
+	switch strings.ToUpper(format) {
+	case "MP3":
+		return writeID3(data, tags), nil
+	case "OGG_OPUS", "OGG":
+		return writeOpusTags(data, tags)
+	default:
+		return data, nil
+	}
+}
+
+// Vorbis comment names, also used for the ID3 TXXX source hash frame
+const (
+	commentTitle      = "TITLE"
+	commentArtist     = "ARTIST"
+	commentLanguage   = "LANGUAGE"
+	commentDate       = "DATE"
+	commentSourceHash = "SOURCE_HASH"
+)
+
+// comments returns the non-empty tags as Vorbis comment name/value pairs
+func (t Tags) comments() [][2]string {
+	var comments [][2]string
+	for _, c := range [][2]string{
+		{commentTitle, t.Title},
+		{commentArtist, t.Artist},
+		{commentLanguage, t.Language},
+		{commentDate, t.Date},
+		{commentSourceHash, t.SourceHash},
+	} {
+		if c[1] != "" {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+// ID3v2 layout
+const (
+	id3v2HeaderSize = 10
+	id3v2Version    = 4
+	id3FooterFlag   = 0x10
+	id3EncodingUTF8 = 0x03
+)
+
+// ID3v2Size returns the size of a leading ID3v2 tag, or 0 without one
+func ID3v2Size(data []byte) int {
+	if len(data) < id3v2HeaderSize || string(data[0:3]) != "ID3" {
+		return 0
+	}
+
+	// The tag size is a 28-bit synchsafe integer excluding the header and
+	// optional footer
+	size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+	size += id3v2HeaderSize
+	if data[5]&id3FooterFlag != 0 {
+		size += id3v2HeaderSize
+	}
+	return min(size, len(data))
+}
+
+func writeID3(data []byte, tags Tags) []byte {
+	var frames bytes.Buffer
+	writeID3Frame(&frames, "TIT2", tags.Title)
+	writeID3Frame(&frames, "TPE1", tags.Artist)
+	writeID3Frame(&frames, "TLAN", id3Language(tags.Language))
+	writeID3Frame(&frames, "TDRC", tags.Date)
+	if tags.SourceHash != "" {
+		writeID3Frame(&frames, "TXXX", commentSourceHash+"\x00"+tags.SourceHash)
+	}
+
+	audio := data[ID3v2Size(data):]
+	if frames.Len() == 0 {
+		return append([]byte{}, audio...)
+	}
+
+	tag := make([]byte, 0, id3v2HeaderSize+frames.Len()+len(audio))
+	tag = append(tag, 'I', 'D', '3', id3v2Version, 0, 0)
+	tag = append(tag, synchsafe(frames.Len())...)
+	tag = append(tag, frames.Bytes()...)
+	return append(tag, audio...)
+}
+
+// iso639Languages maps the ISO 639-1 codes of the languages Text-to-Speech
+// offers to ISO 639-2 terminology codes, which ID3 TLAN frames require.
+// ISO 639-3 codes of Chinese languages map to their macrolanguage.
+var iso639Languages = map[string]string{
+	"af": "afr", "am": "amh", "ar": "ara", "bg": "bul", "bn": "ben",
+	"ca": "cat", "cmn": "zho", "cs": "ces", "cy": "cym", "da": "dan",
+	"de": "deu", "el": "ell", "en": "eng", "es": "spa", "et": "est",
+	"eu": "eus", "fi": "fin", "fr": "fra", "ga": "gle", "gl": "glg",
+	"gu": "guj", "he": "heb", "hi": "hin", "hr": "hrv", "hu": "hun",
+	"id": "ind", "is": "isl", "it": "ita", "ja": "jpn", "kn": "kan",
+	"ko": "kor", "lt": "lit", "lv": "lav", "ml": "mal", "mr": "mar",
+	"ms": "msa", "nb": "nob", "nl": "nld", "nn": "nno", "no": "nor",
+	"pa": "pan", "pl": "pol", "pt": "por", "ro": "ron", "ru": "rus",
+	"sk": "slk", "sl": "slv", "sr": "srp", "sv": "swe", "sw": "swa",
+	"ta": "tam", "te": "tel", "th": "tha", "tr": "tur", "uk": "ukr",
+	"ur": "urd", "vi": "vie", "yue": "zho", "zh": "zho",
+}
+
+// id3Language returns the ISO 639-2 code of a BCP 47 language tag such as
+// "en-US", or "" when it is not known. Three-letter primary subtags such as
+// "fil" are already ISO 639-2 codes.
+func id3Language(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	if code, ok := iso639Languages[primary]; ok {
+		return code
+	}
+	if len(primary) == 3 {
+		return primary
+	}
+	return ""
+}
+
+// writeID3Frame writes a UTF-8 text frame, skipping empty values
+func writeID3Frame(buf *bytes.Buffer, id, value string) {
+	if value == "" {
+		return
+	}
+	buf.WriteString(id)
+	buf.Write(synchsafe(1 + len(value)))
+	buf.Write([]byte{0, 0}) // flags
+	buf.WriteByte(id3EncodingUTF8)
+	buf.WriteString(value)
+}
+
+// synchsafe encodes n as a 4-byte ID3v2 synchsafe integer
+func synchsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+// Opus header packet signatures
+const (
+	opusHead = "OpusHead"
+	opusTags = "OpusTags"
+)
+
+// maxOggSegments is the number of lacing values that fit in one Ogg page
+const maxOggSegments = 255
+
+// writeOpusTags replaces the comment header of an Ogg Opus stream. The
+// comment header is the second packet and, as RFC 7845 requires, occupies
+// its own pages, so only those pages are rewritten; later pages are
+// renumbered if the header now needs a different number of pages.
+func writeOpusTags(data []byte, tags Tags) ([]byte, error) {
+	pages, err := OggPages(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) < 2 || !bytes.HasPrefix(oggBody(pages[0]), []byte(opusHead)) {
+		return nil, fmt.Errorf("not an Ogg Opus stream")
+	}
+
+	// Collect the comment packet from the pages following the ID header
+	var packet []byte
+	end := 1
+	for ; end < len(pages); end++ {
+		page := pages[end]
+		if (page[oggHeaderType]&oggContinued != 0) != (end > 1) {
+			return nil, fmt.Errorf("unexpected Ogg page layout in Opus header")
+		}
+		packet = append(packet, oggBody(page)...)
+
+		// The packet may only end with the last segment of a page
+		lacing := page[oggHeaderSize : oggHeaderSize+int(page[oggSegments])]
+		if len(lacing) == 0 || bytes.Count(lacing[:len(lacing)-1], []byte{255}) != len(lacing)-1 {
+			return nil, fmt.Errorf("unexpected Ogg page layout in Opus header")
+		}
+		if lacing[len(lacing)-1] < 255 {
+			break
+		}
+	}
+	if end == len(pages) {
+		return nil, fmt.Errorf("truncated Opus comment header")
+	}
+
+	comments, err := updateOpusComments(packet, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	first := pages[1]
+	sequence := binary.LittleEndian.Uint32(first[oggSequenceOffset:])
+	header := oggPaginate(first, comments, sequence)
+	shift := uint32(len(header) - end)
+
+	joined := append([]byte{}, pages[0]...)
+	for _, page := range header {
+		joined = append(joined, page...)
+	}
+	for _, page := range pages[end+1:] {
+		if shift != 0 {
+			page = append([]byte{}, page...)
+			binary.LittleEndian.PutUint32(page[oggSequenceOffset:],
+				binary.LittleEndian.Uint32(page[oggSequenceOffset:])+shift)
+			SetOggChecksum(page)
+		}
+		joined = append(joined, page...)
+	}
+	return joined, nil
+}
+
+// updateOpusComments returns an OpusTags packet with tags set, keeping the
+// vendor string, other comments and any trailing binary data
+func updateOpusComments(packet []byte, tags Tags) ([]byte, error) {
+	invalid := fmt.Errorf("invalid Opus comment header")
+	if !bytes.HasPrefix(packet, []byte(opusTags)) {
+		return nil, invalid
+	}
+	rest := packet[len(opusTags):]
+
+	readField := func() ([]byte, bool) {
+		if len(rest) < 4 {
+			return nil, false
+		}
+		size := binary.LittleEndian.Uint32(rest)
+		if uint64(size) > uint64(len(rest)-4) {
+			return nil, false
+		}
+		field := rest[4 : 4+size]
+		rest = rest[4+size:]
+		return field, true
+	}
+
+	vendor, ok := readField()
+	if !ok || len(rest) < 4 {
+		return nil, invalid
+	}
+	count := binary.LittleEndian.Uint32(rest)
+	rest = rest[4:]
+
+	replaced := make(map[string]bool)
+	for _, c := range tags.comments() {
+		replaced[c[0]] = true
+	}
+
+	var kept [][]byte
+	for range count {
+		comment, ok := readField()
+		if !ok {
+			return nil, invalid
+		}
+		name, _, _ := strings.Cut(string(comment), "=")
+		if !replaced[strings.ToUpper(name)] {
+			kept = append(kept, comment)
+		}
+	}
+	for _, c := range tags.comments() {
+		kept = append(kept, []byte(c[0]+"="+c[1]))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(opusTags)
+	writeVorbisField(&buf, vendor)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(kept)))
+	for _, comment := range kept {
+		writeVorbisField(&buf, comment)
+	}
+	buf.Write(rest)
+	return buf.Bytes(), nil
+}
+
+func writeVorbisField(buf *bytes.Buffer, field []byte) {
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(field)))
+	buf.Write(field)
+}
+
+// oggPaginate splits a packet into pages with the stream settings of
+// template, numbered from sequence
+func oggPaginate(template, packet []byte, sequence uint32) [][]byte {
+	lacing := bytes.Repeat([]byte{255}, len(packet)/255)
+	lacing = append(lacing, byte(len(packet)%255))
+
+	var pages [][]byte
+	for len(lacing) > 0 {
+		segments := lacing[:min(maxOggSegments, len(lacing))]
+		lacing = lacing[len(segments):]
+
+		size := 0
+		for _, l := range segments {
+			size += int(l)
+		}
+
+		page := make([]byte, oggHeaderSize, oggHeaderSize+len(segments)+size)
+		copy(page, template[:oggHeaderSize])
+		page[oggHeaderType] = 0
+		if len(pages) > 0 {
+			page[oggHeaderType] = oggContinued
+		}
+		// Header pages have granule position 0, or -1 when no packet ends
+		// on the page
+		granule := uint64(0)
+		if len(lacing) > 0 {
+			granule = ^uint64(0)
+		}
+		binary.LittleEndian.PutUint64(page[oggGranuleOffset:], granule)
+		binary.LittleEndian.PutUint32(page[oggSequenceOffset:], sequence+uint32(len(pages)))
+		page[oggSegments] = byte(len(segments))
+		page = append(page, segments...)
+		page = append(page, packet[:size]...)
+		packet = packet[size:]
+
+		SetOggChecksum(page)
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// oggBody returns the payload of an Ogg page
+func oggBody(page []byte) []byte {
+	return page[oggHeaderSize+int(page[oggSegments]):]
+}
@@ -0,0 +1,204 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTags = Tags{
+	Title:      "Hello world",
+	Artist:     "en-US-Wavenet-D",
+	Language:   "en-US",
+	Date:       "2024-03-09T14:05:30",
+	SourceHash: "abc123",
+}
+
+func TestNewTags(t *testing.T) {
+	now := time.Date(2024, 3, 9, 14, 5, 30, 0, time.UTC)
+	tags := NewTags("<speak>Hello\n  <break time=\"1s\"/>world</speak>", "en-US-Wavenet-D", "en-US", now)
+
+	assert.Equal(t, "Hello world", tags.Title)
+	assert.Equal(t, "en-US-Wavenet-D", tags.Artist)
+	assert.Equal(t, "2024-03-09T14:05:30", tags.Date)
+	assert.Len(t, tags.SourceHash, 64)
+
+	long := NewTags(strings.Repeat("word ", 40), "", "", now)
+	assert.Len(t, []rune(long.Title), titleLength)
+	assert.True(t, strings.HasSuffix(long.Title, "..."))
+}
+
+// id3Frames parses the text frames of an ID3v2.4 tag
+func id3Frames(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	require.Equal(t, "ID3", string(data[0:3]))
+	end := ID3v2Size(data)
+
+	frames := make(map[string]string)
+	for offset := id3v2HeaderSize; offset+10 <= end; {
+		size := int(data[offset+4])<<21 | int(data[offset+5])<<14 | int(data[offset+6])<<7 | int(data[offset+7])
+		body := data[offset+10 : offset+10+size]
+		require.Equal(t, byte(id3EncodingUTF8), body[0])
+		frames[string(data[offset:offset+4])] = string(body[1:])
+		offset += 10 + size
+	}
+	return frames
+}
+
+func TestWriteTagsMP3(t *testing.T) {
+	audio := []byte{0xFF, 0xFB, 0x90, 0x00, 1, 2, 3}
+
+	tagged, err := WriteTags(audio, "MP3", testTags)
+	require.NoError(t, err)
+	assert.Equal(t, audio, tagged[ID3v2Size(tagged):])
+
+	frames := id3Frames(t, tagged)
+	assert.Equal(t, "Hello world", frames["TIT2"])
+	assert.Equal(t, "en-US-Wavenet-D", frames["TPE1"])
+	assert.Equal(t, "eng", frames["TLAN"])
+	assert.Equal(t, "2024-03-09T14:05:30", frames["TDRC"])
+	assert.Equal(t, "SOURCE_HASH\x00abc123", frames["TXXX"])
+
+	// Tagging again replaces the existing tag
+	retagged, err := WriteTags(tagged, "mp3", Tags{Title: "Other"})
+	require.NoError(t, err)
+	assert.Equal(t, audio, retagged[ID3v2Size(retagged):])
+	assert.Equal(t, map[string]string{"TIT2": "Other"}, id3Frames(t, retagged))
+}
+
+func TestID3Language(t *testing.T) {
+	for tag, want := range map[string]string{
+		"en-US":  "eng",
+		"de-DE":  "deu",
+		"pt-BR":  "por",
+		"cmn-CN": "zho",
+		"fil-PH": "fil",
+		"EN":     "eng",
+		"xx-YY":  "",
+		"":       "",
+	} {
+		assert.Equal(t, want, id3Language(tag), tag)
+	}
+}
+
+func TestWriteTagsUnsupportedFormat(t *testing.T) {
+	wav := WAVHeader(0, 8000, 1)
+
+	tagged, err := WriteTags(wav, "LINEAR16", testTags)
+	require.NoError(t, err)
+	assert.Equal(t, wav, tagged)
+	assert.False(t, SupportsTags("LINEAR16"))
+	assert.True(t, SupportsTags("ogg_opus"))
+}
+
+// oggTemplate returns a page header for a stream with the given serial
+func oggTemplate(serial uint32) []byte {
+	template := make([]byte, oggHeaderSize)
+	copy(template, "OggS")
+	binary.LittleEndian.PutUint32(template[oggSerialOffset:], serial)
+	return template
+}
+
+// opusStream builds an Ogg Opus stream with an ID header, a comment header
+// holding comments, and one audio page
+func opusStream(comments ...string) []byte {
+	template := oggTemplate(42)
+
+	var tags bytes.Buffer
+	tags.WriteString(opusTags)
+	writeVorbisField(&tags, []byte("test encoder"))
+	_ = binary.Write(&tags, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		writeVorbisField(&tags, []byte(c))
+	}
+
+	stream := oggPaginate(template, []byte(opusHead+"\x01\x01"), 0)[0]
+	for _, page := range oggPaginate(template, tags.Bytes(), 1) {
+		stream = append(stream, page...)
+	}
+	return append(stream, oggPaginate(template, []byte("audio"), 2)[0]...)
+}
+
+// opusComments returns the comments of an Ogg Opus stream's comment header
+func opusComments(t *testing.T, data []byte) ([]string, [][]byte) {
+	t.Helper()
+	pages, err := OggPages(data)
+	require.NoError(t, err)
+
+	var packet []byte
+	for _, page := range pages[1 : len(pages)-1] {
+		packet = append(packet, oggBody(page)...)
+	}
+	require.True(t, bytes.HasPrefix(packet, []byte(opusTags)))
+
+	rest := packet[len(opusTags):]
+	vendorLength := binary.LittleEndian.Uint32(rest)
+	rest = rest[4+vendorLength:]
+	count := binary.LittleEndian.Uint32(rest)
+	rest = rest[4:]
+
+	var comments []string
+	for range count {
+		size := binary.LittleEndian.Uint32(rest)
+		comments = append(comments, string(rest[4:4+size]))
+		rest = rest[4+size:]
+	}
+	return comments, pages
+}
+
+func TestWriteTagsOpus(t *testing.T) {
+	stream := opusStream("ENCODER=test", "title=Old title")
+
+	tagged, err := WriteTags(stream, "OGG_OPUS", testTags)
+	require.NoError(t, err)
+
+	comments, pages := opusComments(t, tagged)
+	assert.Equal(t, []string{
+		"ENCODER=test",
+		"TITLE=Hello world",
+		"ARTIST=en-US-Wavenet-D",
+		"LANGUAGE=en-US",
+		"DATE=2024-03-09T14:05:30",
+		"SOURCE_HASH=abc123",
+	}, comments)
+
+	// Checksums stay valid and the audio page is untouched
+	for _, page := range pages {
+		check := append([]byte{}, page...)
+		SetOggChecksum(check)
+		assert.Equal(t, page, check)
+	}
+	assert.Equal(t, "audio", string(oggBody(pages[len(pages)-1])))
+}
+
+func TestWriteTagsOpusRenumbersPages(t *testing.T) {
+	// A title longer than one page makes the comment header span two pages
+	long := Tags{Title: strings.Repeat("x", maxOggSegments*255)}
+
+	tagged, err := WriteTags(opusStream(), "OGG_OPUS", long)
+	require.NoError(t, err)
+
+	comments, pages := opusComments(t, tagged)
+	require.Len(t, pages, 4)
+	assert.Equal(t, []string{"TITLE=" + long.Title}, comments)
+	assert.Equal(t, byte(oggContinued), pages[2][oggHeaderType])
+	for i, page := range pages {
+		assert.Equal(t, uint32(i), binary.LittleEndian.Uint32(page[oggSequenceOffset:]))
+	}
+}
+
+func TestWriteTagsOpusErrors(t *testing.T) {
+	_, err := WriteTags([]byte("fake audio"), "OGG_OPUS", testTags)
+	assert.Error(t, err)
+
+	template := oggTemplate(1)
+	notOpus := oggPaginate(template, []byte("\x01vorbis"), 0)[0]
+	notOpus = append(notOpus, oggPaginate(template, []byte("\x03vorbis"), 1)[0]...)
+	_, err = WriteTags(notOpus, "OGG_OPUS", testTags)
+	assert.ErrorContains(t, err, "not an Ogg Opus stream")
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Built-in filename templates used when output.filename_template is unset
+const (
+	DefaultFilenameTemplate = "output.{{.Ext}}"
+	AutoFilenameTemplate    = "{{.Text}}.{{.Ext}}"
+)
+
+// textSlugLength is the number of input characters used for the Text field
+const textSlugLength = 50
+
+// FilenameData is the synthesis metadata available to filename templates
+type FilenameData struct {
+	// Text is a filename-safe excerpt of the start of the input text
+	Text string
+	// Voice and Language are the requested voice name and language code
+	Voice    string
+	Language string
+	// Format is the audio format and Ext its file extension, without a dot
+	Format string
+	Ext    string
+	// Date (2006-01-02), Time (150405) and Timestamp (Unix seconds) give the
+	// time of synthesis
+	Date      string
+	Time      string
+	Timestamp int64
+	// Hash is a short digest of the text and voice settings, stable across runs
+	Hash string
+}
+
+// NewFilenameData collects the template fields for a synthesis request
+func NewFilenameData(text, voice, language, format, ext string, now time.Time) FilenameData {
+	excerpt := []rune(text)
+	excerpt = excerpt[:min(textSlugLength, len(excerpt))]
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{text, voice, language, format}, "\x00")))
+
+	return FilenameData{
+		Text:      GetSafeFilename(string(excerpt), ""),
+		Voice:     voice,
+		Language:  language,
+		Format:    format,
+		Ext:       ext,
+		Date:      now.Format("2006-01-02"),
+		Time:      now.Format("150405"),
+		Timestamp: now.Unix(),
+		Hash:      hex.EncodeToString(sum[:])[:12],
+	}
+}
+
+// FilenameTemplate renders output file names from synthesis metadata
+type FilenameTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseFilenameTemplate parses a text/template filename pattern such as
+// "{{.Date}}_{{.Voice}}_{{.Hash}}.{{.Ext}}". Templates may contain "/" to
+// place files in subdirectories.
+func ParseFilenameTemplate(pattern string) (*FilenameTemplate, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("filename template is empty")
+	}
+
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filename template: %w", err)
+	}
+
+	// Render sample data so unknown fields are reported up front
+	ft := &FilenameTemplate{tmpl: tmpl}
+	sample := NewFilenameData("sample", "en-US-Wavenet-D", "en-US", "MP3", "mp3", time.Now())
+	if _, err := ft.Render(sample, 0); err != nil {
+		return nil, err
+	}
+	return ft, nil
+}
+
+// Render executes the template and makes the result safe to use as a
+// relative path: each path element is sanitized like GetSafeFilename and
+// "." or ".." elements are dropped. The final element, excluding its
+// extension, is cut to maxLength characters when maxLength is positive.
+func (t *FilenameTemplate) Render(data FilenameData, maxLength int) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render filename template: %w", err)
+	}
+
+	var elements []string
+	for _, element := range strings.Split(strings.ReplaceAll(buf.String(), "\\", "/"), "/") {
+		if element == "" || element == "." || element == ".." {
+			continue
+		}
+		ext := path.Ext(element)
+		elements = append(elements, GetSafeFilename(strings.TrimSuffix(element, ext), "")+sanitizeExt(ext))
+	}
+	if len(elements) == 0 {
+		return "", fmt.Errorf("filename template rendered an empty name")
+	}
+
+	last := elements[len(elements)-1]
+	ext := path.Ext(last)
+	if base := strings.TrimSuffix(last, ext); maxLength > 0 && len(base) > maxLength {
+		elements[len(elements)-1] = base[:maxLength] + ext
+	}
+	return path.Join(elements...), nil
+}
+
+// sanitizeExt keeps only the alphanumeric characters of an extension
+func sanitizeExt(ext string) string {
+	clean := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, ext)
+	if clean == "" {
+		return ""
+	}
+	return "." + clean
+}
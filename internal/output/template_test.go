@@ -0,0 +1,73 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var templateTime = time.Date(2024, 3, 9, 14, 5, 30, 0, time.UTC)
+
+func TestNewFilenameData(t *testing.T) {
+	data := NewFilenameData("Hello, world!", "en-US-Wavenet-D", "en-US", "MP3", "mp3", templateTime)
+
+	assert.Equal(t, "Hello_world", data.Text)
+	assert.Equal(t, "en-US-Wavenet-D", data.Voice)
+	assert.Equal(t, "2024-03-09", data.Date)
+	assert.Equal(t, "140530", data.Time)
+	assert.Equal(t, templateTime.Unix(), data.Timestamp)
+	assert.Len(t, data.Hash, 12)
+
+	again := NewFilenameData("Hello, world!", "en-US-Wavenet-D", "en-US", "MP3", "mp3", time.Now())
+	assert.Equal(t, data.Hash, again.Hash, "hash should not depend on the time")
+
+	other := NewFilenameData("Hello, world!", "en-US-Wavenet-A", "en-US", "MP3", "mp3", templateTime)
+	assert.NotEqual(t, data.Hash, other.Hash, "hash should depend on the voice")
+}
+
+func TestFilenameTemplateRender(t *testing.T) {
+	data := NewFilenameData("Good morning", "en-US-Wavenet-D", "en-US", "MP3", "mp3", templateTime)
+
+	tests := []struct {
+		name      string
+		pattern   string
+		maxLength int
+		want      string
+	}{
+		{"default", DefaultFilenameTemplate, 0, "output.mp3"},
+		{"auto", AutoFilenameTemplate, 0, "Good_morning.mp3"},
+		{"fields", "{{.Date}}_{{.Voice}}.{{.Ext}}", 0, "2024-03-09_en-US-Wavenet-D.mp3"},
+		{"subdirectory", "{{.Language}}/{{.Time}}.{{.Ext}}", 0, "en-US/140530.mp3"},
+		{"parent elements dropped", "../../{{.Text}}.{{.Ext}}", 0, "Good_morning.mp3"},
+		{"unsafe characters", "a:b*c?.{{.Ext}}", 0, "abc.mp3"},
+		{"truncated", AutoFilenameTemplate, 4, "Good.mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseFilenameTemplate(tt.pattern)
+			require.NoError(t, err)
+
+			name, err := tmpl.Render(data, tt.maxLength)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, name)
+		})
+	}
+}
+
+func TestParseFilenameTemplateErrors(t *testing.T) {
+	for _, pattern := range []string{"", "   ", "{{.Speaker}}.mp3", "{{.Date"} {
+		_, err := ParseFilenameTemplate(pattern)
+		assert.Error(t, err, "pattern %q", pattern)
+	}
+}
+
+func TestFilenameTemplateRenderEmpty(t *testing.T) {
+	tmpl, err := ParseFilenameTemplate("{{.Voice}}.{{.Ext}}")
+	require.NoError(t, err)
+
+	_, err = tmpl.Render(FilenameData{}, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"encoding/binary"
+)
+
+// DefaultSampleRate is used for WAV files when no sample rate is requested
+const DefaultSampleRate = 24000
+
+// WAV container constants for 16-bit linear PCM
+const (
+	wavHeaderSize    = 44
+	wavBitsPerSample = 16
+)
+
+// WAV format tags and header size of G.711 companded audio
+const (
+	wavFormatALAW  = 6
+	wavFormatMULAW = 7
+	g711HeaderSize = 58
+)
+
+// TelephonySampleRate is the sample rate of G.711 telephone audio
+const TelephonySampleRate = 8000
+
+// WAVHeader returns a canonical 44-byte RIFF/WAVE header for dataSize bytes
+// of 16-bit linear PCM
+func WAVHeader(dataSize, sampleRate, channels int) []byte {
+	blockAlign := channels * wavBitsPerSample / 8
+
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(wavHeaderSize-8+dataSize))
+	copy(header[8:16], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:24], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], wavBitsPerSample)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(dataSize))
+	return header
+}
+
+// EnsureWAV returns 16-bit linear PCM audio as a well-formed WAV file.
+// Headerless samples are wrapped in a header for sampleRate and channels.
+// Existing headers keep their format, but their RIFF and data sizes are
+// corrected to the actual length, since streaming encoders such as espeak
+// write placeholder sizes.
+func EnsureWAV(data []byte, sampleRate, channels int) []byte {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return append(WAVHeader(len(data), sampleRate, channels), data...)
+	}
+
+	return fixWAVSizes(data)
+}
+
+// G711WAV returns 8-bit mu-law, or with alaw A-law, samples as a WAV file,
+// the form telephony services such as Twilio play. Headerless samples are
+// wrapped in a header for sampleRate and channels; existing headers are
+// kept, with their sizes corrected as by EnsureWAV.
+func G711WAV(data []byte, alaw bool, sampleRate, channels int) []byte {
+	if len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE" {
+		return fixWAVSizes(data)
+	}
+
+	formatTag := uint16(wavFormatMULAW)
+	if alaw {
+		formatTag = wavFormatALAW
+	}
+	// Formats other than PCM have an 18-byte fmt chunk and a fact chunk
+	// giving the number of samples
+	header := make([]byte, g711HeaderSize)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(g711HeaderSize-8+len(data)))
+	copy(header[8:16], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 18)
+	binary.LittleEndian.PutUint16(header[20:22], formatTag)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(sampleRate*channels))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(channels))
+	binary.LittleEndian.PutUint16(header[34:36], 8)
+	copy(header[38:42], "fact")
+	binary.LittleEndian.PutUint32(header[42:46], 4)
+	binary.LittleEndian.PutUint32(header[46:50], uint32(len(data)/max(channels, 1)))
+	copy(header[50:54], "data")
+	binary.LittleEndian.PutUint32(header[54:58], uint32(len(data)))
+	return append(header, data...)
+}
+
+// StripWAV returns the samples of the data chunk of a WAV file, as
+// headerless formats such as the .ulaw files of Asterisk hold them. Data
+// that is not a WAV file is returned as is.
+func StripWAV(data []byte) []byte {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return data
+	}
+	for offset := 12; offset+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		remaining := len(data) - offset - 8
+		if string(data[offset:offset+4]) == "data" {
+			if size == 0 || size > remaining {
+				size = remaining
+			}
+			return data[offset+8 : offset+8+size]
+		}
+		if size > remaining {
+			break
+		}
+		offset += 8 + size + size%2
+	}
+	return data
+}
+
+// fixWAVSizes returns a copy of a WAV file with its RIFF and data sizes
+// corrected to the actual length
+func fixWAVSizes(data []byte) []byte {
+	fixed := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(fixed[4:8], uint32(len(fixed)-8))
+
+	for offset := 12; offset+8 <= len(fixed); {
+		size := int(binary.LittleEndian.Uint32(fixed[offset+4 : offset+8]))
+		remaining := len(fixed) - offset - 8
+
+		if string(fixed[offset:offset+4]) == "data" {
+			if size == 0 || size > remaining {
+				binary.LittleEndian.PutUint32(fixed[offset+4:offset+8], uint32(remaining))
+			}
+			break
+		}
+		if size > remaining {
+			break
+		}
+		offset += 8 + size + size%2
+	}
+	return fixed
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAVHeader(t *testing.T) {
+	header := WAVHeader(100, 24000, 2)
+
+	assert.Len(t, header, wavHeaderSize)
+	assert.Equal(t, "RIFF", string(header[0:4]))
+	assert.Equal(t, uint32(136), binary.LittleEndian.Uint32(header[4:8]))
+	assert.Equal(t, "WAVEfmt ", string(header[8:16]))
+	assert.Equal(t, uint16(2), binary.LittleEndian.Uint16(header[22:24]))
+	assert.Equal(t, uint32(24000), binary.LittleEndian.Uint32(header[24:28]))
+	assert.Equal(t, uint32(96000), binary.LittleEndian.Uint32(header[28:32]))
+	assert.Equal(t, uint16(4), binary.LittleEndian.Uint16(header[32:34]))
+	assert.Equal(t, "data", string(header[36:40]))
+	assert.Equal(t, uint32(100), binary.LittleEndian.Uint32(header[40:44]))
+}
+
+func TestEnsureWAVWrapsRawSamples(t *testing.T) {
+	raw := []byte{1, 0, 2, 0}
+
+	wav := EnsureWAV(raw, 16000, 1)
+	assert.Equal(t, append(WAVHeader(4, 16000, 1), raw...), wav)
+}
+
+func TestEnsureWAVKeepsValidFile(t *testing.T) {
+	valid := append(WAVHeader(2, 22050, 1), 7, 0)
+
+	assert.Equal(t, valid, EnsureWAV(valid, 24000, 1))
+}
+
+func TestEnsureWAVFixesPlaceholderSizes(t *testing.T) {
+	streamed := append(WAVHeader(0, 22050, 1), 1, 0, 2, 0)
+	binary.LittleEndian.PutUint32(streamed[4:8], 0xFFFFFFFF)
+	binary.LittleEndian.PutUint32(streamed[40:44], 0xFFFFFFFF)
+
+	fixed := EnsureWAV(streamed, 24000, 1)
+	assert.Equal(t, append(WAVHeader(4, 22050, 1), 1, 0, 2, 0), fixed)
+
+	// The input is not modified
+	assert.Equal(t, uint32(0xFFFFFFFF), binary.LittleEndian.Uint32(streamed[40:44]))
+}
+
+func TestEnsureWAVSkipsChunksBeforeData(t *testing.T) {
+	header := WAVHeader(0, 8000, 1)
+	list := append([]byte("LIST"), 2, 0, 0, 0, 'a', 'b')
+	wav := append(append(append(append([]byte{}, header[:36]...), list...), header[36:]...), 5, 0)
+
+	fixed := EnsureWAV(wav, 24000, 1)
+	assert.Equal(t, uint32(len(fixed)-8), binary.LittleEndian.Uint32(fixed[4:8]))
+	assert.Equal(t, uint32(2), binary.LittleEndian.Uint32(fixed[len(fixed)-6:]))
+}
+
+func TestG711WAV(t *testing.T) {
+	samples := []byte{0xff, 0x7f, 0x00}
+
+	wav := G711WAV(samples, false, 8000, 1)
+	require.Len(t, wav, g711HeaderSize+3)
+	assert.Equal(t, "RIFF", string(wav[0:4]))
+	assert.Equal(t, uint32(len(wav)-8), binary.LittleEndian.Uint32(wav[4:8]))
+	assert.Equal(t, uint16(wavFormatMULAW), binary.LittleEndian.Uint16(wav[20:22]))
+	assert.Equal(t, uint32(8000), binary.LittleEndian.Uint32(wav[24:28]))
+	assert.Equal(t, uint32(8000), binary.LittleEndian.Uint32(wav[28:32]))
+	assert.Equal(t, uint16(8), binary.LittleEndian.Uint16(wav[34:36]))
+	assert.Equal(t, "fact", string(wav[38:42]))
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(wav[46:50]))
+	assert.Equal(t, "data", string(wav[50:54]))
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(wav[54:58]))
+	assert.Equal(t, samples, wav[58:])
+
+	alaw := G711WAV(samples, true, 8000, 1)
+	assert.Equal(t, uint16(wavFormatALAW), binary.LittleEndian.Uint16(alaw[20:22]))
+
+	// A header from the provider is kept
+	assert.Equal(t, wav, G711WAV(wav, true, 16000, 1))
+}
+
+func TestStripWAV(t *testing.T) {
+	samples := []byte{0xff, 0x7f, 0x00}
+
+	assert.Equal(t, samples, StripWAV(G711WAV(samples, false, 8000, 1)))
+	assert.Equal(t, []byte{1, 0}, StripWAV(append(WAVHeader(2, 8000, 1), 1, 0)))
+	assert.Equal(t, samples, StripWAV(samples), "headerless samples are returned as is")
+}
@@ -2,10 +2,13 @@ package player
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
 // Platform constants
@@ -20,12 +23,81 @@ const (
 	afplayPlayer = "afplay"
 )
 
+// Playback speed limits, within what every speed-capable player accepts
+const (
+	MinSpeed = 0.5
+	MaxSpeed = 2.0
+)
+
+// volumePlayers and speedPlayers are the players that can change the
+// playback volume and speed
+var (
+	volumePlayers = map[string]bool{
+		afplayPlayer: true, "paplay": true, "ffplay": true, "mpv": true, "mplayer": true, "powershell": true,
+		BuiltinPlayer: true,
+	}
+	speedPlayers = map[string]bool{
+		afplayPlayer: true, "ffplay": true, "mpv": true, "mplayer": true, "powershell": true,
+	}
+)
+
+// playerArgs are the arguments each known player needs to play a file
+// without a window and exit when done
+var playerArgs = map[string][]string{
+	afplayPlayer:  {},
+	"open":        {"-a", "QuickTime Player"},
+	"aplay":       {},                       // ALSA
+	"paplay":      {},                       // PulseAudio
+	"ffplay":      {"-nodisp", "-autoexit"}, // ffmpeg
+	"mpv":         {"--no-video"},           // mpv
+	"mplayer":     {},                       // mplayer
+	"powershell":  {"-Command"},             // Windows media player
+	BuiltinPlayer: {},
+}
+
+// platformPlayers are the players detected on each platform, in order of
+// preference
+var platformPlayers = map[string][]string{
+	platformDarwin:  {afplayPlayer, "open"},
+	platformLinux:   {"aplay", "paplay", "ffplay", "mpv", "mplayer"},
+	platformWindows: {"powershell"},
+}
+
+// primaryPlayers are the detected players that are not reported as
+// fallbacks
+var primaryPlayers = map[string]bool{
+	afplayPlayer: true, "aplay": true, "paplay": true, "powershell": true,
+}
+
+// Options configure player selection
+type Options struct {
+	// Players are tried in order before platform detection
+	Players []string
+	// FormatPlayers maps file extensions (such as "ogg" or "wav") to the
+	// player for them, used when installed
+	FormatPlayers map[string]string
+	// Fallback allows platform detection when none of Players is installed
+	Fallback bool
+	// Args are extra arguments passed to every external player
+	Args []string
+}
+
 // AudioPlayer handles cross-platform audio playback
 type AudioPlayer struct {
 	// platform-specific player command
 	player   string
 	args     []string
 	fallback bool
+	// detectErr is why no external player was found when the built-in
+	// player was picked in its place, which then only plays WAV files
+	detectErr error
+	// extra arguments from the configuration, and players by file extension
+	extraArgs     []string
+	formatPlayers map[string]string
+	// volume (0.0 to 1.0) and speed multiplier applied when the player
+	// supports them
+	volume float64
+	speed  float64
 }
 
 // PlayerError represents playback-related errors
@@ -45,9 +117,28 @@ func (e *PlayerError) Unwrap() error {
 
 // NewAudioPlayer creates a new audio player with platform detection
 func NewAudioPlayer() (*AudioPlayer, error) {
-	player := &AudioPlayer{}
+	return NewAudioPlayerWithOptions(Options{Fallback: true})
+}
 
-	if err := player.detectPlayer(); err != nil {
+// NewAudioPlayerWithOptions creates an audio player using the first
+// installed player of opts.Players, falling back to platform detection and
+// then, where it has a sound device, the built-in player
+func NewAudioPlayerWithOptions(opts Options) (*AudioPlayer, error) {
+	player := &AudioPlayer{volume: 1.0, speed: 1.0, extraArgs: opts.Args}
+
+	err := player.choosePlayer(opts.Players)
+	if err != nil && (len(opts.Players) == 0 || opts.Fallback) {
+		err = player.detectPlayer()
+		if err != nil && builtinAvailable() {
+			// Fall back to the built-in player when no external one is
+			// installed
+			player.usePlayer(BuiltinPlayer)
+			player.fallback = true
+			player.detectErr = err
+			err = nil
+		}
+	}
+	if err != nil {
 		return nil, &PlayerError{
 			Operation: "initialization",
 			Err:       err,
@@ -55,64 +146,74 @@ func NewAudioPlayer() (*AudioPlayer, error) {
 		}
 	}
 
+	for ext, name := range opts.FormatPlayers {
+		if player.available(name) {
+			if player.formatPlayers == nil {
+				player.formatPlayers = make(map[string]string)
+			}
+			player.formatPlayers[normalizeExt(ext)] = name
+		}
+	}
 	return player, nil
 }
 
-// detectPlayer detects the appropriate audio player for the current platform
-func (p *AudioPlayer) detectPlayer() error {
-	switch runtime.GOOS {
-	case platformDarwin: // macOS
-		if p.commandExists("afplay") {
-			p.player = afplayPlayer
-			p.args = []string{}
+// choosePlayer uses the first installed player of names
+func (p *AudioPlayer) choosePlayer(names []string) error {
+	for _, name := range names {
+		if p.available(name) {
+			p.usePlayer(name)
 			return nil
 		}
-		// Fallback to system open command
-		if p.commandExists("open") {
-			p.player = "open"
-			p.args = []string{"-a", "QuickTime Player"}
-			p.fallback = true
+	}
+	return fmt.Errorf("none of the preferred audio players is available: %s", strings.Join(names, ", "))
+}
+
+// detectPlayer detects the appropriate audio player for the current platform
+func (p *AudioPlayer) detectPlayer() error {
+	players, ok := platformPlayers[runtime.GOOS]
+	if !ok {
+		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+	for _, name := range players {
+		if p.commandExists(name) {
+			p.usePlayer(name)
+			p.fallback = !primaryPlayers[name]
 			return nil
 		}
-		return fmt.Errorf("no suitable audio player found on macOS")
-
-	case platformLinux:
-		// Try common Linux audio players in order of preference
-		players := []struct {
-			cmd  string
-			args []string
-		}{
-			{"aplay", []string{}},                        // ALSA
-			{"paplay", []string{}},                       // PulseAudio
-			{"ffplay", []string{"-nodisp", "-autoexit"}}, // ffmpeg
-			{"mpv", []string{"--no-video"}},              // mpv
-			{"mplayer", []string{}},                      // mplayer
-		}
+	}
+	return fmt.Errorf("no suitable audio player found on %s (tried %s)", runtime.GOOS, strings.Join(players, ", "))
+}
 
-		for _, player := range players {
-			if p.commandExists(player.cmd) {
-				p.player = player.cmd
-				p.args = player.args
-				if player.cmd != "aplay" && player.cmd != "paplay" {
-					p.fallback = true
-				}
-				return nil
-			}
-		}
-		return fmt.Errorf("no suitable audio player found on Linux")
+// usePlayer selects a player with its default arguments. Players unknown
+// to this package get none.
+func (p *AudioPlayer) usePlayer(name string) {
+	p.player = name
+	p.args = append([]string{}, playerArgs[name]...)
+	p.fallback = false
+}
 
-	case platformWindows:
-		// Use PowerShell's media capabilities
-		if p.commandExists("powershell") {
-			p.player = "powershell"
-			p.args = []string{"-Command"}
-			return nil
-		}
-		return fmt.Errorf("PowerShell not found on Windows")
+// available reports whether a player can be used
+func (p *AudioPlayer) available(name string) bool {
+	if name == BuiltinPlayer {
+		return builtinAvailable()
+	}
+	return name != "" && p.commandExists(name)
+}
 
-	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+// normalizeExt lowercases a file extension and strips its dot
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// forFile returns the player for a file: the format's mapped player, or p
+func (p *AudioPlayer) forFile(filePath string) *AudioPlayer {
+	name, ok := p.formatPlayers[normalizeExt(filepath.Ext(filePath))]
+	if !ok || name == p.player {
+		return p
 	}
+	mapped := *p
+	mapped.usePlayer(name)
+	return &mapped
 }
 
 // commandExists checks if a command exists in PATH
@@ -121,10 +222,95 @@ func (p *AudioPlayer) commandExists(cmd string) bool {
 	return err == nil
 }
 
-// Play plays an audio file
+// SetVolume sets the playback volume from 0.0 (silent) to 1.0 (full),
+// clamping values outside that range
+func (p *AudioPlayer) SetVolume(volume float64) {
+	p.volume = max(0.0, min(volume, 1.0))
+}
+
+// SetSpeed sets the playback speed multiplier, clamped to MinSpeed..MaxSpeed
+func (p *AudioPlayer) SetSpeed(speed float64) {
+	p.speed = max(MinSpeed, min(speed, MaxSpeed))
+}
+
+// Unsupported returns the names of the requested settings ("volume",
+// "speed") that the detected player cannot apply. Playback still works but
+// ignores them.
+func (p *AudioPlayer) Unsupported() []string {
+	var unsupported []string
+	if p.volume != 1.0 && !volumePlayers[p.player] {
+		unsupported = append(unsupported, "volume")
+	}
+	if p.speed != 1.0 && !speedPlayers[p.player] {
+		unsupported = append(unsupported, "speed")
+	}
+	return unsupported
+}
+
+// controlArgs returns the player arguments applying the volume and speed.
+// Default settings add no arguments.
+func (p *AudioPlayer) controlArgs() []string {
+	var args []string
+	percent := strconv.Itoa(int(p.volume*100 + 0.5))
+	speed := formatFloat(p.speed)
+
+	switch p.player {
+	case afplayPlayer:
+		if p.volume != 1.0 {
+			args = append(args, "-v", formatFloat(p.volume))
+		}
+		if p.speed != 1.0 {
+			// Rate changes need the high quality setting to keep the pitch
+			args = append(args, "-r", speed, "-q", "1")
+		}
+	case "paplay":
+		if p.volume != 1.0 {
+			// 65536 is 100%
+			args = append(args, "--volume="+strconv.Itoa(int(p.volume*65536+0.5)))
+		}
+	case "ffplay":
+		if p.volume != 1.0 {
+			args = append(args, "-volume", percent)
+		}
+		if p.speed != 1.0 {
+			args = append(args, "-af", "atempo="+speed)
+		}
+	case "mpv":
+		if p.volume != 1.0 {
+			args = append(args, "--volume="+percent)
+		}
+		if p.speed != 1.0 {
+			args = append(args, "--speed="+speed)
+		}
+	case "mplayer":
+		if p.volume != 1.0 {
+			args = append(args, "-volume", percent)
+		}
+		if p.speed != 1.0 {
+			args = append(args, "-af", "scaletempo", "-speed", speed)
+		}
+	}
+	return args
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Play plays an audio file, blocking until playback ends
 func (p *AudioPlayer) Play(filePath string) error {
+	playback, err := p.Start(filePath)
+	if err != nil {
+		return err
+	}
+	return playback.Wait()
+}
+
+// Start begins playing an audio file in the background and returns a
+// handle to pause, resume, stop, or wait for it
+func (p *AudioPlayer) Start(filePath string) (*Playback, error) {
 	if p.player == "" {
-		return &PlayerError{
+		return nil, &PlayerError{
 			Operation: "play",
 			Err:       fmt.Errorf("no audio player configured"),
 			Platform:  runtime.GOOS,
@@ -137,57 +323,78 @@ func (p *AudioPlayer) Play(filePath string) error {
 
 	// Validate file exists
 	if _, err := os.Stat(cleanPath); os.IsNotExist(err) {
-		return &PlayerError{
+		return nil, &PlayerError{
 			Operation: "play",
 			Err:       fmt.Errorf("audio file does not exist: %s", cleanPath),
 			Platform:  runtime.GOOS,
 		}
 	}
 
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case platformDarwin:
-		cmd = p.buildMacOSCommand(cleanPath)
-	case platformLinux:
-		cmd = p.buildLinuxCommand(cleanPath)
-	case platformWindows:
-		cmd = p.buildWindowsCommand(cleanPath)
-	default:
-		return &PlayerError{
+	player := p.forFile(cleanPath)
+	if player.player == BuiltinPlayer && player.detectErr != nil && !isWAVFile(cleanPath) {
+		// The built-in player stands in for a missing external one only
+		// for the audio it can decode
+		return nil, &PlayerError{
 			Operation: "play",
-			Err:       fmt.Errorf("unsupported platform: %s", runtime.GOOS),
-			Platform:  runtime.GOOS,
+			Err: fmt.Errorf("%v, and the built-in player only plays WAV (LINEAR16) audio, not %s files",
+				player.detectErr, strings.TrimPrefix(filepath.Ext(cleanPath), ".")),
+			Platform: runtime.GOOS,
 		}
 	}
+	return player.start(cleanPath)
+}
 
-	// Execute the command
-	if err := cmd.Run(); err != nil {
-		return &PlayerError{
-			Operation: "play",
-			Err:       fmt.Errorf("failed to play audio: %v", err),
-			Platform:  runtime.GOOS,
-		}
+// isWAVFile reports whether a file starts with a RIFF WAVE header
+func isWAVFile(filePath string) bool {
+	// #nosec G304 - File path is validated before use
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false
+	}
+	return string(header[:4]) == "RIFF" && string(header[8:]) == "WAVE"
+}
+
+// start plays a file with this player
+func (p *AudioPlayer) start(filePath string) (*Playback, error) {
+	var cmd *exec.Cmd
+
+	switch p.player {
+	case BuiltinPlayer:
+		return startBuiltin(filePath, p.volume)
+	case afplayPlayer, "open":
+		cmd = p.buildMacOSCommand(filePath)
+	case "powershell":
+		cmd = p.buildWindowsCommand(filePath)
+	default:
+		cmd = p.buildLinuxCommand(filePath)
 	}
 
-	return nil
+	return startPlayback(cmd)
 }
 
 // buildMacOSCommand builds the command for macOS
 func (p *AudioPlayer) buildMacOSCommand(filePath string) *exec.Cmd {
 	if p.player == "afplay" {
+		cmdArgs := append(append(append([]string{}, p.extraArgs...), p.controlArgs()...), filePath)
 		// #nosec G204 - Player command is controlled and validated
-		return exec.Command(p.player, filePath)
+		return exec.Command(p.player, cmdArgs...)
 	}
 	// Fallback to open command
-	cmdArgs := append(p.args, filePath)
+	cmdArgs := append(append(append([]string{}, p.args...), p.extraArgs...), filePath)
 	// #nosec G204 - Player command is controlled and validated
 	return exec.Command(p.player, cmdArgs...)
 }
 
-// buildLinuxCommand builds the command for Linux
+// buildLinuxCommand builds the command for Linux players, and any other
+// player taking the file as its last argument
 func (p *AudioPlayer) buildLinuxCommand(filePath string) *exec.Cmd {
-	cmdArgs := append(p.args, filePath)
+	cmdArgs := append([]string{}, p.args...)
+	cmdArgs = append(append(append(cmdArgs, p.extraArgs...), p.controlArgs()...), filePath)
 	// #nosec G204 - Player command is controlled and validated
 	return exec.Command(p.player, cmdArgs...)
 }
@@ -200,20 +407,24 @@ func (p *AudioPlayer) buildWindowsCommand(filePath string) *exec.Cmd {
 		absPath = filePath // fallback to original path
 	}
 
-	// PowerShell script to play audio
+	// PowerShell script to play audio. SpeedRatio only takes effect once
+	// playback has started.
 	script := fmt.Sprintf(`
 		Add-Type -AssemblyName presentationCore
 		$mediaPlayer = New-Object system.windows.media.mediaplayer
 		$mediaPlayer.open([uri]'%s')
+		$mediaPlayer.Volume = %s
 		$mediaPlayer.Play()
+		$mediaPlayer.SpeedRatio = %s
 		Start-Sleep 1
 		do {
 			Start-Sleep 1
 		} while($mediaPlayer.NaturalDuration.HasTimeSpan -eq $false)
-		Start-Sleep $mediaPlayer.NaturalDuration.TimeSpan.TotalSeconds
-	`, absPath)
+		Start-Sleep ($mediaPlayer.NaturalDuration.TimeSpan.TotalSeconds / %s)
+	`, absPath, formatFloat(p.volume), formatFloat(p.speed), formatFloat(p.speed))
 
-	cmdArgs := append(p.args, script)
+	// Extra arguments are PowerShell options, which precede -Command
+	cmdArgs := append(append(append([]string{}, p.extraArgs...), p.args...), script)
 	// #nosec G204 - Player command is controlled and validated
 	return exec.Command(p.player, cmdArgs...)
 }
@@ -221,23 +432,31 @@ func (p *AudioPlayer) buildWindowsCommand(filePath string) *exec.Cmd {
 // GetPlayerInfo returns information about the detected audio player
 func (p *AudioPlayer) GetPlayerInfo() PlayerInfo {
 	return PlayerInfo{
-		Command:  p.player,
-		Args:     p.args,
-		Platform: runtime.GOOS,
-		Fallback: p.fallback,
+		Command:        p.player,
+		Args:           p.args,
+		Platform:       runtime.GOOS,
+		Fallback:       p.fallback,
+		SupportsVolume: volumePlayers[p.player],
+		SupportsSpeed:  speedPlayers[p.player],
 	}
 }
 
 // PlayerInfo contains information about the audio player
 type PlayerInfo struct {
-	Command  string   `json:"command"`
-	Args     []string `json:"args"`
-	Platform string   `json:"platform"`
-	Fallback bool     `json:"fallback"`
+	Command        string   `json:"command"`
+	Args           []string `json:"args"`
+	Platform       string   `json:"platform"`
+	Fallback       bool     `json:"fallback"`
+	SupportsVolume bool     `json:"supports_volume"`
+	SupportsSpeed  bool     `json:"supports_speed"`
 }
 
-// IsSupported checks if audio playback is supported on the current platform
+// IsSupported checks if audio playback is supported on the current platform,
+// and the environment is not headless
 func IsSupported() bool {
+	if headless, _ := Headless(); headless {
+		return false
+	}
 	player, err := NewAudioPlayer()
 	if err != nil {
 		return false
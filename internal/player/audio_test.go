@@ -256,3 +256,149 @@ func TestAudioPlayer_Play_DifferentExtensions(t *testing.T) {
 		})
 	}
 }
+
+func TestAudioPlayer_controlArgs(t *testing.T) {
+	tests := []struct {
+		player string
+		volume float64
+		speed  float64
+		want   []string
+	}{
+		{player: "afplay", volume: 1, speed: 1, want: nil},
+		{player: "afplay", volume: 0.5, speed: 1.5, want: []string{"-v", "0.5", "-r", "1.5", "-q", "1"}},
+		{player: "paplay", volume: 0.25, speed: 2, want: []string{"--volume=16384"}},
+		{player: "ffplay", volume: 0.8, speed: 0.75, want: []string{"-volume", "80", "-af", "atempo=0.75"}},
+		{player: "mpv", volume: 0, speed: 1.25, want: []string{"--volume=0", "--speed=1.25"}},
+		{player: "mplayer", volume: 0.5, speed: 1, want: []string{"-volume", "50"}},
+		{player: "aplay", volume: 0.5, speed: 1.5, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.player, func(t *testing.T) {
+			player := &AudioPlayer{player: tt.player, volume: tt.volume, speed: tt.speed}
+			assert.Equal(t, tt.want, player.controlArgs())
+		})
+	}
+}
+
+func TestAudioPlayer_Unsupported(t *testing.T) {
+	player := &AudioPlayer{player: "aplay", volume: 1, speed: 1}
+	assert.Empty(t, player.Unsupported())
+
+	player.SetVolume(0.5)
+	player.SetSpeed(1.5)
+	assert.Equal(t, []string{"volume", "speed"}, player.Unsupported())
+
+	player.player = "paplay"
+	assert.Equal(t, []string{"speed"}, player.Unsupported())
+
+	player.player = "mpv"
+	assert.Empty(t, player.Unsupported())
+	info := player.GetPlayerInfo()
+	assert.True(t, info.SupportsVolume)
+	assert.True(t, info.SupportsSpeed)
+}
+
+func TestAudioPlayer_SetVolumeAndSpeedClamp(t *testing.T) {
+	player := &AudioPlayer{}
+
+	player.SetVolume(1.5)
+	assert.Equal(t, 1.0, player.volume)
+	player.SetVolume(-1)
+	assert.Equal(t, 0.0, player.volume)
+
+	player.SetSpeed(10)
+	assert.Equal(t, MaxSpeed, player.speed)
+	player.SetSpeed(0)
+	assert.Equal(t, MinSpeed, player.speed)
+}
+
+func TestAudioPlayer_buildLinuxCommand_Controls(t *testing.T) {
+	player := &AudioPlayer{player: "ffplay", args: []string{"-nodisp", "-autoexit"}, volume: 0.5, speed: 1.5}
+
+	cmd := player.buildLinuxCommand("/tmp/test.mp3")
+	assert.Equal(t, []string{"ffplay", "-nodisp", "-autoexit", "-volume", "50", "-af", "atempo=1.5", "/tmp/test.mp3"},
+		cmd.Args)
+	// The detected arguments are not modified
+	assert.Equal(t, []string{"-nodisp", "-autoexit"}, player.args)
+}
+
+func TestAudioPlayer_buildWindowsCommand_Controls(t *testing.T) {
+	player := &AudioPlayer{player: "powershell", args: []string{"-Command"}, volume: 0.3, speed: 1.25}
+
+	cmd := player.buildWindowsCommand("test.mp3")
+	script := cmd.Args[len(cmd.Args)-1]
+	assert.Contains(t, script, "$mediaPlayer.Volume = 0.3")
+	assert.Contains(t, script, "$mediaPlayer.SpeedRatio = 1.25")
+	assert.Contains(t, script, "TotalSeconds / 1.25")
+}
+
+// fakePlayersOnPath replaces PATH with a directory holding stand-in players
+func fakePlayersOnPath(t *testing.T, names ...string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stand-in players are shell scripts")
+	}
+
+	dir := t.TempDir()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0700))
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestNewAudioPlayerWithOptions_Preferences(t *testing.T) {
+	fakePlayersOnPath(t, "mpv", "aplay", "afplay")
+
+	player, err := NewAudioPlayerWithOptions(Options{Players: []string{"missing", "mpv"}})
+	require.NoError(t, err)
+	assert.Equal(t, "mpv", player.player)
+	assert.Equal(t, []string{"--no-video"}, player.args)
+	assert.False(t, player.GetPlayerInfo().Fallback)
+
+	// Without fallback, a missing preferred player is an error
+	_, err = NewAudioPlayerWithOptions(Options{Players: []string{"missing"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "none of the preferred audio players is available: missing")
+
+	player, err = NewAudioPlayerWithOptions(Options{Players: []string{"missing"}, Fallback: true})
+	require.NoError(t, err)
+	assert.Equal(t, platformPlayers[runtime.GOOS][0], player.player)
+}
+
+func TestAudioPlayer_FormatPlayers(t *testing.T) {
+	fakePlayersOnPath(t, "mpv", "aplay", "afplay")
+
+	player, err := NewAudioPlayerWithOptions(Options{
+		Players:       []string{"aplay"},
+		FormatPlayers: map[string]string{"OGG": "mpv", ".mp3": "missing"},
+		Args:          []string{"-q"},
+	})
+	require.NoError(t, err)
+
+	ogg := player.forFile("/tmp/speech.ogg")
+	assert.Equal(t, "mpv", ogg.player)
+	assert.Equal(t, []string{"--no-video"}, ogg.args)
+	assert.Equal(t, []string{"-q"}, ogg.extraArgs)
+	// The player itself is unchanged
+	assert.Equal(t, "aplay", player.player)
+
+	// Unmapped formats, and formats mapped to missing players, use the player
+	assert.Same(t, player, player.forFile("/tmp/speech.mp3"))
+	assert.Same(t, player, player.forFile("/tmp/speech.wav"))
+}
+
+func TestAudioPlayer_ExtraArgs(t *testing.T) {
+	player := &AudioPlayer{player: "ffplay", args: []string{"-nodisp", "-autoexit"}, extraArgs: []string{"-loglevel",
+		"quiet"}, volume: 0.5, speed: 1}
+	cmd := player.buildLinuxCommand("/tmp/test.mp3")
+	assert.Equal(t, []string{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet", "-volume", "50", "/tmp/test.mp3"},
+		cmd.Args)
+
+	player = &AudioPlayer{player: "afplay", extraArgs: []string{"-t", "5"}, volume: 1, speed: 1}
+	assert.Equal(t, []string{"afplay", "-t", "5", "/tmp/test.mp3"}, player.buildMacOSCommand("/tmp/test.mp3").Args)
+
+	player = &AudioPlayer{player: "powershell", args: []string{"-Command"}, extraArgs: []string{"-NoProfile"},
+		volume: 1, speed: 1}
+	assert.Equal(t, []string{"powershell", "-NoProfile", "-Command"}, player.buildWindowsCommand("test.mp3").Args[:3])
+}
@@ -0,0 +1,200 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+)
+
+// BuiltinPlayer is the name of the pure-Go player, which writes WAV audio
+// straight to the sound device without an external program
+const BuiltinPlayer = "builtin"
+
+// errBuiltinUnavailable is returned when the built-in player has no sound
+// device to play on
+var errBuiltinUnavailable = fmt.Errorf("the built-in player has no sound device available on %s", runtime.GOOS)
+
+// soundOutput is an open sound device playing 16-bit interleaved PCM
+type soundOutput interface {
+	// write blocks until the samples are queued on the device
+	write(samples []int16) error
+	// pause and resume suspend the device where it supports it; otherwise
+	// playback pauses once the queued audio has played
+	pause() error
+	resume() error
+	// drain blocks until the queued audio has played
+	drain() error
+	// stop discards the queued audio. It may be called while write blocks,
+	// which then returns.
+	stop() error
+	close() error
+}
+
+// openSoundOutput opens the default sound device for the given format. It
+// is a variable so tests can replace the device.
+var openSoundOutput = openPlatformOutput
+
+// builtinChunksPerSecond splits audio into tenth of a second writes, which
+// bounds how quickly pause and stop take effect
+const builtinChunksPerSecond = 10
+
+// builtinProcess plays decoded PCM in a goroutine
+type builtinProcess struct {
+	output  soundOutput
+	samples []int16
+	chunk   int
+	done    chan struct{}
+	err     error
+
+	mu      sync.Mutex
+	resumed *sync.Cond
+	paused  bool
+	stopped bool
+}
+
+// startBuiltin decodes a WAV file and starts playing it on the sound device
+func startBuiltin(filePath string, volume float64) (*Playback, error) {
+	// #nosec G304 - File path is validated before use
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, builtinError(fmt.Errorf("failed to read audio file: %v", err))
+	}
+	pcm, err := audio.DecodeWAV(data)
+	if err != nil {
+		return nil, builtinError(fmt.Errorf("the built-in player only plays WAV (LINEAR16) audio: %v", err))
+	}
+
+	output, err := openSoundOutput(pcm.SampleRate, pcm.Channels)
+	if err != nil {
+		return nil, builtinError(err)
+	}
+
+	p := &builtinProcess{
+		output:  output,
+		samples: scaleSamples(pcm.Samples, volume),
+		chunk:   max(pcm.SampleRate/builtinChunksPerSecond, 1) * pcm.Channels,
+		done:    make(chan struct{}),
+	}
+	p.resumed = sync.NewCond(&p.mu)
+	go p.run()
+	return newPlayback(p), nil
+}
+
+func builtinError(err error) error {
+	return &PlayerError{Operation: "play", Err: err, Platform: runtime.GOOS}
+}
+
+// scaleSamples applies the volume, returning the samples unchanged at full
+// volume
+func scaleSamples(samples []int16, volume float64) []int16 {
+	if volume == 1.0 {
+		return samples
+	}
+	scaled := make([]int16, len(samples))
+	for i, sample := range samples {
+		scaled[i] = int16(float64(sample) * volume)
+	}
+	return scaled
+}
+
+func (p *builtinProcess) run() {
+	defer close(p.done)
+
+	var err error
+	stopped := false
+	for offset := 0; offset < len(p.samples) && err == nil && !stopped; offset += p.chunk {
+		if stopped = !p.waitWhilePaused(); !stopped {
+			err = p.output.write(p.samples[offset:min(offset+p.chunk, len(p.samples))])
+		}
+	}
+
+	if err == nil && !stopped {
+		err = p.output.drain()
+	}
+	p.mu.Lock()
+	if p.stopped {
+		// Writes and drains interrupted by stop fail
+		err = nil
+	}
+	p.mu.Unlock()
+	p.err = errors.Join(err, p.output.close())
+}
+
+// waitWhilePaused blocks while playback is paused, returning false once it
+// is stopped
+func (p *builtinProcess) waitWhilePaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.paused && !p.stopped {
+		p.resumed.Wait()
+	}
+	return !p.stopped
+}
+
+func (p *builtinProcess) pause() error {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+	return p.output.pause()
+}
+
+func (p *builtinProcess) resume() error {
+	p.mu.Lock()
+	p.paused = false
+	p.resumed.Broadcast()
+	p.mu.Unlock()
+	return p.output.resume()
+}
+
+func (p *builtinProcess) kill() error {
+	p.mu.Lock()
+	p.stopped = true
+	p.resumed.Broadcast()
+	p.mu.Unlock()
+	return p.output.stop()
+}
+
+func (p *builtinProcess) wait() error {
+	<-p.done
+	return p.err
+}
+
+// convertSamples converts interleaved samples between rates and channel
+// counts, interpolating linearly between frames and mixing or duplicating
+// channels
+func convertSamples(samples []int16, fromRate, fromChannels, toRate, toChannels int) []int16 {
+	if fromRate == toRate && fromChannels == toChannels {
+		return samples
+	}
+
+	frames := len(samples) / fromChannels
+	outFrames := frames * toRate / fromRate
+	out := make([]int16, outFrames*toChannels)
+	channel := func(frame, ch int) float64 {
+		frame = min(frame, frames-1)
+		if fromChannels == toChannels {
+			return float64(samples[frame*fromChannels+ch])
+		}
+		// Mix down, or duplicate, the source channels
+		var sum float64
+		for c := range fromChannels {
+			sum += float64(samples[frame*fromChannels+c])
+		}
+		return sum / float64(fromChannels)
+	}
+
+	for i := range outFrames {
+		pos := float64(i) * float64(fromRate) / float64(toRate)
+		frame := int(pos)
+		frac := pos - float64(frame)
+		for ch := range toChannels {
+			a, b := channel(frame, ch), channel(frame+1, ch)
+			out[i*toChannels+ch] = int16(a + (b-a)*frac)
+		}
+	}
+	return out
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package player
+
+// builtinAvailable reports false: the built-in player has no sound device
+// backend on this platform
+func builtinAvailable() bool {
+	return false
+}
+
+func openPlatformOutput(_, _ int) (soundOutput, error) {
+	return nil, errBuiltinUnavailable
+}
@@ -0,0 +1,195 @@
+package player
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutput records what the built-in player writes, taking delay per write
+type fakeOutput struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	rate    int
+	samples []int16
+	calls   []string
+}
+
+func (f *fakeOutput) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if n := len(f.calls); n == 0 || f.calls[n-1] != call {
+		f.calls = append(f.calls, call)
+	}
+}
+
+func (f *fakeOutput) write(samples []int16) error {
+	time.Sleep(f.delay)
+	f.mu.Lock()
+	f.samples = append(f.samples, samples...)
+	f.mu.Unlock()
+	f.record("write")
+	return nil
+}
+
+func (f *fakeOutput) pause() error  { f.record("pause"); return nil }
+func (f *fakeOutput) resume() error { f.record("resume"); return nil }
+func (f *fakeOutput) drain() error  { f.record("drain"); return nil }
+func (f *fakeOutput) stop() error   { f.record("stop"); return nil }
+func (f *fakeOutput) close() error  { f.record("close"); return nil }
+
+func (f *fakeOutput) called() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.calls...)
+}
+
+func (f *fakeOutput) written() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.samples)
+}
+
+// useFakeOutput replaces the sound device for the test
+func useFakeOutput(t *testing.T, delay time.Duration) *fakeOutput {
+	t.Helper()
+	output := &fakeOutput{delay: delay}
+	original := openSoundOutput
+	openSoundOutput = func(rate, _ int) (soundOutput, error) {
+		output.rate = rate
+		return output, nil
+	}
+	t.Cleanup(func() { openSoundOutput = original })
+	return output
+}
+
+// writeWAV writes a mono WAV file of the given length in seconds
+func writeWAV(t *testing.T, seconds float64) (string, []int16) {
+	t.Helper()
+	samples := make([]int16, int(8000*seconds))
+	for i := range samples {
+		samples[i] = int16(i % 1000)
+	}
+	path := filepath.Join(t.TempDir(), "speech.wav")
+	require.NoError(t, os.WriteFile(path, audio.EncodeWAV(&audio.PCM{SampleRate: 8000, Channels: 1, Samples: samples}),
+		0600))
+	return path, samples
+}
+
+func TestBuiltin_PlaysWAV(t *testing.T) {
+	output := useFakeOutput(t, 0)
+	path, samples := writeWAV(t, 0.35)
+
+	player := &AudioPlayer{player: BuiltinPlayer, volume: 0.5, speed: 1.0}
+	require.NoError(t, player.Play(path))
+
+	assert.Equal(t, 8000, output.rate)
+	require.Len(t, output.samples, len(samples))
+	assert.Equal(t, samples[999]/2, output.samples[999])
+	assert.Equal(t, []string{"write", "drain", "close"}, output.calls)
+}
+
+func TestBuiltin_RejectsOtherFormats(t *testing.T) {
+	useFakeOutput(t, 0)
+	path := filepath.Join(t.TempDir(), "speech.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("ID3 fake mp3"), 0600))
+
+	player := &AudioPlayer{player: BuiltinPlayer, volume: 1.0, speed: 1.0}
+	_, err := player.Start(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only plays WAV")
+}
+
+func TestBuiltin_FallbackPlaysOnlyWAV(t *testing.T) {
+	useFakeOutput(t, 0)
+	wav, _ := writeWAV(t, 0.1)
+	mp3 := filepath.Join(t.TempDir(), "speech.mp3")
+	require.NoError(t, os.WriteFile(mp3, []byte("ID3 fake mp3"), 0600))
+
+	player := &AudioPlayer{player: BuiltinPlayer, fallback: true, volume: 1.0, speed: 1.0,
+		detectErr: errors.New("no suitable audio player found on linux (tried aplay, paplay)")}
+	require.NoError(t, player.Play(wav))
+
+	// MP3 is refused before the device is opened, naming the missing players
+	_, err := player.Start(mp3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tried aplay, paplay")
+	assert.Contains(t, err.Error(), "only plays WAV (LINEAR16) audio, not mp3 files")
+}
+
+func TestBuiltin_PauseResumeStop(t *testing.T) {
+	output := useFakeOutput(t, 20*time.Millisecond)
+	path, _ := writeWAV(t, 10)
+
+	player := &AudioPlayer{player: BuiltinPlayer, volume: 1.0, speed: 1.0}
+	playback, err := player.Start(path)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return output.written() > 0 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, playback.Pause())
+	assert.Equal(t, StatePaused, playback.Status())
+	assert.Contains(t, output.called(), "pause")
+	// Writing stops once the current chunk is done
+	time.Sleep(50 * time.Millisecond)
+	written := output.written()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, written, output.written())
+
+	require.NoError(t, playback.Resume())
+	require.Eventually(t, func() bool { return output.written() > written }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, playback.Stop())
+	assert.Equal(t, StateStopped, playback.Status())
+	assert.NoError(t, playback.Wait())
+	calls := output.called()
+	assert.Contains(t, calls, "stop")
+	assert.NotContains(t, calls, "drain")
+	assert.Equal(t, "close", calls[len(calls)-1])
+}
+
+func TestNewAudioPlayerWithOptions_Builtin(t *testing.T) {
+	player, err := NewAudioPlayerWithOptions(Options{Players: []string{BuiltinPlayer}})
+	if !builtinAvailable() {
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "none of the preferred audio players")
+		return
+	}
+	require.NoError(t, err)
+	info := player.GetPlayerInfo()
+	assert.Equal(t, BuiltinPlayer, info.Command)
+	assert.True(t, info.SupportsVolume)
+	assert.False(t, info.SupportsSpeed)
+}
+
+func TestNewAudioPlayer_BuiltinFallback(t *testing.T) {
+	// No external player is installed
+	t.Setenv("PATH", t.TempDir())
+
+	player, err := NewAudioPlayerWithOptions(Options{Fallback: true})
+	if !builtinAvailable() {
+		// There is no built-in backend on this platform
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no suitable audio player found")
+		return
+	}
+	require.NoError(t, err)
+	assert.Equal(t, BuiltinPlayer, player.GetPlayerInfo().Command)
+}
+
+func TestConvertSamples(t *testing.T) {
+	mono := []int16{0, 100, 200, 300}
+
+	assert.Equal(t, mono, convertSamples(mono, 8000, 1, 8000, 1))
+	assert.Equal(t, []int16{0, 0, 50, 50, 100, 100, 150, 150, 200, 200, 250, 250, 300, 300, 300, 300},
+		convertSamples(mono, 8000, 1, 16000, 2))
+	assert.Equal(t, []int16{50, 250}, convertSamples([]int16{0, 100, 200, 300}, 8000, 2, 8000, 1))
+	assert.Equal(t, []int16{0, 200}, convertSamples(mono, 16000, 1, 8000, 1))
+}
@@ -0,0 +1,197 @@
+//go:build windows
+
+package player
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// The built-in player uses the waveOut API of winmm.dll, present on every
+// Windows installation
+
+var (
+	winmm                 = syscall.NewLazyDLL("winmm.dll")
+	procWaveOutGetNumDevs = winmm.NewProc("waveOutGetNumDevs")
+	procWaveOutOpen       = winmm.NewProc("waveOutOpen")
+	procWaveOutPrepare    = winmm.NewProc("waveOutPrepareHeader")
+	procWaveOutUnprepare  = winmm.NewProc("waveOutUnprepareHeader")
+	procWaveOutWrite      = winmm.NewProc("waveOutWrite")
+	procWaveOutPause      = winmm.NewProc("waveOutPause")
+	procWaveOutRestart    = winmm.NewProc("waveOutRestart")
+	procWaveOutReset      = winmm.NewProc("waveOutReset")
+	procWaveOutClose      = winmm.NewProc("waveOutClose")
+)
+
+const (
+	waveMapper     = 0xFFFFFFFF
+	waveFormatPCM  = 1
+	waveHdrDone    = 0x1
+	waveBuffers    = 3
+	wavePollPeriod = 5 * time.Millisecond
+)
+
+// waveFormatEx mirrors WAVEFORMATEX
+type waveFormatEx struct {
+	formatTag      uint16
+	channels       uint16
+	samplesPerSec  uint32
+	avgBytesPerSec uint32
+	blockAlign     uint16
+	bitsPerSample  uint16
+	size           uint16
+}
+
+// waveHdr mirrors WAVEHDR
+type waveHdr struct {
+	data          *byte
+	bufferLength  uint32
+	bytesRecorded uint32
+	user          uintptr
+	flags         uint32
+	loops         uint32
+	next          uintptr
+	reserved      uintptr
+}
+
+// waveBuffer is a header and the audio it points to, kept alive while the
+// device plays it
+type waveBuffer struct {
+	header waveHdr
+	data   []byte
+}
+
+// waveOutput is an open waveOut device
+type waveOutput struct {
+	handle  uintptr
+	stopped atomic.Bool
+
+	mu     sync.Mutex
+	queued []*waveBuffer
+}
+
+// builtinAvailable reports whether winmm has an output device
+func builtinAvailable() bool {
+	if winmm.Load() != nil {
+		return false
+	}
+	devices, _, _ := procWaveOutGetNumDevs.Call()
+	return devices > 0
+}
+
+// openPlatformOutput opens the default waveOut device, which converts the
+// format as needed
+func openPlatformOutput(rate, channels int) (soundOutput, error) {
+	if err := winmm.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load winmm.dll: %v", err)
+	}
+
+	format := waveFormatEx{
+		formatTag:      waveFormatPCM,
+		channels:       uint16(channels),
+		samplesPerSec:  uint32(rate),
+		avgBytesPerSec: uint32(rate * channels * 2),
+		blockAlign:     uint16(channels * 2),
+		bitsPerSample:  16,
+	}
+	var handle uintptr
+	result, _, _ := procWaveOutOpen.Call(uintptr(unsafe.Pointer(&handle)), waveMapper,
+		uintptr(unsafe.Pointer(&format)), 0, 0, 0)
+	if result != 0 {
+		return nil, fmt.Errorf("failed to open sound device: waveOutOpen error %d", result)
+	}
+	return &waveOutput{handle: handle}, nil
+}
+
+func (o *waveOutput) call(proc *syscall.LazyProc, name string, args ...uintptr) error {
+	result, _, _ := proc.Call(append([]uintptr{o.handle}, args...)...)
+	if result != 0 {
+		return fmt.Errorf("%s error %d", name, result)
+	}
+	return nil
+}
+
+func (o *waveOutput) write(samples []int16) error {
+	// Keep a few buffers in flight so the device never runs dry
+	if err := o.waitQueued(waveBuffers - 1); err != nil {
+		return err
+	}
+	if o.stopped.Load() {
+		return fmt.Errorf("playback stopped")
+	}
+
+	buffer := &waveBuffer{data: make([]byte, len(samples)*2)}
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(buffer.data[i*2:], uint16(sample))
+	}
+	if len(buffer.data) == 0 {
+		return nil
+	}
+	buffer.header.data = &buffer.data[0]
+	buffer.header.bufferLength = uint32(len(buffer.data))
+
+	header := uintptr(unsafe.Pointer(&buffer.header))
+	size := unsafe.Sizeof(buffer.header)
+	if err := o.call(procWaveOutPrepare, "waveOutPrepareHeader", header, size); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	o.queued = append(o.queued, buffer)
+	o.mu.Unlock()
+	return o.call(procWaveOutWrite, "waveOutWrite", header, size)
+}
+
+// waitQueued waits until at most n buffers are still playing, releasing the
+// finished ones
+func (o *waveOutput) waitQueued(n int) error {
+	for {
+		o.mu.Lock()
+		for len(o.queued) > 0 && atomic.LoadUint32(&o.queued[0].header.flags)&waveHdrDone != 0 {
+			buffer := o.queued[0]
+			o.queued = o.queued[1:]
+			err := o.call(procWaveOutUnprepare, "waveOutUnprepareHeader",
+				uintptr(unsafe.Pointer(&buffer.header)), unsafe.Sizeof(buffer.header))
+			if err != nil {
+				o.mu.Unlock()
+				return err
+			}
+		}
+		queued := len(o.queued)
+		o.mu.Unlock()
+
+		if queued <= n {
+			return nil
+		}
+		time.Sleep(wavePollPeriod)
+	}
+}
+
+func (o *waveOutput) pause() error {
+	return o.call(procWaveOutPause, "waveOutPause")
+}
+
+func (o *waveOutput) resume() error {
+	return o.call(procWaveOutRestart, "waveOutRestart")
+}
+
+func (o *waveOutput) drain() error {
+	return o.waitQueued(0)
+}
+
+// stop marks every queued buffer done, which ends waits in write and drain
+func (o *waveOutput) stop() error {
+	o.stopped.Store(true)
+	return o.call(procWaveOutReset, "waveOutReset")
+}
+
+func (o *waveOutput) close() error {
+	if err := o.waitQueued(0); err != nil {
+		return err
+	}
+	return o.call(procWaveOutClose, "waveOutClose")
+}
@@ -0,0 +1,61 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ForcePlaybackEnv is the environment variable that disables headless
+// detection, for CI jobs and servers that can play audio
+const ForcePlaybackEnv = "ASSISTANT_CLI_FORCE_PLAYBACK"
+
+// alsaDevices matches the ALSA playback devices
+const alsaDevices = "/dev/snd/pcmC*D*p"
+
+// ciEnvVars are set by common CI services
+var ciEnvVars = []string{
+	"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "TRAVIS", "JENKINS_URL", "TF_BUILD", "TEAMCITY_VERSION",
+}
+
+// Headless reports whether the environment has no way to play audio to a
+// listener, such as a CI job or a Linux machine without sound devices, and
+// why. Setting ForcePlaybackEnv turns detection off.
+func Headless() (bool, string) {
+	if os.Getenv(ForcePlaybackEnv) != "" {
+		return false, ""
+	}
+
+	for _, name := range ciEnvVars {
+		if value := os.Getenv(name); value != "" && value != "false" && value != "0" {
+			return true, "running in CI (" + name + " is set)"
+		}
+	}
+
+	if runtime.GOOS == platformLinux && !linuxAudioAvailable() {
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return true, "no display or audio device found"
+		}
+		return true, "no audio device found"
+	}
+	return false, ""
+}
+
+// linuxAudioAvailable reports whether there is an ALSA device or a sound
+// server to play on
+func linuxAudioAvailable() bool {
+	if devices, _ := filepath.Glob(alsaDevices); len(devices) > 0 {
+		return true
+	}
+	if os.Getenv("PULSE_SERVER") != "" {
+		return true
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		for _, socket := range []string{"pulse/native", "pipewire-0"} {
+			if _, err := os.Stat(filepath.Join(runtimeDir, socket)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
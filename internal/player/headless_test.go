@@ -0,0 +1,73 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearCIEnv unsets the CI variables for the test
+func clearCIEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(ForcePlaybackEnv, "")
+	for _, name := range ciEnvVars {
+		t.Setenv(name, "")
+	}
+}
+
+func TestHeadless_CI(t *testing.T) {
+	clearCIEnv(t)
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	headless, reason := Headless()
+	assert.True(t, headless)
+	assert.Equal(t, "running in CI (GITHUB_ACTIONS is set)", reason)
+	assert.False(t, IsSupported())
+
+	t.Setenv(ForcePlaybackEnv, "1")
+	headless, _ = Headless()
+	assert.False(t, headless)
+}
+
+func TestHeadless_FalseCIValues(t *testing.T) {
+	clearCIEnv(t)
+	// A sound server socket makes the audio check pass on Linux
+	runtimeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(runtimeDir, "pulse"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(runtimeDir, "pulse", "native"), nil, 0600))
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	t.Setenv("CI", "false")
+	headless, reason := Headless()
+	assert.False(t, headless, reason)
+}
+
+func TestHeadless_NoAudioDevice(t *testing.T) {
+	if runtime.GOOS != platformLinux {
+		t.Skip("audio device detection is Linux only")
+	}
+	if devices, _ := filepath.Glob(alsaDevices); len(devices) > 0 {
+		t.Skip("this machine has sound devices")
+	}
+	clearCIEnv(t)
+	t.Setenv("PULSE_SERVER", "")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	headless, reason := Headless()
+	assert.True(t, headless)
+	assert.Equal(t, "no display or audio device found", reason)
+
+	t.Setenv("DISPLAY", ":0")
+	_, reason = Headless()
+	assert.Equal(t, "no audio device found", reason)
+
+	t.Setenv("PULSE_SERVER", "tcp:localhost")
+	headless, _ = Headless()
+	assert.False(t, headless)
+}
@@ -0,0 +1,176 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+// ErrPauseUnsupported is returned by Pause and Resume on platforms whose
+// players cannot be suspended
+var ErrPauseUnsupported = errors.New("pausing playback is not supported on this platform")
+
+// State is the state of a playback
+type State string
+
+// Playback states
+const (
+	StatePlaying  State = "playing"
+	StatePaused   State = "paused"
+	StateStopped  State = "stopped"
+	StateFinished State = "finished"
+	StateFailed   State = "failed"
+)
+
+// process is a running player: an external command or the built-in player
+type process interface {
+	pause() error
+	resume() error
+	kill() error
+	wait() error
+}
+
+// commandProcess is an external player command
+type commandProcess struct {
+	cmd *exec.Cmd
+}
+
+func (c *commandProcess) pause() error  { return pauseProcess(c.cmd.Process) }
+func (c *commandProcess) resume() error { return resumeProcess(c.cmd.Process) }
+func (c *commandProcess) kill() error   { return c.cmd.Process.Kill() }
+func (c *commandProcess) wait() error   { return c.cmd.Wait() }
+
+// Playback is an audio file playing in the background. Its methods are safe
+// for concurrent use.
+type Playback struct {
+	process process
+	done    chan struct{}
+
+	mu    sync.Mutex
+	state State
+	err   error
+}
+
+// startPlayback starts the player command and watches it in a goroutine
+func startPlayback(cmd *exec.Cmd) (*Playback, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, &PlayerError{
+			Operation: "play",
+			Err:       fmt.Errorf("failed to start audio player: %v", err),
+			Platform:  runtime.GOOS,
+		}
+	}
+
+	return newPlayback(&commandProcess{cmd: cmd}), nil
+}
+
+// newPlayback watches a started process in a goroutine
+func newPlayback(process process) *Playback {
+	playback := &Playback{process: process, done: make(chan struct{}), state: StatePlaying}
+	go playback.wait()
+	return playback
+}
+
+func (p *Playback) wait() {
+	err := p.process.wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case p.state == StateStopped:
+		// Killed by Stop
+	case err != nil:
+		p.state = StateFailed
+		p.err = &PlayerError{
+			Operation: "play",
+			Err:       fmt.Errorf("failed to play audio: %v", err),
+			Platform:  runtime.GOOS,
+		}
+	default:
+		p.state = StateFinished
+	}
+	close(p.done)
+}
+
+// Status returns the current playback state
+func (p *Playback) Status() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// Pause suspends playback. Pausing a paused or ended playback does nothing.
+func (p *Playback) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state != StatePlaying {
+		return nil
+	}
+	if err := p.process.pause(); err != nil {
+		return p.controlError("pause", err)
+	}
+	p.state = StatePaused
+	return nil
+}
+
+// Resume continues paused playback. Resuming a playing or ended playback
+// does nothing.
+func (p *Playback) Resume() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state != StatePaused {
+		return nil
+	}
+	if err := p.process.resume(); err != nil {
+		return p.controlError("resume", err)
+	}
+	p.state = StatePlaying
+	return nil
+}
+
+// Stop ends playback early. Stopping an ended playback does nothing.
+func (p *Playback) Stop() error {
+	p.mu.Lock()
+	if p.state != StatePlaying && p.state != StatePaused {
+		p.mu.Unlock()
+		return nil
+	}
+	p.state = StateStopped
+	err := p.process.kill()
+	p.mu.Unlock()
+
+	if err != nil {
+		select {
+		case <-p.done:
+			// The player exited on its own in the meantime
+			return nil
+		default:
+			return p.controlError("stop", err)
+		}
+	}
+	<-p.done
+	return nil
+}
+
+// Done is closed when playback has ended
+func (p *Playback) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait blocks until playback ends. It returns nil when the audio finished
+// or was stopped, and the player's error when it failed.
+func (p *Playback) Wait() error {
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *Playback) controlError(operation string, err error) error {
+	if errors.Is(err, ErrPauseUnsupported) {
+		return err
+	}
+	return &PlayerError{Operation: operation, Err: err, Platform: runtime.GOOS}
+}
@@ -0,0 +1,97 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptPlayer returns a player running a shell script instead of an audio
+// player, and a file for it to "play"
+func scriptPlayer(t *testing.T, script string) (*AudioPlayer, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("script player needs a POSIX shell")
+	}
+
+	file := filepath.Join(t.TempDir(), "test.wav")
+	require.NoError(t, os.WriteFile(file, []byte("fake audio"), 0600))
+	return &AudioPlayer{player: "sh", args: []string{"-c", script, "sh"}, volume: 1, speed: 1}, file
+}
+
+func TestPlayback_Finished(t *testing.T) {
+	player, file := scriptPlayer(t, "exit 0")
+
+	playback, err := player.Start(file)
+	require.NoError(t, err)
+	assert.NoError(t, playback.Wait())
+	assert.Equal(t, StateFinished, playback.Status())
+
+	// Controls on an ended playback do nothing
+	assert.NoError(t, playback.Pause())
+	assert.NoError(t, playback.Stop())
+	assert.Equal(t, StateFinished, playback.Status())
+}
+
+func TestPlayback_Failed(t *testing.T) {
+	player, file := scriptPlayer(t, "exit 3")
+
+	playback, err := player.Start(file)
+	require.NoError(t, err)
+	err = playback.Wait()
+	var playerErr *PlayerError
+	require.ErrorAs(t, err, &playerErr)
+	assert.Equal(t, "play", playerErr.Operation)
+	assert.Equal(t, StateFailed, playback.Status())
+
+	assert.Error(t, player.Play(file))
+}
+
+func TestPlayback_PauseResumeStop(t *testing.T) {
+	player, file := scriptPlayer(t, "sleep 30")
+
+	playback, err := player.Start(file)
+	require.NoError(t, err)
+	assert.Equal(t, StatePlaying, playback.Status())
+
+	require.NoError(t, playback.Pause())
+	assert.Equal(t, StatePaused, playback.Status())
+	require.NoError(t, playback.Pause())
+	assert.Equal(t, StatePaused, playback.Status())
+
+	require.NoError(t, playback.Resume())
+	assert.Equal(t, StatePlaying, playback.Status())
+
+	// Stopping works while paused too
+	require.NoError(t, playback.Pause())
+	start := time.Now()
+	require.NoError(t, playback.Stop())
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, StateStopped, playback.Status())
+	assert.NoError(t, playback.Wait())
+
+	select {
+	case <-playback.Done():
+	default:
+		t.Fatal("Done not closed after Stop")
+	}
+}
+
+func TestPlayback_StartMissingPlayer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX paths")
+	}
+	file := filepath.Join(t.TempDir(), "test.wav")
+	require.NoError(t, os.WriteFile(file, []byte("fake audio"), 0600))
+
+	player := &AudioPlayer{player: "definitely_not_a_real_player_12345", volume: 1, speed: 1}
+	_, err := player.Start(file)
+	var playerErr *PlayerError
+	require.ErrorAs(t, err, &playerErr)
+	assert.Contains(t, err.Error(), "failed to start audio player")
+}
@@ -0,0 +1,224 @@
+package player
+
+import (
+	"sync"
+)
+
+// StateWaiting is the state of a queue whose files have all been played
+// while more may still be added
+const StateWaiting State = "waiting"
+
+// Queue plays audio files one after another as they are added, so that
+// files can be played while later ones are still being produced. Its
+// methods are safe for concurrent use.
+type Queue struct {
+	player *AudioPlayer
+	done   chan struct{}
+
+	mu      sync.Mutex
+	added   *sync.Cond
+	pending []string
+	current *Playback
+	file    string
+	closed  bool
+	stopped bool
+	paused  bool
+	played  int
+	err     error
+}
+
+// NewQueue creates a queue playing files with player and starts it
+func NewQueue(player *AudioPlayer) *Queue {
+	q := &Queue{player: player, done: make(chan struct{})}
+	q.added = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+// Add appends a file to the queue. Files added after Close or Stop are
+// ignored.
+func (q *Queue) Add(filePath string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.stopped {
+		return
+	}
+	q.pending = append(q.pending, filePath)
+	q.added.Signal()
+}
+
+// Close marks the end of the queue: playback ends after the last file
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.added.Signal()
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+
+	for {
+		file, ok := q.next()
+		if !ok {
+			return
+		}
+
+		playback, err := q.player.Start(file)
+		q.mu.Lock()
+		if err == nil && q.stopped {
+			// Stopped while the player was starting
+			_ = playback.Stop()
+			q.mu.Unlock()
+			return
+		}
+		if err == nil {
+			q.current, q.file = playback, file
+			if q.paused {
+				_ = playback.Pause()
+			}
+		}
+		q.mu.Unlock()
+
+		if err == nil {
+			err = playback.Wait()
+		}
+
+		q.mu.Lock()
+		q.current, q.file = nil, ""
+		if err == nil {
+			q.played++
+		} else if q.err == nil {
+			// Later files are still played
+			q.err = err
+		}
+		q.mu.Unlock()
+	}
+}
+
+// next waits for the next file, returning false once the queue is closed
+// and empty or stopped
+func (q *Queue) next() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.pending) == 0 && !q.closed && !q.stopped {
+		q.added.Wait()
+	}
+	if q.stopped || len(q.pending) == 0 {
+		return "", false
+	}
+
+	file := q.pending[0]
+	q.pending = q.pending[1:]
+	return file, true
+}
+
+// Pause pauses the current file, and files started while paused
+func (q *Queue) Pause() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.current != nil {
+		if err := q.current.Pause(); err != nil {
+			return err
+		}
+	}
+	q.paused = true
+	return nil
+}
+
+// Resume continues paused playback
+func (q *Queue) Resume() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.current != nil {
+		if err := q.current.Resume(); err != nil {
+			return err
+		}
+	}
+	q.paused = false
+	return nil
+}
+
+// Skip stops the current file and moves on to the next one, unpausing
+// playback
+func (q *Queue) Skip() error {
+	q.mu.Lock()
+	current := q.current
+	q.paused = false
+	q.mu.Unlock()
+
+	if current == nil {
+		return nil
+	}
+	return current.Stop()
+}
+
+// Stop ends playback and discards the files not played yet
+func (q *Queue) Stop() error {
+	q.mu.Lock()
+	q.stopped = true
+	q.pending = nil
+	current := q.current
+	q.added.Signal()
+	q.mu.Unlock()
+
+	if current != nil {
+		if err := current.Stop(); err != nil {
+			return err
+		}
+	}
+	<-q.done
+	return nil
+}
+
+// Status returns the state of the queue: the state of the current file,
+// StateWaiting between files, or StateFinished or StateStopped once the
+// queue has ended
+func (q *Queue) Status() State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		if q.stopped {
+			return StateStopped
+		}
+		return StateFinished
+	default:
+	}
+	switch {
+	case q.paused:
+		return StatePaused
+	case q.current != nil:
+		return q.current.Status()
+	default:
+		return StateWaiting
+	}
+}
+
+// Current returns the file being played, or "" between files
+func (q *Queue) Current() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file
+}
+
+// Played returns the number of files played to the end or skipped
+func (q *Queue) Played() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.played
+}
+
+// Done is closed when the queue has ended
+func (q *Queue) Done() <-chan struct{} {
+	return q.done
+}
+
+// Wait blocks until the queue ends and returns the first playback error
+func (q *Queue) Wait() error {
+	<-q.done
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
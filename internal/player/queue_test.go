@@ -0,0 +1,105 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logPlayer returns a script player that appends each played file name to
+// a log, plays for the given shell sleep time, and a function reading the
+// log
+func logPlayer(t *testing.T, sleep string) (*AudioPlayer, func() []string) {
+	t.Helper()
+
+	log := filepath.Join(t.TempDir(), "played.log")
+	player, _ := scriptPlayer(t, `basename "$1" >> '`+log+`'; sleep `+sleep)
+	return player, func() []string {
+		data, err := os.ReadFile(log)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		return strings.Fields(string(data))
+	}
+}
+
+func queueFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("fake audio"), 0600))
+	return path
+}
+
+func TestQueue_PlaysInOrderAsAdded(t *testing.T) {
+	player, played := logPlayer(t, "0")
+	dir := t.TempDir()
+
+	q := NewQueue(player)
+	q.Add(queueFile(t, dir, "a.wav"))
+	// Files can be added while earlier ones play
+	time.Sleep(50 * time.Millisecond)
+	q.Add(queueFile(t, dir, "b.wav"))
+	q.Add(queueFile(t, dir, "c.wav"))
+	q.Close()
+	q.Add(queueFile(t, dir, "ignored.wav"))
+
+	require.NoError(t, q.Wait())
+	assert.Equal(t, []string{"a.wav", "b.wav", "c.wav"}, played())
+	assert.Equal(t, 3, q.Played())
+	assert.Equal(t, StateFinished, q.Status())
+}
+
+func TestQueue_WaitingBetweenFiles(t *testing.T) {
+	player, _ := logPlayer(t, "0")
+
+	q := NewQueue(player)
+	assert.Eventually(t, func() bool { return q.Status() == StateWaiting }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, q.Current())
+
+	q.Close()
+	require.NoError(t, q.Wait())
+}
+
+func TestQueue_SkipAndStop(t *testing.T) {
+	player, played := logPlayer(t, "30")
+	dir := t.TempDir()
+
+	q := NewQueue(player)
+	q.Add(queueFile(t, dir, "a.wav"))
+	q.Add(queueFile(t, dir, "b.wav"))
+	q.Add(queueFile(t, dir, "c.wav"))
+
+	require.Eventually(t, func() bool { return strings.HasSuffix(q.Current(), "a.wav") }, 5*time.Second,
+		10*time.Millisecond)
+	require.NoError(t, q.Pause())
+	assert.Equal(t, StatePaused, q.Status())
+
+	require.NoError(t, q.Skip())
+	require.Eventually(t, func() bool { return strings.HasSuffix(q.Current(), "b.wav") }, 5*time.Second,
+		10*time.Millisecond)
+	assert.Equal(t, StatePlaying, q.Status())
+
+	require.NoError(t, q.Stop())
+	assert.Equal(t, StateStopped, q.Status())
+	assert.NoError(t, q.Wait())
+	assert.Equal(t, []string{"a.wav", "b.wav"}, played())
+}
+
+func TestQueue_ContinuesAfterFailure(t *testing.T) {
+	player, _ := scriptPlayer(t, `case "$1" in *bad*) exit 1;; esac`)
+	dir := t.TempDir()
+
+	q := NewQueue(player)
+	q.Add(queueFile(t, dir, "bad.wav"))
+	q.Add(queueFile(t, dir, "good.wav"))
+	q.Close()
+
+	assert.Error(t, q.Wait())
+	assert.Equal(t, 1, q.Played())
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package player
+
+import (
+	"os"
+	"syscall"
+)
+
+// pauseProcess suspends the player process
+func pauseProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGSTOP)
+}
+
+// resumeProcess continues a suspended player process
+func resumeProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGCONT)
+}
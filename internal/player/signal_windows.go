@@ -0,0 +1,15 @@
+//go:build windows
+
+package player
+
+import "os"
+
+// pauseProcess is unsupported: Windows has no signal to suspend a process
+func pauseProcess(_ *os.Process) error {
+	return ErrPauseUnsupported
+}
+
+// resumeProcess is unsupported, like pauseProcess
+func resumeProcess(_ *os.Process) error {
+	return ErrPauseUnsupported
+}
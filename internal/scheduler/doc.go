@@ -0,0 +1,3 @@
+// Package scheduler runs recurring jobs of the daemon, such as a morning
+// briefing, on cron-like schedules, and keeps a history of their runs.
+package scheduler
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// historyVersion identifies the on-disk history format
+const historyVersion = 1
+
+// Run statuses
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Run records one run of a job
+type Run struct {
+	Job        string    `json:"job"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Status is StatusSuccess or StatusFailure, with the failure in Error
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// File is the written audio, unless it was a temporary file
+	File            string  `json:"file,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	Characters      int     `json:"characters,omitempty"`
+}
+
+// historyFile is the layout of the history file
+type historyFile struct {
+	Version int               `json:"version"`
+	Jobs    map[string][]*Run `json:"jobs"`
+}
+
+// History keeps the latest runs of each job in a file. It is safe for
+// concurrent use.
+type History struct {
+	path  string
+	limit int
+
+	mu   sync.Mutex
+	file historyFile
+}
+
+// DefaultHistoryPath returns ~/.assistant-cli/scheduler.json
+func DefaultHistoryPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".assistant-cli", "scheduler.json"), nil
+}
+
+// LoadHistory reads the history file at path, keeping the last limit runs
+// of each job from now on. A missing file is an empty history.
+func LoadHistory(path string, limit int) (*History, error) {
+	h := &History{path: path, limit: limit,
+		file: historyFile{Version: historyVersion, Jobs: make(map[string][]*Run)}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return h, nil
+		}
+		return nil, fmt.Errorf("failed to read job history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &h.file); err != nil {
+		return nil, fmt.Errorf("failed to parse job history %s: %w", path, err)
+	}
+	if h.file.Version != historyVersion {
+		return nil, fmt.Errorf("unsupported job history version %d in %s", h.file.Version, path)
+	}
+	if h.file.Jobs == nil {
+		h.file.Jobs = make(map[string][]*Run)
+	}
+	return h, nil
+}
+
+// Path returns the location of the history file
+func (h *History) Path() string {
+	return h.path
+}
+
+// Runs returns the recorded runs of the job with name, oldest first
+func (h *History) Runs(name string) []*Run {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*Run(nil), h.file.Jobs[name]...)
+}
+
+// Last returns the latest run of the job with name, or nil
+func (h *History) Last(name string) *Run {
+	runs := h.Runs(name)
+	if len(runs) == 0 {
+		return nil
+	}
+	return runs[len(runs)-1]
+}
+
+// Record adds run to the history of its job, dropping the oldest runs
+// beyond the limit, and saves the history
+func (h *History) Record(run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := append(h.file.Jobs[run.Job], run)
+	if h.limit > 0 && len(runs) > h.limit {
+		runs = runs[len(runs)-h.limit:]
+	}
+	h.file.Jobs[run.Job] = runs
+	return h.save()
+}
+
+// save writes the history atomically, so that a daemon stopped while
+// writing never leaves a truncated file behind
+func (h *History) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return fmt.Errorf("failed to create job history directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(&h.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode job history: %w", err)
+	}
+
+	if err := output.WriteFileAtomic(h.path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write job history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// A Wednesday
+	start := time.Date(2026, 3, 4, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", start.Add(time.Minute)},
+		{"30 7 * * *", time.Date(2026, 3, 5, 7, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 4, 7, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)},
+		{"0 7 * * mon-fri", time.Date(2026, 3, 5, 7, 0, 0, 0, time.UTC)},
+		{"0 7 * * sat,sun", time.Date(2026, 3, 7, 7, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Either restricted day runs the job
+		{"0 0 13 * fri", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)},
+		{"@every 90m", start.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(start))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"0 7 * *":        "want 5 fields",
+		"60 * * * *":     "minute",
+		"0 7 * * funday": `invalid value "funday"`,
+		"*/0 * * * *":    "invalid step",
+		"0 0 30 feb *":   "never runs",
+		"@every 10s":     "at least 1m",
+		"@every soon":    "invalid schedule",
+	}
+	for spec, want := range tests {
+		_, err := Parse(spec)
+		assert.ErrorContains(t, err, want, spec)
+	}
+}
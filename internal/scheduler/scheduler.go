@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+)
+
+// maxWait bounds each wait for the next run, so that a suspended machine
+// or a changed system clock delays a run by at most this much
+const maxWait = time.Minute
+
+// Job is a named job and its schedule
+type Job struct {
+	Name     string
+	Schedule Schedule
+}
+
+// RunFunc runs the job with name, which was due at due. ctx is canceled
+// when the scheduler stops.
+type RunFunc func(ctx context.Context, name string, due time.Time)
+
+// Scheduler runs jobs when they are due. A job still running when it is
+// due again is not started a second time.
+type Scheduler struct {
+	jobs  []Job
+	run   RunFunc
+	clock clock.Clock
+	// Logf, when set, is told about skipped runs
+	Logf func(format string, args ...interface{})
+
+	mu      sync.Mutex
+	next    map[string]time.Time
+	running map[string]bool
+	// changed wakes Run when SetJobs replaced the jobs
+	changed chan struct{}
+}
+
+// New creates a scheduler calling run for each job that is due
+func New(jobs []Job, run RunFunc) *Scheduler {
+	return &Scheduler{
+		jobs:    jobs,
+		run:     run,
+		clock:   clock.Real,
+		next:    make(map[string]time.Time),
+		running: make(map[string]bool),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// SetClock makes the scheduler tell the time with c
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.clock = clock.OrReal(c)
+}
+
+// Next returns when the job with name runs next, once Run has started, or
+// the zero time
+func (s *Scheduler) Next(name string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next[name]
+}
+
+// SetJobs replaces the jobs, as when the configuration changed. Each job
+// is next due at its first scheduled time from now; runs of removed jobs
+// that already started are not stopped.
+func (s *Scheduler) SetJobs(jobs []Job) {
+	now := s.clock.Now()
+	s.mu.Lock()
+	s.jobs = jobs
+	s.next = make(map[string]time.Time, len(jobs))
+	for _, job := range jobs {
+		s.next[job.Name] = job.Schedule.Next(now)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs the jobs as they become due until ctx is done, then waits for
+// the jobs that are running to return
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	now := s.clock.Now()
+	s.mu.Lock()
+	for _, job := range s.jobs {
+		s.next[job.Name] = job.Schedule.Next(now)
+	}
+	s.mu.Unlock()
+
+	for {
+		wait := maxWait
+		s.mu.Lock()
+		for _, next := range s.next {
+			wait = min(wait, next.Sub(now))
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changed:
+		case <-s.clock.After(max(wait, 0)):
+		}
+
+		now = s.clock.Now()
+		s.mu.Lock()
+		jobs := s.jobs
+		s.mu.Unlock()
+		for _, job := range jobs {
+			due, ok := s.due(job, now)
+			if !ok {
+				continue
+			}
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				defer s.finish(name)
+				s.run(ctx, name, due)
+			}(job.Name)
+		}
+	}
+}
+
+// due returns when job was due and whether it is to be started at now,
+// scheduling its next run when it is due
+func (s *Scheduler) due(job Job, now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	due := s.next[job.Name]
+	if now.Before(due) {
+		return due, false
+	}
+	// Runs missed while the machine slept are made up for once
+	s.next[job.Name] = job.Schedule.Next(now)
+	if s.running[job.Name] {
+		s.logf("job %s is still running; skipping this run", job.Name)
+		return due, false
+	}
+	s.running[job.Name] = true
+	return due, true
+}
+
+func (s *Scheduler) finish(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
+
+func (s *Scheduler) logf(format string, args ...interface{}) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerRunsDueJobs(t *testing.T) {
+	start := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	hourly, err := Parse("@every 1h")
+	require.NoError(t, err)
+	daily, err := Parse("0 7 * * *")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	runs := map[string][]time.Time{}
+	s := New([]Job{{Name: "hourly", Schedule: hourly}, {Name: "briefing", Schedule: daily}},
+		func(ctx context.Context, name string, due time.Time) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[name] = append(runs[name], due)
+			if name == "briefing" {
+				cancel()
+			}
+		})
+	s.SetClock(clock.NewFake(start))
+	s.Run(ctx)
+
+	assert.Equal(t, []time.Time{time.Date(2026, 3, 5, 7, 0, 0, 0, time.UTC)}, runs["briefing"])
+	require.NotEmpty(t, runs["hourly"])
+	assert.Equal(t, start.Add(time.Hour), runs["hourly"][0])
+}
+
+func TestSchedulerSkipsRunningJob(t *testing.T) {
+	every, err := Parse("@every 1m")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	var started int
+	skipped := make(chan string, 1)
+	s := New([]Job{{Name: "slow", Schedule: every}}, func(ctx context.Context, name string, due time.Time) {
+		started++
+		<-release
+	})
+	s.Logf = func(format string, args ...interface{}) {
+		select {
+		case skipped <- format:
+			cancel()
+		default:
+		}
+	}
+	s.SetClock(clock.NewFake(time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	assert.Contains(t, <-skipped, "still running")
+	close(release)
+	<-done
+	assert.Equal(t, 1, started)
+}
+
+func TestSchedulerSetJobs(t *testing.T) {
+	hourly, err := Parse("@every 1h")
+	require.NoError(t, err)
+	daily, err := Parse("0 7 * * *")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	runs := map[string][]time.Time{}
+	var s *Scheduler
+	s = New([]Job{{Name: "hourly", Schedule: hourly}}, func(ctx context.Context, name string, due time.Time) {
+		mu.Lock()
+		runs[name] = append(runs[name], due)
+		mu.Unlock()
+		switch name {
+		case "hourly":
+			s.SetJobs([]Job{{Name: "briefing", Schedule: daily}})
+		case "briefing":
+			cancel()
+		}
+	})
+	s.SetClock(clock.NewFake(time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.Run(ctx)
+
+	// The removed job does not run again, and the added one runs on its
+	// schedule
+	require.Len(t, runs["hourly"], 1)
+	require.NotEmpty(t, runs["briefing"])
+	assert.Equal(t, 7, runs["briefing"][0].Hour())
+	assert.True(t, runs["briefing"][0].After(runs["hourly"][0]))
+	assert.True(t, s.Next("hourly").IsZero())
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.json")
+	history, err := LoadHistory(path, 2)
+	require.NoError(t, err)
+	assert.Nil(t, history.Last("briefing"))
+
+	started := time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC)
+	for i, status := range []string{StatusSuccess, StatusFailure, StatusSuccess} {
+		require.NoError(t, history.Record(&Run{Job: "briefing", Status: status,
+			StartedAt: started.AddDate(0, 0, i), FinishedAt: started.AddDate(0, 0, i).Add(time.Second)}))
+	}
+
+	reloaded, err := LoadHistory(path, 2)
+	require.NoError(t, err)
+	runs := reloaded.Runs("briefing")
+	require.Len(t, runs, 2)
+	assert.Equal(t, StatusFailure, runs[0].Status)
+	assert.Equal(t, started.AddDate(0, 0, 2), reloaded.Last("briefing").StartedAt)
+
+	// The history is replaced whole, leaving no temporary files behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,6 @@
+// Package secrets encrypts credentials kept at rest, such as API keys in
+// config files and OAuth2 token files, so they stay protected when
+// dotfiles are synced to cloud storage. Values are sealed with NaCl
+// secretbox under a key derived with scrypt from a passphrase, which is
+// read from the environment or the OS keyring.
+package secrets
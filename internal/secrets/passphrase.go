@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase
+const PassphraseEnv = "ASSISTANT_CLI_PASSPHRASE"
+
+// Keyring entry holding the passphrase: the service and account names used
+// with the macOS Keychain and the freedesktop Secret Service
+const (
+	KeyringService = "assistant-cli"
+	KeyringAccount = "passphrase"
+)
+
+// ErrNoPassphrase is returned when encrypted values are found but no
+// passphrase is available to open them
+var ErrNoPassphrase = errors.New("no passphrase: set " + PassphraseEnv +
+	" or store one in the OS keyring under service " + KeyringService)
+
+// Passphrase returns the passphrase from ASSISTANT_CLI_PASSPHRASE or else
+// the OS keyring, read with security on macOS and secret-tool elsewhere
+func Passphrase() (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := keyringPassphrase()
+	if err != nil {
+		return "", fmt.Errorf("%w (%v)", ErrNoPassphrase, err)
+	}
+	if passphrase == "" {
+		return "", ErrNoPassphrase
+	}
+	return passphrase, nil
+}
+
+// keyringPassphrase looks the passphrase up in the OS keyring
+func keyringPassphrase() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password",
+			"-s", KeyringService, "-a", KeyringAccount, "-w")
+	case "windows":
+		return "", fmt.Errorf("the OS keyring is not supported on Windows")
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", KeyringService, "account", KeyringAccount)
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("keyring lookup with %s failed: %w", cmd.Args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Prefix marks an encrypted value, followed by the base64 encoded salt,
+// nonce, and sealed box
+const Prefix = "enc:v1:"
+
+const (
+	saltSize  = 16
+	nonceSize = 24
+	keySize   = 32
+)
+
+// scrypt cost parameters, the recommended interactive settings
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrDecrypt is returned for values that do not open with the passphrase,
+// because it is wrong or the value was altered
+var ErrDecrypt = errors.New("wrong passphrase or corrupted value")
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), Prefix)
+}
+
+// Encrypt seals plaintext with a key derived from passphrase and returns it
+// as a single line of text, safe to store in YAML, JSON, and TOML files
+func Encrypt(plaintext []byte, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", ErrNoPassphrase
+	}
+
+	var salt [saltSize]byte
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(salt[:]); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	key, err := deriveKey(passphrase, salt[:])
+	if err != nil {
+		return "", err
+	}
+
+	sealed := append(salt[:], nonce[:]...)
+	sealed = secretbox.Seal(sealed, plaintext, &nonce, key)
+	return Prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with the same passphrase
+func Decrypt(value, passphrase string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, Prefix) {
+		return nil, fmt.Errorf("value is not encrypted")
+	}
+	if passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil || len(sealed) < saltSize+nonceSize+secretbox.Overhead {
+		return nil, ErrDecrypt
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed[saltSize:saltSize+nonceSize])
+	key, err := deriveKey(passphrase, sealed[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, ok := secretbox.Open(nil, sealed[saltSize+nonceSize:], &nonce, key)
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// deriveKey stretches passphrase into a secretbox key
+func deriveKey(passphrase string, salt []byte) (*[keySize]byte, error) {
+	derived, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	var key [keySize]byte
+	copy(key[:], derived)
+	return &key, nil
+}
@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	value, err := Encrypt([]byte("AIzaSyTestKey1234567890"), "correct horse")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(value))
+	assert.NotContains(t, value, "AIzaSy")
+	assert.NotContains(t, value, "\n")
+
+	plaintext, err := Decrypt(value, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, "AIzaSyTestKey1234567890", string(plaintext))
+
+	again, err := Encrypt([]byte("AIzaSyTestKey1234567890"), "correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, value, again, "each value gets its own salt and nonce")
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	value, err := Encrypt([]byte("secret"), "correct horse")
+	require.NoError(t, err)
+
+	_, err = Decrypt(value, "battery staple")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	tampered := value[:len(value)-2] + "AA"
+	if tampered == value {
+		tampered = value[:len(value)-2] + "BB"
+	}
+	_, err = Decrypt(tampered, "correct horse")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = Decrypt(Prefix+"not base64!", "correct horse")
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = Decrypt("plain", "correct horse")
+	assert.Error(t, err)
+
+	_, err = Decrypt(value, "")
+	assert.ErrorIs(t, err, ErrNoPassphrase)
+	_, err = Encrypt([]byte("secret"), "")
+	assert.ErrorIs(t, err, ErrNoPassphrase)
+}
+
+func TestPassphrase(t *testing.T) {
+	t.Setenv(PassphraseEnv, "from env")
+	passphrase, err := Passphrase()
+	require.NoError(t, err)
+	assert.Equal(t, "from env", passphrase)
+
+	t.Setenv(PassphraseEnv, "")
+	t.Setenv("PATH", t.TempDir())
+	_, err = Passphrase()
+	assert.ErrorIs(t, err, ErrNoPassphrase)
+}
+
+func TestPassphrase_Keyring(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("fake secret-tool is a shell script")
+	}
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$*\" = \"lookup service assistant-cli account passphrase\" ] && printf 'from keyring'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(PassphraseEnv, "")
+
+	passphrase, err := Passphrase()
+	require.NoError(t, err)
+	assert.Equal(t, "from keyring", passphrase)
+	assert.False(t, strings.HasSuffix(passphrase, "\n"))
+}
@@ -0,0 +1,4 @@
+// Package stats persists the performance of the last command run, the
+// cumulative synthesis usage per month, and a ledger of every synthesis with
+// estimated Google Cloud costs.
+package stats
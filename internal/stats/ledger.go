@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one line of the usage ledger: what a command run synthesized
+// with one voice
+type Entry struct {
+	Time          time.Time `json:"time"`
+	Command       string    `json:"command,omitempty"`
+	Provider      string    `json:"provider"`
+	Voice         string    `json:"voice,omitempty"`
+	Tier          string    `json:"tier"`
+	Requests      int       `json:"requests"`
+	Characters    int       `json:"characters"`
+	EstimatedCost float64   `json:"estimated_cost_usd"`
+}
+
+// TierUsage is the usage of one voice tier in a month
+type TierUsage struct {
+	Tier          string  `json:"tier"`
+	Requests      int     `json:"requests"`
+	Characters    int     `json:"characters"`
+	EstimatedCost float64 `json:"estimated_cost_usd"`
+}
+
+// Usage summarizes the ledger entries of a month
+type Usage struct {
+	Month         string       `json:"month"`
+	Requests      int          `json:"requests"`
+	Characters    int          `json:"characters"`
+	EstimatedCost float64      `json:"estimated_cost_usd"`
+	Tiers         []*TierUsage `json:"tiers"`
+}
+
+// DefaultLedgerPath returns ~/.assistant-cli/usage.jsonl
+func DefaultLedgerPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".assistant-cli", "usage.jsonl"), nil
+}
+
+// MonthOf returns the month key of t, e.g. 2024-06
+func MonthOf(t time.Time) string {
+	return t.Format(monthLayout)
+}
+
+// ParseMonth validates a month given as YYYY-MM
+func ParseMonth(month string) (string, error) {
+	parsed, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return "", fmt.Errorf("invalid month %q: expected YYYY-MM, e.g. 2024-06", month)
+	}
+	return MonthOf(parsed), nil
+}
+
+// AppendLedger adds entries to the ledger at path, one JSON object per line.
+// Appending keeps the entries of concurrent runs instead of overwriting them.
+func AppendLedger(path string, entries []*Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode ledger entry: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return nil
+}
+
+// ReadLedger returns the entries of month, or every entry when month is
+// empty. A missing ledger has no entries, and an unfinished last line left
+// by an interrupted run is ignored.
+func ReadLedger(path, month string) ([]*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read ledger: %w", err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	var entries []*Entry
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry := &Entry{}
+		if err := json.Unmarshal([]byte(line), entry); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse ledger %s line %d: %w", path, i+1, err)
+		}
+		if month == "" || MonthOf(entry.Time) == month {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Summarize adds up entries per voice tier, cheapest tier first
+func Summarize(month string, entries []*Entry) *Usage {
+	usage := &Usage{Month: month, Tiers: []*TierUsage{}}
+	tiers := make(map[string]*TierUsage)
+	for _, entry := range entries {
+		tier, ok := tiers[entry.Tier]
+		if !ok {
+			tier = &TierUsage{Tier: entry.Tier}
+			tiers[entry.Tier] = tier
+			usage.Tiers = append(usage.Tiers, tier)
+		}
+		tier.Requests += entry.Requests
+		tier.Characters += entry.Characters
+		tier.EstimatedCost += entry.EstimatedCost
+
+		usage.Requests += entry.Requests
+		usage.Characters += entry.Characters
+		usage.EstimatedCost += entry.EstimatedCost
+	}
+
+	sort.SliceStable(usage.Tiers, func(i, j int) bool {
+		return tierRank(usage.Tiers[i].Tier) < tierRank(usage.Tiers[j].Tier)
+	})
+	return usage
+}
+
+// tierRank orders tiers as tierOrder, with unknown tiers last
+func tierRank(tier string) int {
+	for i, known := range tierOrder {
+		if tier == known {
+			return i
+		}
+	}
+	return len(tierOrder)
+}
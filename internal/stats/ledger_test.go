@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger", "usage.jsonl")
+
+	entries, err := ReadLedger(path, "")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	june := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, AppendLedger(path, []*Entry{
+		{Time: june, Provider: "google", Voice: "en-US-Wavenet-D", Tier: TierWaveNet, Requests: 2, Characters: 1000,
+			EstimatedCost: 0.016},
+		{Time: june, Provider: "espeak", Tier: TierLocal, Requests: 1, Characters: 50},
+	}))
+	require.NoError(t, AppendLedger(path, []*Entry{
+		{Time: june.AddDate(0, -1, 0), Provider: "google", Tier: TierStandard, Requests: 1, Characters: 10},
+		{Time: june.AddDate(0, 0, 2), Provider: "google", Tier: TierStandard, Requests: 1, Characters: 500,
+			EstimatedCost: 0.002},
+	}))
+
+	all, err := ReadLedger(path, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	entries, err = ReadLedger(path, "2024-06")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	usage := Summarize("2024-06", entries)
+	assert.Equal(t, 4, usage.Requests)
+	assert.Equal(t, 1550, usage.Characters)
+	assert.InDelta(t, 0.018, usage.EstimatedCost, 1e-9)
+	require.Len(t, usage.Tiers, 3)
+	assert.Equal(t, []string{TierStandard, TierWaveNet, TierLocal},
+		[]string{usage.Tiers[0].Tier, usage.Tiers[1].Tier, usage.Tiers[2].Tier})
+	assert.Equal(t, 1000, usage.Tiers[1].Characters)
+}
+
+func TestReadLedger_InterruptedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	require.NoError(t, AppendLedger(path, []*Entry{{Time: time.Now(), Provider: "espeak", Tier: TierLocal}}))
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"time":"2024-06`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	entries, err := ReadLedger(path, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json\n{}\n"), 0600))
+	_, err = ReadLedger(path, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1")
+}
+
+func TestParseMonth(t *testing.T) {
+	month, err := ParseMonth("2024-06")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06", month)
+
+	for _, invalid := range []string{"", "2024-6-01", "June 2024", "2024-13"} {
+		_, err := ParseMonth(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,74 @@
+package stats
+
+import "strings"
+
+// Voice tiers, named as on the Google Cloud pricing page
+const (
+	TierStandard = "Standard"
+	TierWaveNet  = "WaveNet"
+	TierNeural2  = "Neural2"
+	TierPolyglot = "Polyglot"
+	TierJourney  = "Journey"
+	TierChirpHD  = "Chirp HD"
+	TierStudio   = "Studio"
+
+	// TierLocal is the free tier of providers that synthesize on this machine
+	TierLocal = "Local"
+)
+
+// tierOrder lists the tiers from cheapest to most expensive Google voice,
+// followed by local synthesis
+var tierOrder = []string{
+	TierStandard, TierWaveNet, TierNeural2, TierPolyglot, TierJourney, TierChirpHD, TierStudio, TierLocal,
+}
+
+// pricePerMillion is the list price in USD per million characters of each
+// tier, without the monthly free allowance
+var pricePerMillion = map[string]float64{
+	TierStandard: 4,
+	TierWaveNet:  16,
+	TierNeural2:  16,
+	TierPolyglot: 16,
+	TierJourney:  30,
+	TierChirpHD:  30,
+	TierStudio:   160,
+}
+
+// tierMarkers map voice name parts to tiers, checked in order
+var tierMarkers = []struct {
+	marker string
+	tier   string
+}{
+	{"chirp3-hd", TierChirpHD},
+	{"chirp-hd", TierChirpHD},
+	{"journey", TierJourney},
+	{"studio", TierStudio},
+	{"neural2", TierNeural2},
+	{"polyglot", TierPolyglot},
+	{"wavenet", TierWaveNet},
+	{"news", TierWaveNet},
+}
+
+// VoiceTier returns the pricing tier of a Google Cloud voice name such as
+// en-US-Wavenet-D. Unrecognized names are Standard voices.
+func VoiceTier(voice string) string {
+	lower := strings.ToLower(voice)
+	for _, m := range tierMarkers {
+		if strings.Contains(lower, m.marker) {
+			return m.tier
+		}
+	}
+	return TierStandard
+}
+
+// EstimateCost returns the list price in USD of synthesizing characters
+// with a voice
+func EstimateCost(voice string, characters int) float64 {
+	return TierCost(VoiceTier(voice), characters)
+}
+
+// TierCost returns the list price in USD of synthesizing characters in a
+// tier. Local synthesis is free.
+func TierCost(tier string, characters int) float64 {
+	return pricePerMillion[tier] * float64(characters) / 1e6
+}
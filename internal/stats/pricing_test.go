@@ -0,0 +1,41 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoiceTier(t *testing.T) {
+	tests := []struct {
+		voice string
+		want  string
+	}{
+		{"en-US-Standard-A", TierStandard},
+		{"en-US-Wavenet-D", TierWaveNet},
+		{"en-GB-News-K", TierWaveNet},
+		{"en-US-Neural2-F", TierNeural2},
+		{"en-US-Polyglot-1", TierPolyglot},
+		{"en-US-Journey-D", TierJourney},
+		{"en-US-Chirp3-HD-Aoede", TierChirpHD},
+		{"en-US-Studio-O", TierStudio},
+		{"", TierStandard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.voice, func(t *testing.T) {
+			assert.Equal(t, tt.want, VoiceTier(tt.voice))
+		})
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	assert.InDelta(t, 16.0, EstimateCost("en-US-Wavenet-D", 1_000_000), 1e-9)
+	assert.InDelta(t, 0.0004, EstimateCost("en-US-Standard-A", 100), 1e-12)
+	assert.Zero(t, EstimateCost("en-US-Studio-O", 0))
+}
+
+func TestTierCost(t *testing.T) {
+	assert.InDelta(t, 0.016, TierCost(TierWaveNet, 1000), 1e-9)
+	assert.Zero(t, TierCost(TierLocal, 1000))
+}
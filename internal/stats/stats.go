@@ -0,0 +1,155 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// statsVersion identifies the on-disk stats format
+const statsVersion = 1
+
+// monthLayout formats the month keys, e.g. 2024-06
+const monthLayout = "2006-01"
+
+// Run is the synthesis activity of one command run
+type Run struct {
+	Command   string    `json:"command"`
+	Providers []string  `json:"providers"`
+	StartedAt time.Time `json:"started_at"`
+	// DurationMs is the wall time of the whole command
+	DurationMs int64 `json:"duration_ms"`
+	Requests   int   `json:"requests"`
+	Failed     int   `json:"failed"`
+	// Characters counts the text of successful requests
+	Characters       int     `json:"characters"`
+	EstimatedCost    float64 `json:"estimated_cost_usd"`
+	AverageLatencyMs int64   `json:"average_latency_ms"`
+	P50LatencyMs     int64   `json:"p50_latency_ms"`
+	P90LatencyMs     int64   `json:"p90_latency_ms"`
+	P99LatencyMs     int64   `json:"p99_latency_ms"`
+}
+
+// Month is the cumulative activity of a calendar month
+type Month struct {
+	Month         string  `json:"month"`
+	Runs          int     `json:"runs"`
+	Requests      int     `json:"requests"`
+	Failed        int     `json:"failed"`
+	Characters    int     `json:"characters"`
+	EstimatedCost float64 `json:"estimated_cost_usd"`
+}
+
+// statsFile is the layout of the stats file
+type statsFile struct {
+	Version int               `json:"version"`
+	LastRun *Run              `json:"last_run,omitempty"`
+	Months  map[string]*Month `json:"months"`
+}
+
+// Stats is the persistent record of the last run and monthly totals
+type Stats struct {
+	path string
+	file statsFile
+}
+
+// DefaultPath returns ~/.assistant-cli/stats.json
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".assistant-cli", "stats.json"), nil
+}
+
+// Load reads the stats file at path. A missing file has no stats.
+func Load(path string) (*Stats, error) {
+	s := &Stats{path: path, file: statsFile{Version: statsVersion, Months: make(map[string]*Month)}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.file); err != nil {
+		return nil, fmt.Errorf("failed to parse stats %s: %w", path, err)
+	}
+	if s.file.Version != statsVersion {
+		return nil, fmt.Errorf("unsupported stats version %d in %s", s.file.Version, path)
+	}
+	if s.file.Months == nil {
+		s.file.Months = make(map[string]*Month)
+	}
+	return s, nil
+}
+
+// Record makes run the last run and adds it to the totals of the month it
+// started in
+func (s *Stats) Record(run *Run) {
+	s.file.LastRun = run
+
+	key := run.StartedAt.Format(monthLayout)
+	month, ok := s.file.Months[key]
+	if !ok {
+		month = &Month{Month: key}
+		s.file.Months[key] = month
+	}
+	month.Runs++
+	month.Requests += run.Requests
+	month.Failed += run.Failed
+	month.Characters += run.Characters
+	month.EstimatedCost += run.EstimatedCost
+}
+
+// LastRun returns the most recently recorded run, or nil
+func (s *Stats) LastRun() *Run {
+	return s.file.LastRun
+}
+
+// Months returns the monthly totals, oldest first
+func (s *Stats) Months() []*Month {
+	months := make([]*Month, 0, len(s.file.Months))
+	for _, month := range s.file.Months {
+		months = append(months, month)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Month < months[j].Month })
+	return months
+}
+
+// Reset forgets all recorded activity
+func (s *Stats) Reset() {
+	s.file.LastRun = nil
+	s.file.Months = make(map[string]*Month)
+}
+
+// Path returns the location of the stats file
+func (s *Stats) Path() string {
+	return s.path
+}
+
+// Save writes the stats atomically, so that an interrupted run never
+// leaves a truncated file behind
+func (s *Stats) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(&s.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %w", err)
+	}
+
+	if err := output.WriteFileAtomic(s.path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats", "stats.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, s.LastRun())
+	assert.Empty(t, s.Months())
+
+	june := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	s.Record(&Run{Command: "synthesize", StartedAt: june, Requests: 2, Failed: 1, Characters: 100,
+		EstimatedCost: 0.0016})
+	s.Record(&Run{Command: "audiobook", StartedAt: june.AddDate(0, 0, 5), Requests: 3, Characters: 900,
+		EstimatedCost: 0.0144})
+	s.Record(&Run{Command: "synthesize", StartedAt: june.AddDate(0, -1, 0), Requests: 1, Characters: 10})
+	require.NoError(t, s.Save())
+
+	reloaded, err := Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, reloaded.LastRun())
+	assert.Equal(t, "synthesize", reloaded.LastRun().Command)
+
+	months := reloaded.Months()
+	require.Len(t, months, 2)
+	assert.Equal(t, "2024-05", months[0].Month)
+	assert.Equal(t, &Month{Month: "2024-06", Runs: 2, Requests: 5, Failed: 1, Characters: 1000,
+		EstimatedCost: 0.016}, months[1])
+
+	reloaded.Reset()
+	assert.Nil(t, reloaded.LastRun())
+	assert.Empty(t, reloaded.Months())
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{"), 0600))
+	_, err := Load(corrupt)
+	assert.ErrorContains(t, err, "failed to parse stats")
+
+	future := filepath.Join(dir, "future.json")
+	require.NoError(t, os.WriteFile(future, []byte(`{"version": 99, "months": {}}`), 0600))
+	_, err = Load(future)
+	assert.ErrorContains(t, err, "unsupported stats version 99")
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/clock"
 )
 
 type CacheEntry struct {
@@ -19,6 +20,7 @@ type VoiceCache struct {
 	mu      sync.RWMutex
 	entries map[string]*CacheEntry
 	client  VoiceListClient
+	clock   clock.Clock
 	stats   CacheStats
 }
 
@@ -38,6 +40,7 @@ func NewVoiceCache(client VoiceListClient) *VoiceCache {
 	cache := &VoiceCache{
 		entries: make(map[string]*CacheEntry),
 		client:  client,
+		clock:   clock.Real,
 	}
 
 	go cache.cleanupExpired()
@@ -45,6 +48,13 @@ func NewVoiceCache(client VoiceListClient) *VoiceCache {
 	return cache
 }
 
+// SetClock makes the cache timestamp and expire entries with c
+func (vc *VoiceCache) SetClock(c clock.Clock) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	vc.clock = clock.OrReal(c)
+}
+
 func (vc *VoiceCache) GetVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
 	cacheKey := fmt.Sprintf("voices:%s", languageCode)
 
@@ -66,7 +76,7 @@ func (vc *VoiceCache) GetVoices(ctx context.Context, languageCode string) ([]*te
 	vc.mu.Lock()
 	vc.entries[cacheKey] = &CacheEntry{
 		Data:      voices,
-		Timestamp: time.Now(),
+		Timestamp: vc.clock.Now(),
 		TTL:       15 * time.Minute, // Cache voices for 15 minutes
 	}
 	vc.mu.Unlock()
@@ -75,7 +85,7 @@ func (vc *VoiceCache) GetVoices(ctx context.Context, languageCode string) ([]*te
 }
 
 func (vc *VoiceCache) isExpired(entry *CacheEntry) bool {
-	return time.Since(entry.Timestamp) > entry.TTL
+	return vc.clock.Since(entry.Timestamp) > entry.TTL
 }
 
 func (vc *VoiceCache) cleanupExpired() {
@@ -84,7 +94,7 @@ func (vc *VoiceCache) cleanupExpired() {
 
 	for range ticker.C {
 		vc.mu.Lock()
-		now := time.Now()
+		now := vc.clock.Now()
 		for key, entry := range vc.entries {
 			if now.Sub(entry.Timestamp) > entry.TTL {
 				delete(vc.entries, key)
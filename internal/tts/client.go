@@ -10,6 +10,8 @@ import (
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
 	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -30,26 +32,19 @@ const (
 )
 
 type Client struct {
-	client             *texttospeech.Client
-	defaultVoice       *texttospeechpb.VoiceSelectionParams
-	defaultAudio       *texttospeechpb.AudioConfig
-	retryAttempts      int
-	retryDelay         time.Duration
-	timeout            time.Duration
-	pool               *ConnectionPool
+	client       *texttospeech.Client
+	defaultVoice *texttospeechpb.VoiceSelectionParams
+	defaultAudio *texttospeechpb.AudioConfig
+	// interceptor wraps every API call, see clientInterceptors
+	interceptor grpc.UnaryClientInterceptor
+	pool        *ConnectionPool
+	// poolKey identifies the connection in the pool
+	poolKey            string
 	metrics            *Metrics
 	voiceCache         *VoiceCache
 	performanceMonitor *PerformanceMonitor
 }
 
-type ConnectionPool struct {
-	mu          sync.RWMutex
-	connections map[string]interface{} // Generic connection pool for future use
-	maxSize     int
-	idleTimeout time.Duration
-	lastUsed    map[string]time.Time
-}
-
 type Metrics struct {
 	mu              sync.RWMutex
 	requestCount    int64
@@ -61,6 +56,16 @@ type Metrics struct {
 	avgLatency      time.Duration
 }
 
+// MetricsSnapshot is a serializable, point-in-time copy of client metrics
+type MetricsSnapshot struct {
+	RequestCount     int64 `json:"request_count"`
+	FailedRequests   int64 `json:"failed_requests"`
+	CacheHits        int64 `json:"cache_hits"`
+	CacheMisses      int64 `json:"cache_misses"`
+	AverageLatencyMs int64 `json:"average_latency_ms"`
+	TotalLatencyMs   int64 `json:"total_latency_ms"`
+}
+
 type ClientConfig struct {
 	Voice            string
 	LanguageCode     string
@@ -76,6 +81,30 @@ type ClientConfig struct {
 	KeepAliveTime    time.Duration
 	KeepAliveTimeout time.Duration
 	EnableMetrics    bool
+	// RequestsPerMinute limits API calls made by the client (0 means unlimited)
+	RequestsPerMinute int
+	// RetryMaxDelay caps the delay between retries (0 means DefaultMaxRetryDelay)
+	RetryMaxDelay time.Duration
+	// RetryBackoff is how the delay between retries grows (empty means linear)
+	RetryBackoff BackoffStrategy
+	// TotalTimeout bounds a request across all its attempts and the delays
+	// between them (0 means each attempt gets the full Timeout)
+	TotalTimeout time.Duration
+	// EffectsProfile lists the audio profiles applied to the speech (empty
+	// means headphone-class-device)
+	EffectsProfile []string
+	// Interceptors wrap each attempt of every API call, inside the retries,
+	// rate limiting, and timeout, e.g. to trace or inject faults
+	Interceptors []grpc.UnaryClientInterceptor
+	// Clock times the retry delays, rate limiting, voice cache expiry, and
+	// performance benchmarks (nil means the system clock)
+	Clock clock.Clock
+	// Recording records API calls to a cassette or replays them from one
+	// (nil means calls go to the API unrecorded); see RecordingFromEnv
+	Recording *Recording
+	// CustomVoice is the resource name of a Custom Voice model spoken with
+	// instead of Voice
+	CustomVoice string
 }
 
 func DefaultClientConfig() *ClientConfig {
@@ -88,6 +117,8 @@ func DefaultClientConfig() *ClientConfig {
 		AudioEncoding:    "MP3",
 		RetryAttempts:    3,
 		RetryDelay:       1 * time.Second,
+		RetryMaxDelay:    DefaultMaxRetryDelay,
+		RetryBackoff:     BackoffLinear,
 		Timeout:          30 * time.Second,
 		PoolMaxSize:      10,
 		PoolIdleTimeout:  5 * time.Minute,
@@ -98,19 +129,11 @@ func DefaultClientConfig() *ClientConfig {
 }
 
 func NewClient(ctx context.Context, authManager *auth.AuthManager, config *ClientConfig) (*Client, error) {
-	if authManager == nil {
-		return nil, fmt.Errorf("auth manager is required")
-	}
-
 	if config == nil {
 		config = DefaultClientConfig()
 	}
-
-	pool := &ConnectionPool{
-		connections: make(map[string]interface{}),
-		maxSize:     config.PoolMaxSize,
-		idleTimeout: config.PoolIdleTimeout,
-		lastUsed:    make(map[string]time.Time),
+	if authManager == nil && !config.Recording.Replaying() {
+		return nil, fmt.Errorf("auth manager is required")
 	}
 
 	var metrics *Metrics
@@ -119,10 +142,19 @@ func NewClient(ctx context.Context, authManager *auth.AuthManager, config *Clien
 	}
 
 	perfMonitor := NewPerformanceMonitor(config.EnableMetrics)
-
-	ttsClient, err := createOptimizedClient(ctx, authManager, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create TTS client: %w", err)
+	if config.Clock != nil {
+		perfMonitor.SetClock(config.Clock)
+	}
+
+	// Replayed calls never reach the API, so they need no connection
+	var pool *ConnectionPool
+	var poolKey string
+	var ttsClient *texttospeech.Client
+	if !config.Recording.Replaying() {
+		var err error
+		if pool, poolKey, ttsClient, err = acquireConnection(ctx, authManager, config); err != nil {
+			return nil, err
+		}
 	}
 
 	audioEncoding := texttospeechpb.AudioEncoding_MP3
@@ -154,73 +186,71 @@ func NewClient(ctx context.Context, authManager *auth.AuthManager, config *Clien
 			SpeakingRate:     config.SpeakingRate,
 			Pitch:            config.Pitch,
 			VolumeGainDb:     config.VolumeGain,
-			EffectsProfileId: []string{"headphone-class-device"},
+			EffectsProfileId: effectsProfile(config.EffectsProfile),
 		},
-		retryAttempts:      config.RetryAttempts,
-		retryDelay:         config.RetryDelay,
-		timeout:            config.Timeout,
+		interceptor:        clientInterceptors(config, metrics),
 		pool:               pool,
+		poolKey:            poolKey,
 		metrics:            metrics,
 		performanceMonitor: perfMonitor,
 	}
 
 	client.voiceCache = NewVoiceCache(client)
-	go client.poolCleanup()
+	if config.Clock != nil {
+		client.voiceCache.SetClock(config.Clock)
+	}
 
 	return client, nil
 }
 
-func createOptimizedClient(ctx context.Context, authManager *auth.AuthManager,
-	config *ClientConfig) (*texttospeech.Client, error) {
-	// For now, use the existing auth manager method and enhance it with connection optimization
-	// We could implement our own client creation with custom gRPC options, but that would require
-	// duplicating the authentication logic. For Phase 1.6, we'll focus on other optimizations.
-	return authManager.GetClient(ctx)
-}
-
-func (c *Client) poolCleanup() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		if c.pool != nil {
-			c.pool.cleanup()
-		}
+// acquireConnection takes a connection for the credentials of authManager
+// from the shared pool, reusing one made with the same credentials when
+// there is one
+func acquireConnection(ctx context.Context, authManager *auth.AuthManager,
+	config *ClientConfig) (*ConnectionPool, string, *texttospeech.Client, error) {
+	pool := sharedConnections
+	pool.setLimits(config.PoolMaxSize, config.PoolIdleTimeout)
+	poolKey, err := authManager.ConnectionKey()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to create TTS client: %w", err)
+	}
+	ttsClient, reused, err := pool.acquire(poolKey, func() (*texttospeech.Client, error) {
+		return createOptimizedClient(ctx, authManager)
+	})
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to create TTS client: %w", err)
 	}
+	if reused {
+		warmup(ttsClient)
+	}
+	return pool, poolKey, ttsClient, nil
 }
 
-func (cp *ConnectionPool) cleanup() {
-	cp.mu.Lock()
-	defer cp.mu.Unlock()
-
-	now := time.Now()
-	for key, lastUsed := range cp.lastUsed {
-		if now.Sub(lastUsed) > cp.idleTimeout {
-			if _, exists := cp.connections[key]; exists {
-				// For now, just remove from pool - actual connection cleanup would depend on connection type
-				delete(cp.connections, key)
-				delete(cp.lastUsed, key)
-			}
-		}
-	}
+// createOptimizedClient creates the gRPC client through the auth manager.
+// Keepalive and idle settings must reach the manager as ConnectionOptions in
+// AuthConfig.ClientOptions, since validating the credentials may already have
+// created the client.
+func createOptimizedClient(ctx context.Context, authManager *auth.AuthManager) (*texttospeech.Client, error) {
+	return authManager.GetClient(ctx)
 }
 
-func (c *Client) recordMetrics(start time.Time, success bool) {
-	if c.metrics == nil {
+// record counts a request started at start; a nil Metrics records nothing
+func (m *Metrics) record(start time.Time, success bool) {
+	if m == nil {
 		return
 	}
 
-	c.metrics.mu.Lock()
-	defer c.metrics.mu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	duration := time.Since(start)
-	c.metrics.requestCount++
-	c.metrics.totalLatency += duration
-	c.metrics.lastRequestTime = start
-	c.metrics.avgLatency = c.metrics.totalLatency / time.Duration(c.metrics.requestCount)
+	m.requestCount++
+	m.totalLatency += duration
+	m.lastRequestTime = start
+	m.avgLatency = m.totalLatency / time.Duration(m.requestCount)
 
 	if !success {
-		c.metrics.failedRequests++
+		m.failedRequests++
 	}
 }
 
@@ -243,9 +273,23 @@ func (c *Client) GetMetrics() *Metrics {
 	}
 }
 
+// Snapshot returns a serializable copy of the metrics
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return MetricsSnapshot{
+		RequestCount:     m.requestCount,
+		FailedRequests:   m.failedRequests,
+		CacheHits:        m.cacheHits,
+		CacheMisses:      m.cacheMisses,
+		AverageLatencyMs: m.avgLatency.Milliseconds(),
+		TotalLatencyMs:   m.totalLatency.Milliseconds(),
+	}
+}
+
 func (c *Client) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
 	audio *texttospeechpb.AudioConfig) ([]byte, error) {
-	start := time.Now()
 	var success bool
 	var benchmarkDone func(bool, string)
 
@@ -256,7 +300,6 @@ func (c *Client) Synthesize(ctx context.Context, text string, voice *texttospeec
 	}
 
 	defer func() {
-		c.recordMetrics(start, success)
 		if success {
 			benchmarkDone(true, "")
 		} else {
@@ -294,35 +337,26 @@ func (c *Client) Synthesize(ctx context.Context, text string, voice *texttospeec
 		AudioConfig: audio,
 	}
 
-	var lastErr error
-	for attempt := 0; attempt <= c.retryAttempts; attempt++ {
-		ctxWithTimeout, cancel := context.WithTimeout(ctx, c.timeout)
-		defer cancel()
-
-		resp, err := c.client.SynthesizeSpeech(ctxWithTimeout, req)
+	if tp, ok := wantsTimepoints(ctx, text); ok {
+		audio, timepoints, err := c.synthesizeWithTimepoints(ctx, req)
 		if err == nil {
+			*tp = timepoints
 			success = true
-			return resp.AudioContent, nil
+			return audio, nil
 		}
-
-		lastErr = err
-
-		if !isRetryableError(err) {
+		// Without the v1beta1 API the marks are estimated by the caller
+		if status.Code(err) != codes.Unimplemented {
 			return nil, fmt.Errorf("synthesis failed: %w", err)
 		}
+	}
 
-		if attempt < c.retryAttempts {
-			delay := c.retryDelay * time.Duration(attempt+1)
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(delay):
-				continue
-			}
-		}
+	resp := &texttospeechpb.SynthesizeSpeechResponse{}
+	if err := c.invoke(ctx, methodSynthesizeSpeech, req, resp); err != nil {
+		return nil, fmt.Errorf("synthesis failed: %w", err)
 	}
 
-	return nil, fmt.Errorf("synthesis failed after %d attempts: %w", c.retryAttempts, lastErr)
+	success = true
+	return resp.AudioContent, nil
 }
 
 func (c *Client) ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
@@ -330,22 +364,10 @@ func (c *Client) ListVoices(ctx context.Context, languageCode string) ([]*textto
 		LanguageCode: languageCode,
 	}
 
-	start := time.Now()
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
-
-	resp, err := c.client.ListVoices(ctxWithTimeout, req)
-	if err != nil {
-		if c.metrics != nil {
-			c.recordMetrics(start, false)
-		}
+	resp := &texttospeechpb.ListVoicesResponse{}
+	if err := c.invoke(ctx, methodListVoices, req, resp); err != nil {
 		return nil, fmt.Errorf("failed to list voices: %w", err)
 	}
-
-	if c.metrics != nil {
-		c.recordMetrics(start, true)
-	}
-
 	return resp.Voices, nil
 }
 
@@ -397,11 +419,16 @@ func (c *Client) ResetPerformanceStats() {
 	}
 }
 
+// Close releases the client's connection, which stays open for reuse by
+// other clients until it has been idle for the pool's idle timeout
 func (c *Client) Close() error {
-	if c.client != nil {
-		return c.client.Close()
+	if c.client == nil {
+		return nil
 	}
-	return nil
+	if c.pool != nil {
+		return c.pool.release(c.poolKey, c.client)
+	}
+	return c.client.Close()
 }
 
 func isRetryableError(err error) bool {
@@ -23,6 +23,8 @@ func TestDefaultClientConfig(t *testing.T) {
 	assert.Equal(t, "MP3", config.AudioEncoding)
 	assert.Equal(t, 3, config.RetryAttempts)
 	assert.Equal(t, 1*time.Second, config.RetryDelay)
+	assert.Equal(t, DefaultMaxRetryDelay, config.RetryMaxDelay)
+	assert.Equal(t, BackoffLinear, config.RetryBackoff)
 	assert.Equal(t, 30*time.Second, config.Timeout)
 }
 
@@ -0,0 +1,67 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCustomVoiceUnsupported reports a custom voice requested from a
+// provider other than Google Cloud
+var ErrCustomVoiceUnsupported = errors.New("custom voices need the google provider")
+
+// CustomVoiceModel is a Cloud Text-to-Speech Custom Voice model, named
+// "projects/PROJECT/locations/LOCATION/models/MODEL"
+type CustomVoiceModel struct {
+	Project  string
+	Location string
+	Model    string
+}
+
+// ParseCustomVoiceModel parses the resource name of a Custom Voice model
+func ParseCustomVoiceModel(name string) (CustomVoiceModel, error) {
+	parts := strings.Split(strings.TrimSpace(name), "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "models" ||
+		parts[1] == "" || parts[3] == "" || parts[5] == "" {
+		return CustomVoiceModel{}, fmt.Errorf(
+			"invalid custom voice model %q (want projects/PROJECT/locations/LOCATION/models/MODEL)", name)
+	}
+	return CustomVoiceModel{Project: parts[1], Location: parts[3], Model: parts[5]}, nil
+}
+
+// String returns the resource name of the model
+func (m CustomVoiceModel) String() string {
+	return fmt.Sprintf("projects/%s/locations/%s/models/%s", m.Project, m.Location, m.Model)
+}
+
+// CheckProject returns an error when project, the project of the
+// credentials, is known and is not the project the model belongs to.
+// Models are only usable by other projects once access is granted to them,
+// so the error is a warning rather than a reason to refuse.
+func (m CustomVoiceModel) CheckProject(project string) error {
+	if project == "" || project == m.Project {
+		return nil
+	}
+	return fmt.Errorf("custom voice model %s belongs to project %s, but the credentials are for project %s; "+
+		"synthesis fails unless that project was granted access to the model", m, m.Project, project)
+}
+
+// ExplainCustomVoiceError replaces an API error saying a custom voice model
+// is missing or out of reach with one naming the model and what to check
+func ExplainCustomVoiceError(err error, model string) error {
+	if err == nil || model == "" {
+		return err
+	}
+	switch status.Code(err) {
+	case codes.NotFound:
+		return fmt.Errorf("custom voice model %s was not found; check the name and location in the "+
+			"Custom Voice console: %w", model, err)
+	case codes.PermissionDenied:
+		return fmt.Errorf("the credentials have no access to custom voice model %s; use credentials of "+
+			"its project or have the model shared with yours: %w", model, err)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testCustomVoice = "projects/my-project/locations/us-central1/models/narrator"
+
+func TestParseCustomVoiceModel(t *testing.T) {
+	model, err := ParseCustomVoiceModel(testCustomVoice)
+	require.NoError(t, err)
+	assert.Equal(t, CustomVoiceModel{Project: "my-project", Location: "us-central1", Model: "narrator"}, model)
+	assert.Equal(t, testCustomVoice, model.String())
+
+	for _, name := range []string{"narrator", "projects/p/models/m", "projects//locations/l/models/m",
+		"projects/p/locations/l/voices/m", testCustomVoice + "/extra"} {
+		_, err := ParseCustomVoiceModel(name)
+		assert.ErrorContains(t, err, "invalid custom voice model", name)
+	}
+}
+
+func TestCustomVoiceModel_CheckProject(t *testing.T) {
+	model, err := ParseCustomVoiceModel(testCustomVoice)
+	require.NoError(t, err)
+	assert.NoError(t, model.CheckProject(""), "API keys name no project")
+	assert.NoError(t, model.CheckProject("my-project"))
+	assert.ErrorContains(t, model.CheckProject("other"), "belongs to project my-project")
+}
+
+func TestExplainCustomVoiceError(t *testing.T) {
+	denied := status.Error(codes.PermissionDenied, "denied")
+	assert.Equal(t, denied, ExplainCustomVoiceError(denied, ""))
+
+	err := ExplainCustomVoiceError(denied, testCustomVoice)
+	assert.ErrorContains(t, err, "no access to custom voice model "+testCustomVoice)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	err = ExplainCustomVoiceError(status.Error(codes.NotFound, "no model"), testCustomVoice)
+	assert.ErrorContains(t, err, "was not found")
+
+	other := errors.New("network down")
+	assert.Equal(t, other, ExplainCustomVoiceError(other, testCustomVoice))
+}
+
+func TestSynthesize_CustomVoice(t *testing.T) {
+	client := &mockTTSClient{synthesizeResponse: []byte("audio")}
+	synth := NewSynthesizer(client)
+
+	req := &SynthesizeRequest{Text: "Hello", Voice: "en-US-Wavenet-D", SpeakingRate: 1.0,
+		CustomVoice: testCustomVoice}
+	_, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, testCustomVoice, client.lastVoice.GetCustomVoice().GetModel())
+	assert.Empty(t, client.lastVoice.GetName(), "the custom voice replaces the voice name")
+	assert.Equal(t, "en-US", client.lastVoice.GetLanguageCode())
+
+	req.CustomVoice = "narrator"
+	_, err = synth.Synthesize(context.Background(), req)
+	assert.ErrorContains(t, err, "invalid custom voice model")
+}
+
+func TestEspeakProvider_CustomVoice(t *testing.T) {
+	p := &EspeakProvider{run: func(context.Context, string, ...string) ([]byte, error) {
+		t.Fatal("espeak should not run")
+		return nil, nil
+	}}
+	voice := &texttospeechpb.VoiceSelectionParams{CustomVoice: &texttospeechpb.CustomVoiceParams{Model: testCustomVoice}}
+	_, err := p.Synthesize(context.Background(), "Hello", voice, nil)
+	assert.ErrorIs(t, err, ErrCustomVoiceUnsupported)
+}
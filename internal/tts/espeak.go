@@ -0,0 +1,214 @@
+package tts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+)
+
+// espeakBinaries are the executables tried, in order, by the espeak provider
+var espeakBinaries = []string{"espeak-ng", "espeak"}
+
+// espeak parameter ranges and defaults
+const (
+	espeakDefaultWPM       = 175
+	espeakMinWPM           = 80
+	espeakMaxWPM           = 500
+	espeakDefaultPitch     = 50
+	espeakMaxPitch         = 99
+	espeakDefaultAmplitude = 100
+	espeakMaxAmplitude     = 200
+	espeakSampleRateHertz  = 22050
+)
+
+// espeakRunner runs the espeak binary with args, feeding stdin, and returns stdout
+type espeakRunner func(ctx context.Context, stdin string, args ...string) ([]byte, error)
+
+// EspeakProvider synthesizes speech locally with espeak-ng (or espeak). It needs
+// no credentials or network access, but only produces LINEAR16 (WAV) audio.
+type EspeakProvider struct {
+	binary  string
+	timeout time.Duration
+	run     espeakRunner
+}
+
+// NewEspeakProvider creates a provider using the first espeak binary found on PATH
+func NewEspeakProvider(config *ClientConfig) (*EspeakProvider, error) {
+	if config == nil {
+		config = DefaultClientConfig()
+	}
+
+	var lookupErr error
+	for _, name := range espeakBinaries {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+
+		p := &EspeakProvider{binary: path, timeout: config.Timeout}
+		p.run = p.exec
+		return p, nil
+	}
+
+	return nil, fmt.Errorf("espeak provider requires espeak-ng or espeak on PATH: %w", lookupErr)
+}
+
+// Name returns the provider name
+func (p *EspeakProvider) Name() string {
+	return ProviderEspeak
+}
+
+// Synthesize renders text, which may be SSML, to WAV audio. The voice name is
+// passed to espeak as is; without one the language code selects the voice.
+func (p *EspeakProvider) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audio *texttospeechpb.AudioConfig) ([]byte, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+
+	if audio != nil && audio.GetAudioEncoding() != texttospeechpb.AudioEncoding_LINEAR16 &&
+		audio.GetAudioEncoding() != texttospeechpb.AudioEncoding_AUDIO_ENCODING_UNSPECIFIED {
+		return nil, fmt.Errorf("espeak provider only produces LINEAR16 (WAV) audio, not %s; use --format LINEAR16",
+			audio.GetAudioEncoding())
+	}
+	if voice.GetCustomVoice() != nil {
+		return nil, fmt.Errorf("espeak provider: %w", ErrCustomVoiceUnsupported)
+	}
+
+	args := append([]string{"--stdout", "--stdin"}, espeakArgs(voice, audio)...)
+	if isSSML(text) {
+		args = append(args, "-m")
+	}
+
+	data, err := p.run(ctx, text, args...)
+	if err != nil {
+		return nil, fmt.Errorf("synthesis failed: %w", err)
+	}
+	return data, nil
+}
+
+// ListVoices returns the installed espeak voices whose language matches
+// languageCode, or all voices when languageCode is empty
+func (p *EspeakProvider) ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
+	out, err := p.run(ctx, "", "--voices")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+
+	voices := parseEspeakVoices(out)
+	if languageCode == "" {
+		return voices, nil
+	}
+
+	filtered := make([]*texttospeechpb.Voice, 0, len(voices))
+	for _, v := range voices {
+		if matchesLanguage(v.GetName(), languageCode) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered, nil
+}
+
+// Close is a no-op; each call runs its own process
+func (p *EspeakProvider) Close() error {
+	return nil
+}
+
+func (p *EspeakProvider) exec(ctx context.Context, stdin string, args ...string) ([]byte, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.binary, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", p.binary, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", p.binary, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// espeakArgs translates Google voice and audio parameters to espeak flags
+func espeakArgs(voice *texttospeechpb.VoiceSelectionParams, audio *texttospeechpb.AudioConfig) []string {
+	var args []string
+
+	switch {
+	case voice.GetName() != "":
+		args = append(args, "-v", voice.GetName())
+	case voice.GetLanguageCode() != "":
+		args = append(args, "-v", strings.ToLower(voice.GetLanguageCode()))
+	}
+
+	if audio == nil {
+		return args
+	}
+
+	wpm := espeakDefaultWPM
+	if rate := audio.GetSpeakingRate(); rate > 0 {
+		wpm = clampInt(int(math.Round(espeakDefaultWPM*rate)), espeakMinWPM, espeakMaxWPM)
+	}
+	// Google pitch is -20..20 semitones; espeak pitch is 0..99 around 50
+	pitch := clampInt(int(math.Round(espeakDefaultPitch+audio.GetPitch()*2.5)), 0, espeakMaxPitch)
+	// Google volume gain is in dB; espeak amplitude is linear around 100
+	amplitude := clampInt(int(math.Round(espeakDefaultAmplitude*math.Pow(10, audio.GetVolumeGainDb()/20))),
+		0, espeakMaxAmplitude)
+
+	return append(args,
+		"-s", strconv.Itoa(wpm),
+		"-p", strconv.Itoa(pitch),
+		"-a", strconv.Itoa(amplitude),
+	)
+}
+
+// parseEspeakVoices parses the table printed by espeak --voices:
+//
+//	Pty Language       Age/Gender VoiceName          File                 Other Languages
+//	 5  en-us           --/M      English_(America)  gmw/en-US            (en 3)
+func parseEspeakVoices(out []byte) []*texttospeechpb.Voice {
+	var voices []*texttospeechpb.Voice
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] == "Pty" {
+			continue
+		}
+
+		gender := texttospeechpb.SsmlVoiceGender_NEUTRAL
+		switch {
+		case strings.HasSuffix(fields[2], "/M"):
+			gender = texttospeechpb.SsmlVoiceGender_MALE
+		case strings.HasSuffix(fields[2], "/F"):
+			gender = texttospeechpb.SsmlVoiceGender_FEMALE
+		}
+
+		voices = append(voices, &texttospeechpb.Voice{
+			Name:                   fields[1],
+			LanguageCodes:          []string{fields[1]},
+			SsmlGender:             gender,
+			NaturalSampleRateHertz: espeakSampleRateHertz,
+		})
+	}
+	return voices
+}
+
+func clampInt(v, low, high int) int {
+	return max(low, min(v, high))
+}
@@ -0,0 +1,173 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const espeakVoicesOutput = `Pty Language       Age/Gender VoiceName          File                 Other Languages
+ 5  de              --/M      German             gmw/de
+ 2  en-gb           --/M      English_(Great_Britain) gmw/en            (en 2)
+ 5  en-us           --/F      English_(America)  gmw/en-US            (en 3)
+ 5  fr-fr           --/-      French_(France)    roa/fr               (fr 5)
+`
+
+// fakeEspeak records the arguments and input of each run
+type fakeEspeak struct {
+	args   [][]string
+	stdin  []string
+	output []byte
+	err    error
+}
+
+func (f *fakeEspeak) run(_ context.Context, stdin string, args ...string) ([]byte, error) {
+	f.args = append(f.args, args)
+	f.stdin = append(f.stdin, stdin)
+	return f.output, f.err
+}
+
+func newFakeEspeakProvider(fake *fakeEspeak) *EspeakProvider {
+	return &EspeakProvider{binary: "espeak-ng", run: fake.run}
+}
+
+func TestEspeakProvider_Synthesize(t *testing.T) {
+	fake := &fakeEspeak{output: []byte("RIFF....WAVE")}
+	provider := newFakeEspeakProvider(fake)
+
+	audio, err := provider.Synthesize(context.Background(), "Hello world",
+		&texttospeechpb.VoiceSelectionParams{LanguageCode: "en-US"},
+		&texttospeechpb.AudioConfig{AudioEncoding: texttospeechpb.AudioEncoding_LINEAR16, SpeakingRate: 1.0})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("RIFF....WAVE"), audio)
+
+	require.Len(t, fake.args, 1)
+	assert.Equal(t, []string{"--stdout", "--stdin", "-v", "en-us", "-s", "175", "-p", "50", "-a", "100"},
+		fake.args[0])
+	assert.Equal(t, "Hello world", fake.stdin[0])
+}
+
+func TestEspeakProvider_SynthesizeSSML(t *testing.T) {
+	fake := &fakeEspeak{}
+	provider := newFakeEspeakProvider(fake)
+
+	_, err := provider.Synthesize(context.Background(), "<speak>Hi</speak>",
+		&texttospeechpb.VoiceSelectionParams{Name: "en-gb"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--stdout", "--stdin", "-v", "en-gb", "-m"}, fake.args[0])
+}
+
+func TestEspeakProvider_SynthesizeErrors(t *testing.T) {
+	fake := &fakeEspeak{}
+	provider := newFakeEspeakProvider(fake)
+
+	_, err := provider.Synthesize(context.Background(), "", nil, nil)
+	assert.Error(t, err)
+
+	_, err = provider.Synthesize(context.Background(), "Hello", nil,
+		&texttospeechpb.AudioConfig{AudioEncoding: texttospeechpb.AudioEncoding_MP3})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only produces LINEAR16")
+	assert.Empty(t, fake.args)
+
+	fake.err = errors.New("exit status 1")
+	_, err = provider.Synthesize(context.Background(), "Hello", nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "synthesis failed")
+}
+
+func TestEspeakArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		audio *texttospeechpb.AudioConfig
+		want  []string
+	}{
+		{"fast and high", &texttospeechpb.AudioConfig{SpeakingRate: 2.0, Pitch: 20},
+			[]string{"-s", "350", "-p", "99", "-a", "100"}},
+		{"slow and low", &texttospeechpb.AudioConfig{SpeakingRate: 0.25, Pitch: -20},
+			[]string{"-s", "80", "-p", "0", "-a", "100"}},
+		{"louder", &texttospeechpb.AudioConfig{SpeakingRate: 1.0, VolumeGainDb: 6},
+			[]string{"-s", "175", "-p", "50", "-a", "200"}},
+		{"quieter", &texttospeechpb.AudioConfig{SpeakingRate: 1.0, VolumeGainDb: -6},
+			[]string{"-s", "175", "-p", "50", "-a", "50"}},
+		{"unset rate", &texttospeechpb.AudioConfig{},
+			[]string{"-s", "175", "-p", "50", "-a", "100"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, espeakArgs(nil, tt.audio))
+		})
+	}
+}
+
+func TestEspeakProvider_ListVoices(t *testing.T) {
+	fake := &fakeEspeak{output: []byte(espeakVoicesOutput)}
+	provider := newFakeEspeakProvider(fake)
+
+	voices, err := provider.ListVoices(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, voices, 4)
+	assert.Equal(t, []string{"--voices"}, fake.args[0])
+
+	assert.Equal(t, "de", voices[0].Name)
+	assert.Equal(t, texttospeechpb.SsmlVoiceGender_MALE, voices[0].SsmlGender)
+	assert.Equal(t, "en-gb", voices[1].Name)
+	assert.Equal(t, texttospeechpb.SsmlVoiceGender_FEMALE, voices[2].SsmlGender)
+	assert.Equal(t, texttospeechpb.SsmlVoiceGender_NEUTRAL, voices[3].SsmlGender)
+	assert.Equal(t, int32(espeakSampleRateHertz), voices[3].NaturalSampleRateHertz)
+
+	voices, err = provider.ListVoices(context.Background(), "en")
+	require.NoError(t, err)
+	require.Len(t, voices, 2)
+
+	voices, err = provider.ListVoices(context.Background(), "en-US")
+	require.NoError(t, err)
+	require.Len(t, voices, 1)
+	assert.Equal(t, "en-us", voices[0].Name)
+}
+
+func TestNewEspeakProvider_NotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := NewEspeakProvider(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires espeak-ng or espeak")
+}
+
+func TestNormalizeProvider(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", ProviderGoogle, false},
+		{"google", ProviderGoogle, false},
+		{" Espeak ", ProviderEspeak, false},
+		{"polly", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeProvider(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProvidersImplementInterface(t *testing.T) {
+	var _ Provider = (*Client)(nil)
+	var _ Provider = (*EspeakProvider)(nil)
+
+	assert.Equal(t, ProviderGoogle, (&Client{}).Name())
+	assert.Equal(t, ProviderEspeak, (&EspeakProvider{}).Name())
+}
@@ -0,0 +1,161 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Full method names of the API calls made through the interceptors
+const (
+	methodSynthesizeSpeech = "/google.cloud.texttospeech.v1.TextToSpeech/SynthesizeSpeech"
+	methodListVoices       = "/google.cloud.texttospeech.v1.TextToSpeech/ListVoices"
+)
+
+// Every API call of a Client runs through a chain of gRPC unary
+// interceptors, so that metrics, retries, rate limiting, timeouts, and
+// logging are separate pieces that can be composed and tested on their own.
+// The chain belongs to the Client rather than to the connection, so clients
+// with different settings can share a pooled connection. Interceptors are
+// called with a nil *grpc.ClientConn.
+
+// clientInterceptors returns the chain of a client made with config:
+// metrics around the whole call, then retries, and for each attempt rate
+// limiting, the attempt timeout, logging, config.Interceptors, and
+// innermost the recording of config.Recording
+func clientInterceptors(config *ClientConfig, metrics *Metrics) grpc.UnaryClientInterceptor {
+	limiter := NewRateLimiter(config.RequestsPerMinute)
+	limiter.SetClock(config.Clock)
+	interceptors := []grpc.UnaryClientInterceptor{
+		MetricsInterceptor(metrics),
+		RetryInterceptor(RetryPolicy{
+			Attempts:     config.RetryAttempts,
+			Delay:        config.RetryDelay,
+			MaxDelay:     config.RetryMaxDelay,
+			Backoff:      config.RetryBackoff,
+			TotalTimeout: config.TotalTimeout,
+			Clock:        config.Clock,
+		}),
+		RateLimitInterceptor(limiter),
+		TimeoutInterceptor(config.Timeout),
+		LoggingInterceptor(),
+	}
+	interceptors = append(interceptors, config.Interceptors...)
+	if config.Recording != nil {
+		interceptors = append(interceptors, RecordingInterceptor(config.Recording))
+	}
+	return ChainInterceptors(interceptors...)
+}
+
+// ChainInterceptors combines interceptors into one, the first outermost
+func ChainInterceptors(interceptors ...grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], invoker
+			invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+				opts ...grpc.CallOption) error {
+				return interceptor(ctx, method, req, reply, cc, next, opts...)
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// invoke makes the API call method through the client's interceptors
+func (c *Client) invoke(ctx context.Context, method string, req, reply proto.Message) error {
+	invoker := c.callAPI
+	if c.interceptor == nil {
+		return invoker(ctx, method, req, reply, nil)
+	}
+	return c.interceptor(ctx, method, req, reply, nil, invoker)
+}
+
+// callAPI is the invoker at the end of the chain, making the call with the
+// Cloud client
+func (c *Client) callAPI(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	opts ...grpc.CallOption) error {
+	var resp proto.Message
+	var err error
+	switch req := req.(type) {
+	case *texttospeechpb.SynthesizeSpeechRequest:
+		resp, err = c.client.SynthesizeSpeech(ctx, req)
+	case *texttospeechpb.ListVoicesRequest:
+		resp, err = c.client.ListVoices(ctx, req)
+	case *dynamicpb.Message:
+		if method != methodSynthesizeSpeechBeta {
+			return fmt.Errorf("unsupported API call %s", method)
+		}
+		// The client library has no v1beta1 stub, so the call goes
+		// straight to its connection
+		//nolint:staticcheck // Connection is deprecated for multi-channel pools; ours has one channel
+		return c.client.Connection().Invoke(ctx, method, req, reply, opts...)
+	default:
+		return fmt.Errorf("unsupported API call %s", method)
+	}
+	if err != nil {
+		return err
+	}
+	proto.Merge(reply.(proto.Message), resp)
+	return nil
+}
+
+// MetricsInterceptor counts calls, failures, and latency in metrics, which
+// may be nil to record nothing
+func MetricsInterceptor(metrics *Metrics) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		metrics.record(start, err == nil)
+		return err
+	}
+}
+
+// RateLimitInterceptor waits for limiter before each call; a nil limiter
+// imposes no limit
+func RateLimitInterceptor(limiter *RateLimiter) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// TimeoutInterceptor bounds each call by timeout or, inside
+// RetryInterceptor, by an even share of the time left for the attempts
+// remaining, whichever is shorter
+func TimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if d := attemptTimeout(ctx, timeout, attemptsLeft(ctx)); d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// LoggingInterceptor logs each call at debug level with its outcome and
+// duration
+func LoggingInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		slog.Debug("API call", "method", path.Base(method), "code", status.Code(err).String(),
+			"elapsed", time.Since(start))
+		return err
+	}
+}
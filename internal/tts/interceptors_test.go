@@ -0,0 +1,146 @@
+package tts
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeTTSServer answers SynthesizeSpeech with the text as audio
+type fakeTTSServer struct {
+	texttospeechpb.UnimplementedTextToSpeechServer
+	calls atomic.Int32
+}
+
+func (s *fakeTTSServer) SynthesizeSpeech(ctx context.Context,
+	req *texttospeechpb.SynthesizeSpeechRequest) (*texttospeechpb.SynthesizeSpeechResponse, error) {
+	s.calls.Add(1)
+	return &texttospeechpb.SynthesizeSpeechResponse{AudioContent: []byte(req.GetInput().GetText())}, nil
+}
+
+func (s *fakeTTSServer) ListVoices(ctx context.Context,
+	req *texttospeechpb.ListVoicesRequest) (*texttospeechpb.ListVoicesResponse, error) {
+	s.calls.Add(1)
+	return &texttospeechpb.ListVoicesResponse{Voices: []*texttospeechpb.Voice{{Name: "en-US-Wavenet-D"}}}, nil
+}
+
+// newFakeClient returns a client of config talking to server in memory
+func newFakeClient(t *testing.T, server texttospeechpb.TextToSpeechServer, config *ClientConfig) *Client {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	texttospeechpb.RegisterTextToSpeechServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	apiClient, err := texttospeech.NewClient(context.Background(), option.WithGRPCConn(conn))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = apiClient.Close() })
+
+	metrics := &Metrics{}
+	return &Client{client: apiClient, interceptor: clientInterceptors(config, metrics), metrics: metrics}
+}
+
+func TestClient_Interceptors(t *testing.T) {
+	server := &fakeTTSServer{}
+	var attempts atomic.Int32
+	// Fails the first attempt of each call, as a flaky network would
+	injectFault := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if attempts.Add(1)%2 == 1 {
+			return status.Error(codes.Unavailable, "injected")
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	config := DefaultClientConfig()
+	config.RetryDelay = time.Millisecond
+	config.Interceptors = []grpc.UnaryClientInterceptor{injectFault}
+	client := newFakeClient(t, server, config)
+
+	var retries int
+	audio, err := client.Synthesize(withRetryCount(context.Background(), &retries), "hello", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(audio))
+	assert.Equal(t, 1, retries)
+	assert.Equal(t, int32(2), attempts.Load(), "caller interceptors see every attempt")
+	assert.Equal(t, int32(1), server.calls.Load())
+
+	voices, err := client.ListVoices(context.Background(), "en-US")
+	require.NoError(t, err)
+	require.Len(t, voices, 1)
+	assert.Equal(t, "en-US-Wavenet-D", voices[0].GetName())
+
+	snapshot := client.GetMetrics().Snapshot()
+	assert.Equal(t, int64(2), snapshot.RequestCount, "metrics count calls, not attempts")
+	assert.Zero(t, snapshot.FailedRequests)
+}
+
+func TestChainInterceptors(t *testing.T) {
+	var order []string
+	trace := func(name string) grpc.UnaryClientInterceptor {
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+			invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			order = append(order, name+" in")
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			order = append(order, name+" out")
+			return err
+		}
+	}
+
+	chain := ChainInterceptors(trace("outer"), trace("inner"))
+	err := chain(context.Background(), methodListVoices, nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			order = append(order, "call")
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer in", "inner in", "call", "inner out", "outer out"}, order)
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	metrics := &Metrics{}
+	interceptor := MetricsInterceptor(metrics)
+	fail := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		opts ...grpc.CallOption) error {
+		return status.Error(codes.Internal, "boom")
+	}
+
+	assert.Error(t, interceptor(context.Background(), methodListVoices, nil, nil, nil, fail))
+	snapshot := metrics.Snapshot()
+	assert.Equal(t, int64(1), snapshot.RequestCount)
+	assert.Equal(t, int64(1), snapshot.FailedRequests)
+
+	assert.Error(t, MetricsInterceptor(nil)(context.Background(), methodListVoices, nil, nil, nil, fail),
+		"nil metrics record nothing")
+}
+
+func TestTimeoutInterceptor(t *testing.T) {
+	var deadline time.Time
+	capture := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		opts ...grpc.CallOption) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	}
+
+	require.NoError(t, TimeoutInterceptor(time.Minute)(context.Background(), methodListVoices, nil, nil, nil, capture))
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+}
@@ -0,0 +1,119 @@
+package tts
+
+import (
+	"regexp"
+	"strings"
+)
+
+// languageVoices holds the locale and Google voice chosen for a language
+// detected in the input
+var languageVoices = map[string]struct{ languageCode, voice string }{
+	"ar": {"ar-XA", "ar-XA-Standard-A"},
+	"de": {"de-DE", "de-DE-Standard-A"},
+	"el": {"el-GR", "el-GR-Standard-A"},
+	"en": {"en-US", "en-US-Standard-C"},
+	"es": {"es-ES", "es-ES-Standard-A"},
+	"fr": {"fr-FR", "fr-FR-Standard-A"},
+	"he": {"he-IL", "he-IL-Standard-A"},
+	"hi": {"hi-IN", "hi-IN-Standard-A"},
+	"it": {"it-IT", "it-IT-Standard-A"},
+	"ja": {"ja-JP", "ja-JP-Standard-A"},
+	"ko": {"ko-KR", "ko-KR-Standard-A"},
+	"nl": {"nl-NL", "nl-NL-Standard-A"},
+	"pl": {"pl-PL", "pl-PL-Standard-A"},
+	"pt": {"pt-BR", "pt-BR-Standard-A"},
+	"ru": {"ru-RU", "ru-RU-Standard-A"},
+	"sv": {"sv-SE", "sv-SE-Standard-A"},
+	"th": {"th-TH", "th-TH-Standard-A"},
+	"uk": {"uk-UA", "uk-UA-Standard-A"},
+	"zh": {"cmn-CN", "cmn-CN-Standard-A"},
+}
+
+// LanguageVoice returns the language code and voice to synthesize a
+// language with, given as an ISO 639-1 code such as "de"
+func LanguageVoice(language string) (languageCode, voice string, ok bool) {
+	v, ok := languageVoices[strings.ToLower(language)]
+	return v.languageCode, v.voice, ok
+}
+
+// languageCodePattern matches language codes such as "en-US" and "cmn-CN"
+var languageCodePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}-[a-zA-Z0-9]{2,3}$`)
+
+// PreferredVoice returns the voice that voices, a map of language codes to
+// favorite voices, gives for languageCode: the entry of the code itself,
+// such as "es-MX", or else of its language, such as "es". Keys match in any
+// case. Voices of full language codes may leave out the code, as in
+// "Neural2-F" for "en-US-Neural2-F".
+func PreferredVoice(voices map[string]string, languageCode string) (string, bool) {
+	if languageCode == "" {
+		return "", false
+	}
+	for key, voice := range voices {
+		if strings.EqualFold(key, languageCode) && voice != "" {
+			if HasLanguagePrefix(voice) {
+				return voice, true
+			}
+			return canonicalLanguageCode(languageCode) + "-" + voice, true
+		}
+	}
+	for key, voice := range voices {
+		if strings.EqualFold(key, baseLanguage(languageCode)) && HasLanguagePrefix(voice) {
+			return voice, true
+		}
+	}
+	return "", false
+}
+
+// ResolveVoiceAlias returns the voice aliases gives name, matched in any
+// case, or name itself when it is no alias
+func ResolveVoiceAlias(aliases map[string]string, name string) string {
+	if voice := aliases[strings.ToLower(name)]; voice != "" {
+		return voice
+	}
+	return name
+}
+
+// HasLanguagePrefix reports whether a voice name starts with a language
+// code, as "en-US-Neural2-F" does and "Neural2-F" does not
+func HasLanguagePrefix(voice string) bool {
+	return languageCodePattern.MatchString(VoiceLanguage(voice))
+}
+
+// canonicalLanguageCode writes a language code as voice names do, such as
+// "en-US" for "en-us"
+func canonicalLanguageCode(code string) string {
+	language, region, ok := strings.Cut(code, "-")
+	if !ok {
+		return strings.ToLower(code)
+	}
+	return strings.ToLower(language) + "-" + strings.ToUpper(region)
+}
+
+// SameLanguage reports whether two language codes such as "en-US" and "en"
+// name the same language, ignoring the region. Chinese is written "cmn" or
+// "zh".
+func SameLanguage(a, b string) bool {
+	return strings.EqualFold(baseLanguage(a), baseLanguage(b))
+}
+
+// baseLanguage returns the language of a code without its region
+func baseLanguage(code string) string {
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	switch strings.ToLower(code) {
+	case "cmn", "yue":
+		return "zh"
+	}
+	return code
+}
+
+// VoiceLanguage returns the language code a Google voice name starts with,
+// such as "en-GB" for "en-GB-News-K", or "" for other names
+func VoiceLanguage(voice string) string {
+	parts := strings.SplitN(voice, "-", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[0] + "-" + parts[1]
+}
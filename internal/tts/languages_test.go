@@ -0,0 +1,76 @@
+package tts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguageVoice(t *testing.T) {
+	code, voice, ok := LanguageVoice("DE")
+	assert.True(t, ok)
+	assert.Equal(t, "de-DE", code)
+	assert.Equal(t, "de-DE-Standard-A", voice)
+
+	_, _, ok = LanguageVoice("xx")
+	assert.False(t, ok)
+}
+
+func TestSameLanguage(t *testing.T) {
+	assert.True(t, SameLanguage("en-US", "en"))
+	assert.True(t, SameLanguage("en_GB", "EN-us"))
+	assert.True(t, SameLanguage("cmn-CN", "zh"))
+	assert.False(t, SameLanguage("de-DE", "nl"))
+}
+
+func TestVoiceLanguage(t *testing.T) {
+	assert.Equal(t, "en-GB", VoiceLanguage("en-GB-News-K"))
+	assert.Equal(t, "cmn-CN", VoiceLanguage("cmn-CN-Wavenet-A"))
+	assert.Empty(t, VoiceLanguage("en-us"))
+}
+
+func TestPreferredVoice(t *testing.T) {
+	// Configuration keys arrive lowercased
+	voices := map[string]string{
+		"en-us": "Neural2-F",
+		"es":    "es-US-Neural2-A",
+		"es-es": "es-ES-Neural2-A",
+		"fr":    "Neural2-B",
+	}
+
+	tests := []struct {
+		languageCode string
+		want         string
+		wantOK       bool
+	}{
+		{"en-US", "en-US-Neural2-F", true},
+		{"es-ES", "es-ES-Neural2-A", true},
+		{"es-MX", "es-US-Neural2-A", true},
+		{"fr-FR", "", false},
+		{"de-DE", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.languageCode, func(t *testing.T) {
+			voice, ok := PreferredVoice(voices, tt.languageCode)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, voice)
+		})
+	}
+}
+
+func TestHasLanguagePrefix(t *testing.T) {
+	assert.True(t, HasLanguagePrefix("en-US-Neural2-F"))
+	assert.True(t, HasLanguagePrefix("cmn-CN-Wavenet-A"))
+	assert.False(t, HasLanguagePrefix("Neural2-F"))
+	assert.False(t, HasLanguagePrefix("Chirp3-HD-Aoede"))
+}
+
+func TestResolveVoiceAlias(t *testing.T) {
+	aliases := map[string]string{"sarah": "en-US-Neural2-F"}
+
+	assert.Equal(t, "en-US-Neural2-F", ResolveVoiceAlias(aliases, "Sarah"))
+	assert.Equal(t, "en-GB-Studio-B", ResolveVoiceAlias(aliases, "en-GB-Studio-B"))
+	assert.Empty(t, ResolveVoiceAlias(aliases, ""))
+	assert.Equal(t, "sarah", ResolveVoiceAlias(nil, "sarah"))
+}
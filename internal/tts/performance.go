@@ -6,11 +6,14 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
 )
 
 type PerformanceMonitor struct {
 	mu            sync.RWMutex
 	enabled       bool
+	clock         clock.Clock
 	startupTime   time.Time
 	benchmarks    []Benchmark
 	systemMetrics SystemMetrics
@@ -38,6 +41,7 @@ type SystemMetrics struct {
 func NewPerformanceMonitor(enabled bool) *PerformanceMonitor {
 	pm := &PerformanceMonitor{
 		enabled:     enabled,
+		clock:       clock.Real,
 		startupTime: time.Now(),
 		benchmarks:  make([]Benchmark, 0),
 	}
@@ -49,6 +53,15 @@ func NewPerformanceMonitor(enabled bool) *PerformanceMonitor {
 	return pm
 }
 
+// SetClock makes the monitor time benchmarks and uptime with c, restarting
+// the uptime from the current time of c
+func (pm *PerformanceMonitor) SetClock(c clock.Clock) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.clock = clock.OrReal(c)
+	pm.startupTime = pm.clock.Now()
+}
+
 func (pm *PerformanceMonitor) collectSystemMetrics() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
@@ -82,7 +95,11 @@ func (pm *PerformanceMonitor) StartBenchmark(name string) func(success bool, err
 		return func(bool, string) {}
 	}
 
-	start := time.Now()
+	pm.mu.RLock()
+	clk := pm.clock
+	pm.mu.RUnlock()
+
+	start := clk.Now()
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 	// Convert uint64 to int64 safely
@@ -94,7 +111,7 @@ func (pm *PerformanceMonitor) StartBenchmark(name string) func(success bool, err
 	}
 
 	return func(success bool, errorMsg string) {
-		duration := time.Since(start)
+		duration := clk.Since(start)
 		runtime.ReadMemStats(&m)
 		// Convert uint64 to int64 safely
 		var endMem int64
@@ -133,7 +150,7 @@ func (pm *PerformanceMonitor) GetReport() PerformanceReport {
 	pm.mu.RUnlock()
 
 	pm.systemMetrics.mu.RLock()
-	systemMetrics := SystemMetrics{
+	systemMetrics := &SystemMetrics{
 		memStats:         pm.systemMetrics.memStats,
 		lastGCTime:       pm.systemMetrics.lastGCTime,
 		totalAllocations: pm.systemMetrics.totalAllocations,
@@ -145,7 +162,7 @@ func (pm *PerformanceMonitor) GetReport() PerformanceReport {
 
 	return PerformanceReport{
 		Enabled:       pm.enabled,
-		Uptime:        time.Since(pm.startupTime),
+		Uptime:        pm.clock.Since(pm.startupTime),
 		Benchmarks:    benchmarksCopy,
 		SystemMetrics: systemMetrics,
 		SummaryStats:  pm.calculateSummaryStats(benchmarksCopy),
@@ -156,7 +173,7 @@ type PerformanceReport struct {
 	Enabled       bool
 	Uptime        time.Duration
 	Benchmarks    []Benchmark
-	SystemMetrics SystemMetrics
+	SystemMetrics *SystemMetrics
 	SummaryStats  SummaryStats
 }
 
@@ -207,7 +224,7 @@ func (pm *PerformanceMonitor) calculateSummaryStats(benchmarks []Benchmark) Summ
 	avgMemory := totalMemory / int64(total)
 	successRate := float64(successful) / float64(total) * 100
 
-	uptime := time.Since(pm.startupTime)
+	uptime := pm.clock.Since(pm.startupTime)
 	rps := float64(total) / uptime.Seconds()
 
 	return SummaryStats{
@@ -309,6 +326,6 @@ func (pm *PerformanceMonitor) Reset() {
 
 	pm.mu.Lock()
 	pm.benchmarks = make([]Benchmark, 0)
-	pm.startupTime = time.Now()
+	pm.startupTime = pm.clock.Now()
 	pm.mu.Unlock()
 }
@@ -4,18 +4,29 @@ import (
 	"math"
 	"testing"
 	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
 )
 
 func abs(x float64) float64 {
 	return math.Abs(x)
 }
 
-func TestPerformanceMonitor_StartBenchmark(t *testing.T) {
+// newFakeMonitor returns an enabled monitor timed by a fake clock, so
+// benchmarks take exactly as long as the clock is advanced
+func newFakeMonitor() (*PerformanceMonitor, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC))
 	pm := NewPerformanceMonitor(true)
+	pm.SetClock(fake)
+	return pm, fake
+}
+
+func TestPerformanceMonitor_StartBenchmark(t *testing.T) {
+	pm, fake := newFakeMonitor()
 
 	// Test successful benchmark
 	done := pm.StartBenchmark("test_operation")
-	time.Sleep(10 * time.Millisecond) // Simulate work
+	fake.Advance(10 * time.Millisecond) // Simulate work
 	done(true, "")
 
 	report := pm.GetReport()
@@ -32,16 +43,16 @@ func TestPerformanceMonitor_StartBenchmark(t *testing.T) {
 		t.Error("expected benchmark to be successful")
 	}
 
-	if benchmark.Duration < 10*time.Millisecond {
-		t.Errorf("expected duration >= 10ms, got %v", benchmark.Duration)
+	if benchmark.Duration != 10*time.Millisecond {
+		t.Errorf("expected duration 10ms, got %v", benchmark.Duration)
 	}
 }
 
 func TestPerformanceMonitor_StartBenchmark_Failed(t *testing.T) {
-	pm := NewPerformanceMonitor(true)
+	pm, fake := newFakeMonitor()
 
 	done := pm.StartBenchmark("failed_operation")
-	time.Sleep(5 * time.Millisecond)
+	fake.Advance(5 * time.Millisecond)
 	done(false, "test error")
 
 	report := pm.GetReport()
@@ -73,19 +84,19 @@ func TestPerformanceMonitor_Disabled(t *testing.T) {
 }
 
 func TestPerformanceMonitor_SummaryStats(t *testing.T) {
-	pm := NewPerformanceMonitor(true)
+	pm, fake := newFakeMonitor()
 
 	// Add some benchmarks
 	done1 := pm.StartBenchmark("operation1")
-	time.Sleep(10 * time.Millisecond)
+	fake.Advance(10 * time.Millisecond)
 	done1(true, "")
 
 	done2 := pm.StartBenchmark("operation2")
-	time.Sleep(20 * time.Millisecond)
+	fake.Advance(20 * time.Millisecond)
 	done2(true, "")
 
 	done3 := pm.StartBenchmark("operation3")
-	time.Sleep(15 * time.Millisecond)
+	fake.Advance(15 * time.Millisecond)
 	done3(false, "error")
 
 	report := pm.GetReport()
@@ -108,12 +119,13 @@ func TestPerformanceMonitor_SummaryStats(t *testing.T) {
 		t.Errorf("expected success rate %.2f%%, got %.2f%%", expectedSuccessRate, stats.SuccessRate)
 	}
 
-	if stats.AverageLatency == 0 {
-		t.Error("expected non-zero average latency")
+	if stats.AverageLatency != 15*time.Millisecond {
+		t.Errorf("expected average latency 15ms, got %v", stats.AverageLatency)
 	}
 
-	if stats.RequestsPerSecond <= 0 {
-		t.Error("expected positive requests per second")
+	// Three requests over the 45ms of uptime
+	if expected := 3 / 0.045; abs(stats.RequestsPerSecond-expected) > 0.01 {
+		t.Errorf("expected %.2f requests per second, got %.2f", expected, stats.RequestsPerSecond)
 	}
 }
 
@@ -141,10 +153,10 @@ func TestPerformanceMonitor_Reset(t *testing.T) {
 }
 
 func TestPerformanceMonitor_FormatReport(t *testing.T) {
-	pm := NewPerformanceMonitor(true)
+	pm, fake := newFakeMonitor()
 
 	done := pm.StartBenchmark("test_operation")
-	time.Sleep(10 * time.Millisecond)
+	fake.Advance(10 * time.Millisecond)
 	done(true, "")
 
 	report := pm.FormatReport()
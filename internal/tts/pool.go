@@ -0,0 +1,184 @@
+package tts
+
+import (
+	"sync"
+	"time"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// sharedConnections is the pool NewClient takes connections from
+var sharedConnections = NewConnectionPool(DefaultClientConfig().PoolMaxSize, DefaultClientConfig().PoolIdleTimeout)
+
+// ConnectionPool shares TTS API connections between clients created with
+// the same credentials, so sequential jobs in one process reuse a warm gRPC
+// channel instead of dialing and handshaking again. A connection no client
+// uses is closed once it has been idle for the idle timeout.
+type ConnectionPool struct {
+	mu          sync.Mutex
+	connections map[string]*pooledConnection
+	maxSize     int
+	idleTimeout time.Duration
+}
+
+// pooledConnection is a shared connection and the number of clients using it
+type pooledConnection struct {
+	client *texttospeech.Client
+	refs   int
+	// idle closes the connection once unused for the idle timeout
+	idle *time.Timer
+}
+
+// NewConnectionPool creates a pool keeping up to maxSize connections, each
+// closed after idleTimeout unused. A pool with a maxSize of 0 shares nothing.
+func NewConnectionPool(maxSize int, idleTimeout time.Duration) *ConnectionPool {
+	return &ConnectionPool{
+		connections: make(map[string]*pooledConnection),
+		maxSize:     maxSize,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// setLimits changes the size and idle timeout of the pool
+func (cp *ConnectionPool) setLimits(maxSize int, idleTimeout time.Duration) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.maxSize = maxSize
+	cp.idleTimeout = idleTimeout
+}
+
+// Len returns the number of connections in the pool
+func (cp *ConnectionPool) Len() int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return len(cp.connections)
+}
+
+// acquire returns the pooled connection for key, or dials a new one and
+// pools it when there is room. reused reports whether the connection was
+// pooled already.
+func (cp *ConnectionPool) acquire(key string,
+	dial func() (*texttospeech.Client, error)) (client *texttospeech.Client, reused bool, err error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if conn, ok := cp.connections[key]; ok {
+		conn.refs++
+		if conn.idle != nil {
+			conn.idle.Stop()
+			conn.idle = nil
+		}
+		return conn.client, true, nil
+	}
+
+	client, err = dial()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(cp.connections) >= cp.maxSize {
+		cp.evictIdle()
+	}
+	if len(cp.connections) < cp.maxSize {
+		cp.connections[key] = &pooledConnection{client: client, refs: 1}
+	}
+	return client, false, nil
+}
+
+// release gives back a connection acquired for key. A pooled connection is
+// closed once idle for the idle timeout, any other at once.
+func (cp *ConnectionPool) release(key string, client *texttospeech.Client) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	conn, ok := cp.connections[key]
+	if !ok || conn.client != client {
+		return client.Close()
+	}
+
+	conn.refs--
+	if conn.refs > 0 {
+		return nil
+	}
+	if cp.idleTimeout <= 0 {
+		delete(cp.connections, key)
+		return client.Close()
+	}
+	conn.idle = time.AfterFunc(cp.idleTimeout, func() { cp.closeIdle(key, conn) })
+	return nil
+}
+
+// closeIdle closes a connection that stayed unused for the idle timeout
+func (cp *ConnectionPool) closeIdle(key string, conn *pooledConnection) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if cp.connections[key] != conn || conn.refs > 0 {
+		return
+	}
+	delete(cp.connections, key)
+	_ = conn.client.Close()
+}
+
+// evictIdle closes one unused connection to make room for another
+func (cp *ConnectionPool) evictIdle() {
+	for key, conn := range cp.connections {
+		if conn.refs == 0 {
+			if conn.idle != nil {
+				conn.idle.Stop()
+			}
+			delete(cp.connections, key)
+			_ = conn.client.Close()
+			return
+		}
+	}
+}
+
+// Close closes every pooled connection, e.g. before the process exits
+func (cp *ConnectionPool) Close() {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	for key, conn := range cp.connections {
+		if conn.idle != nil {
+			conn.idle.Stop()
+		}
+		delete(cp.connections, key)
+		_ = conn.client.Close()
+	}
+}
+
+// CloseConnections closes the connections shared between clients
+func CloseConnections() {
+	sharedConnections.Close()
+}
+
+// ConnectionOptions returns the client options applying the keepalive and
+// idle settings of config to a gRPC connection. Keepalive pings detect a
+// connection dropped by a proxy or NAT while a request is in flight; an idle
+// connection releases its transport and reconnects on the next request.
+func ConnectionOptions(config *ClientConfig) []option.ClientOption {
+	var opts []option.ClientOption
+	if config.KeepAliveTime > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    config.KeepAliveTime,
+			Timeout: config.KeepAliveTimeout,
+		})))
+	}
+	if config.PoolIdleTimeout > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithIdleTimeout(config.PoolIdleTimeout)))
+	}
+	return opts
+}
+
+// warmup starts reconnecting a reused connection that went idle, so the
+// handshake overlaps with preparing the first request instead of delaying it
+func warmup(client *texttospeech.Client) {
+	//nolint:staticcheck // Connection is deprecated for multi-channel pools; ours has one channel
+	if conn := client.Connection(); conn != nil {
+		conn.Connect()
+	}
+}
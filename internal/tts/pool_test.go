@@ -0,0 +1,126 @@
+package tts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// dialCounter returns a dial function for a client that never connects and
+// counts how often it is called
+func dialCounter(t *testing.T, dials *int) func() (*texttospeech.Client, error) {
+	t.Helper()
+	return func() (*texttospeech.Client, error) {
+		*dials++
+		return texttospeech.NewClient(context.Background(),
+			option.WithEndpoint("localhost:0"),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	}
+}
+
+func TestConnectionPool_Reuse(t *testing.T) {
+	pool := NewConnectionPool(2, time.Minute)
+	defer pool.Close()
+	dials := 0
+
+	first, reused, err := pool.acquire("key", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.False(t, reused)
+
+	second, reused, err := pool.acquire("key", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.True(t, reused)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, dials)
+
+	// Released connections stay pooled for the next client
+	require.NoError(t, pool.release("key", first))
+	require.NoError(t, pool.release("key", second))
+	third, reused, err := pool.acquire("key", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.True(t, reused)
+	assert.Same(t, first, third)
+
+	// Other credentials get their own connection
+	other, reused, err := pool.acquire("other", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.False(t, reused)
+	assert.NotSame(t, first, other)
+	assert.Equal(t, 2, pool.Len())
+}
+
+func TestConnectionPool_IdleTimeout(t *testing.T) {
+	pool := NewConnectionPool(2, 10*time.Millisecond)
+	defer pool.Close()
+	dials := 0
+
+	client, _, err := pool.acquire("key", dialCounter(t, &dials))
+	require.NoError(t, err)
+	require.NoError(t, pool.release("key", client))
+
+	assert.Eventually(t, func() bool { return pool.Len() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestConnectionPool_InUseIsNotClosed(t *testing.T) {
+	pool := NewConnectionPool(2, 10*time.Millisecond)
+	defer pool.Close()
+	dials := 0
+
+	client, _, err := pool.acquire("key", dialCounter(t, &dials))
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 1, pool.Len())
+	require.NoError(t, pool.release("key", client))
+}
+
+func TestConnectionPool_Full(t *testing.T) {
+	pool := NewConnectionPool(1, time.Minute)
+	defer pool.Close()
+	dials := 0
+
+	first, _, err := pool.acquire("first", dialCounter(t, &dials))
+	require.NoError(t, err)
+
+	// With the only slot in use, a second connection is not pooled
+	second, _, err := pool.acquire("second", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Len())
+	require.NoError(t, pool.release("second", second))
+
+	// Once the first is unused it makes room for another
+	require.NoError(t, pool.release("first", first))
+	_, _, err = pool.acquire("third", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Len())
+	_, reused, err := pool.acquire("first", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.False(t, reused)
+}
+
+func TestConnectionPool_Disabled(t *testing.T) {
+	pool := NewConnectionPool(0, time.Minute)
+	dials := 0
+
+	client, _, err := pool.acquire("key", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.Equal(t, 0, pool.Len())
+	require.NoError(t, pool.release("key", client))
+
+	_, reused, err := pool.acquire("key", dialCounter(t, &dials))
+	require.NoError(t, err)
+	assert.False(t, reused)
+	assert.Equal(t, 2, dials)
+}
+
+func TestConnectionOptions(t *testing.T) {
+	assert.Len(t, ConnectionOptions(DefaultClientConfig()), 2)
+	assert.Empty(t, ConnectionOptions(&ClientConfig{}))
+}
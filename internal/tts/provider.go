@@ -0,0 +1,60 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mikefarmer/assistant-cli/internal/audio/ffmpeg"
+)
+
+// Provider names accepted by the tts.provider setting
+const (
+	ProviderGoogle = "google"
+	ProviderEspeak = "espeak"
+)
+
+// Provider is a speech synthesis backend. All backends accept the Google
+// Cloud voice and audio parameters and translate them as closely as they can,
+// so the Synthesizer and voice listing work unchanged across providers.
+type Provider interface {
+	TTSClient
+
+	// Name returns the provider name as used in tts.provider
+	Name() string
+}
+
+// Providers returns the names of all supported providers
+func Providers() []string {
+	return []string{ProviderGoogle, ProviderEspeak}
+}
+
+// NormalizeProvider maps an empty provider name to the default and rejects
+// unknown names
+func NormalizeProvider(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ProviderGoogle, nil
+	}
+
+	for _, known := range Providers() {
+		if name == known {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown TTS provider %q (supported: %s)", name, strings.Join(Providers(), ", "))
+}
+
+// SupportedFormats returns the audio formats the named provider can produce,
+// or nil when it supports every format. Formats transcoded with ffmpeg are
+// made from WAV audio, so every provider supports them.
+func SupportedFormats(name string) []string {
+	if name == ProviderEspeak {
+		return append([]string{audioEncodingLINEAR16, formatWAV}, ffmpeg.Formats()...)
+	}
+	return nil
+}
+
+// Name returns the provider name of the Google Cloud client
+func (c *Client) Name() string {
+	return ProviderGoogle
+}
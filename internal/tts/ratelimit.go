@@ -0,0 +1,103 @@
+package tts
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+)
+
+// RateLimiter is a client-side token bucket that spaces out API requests so
+// that long-running jobs stay within the per-minute Google Cloud quota.
+// A nil RateLimiter imposes no limit.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+	clock    clock.Clock
+}
+
+// NewRateLimiter creates a rate limiter allowing requestsPerMinute requests
+// per minute. It returns nil, meaning unlimited, when requestsPerMinute is
+// not positive.
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{
+		interval: time.Minute / time.Duration(requestsPerMinute),
+		burst:    1,
+		tokens:   1,
+		clock:    clock.Real,
+	}
+}
+
+// SetClock makes the limiter measure and wait for time with c
+func (rl *RateLimiter) SetClock(c clock.Clock) {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.clock = clock.OrReal(c)
+}
+
+// Wait blocks until a request may be made or the context is canceled
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	if rl == nil {
+		return nil
+	}
+
+	delay := rl.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	rl.mu.Lock()
+	after := rl.clock.After
+	rl.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		rl.cancel()
+		return ctx.Err()
+	case <-after(delay):
+		return nil
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it
+func (rl *RateLimiter) reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.clock.Now()
+	if !rl.last.IsZero() {
+		rl.tokens += float64(now.Sub(rl.last)) / float64(rl.interval)
+		if rl.tokens > rl.burst {
+			rl.tokens = rl.burst
+		}
+	}
+	rl.last = now
+
+	rl.tokens--
+	if rl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-rl.tokens * float64(rl.interval))
+}
+
+// cancel returns a reserved token that was not used
+func (rl *RateLimiter) cancel() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.tokens++
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+}
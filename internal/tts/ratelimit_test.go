@@ -0,0 +1,60 @@
+package tts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimiter_Disabled(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0))
+	assert.Nil(t, NewRateLimiter(-5))
+
+	// A nil limiter never blocks
+	var limiter *RateLimiter
+	assert.NoError(t, limiter.Wait(context.Background()))
+}
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	limiter := NewRateLimiter(60) // one request per second
+	limiter.SetClock(fake)
+
+	// The first request goes through immediately
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+
+	// Back-to-back requests queue up one interval apart
+	assert.Equal(t, time.Second, limiter.reserve())
+	assert.Equal(t, 2*time.Second, limiter.reserve())
+
+	// After enough idle time the bucket refills, but never beyond one token
+	fake.Advance(10 * time.Second)
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Second, limiter.reserve())
+}
+
+func TestRateLimiter_WaitSpacesRequests(t *testing.T) {
+	limiter := NewRateLimiter(1200) // one request every 50ms
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestRateLimiter_WaitCanceled(t *testing.T) {
+	limiter := NewRateLimiter(1) // one request per minute
+	require.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := limiter.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
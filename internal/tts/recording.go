@@ -0,0 +1,279 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Environment variables toggling the recorded-response mode, in which API
+// calls are recorded to or replayed from a cassette file so that tests of
+// synthesis and voice listing run without credentials
+const (
+	// EnvRecording selects the mode: "record" makes real calls and saves
+	// them, "replay" answers every call from the cassette
+	EnvRecording = "ASSISTANT_CLI_TTS_RECORDING"
+	// EnvCassette is the path of the cassette file
+	EnvCassette = "ASSISTANT_CLI_TTS_CASSETTE"
+)
+
+// RecordingMode says whether API calls are recorded or replayed
+type RecordingMode string
+
+// Recording modes
+const (
+	RecordingRecord RecordingMode = "record"
+	RecordingReplay RecordingMode = "replay"
+)
+
+// ErrNotRecorded reports a call in replay mode that the cassette holds no
+// response for
+var ErrNotRecorded = errors.New("no recorded response")
+
+// cassetteVersion identifies the cassette file format
+const cassetteVersion = 1
+
+// Recording is the recorded-response setting of a client
+type Recording struct {
+	Mode     RecordingMode
+	Cassette *Cassette
+}
+
+// Replaying reports whether calls are answered from the cassette, in which
+// case the client needs neither credentials nor a connection. It is false
+// for a nil Recording.
+func (r *Recording) Replaying() bool {
+	return r != nil && r.Mode == RecordingReplay
+}
+
+// RecordingFromEnv returns the recording set by EnvRecording and
+// EnvCassette, or nil when EnvRecording is unset. Replaying needs an
+// existing cassette; recording adds to one if it exists.
+func RecordingFromEnv() (*Recording, error) {
+	mode := RecordingMode(strings.ToLower(strings.TrimSpace(os.Getenv(EnvRecording))))
+	switch mode {
+	case "":
+		return nil, nil
+	case RecordingRecord, RecordingReplay:
+	default:
+		return nil, fmt.Errorf("invalid %s %q (valid: record, replay)", EnvRecording, mode)
+	}
+
+	file := os.Getenv(EnvCassette)
+	if file == "" {
+		return nil, fmt.Errorf("%s=%s needs %s to name the cassette file", EnvRecording, mode, EnvCassette)
+	}
+	cassette, err := LoadCassette(file)
+	if errors.Is(err, os.ErrNotExist) && mode == RecordingRecord {
+		cassette, err = NewCassette(file), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Recording{Mode: mode, Cassette: cassette}, nil
+}
+
+// Interaction is a recorded API call: the request and either the response
+// or the error status, in protobuf JSON so cassettes can be reviewed
+type Interaction struct {
+	// Method is the short method name, e.g. "SynthesizeSpeech"
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    *RecordedError  `json:"error,omitempty"`
+}
+
+// RecordedError is the gRPC status of a recorded call that failed
+type RecordedError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Cassette is a file of recorded API calls. It is safe for concurrent use.
+type Cassette struct {
+	mu           sync.Mutex
+	path         string
+	interactions []Interaction
+}
+
+// cassetteFile is the on-disk form of a cassette
+type cassetteFile struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// NewCassette returns an empty cassette saved to path
+func NewCassette(path string) *Cassette {
+	return &Cassette{path: path}
+}
+
+// LoadCassette reads the cassette at path
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	if file.Version != cassetteVersion {
+		return nil, fmt.Errorf("cassette %s has unsupported version %d", path, file.Version)
+	}
+	return &Cassette{path: path, interactions: file.Interactions}, nil
+}
+
+// Path returns the file the cassette is saved to
+func (c *Cassette) Path() string {
+	return c.path
+}
+
+// Interactions returns the recorded calls in the order they were made
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Record adds a call with its response or error, replacing an earlier
+// recording of the same request, and saves the cassette
+func (c *Cassette) Record(method string, req, resp proto.Message, callErr error) error {
+	request, err := protojson.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to record request: %w", err)
+	}
+	interaction := Interaction{Method: path.Base(method), Request: compactJSON(request)}
+	if callErr != nil {
+		st := status.Convert(callErr)
+		interaction.Error = &RecordedError{Code: st.Code().String(), Message: st.Message()}
+	} else {
+		response, err := protojson.Marshal(resp)
+		if err != nil {
+			return fmt.Errorf("failed to record response: %w", err)
+		}
+		interaction.Response = compactJSON(response)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i, ok := c.find(interaction.Method, req); ok {
+		c.interactions[i] = interaction
+	} else {
+		c.interactions = append(c.interactions, interaction)
+	}
+	return c.save()
+}
+
+// Replay fills reply with the recorded response to req, or returns the
+// recorded error. It returns an error wrapping ErrNotRecorded when the
+// cassette holds no call of method with an equal request.
+func (c *Cassette) Replay(method string, req, reply proto.Message) error {
+	c.mu.Lock()
+	i, ok := c.find(path.Base(method), req)
+	var interaction Interaction
+	if ok {
+		interaction = c.interactions[i]
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		request, _ := protojson.Marshal(req)
+		return fmt.Errorf("%w in cassette %s for %s %s; record it again with %s=record",
+			ErrNotRecorded, c.path, path.Base(method), compactJSON(request), EnvRecording)
+	}
+	if interaction.Error != nil {
+		return status.Error(parseCode(interaction.Error.Code), interaction.Error.Message)
+	}
+	if err := protojson.Unmarshal(interaction.Response, reply); err != nil {
+		return fmt.Errorf("invalid recorded response in cassette %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// find returns the index of the recorded call of method with a request
+// equal to req
+func (c *Cassette) find(method string, req proto.Message) (int, bool) {
+	for i, interaction := range c.interactions {
+		if interaction.Method != method {
+			continue
+		}
+		recorded := req.ProtoReflect().New().Interface()
+		if err := protojson.Unmarshal(interaction.Request, recorded); err == nil && proto.Equal(recorded, req) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// save writes the cassette to its file
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(cassetteFile{Version: cassetteVersion, Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := output.WriteFileAtomic(c.path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to save cassette: %w", err)
+	}
+	return nil
+}
+
+// compactJSON removes the whitespace protojson adds at random
+func compactJSON(data []byte) json.RawMessage {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return data
+	}
+	return compact.Bytes()
+}
+
+// parseCode maps a recorded status code name back to the code
+func parseCode(name string) codes.Code {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if code.String() == name {
+			return code
+		}
+	}
+	return codes.Unknown
+}
+
+// RecordingInterceptor records each call to the cassette of recording, or
+// answers it from the cassette without calling the API when replaying. It
+// sits innermost in the chain, so retries, rate limiting, and metrics work
+// the same on recorded calls.
+func RecordingInterceptor(recording *Recording) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		request, reqOK := req.(proto.Message)
+		response, replyOK := reply.(proto.Message)
+		if recording == nil || !reqOK || !replyOK {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if recording.Replaying() {
+			return recording.Cassette.Replay(method, request, response)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if ctx.Err() != nil {
+			// A canceled call says nothing about the API
+			return err
+		}
+		if recordErr := recording.Cassette.Record(method, request, response, err); recordErr != nil {
+			return errors.Join(err, recordErr)
+		}
+		return err
+	}
+}
@@ -0,0 +1,107 @@
+package tts
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failingVoicesServer refuses to list voices for a language
+type failingVoicesServer struct {
+	fakeTTSServer
+}
+
+func (s *failingVoicesServer) ListVoices(ctx context.Context,
+	req *texttospeechpb.ListVoicesRequest) (*texttospeechpb.ListVoicesResponse, error) {
+	return nil, status.Error(codes.InvalidArgument, "unknown language "+req.GetLanguageCode())
+}
+
+func TestRecording_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	server := &fakeTTSServer{}
+	recorder := newFakeClient(t, server, &ClientConfig{
+		Recording: &Recording{Mode: RecordingRecord, Cassette: NewCassette(path)},
+	})
+
+	audio, err := recorder.Synthesize(context.Background(), "Hello", nil, nil)
+	require.NoError(t, err)
+	voices, err := recorder.ListVoices(context.Background(), "en-US")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.calls.Load())
+
+	// Replaying needs no server: the client has no connection at all
+	t.Setenv(EnvRecording, "replay")
+	t.Setenv(EnvCassette, path)
+	recording, err := RecordingFromEnv()
+	require.NoError(t, err)
+	require.True(t, recording.Replaying())
+	require.Len(t, recording.Cassette.Interactions(), 2)
+
+	config := &ClientConfig{Recording: recording}
+	replayer := &Client{interceptor: clientInterceptors(config, nil)}
+	replayed, err := replayer.Synthesize(context.Background(), "Hello", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, audio, replayed)
+
+	replayedVoices, err := replayer.ListVoices(context.Background(), "en-US")
+	require.NoError(t, err)
+	require.Len(t, replayedVoices, 1)
+	assert.Equal(t, voices[0].GetName(), replayedVoices[0].GetName())
+
+	_, err = replayer.Synthesize(context.Background(), "Goodbye", nil, nil)
+	assert.ErrorIs(t, err, ErrNotRecorded)
+	assert.ErrorContains(t, err, `"text":"Goodbye"`)
+}
+
+func TestRecording_ReplaysErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := newFakeClient(t, &failingVoicesServer{}, &ClientConfig{
+		Recording: &Recording{Mode: RecordingRecord, Cassette: NewCassette(path)},
+	})
+	_, err := recorder.ListVoices(context.Background(), "xx-XX")
+	require.Error(t, err)
+
+	cassette, err := LoadCassette(path)
+	require.NoError(t, err)
+	replayer := &Client{interceptor: clientInterceptors(&ClientConfig{
+		Recording: &Recording{Mode: RecordingReplay, Cassette: cassette},
+	}, nil)}
+
+	_, err = replayer.ListVoices(context.Background(), "xx-XX")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, "unknown language xx-XX")
+}
+
+func TestRecordingFromEnv(t *testing.T) {
+	t.Setenv(EnvRecording, "")
+	recording, err := RecordingFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, recording)
+	assert.False(t, recording.Replaying())
+
+	t.Setenv(EnvRecording, "rewind")
+	_, err = RecordingFromEnv()
+	assert.ErrorContains(t, err, "invalid "+EnvRecording)
+
+	t.Setenv(EnvRecording, "replay")
+	t.Setenv(EnvCassette, "")
+	_, err = RecordingFromEnv()
+	assert.ErrorContains(t, err, "needs "+EnvCassette)
+
+	// Replaying a missing cassette fails; recording starts a new one
+	t.Setenv(EnvCassette, filepath.Join(t.TempDir(), "missing.json"))
+	_, err = RecordingFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv(EnvRecording, "record")
+	recording, err = RecordingFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, RecordingRecord, recording.Mode)
+	assert.Empty(t, recording.Cassette.Interactions())
+}
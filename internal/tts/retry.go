@@ -0,0 +1,210 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BackoffStrategy is how the delay between retries of a failed request grows
+type BackoffStrategy string
+
+const (
+	// BackoffLinear waits the base delay times the attempt number
+	BackoffLinear BackoffStrategy = "linear"
+	// BackoffExponential doubles the delay with each attempt
+	BackoffExponential BackoffStrategy = "exponential"
+	// BackoffJittered doubles the delay with each attempt and waits a random
+	// time in the upper half of it, so concurrent jobs do not retry in lockstep
+	BackoffJittered BackoffStrategy = "jittered"
+)
+
+// DefaultMaxRetryDelay caps the delay between retries when no cap is set
+const DefaultMaxRetryDelay = 60 * time.Second
+
+// ParseBackoffStrategy parses a backoff strategy name, with empty meaning linear
+func ParseBackoffStrategy(name string) (BackoffStrategy, error) {
+	switch strategy := BackoffStrategy(name); strategy {
+	case "":
+		return BackoffLinear, nil
+	case BackoffLinear, BackoffExponential, BackoffJittered:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown backoff strategy %q (valid: linear, exponential, jittered)", name)
+	}
+}
+
+// retryDelay returns how long to wait before retrying after err, growing
+// from base as strategy says and capped at max. Quota errors always back off
+// exponentially with jitter, whatever the strategy, since retrying them
+// quickly only uses up more of the quota.
+func retryDelay(strategy BackoffStrategy, base, max time.Duration, attempt int, err error) time.Duration {
+	if max <= 0 {
+		max = DefaultMaxRetryDelay
+	}
+	if status.Code(err) == codes.ResourceExhausted {
+		strategy = BackoffJittered
+	}
+
+	delay := max
+	switch strategy {
+	case BackoffExponential, BackoffJittered:
+		if attempt < 16 {
+			if d := base << attempt; d > 0 && d < max {
+				delay = d
+			}
+		}
+	default:
+		if d := base * time.Duration(attempt+1); d < max {
+			delay = d
+		}
+	}
+
+	if strategy != BackoffJittered {
+		return delay
+	}
+
+	// Jitter within the upper half of the window keeps a minimum spacing between retries
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1)
+}
+
+// retryCountKey is the context key of the retry counter of a request
+type retryCountKey struct{}
+
+// withRetryCount returns a context in which the client adds each retry of
+// the request to n
+func withRetryCount(ctx context.Context, n *int) context.Context {
+	return context.WithValue(ctx, retryCountKey{}, n)
+}
+
+// countRetry adds a retry to the counter of ctx, if any
+func countRetry(ctx context.Context) {
+	if n, ok := ctx.Value(retryCountKey{}).(*int); ok {
+		*n++
+	}
+}
+
+// RetryPolicy says how RetryInterceptor retries failed calls
+type RetryPolicy struct {
+	// Attempts is the number of retries after the first attempt
+	Attempts int
+	// Delay is the base delay before a retry, grown as Backoff says and
+	// capped at MaxDelay (0 means DefaultMaxRetryDelay)
+	Delay    time.Duration
+	MaxDelay time.Duration
+	Backoff  BackoffStrategy
+	// TotalTimeout bounds the attempts and the delays between them together
+	// (0 means no bound)
+	TotalTimeout time.Duration
+	// Clock waits out the delays (nil means the system clock). The total
+	// timeout is a context deadline and always follows the system clock.
+	Clock clock.Clock
+}
+
+// attemptsLeftKey is the context key of the number of attempts left,
+// including the current one
+type attemptsLeftKey struct{}
+
+// attemptsLeft returns the attempts left in a call made by RetryInterceptor,
+// or 1 outside one
+func attemptsLeft(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptsLeftKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// RetryInterceptor repeats a call until it succeeds, fails with an error not
+// worth retrying, or runs out of attempts or of the total timeout. The
+// attempts see how many are left, so TimeoutInterceptor further in can give
+// each an even share of the time left, and a hanging attempt cannot use up
+// the time of its retries.
+func RetryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	clk := clock.OrReal(policy.Clock)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		budget := ctx
+		if policy.TotalTimeout > 0 {
+			var cancel context.CancelFunc
+			budget, cancel = context.WithTimeout(ctx, policy.TotalTimeout)
+			defer cancel()
+		}
+
+		var lastErr error
+		for attempt := 0; attempt <= policy.Attempts; attempt++ {
+			attemptCtx := context.WithValue(budget, attemptsLeftKey{}, policy.Attempts-attempt+1)
+			err := invoker(attemptCtx, method, req, reply, cc, opts...)
+			if err == nil {
+				return nil
+			}
+			if _, isStatus := status.FromError(err); !isStatus && budget.Err() != nil {
+				// Stopped before the call was made, e.g. waiting for the rate limit
+				return policy.budgetError(ctx, attempt, lastErr, budget.Err())
+			}
+			lastErr = err
+
+			if !isRetryableError(err) {
+				return err
+			}
+			if attempt == policy.Attempts {
+				break
+			}
+
+			delay := retryDelay(policy.Backoff, policy.Delay, policy.MaxDelay, attempt, err)
+			if deadline, ok := budget.Deadline(); ok && time.Until(deadline) < delay {
+				return policy.budgetError(ctx, attempt+1, lastErr, context.DeadlineExceeded)
+			}
+			select {
+			case <-budget.Done():
+				return policy.budgetError(ctx, attempt+1, lastErr, budget.Err())
+			case <-clk.After(delay):
+				countRetry(ctx)
+			}
+		}
+
+		if budget.Err() != nil {
+			return policy.budgetError(ctx, policy.Attempts+1, lastErr, budget.Err())
+		}
+		return fmt.Errorf("gave up after %d attempts: %w", policy.Attempts+1, lastErr)
+	}
+}
+
+// budgetError reports that the attempts stopped early with err, because ctx
+// was canceled or the total timeout ran out after the given attempts
+func (policy RetryPolicy) budgetError(ctx context.Context, attempts int, lastErr, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if policy.TotalTimeout <= 0 {
+		return err
+	}
+	if lastErr == nil {
+		lastErr = status.Error(codes.DeadlineExceeded, "no attempt was made")
+	}
+	return fmt.Errorf("tts.total_timeout of %s used up after %d attempts: %w", policy.TotalTimeout, attempts, lastErr)
+}
+
+// attemptTimeout returns the timeout of an attempt: the time left before
+// the deadline of ctx split evenly between the attempts left, at most
+// timeout
+func attemptTimeout(ctx context.Context, timeout time.Duration, attemptsLeft int) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok || attemptsLeft < 1 {
+		return timeout
+	}
+	share := time.Until(deadline) / time.Duration(attemptsLeft)
+	if timeout > 0 && share > timeout {
+		return timeout
+	}
+	return share
+}
@@ -0,0 +1,203 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseBackoffStrategy(t *testing.T) {
+	for name, want := range map[string]BackoffStrategy{
+		"":            BackoffLinear,
+		"linear":      BackoffLinear,
+		"exponential": BackoffExponential,
+		"jittered":    BackoffJittered,
+	} {
+		strategy, err := ParseBackoffStrategy(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, strategy)
+	}
+
+	_, err := ParseBackoffStrategy("fibonacci")
+	assert.ErrorContains(t, err, "unknown backoff strategy")
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+
+	t.Run("transient errors back off linearly", func(t *testing.T) {
+		err := status.Error(codes.Unavailable, "unavailable")
+		assert.Equal(t, base, retryDelay(BackoffLinear, base, 0, 0, err))
+		assert.Equal(t, 3*base, retryDelay(BackoffLinear, base, 0, 2, err))
+	})
+
+	t.Run("exponential backoff doubles", func(t *testing.T) {
+		err := status.Error(codes.Unavailable, "unavailable")
+		assert.Equal(t, base, retryDelay(BackoffExponential, base, 0, 0, err))
+		assert.Equal(t, 4*base, retryDelay(BackoffExponential, base, 0, 2, err))
+	})
+
+	t.Run("jittered backoff stays in the upper half of the window", func(t *testing.T) {
+		err := status.Error(codes.Unavailable, "unavailable")
+		for i := 0; i < 20; i++ {
+			delay := retryDelay(BackoffJittered, base, 0, 3, err)
+			assert.GreaterOrEqual(t, delay, 4*base)
+			assert.LessOrEqual(t, delay, 8*base)
+		}
+	})
+
+	t.Run("delays are capped", func(t *testing.T) {
+		err := status.Error(codes.Unavailable, "unavailable")
+		assert.Equal(t, 250*time.Millisecond, retryDelay(BackoffLinear, base, 250*time.Millisecond, 5, err))
+		assert.Equal(t, 250*time.Millisecond, retryDelay(BackoffExponential, base, 250*time.Millisecond, 5, err))
+	})
+
+	t.Run("quota errors back off exponentially with jitter", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", status.Error(codes.ResourceExhausted, "quota exceeded"))
+		for attempt := 0; attempt < 4; attempt++ {
+			window := base << attempt
+			for i := 0; i < 20; i++ {
+				delay := retryDelay(BackoffLinear, base, 0, attempt, err)
+				assert.GreaterOrEqual(t, delay, window/2)
+				assert.LessOrEqual(t, delay, window)
+			}
+		}
+	})
+
+	t.Run("quota backoff is capped", func(t *testing.T) {
+		err := status.Error(codes.ResourceExhausted, "quota exceeded")
+		for _, attempt := range []int{10, 40, 100} {
+			delay := retryDelay(BackoffLinear, time.Second, 0, attempt, err)
+			assert.GreaterOrEqual(t, delay, DefaultMaxRetryDelay/2)
+			assert.LessOrEqual(t, delay, DefaultMaxRetryDelay)
+		}
+	})
+
+	t.Run("non-grpc errors back off linearly", func(t *testing.T) {
+		assert.Equal(t, 2*base, retryDelay(BackoffLinear, base, 0, 1, errors.New("boom")))
+	})
+}
+
+// withRetries makes call through RetryInterceptor with policy and
+// TimeoutInterceptor with timeout
+func withRetries(ctx context.Context, policy RetryPolicy, timeout time.Duration,
+	call func(ctx context.Context) error) error {
+	chain := ChainInterceptors(RetryInterceptor(policy), TimeoutInterceptor(timeout))
+	return chain(ctx, methodSynthesizeSpeech, nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			return call(ctx)
+		})
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	t.Run("retries until success", func(t *testing.T) {
+		policy, timeout := RetryPolicy{Attempts: 3, Delay: time.Millisecond}, time.Second
+		calls := 0
+		err := withRetries(context.Background(), policy, timeout, func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return unavailable
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops at errors not worth retrying", func(t *testing.T) {
+		policy, timeout := RetryPolicy{Attempts: 3, Delay: time.Millisecond}, time.Second
+		calls := 0
+		invalid := status.Error(codes.InvalidArgument, "bad voice")
+		err := withRetries(context.Background(), policy, timeout, func(ctx context.Context) error {
+			calls++
+			return invalid
+		})
+		assert.Equal(t, invalid, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		policy, timeout := RetryPolicy{Attempts: 2, Delay: time.Millisecond}, time.Second
+		err := withRetries(context.Background(), policy, timeout, func(ctx context.Context) error { return unavailable })
+		assert.EqualError(t, err, "gave up after 3 attempts: "+unavailable.Error())
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("the total timeout bounds hanging attempts", func(t *testing.T) {
+		policy := RetryPolicy{Attempts: 3, Delay: time.Millisecond, TotalTimeout: 200 * time.Millisecond}
+		timeout := time.Minute
+		var timeouts []time.Duration
+		start := time.Now()
+		err := withRetries(context.Background(), policy, timeout, func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			timeouts = append(timeouts, time.Until(deadline))
+			<-ctx.Done()
+			return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+		})
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Contains(t, err.Error(), "tts.total_timeout of 200ms used up")
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		require.NotEmpty(t, timeouts)
+		assert.LessOrEqual(t, timeouts[0], 50*time.Millisecond, "the first attempt gets its share of the budget")
+	})
+
+	t.Run("the total timeout stops waiting for a retry", func(t *testing.T) {
+		policy, timeout := RetryPolicy{Attempts: 3, Delay: time.Minute, TotalTimeout: time.Second}, time.Second
+		calls := 0
+		err := withRetries(context.Background(), policy, timeout, func(ctx context.Context) error {
+			calls++
+			return unavailable
+		})
+		assert.Contains(t, err.Error(), "used up after 1 attempts")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("cancellation is returned as is", func(t *testing.T) {
+		policy, timeout := RetryPolicy{Attempts: 3, Delay: time.Minute, TotalTimeout: time.Minute}, time.Second
+		ctx, cancel := context.WithCancel(context.Background())
+		err := withRetries(ctx, policy, timeout, func(ctx context.Context) error {
+			cancel()
+			return unavailable
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("waits out the backoff on the clock", func(t *testing.T) {
+		fake := clock.NewFake(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC))
+		policy := RetryPolicy{Attempts: 3, Delay: time.Minute, MaxDelay: time.Hour,
+			Backoff: BackoffExponential, Clock: fake}
+		var calledAt []time.Time
+		err := withRetries(context.Background(), policy, time.Second, func(ctx context.Context) error {
+			calledAt = append(calledAt, fake.Now())
+			return unavailable
+		})
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute}, fake.Waits())
+		require.Len(t, calledAt, 4)
+		assert.Equal(t, 7*time.Minute, calledAt[3].Sub(calledAt[0]))
+	})
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	assert.Equal(t, 30*time.Second, attemptTimeout(context.Background(), 30*time.Second, 4))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	share := attemptTimeout(ctx, 30*time.Second, 4)
+	assert.LessOrEqual(t, share, 15*time.Second)
+	assert.Greater(t, share, 14*time.Second)
+	assert.Equal(t, 30*time.Second, attemptTimeout(ctx, 30*time.Second, 1), "capped at the request timeout")
+}
@@ -7,8 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/audio/ffmpeg"
+	"github.com/mikefarmer/assistant-cli/internal/output"
 )
 
 // Audio format constants
@@ -17,6 +21,14 @@ const (
 	formatOGG = "OGG"
 )
 
+// Containers of mu-law and A-law audio
+const (
+	// ContainerWAV writes the samples in a WAV file, as Twilio plays them
+	ContainerWAV = "wav"
+	// ContainerRaw writes headerless samples, as Asterisk plays them
+	ContainerRaw = "raw"
+)
+
 // TTSClient interface for testability
 type TTSClient interface {
 	Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
@@ -25,8 +37,14 @@ type TTSClient interface {
 	Close() error
 }
 
+// AudioProcessor transforms synthesized audio in the given format before it
+// is saved
+type AudioProcessor func(audio []byte, format string) ([]byte, error)
+
 type Synthesizer struct {
-	client TTSClient
+	client        TTSClient
+	postProcess   AudioProcessor
+	writeMetadata bool
 }
 
 type SynthesizeRequest struct {
@@ -38,6 +56,21 @@ type SynthesizeRequest struct {
 	VolumeGain   float64
 	OutputFile   string
 	AudioFormat  string
+	// SampleRate in Hz for linear PCM formats; 0 uses the provider default,
+	// or output.DefaultSampleRate for WAV files
+	SampleRate int
+	// EffectsProfile lists the audio profiles applied to the speech; empty
+	// uses headphone-class-device
+	EffectsProfile []string
+	// CustomVoice is the resource name of a Custom Voice model to speak
+	// with instead of Voice
+	CustomVoice string
+	// Container is ContainerWAV or ContainerRaw to write MULAW and ALAW
+	// audio in; empty keeps the audio as the provider returns it
+	Container string
+	// Timepoints asks for the times at which the audio reaches the SSML
+	// <mark> elements of Text, where the provider can report them
+	Timepoints bool
 }
 
 type SynthesizeResponse struct {
@@ -45,6 +78,16 @@ type SynthesizeResponse struct {
 	OutputFile string
 	Format     string
 	Size       int
+	// Latency is the time the provider took to return the audio
+	Latency time.Duration
+	// Retries counts the requests repeated after transient errors
+	Retries int
+	// Duration is the playing time of the audio, or 0 when it cannot be
+	// told, e.g. for headerless audio of an unknown sample rate
+	Duration time.Duration
+	// Timepoints are the times of the <mark> elements of the text when
+	// the request asked for them and the provider reported them
+	Timepoints []Timepoint
 }
 
 func NewSynthesizer(client TTSClient) *Synthesizer {
@@ -53,6 +96,17 @@ func NewSynthesizer(client TTSClient) *Synthesizer {
 	}
 }
 
+// SetPostProcessor sets a processor applied to all synthesized audio
+func (s *Synthesizer) SetPostProcessor(process AudioProcessor) {
+	s.postProcess = process
+}
+
+// SetWriteMetadata enables embedding the text, voice and synthesis date as
+// tags in formats that support them
+func (s *Synthesizer) SetWriteMetadata(enabled bool) {
+	s.writeMetadata = enabled
+}
+
 func (s *Synthesizer) SynthesizeFromReader(ctx context.Context, reader io.Reader,
 	req *SynthesizeRequest) (*SynthesizeResponse, error) {
 	textData, err := io.ReadAll(reader)
@@ -92,37 +146,94 @@ func (s *Synthesizer) Synthesize(ctx context.Context, req *SynthesizeRequest) (*
 	}
 
 	voice := &texttospeechpb.VoiceSelectionParams{}
-	if req.Voice != "" {
+	if req.CustomVoice != "" {
+		voice.CustomVoice = &texttospeechpb.CustomVoiceParams{Model: req.CustomVoice}
+	} else if req.Voice != "" {
 		voice.Name = req.Voice
 	}
 	if req.LanguageCode != "" {
 		voice.LanguageCode = req.LanguageCode
-	} else if req.Voice == "" {
+	} else if voice.Name == "" {
 		voice.LanguageCode = "en-US"
 	}
 
 	audioEncoding := s.getAudioEncoding(req.AudioFormat)
-	audio := &texttospeechpb.AudioConfig{
+	audioConfig := &texttospeechpb.AudioConfig{
 		AudioEncoding:    audioEncoding,
 		SpeakingRate:     req.SpeakingRate,
 		Pitch:            req.Pitch,
 		VolumeGainDb:     req.VolumeGain,
-		EffectsProfileId: []string{"headphone-class-device"},
+		EffectsProfileId: effectsProfile(req.EffectsProfile),
+	}
+
+	// Transcoded formats are encoded locally from WAV audio
+	transcode, transcoded := ffmpeg.Lookup(req.AudioFormat)
+	sourceFormat := req.AudioFormat
+	if transcoded {
+		sourceFormat = formatWAV
 	}
 
-	audioData, err := s.client.Synthesize(ctx, req.Text, voice, audio)
+	// WAV headers must state the sample rate, so request a known one
+	wav := isWAVFormat(sourceFormat)
+	sampleRate := req.SampleRate
+	if sampleRate == 0 && wav {
+		sampleRate = output.DefaultSampleRate
+	}
+	audioConfig.SampleRateHertz = int32(sampleRate)
+
+	var retries int
+	var timepoints []Timepoint
+	callCtx := withRetryCount(ctx, &retries)
+	if req.Timepoints {
+		callCtx = withTimepoints(callCtx, &timepoints)
+	}
+	start := time.Now()
+	audioData, err := s.client.Synthesize(callCtx, req.Text, voice, audioConfig)
 	if err != nil {
-		return nil, fmt.Errorf("synthesis failed: %w", err)
+		return nil, fmt.Errorf("synthesis failed: %w", ExplainCustomVoiceError(err, req.CustomVoice))
+	}
+	latency := time.Since(start)
+
+	if wav {
+		audioData = output.EnsureWAV(audioData, sampleRate, 1)
+	}
+	if isG711Format(sourceFormat) {
+		audioData = g711Container(audioData, sourceFormat, req.Container, sampleRate)
+	}
+
+	if s.postProcess != nil {
+		if audioData, err = s.postProcess(audioData, sourceFormat); err != nil {
+			return nil, fmt.Errorf("post-processing failed: %w", err)
+		}
+	}
+
+	// The WAV audio tells its length, which the transcoded file may not
+	duration := audioDuration(audioData, sourceFormat, sampleRate)
+	if transcoded {
+		if audioData, err = ffmpeg.Transcode(ctx, audioData, transcode); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.writeMetadata && output.SupportsTags(req.AudioFormat) {
+		tags := output.NewTags(req.Text, req.Voice, req.LanguageCode, time.Now())
+		if audioData, err = output.WriteTags(audioData, req.AudioFormat, tags); err != nil {
+			return nil, fmt.Errorf("failed to write metadata: %w", err)
+		}
 	}
 
 	response := &SynthesizeResponse{
-		AudioData: audioData,
-		Format:    req.AudioFormat,
-		Size:      len(audioData),
+		AudioData:  audioData,
+		Format:     req.AudioFormat,
+		Size:       len(audioData),
+		Latency:    latency,
+		Retries:    retries,
+		Duration:   duration,
+		Timepoints: timepoints,
 	}
 
 	if req.OutputFile != "" {
-		outputPath, err := s.saveToFile(audioData, req.OutputFile, req.AudioFormat)
+		outputPath, err := s.saveToFile(audioData, req.OutputFile, ContainerExtension(req.AudioFormat, req.Container))
 		if err != nil {
 			return nil, fmt.Errorf("failed to save audio: %w", err)
 		}
@@ -145,6 +256,14 @@ func (s *Synthesizer) validateRequest(req *SynthesizeRequest) error {
 		return fmt.Errorf("volume gain must be between -96.0 and 16.0, got %f", req.VolumeGain)
 	}
 
+	if req.SampleRate != 0 && (req.SampleRate < 8000 || req.SampleRate > 48000) {
+		return fmt.Errorf("sample rate must be between 8000 and 48000 Hz, got %d", req.SampleRate)
+	}
+
+	if req.Container != "" && req.Container != ContainerWAV && req.Container != ContainerRaw {
+		return fmt.Errorf("unknown audio container %q", req.Container)
+	}
+
 	if len(req.Text) > 5000 && !isSSML(req.Text) {
 		return fmt.Errorf("text length exceeds 5000 characters")
 	}
@@ -155,10 +274,94 @@ func (s *Synthesizer) validateRequest(req *SynthesizeRequest) error {
 		}
 	}
 
+	if req.CustomVoice != "" {
+		if _, err := ParseCustomVoiceModel(req.CustomVoice); err != nil {
+			return err
+		}
+	}
+
+	// Check for ffmpeg before the synthesis is paid for
+	if ffmpeg.IsFormat(req.AudioFormat) {
+		if _, err := ffmpeg.Path(); err != nil {
+			return fmt.Errorf("%s output needs ffmpeg: %w", strings.ToUpper(req.AudioFormat), err)
+		}
+	}
+
 	return nil
 }
 
+// isWAVFormat reports whether format is written as a WAV file of 16-bit
+// linear PCM
+func isWAVFormat(format string) bool {
+	switch strings.ToUpper(format) {
+	case audioEncodingLINEAR16, formatWAV:
+		return true
+	default:
+		return false
+	}
+}
+
+// audioDuration returns the playing time of audio in format: read from the
+// WAV, Ogg Opus, or MP3 data, or derived from the length of headerless PCM,
+// mu-law, and A-law audio of a known sample rate. It is 0 when it cannot be
+// told.
+func audioDuration(data []byte, format string, sampleRate int) time.Duration {
+	if duration, err := audio.Duration(data); err == nil {
+		return duration
+	}
+
+	var sampleSize int
+	switch strings.ToUpper(format) {
+	case audioEncodingPCM:
+		sampleSize = 2
+	case audioEncodingMULAW, audioEncodingALAW:
+		sampleSize = 1
+	}
+	if sampleSize == 0 || sampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(len(data)/sampleSize) * time.Second / time.Duration(sampleRate)
+}
+
+// isG711Format reports whether format is 8-bit mu-law or A-law audio
+func isG711Format(format string) bool {
+	switch strings.ToUpper(format) {
+	case audioEncodingMULAW, audioEncodingALAW:
+		return true
+	default:
+		return false
+	}
+}
+
+// g711Container returns mu-law or A-law audio in container, which keeps it
+// as it is when empty
+func g711Container(data []byte, format, container string, sampleRate int) []byte {
+	if sampleRate == 0 {
+		sampleRate = output.TelephonySampleRate
+	}
+	switch container {
+	case ContainerWAV:
+		return output.G711WAV(data, strings.EqualFold(format, audioEncodingALAW), sampleRate, 1)
+	case ContainerRaw:
+		return output.StripWAV(data)
+	default:
+		return data
+	}
+}
+
+// effectsProfile returns the audio profiles to request, headphone-class-device
+// unless others are given
+func effectsProfile(profiles []string) []string {
+	if len(profiles) == 0 {
+		return []string{"headphone-class-device"}
+	}
+	return profiles
+}
+
 func (s *Synthesizer) getAudioEncoding(format string) texttospeechpb.AudioEncoding {
+	if ffmpeg.IsFormat(format) {
+		return texttospeechpb.AudioEncoding_LINEAR16
+	}
 	switch strings.ToUpper(format) {
 	case audioEncodingLINEAR16, formatWAV:
 		return texttospeechpb.AudioEncoding_LINEAR16
@@ -177,15 +380,17 @@ func (s *Synthesizer) getAudioEncoding(format string) texttospeechpb.AudioEncodi
 	}
 }
 
-func (s *Synthesizer) saveToFile(audioData []byte, outputFile string, format string) (string, error) {
+// saveToFile writes audioData to outputFile, adding extension when the
+// file name has none
+func (s *Synthesizer) saveToFile(audioData []byte, outputFile string, extension string) (string, error) {
 	outputFile = filepath.Clean(outputFile)
 
 	if outputFile == "" {
-		outputFile = fmt.Sprintf("output.%s", s.getFileExtension(format))
+		outputFile = fmt.Sprintf("output.%s", extension)
 	}
 
 	if !strings.Contains(filepath.Base(outputFile), ".") {
-		outputFile = fmt.Sprintf("%s.%s", outputFile, s.getFileExtension(format))
+		outputFile = fmt.Sprintf("%s.%s", outputFile, extension)
 	}
 
 	dir := filepath.Dir(outputFile)
@@ -195,7 +400,7 @@ func (s *Synthesizer) saveToFile(audioData []byte, outputFile string, format str
 		}
 	}
 
-	if err := os.WriteFile(outputFile, audioData, 0600); err != nil {
+	if err := output.WriteFileAtomic(outputFile, audioData, 0600); err != nil {
 		return "", fmt.Errorf("failed to write audio file: %w", err)
 	}
 
@@ -208,6 +413,14 @@ func (s *Synthesizer) saveToFile(audioData []byte, outputFile string, format str
 }
 
 func (s *Synthesizer) getFileExtension(format string) string {
+	return FileExtension(format)
+}
+
+// FileExtension returns the file extension, without a dot, for an audio format
+func FileExtension(format string) string {
+	if transcoded, ok := ffmpeg.Lookup(format); ok {
+		return transcoded.Extension
+	}
 	switch strings.ToUpper(format) {
 	case audioEncodingLINEAR16, formatWAV:
 		return "wav"
@@ -226,6 +439,26 @@ func (s *Synthesizer) getFileExtension(format string) string {
 	}
 }
 
+// ContainerExtension returns the file extension, without a dot, for audio
+// in format written in container: wav for ContainerWAV, and the ulaw and
+// alaw of Asterisk for headerless mu-law and A-law
+func ContainerExtension(format, container string) string {
+	if !isG711Format(format) {
+		return FileExtension(format)
+	}
+	switch container {
+	case ContainerWAV:
+		return "wav"
+	case ContainerRaw:
+		if strings.EqualFold(format, audioEncodingALAW) {
+			return "alaw"
+		}
+		return "ulaw"
+	default:
+		return FileExtension(format)
+	}
+}
+
 func validateSSML(text string) error {
 	if !strings.HasPrefix(text, "<speak>") {
 		return fmt.Errorf("SSML must start with <speak> tag")
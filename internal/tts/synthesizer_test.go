@@ -3,10 +3,16 @@ package tts
 import (
 	"bytes"
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/output"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -297,6 +303,7 @@ func TestGetAudioEncodingFromSynthesizer(t *testing.T) {
 		{"MULAW", texttospeechpb.AudioEncoding_MULAW},
 		{"ALAW", texttospeechpb.AudioEncoding_ALAW},
 		{"PCM", texttospeechpb.AudioEncoding_PCM},
+		{"FLAC", texttospeechpb.AudioEncoding_LINEAR16},
 		{"unknown", texttospeechpb.AudioEncoding_MP3},
 		{"", texttospeechpb.AudioEncoding_MP3},
 	}
@@ -325,6 +332,8 @@ func TestGetFileExtension(t *testing.T) {
 		{"MULAW", "mulaw"},
 		{"ALAW", "alaw"},
 		{"PCM", "pcm"},
+		{"AAC", "m4a"},
+		{"webm", "webm"},
 		{"unknown", "mp3"},
 		{"", "mp3"},
 	}
@@ -369,10 +378,14 @@ type mockTTSClient struct {
 	synthesizeError    error
 	listVoicesResponse []*texttospeechpb.Voice
 	listVoicesError    error
+	lastAudioConfig    *texttospeechpb.AudioConfig
+	lastVoice          *texttospeechpb.VoiceSelectionParams
 }
 
 func (m *mockTTSClient) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
 	audio *texttospeechpb.AudioConfig) ([]byte, error) {
+	m.lastAudioConfig = audio
+	m.lastVoice = voice
 	return m.synthesizeResponse, m.synthesizeError
 }
 
@@ -412,3 +425,209 @@ func TestSynthesizeFromReader_Integration(t *testing.T) {
 	assert.Equal(t, "MP3", resp.Format)
 	assert.Equal(t, 15, resp.Size)
 }
+
+func TestSynthesize_PostProcessor(t *testing.T) {
+	synth := NewSynthesizer(&mockTTSClient{synthesizeResponse: []byte("raw")})
+	synth.SetPostProcessor(func(audio []byte, format string) ([]byte, error) {
+		return append(audio, []byte("+"+format)...), nil
+	})
+
+	req := &SynthesizeRequest{Text: "Hello", SpeakingRate: 1.0, AudioFormat: "OGG_OPUS"}
+	resp, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("raw+OGG_OPUS"), resp.AudioData)
+	assert.Equal(t, len("raw+OGG_OPUS"), resp.Size)
+
+	synth.SetPostProcessor(func([]byte, string) ([]byte, error) {
+		return nil, errors.New("not a WAV file")
+	})
+	_, err = synth.Synthesize(context.Background(), req)
+	assert.ErrorContains(t, err, "post-processing failed: not a WAV file")
+}
+
+func TestSynthesize_WriteMetadata(t *testing.T) {
+	mp3 := []byte{0xFF, 0xFB, 0x90, 0x00}
+	synth := NewSynthesizer(&mockTTSClient{synthesizeResponse: mp3})
+	req := &SynthesizeRequest{Text: "Hello", Voice: "en-US-Wavenet-D", SpeakingRate: 1.0, AudioFormat: "MP3"}
+
+	resp, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, mp3, resp.AudioData, "metadata is off by default")
+
+	synth.SetWriteMetadata(true)
+	resp, err = synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "ID3", string(resp.AudioData[:3]))
+	assert.Contains(t, string(resp.AudioData), "en-US-Wavenet-D")
+	assert.Equal(t, mp3, resp.AudioData[output.ID3v2Size(resp.AudioData):])
+
+	// Formats without tag support are left alone
+	req.AudioFormat = "MULAW"
+	resp, err = synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, mp3, resp.AudioData)
+
+	req.AudioFormat = "OGG_OPUS"
+	_, err = synth.Synthesize(context.Background(), req)
+	assert.ErrorContains(t, err, "failed to write metadata")
+}
+
+func TestSynthesize_WAVContainer(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		sampleRate     int
+		wantSampleRate int32
+		wantHeader     bool
+	}{
+		{"WAV uses default sample rate", "WAV", 0, output.DefaultSampleRate, true},
+		{"LINEAR16 with explicit rate", "LINEAR16", 16000, 16000, true},
+		{"PCM stays headerless", "PCM", 8000, 8000, false},
+		{"MP3 leaves rate to the voice", "MP3", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockTTSClient{synthesizeResponse: []byte{1, 0, 2, 0}}
+			synth := NewSynthesizer(client)
+
+			req := &SynthesizeRequest{Text: "Hello", SpeakingRate: 1.0, AudioFormat: tt.format,
+				SampleRate: tt.sampleRate}
+			resp, err := synth.Synthesize(context.Background(), req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantSampleRate, client.lastAudioConfig.GetSampleRateHertz())
+			if tt.wantHeader {
+				assert.Equal(t, append(output.WAVHeader(4, int(tt.wantSampleRate), 1), 1, 0, 2, 0), resp.AudioData)
+			} else {
+				assert.Equal(t, []byte{1, 0, 2, 0}, resp.AudioData)
+			}
+		})
+	}
+}
+
+func TestSynthesize_G711Container(t *testing.T) {
+	samples := []byte{0xff, 0x7f, 0x00, 0x80}
+	tests := []struct {
+		name      string
+		format    string
+		container string
+		response  []byte
+		want      []byte
+		wantFile  string
+	}{
+		{"mu-law WAV", "MULAW", ContainerWAV, samples, output.G711WAV(samples, false, 8000, 1), "hold.wav"},
+		{"A-law WAV", "ALAW", ContainerWAV, samples, output.G711WAV(samples, true, 8000, 1), "hold.wav"},
+		{"headerless mu-law", "MULAW", ContainerRaw, output.G711WAV(samples, false, 8000, 1), samples, "hold.ulaw"},
+		{"provider audio kept", "MULAW", "", samples, samples, "hold.mulaw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synth := NewSynthesizer(&mockTTSClient{synthesizeResponse: tt.response})
+
+			req := &SynthesizeRequest{Text: "Hello", SpeakingRate: 1.0, AudioFormat: tt.format,
+				SampleRate: 8000, Container: tt.container, OutputFile: filepath.Join(t.TempDir(), "hold")}
+			resp, err := synth.Synthesize(context.Background(), req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, resp.AudioData)
+			assert.Equal(t, tt.wantFile, filepath.Base(resp.OutputFile))
+			assert.Equal(t, 500*time.Microsecond, resp.Duration)
+		})
+	}
+}
+
+func TestSynthesize_InvalidSampleRate(t *testing.T) {
+	synth := NewSynthesizer(&mockTTSClient{})
+
+	req := &SynthesizeRequest{Text: "Hello", SpeakingRate: 1.0, AudioFormat: "WAV", SampleRate: 100}
+	_, err := synth.Synthesize(context.Background(), req)
+	assert.ErrorContains(t, err, "sample rate must be between 8000 and 48000 Hz")
+}
+
+// retryingTTSClient counts retries the way Client does before succeeding
+type retryingTTSClient struct {
+	mockTTSClient
+	retries int
+}
+
+func (m *retryingTTSClient) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audio *texttospeechpb.AudioConfig) ([]byte, error) {
+	for i := 0; i < m.retries; i++ {
+		countRetry(ctx)
+	}
+	return m.synthesizeResponse, nil
+}
+
+func TestSynthesize_Retries(t *testing.T) {
+	client := &retryingTTSClient{mockTTSClient: mockTTSClient{synthesizeResponse: []byte("audio")}, retries: 2}
+	synth := NewSynthesizer(client)
+
+	resp, err := synth.SynthesizeText(context.Background(), "Hello", &SynthesizeRequest{SpeakingRate: 1.0})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Retries)
+
+	// Counting outside a synthesis is ignored
+	countRetry(context.Background())
+}
+
+func TestSynthesize_Duration(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		sampleRate int
+		audio      []byte
+		want       time.Duration
+	}{
+		{"WAV from its header", "WAV", 8000, make([]byte, 1600), 100 * time.Millisecond},
+		{"PCM from its length", "PCM", 16000, make([]byte, 8000), 250 * time.Millisecond},
+		{"MULAW from its length", "MULAW", 8000, make([]byte, 4000), 500 * time.Millisecond},
+		{"PCM of unknown rate", "PCM", 0, make([]byte, 8000), 0},
+		{"unreadable MP3", "MP3", 0, []byte("audio"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synth := NewSynthesizer(&mockTTSClient{synthesizeResponse: tt.audio})
+
+			req := &SynthesizeRequest{Text: "Hello", SpeakingRate: 1.0, AudioFormat: tt.format,
+				SampleRate: tt.sampleRate}
+			resp, err := synth.Synthesize(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.Duration)
+		})
+	}
+}
+
+func TestSynthesize_Transcode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg binary is a shell script")
+	}
+	client := &mockTTSClient{synthesizeResponse: make([]byte, 1600)}
+	synth := NewSynthesizer(client)
+	synth.SetPostProcessor(func(audio []byte, format string) ([]byte, error) {
+		assert.Equal(t, formatWAV, format, "post-processing sees the WAV audio")
+		return audio, nil
+	})
+	req := &SynthesizeRequest{Text: "Hello", SpeakingRate: 1.0, AudioFormat: "FLAC", SampleRate: 8000}
+
+	path := os.Getenv("PATH")
+	t.Setenv("PATH", t.TempDir())
+	_, err := synth.Synthesize(context.Background(), req)
+	assert.ErrorContains(t, err, "FLAC output needs ffmpeg")
+	assert.Nil(t, client.lastAudioConfig, "nothing is synthesized without ffmpeg")
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\ncat >/dev/null\nprintf fLaC > \"$last\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+
+	req.OutputFile = filepath.Join(t.TempDir(), "speech")
+	resp, err := synth.Synthesize(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, texttospeechpb.AudioEncoding_LINEAR16, client.lastAudioConfig.GetAudioEncoding())
+	assert.Equal(t, []byte("fLaC"), resp.AudioData)
+	assert.Equal(t, 100*time.Millisecond, resp.Duration, "read from the WAV audio before transcoding")
+	assert.Equal(t, req.OutputFile+".flac", resp.OutputFile)
+}
@@ -0,0 +1,181 @@
+package tts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Only the v1beta1 API tells when the SSML <mark> elements of a request are
+// reached, and the Cloud client library ships only v1. The v1beta1 request
+// and response are described here instead; their input, voice, and audio
+// messages are the v1 ones, which are the same on the wire.
+
+// methodSynthesizeSpeechBeta is the v1beta1 call that returns timepoints
+const methodSynthesizeSpeechBeta = "/google.cloud.texttospeech.v1beta1.TextToSpeech/SynthesizeSpeech"
+
+// timepointSSMLMark is the SSML_MARK value of the TimepointType enum
+const timepointSSMLMark = 1
+
+// Timepoint is the time at which the audio reaches an SSML <mark> element
+type Timepoint struct {
+	Mark string
+	Time time.Duration
+}
+
+// timepointsKey is the context key of the timepoints of a request
+type timepointsKey struct{}
+
+// withTimepoints returns a context in which the client asks for the
+// timepoints of the <mark> elements of the request and stores them in tp
+func withTimepoints(ctx context.Context, tp *[]Timepoint) context.Context {
+	return context.WithValue(ctx, timepointsKey{}, tp)
+}
+
+// wantsTimepoints returns the timepoints receiver of ctx when text has
+// <mark> elements to time
+func wantsTimepoints(ctx context.Context, text string) (*[]Timepoint, bool) {
+	tp, ok := ctx.Value(timepointsKey{}).(*[]Timepoint)
+	if !ok || !isSSML(text) || !strings.Contains(text, "<mark") {
+		return nil, false
+	}
+	return tp, true
+}
+
+// betaMessages are the descriptors of the v1beta1 request and response
+type betaMessages struct {
+	request  protoreflect.MessageDescriptor
+	response protoreflect.MessageDescriptor
+}
+
+// loadBetaMessages builds the v1beta1 descriptors once, resolving the v1
+// messages they embed from the registry the v1 package fills
+var loadBetaMessages = sync.OnceValues(func() (betaMessages, error) {
+	const v1 = ".google.cloud.texttospeech.v1."
+	const beta = ".google.cloud.texttospeech.v1beta1."
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, typeName string,
+		repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number),
+			Type: kind.Enum(), Label: label.Enum(), JsonName: proto.String(jsonName(name))}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("assistant-cli/texttospeech/v1beta1/timepoints.proto"),
+		Package:    proto.String("google.cloud.texttospeech.v1beta1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{texttospeechpb.File_google_cloud_texttospeech_v1_cloud_tts_proto.Path()},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("SynthesizeSpeechRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("input", 1, message, v1+"SynthesisInput", false),
+					field("voice", 2, message, v1+"VoiceSelectionParams", false),
+					field("audio_config", 3, message, v1+"AudioConfig", false),
+					field("enable_time_pointing", 4, descriptorpb.FieldDescriptorProto_TYPE_ENUM,
+						beta+"SynthesizeSpeechRequest.TimepointType", true),
+				},
+				EnumType: []*descriptorpb.EnumDescriptorProto{{
+					Name: proto.String("TimepointType"),
+					Value: []*descriptorpb.EnumValueDescriptorProto{
+						{Name: proto.String("TIMEPOINT_TYPE_UNSPECIFIED"), Number: proto.Int32(0)},
+						{Name: proto.String("SSML_MARK"), Number: proto.Int32(timepointSSMLMark)},
+					},
+				}},
+			},
+			{
+				Name: proto.String("Timepoint"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("time_seconds", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, "", false),
+					field("mark_name", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+				},
+			},
+			{
+				Name: proto.String("SynthesizeSpeechResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("audio_content", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES, "", false),
+					field("timepoints", 2, message, beta+"Timepoint", true),
+					field("audio_config", 4, message, v1+"AudioConfig", false),
+				},
+			},
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		return betaMessages{}, fmt.Errorf("failed to describe the v1beta1 API: %w", err)
+	}
+	return betaMessages{
+		request:  fd.Messages().ByName("SynthesizeSpeechRequest"),
+		response: fd.Messages().ByName("SynthesizeSpeechResponse"),
+	}, nil
+})
+
+// jsonName returns the lowerCamelCase JSON name of a snake_case field
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// synthesizeWithTimepoints makes req as a v1beta1 call that also returns
+// when each <mark> element is reached
+func (c *Client) synthesizeWithTimepoints(ctx context.Context,
+	req *texttospeechpb.SynthesizeSpeechRequest) ([]byte, []Timepoint, error) {
+	messages, err := loadBetaMessages()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The v1 fields keep their numbers in v1beta1, so the request carries over
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	betaReq := dynamicpb.NewMessage(messages.request)
+	if err := proto.Unmarshal(data, betaReq); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	pointing := betaReq.Mutable(messages.request.Fields().ByName("enable_time_pointing")).List()
+	pointing.Append(protoreflect.ValueOfEnum(timepointSSMLMark))
+
+	betaResp := dynamicpb.NewMessage(messages.response)
+	if err := c.invoke(ctx, methodSynthesizeSpeechBeta, betaReq, betaResp); err != nil {
+		return nil, nil, err
+	}
+
+	fields := messages.response.Fields()
+	audio := betaResp.Get(fields.ByName("audio_content")).Bytes()
+	list := betaResp.Get(fields.ByName("timepoints")).List()
+	timepoints := make([]Timepoint, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		point := list.Get(i).Message()
+		pointFields := point.Descriptor().Fields()
+		seconds := point.Get(pointFields.ByName("time_seconds")).Float()
+		timepoints = append(timepoints, Timepoint{
+			Mark: point.Get(pointFields.ByName("mark_name")).String(),
+			Time: time.Duration(seconds * float64(time.Second)),
+		})
+	}
+	return audio, timepoints, nil
+}
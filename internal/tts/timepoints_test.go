@@ -0,0 +1,80 @@
+package tts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const markedSSML = `<speak>Hello <mark name="there"/>there</speak>`
+
+func TestWantsTimepoints(t *testing.T) {
+	var timepoints []Timepoint
+	ctx := withTimepoints(context.Background(), &timepoints)
+
+	tp, ok := wantsTimepoints(ctx, markedSSML)
+	assert.True(t, ok)
+	assert.Same(t, &timepoints, tp)
+
+	_, ok = wantsTimepoints(ctx, "<speak>Hello there</speak>")
+	assert.False(t, ok, "SSML without marks has nothing to time")
+	_, ok = wantsTimepoints(ctx, `Hello <mark name="there"/>`)
+	assert.False(t, ok, "plain text is not read as SSML")
+	_, ok = wantsTimepoints(context.Background(), markedSSML)
+	assert.False(t, ok)
+}
+
+func TestClient_SynthesizeTimepoints(t *testing.T) {
+	// Answers the v1beta1 call the fake server does not implement
+	beta := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method != methodSynthesizeSpeechBeta {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		request := req.(*dynamicpb.Message)
+		pointing := request.Get(request.Descriptor().Fields().ByName("enable_time_pointing")).List()
+		require.Equal(t, 1, pointing.Len())
+		assert.Equal(t, protoreflect.EnumNumber(timepointSSMLMark), pointing.Get(0).Enum())
+
+		response := reply.(*dynamicpb.Message)
+		fields := response.Descriptor().Fields()
+		response.Set(fields.ByName("audio_content"), protoreflect.ValueOfBytes([]byte("audio")))
+		list := response.Mutable(fields.ByName("timepoints")).List()
+		point := list.NewElement()
+		pointFields := point.Message().Descriptor().Fields()
+		point.Message().Set(pointFields.ByName("mark_name"), protoreflect.ValueOfString("there"))
+		point.Message().Set(pointFields.ByName("time_seconds"), protoreflect.ValueOfFloat64(0.5))
+		list.Append(point)
+		return nil
+	}
+
+	config := DefaultClientConfig()
+	config.Interceptors = []grpc.UnaryClientInterceptor{beta}
+	server := &fakeTTSServer{}
+	client := newFakeClient(t, server, config)
+
+	var timepoints []Timepoint
+	audio, err := client.Synthesize(withTimepoints(context.Background(), &timepoints), markedSSML, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(audio))
+	assert.Equal(t, []Timepoint{{Mark: "there", Time: 500 * time.Millisecond}}, timepoints)
+	assert.Zero(t, server.calls.Load())
+}
+
+func TestClient_SynthesizeTimepoints_Unimplemented(t *testing.T) {
+	server := &fakeTTSServer{}
+	client := newFakeClient(t, server, DefaultClientConfig())
+
+	// The fake server has only the v1 API, so the call falls back to it
+	var timepoints []Timepoint
+	_, err := client.Synthesize(withTimepoints(context.Background(), &timepoints), markedSSML, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, timepoints)
+	assert.Equal(t, int32(1), server.calls.Load())
+}
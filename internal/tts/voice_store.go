@@ -0,0 +1,266 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"github.com/mikefarmer/assistant-cli/internal/output"
+)
+
+// DefaultVoiceCacheTTL is how long a persisted voice list is considered fresh
+const DefaultVoiceCacheTTL = 24 * time.Hour
+
+// voiceStoreVersion identifies the on-disk cache format
+const voiceStoreVersion = 1
+
+// allLanguagesKey is the cache key for a voice list fetched without a language filter
+const allLanguagesKey = "*"
+
+// StoredVoice is the on-disk representation of a voice
+type StoredVoice struct {
+	Name                   string   `json:"name"`
+	LanguageCodes          []string `json:"language_codes"`
+	SsmlGender             int32    `json:"ssml_gender"`
+	NaturalSampleRateHertz int32    `json:"natural_sample_rate_hertz"`
+}
+
+// voiceStoreEntry is a voice list together with the time it was fetched
+type voiceStoreEntry struct {
+	FetchedAt time.Time     `json:"fetched_at"`
+	Voices    []StoredVoice `json:"voices"`
+}
+
+// voiceStoreFile is the layout of the cache file
+type voiceStoreFile struct {
+	Version int                         `json:"version"`
+	Entries map[string]*voiceStoreEntry `json:"entries"`
+}
+
+// VoiceListing is the result of a PersistentVoiceCache lookup
+type VoiceListing struct {
+	Voices    []*texttospeechpb.Voice
+	FetchedAt time.Time
+	// FromCache is true when the voices were read from disk rather than the API
+	FromCache bool
+	// Stale is true when the API could not be reached and an expired entry was used
+	Stale bool
+}
+
+// PersistentVoiceCache keeps voice lists on disk so that they survive across
+// CLI invocations and remain available offline
+type PersistentVoiceCache struct {
+	path   string
+	ttl    time.Duration
+	client VoiceListClient
+	clock  clock.Clock
+}
+
+// DefaultVoiceCachePath returns ~/.assistant-cli/cache/voices.json
+func DefaultVoiceCachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".assistant-cli", "cache", "voices.json"), nil
+}
+
+// NewPersistentVoiceCache creates a disk-backed voice cache at path. A ttl that
+// is not positive means cached entries are always refreshed when the API is
+// reachable, but are still used as an offline fallback.
+func NewPersistentVoiceCache(client VoiceListClient, path string, ttl time.Duration) *PersistentVoiceCache {
+	return &PersistentVoiceCache{
+		path:   path,
+		ttl:    ttl,
+		client: client,
+		clock:  clock.Real,
+	}
+}
+
+// GetVoices returns the voices for languageCode, using the on-disk cache when
+// it is fresh. With refresh set the API is always queried. If the API call
+// fails, an expired cache entry is returned instead when one exists.
+func (c *PersistentVoiceCache) GetVoices(ctx context.Context, languageCode string,
+	refresh bool) (*VoiceListing, error) {
+	store, err := c.load()
+	if err != nil {
+		// A corrupt or unreadable cache is rebuilt from the API
+		store = newVoiceStoreFile()
+	}
+
+	entry := store.lookup(languageCode)
+	if entry != nil && !refresh && c.isFresh(entry) {
+		return entry.listing(true, false), nil
+	}
+
+	voices, fetchErr := c.client.ListVoices(ctx, languageCode)
+	if fetchErr != nil {
+		if entry != nil {
+			return entry.listing(true, true), nil
+		}
+		return nil, fetchErr
+	}
+
+	fresh := &voiceStoreEntry{FetchedAt: c.clock.Now(), Voices: toStoredVoices(voices)}
+	store.Entries[cacheKey(languageCode)] = fresh
+	// Failing to persist only costs another API call next time
+	_ = c.save(store)
+
+	return fresh.listing(false, false), nil
+}
+
+// Cached returns the voices cached for languageCode without contacting the
+// API, regardless of age; expired entries are marked Stale. The boolean is
+// false when nothing usable is cached.
+func (c *PersistentVoiceCache) Cached(languageCode string) (*VoiceListing, bool) {
+	store, err := c.load()
+	if err != nil {
+		return nil, false
+	}
+
+	entry := store.lookup(languageCode)
+	if entry == nil {
+		return nil, false
+	}
+	return entry.listing(true, !c.isFresh(entry)), true
+}
+
+// Clear removes the cache file
+func (c *PersistentVoiceCache) Clear() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove voice cache: %w", err)
+	}
+	return nil
+}
+
+// Path returns the location of the cache file
+func (c *PersistentVoiceCache) Path() string {
+	return c.path
+}
+
+func (c *PersistentVoiceCache) isFresh(entry *voiceStoreEntry) bool {
+	return c.ttl > 0 && c.clock.Since(entry.FetchedAt) <= c.ttl
+}
+
+func (c *PersistentVoiceCache) load() (*voiceStoreFile, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return newVoiceStoreFile(), nil
+		}
+		return nil, fmt.Errorf("failed to read voice cache: %w", err)
+	}
+
+	var store voiceStoreFile
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse voice cache: %w", err)
+	}
+	if store.Version != voiceStoreVersion || store.Entries == nil {
+		return newVoiceStoreFile(), nil
+	}
+
+	return &store, nil
+}
+
+// save writes the cache atomically so that concurrent invocations never see
+// a partially written file
+func (c *PersistentVoiceCache) save(store *voiceStoreFile) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create voice cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode voice cache: %w", err)
+	}
+
+	if err := output.WriteFileAtomic(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write voice cache: %w", err)
+	}
+	return nil
+}
+
+func newVoiceStoreFile() *voiceStoreFile {
+	return &voiceStoreFile{
+		Version: voiceStoreVersion,
+		Entries: make(map[string]*voiceStoreEntry),
+	}
+}
+
+// lookup finds the entry for languageCode, falling back to filtering the
+// unfiltered voice list when no language-specific entry exists
+func (s *voiceStoreFile) lookup(languageCode string) *voiceStoreEntry {
+	if entry, ok := s.Entries[cacheKey(languageCode)]; ok {
+		return entry
+	}
+
+	all, ok := s.Entries[allLanguagesKey]
+	if !ok || languageCode == "" {
+		return nil
+	}
+
+	filtered := &voiceStoreEntry{FetchedAt: all.FetchedAt}
+	for _, voice := range all.Voices {
+		for _, code := range voice.LanguageCodes {
+			if matchesLanguage(code, languageCode) {
+				filtered.Voices = append(filtered.Voices, voice)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func (e *voiceStoreEntry) listing(fromCache, stale bool) *VoiceListing {
+	voices := make([]*texttospeechpb.Voice, 0, len(e.Voices))
+	for _, voice := range e.Voices {
+		voices = append(voices, &texttospeechpb.Voice{
+			Name:                   voice.Name,
+			LanguageCodes:          voice.LanguageCodes,
+			SsmlGender:             texttospeechpb.SsmlVoiceGender(voice.SsmlGender),
+			NaturalSampleRateHertz: voice.NaturalSampleRateHertz,
+		})
+	}
+
+	return &VoiceListing{
+		Voices:    voices,
+		FetchedAt: e.FetchedAt,
+		FromCache: fromCache,
+		Stale:     stale,
+	}
+}
+
+func toStoredVoices(voices []*texttospeechpb.Voice) []StoredVoice {
+	stored := make([]StoredVoice, 0, len(voices))
+	for _, voice := range voices {
+		stored = append(stored, StoredVoice{
+			Name:                   voice.GetName(),
+			LanguageCodes:          voice.GetLanguageCodes(),
+			SsmlGender:             int32(voice.GetSsmlGender()),
+			NaturalSampleRateHertz: voice.GetNaturalSampleRateHertz(),
+		})
+	}
+	return stored
+}
+
+// matchesLanguage reports whether code is languageCode or one of its regional
+// variants, mirroring the API's filter (e.g. "en" matches "en-US")
+func matchesLanguage(code, languageCode string) bool {
+	code = strings.ToLower(code)
+	languageCode = strings.ToLower(languageCode)
+	return code == languageCode || strings.HasPrefix(code, languageCode+"-")
+}
+
+func cacheKey(languageCode string) string {
+	if languageCode == "" {
+		return allLanguagesKey
+	}
+	return strings.ToLower(languageCode)
+}
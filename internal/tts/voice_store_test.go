@@ -0,0 +1,209 @@
+package tts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVoiceStore(t *testing.T, client VoiceListClient, ttl time.Duration) (*PersistentVoiceCache, *clock.Fake) {
+	t.Helper()
+
+	fake := clock.NewFake(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC))
+	cache := NewPersistentVoiceCache(client, filepath.Join(t.TempDir(), "cache", "voices.json"), ttl)
+	cache.clock = fake
+	return cache, fake
+}
+
+func testVoices() []*texttospeechpb.Voice {
+	return []*texttospeechpb.Voice{
+		{
+			Name:                   "en-US-Wavenet-D",
+			LanguageCodes:          []string{"en-US"},
+			SsmlGender:             texttospeechpb.SsmlVoiceGender_MALE,
+			NaturalSampleRateHertz: 24000,
+		},
+		{
+			Name:                   "en-GB-Wavenet-A",
+			LanguageCodes:          []string{"en-GB"},
+			SsmlGender:             texttospeechpb.SsmlVoiceGender_FEMALE,
+			NaturalSampleRateHertz: 24000,
+		},
+	}
+}
+
+func TestPersistentVoiceCache_PersistsAcrossInstances(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, _ := newTestVoiceStore(t, mockClient, time.Hour)
+
+	listing, err := cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+	assert.False(t, listing.FromCache)
+	assert.Len(t, listing.Voices, 2)
+	assert.Equal(t, 1, mockClient.callCount)
+
+	info, err := os.Stat(cache.Path())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// A new instance (i.e. a later CLI invocation) reads from disk
+	offline := &mockVoiceListClient{shouldError: true}
+	second := NewPersistentVoiceCache(offline, cache.Path(), time.Hour)
+	second.clock = cache.clock
+
+	listing, err = second.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+	assert.True(t, listing.FromCache)
+	assert.False(t, listing.Stale)
+	assert.Equal(t, 0, offline.callCount)
+	require.Len(t, listing.Voices, 2)
+	assert.Equal(t, "en-US-Wavenet-D", listing.Voices[0].Name)
+	assert.Equal(t, texttospeechpb.SsmlVoiceGender_MALE, listing.Voices[0].SsmlGender)
+	assert.Equal(t, int32(24000), listing.Voices[0].NaturalSampleRateHertz)
+}
+
+func TestPersistentVoiceCache_Expiry(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, now := newTestVoiceStore(t, mockClient, time.Hour)
+
+	_, err := cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+
+	now.Advance(30 * time.Minute)
+	listing, err := cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+	assert.True(t, listing.FromCache)
+	assert.Equal(t, 1, mockClient.callCount)
+
+	now.Advance(time.Hour)
+	listing, err = cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+	assert.False(t, listing.FromCache)
+	assert.Equal(t, 2, mockClient.callCount)
+	assert.Equal(t, now.Now(), listing.FetchedAt)
+}
+
+func TestPersistentVoiceCache_Refresh(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, _ := newTestVoiceStore(t, mockClient, time.Hour)
+
+	_, err := cache.GetVoices(context.Background(), "", false)
+	require.NoError(t, err)
+
+	listing, err := cache.GetVoices(context.Background(), "", true)
+	require.NoError(t, err)
+	assert.False(t, listing.FromCache)
+	assert.Equal(t, 2, mockClient.callCount)
+}
+
+func TestPersistentVoiceCache_StaleFallback(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, now := newTestVoiceStore(t, mockClient, time.Hour)
+
+	_, err := cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+
+	// Expired and offline: the old entry is still served
+	now.Advance(48 * time.Hour)
+	mockClient.shouldError = true
+
+	listing, err := cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+	assert.True(t, listing.FromCache)
+	assert.True(t, listing.Stale)
+	assert.Len(t, listing.Voices, 2)
+
+	// Nothing cached for this language: the API error is returned
+	_, err = cache.GetVoices(context.Background(), "fr-FR", false)
+	assert.Error(t, err)
+}
+
+func TestPersistentVoiceCache_FiltersAllLanguagesEntry(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, _ := newTestVoiceStore(t, mockClient, time.Hour)
+
+	_, err := cache.GetVoices(context.Background(), "", false)
+	require.NoError(t, err)
+
+	listing, err := cache.GetVoices(context.Background(), "en-GB", false)
+	require.NoError(t, err)
+	assert.True(t, listing.FromCache)
+	require.Len(t, listing.Voices, 1)
+	assert.Equal(t, "en-GB-Wavenet-A", listing.Voices[0].Name)
+
+	listing, err = cache.GetVoices(context.Background(), "en", false)
+	require.NoError(t, err)
+	assert.Len(t, listing.Voices, 2)
+	assert.Equal(t, 1, mockClient.callCount)
+}
+
+func TestPersistentVoiceCache_CorruptFile(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, _ := newTestVoiceStore(t, mockClient, time.Hour)
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(cache.Path()), 0700))
+	require.NoError(t, os.WriteFile(cache.Path(), []byte("{not json"), 0600))
+
+	listing, err := cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+	assert.False(t, listing.FromCache)
+	assert.Equal(t, 1, mockClient.callCount)
+}
+
+func TestPersistentVoiceCache_ZeroTTLAlwaysRefreshes(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, _ := newTestVoiceStore(t, mockClient, 0)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetVoices(context.Background(), "en-US", false)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, mockClient.callCount)
+}
+
+func TestPersistentVoiceCache_Clear(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, _ := newTestVoiceStore(t, mockClient, time.Hour)
+
+	// Clearing a missing cache is not an error
+	require.NoError(t, cache.Clear())
+
+	_, err := cache.GetVoices(context.Background(), "en-US", false)
+	require.NoError(t, err)
+	require.NoError(t, cache.Clear())
+
+	_, err = os.Stat(cache.Path())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPersistentVoiceCache_Cached(t *testing.T) {
+	mockClient := &mockVoiceListClient{voices: testVoices()}
+	cache, now := newTestVoiceStore(t, mockClient, time.Hour)
+
+	_, ok := cache.Cached("en-US")
+	assert.False(t, ok)
+
+	_, err := cache.GetVoices(context.Background(), "", false)
+	require.NoError(t, err)
+
+	listing, ok := cache.Cached("en-GB")
+	require.True(t, ok)
+	assert.False(t, listing.Stale)
+	require.Len(t, listing.Voices, 1)
+	assert.Equal(t, "en-GB-Wavenet-A", listing.Voices[0].Name)
+
+	// Expired entries are still returned, without calling the API
+	now.Advance(2 * time.Hour)
+	listing, ok = cache.Cached("")
+	require.True(t, ok)
+	assert.True(t, listing.Stale)
+	assert.Len(t, listing.Voices, 2)
+	assert.Equal(t, 1, mockClient.callCount)
+}
@@ -0,0 +1,314 @@
+package assistant
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/audio"
+	"github.com/mikefarmer/assistant-cli/internal/auth"
+	"github.com/mikefarmer/assistant-cli/internal/longtext"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+	"github.com/mikefarmer/assistant-cli/pkg/utils"
+)
+
+// Providers accepted in Options.Provider
+const (
+	ProviderGoogle = tts.ProviderGoogle
+	ProviderEspeak = tts.ProviderEspeak
+)
+
+// ssmlSniffSize is how much of a stream SynthesizeReader looks at to tell
+// SSML from plain text, allowing for leading whitespace
+const ssmlSniffSize = 512
+
+// ErrEmptyText is returned for text with nothing to synthesize
+var ErrEmptyText = errors.New("text is empty")
+
+// Backend is a speech synthesis engine. Options.Backend replaces the
+// configured provider with one, e.g. a fake in tests.
+type Backend interface {
+	Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+		audio *texttospeechpb.AudioConfig) ([]byte, error)
+	ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error)
+	Close() error
+}
+
+// Options configures a Client. Zero values select the defaults of the CLI.
+type Options struct {
+	// Provider is ProviderGoogle (the default) or ProviderEspeak, which
+	// synthesizes locally with espeak-ng and needs no credentials
+	Provider string
+	// Backend, when set, is used instead of Provider and the credentials
+	Backend Backend
+
+	// APIKey, ServiceAccountFile, or the OAuth2 client and token file select
+	// the credentials, tried in that order; when all are empty they come
+	// from the environment
+	APIKey             string
+	ServiceAccountFile string
+	OAuth2ClientID     string
+	OAuth2ClientSecret string
+	OAuth2TokenFile    string
+
+	// Voice is the voice name, e.g. "en-US-Neural2-F" (default
+	// "en-US-Wavenet-D")
+	Voice string
+	// Language is the BCP-47 language code (default "en-US")
+	Language string
+	// SpeakingRate from 0.25 to 4.0 (default 1.0)
+	SpeakingRate float64
+	// Pitch in semitones from -20 to 20
+	Pitch float64
+	// VolumeGain in dB from -96 to 16
+	VolumeGain float64
+	// Format is the audio format: "MP3" (the default), "LINEAR16" for WAV,
+	// or "OGG_OPUS". Texts too long for one request need one of these.
+	Format string
+
+	// MaxRetries is how often a failed request is retried (default 3; a
+	// negative value disables retries)
+	MaxRetries int
+	// Timeout bounds each request (default 30 seconds)
+	Timeout time.Duration
+	// TotalTimeout bounds a request across all its retries (default: no
+	// limit beyond Timeout per attempt)
+	TotalTimeout time.Duration
+	// Concurrency is how many pieces of a long text are synthesized at once
+	// (default 1)
+	Concurrency int
+}
+
+// Client synthesizes speech. It is safe for concurrent use.
+type Client struct {
+	backend     Backend
+	synthesizer *tts.Synthesizer
+	request     tts.SynthesizeRequest
+	concurrency int
+}
+
+// New creates a client, authenticating with the credentials of opts unless
+// opts.Backend or the espeak provider is used
+func New(ctx context.Context, opts Options) (*Client, error) {
+	config := clientConfig(opts)
+
+	backend := opts.Backend
+	if backend == nil {
+		provider, err := tts.NormalizeProvider(opts.Provider)
+		if err != nil {
+			return nil, err
+		}
+		if provider == ProviderEspeak {
+			if backend, err = tts.NewEspeakProvider(config); err != nil {
+				return nil, err
+			}
+		} else {
+			manager := auth.NewAuthManager(authConfig(opts))
+			if backend, err = tts.NewClient(ctx, manager, config); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Client{
+		backend:     backend,
+		synthesizer: tts.NewSynthesizer(backend),
+		request: tts.SynthesizeRequest{
+			Voice:        config.Voice,
+			LanguageCode: config.LanguageCode,
+			SpeakingRate: config.SpeakingRate,
+			Pitch:        config.Pitch,
+			VolumeGain:   config.VolumeGain,
+			AudioFormat:  config.AudioEncoding,
+		},
+		concurrency: max(opts.Concurrency, 1),
+	}, nil
+}
+
+// clientConfig applies opts to the default provider settings
+func clientConfig(opts Options) *tts.ClientConfig {
+	config := tts.DefaultClientConfig()
+	if opts.Voice != "" {
+		config.Voice = opts.Voice
+	}
+	if opts.Language != "" {
+		config.LanguageCode = opts.Language
+	} else if opts.Voice != "" {
+		config.LanguageCode = voiceLanguage(opts.Voice)
+	}
+	if opts.SpeakingRate != 0 {
+		config.SpeakingRate = opts.SpeakingRate
+	}
+	config.Pitch = opts.Pitch
+	config.VolumeGain = opts.VolumeGain
+	if opts.Format != "" {
+		config.AudioEncoding = strings.ToUpper(opts.Format)
+	}
+	switch {
+	case opts.MaxRetries < 0:
+		config.RetryAttempts = 0
+	case opts.MaxRetries > 0:
+		config.RetryAttempts = opts.MaxRetries
+	}
+	if opts.Timeout > 0 {
+		config.Timeout = opts.Timeout
+	}
+	config.TotalTimeout = opts.TotalTimeout
+	return config
+}
+
+// voiceLanguage returns the language of a voice name such as
+// "de-DE-Wavenet-B"
+func voiceLanguage(voice string) string {
+	if parts := strings.SplitN(voice, "-", 3); len(parts) == 3 {
+		return parts[0] + "-" + parts[1]
+	}
+	return ""
+}
+
+// authConfig selects the credentials of opts, falling back to the
+// environment
+func authConfig(opts Options) auth.AuthConfig {
+	config := auth.DefaultAuthConfig()
+	switch {
+	case opts.APIKey != "":
+		config.Method = auth.AuthMethodAPIKey
+		config.APIKey = opts.APIKey
+	case opts.ServiceAccountFile != "":
+		config.Method = auth.AuthMethodServiceAccount
+		config.ServiceAccountFile = opts.ServiceAccountFile
+	case opts.OAuth2ClientID != "":
+		config.Method = auth.AuthMethodOAuth2
+		config.OAuth2ClientID = opts.OAuth2ClientID
+		config.OAuth2ClientSecret = opts.OAuth2ClientSecret
+	}
+	if opts.OAuth2TokenFile != "" {
+		config.OAuth2TokenFile = opts.OAuth2TokenFile
+	}
+	return config
+}
+
+// Synthesize turns text, plain or SSML, into audio. Texts longer than one
+// request allows are split at paragraph, sentence, or SSML element
+// boundaries, synthesized Concurrency pieces at a time, and joined. Long
+// SSML that is not well-formed fails instead of being split.
+func (c *Client) Synthesize(ctx context.Context, text string) (*Audio, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+	chunks, err := longtext.Split(text, longtext.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return c.synthesizePieces(ctx, chunks)
+}
+
+// SynthesizeReader synthesizes all text read from r. Plain text is split
+// into request-sized pieces as it is read, so it is never held in memory
+// twice; SSML is read whole to be split between elements.
+func (c *Client) SynthesizeReader(ctx context.Context, r io.Reader) (*Audio, error) {
+	buffered := bufio.NewReader(r)
+	head, err := buffered.Peek(ssmlSniffSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if longtext.IsSSML(string(head)) {
+		text, err := io.ReadAll(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		return c.Synthesize(ctx, string(text))
+	}
+
+	reader := utils.NewInputProcessorWithLimit(buffered, math.MaxInt).NewChunkReader(longtext.ChunkSize)
+	var chunks []string
+	for {
+		chunk, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var inputErr *utils.InputError
+		if errors.As(err, &inputErr) && inputErr.Type == "empty" {
+			return nil, ErrEmptyText
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 1 {
+		return c.Synthesize(ctx, chunks[0])
+	}
+	return c.synthesizePieces(ctx, chunks)
+}
+
+// synthesizePieces synthesizes the pieces of a text and joins their audio
+func (c *Client) synthesizePieces(ctx context.Context, chunks []string) (*Audio, error) {
+	if len(chunks) == 1 {
+		req := c.request
+		resp, err := c.synthesizer.SynthesizeText(ctx, chunks[0], &req)
+		if err != nil {
+			return nil, err
+		}
+		return &Audio{Data: resp.AudioData, Format: req.AudioFormat, Duration: resp.Duration}, nil
+	}
+
+	pieces := make([]longtext.Piece, len(chunks))
+	for i, chunk := range chunks {
+		pieces[i] = longtext.Piece{Text: chunk, Request: &c.request}
+	}
+	responses, err := longtext.Synthesize(ctx, c.synthesizer, pieces, c.concurrency, nil)
+	if err != nil {
+		return nil, err
+	}
+	var duration time.Duration
+	for _, resp := range responses {
+		duration += resp.Duration
+	}
+	data, err := audio.Concat(longtext.Audio(responses), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join audio: %w", err)
+	}
+	return &Audio{Data: data, Format: c.request.AudioFormat, Duration: duration}, nil
+}
+
+// Voice describes a voice of the provider
+type Voice struct {
+	Name      string
+	Languages []string
+	// Gender is "MALE", "FEMALE", "NEUTRAL", or "SSML_VOICE_GENDER_UNSPECIFIED"
+	Gender string
+	// SampleRate is the natural sample rate of the voice in Hz
+	SampleRate int
+}
+
+// Voices lists the voices for a language code such as "en-US", or all
+// voices when language is empty
+func (c *Client) Voices(ctx context.Context, language string) ([]Voice, error) {
+	voices, err := c.backend.ListVoices(ctx, language)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Voice, 0, len(voices))
+	for _, voice := range voices {
+		result = append(result, Voice{
+			Name:       voice.GetName(),
+			Languages:  voice.GetLanguageCodes(),
+			Gender:     voice.GetSsmlGender().String(),
+			SampleRate: int(voice.GetNaturalSampleRateHertz()),
+		})
+	}
+	return result, nil
+}
+
+// Close releases the connection to the provider
+func (c *Client) Close() error {
+	return c.backend.Close()
+}
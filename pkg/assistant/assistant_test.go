@@ -0,0 +1,184 @@
+package assistant
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/internal/longtext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeBackend returns a tenth of a second of 16-bit PCM at 24 kHz for every
+// request and records the texts it was given
+type fakeBackend struct {
+	mu     sync.Mutex
+	texts  []string
+	voices []*texttospeechpb.VoiceSelectionParams
+	err    error
+	closed bool
+}
+
+func (b *fakeBackend) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audio *texttospeechpb.AudioConfig) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.texts = append(b.texts, text)
+	b.voices = append(b.voices, voice)
+	if b.err != nil {
+		return nil, b.err
+	}
+	return make([]byte, 4800), nil
+}
+
+func (b *fakeBackend) ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
+	return []*texttospeechpb.Voice{{
+		Name:                   "en-US-Neural2-F",
+		LanguageCodes:          []string{"en-US"},
+		SsmlGender:             texttospeechpb.SsmlVoiceGender_FEMALE,
+		NaturalSampleRateHertz: 24000,
+	}}, nil
+}
+
+func (b *fakeBackend) Close() error {
+	b.closed = true
+	return nil
+}
+
+func newTestClient(t *testing.T, backend *fakeBackend, opts Options) *Client {
+	t.Helper()
+	opts.Backend = backend
+	if opts.Format == "" {
+		opts.Format = "LINEAR16"
+	}
+	client, err := New(context.Background(), opts)
+	require.NoError(t, err)
+	return client
+}
+
+func TestSynthesize(t *testing.T) {
+	backend := &fakeBackend{}
+	client := newTestClient(t, backend, Options{Voice: "de-DE-Wavenet-B"})
+
+	audio, err := client.Synthesize(context.Background(), "  Hallo Welt  ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hallo Welt"}, backend.texts)
+	assert.Equal(t, "de-DE-Wavenet-B", backend.voices[0].GetName())
+	assert.Equal(t, "de-DE", backend.voices[0].GetLanguageCode(), "the language follows the voice")
+	assert.Equal(t, "RIFF", string(audio.Data[:4]))
+	assert.Equal(t, "wav", audio.Extension())
+	assert.Equal(t, 100*time.Millisecond, audio.Duration)
+
+	_, err = client.Synthesize(context.Background(), " \n")
+	assert.ErrorIs(t, err, ErrEmptyText)
+
+	require.NoError(t, client.Close())
+	assert.True(t, backend.closed)
+}
+
+func TestSynthesize_LongText(t *testing.T) {
+	backend := &fakeBackend{}
+	client := newTestClient(t, backend, Options{Concurrency: 3})
+
+	text := strings.Repeat("This sentence is part of a long chapter. ", 300)
+	audio, err := client.Synthesize(context.Background(), text)
+	require.NoError(t, err)
+
+	require.Greater(t, len(backend.texts), 1)
+	for _, chunk := range backend.texts {
+		assert.LessOrEqual(t, len(chunk), longtext.ChunkSize)
+	}
+	assert.Equal(t, time.Duration(len(backend.texts))*100*time.Millisecond, audio.Duration)
+	assert.Equal(t, "RIFF", string(audio.Data[:4]), "the pieces are joined into one file")
+}
+
+func TestSynthesizeReader(t *testing.T) {
+	text := strings.Repeat("This sentence is part of a long chapter. ", 300)
+
+	// Plain text is split as it streams, into the same pieces as a string
+	streamed := &fakeBackend{}
+	_, err := newTestClient(t, streamed, Options{}).SynthesizeReader(context.Background(), strings.NewReader(text))
+	require.NoError(t, err)
+	whole := &fakeBackend{}
+	_, err = newTestClient(t, whole, Options{}).Synthesize(context.Background(), text)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, whole.texts, streamed.texts)
+
+	backend := &fakeBackend{}
+	client := newTestClient(t, backend, Options{})
+	_, err = client.SynthesizeReader(context.Background(), strings.NewReader("\n <speak>Hello</speak>\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"<speak>Hello</speak>"}, backend.texts)
+
+	_, err = client.SynthesizeReader(context.Background(), strings.NewReader(" \n\t"))
+	assert.ErrorIs(t, err, ErrEmptyText)
+}
+
+func TestSynthesize_Error(t *testing.T) {
+	backend := &fakeBackend{err: status.Error(codes.ResourceExhausted, "quota exceeded")}
+	client := newTestClient(t, backend, Options{})
+
+	_, err := client.Synthesize(context.Background(), strings.Repeat("Quota test sentence. ", 400))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "piece")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the API status stays in the chain")
+
+	_, err = New(context.Background(), Options{Provider: "festival"})
+	assert.Error(t, err)
+}
+
+func TestVoices(t *testing.T) {
+	client := newTestClient(t, &fakeBackend{}, Options{})
+
+	voices, err := client.Voices(context.Background(), "en-US")
+	require.NoError(t, err)
+	assert.Equal(t, []Voice{{Name: "en-US-Neural2-F", Languages: []string{"en-US"}, Gender: "FEMALE", SampleRate: 24000}}, voices)
+}
+
+func TestAudioWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "speech.mp3")
+	audio := &Audio{Data: []byte("ID3 audio"), Format: "MP3"}
+
+	require.NoError(t, audio.WriteFile(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ID3 audio", string(data))
+	assert.Equal(t, "mp3", audio.Extension())
+}
+
+func TestClientConfig(t *testing.T) {
+	config := clientConfig(Options{})
+	assert.Equal(t, "en-US-Wavenet-D", config.Voice)
+	assert.Equal(t, "en-US", config.LanguageCode)
+	assert.Equal(t, 1.0, config.SpeakingRate)
+	assert.Equal(t, "MP3", config.AudioEncoding)
+	assert.Equal(t, 3, config.RetryAttempts)
+
+	config = clientConfig(Options{Language: "en-GB", Voice: "en-GB-Neural2-A", Format: "ogg_opus", MaxRetries: -1})
+	assert.Equal(t, "en-GB", config.LanguageCode)
+	assert.Equal(t, "OGG_OPUS", config.AudioEncoding)
+	assert.Zero(t, config.RetryAttempts)
+}
+
+func TestAuthConfig(t *testing.T) {
+	t.Setenv("ASSISTANT_CLI_API_KEY", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "/env/key.json")
+
+	config := authConfig(Options{})
+	assert.Equal(t, "/env/key.json", config.ServiceAccountFile, "credentials come from the environment")
+
+	config = authConfig(Options{ServiceAccountFile: "/etc/key.json", OAuth2TokenFile: "/tmp/token.json"})
+	assert.Equal(t, "/etc/key.json", config.ServiceAccountFile)
+	assert.Equal(t, "/tmp/token.json", config.OAuth2TokenFile)
+	assert.Equal(t, "serviceaccount", config.Method.String())
+
+	assert.Equal(t, "apikey", authConfig(Options{APIKey: "AIzaSyTestKey1234567890"}).Method.String())
+}
@@ -0,0 +1,33 @@
+package assistant
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mikefarmer/assistant-cli/internal/output"
+	"github.com/mikefarmer/assistant-cli/internal/tts"
+)
+
+// Audio is synthesized speech
+type Audio struct {
+	Data []byte
+	// Format is the audio format requested, e.g. "MP3"
+	Format string
+	// Duration is the playing time, or 0 when it cannot be told
+	Duration time.Duration
+}
+
+// Extension returns the file extension for the format, without a dot
+func (a *Audio) Extension() string {
+	return tts.FileExtension(a.Format)
+}
+
+// WriteFile writes the audio to path, creating its directory, and replaces
+// an existing file in one step so that readers never see a partial file
+func (a *Audio) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return output.WriteFileAtomic(path, a.Data, 0644)
+}
@@ -0,0 +1,39 @@
+// Package assistant is the Go API of assistant-cli, for programs that embed
+// speech synthesis instead of running the CLI. It wraps authentication, the
+// Cloud Text-to-Speech and espeak providers, chunking of long texts, and
+// writing audio files:
+//
+//	client, err := assistant.New(ctx, assistant.Options{
+//		ServiceAccountFile: "/etc/narrator/key.json",
+//		Voice:              "en-US-Neural2-F",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	audio, err := client.Synthesize(ctx, chapterText)
+//	if err != nil {
+//		return err
+//	}
+//	return audio.WriteFile("chapter-1." + audio.Extension())
+//
+// Credentials not given in Options are looked up like the CLI does, from
+// ASSISTANT_CLI_API_KEY, GOOGLE_APPLICATION_CREDENTIALS, and the OAuth2
+// environment variables; configuration files are not read.
+//
+// # Compatibility
+//
+// This package follows semantic versioning with the module: within a major
+// version, exported identifiers are not removed or renamed, function
+// signatures do not change, and fields are only added to structs, with zero
+// values that keep the previous behavior. Create Options with field names,
+// not positionally. Behavior may change in minor versions only to fix bugs or
+// where it is documented as unspecified, such as how long texts are split.
+// The other packages of the module, including pkg/utils, carry no such
+// guarantee, and neither do the command line flags and output of the CLI.
+//
+// Errors from the API keep their gRPC status in the chain, so
+// status.Code(err) tells quota errors (codes.ResourceExhausted) and
+// credential errors (codes.PermissionDenied, codes.Unauthenticated) apart.
+package assistant
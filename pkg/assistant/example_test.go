@@ -0,0 +1,63 @@
+package assistant_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/mikefarmer/assistant-cli/pkg/assistant"
+)
+
+func Example() {
+	ctx := context.Background()
+	client, err := assistant.New(ctx, assistant.Options{
+		ServiceAccountFile: "/etc/narrator/key.json",
+		Voice:              "en-US-Neural2-F",
+		Concurrency:        4,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	audio, err := client.Synthesize(ctx, "Chapter one. It was a dark and stormy night.")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := audio.WriteFile("chapter-1." + audio.Extension()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// silentBackend stands in for the API, returning a tenth of a second of
+// silence for every request
+type silentBackend struct{}
+
+func (silentBackend) Synthesize(ctx context.Context, text string, voice *texttospeechpb.VoiceSelectionParams,
+	audio *texttospeechpb.AudioConfig) ([]byte, error) {
+	return make([]byte, 4800), nil
+}
+
+func (silentBackend) ListVoices(ctx context.Context, languageCode string) ([]*texttospeechpb.Voice, error) {
+	return nil, nil
+}
+
+func (silentBackend) Close() error { return nil }
+
+func ExampleOptions_backend() {
+	client, err := assistant.New(context.Background(), assistant.Options{
+		Backend: silentBackend{},
+		Format:  "LINEAR16",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	audio, err := client.Synthesize(context.Background(), "Testing without credentials.")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(audio.Extension(), audio.Duration)
+	// Output: wav 100ms
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// formatChunks renders chunks for a golden file, one numbered block each
+func formatChunks(chunks []string) string {
+	var b strings.Builder
+	for i, chunk := range chunks {
+		fmt.Fprintf(&b, "--- chunk %d (%d bytes)\n%s\n", i+1, len(chunk), chunk)
+	}
+	return b.String()
+}
+
+// checkGolden compares got with the golden file at path, or rewrites the
+// file when the tests run with -update
+func checkGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run go test with -update to create the golden file")
+	assert.Equal(t, string(want), got, "chunks differ from %s; run go test -update if the change is intended", path)
+}
+
+func TestChunkingGolden(t *testing.T) {
+	tests := []struct {
+		input     string
+		maxLength int
+	}{
+		{"prose.txt", 120},
+		{"prose.txt", 60},
+		{"run-on.txt", 40},
+		{"book.ssml", 120},
+		{"book.ssml", 70},
+		{"context.ssml", 150},
+	}
+
+	processor := NewInputProcessor(nil)
+	validator := NewSSMLValidator()
+	for _, tt := range tests {
+		name := fmt.Sprintf("%s-%d", strings.TrimSuffix(tt.input, filepath.Ext(tt.input)), tt.maxLength)
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "chunking", tt.input))
+			require.NoError(t, err)
+			text := strings.TrimRight(string(data), "\n")
+
+			var chunks []string
+			if filepath.Ext(tt.input) == ".ssml" {
+				chunks, err = processor.SplitSSML(text, tt.maxLength)
+				require.NoError(t, err)
+				for _, chunk := range chunks {
+					assert.NoError(t, validator.ValidateSSML(chunk), "chunk %q", chunk)
+				}
+			} else {
+				chunks = processor.SplitByLength(text, tt.maxLength)
+			}
+			checkGolden(t, filepath.Join("testdata", "chunking", name+".golden"), formatChunks(chunks))
+		})
+	}
+}
+
+// randomWords returns n words of 1 to maxWord letters, some ending a
+// sentence or clause
+func randomWords(rng *rand.Rand, n, maxWord int) []string {
+	words := make([]string, n)
+	for i := range words {
+		word := make([]byte, 1+rng.IntN(maxWord))
+		for j := range word {
+			word[j] = byte('a' + rng.IntN(26))
+		}
+		switch rng.IntN(10) {
+		case 0:
+			word = append(word, '.')
+		case 1:
+			word = append(word, ',')
+		}
+		words[i] = string(word)
+	}
+	return words
+}
+
+func TestSplitByLength_Properties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	processor := NewInputProcessor(nil)
+
+	for i := 0; i < 300; i++ {
+		maxLength := 20 + rng.IntN(200)
+		words := randomWords(rng, 1+rng.IntN(150), maxLength/2)
+		text := strings.Join(words, " ")
+
+		chunks := processor.SplitByLength(text, maxLength)
+		for _, chunk := range chunks {
+			require.NotEmpty(t, strings.TrimSpace(chunk), "empty chunk of %q", text)
+			require.LessOrEqual(t, len(chunk), maxLength, "chunk %q of %q", chunk, text)
+		}
+		// Words that fit are never cut, so the chunks hold exactly the text
+		require.Equal(t, words, strings.Fields(strings.Join(chunks, " ")), "max length %d", maxLength)
+		require.Equal(t, chunks, processor.SplitByLength(text, maxLength), "the split is deterministic")
+	}
+}
+
+// randomSSML returns a <speak> document of paragraphs of sentences, some
+// inside elements that the splitter must carry across chunks
+func randomSSML(rng *rand.Rand, maxWord int) string {
+	var b strings.Builder
+	b.WriteString("<speak>")
+	for p := 0; p < 1+rng.IntN(4); p++ {
+		b.WriteString("<p>")
+		for s := 0; s < 1+rng.IntN(4); s++ {
+			sentence := strings.Join(randomWords(rng, 1+rng.IntN(12), maxWord), " ")
+			switch rng.IntN(5) {
+			case 0:
+				fmt.Fprintf(&b, `<prosody rate="slow">%s</prosody>`, sentence)
+			case 1:
+				fmt.Fprintf(&b, `<emphasis level="moderate">%s</emphasis>`, sentence)
+			case 2:
+				fmt.Fprintf(&b, `<s>%s</s><break time="300ms"/>`, sentence)
+			default:
+				fmt.Fprintf(&b, "<s>%s</s>", sentence)
+			}
+		}
+		b.WriteString("</p>")
+	}
+	b.WriteString("</speak>")
+	return b.String()
+}
+
+func TestSplitSSML_Properties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	processor := NewInputProcessor(nil)
+	validator := NewSSMLValidator()
+
+	for i := 0; i < 300; i++ {
+		maxLength := 120 + rng.IntN(300)
+		ssml := randomSSML(rng, 12)
+
+		chunks, err := processor.SplitSSML(ssml, maxLength)
+		require.NoError(t, err, "splitting %q", ssml)
+		var text []string
+		for _, chunk := range chunks {
+			require.LessOrEqual(t, len(chunk), maxLength, "chunk %q of %q", chunk, ssml)
+			require.True(t, strings.HasPrefix(chunk, "<speak>") && strings.HasSuffix(chunk, "</speak>"),
+				"chunk %q is not a document", chunk)
+			require.NoError(t, validator.ValidateSSML(chunk), "chunk %q of %q", chunk, ssml)
+			text = append(text, stripTags(chunk))
+		}
+		// Splitting only adds markup, never loses or reorders text
+		require.Equal(t, strings.Fields(stripTags(ssml)), strings.Fields(strings.Join(text, "")),
+			"max length %d", maxLength)
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NarratorSpeaker is the speaker name whose voice reads the lines of a
+// script that name no speaker
+const NarratorSpeaker = "narrator"
+
+// speakerLine matches a "NAME: line" of a screenplay-style script. Names
+// are one to four words starting with a letter, so that times like
+// "10:30" and URLs are not taken for speakers.
+var speakerLine = regexp.MustCompile(`^[ \t]*(\pL[\pL\pN.'-]*(?:[ \t]+[\pL\pN.'-]+){0,3})[ \t]*:[ \t]+(\S.*)$`)
+
+// stageDirection matches a line that is wholly a parenthetical or bracketed
+// direction, e.g. "(whispering)" or "[door slams]"
+var stageDirection = regexp.MustCompile(`^[ \t]*(\(.*\)|\[[^\[].*\])[ \t]*$`)
+
+// DialogueFilter turns a screenplay-style script into voice markup. Each
+// "NAME: line" is read by the voice mapped to NAME, lines after it up to
+// the next speaker or blank line continue it, and stage directions are
+// dropped. Lines without a speaker are read by the narrator's voice.
+type DialogueFilter struct {
+	voices map[string]string
+}
+
+// NewDialogueFilter returns a filter reading each speaker with the voice
+// voices maps its name to, ignoring case. Speakers without an entry, and
+// the narrator unless "narrator" has one, are read by the configured
+// voice.
+func NewDialogueFilter(voices map[string]string) *DialogueFilter {
+	normalized := make(map[string]string, len(voices))
+	for name, voice := range voices {
+		normalized[speakerKey(name)] = voice
+	}
+	return &DialogueFilter{voices: normalized}
+}
+
+// Filter rewrites the script as "@voice:" lines for ParseVoiceMarkup.
+// SSML documents and text without speaker lines are returned unchanged.
+func (f *DialogueFilter) Filter(text string) string {
+	if strings.HasPrefix(strings.TrimSpace(text), "<speak") {
+		return text
+	}
+
+	var b strings.Builder
+	current, speaking, found := "", false, false
+	switchTo := func(voice string) {
+		if b.Len() > 0 && voice == current {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		name := voice
+		if name == "" {
+			name = "default"
+		}
+		b.WriteString("@voice: " + name + "\n")
+		current = voice
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.TrimSpace(line) == "":
+			speaking = false
+			continue
+		case stageDirection.MatchString(line):
+			continue
+		}
+
+		if m := speakerLine.FindStringSubmatch(line); m != nil {
+			found, speaking = true, true
+			switchTo(f.voices[speakerKey(m[1])])
+			b.WriteString(m[2] + "\n")
+			continue
+		}
+		if !speaking {
+			switchTo(f.voices[NarratorSpeaker])
+		}
+		b.WriteString(strings.TrimSpace(line) + "\n")
+	}
+
+	if !found {
+		return text
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// speakerKey returns the form speaker names are matched by
+func speakerKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
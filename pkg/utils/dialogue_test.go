@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialogueFilter(t *testing.T) {
+	voices := map[string]string{
+		"ALICE":    "en-US-Neural2-F",
+		"Dr. Bob":  "en-GB-Neural2-B",
+		"Narrator": "en-US-Studio-O",
+	}
+	testCases := []struct {
+		name     string
+		voices   map[string]string
+		input    string
+		expected string
+	}{
+		{"no speakers", voices, "Meet at 10:30.\nSee https://example.com", "Meet at 10:30.\nSee https://example.com"},
+		{"SSML", voices, "<speak>ALICE: hi</speak>", "<speak>ALICE: hi</speak>"},
+		{"alternating speakers", voices, "ALICE: Hello.\ndr.  bob: Hi, Alice.\nAlice: Bye.",
+			"@voice: en-US-Neural2-F\nHello.\n\n@voice: en-GB-Neural2-B\nHi, Alice.\n\n@voice: en-US-Neural2-F\nBye."},
+		{"continuation and narration", voices,
+			"The kitchen, at night.\n\nALICE: Who's there?\n(whispering)\nIs anyone there?\n\nA door slams.\n[pause]",
+			"@voice: en-US-Studio-O\nThe kitchen, at night.\n\n@voice: en-US-Neural2-F\nWho's there?\nIs anyone there?\n\n" +
+				"@voice: en-US-Studio-O\nA door slams."},
+		{"unmapped speakers", nil, "CAROL: One.\nALICE: Two.",
+			"@voice: default\nOne.\nTwo."},
+		{"same voice continues", map[string]string{"a": "v", "b": "v"}, "A: One.\r\nB: Two.",
+			"@voice: v\nOne.\nTwo."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NewDialogueFilter(tc.voices).Filter(tc.input))
+		})
+	}
+}
+
+func TestDialogueFilter_VoiceSegments(t *testing.T) {
+	script := "NARRATOR: Act one.\nALICE: Hello.\nBOB: Hello yourself.\nALICE: Goodbye."
+	filtered := NewDialogueFilter(map[string]string{"alice": "en-US-Neural2-F", "bob": "en-US-Neural2-D"}).Filter(script)
+
+	assert.Equal(t, []VoiceSegment{
+		{Text: "Act one."},
+		{Text: "Hello.", Voice: "en-US-Neural2-F"},
+		{Text: "Hello yourself.", Voice: "en-US-Neural2-D"},
+		{Text: "Goodbye.", Voice: "en-US-Neural2-F"},
+	}, ParseVoiceMarkup(filtered))
+}
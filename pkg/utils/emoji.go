@@ -0,0 +1,298 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Emoji filter modes
+const (
+	EmojiKeep      = "keep"
+	EmojiStrip     = "strip"
+	EmojiVerbalize = "verbalize"
+)
+
+// emojiNames names the common emoji, read as "<name> emoji" when
+// verbalized. Keys are without variation selectors and skin tones.
+var emojiNames = map[string]string{
+	"😀": "grinning face", "😃": "grinning face", "😄": "grinning face", "😁": "beaming face",
+	"😆": "grinning squinting face", "😅": "grinning face with sweat", "🤣": "rolling on the floor laughing",
+	"😂": "face with tears of joy", "🙂": "slightly smiling face", "🙃": "upside-down face",
+	"😉": "winking face", "😊": "smiling face", "😇": "smiling face with halo", "🥰": "smiling face with hearts",
+	"😍": "heart eyes", "🤩": "star-struck", "😘": "face blowing a kiss", "😋": "yum",
+	"😛": "face with tongue", "😜": "winking face with tongue", "🤪": "zany face", "🤗": "hugging face",
+	"🤔": "thinking face", "🤫": "shushing face", "🤐": "zipper-mouth face", "😐": "neutral face",
+	"😑": "expressionless face", "😶": "face without mouth", "😏": "smirking face", "🙄": "face with rolling eyes",
+	"😬": "grimacing face", "😌": "relieved face", "😔": "pensive face", "😴": "sleeping face",
+	"🤒": "face with thermometer", "🤢": "nauseated face", "🤯": "exploding head", "🥳": "partying face",
+	"😎": "smiling face with sunglasses", "🤓": "nerd face", "😕": "confused face", "😟": "worried face",
+	"🙁": "slightly frowning face", "😮": "face with open mouth", "😲": "astonished face", "😳": "flushed face",
+	"🥺": "pleading face", "😨": "fearful face", "😰": "anxious face with sweat", "😢": "crying face",
+	"😭": "loudly crying face", "😱": "face screaming in fear", "😩": "weary face", "😤": "face with steam from nose",
+	"😡": "pouting face", "😠": "angry face", "🤬": "face with symbols on mouth", "💀": "skull",
+	"💩": "pile of poo", "🤡": "clown face", "👻": "ghost", "👽": "alien", "🤖": "robot",
+	"🙈": "see-no-evil monkey", "🙉": "hear-no-evil monkey", "🙊": "speak-no-evil monkey",
+	"💋": "kiss mark", "💯": "hundred points", "💢": "anger symbol", "💥": "collision", "💫": "dizzy",
+	"💦": "sweat droplets", "💤": "zzz", "👋": "waving hand", "🤚": "raised back of hand", "✋": "raised hand",
+	"👌": "OK hand", "🤌": "pinched fingers", "✌": "victory hand", "🤞": "crossed fingers", "🤟": "love-you gesture",
+	"🤘": "sign of the horns", "🤙": "call me hand", "👈": "backhand index pointing left",
+	"👉": "backhand index pointing right", "👆": "backhand index pointing up", "👇": "backhand index pointing down",
+	"☝": "index pointing up", "👍": "thumbs up", "👎": "thumbs down", "✊": "raised fist", "👊": "oncoming fist",
+	"👏": "clapping hands", "🙌": "raising hands", "👐": "open hands", "🤝": "handshake", "🙏": "folded hands",
+	"✍": "writing hand", "💪": "flexed biceps", "🧠": "brain", "👀": "eyes", "👁": "eye", "👶": "baby",
+	"🧒": "child", "👦": "boy", "👧": "girl", "🧑": "person", "👨": "man", "👩": "woman", "🧓": "older person",
+	"🤷": "person shrugging", "🤦": "person facepalming", "🙋": "person raising hand", "🙇": "person bowing",
+	"🏃": "person running", "🚶": "person walking", "💃": "woman dancing", "🕺": "man dancing",
+	"❤": "red heart", "🧡": "orange heart", "💛": "yellow heart", "💚": "green heart", "💙": "blue heart",
+	"💜": "purple heart", "🖤": "black heart", "🤍": "white heart", "💔": "broken heart", "💕": "two hearts",
+	"💖": "sparkling heart", "💗": "growing heart", "💘": "heart with arrow", "💝": "heart with ribbon",
+	"🐶": "dog face", "🐕": "dog", "🐱": "cat face", "🐈": "cat", "🐭": "mouse face", "🐰": "rabbit face",
+	"🦊": "fox", "🐻": "bear", "🐼": "panda", "🐨": "koala", "🐯": "tiger face", "🦁": "lion", "🐮": "cow face",
+	"🐷": "pig face", "🐸": "frog", "🐵": "monkey face", "🐔": "chicken", "🐧": "penguin", "🐦": "bird",
+	"🦄": "unicorn", "🐝": "honeybee", "🦋": "butterfly", "🐢": "turtle", "🐍": "snake", "🐙": "octopus",
+	"🐟": "fish", "🐬": "dolphin", "🐳": "spouting whale", "🌸": "cherry blossom", "🌹": "rose",
+	"🌻": "sunflower", "🌷": "tulip", "🌱": "seedling", "🌲": "evergreen tree", "🌳": "deciduous tree",
+	"🌴": "palm tree", "🍀": "four leaf clover", "🍁": "maple leaf", "🍂": "fallen leaf",
+	"🍎": "red apple", "🍌": "banana", "🍇": "grapes", "🍓": "strawberry", "🍉": "watermelon", "🍋": "lemon",
+	"🥑": "avocado", "🥕": "carrot", "🌽": "ear of corn", "🍞": "bread", "🧀": "cheese", "🍔": "hamburger",
+	"🍟": "french fries", "🍕": "pizza", "🌮": "taco", "🍣": "sushi", "🍜": "steaming bowl", "🍿": "popcorn",
+	"🍩": "doughnut", "🍪": "cookie", "🎂": "birthday cake", "🍰": "shortcake", "🍫": "chocolate bar",
+	"🍦": "ice cream", "☕": "hot beverage", "🍵": "teacup", "🍺": "beer mug", "🍻": "clinking beer mugs",
+	"🍷": "wine glass", "🥂": "clinking glasses", "🍸": "cocktail glass",
+	"🌍": "globe showing Europe-Africa", "🌎": "globe showing Americas", "🌏": "globe showing Asia-Australia",
+	"🌐": "globe with meridians", "🏠": "house", "🏡": "house with garden", "🏢": "office building",
+	"🏥": "hospital", "🏫": "school", "🚗": "car", "🚕": "taxi", "🚌": "bus", "🚲": "bicycle", "🚂": "locomotive",
+	"🚆": "train", "✈": "airplane", "🚀": "rocket", "🚢": "ship", "⛵": "sailboat",
+	"☀": "sun", "🌞": "sun with face", "🌙": "crescent moon", "⭐": "star", "🌟": "glowing star", "✨": "sparkles",
+	"☁": "cloud", "⛅": "sun behind cloud", "🌧": "cloud with rain", "⛈": "cloud with lightning and rain",
+	"🌈": "rainbow", "☔": "umbrella with rain drops", "❄": "snowflake", "⛄": "snowman", "🔥": "fire",
+	"💧": "droplet", "🌊": "water wave", "⚡": "high voltage",
+	"🎉": "party popper", "🎊": "confetti ball", "🎈": "balloon", "🎁": "wrapped gift", "🎄": "Christmas tree",
+	"🎃": "jack-o-lantern", "🏆": "trophy", "🥇": "first place medal", "🥈": "second place medal",
+	"🥉": "third place medal", "⚽": "soccer ball", "🏀": "basketball", "🏈": "american football",
+	"⚾": "baseball", "🎾": "tennis", "🎮": "video game", "🎲": "game die", "🎯": "bullseye", "🎵": "musical note",
+	"🎶": "musical notes", "🎤": "microphone", "🎧": "headphone", "🎸": "guitar", "🎹": "musical keyboard",
+	"🎬": "clapper board", "📷": "camera", "📸": "camera with flash", "📺": "television", "📻": "radio",
+	"📱": "mobile phone", "☎": "telephone", "📞": "telephone receiver", "💻": "laptop", "🖥": "desktop computer",
+	"⌨": "keyboard", "🖨": "printer", "🔋": "battery", "🔌": "electric plug", "💡": "light bulb",
+	"🔦": "flashlight", "📚": "books", "📖": "open book", "📝": "memo", "✏": "pencil", "📄": "page facing up",
+	"📅": "calendar", "📆": "tear-off calendar", "📈": "chart increasing", "📉": "chart decreasing",
+	"📊": "bar chart", "📋": "clipboard", "📌": "pushpin", "📍": "round pushpin", "📎": "paperclip",
+	"✂": "scissors", "🗑": "wastebasket", "🔒": "locked", "🔓": "unlocked", "🔑": "key", "🔨": "hammer",
+	"🔧": "wrench", "⚙": "gear", "🧰": "toolbox", "💰": "money bag", "💵": "dollar banknote",
+	"💳": "credit card", "✉": "envelope", "📧": "e-mail", "📦": "package", "📢": "loudspeaker",
+	"📣": "megaphone", "🔔": "bell", "🔕": "bell with slash", "⏰": "alarm clock", "⌛": "hourglass done",
+	"⏳": "hourglass not done", "⌚": "watch", "🕐": "one o'clock",
+	"✅": "check mark button", "☑": "check box with check", "✔": "check mark", "❌": "cross mark",
+	"❎": "cross mark button", "❓": "question mark", "❔": "white question mark", "❗": "exclamation mark",
+	"❕": "white exclamation mark", "‼": "double exclamation mark", "⁉": "exclamation question mark",
+	"⚠": "warning", "⛔": "no entry", "🚫": "prohibited", "🔴": "red circle", "🟢": "green circle",
+	"🔵": "blue circle", "🟡": "yellow circle", "⚪": "white circle", "⚫": "black circle",
+	"🆗": "OK button", "🆕": "NEW button", "🆓": "FREE button", "🆒": "COOL button", "🔝": "TOP arrow",
+	"🔜": "SOON arrow", "➕": "plus", "➖": "minus", "➗": "divide", "✖": "multiply", "♻": "recycling symbol",
+	"🏁": "chequered flag", "🚩": "triangular flag", "🏳": "white flag", "🏴": "black flag",
+	// Sequences joined by zero-width joiners
+	"👨‍💻": "man technologist", "👩‍💻": "woman technologist", "🧑‍💻": "technologist",
+	"👨‍👩‍👧": "family", "👨‍👩‍👧‍👦": "family", "🏳‍🌈": "rainbow flag", "❤‍🔥": "heart on fire",
+	"🤷‍♂": "man shrugging", "🤷‍♀": "woman shrugging", "🤦‍♂": "man facepalming", "🤦‍♀": "woman facepalming",
+}
+
+// symbolNames names symbols that are read oddly or not at all, verbalized
+// as the name alone
+var symbolNames = map[string]string{
+	"©": "copyright", "®": "registered", "™": "trademark", "℠": "service mark",
+	"✓": "check mark", "✗": "cross", "✘": "cross", "★": "star", "☆": "star",
+	"♥": "heart", "♡": "heart", "☺": "smiling face", "☹": "frowning face",
+	"→": "right arrow", "←": "left arrow", "↑": "up arrow", "↓": "down arrow", "↔": "left-right arrow",
+	"⇒": "implies", "⇔": "if and only if", "♪": "musical note", "♫": "musical notes", "∞": "infinity",
+}
+
+// EmojiFilter removes emoji and special symbols from text or replaces them
+// with their names, for voices that read their Unicode names oddly or skip
+// them
+type EmojiFilter struct {
+	mode  string
+	names map[string]string
+}
+
+// NewEmojiFilter returns a filter of the given mode. names adds to or
+// replaces the built-in names, keyed by the emoji or symbol; an empty name
+// drops the emoji even when verbalizing.
+func NewEmojiFilter(mode string, names map[string]string) (*EmojiFilter, error) {
+	switch mode {
+	case EmojiKeep, EmojiStrip, EmojiVerbalize:
+	default:
+		return nil, fmt.Errorf("unknown emoji mode %q (want keep, strip, or verbalize)", mode)
+	}
+
+	all := make(map[string]string, len(emojiNames)+len(symbolNames)+len(names))
+	for emoji, name := range emojiNames {
+		all[emoji] = name + " emoji"
+	}
+	for symbol, name := range symbolNames {
+		all[symbol] = name
+	}
+	for key, name := range names {
+		all[stripEmojiModifiers(key)] = strings.TrimSpace(name)
+	}
+	return &EmojiFilter{mode: mode, names: all}, nil
+}
+
+// Filter strips or verbalizes the emoji and symbols in text. Like the
+// other filters, it rewrites only text outside the tags of an SSML
+// document.
+func (f *EmojiFilter) Filter(text string) string {
+	switch {
+	case f.mode == EmojiKeep:
+		return text
+	case strings.HasPrefix(strings.TrimSpace(text), "<speak"):
+		return rewriteSSMLText(text, f.rewrite)
+	}
+	return f.rewrite(text)
+}
+
+// rewrite strips or verbalizes the emoji and symbols in a run of text
+func (f *EmojiFilter) rewrite(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		length, ok := f.match(text[i:])
+		if !ok {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			b.WriteRune(r)
+			i += size
+			continue
+		}
+
+		name := ""
+		if f.mode == EmojiVerbalize {
+			name = f.name(text[i : i+length])
+		}
+		before, _ := utf8.DecodeLastRuneInString(b.String())
+		after, _ := utf8.DecodeRuneInString(text[i+length:])
+		if name != "" {
+			// Set the name off from the words around it
+			if b.Len() > 0 && !unicode.IsSpace(before) {
+				b.WriteByte(' ')
+			}
+			b.WriteString(name)
+			if i+length < len(text) && !unicode.IsSpace(after) && !unicode.IsPunct(after) {
+				b.WriteByte(' ')
+			}
+		} else if b.Len() == 0 && unicode.IsSpace(after) {
+			// Leave no space at the start
+			length += utf8.RuneLen(after)
+		} else if unicode.IsSpace(before) && (i+length == len(text) || unicode.IsSpace(after) || unicode.IsPunct(after)) {
+			// Leave no double space or space before punctuation behind
+			trimmed := strings.TrimRightFunc(b.String(), unicode.IsSpace)
+			b.Reset()
+			b.WriteString(trimmed)
+		}
+		i += length
+	}
+	return b.String()
+}
+
+// match returns the length of the emoji or symbol at the start of text,
+// with its modifiers and any sequence joined to it
+func (f *EmojiFilter) match(text string) (int, bool) {
+	r, size := utf8.DecodeRuneInString(text)
+	if _, ok := f.names[string(r)]; !ok && !isEmoji(r) {
+		return 0, false
+	}
+	if isRegionalIndicator(r) {
+		if next, n := utf8.DecodeRuneInString(text[size:]); isRegionalIndicator(next) {
+			return size + n, true
+		}
+		return size, true
+	}
+
+	length := size
+	for length < len(text) {
+		next, n := utf8.DecodeRuneInString(text[length:])
+		switch {
+		case isEmojiModifier(next):
+			length += n
+		case next == zeroWidthJoiner:
+			joined, m := utf8.DecodeRuneInString(text[length+n:])
+			if !isEmoji(joined) && joined != '♂' && joined != '♀' {
+				return length, true
+			}
+			length += n + m
+		default:
+			return length, true
+		}
+	}
+	return length, true
+}
+
+// name returns the spoken name of an emoji or symbol: its own, else that of
+// the first emoji of a sequence, or "" for an unknown one
+func (f *EmojiFilter) name(emoji string) string {
+	key := stripEmojiModifiers(emoji)
+	if name, ok := f.names[key]; ok {
+		return name
+	}
+	first, size := utf8.DecodeRuneInString(key)
+	if isRegionalIndicator(first) {
+		second, _ := utf8.DecodeRuneInString(key[size:])
+		if isRegionalIndicator(second) {
+			return fmt.Sprintf("%c%c flag emoji", 'A'+first-regionalIndicatorA, 'A'+second-regionalIndicatorA)
+		}
+		return ""
+	}
+	if end := strings.IndexRune(key, zeroWidthJoiner); end > 0 {
+		return f.names[key[:end]]
+	}
+	return ""
+}
+
+// Code points that combine with emoji
+const (
+	zeroWidthJoiner    = '‍'
+	regionalIndicatorA = '\U0001F1E6'
+	regionalIndicatorZ = '\U0001F1FF'
+)
+
+// stripEmojiModifiers removes the variation selectors, skin tones, and
+// keycap marks from an emoji, leaving the form the names are keyed by
+func stripEmojiModifiers(emoji string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmojiModifier(r) {
+			return -1
+		}
+		return r
+	}, emoji)
+}
+
+// isEmojiModifier reports whether r changes the look of the emoji before
+// it: a variation selector, a skin tone, a keycap mark, or a tag
+func isEmojiModifier(r rune) bool {
+	return r == '︎' || r == '️' || r == '⃣' ||
+		(r >= '\U0001F3FB' && r <= '\U0001F3FF') ||
+		(r >= '\U000E0020' && r <= '\U000E007F')
+}
+
+// isRegionalIndicator reports whether r is one of the letters that pair up
+// into flags
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+// isEmoji reports whether r is in the blocks of pictographic emoji
+func isEmoji(r rune) bool {
+	switch {
+	case r >= '\U0001F000' && r <= '\U0001FAFF':
+		// Pictographs, emoticons, transport, and their supplements
+		return !isEmojiModifier(r)
+	case r >= '☀' && r <= '➿':
+		// Miscellaneous symbols and dingbats
+		return true
+	case r == '⌚', r == '⌛', r == '⌨', r == '⏏', r >= '⏩' && r <= '⏳',
+		r >= '⏸' && r <= '⏺', r == '⭐', r == '⭕', r >= '⬅' && r <= '⬇',
+		r == '⬛', r == '⬜', r == '‼', r == '⁉':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmojiFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     string
+		input    string
+		expected string
+	}{
+		{"keep", EmojiKeep, "Great work 👍", "Great work 👍"},
+		{"strip at end", EmojiStrip, "Great work 👍", "Great work"},
+		{"strip between words", EmojiStrip, "Ship it 🚀 today", "Ship it today"},
+		{"strip before punctuation", EmojiStrip, "Done ✅!", "Done!"},
+		{"strip at start", EmojiStrip, "🎉 Party time", "Party time"},
+		{"strip symbols", EmojiStrip, "Acme™ © 2024", "Acme 2024"},
+		{"verbalize", EmojiVerbalize, "Great work 👍", "Great work thumbs up emoji"},
+		{"verbalize attached", EmojiVerbalize, "Thanks🙏!", "Thanks folded hands emoji!"},
+		{"verbalize symbols", EmojiVerbalize, "Acme™ → ∞", "Acme trademark right arrow infinity"},
+		{"skin tone", EmojiVerbalize, "👋🏽 hi", "waving hand emoji hi"},
+		{"variation selector", EmojiVerbalize, "I ❤️ it", "I red heart emoji it"},
+		{"joined sequence", EmojiVerbalize, "👩‍💻 coding", "woman technologist emoji coding"},
+		{"unknown joined sequence", EmojiVerbalize, "👩🏻‍🚀 flies", "woman emoji flies"},
+		{"flag", EmojiVerbalize, "Go 🇫🇷", "Go FR flag emoji"},
+		{"strip flag", EmojiStrip, "Go 🇫🇷 team", "Go team"},
+		{"unknown emoji dropped", EmojiVerbalize, "A 🫨 shake", "A shake"},
+		{"plain text untouched", EmojiStrip, "Café & 3 < 4", "Café & 3 < 4"},
+		{"SSML", EmojiVerbalize, `<speak><p>Nice 👍</p><sub alias="rocket">🚀</sub></speak>`,
+			`<speak><p>Nice thumbs up emoji</p><sub alias="rocket">🚀</sub></speak>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := NewEmojiFilter(tc.mode, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, filter.Filter(tc.input))
+		})
+	}
+}
+
+func TestEmojiFilter_Names(t *testing.T) {
+	filter, err := NewEmojiFilter(EmojiVerbalize, map[string]string{"🚀": "launch", "❤️": "love", "©": ""})
+	require.NoError(t, err)
+	assert.Equal(t, "Ready to launch, with love", filter.Filter("Ready to 🚀, with ❤"))
+	assert.Equal(t, "Acme 2024", filter.Filter("Acme © 2024"))
+
+	_, err = NewEmojiFilter("shout", nil)
+	assert.Error(t, err)
+}
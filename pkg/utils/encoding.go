@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Input encodings accepted by DecodeReader and InputProcessor.SetEncoding
+const (
+	// EncodingAuto detects the encoding from a byte order mark or the text
+	EncodingAuto    = "auto"
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	// EncodingUTF16 is UTF-16 in the byte order of its byte order mark, or
+	// else as detected, little-endian by default as written on Windows
+	EncodingUTF16 = "utf-16"
+	// EncodingLatin1 is ISO 8859-1
+	EncodingLatin1 = "latin1"
+	// EncodingWindows1252 is the Western European code page of Windows, a
+	// superset of the printable characters of Latin-1
+	EncodingWindows1252 = "windows-1252"
+)
+
+// Encodings lists the canonical names of the input encodings
+var Encodings = []string{
+	EncodingAuto, EncodingUTF8, EncodingUTF16, EncodingUTF16LE, EncodingUTF16BE,
+	EncodingLatin1, EncodingWindows1252,
+}
+
+// encodingAliases maps other common names to the canonical ones
+var encodingAliases = map[string]string{
+	"":            EncodingAuto,
+	"utf8":        EncodingUTF8,
+	"utf16":       EncodingUTF16,
+	"utf16le":     EncodingUTF16LE,
+	"utf16be":     EncodingUTF16BE,
+	"latin-1":     EncodingLatin1,
+	"iso-8859-1":  EncodingLatin1,
+	"iso8859-1":   EncodingLatin1,
+	"cp1252":      EncodingWindows1252,
+	"windows1252": EncodingWindows1252,
+}
+
+// encodingSniffSize is how much of the input is examined to detect its
+// encoding
+const encodingSniffSize = 4096
+
+// Byte order marks
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// ParseEncoding returns the canonical name of an input encoding, accepting
+// common aliases such as "utf8", "iso-8859-1", and "cp1252"
+func ParseEncoding(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if canonical, ok := encodingAliases[name]; ok {
+		return canonical, nil
+	}
+	for _, valid := range Encodings {
+		if name == valid {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown input encoding %q (valid: %s)", name, strings.Join(Encodings, ", "))
+}
+
+// DecodeReader returns a reader of the text of r converted from encoding
+// to UTF-8, without any byte order mark, and the encoding it was read in:
+// for EncodingAuto, the one detected. Detection recognizes byte order
+// marks, UTF-16 without one, and UTF-8; any other text is read as
+// Windows-1252.
+func DecodeReader(r io.Reader, encodingName string) (io.Reader, string, error) {
+	name, err := ParseEncoding(encodingName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	buffered := bufio.NewReaderSize(r, encodingSniffSize)
+	head, err := buffered.Peek(encodingSniffSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, "", err
+	}
+
+	// A byte order mark settles the encoding unless another was asked for
+	bom := detectBOM(head)
+	switch {
+	case bom == "":
+	case name == EncodingAuto || name == bom || (name == EncodingUTF16 && bom != EncodingUTF8):
+		name = bom
+		if _, err := buffered.Discard(bomLength(bom)); err != nil {
+			return nil, "", err
+		}
+		head = head[bomLength(bom):]
+	}
+
+	switch name {
+	case EncodingAuto:
+		name = detectEncoding(head, len(head) == encodingSniffSize)
+	case EncodingUTF16:
+		if name = detectUTF16(head); name == "" {
+			name = EncodingUTF16LE
+		}
+	}
+
+	var decoder *encoding.Decoder
+	switch name {
+	case EncodingUTF8:
+		return buffered, name, nil
+	case EncodingUTF16LE:
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
+	case EncodingUTF16BE:
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
+	case EncodingLatin1:
+		decoder = charmap.ISO8859_1.NewDecoder()
+	default:
+		decoder = charmap.Windows1252.NewDecoder()
+	}
+	return transform.NewReader(buffered, decoder), name, nil
+}
+
+// detectBOM returns the encoding whose byte order mark data starts with
+func detectBOM(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return EncodingUTF16BE
+	default:
+		return ""
+	}
+}
+
+// bomLength returns the size of the byte order mark of an encoding
+func bomLength(name string) int {
+	if name == EncodingUTF8 {
+		return len(bomUTF8)
+	}
+	return len(bomUTF16LE)
+}
+
+// detectEncoding guesses the encoding of head, the start of a text without
+// a byte order mark; truncated is set when the text goes on
+func detectEncoding(head []byte, truncated bool) string {
+	if utf16 := detectUTF16(head); utf16 != "" {
+		return utf16
+	}
+	if truncated {
+		// Leave out a character cut off at the end of head
+		if last := lastRuneStart(head); !utf8.FullRune(head[last:]) {
+			head = head[:last]
+		}
+	}
+	if utf8.Valid(head) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// lastRuneStart returns the offset of the last character of data
+func lastRuneStart(data []byte) int {
+	for i := len(data) - 1; i > 0; i-- {
+		if utf8.RuneStart(data[i]) {
+			return i
+		}
+	}
+	return 0
+}
+
+// detectUTF16 recognizes UTF-16 text without a byte order mark by the zero
+// bytes of its ASCII characters, which fall on odd offsets in little-endian
+// and even ones in big-endian text, returning "" for other text
+func detectUTF16(head []byte) string {
+	pairs := len(head) / 2
+	if pairs == 0 {
+		return ""
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(head); i += 2 {
+		if head[i] == 0 {
+			evenZeros++
+		}
+		if head[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	switch {
+	case oddZeros*10 >= pairs*4 && evenZeros*10 < pairs:
+		return EncodingUTF16LE
+	case evenZeros*10 >= pairs*4 && oddZeros*10 < pairs:
+		return EncodingUTF16BE
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeUTF16 returns text in UTF-16 of the given byte order
+func encodeUTF16(text string, bigEndian bool) []byte {
+	var buf bytes.Buffer
+	for _, unit := range utf16.Encode([]rune(text)) {
+		if bigEndian {
+			buf.Write([]byte{byte(unit >> 8), byte(unit)})
+		} else {
+			buf.Write([]byte{byte(unit), byte(unit >> 8)})
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeReader(t *testing.T) {
+	const text = "Café «naïve» — 日本語 🌍"
+	latin1 := []byte("Caf\xe9 \xabna\xefve\xbb")
+	tests := []struct {
+		name     string
+		input    []byte
+		encoding string
+		want     string
+		detected string
+	}{
+		{"utf-8", []byte(text), EncodingAuto, text, EncodingUTF8},
+		{"utf-8 bom", append([]byte("\xEF\xBB\xBF"), text...), EncodingAuto, text, EncodingUTF8},
+		{"utf-8 bom given", append([]byte("\xEF\xBB\xBF"), text...), "utf8", text, EncodingUTF8},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, encodeUTF16(text, false)...), EncodingAuto, text, EncodingUTF16LE},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, encodeUTF16(text, true)...), EncodingAuto, text, EncodingUTF16BE},
+		{"utf-16 bom given", append([]byte{0xFE, 0xFF}, encodeUTF16(text, true)...), EncodingUTF16, text, EncodingUTF16BE},
+		{"utf-16le without bom", encodeUTF16("Hello, world!", false), EncodingAuto, "Hello, world!", EncodingUTF16LE},
+		{"utf-16be without bom", encodeUTF16("Hello, world!", true), EncodingAuto, "Hello, world!", EncodingUTF16BE},
+		{"utf-16 without bom", encodeUTF16("Hi", false), EncodingUTF16, "Hi", EncodingUTF16LE},
+		{"latin-1 detected", latin1, EncodingAuto, "Café «naïve»", EncodingWindows1252},
+		{"latin-1 given", latin1, "iso-8859-1", "Café «naïve»", EncodingLatin1},
+		{"windows-1252 quotes", []byte("\x93quoted\x94 \x85"), EncodingAuto, "“quoted” …", EncodingWindows1252},
+		{"empty", nil, EncodingAuto, "", EncodingUTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, detected, err := DecodeReader(bytes.NewReader(tt.input), tt.encoding)
+			require.NoError(t, err)
+			assert.Equal(t, tt.detected, detected)
+			decoded, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(decoded))
+		})
+	}
+
+	_, _, err := DecodeReader(strings.NewReader("text"), "ebcdic")
+	assert.ErrorContains(t, err, `unknown input encoding "ebcdic"`)
+}
+
+func TestDetectEncoding_CutCharacter(t *testing.T) {
+	// A character cut off at the end of the sample is not invalid UTF-8
+	text := strings.Repeat("a", encodingSniffSize-1) + "é and more"
+	reader, detected, err := DecodeReader(strings.NewReader(text), EncodingAuto)
+	require.NoError(t, err)
+	assert.Equal(t, EncodingUTF8, detected)
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, text, string(decoded))
+}
+
+func TestInputProcessor_Encoding(t *testing.T) {
+	input := append([]byte{0xFF, 0xFE}, encodeUTF16("Hello from Notepad\r\n", false)...)
+	processor := NewInputProcessor(bytes.NewReader(input))
+	assert.Equal(t, EncodingAuto, processor.Encoding())
+
+	text, err := processor.ReadText()
+	require.NoError(t, err)
+	assert.Equal(t, "Hello from Notepad", text)
+	assert.Equal(t, EncodingUTF16LE, processor.Encoding())
+
+	assert.Error(t, processor.SetEncoding("klingon"))
+	require.NoError(t, processor.SetEncoding("CP1252"))
+	assert.Equal(t, EncodingWindows1252, processor.Encoding())
+}
@@ -0,0 +1,81 @@
+package utils
+
+import "strings"
+
+// TextFilter rewrites input text before synthesis. Filters are applied in
+// order by ApplyFilters; a filter may turn plain text into an SSML document.
+type TextFilter interface {
+	Filter(text string) string
+}
+
+// TextFilterFunc adapts a function to a TextFilter
+type TextFilterFunc func(text string) string
+
+// Filter calls f(text)
+func (f TextFilterFunc) Filter(text string) string {
+	return f(text)
+}
+
+// ApplyFilters passes text through each filter in turn
+func ApplyFilters(text string, filters ...TextFilter) string {
+	for _, filter := range filters {
+		text = filter.Filter(text)
+	}
+	return text
+}
+
+// rewriteSSMLText applies rewrite to the runs of text between the tags of
+// an SSML document, leaving the content of <say-as>, <sub>, and <phoneme>
+// alone
+func rewriteSSMLText(ssml string, rewrite func(string) string) string {
+	var b strings.Builder
+	protected := 0
+	for ssml != "" {
+		start := strings.IndexByte(ssml, '<')
+		if start < 0 {
+			start = len(ssml)
+		}
+		if protected == 0 {
+			b.WriteString(rewrite(ssml[:start]))
+		} else {
+			b.WriteString(ssml[:start])
+		}
+		ssml = ssml[start:]
+		if ssml == "" {
+			break
+		}
+
+		end := strings.IndexByte(ssml, '>')
+		if end < 0 {
+			b.WriteString(ssml)
+			break
+		}
+		tag := ssml[:end+1]
+		b.WriteString(tag)
+		ssml = ssml[end+1:]
+
+		name := strings.TrimPrefix(strings.Trim(tag, "<>/"), "/")
+		if i := strings.IndexAny(name, " \t\n/"); i >= 0 {
+			name = name[:i]
+		}
+		if protectedElements[name] && !strings.HasSuffix(tag, "/>") {
+			if strings.HasPrefix(tag, "</") {
+				protected--
+			} else {
+				protected++
+			}
+		}
+	}
+	return b.String()
+}
+
+// rewritePlainAsSSML applies rewrite, which produces SSML markup, to
+// escaped plain text and returns it as an SSML document, or returns text
+// unchanged when nothing was rewritten
+func rewritePlainAsSSML(text string, rewrite func(string) string) string {
+	escaped := ssmlTextEscaper.Replace(strings.TrimSpace(text))
+	if rewritten := rewrite(escaped); rewritten != escaped {
+		return "<speak>" + rewritten + "</speak>"
+	}
+	return text
+}
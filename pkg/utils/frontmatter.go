@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatterKey is the front-matter key holding the settings of an input
+// file, so the block can also hold the front-matter of other tools such as
+// static site generators
+const FrontMatterKey = "assistant-cli"
+
+// frontMatterBlock matches a YAML front-matter block: a "---" line at the
+// very start of the text, the YAML, and a closing "---" or "..." line
+var frontMatterBlock = regexp.MustCompile(`\A---[ \t]*\r?\n((?s:.*?)\r?\n)?(?:---|\.\.\.)[ \t]*(?:\r?\n|\z)`)
+
+// FrontMatter holds the settings an input file gives for itself in its
+// front-matter. Settings left empty or zero keep the configured value.
+type FrontMatter struct {
+	Voice        string  `yaml:"voice"`
+	Language     string  `yaml:"language"`
+	SpeakingRate float64 `yaml:"speaking_rate"`
+	Pitch        float64 `yaml:"pitch"`
+	VolumeGain   float64 `yaml:"volume_gain"`
+	// Output names the output file, as a filename template
+	Output string `yaml:"output"`
+}
+
+// IsZero reports whether the front-matter gives no settings
+func (f FrontMatter) IsZero() bool {
+	return f == FrontMatter{}
+}
+
+// ParseFrontMatter splits a YAML front-matter block off the top of text and
+// returns the settings under its "assistant-cli" key with the rest of the
+// text:
+//
+//	---
+//	title: Chapter One
+//	assistant-cli:
+//	  voice: en-GB-News-K
+//	  speaking_rate: 1.1
+//	  output: chapter-one.mp3
+//	---
+//
+// The block is removed even without that key, so other front-matter is not
+// read aloud. Text whose first lines are not a YAML mapping between "---"
+// lines, such as a horizontal rule in Markdown, is returned unchanged.
+// Unknown settings under the key are an error.
+func ParseFrontMatter(text string) (FrontMatter, string, error) {
+	var settings FrontMatter
+
+	match := frontMatterBlock.FindStringSubmatchIndex(text)
+	if match == nil {
+		return settings, text, nil
+	}
+	block := ""
+	if match[2] >= 0 {
+		block = text[match[2]:match[3]]
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(block), &doc); err != nil {
+		return settings, text, nil
+	}
+	if len(doc.Content) == 0 {
+		// An empty block
+		return settings, text[match[1]:], nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return settings, text, nil
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != FrontMatterKey {
+			continue
+		}
+		if err := decodeFrontMatter(mapping.Content[i+1], &settings); err != nil {
+			return FrontMatter{}, text, &InputError{
+				Type:    "front-matter",
+				Message: fmt.Sprintf("invalid %s settings: %v", FrontMatterKey, err),
+			}
+		}
+	}
+	return settings, text[match[1]:], nil
+}
+
+// decodeFrontMatter decodes the settings node of a front-matter block,
+// rejecting settings FrontMatter does not have
+func decodeFrontMatter(node *yaml.Node, settings *FrontMatter) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("expected a mapping of settings")
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	return decoder.Decode(settings)
+}
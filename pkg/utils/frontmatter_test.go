@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		settings FrontMatter
+		body     string
+	}{
+		{
+			"settings",
+			"---\nassistant-cli:\n  voice: en-GB-News-K\n  speaking_rate: 1.1\n  output: chapter-one.mp3\n---\nIt began.",
+			FrontMatter{Voice: "en-GB-News-K", SpeakingRate: 1.1, Output: "chapter-one.mp3"},
+			"It began.",
+		},
+		{
+			"other front-matter",
+			"---\ntitle: Chapter One\ntags: [fiction]\n---\n\nIt began.\n",
+			FrontMatter{},
+			"\nIt began.\n",
+		},
+		{
+			"shared block with CRLF and dots",
+			"---\r\ntitle: Chapter One\r\nassistant-cli:\r\n  pitch: -2\r\n...\r\nIt began.",
+			FrontMatter{Pitch: -2},
+			"It began.",
+		},
+		{
+			"empty block",
+			"---\n---\nIt began.",
+			FrontMatter{},
+			"It began.",
+		},
+		{
+			"no front-matter",
+			"It began.\n---\nvoice: x\n---\n",
+			FrontMatter{},
+			"It began.\n---\nvoice: x\n---\n",
+		},
+		{
+			"horizontal rules",
+			"---\nJust a line between rules\n---\nIt began.",
+			FrontMatter{},
+			"---\nJust a line between rules\n---\nIt began.",
+		},
+		{
+			"unclosed",
+			"---\nvoice: x\nIt began.",
+			FrontMatter{},
+			"---\nvoice: x\nIt began.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			settings, body, err := ParseFrontMatter(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.settings, settings)
+			assert.Equal(t, tc.body, body)
+		})
+	}
+}
+
+func TestParseFrontMatter_Errors(t *testing.T) {
+	for _, input := range []string{
+		"---\nassistant-cli:\n  vioce: en-GB-News-K\n---\nIt began.",
+		"---\nassistant-cli: en-GB-News-K\n---\nIt began.",
+		"---\nassistant-cli:\n  speaking_rate: fast\n---\nIt began.",
+	} {
+		_, body, err := ParseFrontMatter(input)
+		var inputErr *InputError
+		require.ErrorAs(t, err, &inputErr, input)
+		assert.Equal(t, "front-matter", inputErr.Type)
+		assert.Equal(t, input, body)
+	}
+}
+
+func TestFrontMatter_IsZero(t *testing.T) {
+	assert.True(t, FrontMatter{}.IsZero())
+	assert.False(t, FrontMatter{Output: "out.mp3"}.IsZero())
+}
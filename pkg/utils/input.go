@@ -19,6 +19,10 @@ const (
 type InputProcessor struct {
 	maxLength int
 	reader    io.Reader
+	// encoding is the input encoding, empty for EncodingAuto
+	encoding string
+	// decoded is reader converted to UTF-8, once reading has started
+	decoded io.Reader
 }
 
 // InputError represents input-related errors
@@ -67,18 +71,59 @@ func NewInputProcessorWithLimit(reader io.Reader, maxLength int) *InputProcessor
 	}
 }
 
-// ReadText reads and validates text from the input source
-func (p *InputProcessor) ReadText() (string, error) {
+// SetEncoding sets the encoding the input is read in, one of Encodings or
+// an alias ParseEncoding accepts. The default, EncodingAuto, detects it.
+func (p *InputProcessor) SetEncoding(encoding string) error {
+	name, err := ParseEncoding(encoding)
+	if err != nil {
+		return err
+	}
+	p.encoding = name
+	return nil
+}
+
+// Encoding returns the encoding the input is read in: once reading has
+// started, the detected one if it was EncodingAuto
+func (p *InputProcessor) Encoding() string {
+	if p.encoding == "" {
+		return EncodingAuto
+	}
+	return p.encoding
+}
+
+// input returns the input converted to UTF-8, detecting its encoding on
+// the first call
+func (p *InputProcessor) input() (io.Reader, error) {
 	if p.reader == nil {
-		return "", &InputError{
+		return nil, &InputError{
 			Type:    "configuration",
 			Message: "no input reader configured",
 		}
 	}
+	if p.decoded == nil {
+		decoded, encoding, err := DecodeReader(p.reader, p.encoding)
+		if err != nil {
+			return nil, &InputError{
+				Type:    "read",
+				Message: fmt.Sprintf("failed to read input: %v", err),
+			}
+		}
+		p.decoded, p.encoding = decoded, encoding
+	}
+	return p.decoded, nil
+}
+
+// ReadText reads and validates text from the input source, converting it
+// to UTF-8 from the encoding set with SetEncoding
+func (p *InputProcessor) ReadText() (string, error) {
+	reader, err := p.input()
+	if err != nil {
+		return "", err
+	}
 
 	// Read input with buffering
 	var buffer strings.Builder
-	scanner := bufio.NewScanner(p.reader)
+	scanner := bufio.NewScanner(reader)
 	// Set scanner buffer to be larger than our limit to avoid scanner errors for length issues
 	scanner.Buffer(make([]byte, BufferSize), p.maxLength+1000)
 
@@ -118,6 +163,37 @@ func (p *InputProcessor) ReadText() (string, error) {
 	return text, nil
 }
 
+// ReadValidatedText reads text like ReadText. An SSML document is validated
+// with validator as it is read, so a document that breaks its size, nesting,
+// or security rules is refused before it is held whole, and no more than
+// the validator's maximum document size is read. Plain text is returned
+// unchecked by validator.
+func (p *InputProcessor) ReadValidatedText(validator *SSMLValidator) (string, error) {
+	reader, err := p.input()
+	if err != nil {
+		return "", err
+	}
+	buffered := bufio.NewReaderSize(reader, BufferSize)
+	p.decoded = buffered
+	// A short input is peeked whole, with an io.EOF error
+	start, _ := buffered.Peek(BufferSize)
+	if !strings.HasPrefix(strings.TrimSpace(string(start)), "<speak") {
+		text, err := p.ReadText()
+		if err != nil || !strings.HasPrefix(strings.TrimSpace(text), "<speak") {
+			return text, err
+		}
+		// The document starts after more whitespace than was peeked
+		return text, validator.ValidateSSMLReader(strings.NewReader(text))
+	}
+
+	var document strings.Builder
+	if err := validator.ValidateSSMLReader(io.TeeReader(buffered, &document)); err != nil {
+		return "", err
+	}
+	p.decoded = strings.NewReader(document.String())
+	return p.ReadText()
+}
+
 // ReadTextWithPrompt reads text with a user prompt (for interactive mode)
 func (p *InputProcessor) ReadTextWithPrompt(prompt string) (string, error) {
 	fmt.Print(prompt)
@@ -255,24 +331,32 @@ func (p *InputProcessor) SplitByLength(text string, maxLength int) []string {
 	return chunks
 }
 
-// findSplitPoint finds the best point to split text, preferring word boundaries
+// findSplitPoint finds the best point to split text, preferring the end of
+// a sentence as found by SegmentSentences, then word boundaries
 func (p *InputProcessor) findSplitPoint(text string, maxLength int) int {
 	if len(text) <= maxLength {
 		return len(text)
 	}
 
+	// The last sentence that ends within the allowable length
+	best := 0
+	for _, boundary := range sentenceBoundaries(text[:maxLength+1]) {
+		if boundary <= maxLength {
+			best = boundary
+		}
+	}
+	if best > 0 {
+		return best
+	}
+
 	// Try to find a good break point
-	breakChars := []string{". ", "! ", "? ", "; ", ", ", " "}
+	breakChars := []string{"; ", ", ", " "}
 
 	// Look for the best break point within the allowable length
 	for _, breakChar := range breakChars {
 		// Look for the last occurrence of the break character within maxLength
 		substr := text[:maxLength]
 		if idx := strings.LastIndex(substr, breakChar); idx != -1 {
-			// For sentence endings, we want to include the space
-			if breakChar == ". " || breakChar == "! " || breakChar == "? " {
-				return idx + len(breakChar)
-			}
 			// For word boundaries (just spaces), include the space
 			if breakChar == " " {
 				return idx + 1
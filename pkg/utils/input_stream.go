@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ChunkReader reads text from the input of an InputProcessor in chunks of
+// at most a given size, validating each as it is read, so that inputs of
+// many megabytes are never held in memory whole. Memory use is bounded by
+// the chunk size. The chunks are those SplitByLength returns for the whole
+// text, except that a chunk split without a word boundary never ends inside
+// a UTF-8 character. The input is converted to UTF-8 like ReadText does.
+//
+// The maximum length of the processor applies to the total size of the
+// stream. Plain text only: SSML documents are validated as streams with
+// SSMLValidator.ValidateSSMLReader but split whole with SplitSSML.
+type ChunkReader struct {
+	processor *InputProcessor
+	maxChunk  int
+	block     []byte
+	// pending is the text read but not returned yet
+	pending string
+	// bytesRead is the size of the stream read so far
+	bytesRead int
+	// split is set once a chunk was split off, after which the remaining
+	// text is trimmed like SplitByLength trims it
+	split        bool
+	eof          bool
+	chunks       int
+	controlChars int
+	err          error
+}
+
+// NewChunkReader returns a reader of the processor's input in chunks of at
+// most maxChunk bytes
+func (p *InputProcessor) NewChunkReader(maxChunk int) *ChunkReader {
+	if maxChunk <= 0 {
+		maxChunk = MaxTextLength
+	}
+	return &ChunkReader{
+		processor: p,
+		maxChunk:  maxChunk,
+		block:     make([]byte, BufferSize),
+	}
+}
+
+// Next returns the next chunk of text, or io.EOF after the last one. An
+// input that is empty, too long, or not valid text fails with an
+// *InputError, as ReadText does; a failure is returned by every later call.
+func (r *ChunkReader) Next() (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	chunk, err := r.next()
+	if err == nil {
+		err = r.check(chunk)
+	}
+	if err != nil {
+		r.err = err
+		return "", err
+	}
+	r.chunks++
+	return chunk, nil
+}
+
+// BytesRead returns the size of the input read so far
+func (r *ChunkReader) BytesRead() int {
+	return r.bytesRead
+}
+
+// next returns the next chunk without validating it
+func (r *ChunkReader) next() (string, error) {
+	// Read until the text is longer than a chunk, not counting trailing
+	// whitespace, so the split point is the one of the whole text
+	for !r.eof && len(strings.TrimRightFunc(r.pending, unicode.IsSpace)) <= r.maxChunk {
+		if err := r.fill(); err != nil {
+			return "", err
+		}
+	}
+
+	remaining := r.pending
+	if r.eof && r.split {
+		remaining = strings.TrimSpace(remaining)
+	}
+	if len(remaining) <= r.maxChunk {
+		// The last chunk
+		r.pending = ""
+		if r.chunks == 0 && strings.TrimSpace(remaining) == "" {
+			return "", &InputError{
+				Type:    "empty",
+				Message: "input text is empty or contains only whitespace",
+			}
+		}
+		if remaining == "" {
+			return "", io.EOF
+		}
+		return remaining, nil
+	}
+
+	splitPoint := r.processor.findSplitPoint(remaining, r.maxChunk)
+	for splitPoint > 0 && !utf8.RuneStart(remaining[splitPoint]) {
+		splitPoint--
+	}
+	if splitPoint == 0 {
+		// A chunk smaller than one character still holds that character
+		_, splitPoint = utf8.DecodeRuneInString(remaining)
+	}
+
+	chunk := remaining[:splitPoint]
+	if !strings.HasSuffix(chunk, ". ") && !strings.HasSuffix(chunk, "! ") && !strings.HasSuffix(chunk, "? ") {
+		chunk = strings.TrimSpace(chunk)
+	}
+	r.pending = strings.TrimLeftFunc(remaining[splitPoint:], unicode.IsSpace)
+	r.split = true
+	return chunk, nil
+}
+
+// fill reads the next block of the input into pending
+func (r *ChunkReader) fill() error {
+	reader, err := r.processor.input()
+	if err != nil {
+		return err
+	}
+	n, err := reader.Read(r.block)
+	r.bytesRead += n
+	if r.bytesRead > r.processor.maxLength {
+		return &InputError{
+			Type:    "length",
+			Message: fmt.Sprintf("input exceeds maximum length of %d characters", r.processor.maxLength),
+		}
+	}
+	r.pending += string(r.block[:n])
+
+	if errors.Is(err, io.EOF) {
+		r.eof = true
+		return nil
+	}
+	if err != nil {
+		return &InputError{
+			Type:    "read",
+			Message: fmt.Sprintf("failed to read input: %v", err),
+		}
+	}
+	return nil
+}
+
+// check validates a chunk like validateText validates a whole text, with
+// control characters counted over the whole stream
+func (r *ChunkReader) check(chunk string) error {
+	if !utf8.ValidString(chunk) {
+		return &InputError{
+			Type:    "encoding",
+			Message: "input contains invalid UTF-8 characters",
+			Input:   chunk,
+		}
+	}
+	if strings.Contains(chunk, "\x00") {
+		return &InputError{
+			Type:    "characters",
+			Message: "input contains null bytes which are not allowed",
+			Input:   chunk,
+		}
+	}
+
+	for _, c := range chunk {
+		if c < 32 && c != '\n' && c != '\r' && c != '\t' {
+			r.controlChars++
+		}
+	}
+	if r.controlChars > 10 {
+		return &InputError{
+			Type:    "characters",
+			Message: fmt.Sprintf("input contains %d control characters which may cause processing issues", r.controlChars),
+			Input:   chunk,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readChunks returns every chunk of r, or the first error
+func readChunks(r *ChunkReader) ([]string, error) {
+	var chunks []string
+	for {
+		chunk, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return chunks, nil
+		}
+		if err != nil {
+			return chunks, err
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestChunkReader_MatchesSplitByLength(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	for i := 0; i < 200; i++ {
+		maxChunk := 20 + rng.IntN(200)
+		text := strings.Join(randomWords(rng, 1+rng.IntN(300), maxChunk/2), " ")
+		if rng.IntN(4) == 0 {
+			text = "  " + text + " \n\n"
+		}
+
+		var reader io.Reader = strings.NewReader(text)
+		if rng.IntN(2) == 0 {
+			reader = iotest.OneByteReader(reader)
+		}
+		chunks, err := readChunks(NewInputProcessorWithLimit(reader, len(text)).NewChunkReader(maxChunk))
+		require.NoError(t, err)
+		require.Equal(t, NewInputProcessor(nil).SplitByLength(text, maxChunk), chunks,
+			"max chunk %d of %q", maxChunk, text)
+	}
+}
+
+func TestChunkReader_BoundedMemory(t *testing.T) {
+	sentence := "A sentence of the very long stream. "
+	count := (4 << 20) / len(sentence)
+	size := count * len(sentence)
+	stream := &repeatReader{chunk: sentence, count: count}
+
+	reader := NewInputProcessorWithLimit(stream, size).NewChunkReader(MaxTextLength)
+	total := 0
+	for {
+		chunk, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(chunk), MaxTextLength)
+		require.LessOrEqual(t, len(reader.pending), MaxTextLength+BufferSize)
+		total += len(chunk)
+	}
+	assert.Equal(t, size, reader.BytesRead())
+	assert.InDelta(t, size, total, float64(size/len(sentence)))
+}
+
+func TestChunkReader_LengthLimit(t *testing.T) {
+	stream := &repeatReader{chunk: "word ", count: 1 << 20}
+	reader := NewInputProcessorWithLimit(stream, 100000).NewChunkReader(1000)
+
+	_, err := readChunks(reader)
+	var inputErr *InputError
+	require.ErrorAs(t, err, &inputErr)
+	assert.Equal(t, "length", inputErr.Type)
+	// The stream is abandoned once over the limit, not read to its end
+	assert.LessOrEqual(t, reader.BytesRead(), 100000+BufferSize)
+
+	// Failures are sticky
+	_, again := reader.Next()
+	assert.Equal(t, err, again)
+}
+
+func TestChunkReader_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxChunk int
+		errType  string
+	}{
+		{"empty", "", 10, "empty"},
+		{"whitespace", " \n\t \n", 10, "empty"},
+		{"invalid UTF-8", "valid words then \xff\xfe", 100, "encoding"},
+		{"null byte", "words\x00more", 100, "characters"},
+		// Control characters count across chunks
+		{"control characters", strings.Repeat("ab\x01 cd\x02 ef\x03 ", 4), 12, "characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewInputProcessor(strings.NewReader(tt.input))
+			require.NoError(t, processor.SetEncoding(EncodingUTF8))
+			_, err := readChunks(processor.NewChunkReader(tt.maxChunk))
+			var inputErr *InputError
+			require.ErrorAs(t, err, &inputErr)
+			assert.Equal(t, tt.errType, inputErr.Type)
+		})
+	}
+
+	_, err := NewInputProcessor(iotest.ErrReader(errors.New("broken pipe"))).NewChunkReader(10).Next()
+	assert.ErrorContains(t, err, "broken pipe")
+
+	_, err = NewInputProcessor(nil).NewChunkReader(10).Next()
+	assert.ErrorContains(t, err, "no input reader configured")
+}
+
+func TestChunkReader_MultibyteSplit(t *testing.T) {
+	text := strings.Repeat("世界", 10)
+	chunks, err := readChunks(NewInputProcessor(strings.NewReader(text)).NewChunkReader(7))
+	require.NoError(t, err)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 7)
+	}
+	assert.Equal(t, text, strings.Join(chunks, ""))
+}
@@ -98,6 +98,8 @@ func TestInputProcessor_ReadText_InvalidUTF8(t *testing.T) {
 	invalidUTF8 := string([]byte{0xFF, 0xFE, 0xFD})
 	reader := strings.NewReader("valid text" + invalidUTF8)
 	processor := NewInputProcessor(reader)
+	// Detection would read it as Windows-1252
+	require.NoError(t, processor.SetEncoding(EncodingUTF8))
 
 	result, err := processor.ReadText()
 	require.Error(t, err)
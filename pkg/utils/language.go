@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// languageStopwords holds common short words of the languages written in
+// Latin script that DetectLanguage tells apart
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "was", "for", "with", "you", "this", "are", "have", "not"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "mit", "sich", "auf", "ich", "auch", "den", "dem"},
+	"fr": {"le", "la", "les", "et", "est", "un", "une", "des", "du", "que", "pas", "pour", "dans", "je", "il", "nous"},
+	"es": {"el", "la", "los", "las", "y", "es", "un", "una", "que", "del", "por", "con", "para", "no", "se", "muy"},
+	"it": {"il", "lo", "la", "gli", "e", "è", "un", "una", "che", "di", "per", "non", "con", "sono", "della", "mi"},
+	"pt": {"o", "os", "as", "e", "é", "um", "uma", "que", "do", "da", "não", "com", "para", "em", "dos", "muito"},
+	"nl": {"de", "het", "een", "en", "is", "van", "niet", "dat", "ik", "zijn", "op", "te", "met", "voor", "ook", "wij"},
+	"sv": {"och", "är", "att", "det", "som", "en", "ett", "inte", "jag", "på", "med", "för", "av", "till", "har", "vi"},
+	"pl": {"i", "w", "nie", "się", "na", "jest", "że", "to", "z", "do", "jak", "ale", "co", "tak", "czy", "jestem"},
+}
+
+// languageStopwordSets indexes languageStopwords by word
+var languageStopwordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(languageStopwords))
+	for language, words := range languageStopwords {
+		sets[language] = make(map[string]bool, len(words))
+		for _, word := range words {
+			sets[language][word] = true
+		}
+	}
+	return sets
+}()
+
+// languageScripts maps the scripts of languages other than those in
+// languageStopwords to the language they are taken as
+var languageScripts = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// paragraphBreak separates paragraphs
+var paragraphBreak = regexp.MustCompile(`\n[ \t\r]*\n\s*`)
+
+// LanguageSegment is a run of text in one language
+type LanguageSegment struct {
+	Text string
+	// Language is an ISO 639-1 code such as "de", or empty when it could
+	// not be detected
+	Language string
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language text is written
+// in, or "" when it cannot tell. Languages with their own script are told
+// by the script (Japanese by its kana, Ukrainian by its letters among
+// Cyrillic ones); English, German, French, Spanish, Italian, Portuguese,
+// Dutch, Swedish, and Polish by their most common words. SSML markup is
+// ignored.
+func DetectLanguage(text string) string {
+	if strings.HasPrefix(strings.TrimSpace(text), "<speak") {
+		text = stripTags(text)
+	}
+
+	scripts := make(map[string]int)
+	latin, letters := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, script := range languageScripts {
+			if unicode.Is(script.table, r) {
+				scripts[script.language]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	if latin*2 < letters {
+		// Japanese mixes kanji with kana
+		if scripts["ja"] > 0 {
+			return "ja"
+		}
+		best := ""
+		for _, script := range languageScripts {
+			if scripts[script.language] > scripts[best] {
+				best = script.language
+			}
+		}
+		if best == "ru" && strings.ContainsAny(strings.ToLower(text), "іїєґ") {
+			return "uk"
+		}
+		return best
+	}
+
+	scores := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for language, words := range languageStopwordSets {
+			if words[word] {
+				scores[language]++
+			}
+		}
+	}
+
+	best, second := "", 0
+	for language := range languageStopwords {
+		if score := scores[language]; best == "" || score > scores[best] {
+			if best != "" {
+				second = scores[best]
+			}
+			best = language
+		} else if score > second {
+			second = score
+		}
+	}
+	// Too few common words, or a tie, is no evidence either way
+	if scores[best] < 2 || scores[best] == second {
+		return ""
+	}
+	return best
+}
+
+// SegmentLanguages splits text at blank lines into paragraphs and returns
+// runs of consecutive paragraphs in the same language, as DetectLanguage
+// finds it. A paragraph in no detectable language joins the run before it,
+// or the one after it at the start of the text. SSML documents are not
+// split and make up one segment.
+func SegmentLanguages(text string) []LanguageSegment {
+	if strings.HasPrefix(strings.TrimSpace(text), "<speak") {
+		return []LanguageSegment{{Text: text, Language: DetectLanguage(text)}}
+	}
+
+	var segments []LanguageSegment
+	for _, paragraph := range paragraphBreak.Split(strings.TrimSpace(text), -1) {
+		language := DetectLanguage(paragraph)
+		last := len(segments) - 1
+		switch {
+		case last < 0:
+			segments = append(segments, LanguageSegment{Text: paragraph, Language: language})
+		case language == "" || language == segments[last].Language:
+			segments[last].Text += "\n\n" + paragraph
+		case segments[last].Language == "":
+			segments[last].Text += "\n\n" + paragraph
+			segments[last].Language = language
+		default:
+			segments = append(segments, LanguageSegment{Text: paragraph, Language: language})
+		}
+	}
+	return segments
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{"The weather is nice and the birds are singing.", "en"},
+		{"Das Wetter ist schön und die Vögel singen auf dem Dach.", "de"},
+		{"Le temps est beau et les oiseaux chantent dans le jardin.", "fr"},
+		{"El tiempo es muy bueno y los pájaros cantan en el jardín.", "es"},
+		{"Il tempo è bello e gli uccelli cantano per tutto il giorno.", "it"},
+		{"O tempo está muito bom e os pássaros cantam no jardim da casa.", "pt"},
+		{"Het weer is mooi en de vogels zingen in de tuin.", "nl"},
+		{"Vädret är fint och fåglarna sjunger i trädgården.", "sv"},
+		{"Pogoda jest ładna i ptaki śpiewają w ogrodzie.", "pl"},
+		{"Погода хорошая, и птицы поют в саду.", "ru"},
+		{"Погода гарна, і птахи співають у саду.", "uk"},
+		{"今日はいい天気ですね。", "ja"},
+		{"今天天气很好。", "zh"},
+		{"오늘 날씨가 좋네요.", "ko"},
+		{"Ο καιρός είναι ωραίος.", "el"},
+		{"<speak><p>Das ist <emphasis>nicht</emphasis> der Fall.</p></speak>", "de"},
+		{"Hello", ""},
+		{"1234 5678", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DetectLanguage(tc.text))
+		})
+	}
+}
+
+func TestSegmentLanguages(t *testing.T) {
+	text := "Chapter 1\n\nThe weather is nice and the birds are singing.\n\nIt is a good day.\n\n" +
+		"Das Wetter ist schön und die Vögel singen.\n  \nThe end of the story."
+
+	assert.Equal(t, []LanguageSegment{
+		{Text: "Chapter 1\n\nThe weather is nice and the birds are singing.\n\nIt is a good day.", Language: "en"},
+		{Text: "Das Wetter ist schön und die Vögel singen.", Language: "de"},
+		{Text: "The end of the story.", Language: "en"},
+	}, SegmentLanguages(text))
+
+	ssml := "<speak>Le temps est beau.\n\nThe weather is nice and the birds sing.</speak>"
+	assert.Equal(t, []LanguageSegment{{Text: ssml, Language: "en"}}, SegmentLanguages(ssml))
+	assert.Equal(t, []LanguageSegment{{Text: "Hello", Language: ""}}, SegmentLanguages("Hello"))
+}
@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NormalizeOptions selects what NormalizeText rewrites and how
+type NormalizeOptions struct {
+	// Locale such as "en-US" decides the order of day and month in dates
+	// and the spelling of words. Numbers are written out in English words;
+	// other languages are always marked up with <say-as>.
+	Locale string
+	// SayAs marks up matches with SSML <say-as> for the voice to read
+	// instead of writing them out as words
+	SayAs bool
+
+	Numbers  bool
+	Dates    bool
+	Currency bool
+	Units    bool
+}
+
+// normalizePattern matches, in order of precedence, ISO dates, numeric
+// dates, times, currency amounts, ordinals, and numbers with an optional unit
+var normalizePattern = regexp.MustCompile(
+	`\b(\d{4})-(\d{1,2})-(\d{1,2})\b` +
+		`|\b(\d{1,2})/(\d{1,2})/(\d{4})\b` +
+		`|\b([01]?\d|2[0-3]):([0-5]\d)\b` +
+		`|([$€£¥])(\d{1,3}(?:,\d{3})+|\d+)(?:\.(\d{1,2}))?\b` +
+		`|\b(\d+)(st|nd|rd|th)\b` +
+		`|\b(\d{1,3}(?:,\d{3})+|\d+)(?:\.(\d+))?(?:\s?(%|(?:°[CF]|km/h|kph|mph|km|cm|mm|m|kg|mg|g|lbs|lb|oz|mi|ft|TB|GB|MB|KB|GHz|MHz|kHz|Hz|ms|kW|W|V|mL)\b))?`)
+
+// Submatch indexes of normalizePattern
+const (
+	groupISOYear = 1 + iota
+	groupISOMonth
+	groupISODay
+	groupDateFirst
+	groupDateSecond
+	groupDateYear
+	groupHour
+	groupMinute
+	groupCurrency
+	groupCurrencyUnits
+	groupCurrencyCents
+	groupOrdinal
+	groupOrdinalSuffix
+	groupNumber
+	groupFraction
+	groupUnit
+)
+
+// protectedElements are SSML elements whose content is already read as
+// the author intends
+var protectedElements = map[string]bool{"say-as": true, "sub": true, "phoneme": true}
+
+var (
+	smallNumbers = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+		"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tensNumbers = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	monthNames  = []string{"January", "February", "March", "April", "May", "June", "July", "August",
+		"September", "October", "November", "December"}
+	irregularOrdinals = map[string]string{"one": "first", "two": "second", "three": "third", "five": "fifth",
+		"eight": "eighth", "nine": "ninth", "twelve": "twelfth"}
+	numberScales = []struct {
+		value int64
+		name  string
+	}{{1e12, "trillion"}, {1e9, "billion"}, {1e6, "million"}, {1e3, "thousand"}}
+)
+
+// currencyNames holds the singular and plural names of a currency and its
+// hundredth part
+var currencyNames = map[string][4]string{
+	"$": {"dollar", "dollars", "cent", "cents"},
+	"€": {"euro", "euros", "cent", "cents"},
+	"£": {"pound", "pounds", "penny", "pence"},
+	"¥": {"yen", "yen", "", ""},
+}
+
+// unitNames holds the singular and plural names of a unit symbol
+var unitNames = map[string][2]string{
+	"%": {"percent", "percent"}, "°C": {"degree Celsius", "degrees Celsius"},
+	"°F": {"degree Fahrenheit", "degrees Fahrenheit"}, "km/h": {"kilometer per hour", "kilometers per hour"},
+	"kph": {"kilometer per hour", "kilometers per hour"}, "mph": {"mile per hour", "miles per hour"},
+	"km": {"kilometer", "kilometers"}, "cm": {"centimeter", "centimeters"}, "mm": {"millimeter", "millimeters"},
+	"m": {"meter", "meters"}, "kg": {"kilogram", "kilograms"}, "mg": {"milligram", "milligrams"},
+	"g": {"gram", "grams"}, "lb": {"pound", "pounds"}, "lbs": {"pound", "pounds"}, "oz": {"ounce", "ounces"},
+	"mi": {"mile", "miles"}, "ft": {"foot", "feet"}, "TB": {"terabyte", "terabytes"},
+	"GB": {"gigabyte", "gigabytes"}, "MB": {"megabyte", "megabytes"}, "KB": {"kilobyte", "kilobytes"},
+	"GHz": {"gigahertz", "gigahertz"}, "MHz": {"megahertz", "megahertz"}, "kHz": {"kilohertz", "kilohertz"},
+	"Hz": {"hertz", "hertz"}, "ms": {"millisecond", "milliseconds"}, "kW": {"kilowatt", "kilowatts"},
+	"W": {"watt", "watts"}, "V": {"volt", "volts"}, "mL": {"milliliter", "milliliters"},
+}
+
+// normalizer rewrites the matches of normalizePattern
+type normalizer struct {
+	opts NormalizeOptions
+	// english is set when matches can be written out as words
+	english bool
+	// dayFirst is set when numeric dates put the day before the month
+	dayFirst bool
+	// british is set for English spellings such as "metre"
+	british bool
+}
+
+// NormalizeText rewrites the numbers, dates, times, currency amounts, and
+// units of text that opts selects into speakable words, or marks them up
+// with <say-as>. In an SSML document only text outside tags is rewritten,
+// leaving <say-as>, <sub>, and <phoneme> content alone; plain text marked
+// up with <say-as> is returned as an SSML document.
+func NormalizeText(text string, opts NormalizeOptions) string {
+	locale := strings.ToLower(strings.ReplaceAll(opts.Locale, "_", "-"))
+	n := &normalizer{
+		opts:     opts,
+		english:  locale == "" || locale == "en" || strings.HasPrefix(locale, "en-"),
+		dayFirst: locale != "" && locale != "en" && locale != "en-us",
+		british:  strings.HasPrefix(locale, "en-") && locale != "en-us",
+	}
+	if !n.english {
+		n.opts.SayAs = true
+	}
+
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(text), "<speak"):
+		return rewriteSSMLText(text, n.rewrite)
+	case n.opts.SayAs:
+		return rewritePlainAsSSML(text, n.rewrite)
+	}
+	return n.rewrite(text)
+}
+
+// rewrite rewrites the matches in a run of text
+func (n *normalizer) rewrite(text string) string {
+	matches := normalizePattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return text[m[2*i]:m[2*i+1]]
+		}
+		match := text[m[0]:m[1]]
+
+		replacement := match
+		switch {
+		case group(groupISOYear) != "":
+			if n.opts.Dates {
+				replacement = n.date(match, group(groupISOYear), group(groupISOMonth), group(groupISODay), "yyyymmdd")
+			}
+		case group(groupDateYear) != "":
+			if n.opts.Dates {
+				month, day, format := group(groupDateFirst), group(groupDateSecond), "mmddyyyy"
+				if n.dayFirst {
+					month, day, format = day, month, "ddmmyyyy"
+				}
+				replacement = n.date(match, group(groupDateYear), month, day, format)
+			}
+		case group(groupHour) != "":
+			if n.opts.Dates {
+				replacement = n.time(match, group(groupHour), group(groupMinute))
+			}
+		case group(groupCurrency) != "":
+			if n.opts.Currency {
+				replacement = n.currency(match, group(groupCurrency), group(groupCurrencyUnits),
+					group(groupCurrencyCents))
+			}
+		case group(groupOrdinal) != "":
+			if n.opts.Numbers {
+				replacement = n.ordinal(match, group(groupOrdinal))
+			}
+		case group(groupUnit) != "":
+			if n.opts.Units {
+				replacement = n.unit(match, group(groupNumber), group(groupFraction), group(groupUnit))
+			} else if n.opts.Numbers {
+				// Only the number is read; the unit symbol is kept
+				end := m[2*groupNumber+1]
+				if m[2*groupFraction] >= 0 {
+					end = m[2*groupFraction+1]
+				}
+				replacement = n.number(text[m[0]:end], group(groupNumber), group(groupFraction)) + text[end:m[1]]
+			}
+		default:
+			if n.opts.Numbers {
+				replacement = n.number(match, group(groupNumber), group(groupFraction))
+			}
+		}
+
+		b.WriteString(text[last:m[0]])
+		b.WriteString(replacement)
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// date reads a date, leaving match alone when it is not a valid date
+func (n *normalizer) date(match, year, month, day, format string) string {
+	y, _ := strconv.Atoi(year)
+	mo, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	if mo < 1 || mo > 12 || d < 1 || d > 31 {
+		return match
+	}
+	if n.opts.SayAs {
+		return sayAs(match, `interpret-as="date" format="`+format+`"`)
+	}
+	if n.dayFirst {
+		return fmt.Sprintf("the %s of %s, %s", ordinalWords(int64(d)), monthNames[mo-1], yearWords(y))
+	}
+	return fmt.Sprintf("%s %s, %s", monthNames[mo-1], ordinalWords(int64(d)), yearWords(y))
+}
+
+// time reads a time of day on a 24 hour clock
+func (n *normalizer) time(match, hour, minute string) string {
+	if n.opts.SayAs {
+		return sayAs(match, `interpret-as="time" format="hms24"`)
+	}
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+	switch {
+	case m == 0 && h > 12:
+		return cardinalWords(int64(h)) + " hundred"
+	case m == 0:
+		return cardinalWords(int64(h)) + " o'clock"
+	case m < 10:
+		return cardinalWords(int64(h)) + " oh " + smallNumbers[m]
+	default:
+		return cardinalWords(int64(h)) + " " + cardinalWords(int64(m))
+	}
+}
+
+// currency reads an amount of money
+func (n *normalizer) currency(match, symbol, units, cents string) string {
+	if n.opts.SayAs {
+		attrs := `interpret-as="currency"`
+		if n.opts.Locale != "" {
+			attrs += ` language="` + n.opts.Locale + `"`
+		}
+		return sayAs(match, attrs)
+	}
+
+	names := currencyNames[symbol]
+	words := numberWords(units, "") + " " + plural(names[0], names[1], units, "")
+	if cents == "" || names[2] == "" {
+		return words
+	}
+	if len(cents) == 1 {
+		cents += "0"
+	}
+	if c, _ := strconv.Atoi(cents); c > 0 {
+		words += " and " + cardinalWords(int64(c)) + " " + plural(names[2], names[3], strconv.Itoa(c), "")
+	}
+	return words
+}
+
+// ordinal reads an ordinal such as "21st"
+func (n *normalizer) ordinal(match, digits string) string {
+	if n.opts.SayAs {
+		return sayAs(digits, `interpret-as="ordinal"`)
+	}
+	value, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || len(digits) > 15 {
+		return match
+	}
+	return ordinalWords(value)
+}
+
+// unit reads a number with a unit symbol
+func (n *normalizer) unit(match, number, fraction, symbol string) string {
+	if n.opts.SayAs {
+		return sayAs(match, `interpret-as="unit"`)
+	}
+	name := plural(unitNames[symbol][0], unitNames[symbol][1], number, fraction)
+	if n.british {
+		name = strings.NewReplacer("meter", "metre", "liter", "litre").Replace(name)
+	}
+	return numberWords(number, fraction) + " " + name
+}
+
+// number reads a number
+func (n *normalizer) number(match, number, fraction string) string {
+	if n.opts.SayAs {
+		return sayAs(match, `interpret-as="cardinal"`)
+	}
+	return numberWords(number, fraction)
+}
+
+// sayAs marks up text with a <say-as> element of the given attributes
+func sayAs(text, attrs string) string {
+	return "<say-as " + attrs + ">" + text + "</say-as>"
+}
+
+// plural returns singular when the number is exactly one
+func plural(singular, pluralName, number, fraction string) string {
+	if number == "1" && strings.Trim(fraction, "0") == "" {
+		return singular
+	}
+	return pluralName
+}
+
+// numberWords reads a number with optional grouping commas and decimals.
+// Numbers with leading zeros or too large to name are read digit by digit.
+func numberWords(number, fraction string) string {
+	number = strings.ReplaceAll(number, ",", "")
+
+	var words string
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || len(number) > 15 || (len(number) > 1 && number[0] == '0') {
+		words = digitWords(number)
+	} else {
+		words = cardinalWords(value)
+	}
+	if fraction != "" {
+		words += " point " + digitWords(fraction)
+	}
+	return words
+}
+
+// digitWords reads each digit of a number
+func digitWords(digits string) string {
+	words := make([]string, 0, len(digits))
+	for _, d := range digits {
+		words = append(words, smallNumbers[d-'0'])
+	}
+	return strings.Join(words, " ")
+}
+
+// cardinalWords writes out a non-negative number in English words
+func cardinalWords(n int64) string {
+	switch {
+	case n < 20:
+		return smallNumbers[n]
+	case n < 100:
+		if n%10 == 0 {
+			return tensNumbers[n/10]
+		}
+		return tensNumbers[n/10] + "-" + smallNumbers[n%10]
+	case n < 1000:
+		words := smallNumbers[n/100] + " hundred"
+		if n%100 != 0 {
+			words += " " + cardinalWords(n%100)
+		}
+		return words
+	}
+
+	for _, scale := range numberScales {
+		if n >= scale.value {
+			words := cardinalWords(n/scale.value) + " " + scale.name
+			if n%scale.value != 0 {
+				words += " " + cardinalWords(n%scale.value)
+			}
+			return words
+		}
+	}
+	return smallNumbers[0]
+}
+
+// ordinalWords writes out the ordinal of a non-negative number, such as
+// "twenty-first"
+func ordinalWords(n int64) string {
+	words := cardinalWords(n)
+	i := strings.LastIndexAny(words, " -") + 1
+	head, last := words[:i], words[i:]
+	switch {
+	case irregularOrdinals[last] != "":
+		return head + irregularOrdinals[last]
+	case strings.HasSuffix(last, "y"):
+		return head + strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		return head + last + "th"
+	}
+}
+
+// yearWords reads a year the way years are spoken, such as "nineteen oh
+// five" or "twenty twenty-four"
+func yearWords(year int) string {
+	switch {
+	case year < 1000 || year >= 10000 || (year >= 2000 && year < 2010):
+		return cardinalWords(int64(year))
+	case year%100 == 0:
+		return cardinalWords(int64(year/100)) + " hundred"
+	case year%100 < 10:
+		return cardinalWords(int64(year/100)) + " oh " + smallNumbers[year%100]
+	default:
+		return cardinalWords(int64(year/100)) + " " + cardinalWords(int64(year%100))
+	}
+}
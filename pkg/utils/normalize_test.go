@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeText(t *testing.T) {
+	all := NormalizeOptions{Locale: "en-US", Numbers: true, Dates: true, Currency: true, Units: true}
+	british := all
+	british.Locale = "en-GB"
+
+	testCases := []struct {
+		name     string
+		input    string
+		opts     NormalizeOptions
+		expected string
+	}{
+		{"numbers", "There are 1,234 items and 3.14 pies.", all,
+			"There are one thousand two hundred thirty-four items and three point one four pies."},
+		{"large and zero-padded numbers", "Call 007 about 2000000.", all,
+			"Call zero zero seven about two million."},
+		{"ordinals", "The 1st, 2nd, 23rd and 112th.", all,
+			"The first, second, twenty-third and one hundred twelfth."},
+		{"iso date", "Due 2024-03-05.", all, "Due March fifth, twenty twenty-four."},
+		{"us date", "On 07/04/1905 we met.", all, "On July fourth, nineteen oh five we met."},
+		{"british date", "On 07/04/2005 we met.", british, "On the seventh of April, two thousand five we met."},
+		{"invalid date", "Ratio 13/45/2020.", all, "Ratio 13/45/2020."},
+		{"times", "At 9:05, 10:00 and 14:30.", all, "At nine oh five, ten o'clock and fourteen thirty."},
+		{"currency", "It costs $1,250.50 or £1 or €0.05.", all,
+			"It costs one thousand two hundred fifty dollars and fifty cents or one pound or zero euros and five cents."},
+		{"units", "Drive 5 km at 60km/h, 1 ft, 20% done, 21.5 °C.", all,
+			"Drive five kilometers at sixty kilometers per hour, one foot, twenty percent done, twenty-one point five degrees Celsius."},
+		{"british units", "Walk 1 m.", british, "Walk one metre."},
+		{"numbers only", "Drive 5 km on 2024-01-01 for $3.", NormalizeOptions{Numbers: true},
+			"Drive five km on 2024-01-01 for $3."},
+		{"words with digits", "Play the mp3 on A4 paper.", all, "Play the mp3 on A4 paper."},
+		{"say-as", "Pay $5 by 2024-03-05 & add 2 kg.", NormalizeOptions{Locale: "en-US", SayAs: true, Dates: true,
+			Currency: true, Units: true},
+			`<speak>Pay <say-as interpret-as="currency" language="en-US">$5</say-as> by ` +
+				`<say-as interpret-as="date" format="yyyymmdd">2024-03-05</say-as> &amp; add ` +
+				`<say-as interpret-as="unit">2 kg</say-as>.</speak>`},
+		{"other languages use say-as", "Es kostet 5 Euro.", NormalizeOptions{Locale: "de-DE", Numbers: true},
+			`<speak>Es kostet <say-as interpret-as="cardinal">5</say-as> Euro.</speak>`},
+		{"ssml text only", `<speak><break time="500ms"/>Take 2 <say-as interpret-as="digits">123</say-as></speak>`, all,
+			`<speak><break time="500ms"/>Take two <say-as interpret-as="digits">123</say-as></speak>`},
+		{"nothing to normalize", "Hello & goodbye", NormalizeOptions{SayAs: true, Numbers: true},
+			"Hello & goodbye"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NormalizeText(tc.input, tc.opts))
+		})
+	}
+}
+
+func TestOrdinalAndYearWords(t *testing.T) {
+	assert.Equal(t, "twentieth", ordinalWords(20))
+	assert.Equal(t, "one hundredth", ordinalWords(100))
+	assert.Equal(t, "nineteen hundred", yearWords(1900))
+	assert.Equal(t, "two thousand", yearWords(2000))
+	assert.Equal(t, "nineteen ninety-nine", yearWords(1999))
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PauseFilter inserts <break> elements between the sentences and paragraphs
+// of the text, for pacing without hand-written SSML
+type PauseFilter struct {
+	paragraph time.Duration
+	sentence  time.Duration
+}
+
+// NewPauseFilter returns a filter pausing for paragraph between paragraphs
+// and for sentence between the sentences of a paragraph. A zero duration
+// adds no pause there.
+func NewPauseFilter(paragraph, sentence time.Duration) (*PauseFilter, error) {
+	for _, d := range []time.Duration{paragraph, sentence} {
+		if d < 0 || d > DefaultMaxBreakTime {
+			return nil, fmt.Errorf("pause %s out of range (0 to %s)", d, DefaultMaxBreakTime)
+		}
+	}
+	return &PauseFilter{paragraph: paragraph, sentence: sentence}, nil
+}
+
+// Filter adds the pauses. Plain text becomes an SSML document, split at
+// blank lines into paragraphs; in SSML, the pauses also go after each <p>
+// and <s> element followed by more content.
+func (f *PauseFilter) Filter(text string) string {
+	if f.paragraph <= 0 && f.sentence <= 0 {
+		return text
+	}
+	if strings.HasPrefix(strings.TrimSpace(text), "<speak") {
+		return f.pauseElements(rewriteSSMLText(text, f.rewrite))
+	}
+	return rewritePlainAsSSML(text, f.rewrite)
+}
+
+// rewrite adds pauses at the sentence boundaries of a run of text, before
+// the whitespace between the sentences
+func (f *PauseFilter) rewrite(text string) string {
+	var b strings.Builder
+	start := 0
+	for _, boundary := range sentenceBoundaries(text) {
+		end := len(strings.TrimRightFunc(text[:boundary], unicode.IsSpace))
+		b.WriteString(text[start:end])
+		pause := f.sentence
+		if strings.Count(text[end:boundary], "\n") >= 2 {
+			pause = f.paragraph
+		}
+		b.WriteString(breakElement(pause))
+		start = end
+	}
+	b.WriteString(text[start:])
+	return b.String()
+}
+
+// pauseElements adds pauses after the </p> and </s> end tags of an SSML
+// document that more content follows in the same element
+func (f *PauseFilter) pauseElements(ssml string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(ssml, "</")
+		if i < 0 {
+			break
+		}
+		end := strings.IndexByte(ssml[i:], '>')
+		if end < 0 {
+			break
+		}
+		end += i + 1
+		b.WriteString(ssml[:end])
+		tag, rest := ssml[i:end], ssml[end:]
+		ssml = rest
+
+		next := strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if next == "" || strings.HasPrefix(next, "</") || strings.HasPrefix(next, "<break") {
+			continue
+		}
+		switch tag {
+		case "</p>", "</paragraph>":
+			b.WriteString(breakElement(f.paragraph))
+		case "</s>", "</sentence>":
+			b.WriteString(breakElement(f.sentence))
+		}
+	}
+	b.WriteString(ssml)
+	return b.String()
+}
+
+// breakElement returns a <break> of d, or "" for no pause
+func breakElement(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(`<break time="%dms"/>`, d.Milliseconds())
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseFilter(t *testing.T) {
+	const paragraph, sentence = 600 * time.Millisecond, 200 * time.Millisecond
+	testCases := []struct {
+		name                string
+		paragraph, sentence time.Duration
+		input               string
+		expected            string
+	}{
+		{"no pauses", 0, 0, "One. Two.", "One. Two."},
+		{"sentences", paragraph, sentence, "One. Two? Dr. Who & co.",
+			`<speak>One.<break time="200ms"/> Two?<break time="200ms"/> Dr. Who &amp; co.</speak>`},
+		{"paragraphs", paragraph, sentence, "One. Two.\n\nThree.",
+			`<speak>One.<break time="200ms"/> Two.<break time="600ms"/>` + "\n\nThree.</speak>"},
+		{"paragraphs only", paragraph, 0, "One. Two.\n\nThree.",
+			"<speak>One. Two.<break time=\"600ms\"/>\n\nThree.</speak>"},
+		{"single sentence", paragraph, sentence, "Hello there", "Hello there"},
+		{"SSML elements", paragraph, sentence,
+			`<speak><p><s>One.</s><s>Two.</s></p> <p>Three. Four.</p><p>Five.</p><break time="1s"/><p>Six.</p></speak>`,
+			`<speak><p><s>One.</s><break time="200ms"/><s>Two.</s></p><break time="600ms"/> ` +
+				`<p>Three.<break time="200ms"/> Four.</p><break time="600ms"/><p>Five.</p><break time="1s"/><p>Six.</p></speak>`},
+		{"SSML protected text", paragraph, sentence, `<speak><sub alias="Doctor. Who">Dr. Who</sub> Hi. Bye.</speak>`,
+			`<speak><sub alias="Doctor. Who">Dr. Who</sub> Hi.<break time="200ms"/> Bye.</speak>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := NewPauseFilter(tc.paragraph, tc.sentence)
+			require.NoError(t, err)
+			got := filter.Filter(tc.input)
+			assert.Equal(t, tc.expected, got)
+			if got != tc.input {
+				assert.NoError(t, NewSSMLValidator().ValidateSSML(got))
+			}
+		})
+	}
+}
+
+func TestNewPauseFilter_Range(t *testing.T) {
+	_, err := NewPauseFilter(-time.Second, 0)
+	assert.Error(t, err)
+	_, err = NewPauseFilter(0, 11*time.Second)
+	assert.Error(t, err)
+}
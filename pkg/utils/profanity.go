@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Profanity filter modes
+const (
+	ProfanityOff    = "off"
+	ProfanityBleep  = "bleep"
+	ProfanityRemove = "remove"
+)
+
+// profanityWords is the built-in list of words the profanity filter
+// detects, each also matched with the endings NewProfanityFilter allows
+var profanityWords = []string{
+	"arse", "arsehole", "asshole", "bastard", "bitch", "bollocks", "bullshit", "crap", "cunt", "damn",
+	"dickhead", "douche", "douchebag", "fuck", "fuckin", "goddamn", "horseshit", "jackass", "motherfucker",
+	"piss", "prick", "shit", "shitty", "slut", "twat", "wanker", "whore",
+}
+
+// ProfanityFilter finds profanity in text and removes it or marks it up
+// for the voice to bleep
+type ProfanityFilter struct {
+	mode    string
+	pattern *regexp.Regexp
+}
+
+// NewProfanityFilter returns a filter of the given mode for the built-in
+// words and any extra words. Words match whole, ignoring case, and with the
+// endings "s", "es", "ed", "ing", "er", and "ers".
+func NewProfanityFilter(mode string, extra []string) (*ProfanityFilter, error) {
+	switch mode {
+	case ProfanityOff, ProfanityBleep, ProfanityRemove:
+	default:
+		return nil, fmt.Errorf("unknown profanity filter mode %q (want off, bleep, or remove)", mode)
+	}
+
+	words := append([]string(nil), profanityWords...)
+	for _, word := range extra {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	// Longer words first, so "bullshit" is not matched as "bull" and "shit"
+	sort.Slice(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+
+	return &ProfanityFilter{
+		mode:    mode,
+		pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)(?:s|es|ed|ing|er|ers)?\b`),
+	}, nil
+}
+
+// Filter removes the profanity in text, or wraps it in
+// <say-as interpret-as="expletive"> to be bleeped. Like NormalizeText, it
+// rewrites only text outside the tags of an SSML document and returns
+// bleeped plain text as an SSML document.
+func (f *ProfanityFilter) Filter(text string) string {
+	rewrite := f.remove
+	if f.mode == ProfanityBleep {
+		rewrite = f.bleep
+	}
+
+	switch {
+	case f.mode == ProfanityOff:
+		return text
+	case strings.HasPrefix(strings.TrimSpace(text), "<speak"):
+		return rewriteSSMLText(text, rewrite)
+	case f.mode == ProfanityBleep:
+		return rewritePlainAsSSML(text, rewrite)
+	}
+	return rewrite(text)
+}
+
+// bleep marks up the profanity in a run of text
+func (f *ProfanityFilter) bleep(text string) string {
+	return f.pattern.ReplaceAllStringFunc(text, func(word string) string {
+		return sayAs(word, `interpret-as="expletive"`)
+	})
+}
+
+// remove deletes the profanity in a run of text together with a space
+// beside it, so no double spaces or spaces before punctuation are left
+func (f *ProfanityFilter) remove(text string) string {
+	matches := f.pattern.FindAllStringIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		before, _ := utf8.DecodeLastRuneInString(text[last:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		switch {
+		case before == ' ' && (after == utf8.RuneError || after == ' ' || unicode.IsPunct(after)):
+			start--
+		case after == ' ' && (before == utf8.RuneError || unicode.IsSpace(before)):
+			end++
+		}
+		b.WriteString(text[last:start])
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfanityFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     string
+		input    string
+		expected string
+	}{
+		{"off", ProfanityOff, "Well, shit.", "Well, shit."},
+		{"remove before punctuation", ProfanityRemove, "What the fuck?", "What the?"},
+		{"remove between words", ProfanityRemove, "That damn dog & that Fucking cat", "That dog & that cat"},
+		{"remove at start", ProfanityRemove, "Shit happens.", "happens."},
+		{"whole words only", ProfanityRemove, "Shiitake scrapped the class.", "Shiitake scrapped the class."},
+		{"bleep plain text", ProfanityBleep, "Tom & a bastard",
+			`<speak>Tom &amp; a <say-as interpret-as="expletive">bastard</say-as></speak>`},
+		{"bleep nothing", ProfanityBleep, "Nice & clean", "Nice & clean"},
+		{"bleep SSML", ProfanityBleep, `<speak><p>Bullshit!</p><sub alias="crap">it</sub></speak>`,
+			`<speak><p><say-as interpret-as="expletive">Bullshit</say-as>!</p><sub alias="crap">it</sub></speak>`},
+		{"remove SSML", ProfanityRemove, `<speak>Oh <emphasis>crap</emphasis> no</speak>`,
+			`<speak>Oh <emphasis></emphasis> no</speak>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := NewProfanityFilter(tc.mode, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, filter.Filter(tc.input))
+		})
+	}
+}
+
+func TestProfanityFilter_ExtraWords(t *testing.T) {
+	filter, err := NewProfanityFilter(ProfanityRemove, []string{"frak", " "})
+	require.NoError(t, err)
+	assert.Equal(t, "What a day.", filter.Filter("What a FRAKing day."))
+
+	_, err = NewProfanityFilter("mute", nil)
+	assert.Error(t, err)
+}
+
+func TestApplyFilters(t *testing.T) {
+	exclaim := TextFilterFunc(func(text string) string { return text + "!" })
+	filter, err := NewProfanityFilter(ProfanityRemove, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello", ApplyFilters("Hello"))
+	assert.Equal(t, "Hello world!", ApplyFilters("Hello damn world", filter, exclaim))
+}
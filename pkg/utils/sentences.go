@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// titleAbbreviations precede a name and never end a sentence
+var titleAbbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "st": true,
+	"sr": true, "rev": true, "gen": true, "capt": true, "lt": true, "col": true,
+	"sgt": true, "gov": true, "sen": true, "rep": true, "hon": true, "mt": true,
+	"e.g": true, "i.e": true, "cf": true, "vs": true, "approx": true,
+	"fig": true, "no": true, "vol": true, "ch": true, "p": true, "pp": true,
+}
+
+// trailingAbbreviations may end a sentence, which they do when the next
+// word is capitalized
+var trailingAbbreviations = map[string]bool{
+	"etc": true, "inc": true, "ltd": true, "co": true, "corp": true, "jr": true,
+	"a.m": true, "p.m": true, "dept": true, "est": true, "min": true, "max": true,
+}
+
+// SegmentSentences splits text into sentences, each trimmed of surrounding
+// whitespace. A sentence ends at ".", "!", "?", or an ellipsis followed by
+// whitespace, including any closing quotes or brackets, and at a blank line.
+// Periods of abbreviations such as "Dr." and "e.g.", of initials, and of
+// numbers such as "3.14" do not end a sentence, nor does punctuation
+// followed by a lowercase word.
+func SegmentSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, end := range sentenceBoundaries(text) {
+		if sentence := strings.TrimSpace(text[start:end]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+	if sentence := strings.TrimSpace(text[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// sentenceBoundaries returns the byte offsets in text where a sentence other
+// than the first begins, after the whitespace ending the one before
+func sentenceBoundaries(text string) []int {
+	var boundaries []int
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		// A run of whitespace follows the word before i
+		end := i + size
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+		if end < len(text) && (strings.Count(text[i:end], "\n") >= 2 ||
+			isSentenceEnd(lastWord(text[:i]), nextWord(text[end:]))) {
+			boundaries = append(boundaries, end)
+		}
+		i = end
+	}
+	return boundaries
+}
+
+// isSentenceEnd reports whether a sentence ends with word when next is the
+// word that follows
+func isSentenceEnd(word, next string) bool {
+	word = strings.TrimRight(word, `"')]}»”’`)
+	if word == "" {
+		return false
+	}
+	next = strings.TrimLeft(next, `"'([{«“‘`)
+
+	switch {
+	case strings.HasSuffix(word, "…") || strings.HasSuffix(word, "..."):
+		return startsUpper(next)
+	case strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?"):
+		return !startsLower(next)
+	case !strings.HasSuffix(word, "."):
+		return false
+	}
+
+	bare := strings.TrimLeft(strings.TrimSuffix(word, "."), `"'([{«“‘`)
+	stem := strings.ToLower(bare)
+	switch {
+	case titleAbbreviations[stem]:
+		return false
+	case trailingAbbreviations[stem]:
+		return startsUpper(next)
+	case utf8.RuneCountInString(bare) == 1 && startsUpper(bare):
+		// An initial, as in "J. R. R. Tolkien", or a list number
+		return false
+	case strings.Contains(stem, "."):
+		// Dotted abbreviations such as "U.S." end a sentence only before a
+		// capitalized word
+		return startsUpper(next)
+	}
+	return !startsLower(next)
+}
+
+// lastWord returns the last whitespace-separated word of text
+func lastWord(text string) string {
+	return text[strings.LastIndexFunc(text, unicode.IsSpace)+1:]
+}
+
+// nextWord returns the first whitespace-separated word of text
+func nextWord(text string) string {
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
+// startsUpper reports whether s begins with an uppercase letter or a digit
+func startsUpper(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r) || unicode.IsDigit(r)
+}
+
+// startsLower reports whether s begins with a lowercase letter
+func startsLower(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLower(r)
+}
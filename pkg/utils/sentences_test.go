@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentSentences(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			"simple sentences",
+			"Hello there. How are you? Fine!",
+			[]string{"Hello there.", "How are you?", "Fine!"},
+		},
+		{
+			"title abbreviations",
+			"Dr. Smith met Mrs. Jones. They talked.",
+			[]string{"Dr. Smith met Mrs. Jones.", "They talked."},
+		},
+		{
+			"latin abbreviations",
+			"Bring fruit, e.g. apples and pears. Or i.e. anything.",
+			[]string{"Bring fruit, e.g. apples and pears.", "Or i.e. anything."},
+		},
+		{
+			"trailing abbreviations",
+			"We sold apples, pears, etc. The rest went to Acme Inc. and others.",
+			[]string{"We sold apples, pears, etc.", "The rest went to Acme Inc. and others."},
+		},
+		{
+			"decimal numbers and initials",
+			"Pi is about 3.14 today. J. R. R. Tolkien wrote it.",
+			[]string{"Pi is about 3.14 today.", "J. R. R. Tolkien wrote it."},
+		},
+		{
+			"dotted abbreviations",
+			"She moved to the U.S. in May. Then she moved to the U.K. Nobody knew.",
+			[]string{"She moved to the U.S. in May.", "Then she moved to the U.K.", "Nobody knew."},
+		},
+		{
+			"quotes",
+			`He said "Stop." Then he left. "Really?" she asked.`,
+			[]string{`He said "Stop."`, "Then he left.", `"Really?" she asked.`},
+		},
+		{
+			"ellipsis",
+			"Wait... what? Well… Fine.",
+			[]string{"Wait... what?", "Well…", "Fine."},
+		},
+		{
+			"blank lines",
+			"Chapter One\n\nIt began. \n",
+			[]string{"Chapter One", "It began."},
+		},
+		{
+			"empty",
+			"  ",
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SegmentSentences(tc.input))
+		})
+	}
+}
+
+func TestInputProcessor_SplitByLength_Abbreviations(t *testing.T) {
+	processor := NewInputProcessor(nil)
+
+	chunks := processor.SplitByLength("Ask Dr. Smith for help. He knows.", 30)
+	assert.Equal(t, []string{"Ask Dr. Smith for help. ", "He knows."}, chunks)
+}
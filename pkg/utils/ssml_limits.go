@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Default resource limits applied to SSML documents to guard against
+// denial-of-service input such as deeply nested or oversized markup
+const (
+	DefaultMaxNestingDepth = 32
+	DefaultMaxDocumentSize = 1 << 20 // 1 MiB
+)
+
+// SetMaxNestingDepth sets the deepest element nesting accepted in SSML.
+// Values that are not positive reset the limit to DefaultMaxNestingDepth.
+func (v *SSMLValidator) SetMaxNestingDepth(depth int) {
+	if depth <= 0 {
+		depth = DefaultMaxNestingDepth
+	}
+	v.maxNestingDepth = depth
+}
+
+// MaxNestingDepth returns the deepest element nesting accepted in SSML
+func (v *SSMLValidator) MaxNestingDepth() int {
+	return v.maxNestingDepth
+}
+
+// SetMaxDocumentSize sets the largest SSML document accepted, in bytes.
+// Values that are not positive reset the limit to DefaultMaxDocumentSize.
+func (v *SSMLValidator) SetMaxDocumentSize(size int) {
+	if size <= 0 {
+		size = DefaultMaxDocumentSize
+	}
+	v.maxDocumentSize = size
+}
+
+// MaxDocumentSize returns the largest SSML document accepted, in bytes
+func (v *SSMLValidator) MaxDocumentSize() int {
+	return v.maxDocumentSize
+}
+
+// checkLimits enforces the document size and nesting depth limits. Depth is
+// measured with an XML tokenizer so that only real element nesting counts,
+// and errors report the byte offset of the offending tag.
+func (v *SSMLValidator) checkLimits(text string) error {
+	if len(text) > v.maxDocumentSize {
+		return &ValidationError{
+			Type:    "limit",
+			Message: fmt.Sprintf("document size %d bytes exceeds maximum of %d bytes", len(text), v.maxDocumentSize),
+			Pos:     v.maxDocumentSize,
+		}
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	decoder.Strict = false
+
+	depth := 0
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err != nil {
+			// io.EOF ends the document. Malformed markup is reported by the
+			// structure check, which also enforces the depth limit on input
+			// the tokenizer cannot read.
+			return nil
+		}
+
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth > v.maxNestingDepth {
+				return v.nestingDepthError(offset, text)
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// nestingDepthError builds the error reported when markup nests too deeply
+func (v *SSMLValidator) nestingDepthError(offset int, text string) *ValidationError {
+	end := strings.IndexByte(text[offset:], '>')
+	input := text[offset:]
+	if end >= 0 {
+		input = text[offset : offset+end+1]
+	}
+
+	return &ValidationError{
+		Type:    "limit",
+		Message: fmt.Sprintf("element nesting exceeds maximum depth of %d", v.maxNestingDepth),
+		Input:   input,
+		Pos:     offset,
+	}
+}
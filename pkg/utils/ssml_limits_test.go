@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nestedSSML(depth int) string {
+	return strings.Repeat("<p>", depth) + "Hello" + strings.Repeat("</p>", depth)
+}
+
+func TestSSMLValidator_NestingDepth(t *testing.T) {
+	validator := NewSSMLValidator()
+	validator.SetMaxNestingDepth(5)
+
+	t.Run("within limit", func(t *testing.T) {
+		// speak plus four paragraphs reaches the limit exactly
+		assert.NoError(t, validator.ValidateSSML("<speak>"+nestedSSML(4)+"</speak>"))
+	})
+
+	t.Run("exceeds limit", func(t *testing.T) {
+		text := "<speak>" + nestedSSML(5) + "</speak>"
+		err := validator.ValidateSSML(text)
+		require.Error(t, err)
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "limit", validationErr.Type)
+		assert.Contains(t, validationErr.Message, "maximum depth of 5")
+		// The sixth opening tag starts after <speak> and four <p> tags
+		assert.Equal(t, len("<speak>")+4*len("<p>"), validationErr.Pos)
+		assert.Equal(t, "<p>", validationErr.Input)
+	})
+
+	t.Run("many sibling tags are not nesting", func(t *testing.T) {
+		text := "<speak>" + strings.Repeat("<s>Hi</s><break time='100ms'/>", 100) + "</speak>"
+		assert.NoError(t, validator.ValidateSSML(text))
+	})
+
+	t.Run("malformed markup still checked", func(t *testing.T) {
+		// The stray "<" stops the XML tokenizer; the structure check enforces the limit instead
+		text := "<speak>a < b " + nestedSSML(6) + "</speak>"
+		err := validator.ValidateSSML(text)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "maximum depth of 5")
+	})
+}
+
+func TestSSMLValidator_DocumentSize(t *testing.T) {
+	validator := NewSSMLValidator()
+	validator.SetMaxDocumentSize(64)
+
+	assert.NoError(t, validator.ValidateSSML("<speak>Hello</speak>"))
+
+	text := "<speak>" + strings.Repeat("a", 100) + "</speak>"
+	err := validator.ValidateSSML(text)
+	require.Error(t, err)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "limit", validationErr.Type)
+	assert.Equal(t, 64, validationErr.Pos)
+	assert.Contains(t, validationErr.Message, "exceeds maximum of 64 bytes")
+}
+
+func TestSSMLValidator_LimitSetters(t *testing.T) {
+	validator := NewSSMLValidator()
+	assert.Equal(t, DefaultMaxNestingDepth, validator.MaxNestingDepth())
+	assert.Equal(t, DefaultMaxDocumentSize, validator.MaxDocumentSize())
+
+	validator.SetMaxNestingDepth(8)
+	validator.SetMaxDocumentSize(4096)
+	assert.Equal(t, 8, validator.MaxNestingDepth())
+	assert.Equal(t, 4096, validator.MaxDocumentSize())
+
+	validator.SetMaxNestingDepth(0)
+	validator.SetMaxDocumentSize(-1)
+	assert.Equal(t, DefaultMaxNestingDepth, validator.MaxNestingDepth())
+	assert.Equal(t, DefaultMaxDocumentSize, validator.MaxDocumentSize())
+}
+
+func TestSSMLValidator_StructureErrorPosition(t *testing.T) {
+	validator := NewSSMLValidator()
+
+	err := validator.ValidateSSML("<speak><p>Hello</s></speak>")
+	require.Error(t, err)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "structure", validationErr.Type)
+	assert.Equal(t, len("<speak><p>Hello"), validationErr.Pos)
+}
@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Kinds of split points in SSML, in increasing order of preference
+const (
+	ssmlBreakWord = iota
+	ssmlBreakSentence
+	ssmlBreakElement
+)
+
+// ssmlTextEscaper escapes text written back into SSML markup
+var ssmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// ssmlBreak is a point in the body of a chunk where it may be split
+type ssmlBreak struct {
+	pos  int
+	kind int
+	// open holds the start tags open at pos, outermost first
+	open []string
+}
+
+// ssmlSplitter collects SSML markup into chunks of at most maxLength bytes
+type ssmlSplitter struct {
+	maxLength int
+	chunks    []string
+	body      strings.Builder
+	// start is the length of the start tags reopened at the beginning of body
+	start  int
+	open   []string
+	names  []string
+	breaks []ssmlBreak
+}
+
+// SplitSSML splits an SSML document into chunks of at most maxLength bytes,
+// each a complete <speak> document. Chunks end after a </p> or </s> where
+// possible, else after a sentence, as found by SegmentSentences, or a word;
+// <say-as>, <sub>, and <phoneme> are never split. Elements open at a split,
+// such as <prosody> or <emphasis>, are closed at the end of the chunk and reopened at the start of
+// the next, so every chunk keeps its context. A chunk exceeds maxLength only
+// when a single word with its context does not fit. The split depends only
+// on the document and maxLength.
+func (p *InputProcessor) SplitSSML(ssml string, maxLength int) ([]string, error) {
+	if len(ssml) <= maxLength {
+		return []string{ssml}, nil
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(ssml))
+	decoder.Strict = true
+
+	s := &ssmlSplitter{maxLength: maxLength}
+	root, closed := false, false
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case closed:
+				return nil, fmt.Errorf("invalid SSML: content after </speak>")
+			case !root:
+				if t.Name.Local != "speak" {
+					return nil, fmt.Errorf("invalid SSML: document must start with <speak>, not <%s>", t.Name.Local)
+				}
+				root = true
+			default:
+				s.startElement(t)
+			}
+
+		case xml.EndElement:
+			if len(s.names) == 0 {
+				if t.Name.Local != "speak" {
+					return nil, fmt.Errorf("invalid SSML: unexpected closing tag: %s", t.Name.Local)
+				}
+				closed = true
+				continue
+			}
+			if err := s.endElement(t.Name.Local); err != nil {
+				return nil, err
+			}
+
+		case xml.CharData:
+			if root && !closed {
+				s.text(string(t))
+			}
+		}
+	}
+
+	if !root {
+		return nil, fmt.Errorf("invalid SSML: no <speak> element")
+	}
+	if !closed {
+		return nil, fmt.Errorf("invalid SSML: unclosed tag: speak")
+	}
+
+	if rest := s.body.String()[s.start:]; strings.TrimSpace(stripTags(rest)) != "" || strings.Contains(rest, "<break") {
+		s.chunks = append(s.chunks, "<speak>"+s.body.String()+"</speak>")
+	}
+	return s.chunks, nil
+}
+
+// startElement writes a start tag and opens its element
+func (s *ssmlSplitter) startElement(element xml.StartElement) {
+	tag := formatStartTag(element)
+	s.body.WriteString(tag)
+	s.open = append(s.open, tag)
+	s.names = append(s.names, element.Name.Local)
+}
+
+// endElement closes the innermost element, which must be name
+func (s *ssmlSplitter) endElement(name string) error {
+	last := len(s.names) - 1
+	if s.names[last] != name {
+		return fmt.Errorf("invalid SSML: unexpected closing tag: %s", name)
+	}
+	s.body.WriteString("</" + name + ">")
+	s.open = s.open[:last]
+	s.names = s.names[:last]
+
+	if name == "p" || name == "s" {
+		s.mark(ssmlBreakElement)
+	}
+	s.fit()
+	return nil
+}
+
+// text writes text one word at a time, marking a break after each word
+// unless inside an element whose content is read as a whole
+func (s *ssmlSplitter) text(text string) {
+	for text != "" {
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		end = len(text) - len(strings.TrimLeftFunc(text[end:], unicode.IsSpace))
+		s.body.WriteString(ssmlTextEscaper.Replace(text[:end]))
+		text = text[end:]
+
+		if s.splittable() {
+			kind := ssmlBreakWord
+			if isSentenceEnd(word, nextWord(text)) {
+				kind = ssmlBreakSentence
+			}
+			s.mark(kind)
+		}
+		s.fit()
+	}
+}
+
+// splittable reports whether a chunk may end inside the open elements
+func (s *ssmlSplitter) splittable() bool {
+	for _, name := range s.names {
+		switch name {
+		case "say-as", "sub", "phoneme":
+			return false
+		}
+	}
+	return true
+}
+
+// mark records a break of kind at the end of the body
+func (s *ssmlSplitter) mark(kind int) {
+	s.breaks = append(s.breaks, ssmlBreak{
+		pos:  s.body.Len(),
+		kind: kind,
+		open: append([]string(nil), s.open...),
+	})
+}
+
+// chunkLength returns the length of a chunk of the body up to pos with the
+// open elements closed
+func (s *ssmlSplitter) chunkLength(pos int, open []string) int {
+	n := len("<speak>") + pos + len("</speak>")
+	for _, tag := range open {
+		n += len(closingTag(tag))
+	}
+	return n
+}
+
+// fit ends chunks at the best breaks while the body is too long
+func (s *ssmlSplitter) fit() {
+	for s.chunkLength(s.body.Len(), s.open) > s.maxLength {
+		best, first := -1, -1
+		for i, b := range s.breaks {
+			if b.pos <= s.start {
+				continue
+			}
+			if first < 0 {
+				first = i
+			}
+			if s.chunkLength(b.pos, b.open) <= s.maxLength && (best < 0 || b.kind >= s.breaks[best].kind) {
+				best = i
+			}
+		}
+		if best < 0 {
+			// Nothing fits: the first break gives the shortest chunk
+			best = first
+		}
+		if best < 0 {
+			return
+		}
+		s.split(best)
+	}
+}
+
+// split ends a chunk at breaks[i] and starts the next one with the elements
+// open there reopened
+func (s *ssmlSplitter) split(i int) {
+	b := s.breaks[i]
+	body := s.body.String()
+
+	var closing strings.Builder
+	for j := len(b.open) - 1; j >= 0; j-- {
+		closing.WriteString(closingTag(b.open[j]))
+	}
+	s.chunks = append(s.chunks, "<speak>"+body[:b.pos]+closing.String()+"</speak>")
+
+	prefix := strings.Join(b.open, "")
+	s.body.Reset()
+	s.body.WriteString(prefix)
+	s.body.WriteString(body[b.pos:])
+	s.start = len(prefix)
+
+	shift := len(prefix) - b.pos
+	rest := s.breaks[:0]
+	for _, later := range s.breaks[i+1:] {
+		later.pos += shift
+		rest = append(rest, later)
+	}
+	s.breaks = rest
+}
+
+// closingTag returns the end tag matching a start tag
+func closingTag(start string) string {
+	name := strings.TrimPrefix(start, "<")
+	if i := strings.IndexAny(name, " >"); i >= 0 {
+		name = name[:i]
+	}
+	return "</" + name + ">"
+}
+
+// stripTags removes markup, leaving the text of an SSML fragment
+func stripTags(markup string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range markup {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputProcessor_SplitSSML(t *testing.T) {
+	processor := NewInputProcessor(nil)
+
+	testCases := []struct {
+		name      string
+		input     string
+		maxLength int
+		expected  []string
+	}{
+		{
+			"short document",
+			"<speak>Hello World</speak>",
+			50,
+			[]string{"<speak>Hello World</speak>"},
+		},
+		{
+			"split at paragraphs",
+			"<speak><p>First paragraph here.</p><p>Second one.</p></speak>",
+			50,
+			[]string{
+				"<speak><p>First paragraph here.</p></speak>",
+				"<speak><p>Second one.</p></speak>",
+			},
+		},
+		{
+			"prefer sentence elements over words",
+			"<speak><p><s>One two.</s><s>Three four five six.</s></p></speak>",
+			50,
+			[]string{
+				"<speak><p><s>One two.</s></p></speak>",
+				"<speak><p><s>Three four five six.</s></p></speak>",
+			},
+		},
+		{
+			"carry prosody context",
+			`<speak><prosody rate="slow">Alpha beta. Gamma delta.</prosody></speak>`,
+			60,
+			[]string{
+				`<speak><prosody rate="slow">Alpha beta. </prosody></speak>`,
+				`<speak><prosody rate="slow">Gamma delta.</prosody></speak>`,
+			},
+		},
+		{
+			"keep say-as whole and escape text",
+			`<speak>Call <say-as interpret-as="telephone">555 1234</say-as> &amp; wait.</speak>`,
+			70,
+			[]string{
+				"<speak>Call </speak>",
+				`<speak><say-as interpret-as="telephone">555 1234</say-as> </speak>`,
+				"<speak>&amp; wait.</speak>",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := processor.SplitSSML(tc.input, tc.maxLength)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+			for i, chunk := range result {
+				assert.LessOrEqual(t, len(chunk), tc.maxLength, "Chunk %d should not exceed max length", i)
+			}
+		})
+	}
+}
+
+func TestInputProcessor_SplitSSML_LargeDocument(t *testing.T) {
+	processor := NewInputProcessor(nil)
+	validator := NewSSMLValidator()
+
+	var b strings.Builder
+	b.WriteString(`<speak><emphasis level="moderate"><prosody pitch="+2%">`)
+	for i := 0; i < 200; i++ {
+		b.WriteString("<p><s>The quick brown fox jumps over the lazy dog.</s> <s>It does so again.</s></p>")
+	}
+	b.WriteString("</prosody></emphasis></speak>")
+
+	first, err := processor.SplitSSML(b.String(), 1000)
+	require.NoError(t, err)
+	require.Greater(t, len(first), 1)
+	for _, chunk := range first {
+		assert.LessOrEqual(t, len(chunk), 1000)
+		assert.True(t, strings.HasPrefix(chunk, `<speak><emphasis level="moderate"><prosody pitch="+2%"><p>`), chunk)
+		assert.True(t, strings.HasSuffix(chunk, "</p></prosody></emphasis></speak>"), chunk)
+		assert.NoError(t, validator.ValidateSSMLReader(strings.NewReader(chunk)))
+	}
+
+	again, err := processor.SplitSSML(b.String(), 1000)
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "Splitting should be deterministic")
+}
+
+func TestInputProcessor_SplitSSML_Invalid(t *testing.T) {
+	processor := NewInputProcessor(nil)
+
+	for _, doc := range []string{
+		"<speak><p>Unclosed paragraph</speak>",
+		"<p>No speak element</p>",
+		"<speak>Unclosed speak",
+		"<speak>One</speak><speak>Two</speak>",
+	} {
+		t.Run(doc, func(t *testing.T) {
+			_, err := processor.SplitSSML(doc, 10)
+			assert.Error(t, err)
+		})
+	}
+}
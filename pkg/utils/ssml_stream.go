@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// errDocumentTooLarge is returned by sizeLimitReader once the limit is exceeded
+var errDocumentTooLarge = errors.New("document too large")
+
+// sizeLimitReader fails with errDocumentTooLarge once more than remaining bytes are read
+type sizeLimitReader struct {
+	reader    io.Reader
+	remaining int
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, errDocumentTooLarge
+	}
+	// Read one byte past the limit so an exact-size document is not rejected
+	if len(p) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= n
+	if r.remaining < 0 {
+		return n, errDocumentTooLarge
+	}
+	return n, err
+}
+
+// ValidateSSMLReader validates an SSML document read from r without loading it
+// into memory. Memory use is bounded by the nesting depth and the largest
+// single text run, so multi-megabyte documents such as audiobooks can be
+// checked before they are split for synthesis. The document must be
+// well-formed XML; the same tag, attribute, security, and limit rules as
+// ValidateSSML apply.
+func (v *SSMLValidator) ValidateSSMLReader(r io.Reader) error {
+	decoder := xml.NewDecoder(&sizeLimitReader{reader: r, remaining: v.maxDocumentSize})
+	decoder.Strict = true
+
+	var stack []string
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return v.streamTokenError(err, int(decoder.InputOffset()))
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if len(stack) > v.maxNestingDepth {
+				return &ValidationError{
+					Type:    "limit",
+					Message: fmt.Sprintf("element nesting exceeds maximum depth of %d", v.maxNestingDepth),
+					Input:   formatStartTag(t),
+					Pos:     offset,
+				}
+			}
+			if err := v.validateStreamElement(t, offset); err != nil {
+				return err
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1] != t.Name.Local {
+				return &ValidationError{
+					Type:    "structure",
+					Message: fmt.Sprintf("unexpected closing tag: %s", t.Name.Local),
+					Input:   "</" + t.Name.Local + ">",
+					Pos:     offset,
+				}
+			}
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if err := v.checkStreamPatterns(string(t), offset); err != nil {
+				return err
+			}
+
+		case xml.Comment:
+			if err := v.checkStreamPatterns(string(t), offset); err != nil {
+				return err
+			}
+
+		case xml.Directive:
+			// DOCTYPE and ENTITY declarations enable XXE and entity expansion attacks
+			return &ValidationError{
+				Type:    "security",
+				Message: "XML directives are not allowed",
+				Input:   "<!" + string(t) + ">",
+				Pos:     offset,
+			}
+		}
+	}
+
+	if len(stack) > 0 {
+		return &ValidationError{
+			Type:    "structure",
+			Message: fmt.Sprintf("unclosed tag: %s", stack[len(stack)-1]),
+		}
+	}
+
+	return nil
+}
+
+// streamTokenError converts a decoder error into a ValidationError
+func (v *SSMLValidator) streamTokenError(err error, offset int) error {
+	if errors.Is(err, errDocumentTooLarge) {
+		return &ValidationError{
+			Type:    "limit",
+			Message: fmt.Sprintf("document size exceeds maximum of %d bytes", v.maxDocumentSize),
+			Pos:     v.maxDocumentSize,
+		}
+	}
+
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &ValidationError{
+			Type:    "structure",
+			Message: syntaxErr.Msg,
+			Pos:     offset,
+		}
+	}
+
+	return fmt.Errorf("failed to read SSML: %w", err)
+}
+
+// validateStreamElement applies tag and attribute rules to a single start element
+func (v *SSMLValidator) validateStreamElement(element xml.StartElement, offset int) error {
+	name := strings.ToLower(element.Name.Local)
+	tag := formatStartTag(element)
+
+	if err := v.checkStreamPatterns(tag, offset); err != nil {
+		return err
+	}
+
+	if !v.allowedTags[name] {
+		return &ValidationError{
+			Type:    "tag",
+			Message: fmt.Sprintf("tag not allowed: %s", name),
+			Input:   tag,
+			Pos:     offset,
+		}
+	}
+
+	match := []string{tag, formatAttrs(element.Attr)}
+	var err error
+	switch name {
+	case "prosody":
+		err = v.validateSingleProsodyTag(match)
+	case "say-as":
+		err = v.validateSingleSayAsTag(match)
+	case "break":
+		err = v.validateSingleBreakTag(match)
+	case "audio":
+		err = &ValidationError{
+			Type:    "security",
+			Message: "audio tags are not allowed for security reasons",
+			Input:   tag,
+		}
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) && validationErr.Pos <= 0 {
+		validationErr.Pos = offset
+	}
+	return err
+}
+
+// checkStreamPatterns runs the dangerous pattern checks on a chunk of the
+// document, reporting positions relative to the whole document
+func (v *SSMLValidator) checkStreamPatterns(chunk string, offset int) error {
+	err := v.checkDangerousPatterns(chunk)
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		validationErr.Pos += offset
+	}
+	return err
+}
+
+// formatStartTag renders a start element back to markup for error messages
+// and pattern checks
+func formatStartTag(element xml.StartElement) string {
+	var b strings.Builder
+	b.WriteString("<")
+	b.WriteString(element.Name.Local)
+	if attrs := formatAttrs(element.Attr); attrs != "" {
+		b.WriteString(" ")
+		b.WriteString(attrs)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// formatAttrs renders attributes as name="value" pairs
+func formatAttrs(attrs []xml.Attr) string {
+	parts := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		name := attr.Name.Local
+		if attr.Name.Space != "" {
+			name = attr.Name.Space + ":" + name
+		}
+		parts = append(parts, fmt.Sprintf("%s=%q", name, attr.Value))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSMLValidator_ValidateSSMLReader_Valid(t *testing.T) {
+	validator := NewSSMLValidator()
+
+	docs := []string{
+		"<speak>Hello World</speak>",
+		`<?xml version="1.0"?><speak><p><s>Hello</s></p></speak>`,
+		"<speak><prosody rate='slow' pitch='+2%'>Slow</prosody><break time='500ms'/></speak>",
+		"<speak><say-as interpret-as='cardinal'>42</say-as> &amp; more</speak>",
+		"<speak><!-- chapter one --><emphasis>Hi</emphasis></speak>",
+	}
+
+	for _, doc := range docs {
+		t.Run(doc, func(t *testing.T) {
+			assert.NoError(t, validator.ValidateSSMLReader(strings.NewReader(doc)))
+		})
+	}
+}
+
+func TestSSMLValidator_ValidateSSMLReader_Invalid(t *testing.T) {
+	validator := NewSSMLValidator()
+
+	tests := []struct {
+		name     string
+		doc      string
+		wantType string
+		wantPos  int
+	}{
+		{"disallowed tag", "<speak><script>x</script></speak>", "security", 7},
+		{"unknown tag", "<speak><custom>x</custom></speak>", "tag", 7},
+		{"mismatched tag", "<speak><p>Hello</s></speak>", "structure", 0},
+		{"unclosed tag", "<speak><p>Hello</p>", "structure", 0},
+		{"invalid break time", "<speak><break time='11s'/></speak>", "attribute", 7},
+		{"missing interpret-as", "<speak><say-as>42</say-as></speak>", "attribute", 7},
+		{"audio tag", "<speak><audio src='a.mp3'/></speak>", "security", 7},
+		{"dangerous text", "<speak>visit javascript:alert(1)</speak>", "security", 13},
+		{"doctype", "<!DOCTYPE speak [<!ENTITY x 'y'>]><speak>&x;</speak>", "security", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateSSMLReader(strings.NewReader(tt.doc))
+			require.Error(t, err)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantType, validationErr.Type)
+			if tt.wantPos > 0 {
+				assert.Equal(t, tt.wantPos, validationErr.Pos)
+			}
+		})
+	}
+}
+
+func TestSSMLValidator_ValidateSSMLReader_Limits(t *testing.T) {
+	validator := NewSSMLValidator()
+	validator.SetMaxNestingDepth(3)
+	validator.SetMaxDocumentSize(1024)
+
+	t.Run("depth", func(t *testing.T) {
+		err := validator.ValidateSSMLReader(strings.NewReader("<speak>" + nestedSSML(3) + "</speak>"))
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "limit", validationErr.Type)
+		assert.Equal(t, len("<speak><p><p>"), validationErr.Pos)
+	})
+
+	t.Run("exact size is accepted", func(t *testing.T) {
+		doc := "<speak>" + strings.Repeat("a", 1024-len("<speak></speak>")) + "</speak>"
+		require.Len(t, doc, 1024)
+		assert.NoError(t, validator.ValidateSSMLReader(strings.NewReader(doc)))
+	})
+
+	t.Run("oversized document", func(t *testing.T) {
+		doc := "<speak>" + strings.Repeat("a", 2048) + "</speak>"
+		err := validator.ValidateSSMLReader(strings.NewReader(doc))
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "limit", validationErr.Type)
+		assert.Contains(t, validationErr.Message, "1024 bytes")
+	})
+}
+
+func TestSSMLValidator_ValidateSSMLReader_LargeDocument(t *testing.T) {
+	validator := NewSSMLValidator()
+	validator.SetMaxDocumentSize(8 << 20)
+
+	// Stream a multi-megabyte document without materializing it as a string
+	paragraph := "<p><s>It was a bright cold day in April.</s><break time='250ms'/></p>"
+	count := (2 << 20) / len(paragraph)
+	reader := io.MultiReader(
+		strings.NewReader("<speak>"),
+		&repeatReader{chunk: paragraph, count: count},
+		strings.NewReader("</speak>"),
+	)
+
+	assert.NoError(t, validator.ValidateSSMLReader(reader))
+}
+
+func TestSSMLValidator_ValidateSSMLReader_ReadError(t *testing.T) {
+	validator := NewSSMLValidator()
+
+	err := validator.ValidateSSMLReader(io.MultiReader(strings.NewReader("<speak>"), &errorReader{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read SSML")
+}
+
+func TestInputProcessor_ReadValidatedText(t *testing.T) {
+	validator := NewSSMLValidator()
+	validator.SetMaxDocumentSize(1024)
+
+	text, err := NewInputProcessor(strings.NewReader("  <speak>Hello <break time='1s'/>there</speak>\n")).
+		ReadValidatedText(validator)
+	require.NoError(t, err)
+	assert.Equal(t, "  <speak>Hello <break time='1s'/>there</speak>", text)
+
+	// Plain text is read like ReadText
+	text, err = NewInputProcessor(strings.NewReader("Hello <there>")).ReadValidatedText(validator)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello <there>", text)
+
+	_, err = NewInputProcessor(strings.NewReader("<speak><audio src='x'/></speak>")).ReadValidatedText(validator)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "security", validationErr.Type)
+
+	// An oversized document is refused once the limit is read, not after
+	// the whole input
+	counter := &countingReader{reader: io.MultiReader(strings.NewReader("<speak>"),
+		&repeatReader{chunk: "<p>A sentence.</p>", count: 1 << 20})}
+	_, err = NewInputProcessorWithLimit(counter, 100<<20).ReadValidatedText(validator)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "limit", validationErr.Type)
+	assert.Less(t, counter.read, 4*BufferSize)
+
+	// A document after more whitespace than is peeked is still validated
+	_, err = NewInputProcessor(strings.NewReader(strings.Repeat(" ", BufferSize+10) + "<speak><audio/></speak>")).
+		ReadValidatedText(NewSSMLValidator())
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "security", validationErr.Type)
+}
+
+// countingReader counts the bytes read from reader
+type countingReader struct {
+	reader io.Reader
+	read   int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+// repeatReader yields chunk count times
+type repeatReader struct {
+	chunk   string
+	count   int
+	pending string
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.pending == "" {
+		if r.count == 0 {
+			return 0, io.EOF
+		}
+		r.count--
+		r.pending = r.chunk
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// errorReader always fails
+type errorReader struct{}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
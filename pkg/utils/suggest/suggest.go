@@ -0,0 +1,85 @@
+// Package suggest provides fuzzy matching for "did you mean" suggestions.
+// It ranks candidates by case-insensitive edit distance to a mistyped value.
+package suggest
+
+import (
+	"sort"
+	"strings"
+)
+
+// Distance returns the Levenshtein edit distance between a and b
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// MaxDistance returns the largest edit distance at which a candidate is still
+// considered a plausible typo of target: roughly a third of its length, at least 2
+func MaxDistance(target string) int {
+	return max(2, len([]rune(target))/3)
+}
+
+// Rank returns the candidates within MaxDistance of target, closest first.
+// Comparison is case-insensitive; ties keep the order of candidates.
+func Rank(target string, candidates []string) []string {
+	type match struct {
+		value    string
+		distance int
+	}
+
+	limit := MaxDistance(target)
+	lower := strings.ToLower(target)
+
+	var matches []match
+	for _, candidate := range candidates {
+		d := Distance(lower, strings.ToLower(candidate))
+		if d <= limit {
+			matches = append(matches, match{value: candidate, distance: d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	ranked := make([]string, len(matches))
+	for i, m := range matches {
+		ranked[i] = m.value
+	}
+	return ranked
+}
+
+// Closest returns the best match for target among candidates, or false when
+// none is close enough to be a useful suggestion
+func Closest(target string, candidates []string) (string, bool) {
+	ranked := Rank(target, candidates)
+	if len(ranked) == 0 {
+		return "", false
+	}
+	return ranked[0], true
+}
@@ -0,0 +1,59 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"en-US-Wavenet-D", "en-US-Wavenet-D", 0},
+		{"en-US-Wavnet-D", "en-US-Wavenet-D", 1},
+		{"flaw", "lawn", 2},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, Distance(tt.a, tt.b))
+			assert.Equal(t, tt.want, Distance(tt.b, tt.a))
+		})
+	}
+}
+
+func TestMaxDistance(t *testing.T) {
+	assert.Equal(t, 2, MaxDistance("abc"))
+	assert.Equal(t, 5, MaxDistance("en-US-Wavenet-D"))
+}
+
+func TestRank(t *testing.T) {
+	candidates := []string{"en-US-Wavenet-A", "en-US-Wavenet-D", "en-GB-Wavenet-D", "de-DE-Standard-A"}
+
+	ranked := Rank("en-US-Wavnet-D", candidates)
+	assert.Equal(t, []string{"en-US-Wavenet-D", "en-US-Wavenet-A", "en-GB-Wavenet-D"}, ranked)
+
+	assert.Equal(t, []string{"en-US-Wavenet-D", "en-US-Wavenet-A", "en-GB-Wavenet-D"},
+		Rank("EN-US-WAVENET-D", candidates))
+
+	assert.Empty(t, Rank("completely-different", candidates))
+	assert.Empty(t, Rank("en-US-Wavenet-D", nil))
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"en-US-Wavenet-D", "en-US-Neural2-C"}
+
+	match, ok := Closest("en-US-Wavenet-E", candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "en-US-Wavenet-D", match)
+
+	_, ok = Closest("xyz", candidates)
+	assert.False(t, ok)
+}
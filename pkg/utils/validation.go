@@ -3,15 +3,30 @@ package utils
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// DefaultMaxBreakTime is the longest <break> duration accepted by default.
+// Google Cloud Text-to-Speech does not honor breaks longer than 10 seconds.
+const DefaultMaxBreakTime = 10 * time.Second
+
+// breakTimeRegex matches SSML break durations such as "500ms" or "2.5s"
+var breakTimeRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(s|ms)$`)
+
 // SSMLValidator handles SSML validation and security checks
 type SSMLValidator struct {
 	// Allow basic SSML tags by default
 	allowedTags map[string]bool
 	// Patterns for detecting potentially malicious content
 	dangerousPatterns []*regexp.Regexp
+	// Maximum accepted <break> duration
+	maxBreakTime time.Duration
+	// Maximum element nesting depth
+	maxNestingDepth int
+	// Maximum document size in bytes
+	maxDocumentSize int
 }
 
 // ValidationError represents validation-related errors
@@ -34,6 +49,9 @@ func NewSSMLValidator() *SSMLValidator {
 	validator := &SSMLValidator{
 		allowedTags:       make(map[string]bool),
 		dangerousPatterns: make([]*regexp.Regexp, 0),
+		maxBreakTime:      DefaultMaxBreakTime,
+		maxNestingDepth:   DefaultMaxNestingDepth,
+		maxDocumentSize:   DefaultMaxDocumentSize,
 	}
 
 	// Initialize with safe SSML tags
@@ -43,6 +61,21 @@ func NewSSMLValidator() *SSMLValidator {
 	return validator
 }
 
+// SetMaxBreakTime sets the longest accepted <break> duration. Values that are
+// not positive or exceed DefaultMaxBreakTime are clamped to DefaultMaxBreakTime,
+// since the API rejects or truncates longer breaks.
+func (v *SSMLValidator) SetMaxBreakTime(d time.Duration) {
+	if d <= 0 || d > DefaultMaxBreakTime {
+		d = DefaultMaxBreakTime
+	}
+	v.maxBreakTime = d
+}
+
+// MaxBreakTime returns the longest accepted <break> duration
+func (v *SSMLValidator) MaxBreakTime() time.Duration {
+	return v.maxBreakTime
+}
+
 // initializeAllowedTags sets up the list of allowed SSML tags
 func (v *SSMLValidator) initializeAllowedTags() {
 	// Google Cloud TTS supported SSML tags (safe subset)
@@ -97,9 +130,6 @@ func (v *SSMLValidator) initializeDangerousPatterns() {
 		`(?i)<!ENTITY`,
 		`(?i)<!DOCTYPE.*ENTITY`,
 		`(?i)&[a-zA-Z][a-zA-Z0-9]*;.*SYSTEM`,
-
-		// Excessive nesting (potential DoS)
-		`(<[^>]+>){50,}`, // More than 50 nested tags
 	}
 
 	for _, pattern := range dangerousRegexps {
@@ -122,7 +152,12 @@ func (v *SSMLValidator) ValidateSSML(text string) error {
 		return nil
 	}
 
-	// Check for dangerous patterns first
+	// Enforce size and nesting limits before any pattern matching
+	if err := v.checkLimits(text); err != nil {
+		return err
+	}
+
+	// Check for dangerous patterns
 	if err := v.checkDangerousPatterns(text); err != nil {
 		return err
 	}
@@ -166,9 +201,10 @@ func (v *SSMLValidator) validateSSMLStructure(text string) error {
 	tagStack := make([]string, 0)
 	tagRegex := regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^/>]*(/?)>`)
 
-	matches := tagRegex.FindAllStringSubmatch(text, -1)
+	matches := tagRegex.FindAllStringSubmatchIndex(text, -1)
 
-	for _, match := range matches {
+	for _, loc := range matches {
+		match := submatches(text, loc)
 		isClosing := match[1] == "/"
 		tagName := match[2]
 		isSelfClosing := match[3] == "/"
@@ -185,6 +221,7 @@ func (v *SSMLValidator) validateSSMLStructure(text string) error {
 					Type:    "structure",
 					Message: fmt.Sprintf("unexpected closing tag: %s", tagName),
 					Input:   match[0],
+					Pos:     loc[0],
 				}
 			}
 
@@ -194,6 +231,7 @@ func (v *SSMLValidator) validateSSMLStructure(text string) error {
 					Type:    "structure",
 					Message: fmt.Sprintf("mismatched tag: expected %s, got %s", tagStack[len(tagStack)-1], tagName),
 					Input:   match[0],
+					Pos:     loc[0],
 				}
 			}
 
@@ -202,6 +240,9 @@ func (v *SSMLValidator) validateSSMLStructure(text string) error {
 		} else {
 			// Opening tag
 			tagStack = append(tagStack, tagName)
+			if len(tagStack) > v.maxNestingDepth {
+				return v.nestingDepthError(loc[0], text)
+			}
 		}
 	}
 
@@ -216,6 +257,17 @@ func (v *SSMLValidator) validateSSMLStructure(text string) error {
 	return nil
 }
 
+// submatches extracts the submatch strings for a match located by FindAllStringSubmatchIndex
+func submatches(text string, loc []int) []string {
+	match := make([]string, len(loc)/2)
+	for i := range match {
+		if loc[2*i] >= 0 {
+			match[i] = text[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return match
+}
+
 // validateAllowedTags checks if all tags are in the allowed list
 func (v *SSMLValidator) validateAllowedTags(text string) error {
 	tagRegex := regexp.MustCompile(`<(?:/?([a-zA-Z][a-zA-Z0-9-]*)[^>]*)/?>`)
@@ -350,6 +402,16 @@ func (v *SSMLValidator) validateSayAsAttributes(text string) error {
 	sayAsRegex := regexp.MustCompile(`<say-as(\s+[^>]+)?>`)
 	matches := sayAsRegex.FindAllStringSubmatch(text, -1)
 
+	for _, match := range matches {
+		if err := v.validateSingleSayAsTag(match); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *SSMLValidator) validateSingleSayAsTag(match []string) error {
 	validInterpretAs := map[string]bool{
 		"characters": true,
 		"spell-out":  true,
@@ -359,6 +421,7 @@ func (v *SSMLValidator) validateSayAsAttributes(text string) error {
 		"digits":     true,
 		"fraction":   true,
 		"unit":       true,
+		"currency":   true,
 		"date":       true,
 		"time":       true,
 		"telephone":  true,
@@ -367,28 +430,26 @@ func (v *SSMLValidator) validateSayAsAttributes(text string) error {
 		"bleep":      true,
 	}
 
-	for _, match := range matches {
-		attrs := match[1] // This will be empty string if no attributes
+	attrs := match[1] // This will be empty string if no attributes
 
-		// interpret-as is required for say-as
-		interpretRegex := regexp.MustCompile(`interpret-as=["']?([^"'\s>]+)["']?`)
-		interpretMatch := interpretRegex.FindStringSubmatch(attrs)
+	// interpret-as is required for say-as
+	interpretRegex := regexp.MustCompile(`interpret-as=["']?([^"'\s>]+)["']?`)
+	interpretMatch := interpretRegex.FindStringSubmatch(attrs)
 
-		if interpretMatch == nil {
-			return &ValidationError{
-				Type:    "attribute",
-				Message: "say-as tag missing required interpret-as attribute",
-				Input:   match[0],
-			}
+	if interpretMatch == nil {
+		return &ValidationError{
+			Type:    "attribute",
+			Message: "say-as tag missing required interpret-as attribute",
+			Input:   match[0],
 		}
+	}
 
-		interpretAs := interpretMatch[1]
-		if !validInterpretAs[interpretAs] {
-			return &ValidationError{
-				Type:    "attribute",
-				Message: fmt.Sprintf("invalid interpret-as value: %s", interpretAs),
-				Input:   match[0],
-			}
+	interpretAs := interpretMatch[1]
+	if !validInterpretAs[interpretAs] {
+		return &ValidationError{
+			Type:    "attribute",
+			Message: fmt.Sprintf("invalid interpret-as value: %s", interpretAs),
+			Input:   match[0],
 		}
 	}
 
@@ -430,7 +491,7 @@ func (v *SSMLValidator) validateBreakTime(attrs, tag string) error {
 		if !v.isValidBreakTime(timeValue) {
 			return &ValidationError{
 				Type:    "attribute",
-				Message: fmt.Sprintf("invalid break time: %s", timeValue),
+				Message: fmt.Sprintf("invalid break time: %s (maximum %s)", timeValue, v.maxBreakTime),
 				Input:   tag,
 			}
 		}
@@ -541,80 +602,96 @@ func (v *SSMLValidator) isValidProsodyVolume(volume string) bool {
 }
 
 func (v *SSMLValidator) isValidBreakTime(timeValue string) bool {
-	// Validate break time values
-	// Format: number followed by 's' (seconds) or 'ms' (milliseconds)
-	timeRegex := regexp.MustCompile(`^\d+(?:\.\d+)?(?:s|ms)$`)
-	if !timeRegex.MatchString(timeValue) {
+	duration, err := ParseBreakTime(timeValue)
+	if err != nil {
 		return false
 	}
+	return duration <= v.maxBreakTime
+}
 
-	// Additional validation: reasonable time limits (max 10 seconds)
-	if strings.HasSuffix(timeValue, "ms") {
-		// Milliseconds - max 10000ms (10s)
-		msRegex := regexp.MustCompile(`^(\d+(?:\.\d+)?)ms$`)
-		matches := msRegex.FindStringSubmatch(timeValue)
-		if len(matches) > 1 {
-			// Simple check - if the number part is more than 5 digits before decimal, it's too long
-			numberPart := matches[1]
-			beforeDecimal := strings.Split(numberPart, ".")[0]
-			if len(beforeDecimal) > 5 {
-				return false
-			}
-		}
-	} else {
-		// Seconds - max 10s
-		sRegex := regexp.MustCompile(`^(\d+(?:\.\d+)?)s$`)
-		matches := sRegex.FindStringSubmatch(timeValue)
-		if len(matches) > 1 {
-			numberPart := matches[1]
-			beforeDecimal := strings.Split(numberPart, ".")[0]
-
-			// Check if it's more than 10 seconds
-			if len(beforeDecimal) > 2 {
-				return false // More than 99 seconds
-			}
-			if len(beforeDecimal) == 2 {
-				// Check if it's 10 or less (first digit 1 and second digit 0, or first digit < 1)
-				if beforeDecimal[0] > '1' || (beforeDecimal[0] == '1' && beforeDecimal[1] > '0') {
-					return false
-				}
-			}
-		}
+// ParseBreakTime parses an SSML break time value ("500ms", "2.5s") into a duration
+func ParseBreakTime(timeValue string) (time.Duration, error) {
+	matches := breakTimeRegex.FindStringSubmatch(timeValue)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid break time format: %s (expected e.g. 500ms or 2.5s)", timeValue)
+	}
+
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid break time value: %s", timeValue)
 	}
 
-	return true
+	unit := time.Second
+	if matches[2] == "ms" {
+		unit = time.Millisecond
+	}
+
+	// Guard against overflow for absurdly large values
+	if value > float64(time.Hour/unit) {
+		return 0, fmt.Errorf("break time out of range: %s", timeValue)
+	}
+
+	return time.Duration(value * float64(unit)), nil
 }
 
-// SanitizeText removes potentially dangerous content while preserving safe SSML
+// Markup removed by SanitizeText: script elements with their content, and
+// any other tag
+var (
+	sanitizeScriptRegex = regexp.MustCompile(`(?i)<script[^>]*>.*?</script>`)
+	sanitizeTagRegex    = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*(/?)>`)
+)
+
+// SanitizeText removes potentially dangerous content while preserving safe SSML.
+// Removal repeats until nothing more is found, so that removing one pattern
+// cannot join the text around it into another, as in "javajavascript:script:".
 func (v *SSMLValidator) SanitizeText(text string) string {
 	if !v.IsSSML(text) {
 		// Not SSML, just clean up basic issues
 		return strings.TrimSpace(text)
 	}
 
+	sanitized := strings.TrimSpace(text)
+	for {
+		next := strings.TrimSpace(v.sanitizeOnce(sanitized))
+		if next == sanitized {
+			return sanitized
+		}
+		sanitized = next
+	}
+}
+
+// sanitizeOnce makes one pass of SanitizeText over text
+func (v *SSMLValidator) sanitizeOnce(text string) string {
 	// Remove dangerous patterns
-	sanitized := text
 	for _, pattern := range v.dangerousPatterns {
-		sanitized = pattern.ReplaceAllString(sanitized, "")
+		text = pattern.ReplaceAllString(text, "")
 	}
 
 	// Remove disallowed tags and their content
 	// First handle script tags specifically (remove content too)
-	scriptRegex := regexp.MustCompile(`(?i)<script[^>]*>.*?</script>`)
-	sanitized = scriptRegex.ReplaceAllString(sanitized, "")
+	text = sanitizeScriptRegex.ReplaceAllString(text, "")
 
 	// Then remove other disallowed tags
-	tagRegex := regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*(/?)>`)
-	sanitized = tagRegex.ReplaceAllStringFunc(sanitized, func(match string) string {
-		submatch := tagRegex.FindStringSubmatch(match)
-		if len(submatch) >= 3 {
-			tagName := strings.ToLower(submatch[2])
-			if v.allowedTags[tagName] {
-				return match // Keep allowed tags
-			}
+	return sanitizeTagRegex.ReplaceAllStringFunc(text, func(match string) string {
+		submatch := sanitizeTagRegex.FindStringSubmatch(match)
+		if len(submatch) >= 3 && v.isAllowedTag(submatch[2]) {
+			return match // Keep allowed tags
 		}
 		return "" // Remove disallowed tags
 	})
+}
+
+// isAllowedTag reports whether the tag name is in the allowed SSML subset
+func (v *SSMLValidator) isAllowedTag(name string) bool {
+	return v.allowedTags[strings.ToLower(name)]
+}
 
-	return strings.TrimSpace(sanitized)
+// dangerousMatch returns the first dangerous pattern found in text, or ""
+func (v *SSMLValidator) dangerousMatch(text string) string {
+	for _, pattern := range v.dangerousPatterns {
+		if match := pattern.FindString(text); match != "" {
+			return match
+		}
+	}
+	return ""
 }
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+// ssmlFuzzSeeds are valid, invalid, and hostile documents the SSML fuzz
+// targets start from; more live in testdata/fuzz
+var ssmlFuzzSeeds = []string{
+	"Hello World",
+	"<speak>Hello</speak>",
+	`<speak><prosody rate="slow" pitch="+2st" volume="loud">Hi</prosody></speak>`,
+	`<speak><say-as interpret-as="date" format="mdy">1/2/2025</say-as><break time="500ms"/></speak>`,
+	`<speak><audio src="gs://bucket/a.mp3">alt</audio><mark name="m1"/></speak>`,
+	"<speak><script>evil</script>Hello</speak>",
+	"<speak><p><s>unclosed</p></speak>",
+	`<!DOCTYPE speak [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><speak>&xxe;</speak>`,
+	"<speak>javajavascript:script:alert(1)</speak>",
+	"<scr<script>ipt>alert(1)</script>",
+	"<speak><break time=\"99999999999999999999s\"/></speak>",
+	"<<<>>>",
+}
+
+func FuzzValidateSSML(f *testing.F) {
+	for _, seed := range ssmlFuzzSeeds {
+		f.Add(seed)
+	}
+
+	validator := NewSSMLValidator()
+	f.Fuzz(func(t *testing.T, text string) {
+		err := validator.ValidateSSML(text)
+		if err == nil {
+			if match := validator.dangerousMatch(text); match != "" && validator.IsSSML(text) {
+				t.Fatalf("ValidateSSML accepted %q containing %q", text, match)
+			}
+			return
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("ValidateSSML(%q) returned %T, want *ValidationError", text, err)
+		}
+	})
+}
+
+func FuzzSanitizeText(f *testing.F) {
+	for _, seed := range ssmlFuzzSeeds {
+		f.Add(seed)
+	}
+
+	validator := NewSSMLValidator()
+	f.Fuzz(func(t *testing.T, text string) {
+		sanitized := validator.SanitizeText(text)
+		if len(sanitized) > len(text) {
+			t.Fatalf("SanitizeText(%q) grew the text to %q", text, sanitized)
+		}
+		if match := validator.dangerousMatch(sanitized); match != "" && validator.IsSSML(text) {
+			t.Fatalf("SanitizeText(%q) = %q still contains %q", text, sanitized, match)
+		}
+		for _, tag := range sanitizeTagRegex.FindAllStringSubmatch(sanitized, -1) {
+			if !validator.isAllowedTag(tag[2]) {
+				t.Fatalf("SanitizeText(%q) = %q kept the tag %q", text, sanitized, tag[0])
+			}
+		}
+		if again := validator.SanitizeText(sanitized); again != sanitized {
+			t.Fatalf("SanitizeText is not idempotent: %q became %q", sanitized, again)
+		}
+	})
+}
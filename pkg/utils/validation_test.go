@@ -2,6 +2,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -362,6 +363,75 @@ func TestSSMLValidator_isValidBreakTime(t *testing.T) {
 	}
 }
 
+func TestSSMLValidator_isValidBreakTimeBoundaries(t *testing.T) {
+	validator := NewSSMLValidator()
+
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"9.99s", true},
+		{"10.0s", true},
+		{"10.5s", false},
+		{"10000ms", true},
+		{"10001ms", false},
+		{"9999.5ms", true},
+		{"0.001s", true},
+		{"00010s", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.valid, validator.isValidBreakTime(tt.value))
+		})
+	}
+}
+
+func TestSSMLValidator_SetMaxBreakTime(t *testing.T) {
+	validator := NewSSMLValidator()
+	assert.Equal(t, DefaultMaxBreakTime, validator.MaxBreakTime())
+
+	validator.SetMaxBreakTime(2 * time.Second)
+	assert.Equal(t, 2*time.Second, validator.MaxBreakTime())
+	assert.True(t, validator.isValidBreakTime("2s"))
+	assert.False(t, validator.isValidBreakTime("2.5s"))
+	assert.Error(t, validator.ValidateSSML("<speak>Hi <break time='3s'/></speak>"))
+
+	// Values outside the API limit fall back to the default cap
+	validator.SetMaxBreakTime(time.Minute)
+	assert.Equal(t, DefaultMaxBreakTime, validator.MaxBreakTime())
+
+	validator.SetMaxBreakTime(0)
+	assert.Equal(t, DefaultMaxBreakTime, validator.MaxBreakTime())
+}
+
+func TestParseBreakTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"500ms", 500 * time.Millisecond, false},
+		{"2.5s", 2500 * time.Millisecond, false},
+		{"1.5ms", 1500 * time.Microsecond, false},
+		{"1sec", 0, true},
+		{"-1s", 0, true},
+		{"99999999999999999999s", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseBreakTime(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestSSMLValidator_SanitizeText(t *testing.T) {
 	validator := NewSSMLValidator()
 
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// voiceDirective matches an "@voice: NAME" line, which switches the voice
+// of the lines after it
+var voiceDirective = regexp.MustCompile(`(?m)^[ \t]*@voice:[ \t]*(\S*)[ \t]*\r?$`)
+
+// voiceSpan matches a "[[voice=NAME]]text[[/voice]]" span
+var voiceSpan = regexp.MustCompile(`(?s)\[\[voice=([^\]\s]*)\]\](.*?)\[\[/voice\]\]`)
+
+// VoiceSegment is a run of text read by one voice
+type VoiceSegment struct {
+	Text string
+	// Voice is a voice name, or empty for the configured voice
+	Voice string
+}
+
+// HasVoiceMarkup reports whether text switches voices with "@voice:" lines
+// or "[[voice=...]]" spans
+func HasVoiceMarkup(text string) bool {
+	return !strings.HasPrefix(strings.TrimSpace(text), "<speak") &&
+		(voiceDirective.MatchString(text) || voiceSpan.MatchString(text))
+}
+
+// ParseVoiceMarkup splits text into the runs read by different voices. A
+// line "@voice: en-GB-News-K" switches the voice of the lines after it, up
+// to the next such line; "@voice: default" switches back to the configured
+// voice. A span "[[voice=en-GB-News-K]]text[[/voice]]" switches the voice of
+// its text only. Segments are trimmed of surrounding whitespace, and empty
+// ones are dropped. Text without markup, and SSML documents, make up a
+// single segment.
+func ParseVoiceMarkup(text string) []VoiceSegment {
+	if !HasVoiceMarkup(text) {
+		return []VoiceSegment{{Text: text}}
+	}
+
+	var segments []VoiceSegment
+	add := func(text, voice string) {
+		if text = strings.TrimSpace(text); text == "" {
+			return
+		}
+		if last := len(segments) - 1; last >= 0 && segments[last].Voice == voice {
+			segments[last].Text += "\n" + text
+			return
+		}
+		segments = append(segments, VoiceSegment{Text: text, Voice: voice})
+	}
+	addBlock := func(block, voice string) {
+		last := 0
+		for _, m := range voiceSpan.FindAllStringSubmatchIndex(block, -1) {
+			add(block[last:m[0]], voice)
+			add(block[m[4]:m[5]], markupVoice(block[m[2]:m[3]]))
+			last = m[1]
+		}
+		add(block[last:], voice)
+	}
+
+	voice, last := "", 0
+	for _, m := range voiceDirective.FindAllStringSubmatchIndex(text, -1) {
+		addBlock(text[last:m[0]], voice)
+		voice = markupVoice(text[m[2]:m[3]])
+		last = m[1]
+	}
+	addBlock(text[last:], voice)
+	return segments
+}
+
+// markupVoice returns the voice named in markup, empty for the configured
+// voice
+func markupVoice(name string) string {
+	if strings.EqualFold(name, "default") {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVoiceMarkup(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []VoiceSegment
+	}{
+		{
+			"no markup",
+			"  Just one voice.\n",
+			[]VoiceSegment{{Text: "  Just one voice.\n"}},
+		},
+		{
+			"directives",
+			"Narrator intro.\n@voice: en-GB-News-K\nGood evening.\nThe news.\n@voice: default\nBack to me.",
+			[]VoiceSegment{
+				{Text: "Narrator intro."},
+				{Text: "Good evening.\nThe news.", Voice: "en-GB-News-K"},
+				{Text: "Back to me."},
+			},
+		},
+		{
+			"spans",
+			"She said [[voice=en-US-Wavenet-F]]hello there[[/voice]] and left.",
+			[]VoiceSegment{
+				{Text: "She said"},
+				{Text: "hello there", Voice: "en-US-Wavenet-F"},
+				{Text: "and left."},
+			},
+		},
+		{
+			"spans inside a directive",
+			"@voice: en-GB-News-K\nHe asked [[voice=default]]why?[[/voice]]\n@voice: en-GB-News-K\nNo reason.",
+			[]VoiceSegment{
+				{Text: "He asked", Voice: "en-GB-News-K"},
+				{Text: "why?"},
+				{Text: "No reason.", Voice: "en-GB-News-K"},
+			},
+		},
+		{
+			"SSML is left alone",
+			"<speak>[[voice=en-GB-News-K]]Hi[[/voice]]</speak>",
+			[]VoiceSegment{{Text: "<speak>[[voice=en-GB-News-K]]Hi[[/voice]]</speak>"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseVoiceMarkup(tc.input))
+		})
+	}
+}
+
+func TestHasVoiceMarkup(t *testing.T) {
+	assert.True(t, HasVoiceMarkup("@voice: en-GB-News-K\nHi"))
+	assert.True(t, HasVoiceMarkup("Say [[voice=x]]hi[[/voice]]"))
+	assert.False(t, HasVoiceMarkup("Email me @voice: not at line start"))
+	assert.False(t, HasVoiceMarkup("[[voice=x]] unclosed"))
+}
@@ -199,6 +199,71 @@ func TestCLISynthesizeNoAuth(t *testing.T) {
 	})
 }
 
+// TestCLIReplay runs synthesize and voices against API responses recorded
+// in testdata/tts-cassette.json, so it needs no credentials or network.
+// Re-record the cassette with real credentials and
+// ASSISTANT_CLI_TTS_RECORDING=record after changing what the CLI requests.
+func TestCLIReplay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	binary := buildTestBinary(t)
+	defer os.Remove(binary)
+
+	cassette, err := filepath.Abs(filepath.Join("testdata", "tts-cassette.json"))
+	require.NoError(t, err)
+	workDir := t.TempDir()
+	env := append(os.Environ(),
+		"HOME="+t.TempDir(),
+		"ASSISTANT_CLI_API_KEY=",
+		"GOOGLE_APPLICATION_CREDENTIALS=",
+		"ASSISTANT_CLI_TTS_RECORDING=replay",
+		"ASSISTANT_CLI_TTS_CASSETTE="+cassette,
+	)
+
+	t.Run("synthesize", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, binary, "synthesize", "--output", "hello.wav",
+			"--format", "LINEAR16", "--voice", "en-US-Wavenet-D")
+		cmd.Dir = workDir
+		cmd.Env = env
+		cmd.Stdin = strings.NewReader("Hello, world!")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+
+		audio, err := os.ReadFile(filepath.Join(workDir, "hello.wav"))
+		require.NoError(t, err)
+		assert.Equal(t, "RIFF", string(audio[:4]))
+	})
+
+	t.Run("voices", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, binary, "voices", "--language", "en-US")
+		cmd.Dir = workDir
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+		assert.Contains(t, string(output), "en-US-Neural2-F")
+	})
+
+	t.Run("unrecorded request", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, binary, "voices", "--language", "de-DE")
+		cmd.Dir = workDir
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		assert.Error(t, err)
+		assert.Contains(t, string(output), "no recorded response")
+	})
+}
+
 func TestCLILoginNoCredentials(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")